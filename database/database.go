@@ -1,33 +1,184 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
 )
 
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// MigrationsDir is where migrations live in the repository, relative to its
+// root
+const MigrationsDir = "database/migrations"
+
+// The body of new migrations, as goose writes them
+const migrationTemplate = `-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+`
+
+var nonWordCharacters = regexp.MustCompile(`[^a-z0-9]+`)
+
 // Runs the goose migrator effectively moving the database from one
 // version to the next incase not already migrated
 // note that the function may panic in the event of an error.
-func RunGooseMigrations(logger *slog.Logger, pool *pgxpool.Pool) {
-
-	if err := goose.SetDialect(string(goose.DialectPostgres)); err != nil {
+func RunGooseMigrations(ctx context.Context, logger *slog.Logger, pool *pgxpool.Pool) {
+	results, err := MigrateUp(ctx, pool)
+	if err != nil {
 		panic(err)
 	}
 
-	goose.SetBaseFS(migrationsFS)
+	for _, result := range results {
+		logger.Info("Applied migration",
+			slog.String("migration", filepath.Base(result.Source.Path)),
+			slog.Duration("duration", result.Duration),
+		)
+	}
+	logger.Info("Migrations ran and were completed successfully")
+}
 
-	db := stdlib.OpenDBFromPool(pool)
+// newMigrationProvider returns a goose provider of the migrations bundled
+// with the binary. A locked provider holds a PostgreSQL advisory lock while
+// it migrates, so that servers starting together or an operator running
+// migrate by hand wait for each other instead of racing to apply the same
+// migrations. The lock is given up after waiting for 5 minutes
+func newMigrationProvider(pool *pgxpool.Pool, locked bool) (*goose.Provider, error) {
+	migrations, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
 
-	if err := goose.Up(db, "migrations"); err != nil {
-		panic(err)
+	var options []goose.ProviderOption
+	if locked {
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, goose.WithSessionLocker(locker))
 	}
+	return goose.NewProvider(goose.DialectPostgres, stdlib.OpenDBFromPool(pool), migrations, options...)
+}
 
-	logger.Info("Migrations ran and were completed successfully")
+// MigrateUp applies every pending migration and returns those it applied.
+// Only one caller migrates the database at a time, others wait for it and
+// then find nothing left to apply
+func MigrateUp(ctx context.Context, pool *pgxpool.Pool) ([]*goose.MigrationResult, error) {
+	provider, err := newMigrationProvider(pool, true)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	return provider.Up(ctx)
+}
+
+// MigrateDown rolls the latest applied migration back
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool) (*goose.MigrationResult, error) {
+	provider, err := newMigrationProvider(pool, true)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	return provider.Down(ctx)
+}
+
+// MigrationStatus lists every migration bundled with the binary and whether
+// it was applied
+func MigrationStatus(ctx context.Context, pool *pgxpool.Pool) ([]*goose.MigrationStatus, error) {
+	provider, err := newMigrationProvider(pool, false)
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	return provider.Status(ctx)
+}
+
+// HasPendingMigrations reports whether migrations bundled with the binary
+// were not applied yet
+func HasPendingMigrations(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	provider, err := newMigrationProvider(pool, false)
+	if err != nil {
+		return false, err
+	}
+	defer provider.Close()
+
+	return provider.HasPending(ctx)
+}
+
+// Checksum fingerprints the migrations and the RBAC seed bundled with the
+// binary. It changes whenever either is added to or edited, so that databases
+// prepared ahead of time, such as those of the tests, can tell they are stale
+func Checksum() (string, error) {
+	names, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		data, err := migrationsFS.ReadFile(name)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s %d\n", name, len(data))
+		hash.Write(data)
+	}
+	hash.Write(defaultRBACSeed)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CreateMigration writes an empty migration named after name to dir and
+// returns its path. Its version is the current time, or follows the latest
+// migration in dir when that one is versioned later, since goose refuses to
+// apply migrations older than those already applied
+func CreateMigration(dir, name string) (string, error) {
+	name = strings.Trim(nonWordCharacters.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("the migration needs a name")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	version, _ := strconv.ParseInt(time.Now().UTC().Format("20060102150405"), 10, 64)
+	for _, entry := range entries {
+		if latest, err := goose.NumericComponent(entry.Name()); err == nil && latest >= version {
+			version = latest + 1
+		}
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%d_%s.sql", version, name))
+	if err := os.WriteFile(path, []byte(migrationTemplate), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
 }
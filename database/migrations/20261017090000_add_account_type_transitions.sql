@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS account_type_transitions (
+  id BIGSERIAL PRIMARY KEY,
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  from_type account_type NOT NULL,
+  to_type account_type NOT NULL,
+  reason TEXT NOT NULL,
+  performed_by UUID NOT NULL REFERENCES accounts(id),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT account_type_transitions_type_changed CHECK (from_type <> to_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_type_transitions_account
+ON account_type_transitions (account_id, created_at DESC);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('update:account:type', 'Permission to convert an account from one type to another.'),
+    ('read:account:type_transitions', 'Permission to view the type transition history of an account.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN (
+    'update:account:type',
+    'read:account:type_transitions'
+);
+
+DROP INDEX IF EXISTS idx_account_type_transitions_account;
+DROP TABLE IF EXISTS account_type_transitions;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Accounts whose emails only differ by case must be merged manually before
+-- this migration can be applied
+UPDATE accounts
+SET email = lower(trim(email))
+WHERE email <> lower(trim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_email_lower
+ON accounts (lower(email));
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_accounts_email_lower;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS account_tags (
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  tag VARCHAR(50) NOT NULL CHECK (tag = lower(tag) AND length(tag) > 0),
+  created_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_id, tag)
+);
+
+-- Lookups by tag are the common path when filtering account searches
+CREATE INDEX IF NOT EXISTS idx_account_tags_tag ON account_tags (tag);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:account_tag:any', 'Permission to view tags attached to any account.'),
+    ('create:account_tag:any', 'Permission to tag any account.'),
+    ('delete:account_tag:any', 'Permission to remove tags from any account.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN (
+    'read:account_tag:any',
+    'create:account_tag:any',
+    'delete:account_tag:any'
+);
+
+DROP INDEX IF EXISTS idx_account_tags_tag;
+DROP TABLE IF EXISTS account_tags;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:account_stats:any', 'Permission to view aggregated account statistics.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'read:account_stats:any';
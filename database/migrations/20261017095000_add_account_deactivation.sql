@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+ALTER TABLE accounts
+ADD COLUMN deactivated_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_deactivated
+ON accounts (id)
+WHERE deactivated_at IS NOT NULL;
+
+-- Deactivated accounts are hidden from the leaderboards
+CREATE OR REPLACE VIEW account_vibepoint_rank AS
+SELECT 
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (ORDER BY vibe_points DESC) AS vibe_rank
+  FROM accounts 
+WHERE accounts.type = 'human'
+  AND accounts.deactivated_at IS NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+CREATE OR REPLACE VIEW account_vibepoint_rank AS
+SELECT 
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (ORDER BY vibe_points DESC) AS vibe_rank
+  FROM accounts 
+WHERE accounts.type = 'human';
+
+DROP INDEX IF EXISTS idx_accounts_deactivated;
+
+ALTER TABLE accounts
+DROP COLUMN deactivated_at;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS account_guardians (
+  guardian_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  managed_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  relationship VARCHAR(50) NOT NULL DEFAULT 'guardian',
+  -- Actions the managed account is not allowed to perform on its own
+  restrictions TEXT[] NOT NULL DEFAULT '{}',
+  created_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (guardian_id, managed_id),
+  CONSTRAINT account_guardians_not_self CHECK (guardian_id <> managed_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_guardians_managed
+ON account_guardians (managed_id);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('create:guardian_link:any', 'Permission to link a guardian to a managed account.'),
+    ('read:guardian_link:any', 'Permission to view guardian links of any account.'),
+    ('delete:guardian_link:any', 'Permission to remove a guardian from a managed account.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN (
+    'create:guardian_link:any',
+    'read:guardian_link:any',
+    'delete:guardian_link:any'
+);
+
+DROP INDEX IF EXISTS idx_account_guardians_managed;
+DROP TABLE IF EXISTS account_guardians;
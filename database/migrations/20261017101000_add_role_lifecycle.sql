@@ -0,0 +1,82 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+ALTER TABLE roles ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- Permissions granted through a deactivated role no longer apply
+CREATE OR REPLACE VIEW user_permissions_view AS
+SELECT
+  a.id AS user_id,
+  r.id AS role_id,
+  r.name AS role_name,
+  p.id AS permission_id,
+  p.name AS permission
+FROM
+  accounts a
+JOIN
+  user_roles ur ON ur.user_id = a.id
+JOIN
+  roles r ON r.id = ur.role_id
+JOIN
+  role_permissions rp ON rp.role_id = r.id
+JOIN
+  permissions p ON p.id = rp.permission_id
+WHERE
+  r.is_active = TRUE;
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION assign_default_roles_to_account()
+RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO user_roles (user_id, role_id)
+  SELECT NEW.id, id FROM roles WHERE is_default = true AND is_active = true
+  ON CONFLICT DO NOTHING;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('delete:role:any', 'Permission to delete roles.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'delete:role:any';
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION assign_default_roles_to_account()
+RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO user_roles (user_id, role_id)
+  SELECT NEW.id, id FROM roles WHERE is_default = true
+  ON CONFLICT DO NOTHING;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE OR REPLACE VIEW user_permissions_view AS
+SELECT
+  a.id AS user_id,
+  r.id AS role_id,
+  r.name AS role_name,
+  p.id AS permission_id,
+  p.name AS permission
+FROM
+  accounts a
+JOIN
+  user_roles ur ON ur.user_id = a.id
+JOIN
+  roles r ON r.id = ur.role_id
+JOIN
+  role_permissions rp ON rp.role_id = r.id
+JOIN
+  permissions p ON p.id = rp.permission_id;
+
+ALTER TABLE roles DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Role assignments that only apply within a single institution. Global
+-- assignments keep living in user_roles
+CREATE TABLE IF NOT EXISTS institution_user_roles (
+  user_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, role_id, institution_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_institution_user_roles_institution
+ON institution_user_roles (institution_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_institution_user_roles_institution;
+DROP TABLE IF EXISTS institution_user_roles;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Attribute based policies that grant a permission to callers who do not hold
+-- it through their roles but satisfy every condition of the policy
+CREATE TABLE IF NOT EXISTS authorization_policies (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name VARCHAR(255) NOT NULL UNIQUE,
+  description TEXT,
+  permission VARCHAR(255) NOT NULL,
+  conditions JSONB NOT NULL DEFAULT '{}',
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_authorization_policies_permission
+ON authorization_policies (permission)
+WHERE is_active = TRUE;
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('create:policy:any', 'Permission to create authorization policies.'),
+    ('read:policy:any', 'Permission to view authorization policies.'),
+    ('update:policy:any', 'Permission to update authorization policies.'),
+    ('delete:policy:any', 'Permission to delete authorization policies.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN (
+    'create:policy:any',
+    'read:policy:any',
+    'update:policy:any',
+    'delete:policy:any'
+);
+
+DROP INDEX IF EXISTS idx_authorization_policies_permission;
+DROP TABLE IF EXISTS authorization_policies;
@@ -0,0 +1,105 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Roles instantiated from a template may be scoped to a single institution.
+-- Such roles can only be held through institution_user_roles
+ALTER TABLE roles
+ADD COLUMN institution_id INT REFERENCES institutions(institution_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_roles_institution ON roles (institution_id);
+
+-- Reusable sets of permissions that can be turned into roles per institution
+CREATE TABLE IF NOT EXISTS role_templates (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name VARCHAR(255) NOT NULL UNIQUE,
+  description TEXT,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS role_template_permissions (
+  template_id UUID NOT NULL REFERENCES role_templates(id) ON DELETE CASCADE,
+  permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (template_id, permission_id)
+);
+
+-- Institution scoped roles never grant global permissions
+CREATE OR REPLACE VIEW user_permissions_view AS
+SELECT
+  a.id AS user_id,
+  r.id AS role_id,
+  r.name AS role_name,
+  p.id AS permission_id,
+  p.name AS permission
+FROM
+  accounts a
+JOIN
+  user_roles ur ON ur.user_id = a.id
+JOIN
+  roles r ON r.id = ur.role_id
+JOIN
+  role_permissions rp ON rp.role_id = r.id
+JOIN
+  permissions p ON p.id = rp.permission_id
+WHERE
+  r.is_active = TRUE AND r.institution_id IS NULL;
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('create:role_template:any', 'Permission to create role templates.'),
+    ('read:role_template:any', 'Permission to read any role template.'),
+    ('update:role_template:any', 'Permission to update any role template.'),
+    ('delete:role_template:any', 'Permission to delete any role template.'),
+    ('instantiate:role_template:any', 'Permission to create roles from a role template.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_templates (name, description)
+VALUES
+    ('Institution Admin Pack', 'Administers a single institution and the roles held within it')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_template_permissions (template_id, permission_id)
+SELECT t.id, p.id
+FROM role_templates t, permissions p
+WHERE t.name = 'Institution Admin Pack'
+  AND p.name IN ('update:institutions:any', 'read:role:any', 'assign:role:any')
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN (
+    'create:role_template:any',
+    'read:role_template:any',
+    'update:role_template:any',
+    'delete:role_template:any',
+    'instantiate:role_template:any'
+);
+
+CREATE OR REPLACE VIEW user_permissions_view AS
+SELECT
+  a.id AS user_id,
+  r.id AS role_id,
+  r.name AS role_name,
+  p.id AS permission_id,
+  p.name AS permission
+FROM
+  accounts a
+JOIN
+  user_roles ur ON ur.user_id = a.id
+JOIN
+  roles r ON r.id = ur.role_id
+JOIN
+  role_permissions rp ON rp.role_id = r.id
+JOIN
+  permissions p ON p.id = rp.permission_id
+WHERE
+  r.is_active = TRUE;
+
+DROP TABLE IF EXISTS role_template_permissions;
+DROP TABLE IF EXISTS role_templates;
+DROP INDEX IF EXISTS idx_roles_institution;
+ALTER TABLE roles DROP COLUMN IF EXISTS institution_id;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Every change made to roles, permissions and their assignments. The actor
+-- is not a foreign key so that entries outlive the accounts they mention
+CREATE TABLE IF NOT EXISTS rbac_audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  action VARCHAR(100) NOT NULL,
+  actor_id UUID,
+  target_type VARCHAR(50) NOT NULL,
+  target_id VARCHAR(255) NOT NULL,
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rbac_audit_log_target
+ON rbac_audit_log (target_type, target_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_rbac_audit_log_actor
+ON rbac_audit_log (actor_id, created_at DESC);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:authz:audit', 'Permission to view the audit log of role and permission changes.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'read:authz:audit';
+
+DROP INDEX IF EXISTS idx_rbac_audit_log_actor;
+DROP INDEX IF EXISTS idx_rbac_audit_log_target;
+DROP TABLE IF EXISTS rbac_audit_log;
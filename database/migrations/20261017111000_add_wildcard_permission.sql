@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- The lone wildcard covers every permission including ones added later on.
+-- It is picked up by the system and Administrator roles through the
+-- existing permission triggers
+INSERT INTO permissions (name, description)
+VALUES
+    ('*', 'Grants every permission.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = '*';
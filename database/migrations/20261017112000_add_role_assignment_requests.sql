@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Sensitive roles are only assigned once a second person approves the
+-- assignment
+ALTER TABLE roles
+ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE roles
+SET requires_approval = TRUE
+WHERE name IN ('system', 'Administrator');
+
+CREATE TYPE role_assignment_request_status AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE IF NOT EXISTS role_assignment_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  requested_by UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  reason TEXT,
+  status role_assignment_request_status NOT NULL DEFAULT 'pending',
+  reviewed_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  review_note TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  reviewed_at TIMESTAMP
+);
+
+-- Only a single request may be pending for the same account and role
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_assignment_requests_pending
+ON role_assignment_requests (user_id, role_id)
+WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_role_assignment_requests_status
+ON role_assignment_requests (status, created_at DESC);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('review:role_assignment:any', 'Permission to approve or reject assignments of roles that require approval.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'review:role_assignment:any';
+
+DROP INDEX IF EXISTS idx_role_assignment_requests_status;
+DROP INDEX IF EXISTS idx_role_assignment_requests_pending;
+DROP TABLE IF EXISTS role_assignment_requests;
+DROP TYPE IF EXISTS role_assignment_request_status;
+
+ALTER TABLE roles
+DROP COLUMN IF EXISTS requires_approval;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Deprecated permission names and the permissions replacing them. Callers
+-- still checking an alias are authorized against its replacement
+CREATE TABLE IF NOT EXISTS permission_aliases (
+  alias VARCHAR(255) PRIMARY KEY,
+  permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_permission_aliases_permission
+ON permission_aliases (permission_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_permission_aliases_permission;
+DROP TABLE IF EXISTS permission_aliases;
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Roles created and managed by the admins of an institution. They are always
+-- scoped to that institution
+ALTER TABLE roles
+ADD COLUMN IF NOT EXISTS is_custom BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_roles_custom
+ON roles (institution_id)
+WHERE is_custom = TRUE;
+
+-- The only permissions institution admins may grant through custom roles
+CREATE TABLE IF NOT EXISTS custom_role_permission_allowlist (
+  permission_id UUID PRIMARY KEY REFERENCES permissions(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO custom_role_permission_allowlist (permission_id)
+SELECT id FROM permissions
+WHERE name IN ('read:role:any', 'assign:role:any')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('manage:custom_role:any', 'Permission to create and manage the custom roles of an institution.')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_template_permissions (template_id, permission_id)
+SELECT t.id, p.id
+FROM role_templates t, permissions p
+WHERE t.name = 'Institution Admin Pack'
+  AND p.name = 'manage:custom_role:any'
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'manage:custom_role:any';
+
+DROP TABLE IF EXISTS custom_role_permission_allowlist;
+
+DROP INDEX IF EXISTS idx_roles_custom;
+
+ALTER TABLE roles
+DROP COLUMN IF EXISTS is_custom;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Explicit denials take precedence over every permission an account is
+-- granted through its roles or authorization policies
+CREATE TABLE IF NOT EXISTS permission_denials (
+  user_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  permission_id UUID NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  reason TEXT,
+  denied_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, permission_id)
+);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('manage:permission_denial:any', 'Permission to deny accounts permissions they would otherwise be granted.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'manage:permission_denial:any';
+
+DROP TABLE IF EXISTS permission_denials;
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Members of an institution are owners, admins or plain members. The order of
+-- the values ranks them from the most to the least privileged
+CREATE TYPE institution_membership_role AS ENUM ('owner', 'admin', 'member');
+
+ALTER TABLE account_institutions
+ADD COLUMN IF NOT EXISTS membership_role institution_membership_role NOT NULL DEFAULT 'member';
+
+CREATE INDEX IF NOT EXISTS idx_account_institutions_membership_role
+ON account_institutions (institution_id, membership_role);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('manage:institution_members:any', 'Permission to manage the members of any institution as if owning it.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'manage:institution_members:any';
+
+DROP INDEX IF EXISTS idx_account_institutions_membership_role;
+
+ALTER TABLE account_institutions
+DROP COLUMN IF EXISTS membership_role;
+
+DROP TYPE IF EXISTS institution_membership_role;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Invitations emailed to people who should join an institution. An
+-- invitation is pending until it is accepted, revoked or expires
+CREATE TABLE IF NOT EXISTS institution_invitations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  email VARCHAR(255) NOT NULL,
+  membership_role institution_membership_role NOT NULL DEFAULT 'member',
+  invited_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  accepted_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  expires_at TIMESTAMP NOT NULL,
+  accepted_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_institution_invitations_pending
+ON institution_invitations (institution_id, email)
+WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_institution_invitations_pending;
+DROP TABLE IF EXISTS institution_invitations;
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Accounts ask to join an institution and wait for one of its admins or
+-- owners to approve them
+CREATE TYPE institution_join_request_status AS ENUM ('pending', 'approved', 'rejected');
+
+CREATE TABLE IF NOT EXISTS institution_join_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  message TEXT,
+  status institution_join_request_status NOT NULL DEFAULT 'pending',
+  reviewed_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  review_note TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  reviewed_at TIMESTAMP
+);
+
+-- Only a single request may be pending for the same account and institution
+CREATE UNIQUE INDEX IF NOT EXISTS idx_institution_join_requests_pending
+ON institution_join_requests (institution_id, account_id)
+WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_institution_join_requests_status
+ON institution_join_requests (institution_id, status, created_at DESC);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_institution_join_requests_status;
+DROP INDEX IF EXISTS idx_institution_join_requests_pending;
+DROP TABLE IF EXISTS institution_join_requests;
+DROP TYPE IF EXISTS institution_join_request_status;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Institutions register the email domains of their members. Once a domain is
+-- verified through a DNS TXT record, accounts signing up with an email on the
+-- domain are linked to the institution automatically
+CREATE TABLE IF NOT EXISTS institution_email_domains (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  domain TEXT NOT NULL,
+  verification_token TEXT NOT NULL,
+  verified_at TIMESTAMP,
+  created_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (institution_id, domain)
+);
+
+-- A domain may only be verified for a single institution
+CREATE UNIQUE INDEX IF NOT EXISTS idx_institution_email_domains_verified
+ON institution_email_domains (domain)
+WHERE verified_at IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_institution_email_domains_verified;
+DROP TABLE IF EXISTS institution_email_domains;
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Departments split an institution into smaller units such as faculties or
+-- schools so that services can target a part of an institution
+CREATE TABLE IF NOT EXISTS institution_departments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  description TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (institution_id, name)
+);
+
+-- Only members of an institution may belong to its departments. Leaving the
+-- institution removes the account from its departments
+CREATE TABLE IF NOT EXISTS department_members (
+  department_id UUID NOT NULL REFERENCES institution_departments(id) ON DELETE CASCADE,
+  account_id UUID NOT NULL,
+  institution_id INT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (department_id, account_id),
+  FOREIGN KEY (account_id, institution_id)
+    REFERENCES account_institutions(account_id, institution_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_department_members_account
+ON department_members (account_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_department_members_account;
+DROP TABLE IF EXISTS department_members;
+DROP TABLE IF EXISTS institution_departments;
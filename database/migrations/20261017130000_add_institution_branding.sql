@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Branding lets client apps render institution specific theming while
+-- metadata holds free form details about the institution
+ALTER TABLE institutions
+ADD COLUMN IF NOT EXISTS logo_url TEXT,
+ADD COLUMN IF NOT EXISTS primary_color TEXT,
+ADD COLUMN IF NOT EXISTS secondary_color TEXT,
+ADD COLUMN IF NOT EXISTS website TEXT,
+ADD COLUMN IF NOT EXISTS timezone TEXT,
+ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+ALTER TABLE institutions
+DROP COLUMN IF EXISTS metadata,
+DROP COLUMN IF EXISTS timezone,
+DROP COLUMN IF EXISTS website,
+DROP COLUMN IF EXISTS secondary_color,
+DROP COLUMN IF EXISTS primary_color,
+DROP COLUMN IF EXISTS logo_url;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Bulk member imports are processed in the background. Existing accounts are
+-- linked to the institution while unknown emails are invited
+CREATE TYPE institution_member_import_status AS ENUM ('pending', 'running', 'completed', 'failed');
+
+CREATE TABLE IF NOT EXISTS institution_member_imports (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  institution_id INT NOT NULL REFERENCES institutions(institution_id) ON DELETE CASCADE,
+  requested_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  status institution_member_import_status NOT NULL DEFAULT 'pending',
+  emails TEXT[] NOT NULL,
+  total INT NOT NULL,
+  processed INT NOT NULL DEFAULT 0,
+  linked INT NOT NULL DEFAULT 0,
+  invited INT NOT NULL DEFAULT 0,
+  skipped INT NOT NULL DEFAULT 0,
+  errors JSONB NOT NULL DEFAULT '[]',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_institution_member_imports_institution
+ON institution_member_imports (institution_id, created_at DESC);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_institution_member_imports_institution;
+DROP TABLE IF EXISTS institution_member_imports;
+DROP TYPE IF EXISTS institution_member_import_status;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Deleting an institution archives it so that it can be restored later
+ALTER TABLE institutions
+ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+ALTER TABLE institutions
+DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Accounts may follow each other, the friends leaderboard only shows the
+-- accounts the caller follows
+CREATE TABLE IF NOT EXISTS account_follows (
+  follower_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  followee_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (follower_id, followee_id),
+  CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_follows_followee
+ON account_follows (followee_id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_account_follows_followee;
+DROP TABLE IF EXISTS account_follows;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- One row per account and day recording its global rank so rank changes can
+-- be reported without recomputing historical standings
+CREATE TABLE IF NOT EXISTS leaderboard_snapshots (
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  snapshot_date DATE NOT NULL,
+  vibe_rank BIGINT NOT NULL,
+  vibe_points BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (account_id, snapshot_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_snapshots_date
+ON leaderboard_snapshots (snapshot_date);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_leaderboard_snapshots_date;
+DROP TABLE IF EXISTS leaderboard_snapshots;
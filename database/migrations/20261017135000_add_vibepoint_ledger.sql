@@ -0,0 +1,346 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- vibepoint_transactions becomes an append-only ledger. Every entry records
+-- the activity it was earned through and the account that made it, and
+-- erroneous grants are corrected by appending a reversal instead of editing
+-- the original entry. The existing trigger keeps accounts.vibe_points in sync
+ALTER TABLE vibepoint_transactions
+ADD COLUMN IF NOT EXISTS activity_id UUID REFERENCES activities(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS actor_id UUID REFERENCES accounts(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS reversal_of BIGINT UNIQUE REFERENCES vibepoint_transactions(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_vibepoint_transactions_account
+ON vibepoint_transactions (account_id, awarded_at DESC);
+
+-- +goose StatementBegin
+-- Ledger entries may not be edited. Only the references to the activity and
+-- the actor may be cleared when those are deleted
+CREATE OR REPLACE FUNCTION prevent_vibepoint_transaction_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.account_id IS DISTINCT FROM OLD.account_id
+       OR NEW.awarding_reason IS DISTINCT FROM OLD.awarding_reason
+       OR NEW.points_awarded IS DISTINCT FROM OLD.points_awarded
+       OR NEW.awarded_at IS DISTINCT FROM OLD.awarded_at
+       OR NEW.awarded_by IS DISTINCT FROM OLD.awarded_by
+       OR NEW.reversal_of IS DISTINCT FROM OLD.reversal_of
+       OR (NEW.activity_id IS NOT NULL AND NEW.activity_id IS DISTINCT FROM OLD.activity_id)
+       OR (NEW.actor_id IS NOT NULL AND NEW.actor_id IS DISTINCT FROM OLD.actor_id) THEN
+        RAISE EXCEPTION 'vibepoint transactions are append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_prevent_vibepoint_transaction_update
+    BEFORE UPDATE ON vibepoint_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_vibepoint_transaction_update();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks. Points
+-- granted for the completion are linked to the activity in the ledger
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive';
+    END IF;
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity';
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system', p_activity_id);
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system', p_activity_id);
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:vibepoint_ledger:any', 'Permission to view the vibe point ledger of any account.'),
+    ('reverse:vibepoint_transaction:any', 'Permission to reverse erroneous vibe point grants.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN ('read:vibepoint_ledger:any', 'reverse:vibepoint_transaction:any');
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive';
+    END IF;
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity';
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system');
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system');
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+DROP TRIGGER IF EXISTS trigger_prevent_vibepoint_transaction_update ON vibepoint_transactions;
+DROP FUNCTION IF EXISTS prevent_vibepoint_transaction_update();
+DROP INDEX IF EXISTS idx_vibepoint_transactions_account;
+
+ALTER TABLE vibepoint_transactions
+DROP COLUMN IF EXISTS reversal_of,
+DROP COLUMN IF EXISTS actor_id,
+DROP COLUMN IF EXISTS activity_id;
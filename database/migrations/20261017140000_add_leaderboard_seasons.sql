@@ -0,0 +1,122 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Seasons split the leaderboard into fixed periods. When a season ends its
+-- standings are archived and the points of every account are optionally
+-- decayed or reset
+CREATE TYPE leaderboard_season_end_action AS ENUM ('keep', 'decay', 'reset');
+
+CREATE TABLE IF NOT EXISTS leaderboard_seasons (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name VARCHAR(255) NOT NULL,
+  starts_at TIMESTAMP NOT NULL,
+  ends_at TIMESTAMP NOT NULL,
+  end_action leaderboard_season_end_action NOT NULL DEFAULT 'keep',
+  -- Share of every account's points taken away when a decaying season ends
+  decay_percent SMALLINT NOT NULL DEFAULT 0 CHECK (decay_percent >= 0 AND decay_percent <= 100),
+  closed_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_seasons_open
+ON leaderboard_seasons (ends_at)
+WHERE closed_at IS NULL;
+
+-- Final standings of a closed season, ranked by the points earned during it
+CREATE TABLE IF NOT EXISTS leaderboard_season_standings (
+  season_id UUID NOT NULL REFERENCES leaderboard_seasons(id) ON DELETE CASCADE,
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  final_rank BIGINT NOT NULL,
+  final_points BIGINT NOT NULL,
+  PRIMARY KEY (season_id, account_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_season_standings_rank
+ON leaderboard_season_standings (season_id, final_rank);
+
+-- Decaying or resetting points appends ledger entries linked to the season.
+-- Those may exceed the usual per entry limit so the amount is widened
+ALTER TABLE vibepoint_transactions
+ADD COLUMN IF NOT EXISTS season_id UUID REFERENCES leaderboard_seasons(id);
+
+ALTER TABLE vibepoint_transactions
+DROP CONSTRAINT IF EXISTS vibepoint_transactions_points_awarded_check;
+
+ALTER TABLE vibepoint_transactions
+ALTER COLUMN points_awarded TYPE INTEGER;
+
+ALTER TABLE vibepoint_transactions
+ADD CONSTRAINT vibepoint_transactions_points_awarded_check
+CHECK ((points_awarded > -11 AND points_awarded < 11) OR season_id IS NOT NULL);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_vibepoint_transaction_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.account_id IS DISTINCT FROM OLD.account_id
+       OR NEW.awarding_reason IS DISTINCT FROM OLD.awarding_reason
+       OR NEW.points_awarded IS DISTINCT FROM OLD.points_awarded
+       OR NEW.awarded_at IS DISTINCT FROM OLD.awarded_at
+       OR NEW.awarded_by IS DISTINCT FROM OLD.awarded_by
+       OR NEW.reversal_of IS DISTINCT FROM OLD.reversal_of
+       OR NEW.season_id IS DISTINCT FROM OLD.season_id
+       OR (NEW.activity_id IS NOT NULL AND NEW.activity_id IS DISTINCT FROM OLD.activity_id)
+       OR (NEW.actor_id IS NOT NULL AND NEW.actor_id IS DISTINCT FROM OLD.actor_id) THEN
+        RAISE EXCEPTION 'vibepoint transactions are append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('manage:leaderboard_season:any', 'Permission to schedule and remove leaderboard seasons.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'manage:leaderboard_season:any';
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION prevent_vibepoint_transaction_update()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.account_id IS DISTINCT FROM OLD.account_id
+       OR NEW.awarding_reason IS DISTINCT FROM OLD.awarding_reason
+       OR NEW.points_awarded IS DISTINCT FROM OLD.points_awarded
+       OR NEW.awarded_at IS DISTINCT FROM OLD.awarded_at
+       OR NEW.awarded_by IS DISTINCT FROM OLD.awarded_by
+       OR NEW.reversal_of IS DISTINCT FROM OLD.reversal_of
+       OR (NEW.activity_id IS NOT NULL AND NEW.activity_id IS DISTINCT FROM OLD.activity_id)
+       OR (NEW.actor_id IS NOT NULL AND NEW.actor_id IS DISTINCT FROM OLD.actor_id) THEN
+        RAISE EXCEPTION 'vibepoint transactions are append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Season adjustments keep their effect on the totals but lose the link to
+-- the season. The amount stays widened so that they still fit and older
+-- entries are not checked against the restored limit
+ALTER TABLE vibepoint_transactions
+DROP CONSTRAINT IF EXISTS vibepoint_transactions_points_awarded_check;
+
+ALTER TABLE vibepoint_transactions
+DROP COLUMN IF EXISTS season_id;
+
+ALTER TABLE vibepoint_transactions
+ADD CONSTRAINT vibepoint_transactions_points_awarded_check
+CHECK (points_awarded > -11 AND points_awarded < 11) NOT VALID;
+
+DROP INDEX IF EXISTS idx_leaderboard_season_standings_rank;
+DROP TABLE IF EXISTS leaderboard_season_standings;
+DROP INDEX IF EXISTS idx_leaderboard_seasons_open;
+DROP TABLE IF EXISTS leaderboard_seasons;
+DROP TYPE IF EXISTS leaderboard_season_end_action;
@@ -0,0 +1,331 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- Minimum number of seconds between two completions of an activity by the
+-- same account
+ALTER TABLE activities
+    ADD COLUMN cooldown_seconds INTEGER NOT NULL DEFAULT 0 CHECK (cooldown_seconds >= 0);
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks. Points
+-- granted for the completion are linked to the activity in the ledger.
+-- Completions of the same activity by the same account are serialized so
+-- concurrent requests cannot slip past the daily limit or the cooldown.
+-- Rejections are raised with their own SQLSTATE:
+--   VS001 the activity does not exist or is inactive
+--   VS002 the daily completion limit was reached
+--   VS003 the activity is cooling down, DETAIL holds the seconds left
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_last_completed_at timestamp;
+    v_cooldown_left int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive'
+            USING ERRCODE = 'VS001';
+    END IF;
+
+    -- Serialize completions of this activity by this account until the
+    -- transaction ends
+    PERFORM pg_advisory_xact_lock(hashtextextended(p_account_id::text || p_activity_id::text, 0));
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity'
+            USING ERRCODE = 'VS002';
+    END IF;
+
+    -- Check the cooldown since the last completion
+    IF v_activity.cooldown_seconds > 0 THEN
+        SELECT MAX(completed_at) INTO v_last_completed_at
+        FROM activity_completions
+        WHERE account_id = p_account_id
+          AND activity_id = p_activity_id;
+
+        IF v_last_completed_at IS NOT NULL THEN
+            v_cooldown_left := CEIL(v_activity.cooldown_seconds
+                - EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP::timestamp - v_last_completed_at)));
+            IF v_cooldown_left > 0 THEN
+                RAISE EXCEPTION 'Activity is cooling down'
+                    USING ERRCODE = 'VS003', DETAIL = v_cooldown_left::text;
+            END IF;
+        END IF;
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system', p_activity_id);
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system', p_activity_id);
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks. Points
+-- granted for the completion are linked to the activity in the ledger
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive';
+    END IF;
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity';
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system', p_activity_id);
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system', p_activity_id);
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+ALTER TABLE activities DROP COLUMN IF EXISTS cooldown_seconds;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- The last global rank observed for every ranked account. Comparing it with
+-- the current ranks tells which accounts entered the top of the leaderboard
+-- or were overtaken since the previous check
+CREATE TABLE IF NOT EXISTS leaderboard_rank_positions (
+  account_id UUID PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+  vibe_rank BIGINT NOT NULL,
+  vibe_points BIGINT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_rank_positions_rank
+ON leaderboard_rank_positions (vibe_rank);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_leaderboard_rank_positions_rank;
+DROP TABLE IF EXISTS leaderboard_rank_positions;
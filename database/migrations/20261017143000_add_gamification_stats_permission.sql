@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:gamification_stats:any', 'Permission to view aggregated activity, streak and milestone statistics.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'read:gamification_stats:any';
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Every event published to the event bus, kept so that downstream services
+-- that lost messages can have them published again
+CREATE TABLE IF NOT EXISTS published_events (
+  id BIGSERIAL PRIMARY KEY,
+  exchange VARCHAR(255) NOT NULL,
+  routing_key VARCHAR(255) NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  -- Account the event is about, if any. Not a foreign key so that events
+  -- about deleted accounts can still be replayed
+  account_id UUID,
+  payload JSONB NOT NULL,
+  published_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_published_events_published_at
+ON published_events (published_at);
+
+CREATE INDEX IF NOT EXISTS idx_published_events_type
+ON published_events (event_type, published_at);
+
+CREATE INDEX IF NOT EXISTS idx_published_events_account
+ON published_events (account_id, published_at)
+WHERE account_id IS NOT NULL;
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('replay:event:any', 'Permission to publish journaled events again.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'replay:event:any';
+
+DROP INDEX IF EXISTS idx_published_events_account;
+DROP INDEX IF EXISTS idx_published_events_type;
+DROP INDEX IF EXISTS idx_published_events_published_at;
+DROP TABLE IF EXISTS published_events;
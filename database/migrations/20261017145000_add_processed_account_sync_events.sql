@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Account sync events other services emitted that were applied, so that
+-- redelivered events are not applied twice
+CREATE TABLE IF NOT EXISTS processed_account_sync_events (
+  source_service_id VARCHAR(255) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (source_service_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_account_sync_events_processed_at
+ON processed_account_sync_events (processed_at);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_processed_account_sync_events_processed_at;
+DROP TABLE IF EXISTS processed_account_sync_events;
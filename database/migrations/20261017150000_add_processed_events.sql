@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Events consumers handled, so that replayed and redelivered events are not
+-- handled twice. Event IDs are only unique per consumer
+CREATE TABLE IF NOT EXISTS processed_events (
+  consumer VARCHAR(255) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (consumer, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at
+ON processed_events (processed_at);
+
+-- Account sync events are processed events of the account sync consumer
+INSERT INTO processed_events (consumer, event_id, processed_at)
+SELECT 'account_sync:' || source_service_id, event_id, processed_at
+FROM processed_account_sync_events
+ON CONFLICT DO NOTHING;
+
+DROP INDEX IF EXISTS idx_processed_account_sync_events_processed_at;
+DROP TABLE IF EXISTS processed_account_sync_events;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+CREATE TABLE IF NOT EXISTS processed_account_sync_events (
+  source_service_id VARCHAR(255) NOT NULL,
+  event_id VARCHAR(255) NOT NULL,
+  event_type VARCHAR(100) NOT NULL,
+  processed_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (source_service_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_processed_account_sync_events_processed_at
+ON processed_account_sync_events (processed_at);
+
+DROP INDEX IF EXISTS idx_processed_events_processed_at;
+DROP TABLE IF EXISTS processed_events;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:dead_letter:any', 'Permission to inspect events in the dead-letter queue.'),
+    ('manage:dead_letter:any', 'Permission to requeue and discard events in the dead-letter queue.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN ('read:dead_letter:any', 'manage:dead_letter:any');
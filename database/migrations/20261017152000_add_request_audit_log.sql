@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Every state changing request served, who made it and how it went
+CREATE TABLE IF NOT EXISTS request_audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  request_id VARCHAR(128) NOT NULL,
+  -- Account that made the request, if it was authenticated. Not a foreign
+  -- key so that the requests of deleted accounts stay in the log
+  actor_id UUID,
+  method VARCHAR(10) NOT NULL,
+  -- Route pattern the request matched e.g. PATCH /api/v1/roles/{id}
+  route VARCHAR(255) NOT NULL,
+  path TEXT NOT NULL,
+  -- Path parameters of the route keyed by name e.g. {"id": "..."}
+  resource_ids JSONB NOT NULL DEFAULT '{}',
+  status INT NOT NULL,
+  latency_ms INT NOT NULL,
+  ip_address VARCHAR(45),
+  user_agent TEXT,
+  occurred_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_request_audit_log_occurred_at
+ON request_audit_log (occurred_at);
+
+CREATE INDEX IF NOT EXISTS idx_request_audit_log_actor
+ON request_audit_log (actor_id, occurred_at)
+WHERE actor_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_request_audit_log_route
+ON request_audit_log (route, occurred_at);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:request_audit:any', 'Permission to read the log of state changing requests.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name = 'read:request_audit:any';
+
+DROP INDEX IF EXISTS idx_request_audit_log_route;
+DROP INDEX IF EXISTS idx_request_audit_log_actor;
+DROP INDEX IF EXISTS idx_request_audit_log_occurred_at;
+DROP TABLE IF EXISTS request_audit_log;
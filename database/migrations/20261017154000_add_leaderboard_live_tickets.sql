@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Short lived tickets clients open the live leaderboard WebSocket with.
+-- WebSocket clients cannot always send an Authorization header, so an
+-- authenticated request exchanges its token for a ticket that is passed in
+-- the URL instead. Only the hash of a ticket is stored and a ticket can be
+-- redeemed once
+CREATE TABLE IF NOT EXISTS leaderboard_live_tickets (
+  ticket_hash TEXT PRIMARY KEY,
+  account_id UUID NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_leaderboard_live_tickets_expires_at
+ON leaderboard_live_tickets (expires_at);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_leaderboard_live_tickets_expires_at;
+DROP TABLE IF EXISTS leaderboard_live_tickets;
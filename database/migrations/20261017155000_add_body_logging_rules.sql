@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Routes whose request and response bodies are sampled into the logs while
+-- a problem is diagnosed. Rules turn themselves off once they expire
+CREATE TABLE IF NOT EXISTS body_logging_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  -- Route pattern whose bodies are logged e.g. PATCH /api/v1/roles/{id}
+  route VARCHAR(255) NOT NULL UNIQUE,
+  -- Share of the requests of the route that are logged
+  sample_rate DOUBLE PRECISION NOT NULL CHECK (sample_rate > 0 AND sample_rate <= 1),
+  expires_at TIMESTAMPTZ NOT NULL,
+  -- Account that turned the logging on
+  created_by UUID REFERENCES accounts(id) ON DELETE SET NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO permissions (name, description)
+VALUES
+    ('read:diagnostics:any', 'Permission to see which routes have their bodies logged.'),
+    ('manage:diagnostics:any', 'Permission to turn the logging of request and response bodies on and off.')
+ON CONFLICT (name) DO NOTHING;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM permissions
+WHERE name IN ('read:diagnostics:any', 'manage:diagnostics:any');
+
+DROP TABLE IF EXISTS body_logging_rules;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Keyset pagination orders rows by creation time and id and continues
+-- after the last row of the previous page. Rows without a creation time
+-- would drop out of such pages, so it becomes mandatory
+UPDATE accounts SET created_at = COALESCE(updated_at, NOW()) WHERE created_at IS NULL;
+ALTER TABLE accounts ALTER COLUMN created_at SET NOT NULL;
+
+UPDATE activities SET created_at = COALESCE(updated_at, NOW()) WHERE created_at IS NULL;
+ALTER TABLE activities ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_listing_keyset
+ON accounts (created_at DESC, id DESC)
+WHERE type = 'human' AND deactivated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_activities_keyset
+ON activities (created_at DESC, id DESC);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_activities_keyset;
+DROP INDEX IF EXISTS idx_accounts_listing_keyset;
+ALTER TABLE activities ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE accounts ALTER COLUMN created_at DROP NOT NULL;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Account searches match substrings and misspellings with trigrams and
+-- whole words of names with full text search. Only active accounts are
+-- searched, so only they are indexed
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_email_trgm
+ON accounts USING GIN (lower(email) gin_trgm_ops)
+WHERE deactivated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_name_trgm
+ON accounts USING GIN (lower(name) gin_trgm_ops)
+WHERE deactivated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_username_trgm
+ON accounts USING GIN (lower(username) gin_trgm_ops)
+WHERE deactivated_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_name_fts
+ON accounts USING GIN (to_tsvector('simple', name))
+WHERE deactivated_at IS NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_accounts_name_fts;
+DROP INDEX IF EXISTS idx_accounts_username_trgm;
+DROP INDEX IF EXISTS idx_accounts_name_trgm;
+DROP INDEX IF EXISTS idx_accounts_email_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
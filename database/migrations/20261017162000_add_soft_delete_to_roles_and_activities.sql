@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Deleting a role or an activity only marks it deleted so that it can be
+-- restored. Accounts already carry deleted_at and institutions archived_at
+ALTER TABLE roles
+ADD COLUMN deleted_at timestamptz;
+
+-- The name of a deleted role can be given to a new one
+ALTER TABLE roles
+DROP CONSTRAINT IF EXISTS roles_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name
+ON roles (name)
+WHERE deleted_at IS NULL;
+
+ALTER TABLE activities
+ADD COLUMN deleted_at timestamptz;
+
+CREATE INDEX IF NOT EXISTS idx_accounts_deleted
+ON accounts (deleted_at DESC)
+WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP INDEX IF EXISTS idx_accounts_deleted;
+
+ALTER TABLE activities
+DROP COLUMN deleted_at;
+
+-- Deleted roles may share their name with other roles
+DELETE FROM roles
+WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_roles_name;
+
+ALTER TABLE roles
+ADD CONSTRAINT roles_name_key UNIQUE (name);
+
+ALTER TABLE roles
+DROP COLUMN deleted_at;
@@ -0,0 +1,515 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Activity completions are partitioned by the month they were made in so
+-- that queries bounded by completion time only scan the months they need and
+-- months past the retention period are dropped instead of deleted row by row.
+-- The partition key has to be part of the primary key, ids stay unique as
+-- they are drawn from a single sequence
+ALTER TABLE activity_completions RENAME TO activity_completions_unpartitioned;
+ALTER TABLE activity_completions_unpartitioned
+RENAME CONSTRAINT activity_completions_pkey TO activity_completions_unpartitioned_pkey;
+DROP INDEX IF EXISTS idx_activity_completions_date;
+DROP INDEX IF EXISTS idx_activity_completions_account_date;
+DROP INDEX IF EXISTS idx_activity_completions_account_activity;
+
+CREATE TABLE activity_completions (
+    id bigint NOT NULL,
+    account_id uuid NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    activity_id uuid NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    completed_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completion_date date GENERATED ALWAYS AS (completed_at::date) STORED,
+    points_earned smallint NOT NULL,
+    metadata jsonb,
+    PRIMARY KEY (id, completed_at)
+) PARTITION BY RANGE (completed_at);
+
+-- Catches completions made in a month whose partition was not created yet
+CREATE TABLE activity_completions_default PARTITION OF activity_completions DEFAULT;
+
+-- +goose StatementBegin
+-- Creates the monthly partitions of activity_completions from the month of
+-- p_from up to p_months_ahead months after the current one and returns how
+-- many were created. Completions of those months that landed in the default
+-- partition are moved to their month's partition
+CREATE OR REPLACE FUNCTION create_activity_completion_partitions(
+    p_from date,
+    p_months_ahead int
+)
+RETURNS int AS $$
+DECLARE
+    v_month date := date_trunc('month', p_from)::date;
+    v_last date := (date_trunc('month', CURRENT_DATE) + make_interval(months => p_months_ahead))::date;
+    v_next date;
+    v_name text;
+    v_created int := 0;
+BEGIN
+    -- Instances maintaining partitions at the same time take turns
+    PERFORM pg_advisory_xact_lock(hashtextextended('activity_completions_partitions', 0));
+
+    WHILE v_month <= v_last LOOP
+        v_next := (v_month + INTERVAL '1 month')::date;
+        v_name := 'activity_completions_' || to_char(v_month, 'YYYY_MM');
+
+        IF to_regclass(v_name) IS NULL THEN
+            CREATE TEMP TABLE activity_completions_moved ON COMMIT DROP AS
+            SELECT id, account_id, activity_id, completed_at, points_earned, metadata
+            FROM activity_completions_default
+            WHERE completed_at >= v_month AND completed_at < v_next;
+
+            DELETE FROM activity_completions_default
+            WHERE completed_at >= v_month AND completed_at < v_next;
+
+            EXECUTE format(
+                'CREATE TABLE %I PARTITION OF activity_completions FOR VALUES FROM (%L) TO (%L)',
+                v_name, v_month::timestamp, v_next::timestamp
+            );
+
+            INSERT INTO activity_completions (id, account_id, activity_id, completed_at, points_earned, metadata)
+            SELECT id, account_id, activity_id, completed_at, points_earned, metadata
+            FROM activity_completions_moved;
+
+            DROP TABLE activity_completions_moved;
+            v_created := v_created + 1;
+        END IF;
+
+        v_month := v_next;
+    END LOOP;
+
+    RETURN v_created;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Drops the monthly partitions of activity_completions holding completions
+-- made before p_before only and returns their names
+CREATE OR REPLACE FUNCTION drop_activity_completion_partitions(p_before date)
+RETURNS SETOF text AS $$
+DECLARE
+    v_partition text;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('activity_completions_partitions', 0));
+
+    FOR v_partition IN
+        SELECT c.relname::text
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'activity_completions'::regclass
+          AND c.relname ~ '^activity_completions_[0-9]{4}_[0-9]{2}$'
+          AND to_date(right(c.relname, 7), 'YYYY_MM') + INTERVAL '1 month' <= p_before
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format('DROP TABLE %I', v_partition);
+        RETURN NEXT v_partition;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+SELECT create_activity_completion_partitions(
+    COALESCE((SELECT min(completed_at)::date FROM activity_completions_unpartitioned), CURRENT_DATE),
+    3
+);
+
+INSERT INTO activity_completions (id, account_id, activity_id, completed_at, points_earned, metadata)
+SELECT id, account_id, activity_id, COALESCE(completed_at, CURRENT_TIMESTAMP::timestamp), points_earned, metadata
+FROM activity_completions_unpartitioned;
+
+DROP TABLE activity_completions_unpartitioned;
+
+CREATE SEQUENCE activity_completions_id_seq OWNED BY activity_completions.id;
+SELECT setval('activity_completions_id_seq', COALESCE((SELECT max(id) FROM activity_completions), 0) + 1, false);
+ALTER TABLE activity_completions ALTER COLUMN id SET DEFAULT nextval('activity_completions_id_seq');
+
+CREATE INDEX idx_activity_completions_account_activity
+ON activity_completions (account_id, activity_id, completed_at DESC);
+CREATE INDEX idx_activity_completions_account_date
+ON activity_completions (account_id, completion_date DESC);
+CREATE INDEX idx_activity_completions_date
+ON activity_completions (completion_date DESC);
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks. Points
+-- granted for the completion are linked to the activity in the ledger.
+-- Completions of the same activity by the same account are serialized so
+-- concurrent requests cannot slip past the daily limit or the cooldown.
+-- Completions are only looked up within the day and the cooldown so that
+-- only the partitions of the current month are scanned.
+-- Rejections are raised with their own SQLSTATE:
+--   VS001 the activity does not exist or is inactive
+--   VS002 the daily completion limit was reached
+--   VS003 the activity is cooling down, DETAIL holds the seconds left
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_last_completed_at timestamp;
+    v_cooldown_left int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive'
+            USING ERRCODE = 'VS001';
+    END IF;
+
+    -- Serialize completions of this activity by this account until the
+    -- transaction ends
+    PERFORM pg_advisory_xact_lock(hashtextextended(p_account_id::text || p_activity_id::text, 0));
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completed_at >= v_today
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity'
+            USING ERRCODE = 'VS002';
+    END IF;
+
+    -- Check the cooldown since the last completion
+    IF v_activity.cooldown_seconds > 0 THEN
+        SELECT MAX(completed_at) INTO v_last_completed_at
+        FROM activity_completions
+        WHERE account_id = p_account_id
+          AND activity_id = p_activity_id
+          AND completed_at > CURRENT_TIMESTAMP::timestamp
+              - make_interval(secs => v_activity.cooldown_seconds);
+
+        IF v_last_completed_at IS NOT NULL THEN
+            v_cooldown_left := CEIL(v_activity.cooldown_seconds
+                - EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP::timestamp - v_last_completed_at)));
+            IF v_cooldown_left > 0 THEN
+                RAISE EXCEPTION 'Activity is cooling down'
+                    USING ERRCODE = 'VS003', DETAIL = v_cooldown_left::text;
+            END IF;
+        END IF;
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system', p_activity_id);
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system', p_activity_id);
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+CREATE TABLE activity_completions_unpartitioned (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    account_id uuid NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    activity_id uuid NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    completed_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    completion_date date GENERATED ALWAYS AS (completed_at::date) STORED,
+    points_earned smallint NOT NULL,
+    metadata jsonb
+);
+
+INSERT INTO activity_completions_unpartitioned (id, account_id, activity_id, completed_at, points_earned, metadata)
+OVERRIDING SYSTEM VALUE
+SELECT id, account_id, activity_id, completed_at, points_earned, metadata
+FROM activity_completions;
+
+DROP TABLE activity_completions;
+DROP FUNCTION IF EXISTS drop_activity_completion_partitions(date);
+DROP FUNCTION IF EXISTS create_activity_completion_partitions(date, int);
+
+ALTER TABLE activity_completions_unpartitioned RENAME TO activity_completions;
+ALTER TABLE activity_completions
+RENAME CONSTRAINT activity_completions_unpartitioned_pkey TO activity_completions_pkey;
+ALTER SEQUENCE activity_completions_unpartitioned_id_seq RENAME TO activity_completions_id_seq;
+SELECT setval('activity_completions_id_seq', COALESCE((SELECT max(id) FROM activity_completions), 0) + 1, false);
+
+CREATE INDEX idx_activity_completions_account_activity ON activity_completions(account_id, activity_id);
+CREATE INDEX idx_activity_completions_account_date ON activity_completions(account_id, completion_date DESC);
+CREATE INDEX idx_activity_completions_date ON activity_completions(completion_date DESC);
+
+-- +goose StatementBegin
+-- Function to record an activity completion and update streaks. Points
+-- granted for the completion are linked to the activity in the ledger.
+-- Completions of the same activity by the same account are serialized so
+-- concurrent requests cannot slip past the daily limit or the cooldown.
+-- Rejections are raised with their own SQLSTATE:
+--   VS001 the activity does not exist or is inactive
+--   VS002 the daily completion limit was reached
+--   VS003 the activity is cooling down, DETAIL holds the seconds left
+CREATE OR REPLACE FUNCTION record_activity_completion(
+    p_account_id uuid,
+    p_activity_id uuid,
+    p_metadata jsonb DEFAULT NULL
+)
+RETURNS TABLE(
+    completion_id bigint,
+    points_earned smallint,
+    current_streak smallint,
+    milestone_achieved boolean,
+    milestone_bonus smallint
+) AS $$
+DECLARE
+    v_activity RECORD;
+    v_completions_today int;
+    v_last_completed_at timestamp;
+    v_cooldown_left int;
+    v_points smallint;
+    v_completion_id bigint;
+    v_user_streak RECORD;
+    v_new_streak smallint;
+    v_milestone_id uuid;
+    v_milestone_bonus smallint := 0;
+    v_milestone_achieved boolean := false;
+    v_today date := CURRENT_DATE;
+    v_yesterday date := CURRENT_DATE - INTERVAL '1 day';
+BEGIN
+    -- Get activity details
+    SELECT * INTO v_activity FROM activities WHERE id = p_activity_id AND is_active = true;
+    
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'Activity not found or inactive'
+            USING ERRCODE = 'VS001';
+    END IF;
+
+    -- Serialize completions of this activity by this account until the
+    -- transaction ends
+    PERFORM pg_advisory_xact_lock(hashtextextended(p_account_id::text || p_activity_id::text, 0));
+    
+    -- Check daily completion limit
+    SELECT COUNT(*) INTO v_completions_today
+    FROM activity_completions
+    WHERE account_id = p_account_id
+      AND activity_id = p_activity_id
+      AND completion_date = v_today;
+    
+    IF v_completions_today >= v_activity.max_daily_completions THEN
+        RAISE EXCEPTION 'Daily completion limit reached for this activity'
+            USING ERRCODE = 'VS002';
+    END IF;
+
+    -- Check the cooldown since the last completion
+    IF v_activity.cooldown_seconds > 0 THEN
+        SELECT MAX(completed_at) INTO v_last_completed_at
+        FROM activity_completions
+        WHERE account_id = p_account_id
+          AND activity_id = p_activity_id;
+
+        IF v_last_completed_at IS NOT NULL THEN
+            v_cooldown_left := CEIL(v_activity.cooldown_seconds
+                - EXTRACT(EPOCH FROM (CURRENT_TIMESTAMP::timestamp - v_last_completed_at)));
+            IF v_cooldown_left > 0 THEN
+                RAISE EXCEPTION 'Activity is cooling down'
+                    USING ERRCODE = 'VS003', DETAIL = v_cooldown_left::text;
+            END IF;
+        END IF;
+    END IF;
+    
+    v_points := v_activity.points_awarded;
+    
+    -- Record the completion
+    INSERT INTO activity_completions (account_id, activity_id, points_earned, metadata)
+    VALUES (p_account_id, p_activity_id, v_points, p_metadata)
+    RETURNING id INTO v_completion_id;
+    
+    -- Award vibepoints
+    INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+    VALUES (p_account_id, 'Activity: ' || v_activity.name, v_points, 'system', p_activity_id);
+    
+    -- Handle streaks if eligible
+    IF v_activity.streak_eligible THEN
+        -- Get or create user streak
+        INSERT INTO user_streaks (account_id, activity_id)
+        VALUES (p_account_id, p_activity_id)
+        ON CONFLICT (account_id, activity_id) DO NOTHING;
+        
+        SELECT * INTO v_user_streak
+        FROM user_streaks
+        WHERE account_id = p_account_id AND activity_id = p_activity_id;
+        
+        -- Update streak logic
+        IF v_user_streak.last_completion_date IS NULL THEN
+            -- First completion ever
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                longest_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_today THEN
+            -- Already completed today, no streak change
+            v_new_streak := v_user_streak.current_streak;
+            UPDATE user_streaks
+            SET total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSIF v_user_streak.last_completion_date = v_yesterday THEN
+            -- Consecutive day, increment streak
+            v_new_streak := v_user_streak.current_streak + 1;
+            UPDATE user_streaks
+            SET current_streak = v_new_streak,
+                longest_streak = GREATEST(longest_streak, v_new_streak),
+                last_completion_date = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+            
+        ELSE
+            -- Streak broken, restart
+            v_new_streak := 1;
+            UPDATE user_streaks
+            SET current_streak = 1,
+                last_completion_date = v_today,
+                streak_started_at = v_today,
+                total_completions = total_completions + 1,
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = v_user_streak.id;
+        END IF;
+        
+        -- Check for milestone achievements
+        SELECT sm.id, sm.bonus_points INTO v_milestone_id, v_milestone_bonus
+        FROM streak_milestones sm
+        LEFT JOIN user_streak_achievements usa ON usa.streak_milestone_id = sm.id 
+            AND usa.account_id = p_account_id
+        WHERE sm.activity_id = p_activity_id
+          AND sm.is_active = true
+          AND sm.days_required = v_new_streak
+          AND usa.id IS NULL -- Not yet achieved
+        LIMIT 1;
+        
+        IF FOUND THEN
+            -- Award milestone bonus
+            INSERT INTO user_streak_achievements (account_id, streak_milestone_id, user_streak_id, bonus_points_awarded)
+            VALUES (p_account_id, v_milestone_id, v_user_streak.id, v_milestone_bonus);
+            
+            INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, activity_id)
+            VALUES (p_account_id, 'Streak Milestone: ' || v_new_streak || ' days', v_milestone_bonus, 'system', p_activity_id);
+            
+            v_milestone_achieved := true;
+        END IF;
+    ELSE
+        v_new_streak := 0;
+    END IF;
+    
+    RETURN QUERY SELECT v_completion_id, v_points, v_new_streak, v_milestone_achieved, v_milestone_bonus;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- The global leaderboard used to rank every human account on each read. It
+-- is now materialized and refreshed on a schedule by the application
+DROP VIEW IF EXISTS account_vibepoint_rank;
+
+CREATE MATERIALIZED VIEW account_vibepoint_rank AS
+SELECT
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (ORDER BY vibe_points DESC) AS vibe_rank
+  FROM accounts
+WHERE accounts.type = 'human'
+  AND accounts.deactivated_at IS NULL
+WITH DATA;
+
+-- Refreshing concurrently requires a unique index
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_vibepoint_rank_id
+ON account_vibepoint_rank (id);
+
+CREATE INDEX IF NOT EXISTS idx_account_vibepoint_rank_rank
+ON account_vibepoint_rank (vibe_rank, id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP MATERIALIZED VIEW IF EXISTS account_vibepoint_rank;
+
+CREATE VIEW account_vibepoint_rank AS
+SELECT
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (ORDER BY vibe_points DESC) AS vibe_rank
+  FROM accounts
+WHERE accounts.type = 'human'
+  AND accounts.deactivated_at IS NULL;
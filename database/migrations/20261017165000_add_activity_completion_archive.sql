@@ -0,0 +1,99 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Completions older than the archival period are moved out of the
+-- partitioned activity_completions table into this one, so that the hot
+-- table only holds the months streaks and statistics read
+CREATE TABLE IF NOT EXISTS activity_completions_archive (
+    id bigint PRIMARY KEY,
+    account_id uuid NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    activity_id uuid NOT NULL REFERENCES activities(id) ON DELETE CASCADE,
+    completed_at timestamp NOT NULL,
+    completion_date date NOT NULL,
+    points_earned smallint NOT NULL,
+    metadata jsonb,
+    archived_at timestamp NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_completions_archive_account
+ON activity_completions_archive (account_id, completed_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_activity_completions_archive_completed_at
+ON activity_completions_archive (completed_at);
+
+-- +goose StatementBegin
+-- Moves the monthly partitions of activity_completions holding completions
+-- made before p_before only into activity_completions_archive, then drops
+-- them. Completions made before p_before that are still in the default
+-- partition are moved too. Returns each partition moved with its number of
+-- completions
+CREATE OR REPLACE FUNCTION archive_activity_completion_partitions(p_before date)
+RETURNS TABLE(archived_partition text, archived_rows bigint) AS $$
+DECLARE
+    v_partition text;
+    v_rows bigint;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtextextended('activity_completions_partitions', 0));
+
+    FOR v_partition IN
+        SELECT c.relname::text
+        FROM pg_inherits i
+        JOIN pg_class c ON c.oid = i.inhrelid
+        WHERE i.inhparent = 'activity_completions'::regclass
+          AND c.relname ~ '^activity_completions_[0-9]{4}_[0-9]{2}$'
+          AND to_date(right(c.relname, 7), 'YYYY_MM') + INTERVAL '1 month' <= p_before
+        ORDER BY c.relname
+    LOOP
+        EXECUTE format(
+            'INSERT INTO activity_completions_archive '
+            '(id, account_id, activity_id, completed_at, completion_date, points_earned, metadata) '
+            'SELECT id, account_id, activity_id, completed_at, completion_date, points_earned, metadata FROM %I '
+            'ON CONFLICT (id) DO NOTHING',
+            v_partition
+        );
+        GET DIAGNOSTICS v_rows = ROW_COUNT;
+        EXECUTE format('DROP TABLE %I', v_partition);
+
+        archived_partition := v_partition;
+        archived_rows := v_rows;
+        RETURN NEXT;
+    END LOOP;
+
+    WITH moved AS (
+        DELETE FROM activity_completions_default
+        WHERE completed_at < p_before
+        RETURNING id, account_id, activity_id, completed_at, completion_date, points_earned, metadata
+    )
+    INSERT INTO activity_completions_archive
+        (id, account_id, activity_id, completed_at, completion_date, points_earned, metadata)
+    SELECT id, account_id, activity_id, completed_at, completion_date, points_earned, metadata
+    FROM moved
+    ON CONFLICT (id) DO NOTHING;
+    GET DIAGNOSTICS v_rows = ROW_COUNT;
+
+    IF v_rows > 0 THEN
+        archived_partition := 'activity_completions_default';
+        archived_rows := v_rows;
+        RETURN NEXT;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+-- Archived completions go back to the partitions of their months
+SELECT create_activity_completion_partitions(
+    COALESCE((SELECT min(completed_at)::date FROM activity_completions_archive), CURRENT_DATE),
+    3
+);
+
+INSERT INTO activity_completions (id, account_id, activity_id, completed_at, points_earned, metadata)
+SELECT id, account_id, activity_id, completed_at, points_earned, metadata
+FROM activity_completions_archive;
+
+DROP FUNCTION IF EXISTS archive_activity_completion_partitions(date);
+DROP TABLE IF EXISTS activity_completions_archive;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Bumped on every update of a record so that clients can tell when their
+-- copy is stale. updated_at cannot be used for this since background jobs
+-- such as vibe point awards touch it too
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+ALTER TABLE service_tokens ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+ALTER TABLE service_tokens DROP COLUMN IF EXISTS version;
+ALTER TABLE accounts DROP COLUMN IF EXISTS version;
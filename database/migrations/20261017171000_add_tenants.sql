@@ -0,0 +1,209 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Returns the tenant the connection serves, set by Verisafe for the requests
+-- of a tenant. NULL when it serves none or every tenant, the latter being
+-- set as '*' for migrations, commands and background jobs
+CREATE OR REPLACE FUNCTION verisafe_current_tenant()
+RETURNS text AS $$
+  SELECT NULLIF(NULLIF(current_setting('verisafe.tenant', true), ''), '*')
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Reports whether the connection sees the accounts of every tenant: when it
+-- was set to serve every tenant, or serves none while tenancy is not
+-- enforced on it. Verisafe enforces tenancy on its connections when tenancy
+-- is enabled, so that work that did not name its tenant sees no account
+-- rather than all of them
+CREATE OR REPLACE FUNCTION verisafe_every_tenant_visible()
+RETURNS boolean AS $$
+  SELECT CASE COALESCE(current_setting('verisafe.tenant', true), '')
+    WHEN '*' THEN true
+    WHEN '' THEN COALESCE(current_setting('verisafe.tenancy', true), '') <> 'enforced'
+    ELSE false
+  END
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- Accounts belong to the tenant they were created in. Existing accounts and
+-- those created while tenancy is disabled belong to the default tenant
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS tenant_id text NOT NULL DEFAULT COALESCE(verisafe_current_tenant(), 'default');
+
+CREATE INDEX IF NOT EXISTS idx_accounts_tenant
+ON accounts (tenant_id);
+
+-- Emails are unique within a tenant, the same person may hold an account in
+-- several tenants
+ALTER TABLE accounts
+DROP CONSTRAINT IF EXISTS accounts_email_key;
+
+DROP INDEX IF EXISTS idx_accounts_email_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_tenant_email_lower
+ON accounts (tenant_id, lower(email));
+
+-- Row level security keeps the requests of a tenant from seeing or writing
+-- the accounts of another one. FORCE applies it to the owner of the tables,
+-- which Verisafe usually connects as
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON accounts
+  USING (verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant())
+  WITH CHECK (verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant());
+
+-- Records belonging to an account are isolated along with it. Institutions,
+-- roles, permissions, role templates, activities and leaderboard seasons have
+-- no tenant and are shared by every tenant, see docs/MULTI_TENANCY.md
+ALTER TABLE service_tokens ENABLE ROW LEVEL SECURITY;
+ALTER TABLE service_tokens FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON service_tokens
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = service_tokens.account_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE socials ENABLE ROW LEVEL SECURITY;
+ALTER TABLE socials FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON socials
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = socials.account_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE user_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE user_roles FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON user_roles
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = user_roles.user_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE institution_user_roles ENABLE ROW LEVEL SECURITY;
+ALTER TABLE institution_user_roles FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON institution_user_roles
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = institution_user_roles.user_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE account_institutions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE account_institutions FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON account_institutions
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = account_institutions.account_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE account_follows ENABLE ROW LEVEL SECURITY;
+ALTER TABLE account_follows FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON account_follows
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = account_follows.follower_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+ALTER TABLE leaderboard_season_standings ENABLE ROW LEVEL SECURITY;
+ALTER TABLE leaderboard_season_standings FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON leaderboard_season_standings
+  USING (verisafe_every_tenant_visible() OR EXISTS (
+    SELECT 1 FROM accounts a WHERE a.id = leaderboard_season_standings.account_id AND a.tenant_id = verisafe_current_tenant()
+  ));
+
+-- Materialized views are not covered by row level security, so accounts are
+-- ranked within their tenant and the leaderboard queries filter on it
+DROP MATERIALIZED VIEW IF EXISTS account_vibepoint_rank;
+
+CREATE MATERIALIZED VIEW account_vibepoint_rank AS
+SELECT
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (PARTITION BY tenant_id ORDER BY vibe_points DESC) AS vibe_rank,
+  tenant_id
+  FROM accounts
+WHERE accounts.type = 'human'
+  AND accounts.deactivated_at IS NULL
+WITH DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_vibepoint_rank_id
+ON account_vibepoint_rank (id);
+
+CREATE INDEX IF NOT EXISTS idx_account_vibepoint_rank_rank
+ON account_vibepoint_rank (tenant_id, vibe_rank, id);
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DROP MATERIALIZED VIEW IF EXISTS account_vibepoint_rank;
+
+CREATE MATERIALIZED VIEW account_vibepoint_rank AS
+SELECT
+  id,
+  email,
+  name,
+  username,
+  vibe_points,
+  avatar_url,
+  created_at,
+  updated_at,
+  RANK() OVER (ORDER BY vibe_points DESC) AS vibe_rank
+  FROM accounts
+WHERE accounts.type = 'human'
+  AND accounts.deactivated_at IS NULL
+WITH DATA;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_vibepoint_rank_id
+ON account_vibepoint_rank (id);
+
+CREATE INDEX IF NOT EXISTS idx_account_vibepoint_rank_rank
+ON account_vibepoint_rank (vibe_rank, id);
+
+DROP POLICY IF EXISTS tenant_isolation ON leaderboard_season_standings;
+ALTER TABLE leaderboard_season_standings NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE leaderboard_season_standings DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON account_follows;
+ALTER TABLE account_follows NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE account_follows DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON account_institutions;
+ALTER TABLE account_institutions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE account_institutions DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON institution_user_roles;
+ALTER TABLE institution_user_roles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE institution_user_roles DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON user_roles;
+ALTER TABLE user_roles NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE user_roles DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON socials;
+ALTER TABLE socials NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE socials DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON service_tokens;
+ALTER TABLE service_tokens NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE service_tokens DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS tenant_isolation ON accounts;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+
+-- Accounts of different tenants sharing an email must be merged manually
+-- before this migration can be rolled back
+DROP INDEX IF EXISTS idx_accounts_tenant_email_lower;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_email_lower
+ON accounts (lower(email));
+
+ALTER TABLE accounts
+ADD CONSTRAINT accounts_email_key UNIQUE (email);
+
+DROP INDEX IF EXISTS idx_accounts_tenant;
+ALTER TABLE accounts DROP COLUMN IF EXISTS tenant_id;
+DROP FUNCTION IF EXISTS verisafe_every_tenant_visible();
+DROP FUNCTION IF EXISTS verisafe_current_tenant();
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+-- Sensitive roles assigned within an institution need approval as well, the
+-- request then records the institution the role is assigned within
+ALTER TABLE role_assignment_requests
+ADD COLUMN IF NOT EXISTS institution_id INT REFERENCES institutions(institution_id) ON DELETE CASCADE;
+
+-- Only a single request may be pending for the same account and role
+-- globally and within each institution
+DROP INDEX IF EXISTS idx_role_assignment_requests_pending;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_assignment_requests_pending
+ON role_assignment_requests (user_id, role_id, COALESCE(institution_id, 0))
+WHERE status = 'pending';
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+DELETE FROM role_assignment_requests
+WHERE institution_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_role_assignment_requests_pending;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_assignment_requests_pending
+ON role_assignment_requests (user_id, role_id)
+WHERE status = 'pending';
+
+ALTER TABLE role_assignment_requests
+DROP COLUMN IF EXISTS institution_id;
@@ -0,0 +1,14 @@
+-- name: FollowAccount :execrows
+INSERT INTO account_follows (follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowAccount :execrows
+DELETE FROM account_follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowCounts :one
+-- Returns how many accounts follow an account and how many it follows
+SELECT
+  (SELECT count(*) FROM account_follows WHERE followee_id = @account_id::uuid) AS followers,
+  (SELECT count(*) FROM account_follows WHERE follower_id = @account_id::uuid) AS following;
@@ -0,0 +1,43 @@
+-- name: CreateGuardianLink :one
+INSERT INTO account_guardians (guardian_id, managed_id, relationship, restrictions, created_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetGuardianLink :one
+SELECT * FROM account_guardians
+WHERE guardian_id = $1 AND managed_id = $2;
+
+-- name: DeleteGuardianLink :execrows
+DELETE FROM account_guardians
+WHERE guardian_id = $1 AND managed_id = $2;
+
+-- name: UpdateGuardianRestrictions :one
+UPDATE account_guardians
+  SET
+    restrictions = $3,
+    updated_at = NOW()
+  WHERE guardian_id = $1 AND managed_id = $2
+RETURNING *;
+
+-- name: GetManagedAccounts :many
+-- Returns the accounts managed by a guardian
+SELECT sqlc.embed(a), g.relationship, g.restrictions
+FROM account_guardians g
+JOIN accounts a ON a.id = g.managed_id
+WHERE g.guardian_id = $1
+ORDER BY a.name;
+
+-- name: GetAccountGuardians :many
+-- Returns the guardians managing an account
+SELECT sqlc.embed(a), g.relationship, g.restrictions
+FROM account_guardians g
+JOIN accounts a ON a.id = g.guardian_id
+WHERE g.managed_id = $1
+ORDER BY a.name;
+
+-- name: IsAccountRestricted :one
+-- Reports whether any guardian of the account restricted the given action
+SELECT EXISTS (
+  SELECT 1 FROM account_guardians
+  WHERE managed_id = $1 AND @restriction::text = ANY(restrictions)
+);
@@ -0,0 +1,41 @@
+-- name: GetAccountCountsByType :many
+-- Returns the number of accounts for every account type, excluding
+-- accounts that are scheduled for deletion
+SELECT type, count(id) AS total
+FROM accounts
+WHERE deleted_at IS NULL
+GROUP BY type
+ORDER BY type;
+
+-- name: GetDailySignups :many
+-- Returns the number of human accounts created on each of the last @days days
+SELECT date_trunc('day', created_at)::date AS day, count(id) AS signups
+FROM accounts
+WHERE type = 'human'
+  AND created_at >= date_trunc('day', NOW()) - make_interval(days => @days::int)
+GROUP BY 1
+ORDER BY 1;
+
+-- name: GetWeeklySignups :many
+-- Returns the number of human accounts created during each of the last @weeks weeks
+SELECT date_trunc('week', created_at)::date AS week, count(id) AS signups
+FROM accounts
+WHERE type = 'human'
+  AND created_at >= date_trunc('week', NOW()) - make_interval(weeks => @weeks::int)
+GROUP BY 1
+ORDER BY 1;
+
+-- name: GetActiveAccountsCount :one
+-- Returns the number of accounts that signed in within the last @days days.
+-- A social connection is refreshed on every successful OAuth login so its
+-- updated_at doubles as the account's last login
+SELECT count(DISTINCT account_id)
+FROM socials
+WHERE updated_at >= NOW() - make_interval(days => @days::int);
+
+-- name: GetSocialProviderBreakdown :many
+-- Returns the number of accounts connected through each social provider
+SELECT provider, count(DISTINCT account_id) AS accounts
+FROM socials
+GROUP BY provider
+ORDER BY accounts DESC;
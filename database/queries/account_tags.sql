@@ -0,0 +1,20 @@
+-- name: AddAccountTag :exec
+INSERT INTO account_tags (account_id, tag, created_by)
+VALUES ($1, $2, $3)
+ON CONFLICT (account_id, tag) DO NOTHING;
+
+-- name: RemoveAccountTag :execrows
+DELETE FROM account_tags
+WHERE account_id = $1 AND tag = $2;
+
+-- name: GetAccountTags :many
+SELECT * FROM account_tags
+WHERE account_id = $1
+ORDER BY tag;
+
+-- name: GetAllAccountTags :many
+-- Returns every tag in use along with the number of accounts carrying it
+SELECT tag, count(account_id) AS account_count
+FROM account_tags
+GROUP BY tag
+ORDER BY tag;
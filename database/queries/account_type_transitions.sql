@@ -0,0 +1,12 @@
+-- name: CreateAccountTypeTransition :one
+INSERT INTO account_type_transitions (account_id, from_type, to_type, reason, performed_by)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetAccountTypeTransitions :many
+-- Returns the type transition history for an account, newest first
+SELECT * FROM account_type_transitions
+WHERE account_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
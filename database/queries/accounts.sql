@@ -5,46 +5,113 @@ RETURNING *;
 
 -- name: GetAllAccounts :many
 -- Returns only accounts of the 'human' type
-SELECT * FROM accounts WHERE type = 'human' 
+SELECT * FROM accounts WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL
 LIMIT $1
 OFFSET $2;
 
+-- name: ListAccountsAfter :many
+-- Returns human accounts newest first, continuing after the account a
+-- cursor points at. Both cursor values are null for the first page
+SELECT * FROM accounts
+WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
+
 -- name: GetAccountByID :one
 SELECT * FROM accounts 
 WHERE id = $1;
 
 -- name: SearchAccountByEmail :many
 SELECT * FROM accounts 
-WHERE lower(email) LIKE '%' || lower(@email::varchar) || '%'
+WHERE (
+    lower(email) LIKE '%' || lower(@email::varchar) || '%'
+    OR lower(@email::varchar) <% lower(email)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    sqlc.narg(tag)::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower(sqlc.narg(tag)::varchar))
+  )
+ORDER BY
+  lower(email) = lower(@email::varchar) DESC,
+  word_similarity(lower(@email::varchar), lower(email)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 ;
 
 -- name: GetAccountByEmail :one
+-- Emails are unique within a tenant. Connections seeing every tenant get the
+-- account of the default tenant first
 SELECT * FROM accounts 
 WHERE lower(email) = lower(@email::varchar)
+  AND tenant_id = COALESCE(verisafe_current_tenant(), tenant_id)
+ORDER BY tenant_id = 'default' DESC, created_at
 LIMIT 1
 ;
 
 
+-- name: ListAccountsByEmails :many
+-- Returns the ids of the accounts owning lower cased emails, used by bulk
+-- imports to look up a batch of emails at once
+SELECT DISTINCT ON (lower(email)) id, lower(email)::varchar AS email
+FROM accounts
+WHERE lower(email) = ANY(@emails::varchar[])
+ORDER BY lower(email), created_at;
+
+
 -- name: GetAccountByUsername :one
 SELECT * FROM accounts WHERE lower(username) = lower(@username::varchar);
 
 -- name: SearchAccountByName :many
 SELECT * FROM accounts 
-WHERE lower(name) LIKE '%' || lower(@name::varchar) || '%'
+WHERE (
+    to_tsvector('simple', name) @@ plainto_tsquery('simple', @name::varchar)
+    OR lower(name) LIKE '%' || lower(@name::varchar) || '%'
+    OR lower(@name::varchar) <% lower(name)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    sqlc.narg(tag)::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower(sqlc.narg(tag)::varchar))
+  )
+ORDER BY
+  lower(name) = lower(@name::varchar) DESC,
+  ts_rank(to_tsvector('simple', name), plainto_tsquery('simple', @name::varchar))
+    + word_similarity(lower(@name::varchar), lower(name)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 ;
 
 -- name: SearchAccountByUsername :many
 SELECT * FROM accounts 
-WHERE lower(username) LIKE '%' || lower(@username::varchar) || '%'
+WHERE (
+    lower(username) LIKE '%' || lower(@username::varchar) || '%'
+    OR lower(@username::varchar) <% lower(username)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    sqlc.narg(tag)::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower(sqlc.narg(tag)::varchar))
+  )
+ORDER BY
+  lower(username) = lower(@username::varchar) DESC,
+  word_similarity(lower(@username::varchar), lower(username)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 ;
 
--- name: UpdateAccountDetails :exec
+-- name: UpdateAccountDetails :execrows
+-- Updates the details of an account and bumps its version. When a version
+-- is given the account is only updated if it is still at that version, so
+-- no rows are affected when someone else updated it in the meantime
 UPDATE accounts
   SET
     username = COALESCE(NULLIF(@username::varchar,''), username),
@@ -55,8 +122,10 @@ UPDATE accounts
     national_id = COALESCE(NULLIF(@national_id::varchar,''), national_id),
     avatar_url = COALESCE(NULLIF(@avatar_url::text,''), avatar_url),
     bio = COALESCE(NULLIF(@bio::text,''), bio),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
   WHERE id = $1
+  AND (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version))
   ;
 
 
@@ -65,13 +134,14 @@ UPDATE accounts
 UPDATE accounts
   SET
     phone = COALESCE(NULLIF(@phone::varchar,''), phone),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
   WHERE id = $1
   ;
 
 -- name: GetAccountsCount :one
 -- Returns the number of all human accounts in the system
-SELECT count(id) FROM accounts WHERE type = 'human';
+SELECT count(id) FROM accounts WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL;
 
 
 -- name: MarkAccountForDeletion :exec
@@ -85,7 +155,7 @@ UPDATE accounts
 
 
 
--- name: MarkAccountForRecovery :exec
+-- name: MarkAccountForRecovery :execrows
 -- Recovers an account from scheduled deletion
 UPDATE accounts
   SET
@@ -93,3 +163,64 @@ UPDATE accounts
   WHERE 
     id = $1
   AND deleted_at IS NOT NULL;
+
+
+-- name: ListDeletedAccounts :many
+-- Returns the accounts marked for deletion, most recently marked first
+SELECT * FROM accounts
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1
+OFFSET $2;
+
+
+-- name: CountDeletedAccounts :one
+SELECT count(id) FROM accounts WHERE deleted_at IS NOT NULL;
+
+
+-- name: UpdateAccountType :one
+-- Changes the type of an account. Callers are expected to have validated
+-- the transition beforehand and to record it in account_type_transitions
+UPDATE accounts
+  SET
+    type = @type::account_type,
+    updated_at = NOW(),
+    version = version + 1
+  WHERE id = $1
+RETURNING *;
+
+
+-- name: DeactivateAccount :exec
+-- Deactivates an account hiding it from searches and leaderboards
+-- until the owner signs in again
+UPDATE accounts
+  SET
+    deactivated_at = NOW(),
+    updated_at = NOW()
+  WHERE id = $1
+  AND deactivated_at IS NULL;
+
+-- name: ReactivateAccount :exec
+-- Reactivates a previously deactivated account
+UPDATE accounts
+  SET
+    deactivated_at = NULL,
+    updated_at = NOW()
+  WHERE id = $1
+  AND deactivated_at IS NOT NULL;
+
+-- name: SyncAccountDetails :one
+-- Applies account details another service emitted. Details that are not
+-- provided are left untouched
+UPDATE accounts
+  SET
+    email = COALESCE(NULLIF(@email::varchar, ''), email),
+    name = COALESCE(NULLIF(@name::varchar, ''), name),
+    username = COALESCE(sqlc.narg(username)::varchar, username),
+    avatar_url = COALESCE(sqlc.narg(avatar_url)::text, avatar_url),
+    phone = COALESCE(sqlc.narg(phone)::varchar, phone),
+    national_id = COALESCE(sqlc.narg(national_id)::varchar, national_id),
+    updated_at = NOW(),
+    version = version + 1
+  WHERE id = $1
+RETURNING *;
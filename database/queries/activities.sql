@@ -8,43 +8,54 @@ INSERT INTO activities (
   category,
   points_awarded, 
   max_daily_completions, 
-  streak_eligible
-) VALUES ( $1, $2, $3, $4, $5, $6 )
+  streak_eligible,
+  cooldown_seconds
+) VALUES ( $1, $2, $3, $4, $5, $6, $7 )
 RETURNING *;
 
 
 -- name: GetActivityByID :one
 -- Returns an activity specified by its id
-SELECT *  FROM activities WHERE id = $1
+SELECT *  FROM activities WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1;
 
 -- name: GetAllActivities :many
 -- Returns all the activities in the system paginated using the 
 -- limit-offset schme
-SELECT * FROM activities LIMIT $1 OFFSET $2;
+SELECT * FROM activities WHERE deleted_at IS NULL LIMIT $1 OFFSET $2;
 
 -- name: GetAllActiveActivities :many
 -- Returns all the active activities in the system paginated using the 
 -- limit-offset schme
-SELECT * FROM activities WHERE is_active = true LIMIT $1 OFFSET $2;
+SELECT * FROM activities WHERE is_active = true AND deleted_at IS NULL LIMIT $1 OFFSET $2;
 
 
 -- name: GetAllInactiveActivities :many
 -- Returns all the inactive activities in the system paginated using the 
 -- limit-offset schme
-SELECT * FROM activities WHERE is_active = false LIMIT $1 OFFSET $2;
+SELECT * FROM activities WHERE is_active = false AND deleted_at IS NULL LIMIT $1 OFFSET $2;
+
+-- name: ListActivitiesAfter :many
+-- Returns all activities newest first, continuing after the activity a
+-- cursor points at. Both cursor values are null for the first page
+SELECT * FROM activities
+WHERE deleted_at IS NULL
+  AND (sqlc.narg(after_created_at)::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(page_size);
 
 -- name: GetAllActivitiesCount :one
 -- Returns all activities count regardless of activity status
-SELECT COUNT(id) FROM activities;
+SELECT COUNT(id) FROM activities WHERE deleted_at IS NULL;
 
 -- name: GetAllActiveActivitiesCount :one
 -- Returns all the active activities count in the system
-SELECT COUNT(id) FROM activities WHERE is_active = true;
+SELECT COUNT(id) FROM activities WHERE is_active = true AND deleted_at IS NULL;
 
 -- name: GetAllInactiveActivitiesCount :one
 -- Returns all the inactive activities count in the system
-SELECT COUNT(id) FROM activities WHERE is_active = false;
+SELECT COUNT(id) FROM activities WHERE is_active = false AND deleted_at IS NULL;
 
 -- name: UpdateActivity :one
 -- Updates an activity specified by its ID
@@ -57,19 +68,44 @@ UPDATE activities
     max_daily_completions = COALESCE(NULLIF(@max_daily_completions::smallint,0), max_daily_completions),
     streak_eligible = COALESCE(NULLIF(@streak_eligible::boolean,false), streak_eligible),
     is_active = COALESCE(NULLIF(@is_active::boolean,false), is_active),
+    cooldown_seconds = COALESCE(sqlc.narg(cooldown_seconds)::integer, cooldown_seconds),
     updated_at = NOW()
-  WHERE id = $1
+  WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteActivity :exec
-DELETE FROM activities
-WHERE id = $1;
+-- Marks an activity deleted and deactivates it so that it can no longer be
+-- completed. Its completions are kept
+UPDATE activities
+  SET deleted_at = NOW(),
+    is_active = false,
+    updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: ListDeletedActivities :many
+-- Returns the deleted activities, most recently deleted first
+SELECT * FROM activities
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1 OFFSET $2;
+
+-- name: CountDeletedActivities :one
+SELECT COUNT(id) FROM activities WHERE deleted_at IS NOT NULL;
+
+-- name: RestoreActivity :one
+-- Restores a deleted activity. It stays inactive until it is activated again
+UPDATE activities
+  SET deleted_at = NULL,
+    updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
 
 
 -- name: GetAllUserActivityCompletions :many
 -- Returns activity a certain user specified by their id has completed ordered 
 -- from the most recent to the oldest
 SELECT * FROM activity_completions WHERE account_id = $1
+ORDER BY completed_at DESC, id DESC
 LIMIT $2 OFFSET $3;
 
 
@@ -77,3 +113,36 @@ LIMIT $2 OFFSET $3;
 -- Returns the number of record that have been done on the user's completed
 -- activities
 SELECT count(id) FROM activity_completions WHERE account_id = $1;
+
+
+-- name: CreateActivityCompletionPartitions :one
+-- Creates the monthly partitions of activity completions up to
+-- @months_ahead months after the current one and returns how many were
+-- created
+SELECT create_activity_completion_partitions(CURRENT_DATE, @months_ahead::int)::int AS created;
+
+
+-- name: DropExpiredActivityCompletionPartitions :many
+-- Drops the monthly partitions of activity completions made before the last
+-- @retention_months whole months and returns their names
+SELECT dropped::text
+FROM drop_activity_completion_partitions(
+  (date_trunc('month', CURRENT_DATE) - make_interval(months => @retention_months::int))::date
+) AS dropped;
+
+
+-- name: ArchiveActivityCompletionPartitions :many
+-- Moves the monthly partitions of activity completions made before the last
+-- @archive_after_months whole months to the archive and returns them with
+-- their number of completions
+SELECT archived_partition::text, archived_rows::bigint
+FROM archive_activity_completion_partitions(
+  (date_trunc('month', CURRENT_DATE) - make_interval(months => @archive_after_months::int))::date
+);
+
+
+-- name: PruneArchivedActivityCompletions :execrows
+-- Deletes the archived completions made before the last @retention_months
+-- whole months
+DELETE FROM activity_completions_archive
+WHERE completed_at < date_trunc('month', CURRENT_DATE) - make_interval(months => @retention_months::int);
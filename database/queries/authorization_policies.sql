@@ -0,0 +1,41 @@
+-- name: CreateAuthorizationPolicy :one
+-- Creates an attribute based authorization policy
+INSERT INTO authorization_policies (
+  name, description, permission, conditions, is_active
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+
+-- name: GetAuthorizationPolicyByID :one
+SELECT * FROM authorization_policies
+WHERE id = $1;
+
+
+-- name: GetAllAuthorizationPolicies :many
+SELECT * FROM authorization_policies
+ORDER BY name;
+
+
+-- name: GetActiveAuthorizationPolicies :many
+-- Retrieves the policies the authorization engine evaluates
+SELECT * FROM authorization_policies
+WHERE is_active = TRUE
+ORDER BY name;
+
+
+-- name: UpdateAuthorizationPolicy :one
+UPDATE authorization_policies
+  SET
+    name = COALESCE(sqlc.narg(name), name),
+    description = COALESCE(sqlc.narg(description), description),
+    permission = COALESCE(sqlc.narg(permission), permission),
+    conditions = COALESCE(sqlc.narg(conditions), conditions),
+    is_active = COALESCE(sqlc.narg(is_active), is_active),
+    updated_at = NOW()
+  WHERE id = $1
+RETURNING *;
+
+
+-- name: DeleteAuthorizationPolicy :execrows
+DELETE FROM authorization_policies
+WHERE id = $1;
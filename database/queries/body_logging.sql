@@ -0,0 +1,24 @@
+-- name: UpsertBodyLoggingRule :one
+-- Turns on the logging of the bodies of a route, replacing the rule already
+-- in place for the route
+INSERT INTO body_logging_rules (route, sample_rate, expires_at, created_by)
+VALUES (@route, @sample_rate, NOW() + make_interval(mins => @duration_minutes::int), @created_by)
+ON CONFLICT (route) DO UPDATE
+SET sample_rate = EXCLUDED.sample_rate,
+    expires_at = EXCLUDED.expires_at,
+    created_by = EXCLUDED.created_by,
+    created_at = NOW()
+RETURNING *;
+
+
+-- name: ListActiveBodyLoggingRules :many
+-- Returns the rules that have not expired yet
+SELECT * FROM body_logging_rules
+WHERE expires_at > NOW()
+ORDER BY route;
+
+
+-- name: DeleteBodyLoggingRule :execrows
+-- Turns off the logging of the bodies of a route
+DELETE FROM body_logging_rules
+WHERE id = $1;
@@ -0,0 +1,41 @@
+-- name: CreateCustomRole :one
+-- Creates a custom role managed by the admins of an institution
+INSERT INTO roles (
+  name, description, institution_id, is_custom
+) VALUES ( $1, $2, $3, TRUE )
+RETURNING *;
+
+
+-- name: GetInstitutionCustomRoles :many
+-- Retrieves the custom roles of an institution
+SELECT * FROM roles
+WHERE institution_id = $1 AND is_custom = TRUE AND deleted_at IS NULL
+ORDER BY name;
+
+
+-- name: GetInstitutionCustomRole :one
+SELECT * FROM roles
+WHERE id = $1 AND institution_id = $2 AND is_custom = TRUE AND deleted_at IS NULL;
+
+
+-- name: CountInstitutionCustomRoles :one
+SELECT COUNT(*) FROM roles
+WHERE institution_id = $1 AND is_custom = TRUE AND deleted_at IS NULL;
+
+
+-- name: GetCustomRolePermissionAllowlist :many
+-- Returns the permissions institution admins may grant through custom roles
+SELECT p.* FROM permissions p
+JOIN custom_role_permission_allowlist a ON a.permission_id = p.id
+ORDER BY p.name;
+
+
+-- name: AllowCustomRolePermission :execrows
+INSERT INTO custom_role_permission_allowlist (permission_id)
+VALUES ($1)
+ON CONFLICT DO NOTHING;
+
+
+-- name: DisallowCustomRolePermission :execrows
+DELETE FROM custom_role_permission_allowlist
+WHERE permission_id = $1;
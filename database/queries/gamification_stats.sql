@@ -0,0 +1,74 @@
+-- name: GetGamificationParticipantsCount :one
+-- Returns the number of accounts that completed at least one activity during
+-- the last @days days
+SELECT count(DISTINCT account_id)
+FROM activity_completions
+WHERE completed_at > CURRENT_DATE - @days::int
+  AND completion_date > CURRENT_DATE - @days::int;
+
+-- name: GetDailyActiveParticipants :many
+-- Returns the number of accounts that completed at least one activity on each
+-- of the last @days days
+SELECT completion_date AS day, count(DISTINCT account_id) AS participants
+FROM activity_completions
+WHERE completed_at > CURRENT_DATE - @days::int
+  AND completion_date > CURRENT_DATE - @days::int
+GROUP BY completion_date
+ORDER BY completion_date;
+
+-- name: GetActivityCompletionCounts :many
+-- Returns how often every activity was completed during the last @days days,
+-- by how many accounts and the points it earned them
+SELECT a.id AS activity_id,
+       a.name,
+       a.is_active,
+       count(c.id) AS completions,
+       count(DISTINCT c.account_id) AS participants,
+       COALESCE(sum(c.points_earned), 0)::bigint AS points_earned
+FROM activities a
+LEFT JOIN activity_completions c
+  ON c.activity_id = a.id
+ AND c.completed_at > CURRENT_DATE - @days::int
+ AND c.completion_date > CURRENT_DATE - @days::int
+GROUP BY a.id, a.name, a.is_active
+ORDER BY completions DESC, a.name;
+
+-- name: GetStreakSummary :one
+-- Summarizes the streaks of every account. A streak is active when the
+-- activity was completed today or yesterday, averages of current streaks only
+-- consider active streaks
+SELECT count(id) AS tracked_streaks,
+       count(id) FILTER (
+         WHERE current_streak > 0 AND last_completion_date >= CURRENT_DATE - 1
+       ) AS active_streaks,
+       COALESCE(avg(current_streak) FILTER (
+         WHERE current_streak > 0 AND last_completion_date >= CURRENT_DATE - 1
+       ), 0)::float8 AS average_current_streak,
+       COALESCE(avg(longest_streak), 0)::float8 AS average_longest_streak,
+       COALESCE(max(longest_streak), 0)::smallint AS longest_streak
+FROM user_streaks;
+
+-- name: GetMilestoneAttainmentRates :many
+-- Returns for every streak milestone how many accounts reached it out of the
+-- accounts that have a streak on its activity
+WITH achieved AS (
+  SELECT streak_milestone_id, count(id) AS achieved
+  FROM user_streak_achievements
+  GROUP BY streak_milestone_id
+), eligible AS (
+  SELECT activity_id, count(id) AS eligible
+  FROM user_streaks
+  GROUP BY activity_id
+)
+SELECT sm.id AS milestone_id,
+       sm.title,
+       sm.activity_id,
+       sm.days_required,
+       sm.is_active,
+       COALESCE(a.achieved, 0)::bigint AS achieved,
+       COALESCE(e.eligible, 0)::bigint AS eligible,
+       COALESCE(a.achieved::float8 / NULLIF(e.eligible, 0), 0)::float8 AS attainment_rate
+FROM streak_milestones sm
+LEFT JOIN achieved a ON a.streak_milestone_id = sm.id
+LEFT JOIN eligible e ON e.activity_id = sm.activity_id
+ORDER BY sm.activity_id, sm.days_required;
@@ -1,9 +1,10 @@
 
 -- name: CreateInstitution :one
 INSERT INTO institutions (
-    name, web_pages, domains, alpha_two_code, country, state_province
+    name, web_pages, domains, alpha_two_code, country, state_province,
+    logo_url, primary_color, secondary_color, website, timezone, metadata
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
 RETURNING *;
 
@@ -13,6 +14,7 @@ WHERE institution_id = $1 LIMIT 1;
 
 -- name: ListInstitutions :many
 SELECT * FROM institutions
+WHERE archived_at IS NULL
 ORDER BY institution_id LIMIT $1 OFFSET $2;
 
 -- name: UpdateInstitution :one
@@ -23,7 +25,13 @@ SET
     domains = COALESCE(NULLIF(@domains::text[], '{}'), domains),
     alpha_two_code = COALESCE(NULLIF(@alpha_two_code::char(2), ''), alpha_two_code),
     country = COALESCE(NULLIF(@country::varchar, ''), country),
-    state_province = COALESCE(NULLIF(@state_province::varchar, ''), state_province)
+    state_province = COALESCE(NULLIF(@state_province::varchar, ''), state_province),
+    logo_url = COALESCE(NULLIF(@logo_url::text, ''), logo_url),
+    primary_color = COALESCE(NULLIF(@primary_color::text, ''), primary_color),
+    secondary_color = COALESCE(NULLIF(@secondary_color::text, ''), secondary_color),
+    website = COALESCE(NULLIF(@website::text, ''), website),
+    timezone = COALESCE(NULLIF(@timezone::text, ''), timezone),
+    metadata = COALESCE(sqlc.narg(metadata), metadata)
 WHERE institution_id = @institution_id
 RETURNING *;
 
@@ -31,21 +39,56 @@ RETURNING *;
 DELETE FROM institutions
 WHERE institution_id = $1;
 
+-- name: ArchiveInstitution :one
+UPDATE institutions
+SET archived_at = NOW()
+WHERE institution_id = $1 AND archived_at IS NULL
+RETURNING *;
+
+-- name: RestoreInstitution :one
+UPDATE institutions
+SET archived_at = NULL
+WHERE institution_id = $1 AND archived_at IS NOT NULL
+RETURNING *;
+
+-- name: ListArchivedInstitutions :many
+-- Retrieves the archived institutions, most recently archived first
+SELECT * FROM institutions
+WHERE archived_at IS NOT NULL
+ORDER BY archived_at DESC, institution_id
+LIMIT $1 OFFSET $2;
+
+-- name: CountArchivedInstitutions :one
+SELECT count(*) FROM institutions
+WHERE archived_at IS NOT NULL;
+
+-- name: UnlinkInstitutionAccounts :execrows
+-- Removes every account from an institution
+DELETE FROM account_institutions
+WHERE institution_id = $1;
+
 
 -- name: SearchInstitutionsByName :many
 SELECT *
 FROM institutions
 WHERE lower(name) LIKE '%' || lower(@name::varchar) || '%'
+  AND archived_at IS NULL
 ORDER BY name
 LIMIT $1 OFFSET $2;
 
+-- name: CountInstitutionsByName :one
+-- Returns the number of institutions that are not archived matching a name
+SELECT count(*) FROM institutions
+WHERE lower(name) LIKE '%' || lower(@name::varchar) || '%'
+  AND archived_at IS NULL;
+
 
 
 
 -- name: AddAccountInstitution :one
 WITH ins AS (
-  INSERT INTO account_institutions (account_id, institution_id)
-  VALUES ($1, $2)
+  INSERT INTO account_institutions (account_id, institution_id, membership_role)
+  VALUES ($1, $2, $3)
   ON CONFLICT DO NOTHING
   RETURNING *
 )
@@ -68,6 +111,11 @@ ORDER BY i.name
 LIMIT $2
 OFFSET $3;
 
+-- name: CountInstitutionsForAccount :one
+-- Returns the number of institutions an account belongs to
+SELECT count(*) FROM account_institutions
+WHERE account_id = $1;
+
 -- name: ListAccountsForInstitution :many
 SELECT a.*
 FROM accounts a
@@ -79,5 +127,72 @@ OFFSET $3;
 
 
 -- name: GetInstitutionsCount :one
--- Returns the number of all institutions in the system
-SELECT count(*) from institutions;
+-- Returns the number of institutions in the system that are not archived
+SELECT count(*) from institutions
+WHERE archived_at IS NULL;
+
+
+-- name: IsAccountInInstitution :one
+-- Reports whether an account is a member of an institution
+SELECT EXISTS (
+  SELECT 1 FROM account_institutions
+  WHERE account_id = $1 AND institution_id = $2
+);
+
+
+-- name: GetInstitutionMembership :one
+-- Returns the membership of an account in an institution
+SELECT * FROM account_institutions
+WHERE account_id = $1 AND institution_id = $2;
+
+
+-- name: UpdateInstitutionMembershipRole :one
+-- Changes the membership role of an account within an institution
+UPDATE account_institutions
+SET membership_role = $3
+WHERE account_id = $1 AND institution_id = $2
+RETURNING *;
+
+
+-- name: CountInstitutionOwners :one
+-- Returns the number of owners of an institution
+SELECT count(*) FROM account_institutions
+WHERE institution_id = $1 AND membership_role = 'owner';
+
+
+-- name: ListInstitutionMembers :many
+-- Returns the members of an institution ranked by membership role
+SELECT a.id AS account_id, a.name, a.username, a.avatar_url, ai.membership_role
+FROM accounts a
+JOIN account_institutions ai ON a.id = ai.account_id
+WHERE ai.institution_id = $1
+ORDER BY ai.membership_role, a.name
+LIMIT $2
+OFFSET $3;
+
+-- name: CountInstitutionMembers :one
+-- Returns the number of accounts linked to an institution
+SELECT count(*) FROM account_institutions
+WHERE institution_id = $1;
+
+
+-- name: GetInstitutionManagers :many
+-- Returns the accounts that administer an institution
+SELECT account_id FROM account_institutions
+WHERE institution_id = $1
+  AND membership_role IN ('owner', 'admin');
+
+
+-- name: ListInstitutionMemberIDs :many
+-- Returns which of the accounts are already members of an institution
+SELECT account_id FROM account_institutions
+WHERE institution_id = @institution_id
+  AND account_id = ANY(@account_ids::uuid[]);
+
+
+-- name: AddAccountInstitutions :execrows
+-- Links accounts to an institution as members in a single statement, COPY
+-- is refused on tables under row level security. Unlike
+-- AddAccountInstitution it fails on accounts that are already members
+INSERT INTO account_institutions (account_id, institution_id)
+SELECT unnest(@account_ids::uuid[]), @institution_id::int;
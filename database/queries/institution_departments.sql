@@ -0,0 +1,62 @@
+-- name: CreateInstitutionDepartment :one
+INSERT INTO institution_departments (
+  institution_id, name, description
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+
+-- name: GetInstitutionDepartment :one
+SELECT * FROM institution_departments
+WHERE id = $1 AND institution_id = $2;
+
+
+-- name: ListInstitutionDepartments :many
+SELECT * FROM institution_departments
+WHERE institution_id = $1
+ORDER BY name;
+
+
+-- name: UpdateInstitutionDepartment :one
+UPDATE institution_departments
+SET
+  name = COALESCE(sqlc.narg(name), name),
+  description = COALESCE(sqlc.narg(description), description),
+  updated_at = NOW()
+WHERE id = @id AND institution_id = @institution_id
+RETURNING *;
+
+
+-- name: DeleteInstitutionDepartment :execrows
+DELETE FROM institution_departments
+WHERE id = $1 AND institution_id = $2;
+
+
+-- name: AddDepartmentMember :exec
+INSERT INTO department_members (department_id, account_id, institution_id)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+
+-- name: RemoveDepartmentMember :execrows
+DELETE FROM department_members
+WHERE department_id = $1 AND account_id = $2;
+
+
+-- name: ListDepartmentMembers :many
+-- Returns the members of a department along with their institution
+-- membership role
+SELECT a.id AS account_id, a.name, a.username, a.avatar_url, ai.membership_role
+FROM department_members dm
+JOIN accounts a ON a.id = dm.account_id
+JOIN account_institutions ai
+  ON ai.account_id = dm.account_id AND ai.institution_id = dm.institution_id
+WHERE dm.department_id = $1
+ORDER BY a.name
+LIMIT $2
+OFFSET $3;
+
+
+-- name: CountDepartmentMembers :one
+-- Returns the number of members of a department
+SELECT count(*) FROM department_members
+WHERE department_id = $1;
@@ -0,0 +1,40 @@
+-- name: CreateInstitutionEmailDomain :one
+INSERT INTO institution_email_domains (
+  institution_id, domain, verification_token, created_by
+) VALUES ( $1, $2, $3, $4 )
+RETURNING *;
+
+
+-- name: GetInstitutionEmailDomainByID :one
+SELECT * FROM institution_email_domains
+WHERE id = $1;
+
+
+-- name: GetInstitutionEmailDomains :many
+SELECT * FROM institution_email_domains
+WHERE institution_id = $1
+ORDER BY domain;
+
+
+-- name: VerifyInstitutionEmailDomain :one
+UPDATE institution_email_domains
+SET verified_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+
+-- name: DeleteInstitutionEmailDomain :execrows
+DELETE FROM institution_email_domains
+WHERE id = $1 AND institution_id = $2;
+
+
+-- name: JoinInstitutionsByEmailDomain :many
+-- Links an account as a plain member of every institution that verified the
+-- domain and returns the institutions it was newly linked to
+INSERT INTO account_institutions (account_id, institution_id, membership_role)
+SELECT $1, d.institution_id, 'member'
+FROM institution_email_domains d
+JOIN institutions i ON i.institution_id = d.institution_id
+WHERE d.domain = $2 AND d.verified_at IS NOT NULL AND i.archived_at IS NULL
+ON CONFLICT DO NOTHING
+RETURNING institution_id;
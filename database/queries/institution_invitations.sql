@@ -0,0 +1,82 @@
+-- name: CreateInstitutionInvitation :one
+INSERT INTO institution_invitations (
+  institution_id, email, membership_role, invited_by, expires_at
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+
+-- name: GetInstitutionInvitationByID :one
+SELECT * FROM institution_invitations
+WHERE id = $1;
+
+
+-- name: GetPendingInstitutionInvitations :many
+-- Returns the invitations of an institution that can still be accepted
+SELECT * FROM institution_invitations
+WHERE institution_id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+
+-- name: RevokePendingInstitutionInvitations :exec
+-- Revokes the invitations still pending for an email so that a new
+-- invitation replaces them
+UPDATE institution_invitations
+SET revoked_at = NOW()
+WHERE institution_id = $1
+  AND email = $2
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+
+-- name: RevokeInstitutionInvitations :exec
+-- Revokes every invitation of an institution that is still pending
+UPDATE institution_invitations
+SET revoked_at = NOW()
+WHERE institution_id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+
+-- name: RevokeInstitutionInvitation :execrows
+UPDATE institution_invitations
+SET revoked_at = NOW()
+WHERE id = $1
+  AND institution_id = $2
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+
+-- name: AcceptInstitutionInvitation :one
+-- Marks an invitation as accepted provided it is still pending
+UPDATE institution_invitations
+SET accepted_at = NOW(),
+  accepted_by = $2
+WHERE id = $1
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING *;
+
+
+-- name: RevokePendingInstitutionInvitationsForEmails :exec
+-- Revokes the invitations still pending for a batch of emails so that new
+-- invitations replace them
+UPDATE institution_invitations
+SET revoked_at = NOW()
+WHERE institution_id = @institution_id
+  AND email = ANY(@emails::varchar[])
+  AND accepted_at IS NULL
+  AND revoked_at IS NULL;
+
+
+-- name: CreateInstitutionInvitations :copyfrom
+-- Invites a batch of emails as members with COPY. Ids are chosen by the
+-- caller since COPY returns no rows
+INSERT INTO institution_invitations (
+  id, institution_id, email, invited_by, expires_at
+) VALUES ( $1, $2, $3, $4, $5 );
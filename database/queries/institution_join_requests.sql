@@ -0,0 +1,33 @@
+-- name: CreateInstitutionJoinRequest :one
+INSERT INTO institution_join_requests (
+  institution_id, account_id, message
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+
+-- name: GetInstitutionJoinRequestByID :one
+SELECT * FROM institution_join_requests
+WHERE id = $1;
+
+
+-- name: GetInstitutionJoinRequests :many
+-- Returns the join requests of an institution newest first optionally
+-- filtered by status
+SELECT * FROM institution_join_requests
+WHERE institution_id = sqlc.arg('institution_id')
+  AND (sqlc.narg(status)::institution_join_request_status IS NULL OR status = sqlc.narg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+
+-- name: ReviewInstitutionJoinRequest :one
+-- Settles a pending join request. Requests that were already reviewed are
+-- left untouched
+UPDATE institution_join_requests
+SET status = $2,
+  reviewed_by = $3,
+  review_note = $4,
+  reviewed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
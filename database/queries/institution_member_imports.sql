@@ -0,0 +1,34 @@
+-- name: CreateInstitutionMemberImport :one
+INSERT INTO institution_member_imports (
+  institution_id, requested_by, emails, total, errors
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+
+-- name: GetInstitutionMemberImport :one
+SELECT * FROM institution_member_imports
+WHERE id = $1;
+
+
+-- name: StartInstitutionMemberImport :exec
+UPDATE institution_member_imports
+SET status = 'running',
+  started_at = NOW()
+WHERE id = $1;
+
+
+-- name: UpdateInstitutionMemberImportProgress :exec
+UPDATE institution_member_imports
+SET processed = $2,
+  linked = $3,
+  invited = $4,
+  skipped = $5,
+  errors = $6
+WHERE id = $1;
+
+
+-- name: FinishInstitutionMemberImport :exec
+UPDATE institution_member_imports
+SET status = $2,
+  finished_at = NOW()
+WHERE id = $1;
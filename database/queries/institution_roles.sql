@@ -0,0 +1,39 @@
+-- name: AssignInstitutionRole :one
+-- Assigns a role to a user within a single institution
+INSERT INTO institution_user_roles (
+  user_id, role_id, institution_id
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+
+-- name: RevokeInstitutionRole :execrows
+-- Revokes an institution scoped role from a user
+DELETE FROM institution_user_roles
+WHERE user_id = $1 AND role_id = $2 AND institution_id = $3;
+
+
+-- name: GetUserInstitutionRoles :many
+-- Retrieves every institution scoped role a user has been granted
+SELECT
+  ur.institution_id,
+  i.name AS institution_name,
+  r.id AS role_id,
+  r.name AS role_name,
+  r.is_active AS role_is_active,
+  ur.created_at
+FROM institution_user_roles ur
+JOIN roles r ON r.id = ur.role_id
+JOIN institutions i ON i.institution_id = ur.institution_id
+WHERE ur.user_id = $1
+ORDER BY ur.institution_id, r.name;
+
+
+-- name: GetUserInstitutionPermissionNames :many
+-- Returns the permission names a user has been granted within an institution
+-- through active institution scoped roles
+SELECT DISTINCT p.name
+FROM institution_user_roles ur
+JOIN roles r ON r.id = ur.role_id AND r.is_active = TRUE
+JOIN role_permissions rp ON rp.role_id = r.id
+JOIN permissions p ON p.id = rp.permission_id
+WHERE ur.user_id = $1 AND ur.institution_id = $2;
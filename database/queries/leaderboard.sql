@@ -1,14 +1,47 @@
 -- name: GetLeaderboard :many
--- Get top N users ranked by vibe points
+-- Get top N users ranked by vibe points. Ranks are kept per tenant, only
+-- the accounts of the tenant being served are listed
 SELECT * FROM account_vibepoint_rank
+WHERE verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant()
+ORDER BY vibe_rank, id
 LIMIT $1 OFFSET $2;
 
 -- name: GetGlobalLeaderBoardCount :one
-SELECT COUNT(*) FROM account_vibepoint_rank;
+SELECT COUNT(*) FROM account_vibepoint_rank
+WHERE verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant();
 
 
 -- name: GetLeaderBoardRankForUser :one
 -- Get the rank for a certain user
 SELECT * FROM account_vibepoint_rank
 WHERE id = $1
+  AND (verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant())
 LIMIT 1 OFFSET 0;
+
+
+-- name: GetFriendsLeaderboard :many
+-- Get the accounts a user follows ranked by vibe points
+SELECT r.*, RANK() OVER (ORDER BY r.vibe_points DESC) AS friend_rank
+FROM account_vibepoint_rank r
+JOIN account_follows f ON f.followee_id = r.id
+WHERE f.follower_id = $1
+ORDER BY r.vibe_points DESC, r.name
+LIMIT $2 OFFSET $3;
+
+-- name: GetFriendsLeaderboardCount :one
+SELECT COUNT(*)
+FROM account_vibepoint_rank r
+JOIN account_follows f ON f.followee_id = r.id
+WHERE f.follower_id = $1;
+
+
+-- name: TryLockLeaderboardRefresh :one
+-- Takes a transaction scoped lock so that only one instance refreshes the
+-- leaderboard at a time. Returns false when another instance holds the lock
+SELECT pg_try_advisory_xact_lock(hashtext('leaderboard_refresh'))::boolean AS locked;
+
+
+-- name: RefreshLeaderboard :exec
+-- Recomputes the global ranks. Readers keep seeing the previous ranks until
+-- the refresh commits
+REFRESH MATERIALIZED VIEW CONCURRENTLY account_vibepoint_rank;
@@ -0,0 +1,26 @@
+-- name: CreateLeaderboardLiveTicket :one
+INSERT INTO leaderboard_live_tickets (ticket_hash, account_id, expires_at)
+VALUES (@ticket_hash, @account_id, NOW() + make_interval(secs => @ttl_seconds::int))
+RETURNING expires_at;
+
+-- name: RedeemLeaderboardLiveTicket :one
+-- Consumes a ticket that has not expired yet and returns the account it was
+-- issued to
+DELETE FROM leaderboard_live_tickets
+WHERE ticket_hash = @ticket_hash AND expires_at > NOW()
+RETURNING account_id;
+
+-- name: PruneLeaderboardLiveTickets :execrows
+DELETE FROM leaderboard_live_tickets
+WHERE expires_at <= NOW();
+
+-- name: ListLiveLeaderboardPositions :many
+-- Returns the global rank of the accounts within the top ranks and of the
+-- given accounts
+SELECT id AS account_id,
+       vibe_rank,
+       vibe_points
+FROM account_vibepoint_rank
+WHERE vibe_rank <= @top::bigint
+   OR id = ANY(@account_ids::uuid[])
+ORDER BY vibe_rank;
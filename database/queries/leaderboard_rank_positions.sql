@@ -0,0 +1,70 @@
+-- name: TryLockLeaderboardRankWatch :one
+-- Takes a transaction scoped lock so that only one instance compares ranks at
+-- a time. Returns false when another instance holds the lock
+SELECT pg_try_advisory_xact_lock(hashtext('leaderboard_rank_watch'))::boolean AS locked;
+
+-- name: CountLeaderboardRankPositions :one
+SELECT COUNT(*) FROM leaderboard_rank_positions;
+
+-- name: ListLeaderboardTopEntries :many
+-- Returns the accounts that are within the top ranks now but were not when
+-- ranks were last recorded
+SELECT r.id AS account_id,
+       p.vibe_rank AS old_rank,
+       r.vibe_rank AS new_rank,
+       r.vibe_points
+FROM account_vibepoint_rank r
+LEFT JOIN leaderboard_rank_positions p ON p.account_id = r.id
+WHERE r.vibe_rank <= @top::bigint
+  AND (p.vibe_rank IS NULL OR p.vibe_rank > @top::bigint)
+ORDER BY r.vibe_rank;
+
+-- name: ListLeaderboardOvertakes :many
+-- Returns every account that was overtaken since ranks were last recorded
+-- together with the closest rival that was behind it and is now ahead of it.
+-- When friends_only is set only accounts the overtaken account follows are
+-- considered rivals
+WITH changes AS (
+  SELECT r.id AS account_id,
+         p.vibe_rank AS old_rank,
+         r.vibe_rank AS new_rank,
+         r.vibe_points
+  FROM account_vibepoint_rank r
+  JOIN leaderboard_rank_positions p ON p.account_id = r.id
+), climbers AS (
+  SELECT account_id, old_rank, new_rank, vibe_points FROM changes WHERE new_rank < old_rank
+)
+SELECT DISTINCT ON (a.account_id)
+       a.account_id,
+       a.old_rank,
+       a.new_rank,
+       a.vibe_points,
+       c.account_id AS rival_id,
+       c.old_rank AS rival_old_rank,
+       c.new_rank AS rival_new_rank,
+       c.vibe_points AS rival_vibe_points
+FROM changes a
+JOIN climbers c ON c.old_rank > a.old_rank AND c.new_rank < a.new_rank
+WHERE NOT @friends_only::boolean
+   OR EXISTS (
+     SELECT 1 FROM account_follows f
+     WHERE f.follower_id = a.account_id AND f.followee_id = c.account_id
+   )
+ORDER BY a.account_id, c.new_rank DESC;
+
+-- name: RecordLeaderboardRankPositions :execrows
+-- Stores the current global rank of every ranked account
+INSERT INTO leaderboard_rank_positions (account_id, vibe_rank, vibe_points)
+SELECT id, vibe_rank, vibe_points
+FROM account_vibepoint_rank
+ON CONFLICT (account_id) DO UPDATE
+SET vibe_rank = EXCLUDED.vibe_rank,
+    vibe_points = EXCLUDED.vibe_points,
+    updated_at = NOW();
+
+-- name: PruneLeaderboardRankPositions :execrows
+-- Forgets the positions of accounts that are no longer ranked
+DELETE FROM leaderboard_rank_positions p
+WHERE NOT EXISTS (
+  SELECT 1 FROM account_vibepoint_rank r WHERE r.id = p.account_id
+);
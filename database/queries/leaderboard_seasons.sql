@@ -0,0 +1,73 @@
+-- name: CreateLeaderboardSeason :one
+INSERT INTO leaderboard_seasons (name, starts_at, ends_at, end_action, decay_percent)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetLeaderboardSeason :one
+SELECT * FROM leaderboard_seasons
+WHERE id = $1;
+
+-- name: ListLeaderboardSeasons :many
+-- Returns the seasons from the most recent to the oldest
+SELECT * FROM leaderboard_seasons
+ORDER BY starts_at DESC
+LIMIT $1 OFFSET $2;
+
+-- name: CountLeaderboardSeasons :one
+SELECT count(*) FROM leaderboard_seasons;
+
+-- name: CountOverlappingLeaderboardSeasons :one
+-- Returns the number of seasons overlapping the given period
+SELECT count(*) FROM leaderboard_seasons
+WHERE starts_at < @ends_at AND ends_at > @starts_at;
+
+-- name: DeleteLeaderboardSeason :execrows
+-- Removes a season that has not been closed yet
+DELETE FROM leaderboard_seasons
+WHERE id = $1 AND closed_at IS NULL;
+
+-- name: ClaimDueLeaderboardSeason :one
+-- Marks the oldest season that has ended as closed and returns it. Seasons
+-- claimed by another instance are skipped
+UPDATE leaderboard_seasons
+SET closed_at = NOW()
+WHERE id = (
+  SELECT id FROM leaderboard_seasons
+  WHERE closed_at IS NULL AND ends_at <= NOW()
+  ORDER BY ends_at
+  LIMIT 1
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: ArchiveLeaderboardSeasonStandings :execrows
+-- Ranks every account by the points it earned during the season, within its
+-- tenant. Season adjustments are left out
+INSERT INTO leaderboard_season_standings (season_id, account_id, final_rank, final_points)
+SELECT @season_id::uuid, r.id, RANK() OVER (PARTITION BY r.tenant_id ORDER BY SUM(t.points_awarded) DESC), SUM(t.points_awarded)
+FROM account_vibepoint_rank r
+JOIN vibepoint_transactions t ON t.account_id = r.id
+WHERE t.awarded_at >= @starts_at
+  AND t.awarded_at < @ends_at
+  AND t.season_id IS NULL
+GROUP BY r.id, r.tenant_id;
+
+-- name: DecayLeaderboardSeasonPoints :execrows
+-- Takes the given share of every account's points away by appending an entry
+-- to the ledger
+INSERT INTO vibepoint_transactions (account_id, awarding_reason, points_awarded, awarded_by, season_id)
+SELECT id, @reason::text, -(vibe_points * @percent::int / 100)::int, 'system', @season_id::uuid
+FROM accounts
+WHERE vibe_points * @percent::int / 100 > 0;
+
+-- name: ListLeaderboardSeasonStandings :many
+SELECT s.account_id, a.name, a.username, a.avatar_url, s.final_rank, s.final_points
+FROM leaderboard_season_standings s
+JOIN accounts a ON a.id = s.account_id
+WHERE s.season_id = $1
+ORDER BY s.final_rank, a.name
+LIMIT $2 OFFSET $3;
+
+-- name: CountLeaderboardSeasonStandings :one
+SELECT count(*) FROM leaderboard_season_standings
+WHERE season_id = $1;
@@ -0,0 +1,18 @@
+-- name: SnapshotLeaderboardRanks :execrows
+-- Records today's global rank of every ranked account. Running it again on
+-- the same day refreshes that day's snapshot
+INSERT INTO leaderboard_snapshots (account_id, snapshot_date, vibe_rank, vibe_points)
+SELECT id, CURRENT_DATE, vibe_rank, vibe_points
+FROM account_vibepoint_rank
+ON CONFLICT (account_id, snapshot_date) DO UPDATE
+SET vibe_rank = EXCLUDED.vibe_rank,
+    vibe_points = EXCLUDED.vibe_points,
+    created_at = NOW();
+
+-- name: GetLeaderboardRankHistory :many
+-- Returns the daily snapshots of an account over the last number of days,
+-- oldest first
+SELECT * FROM leaderboard_snapshots
+WHERE account_id = @account_id
+  AND snapshot_date >= CURRENT_DATE - @days::int
+ORDER BY snapshot_date;
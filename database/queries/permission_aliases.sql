@@ -0,0 +1,21 @@
+-- name: UpsertPermissionAlias :one
+-- Points a deprecated permission name at its replacement
+INSERT INTO permission_aliases (
+  alias, permission_id
+) VALUES ( $1, $2 )
+ON CONFLICT (alias) DO UPDATE
+SET permission_id = EXCLUDED.permission_id
+RETURNING *;
+
+
+-- name: GetPermissionAliases :many
+-- Returns every alias together with the name of the permission replacing it
+SELECT pa.alias, pa.permission_id, p.name AS permission, pa.created_at
+FROM permission_aliases pa
+JOIN permissions p ON p.id = pa.permission_id
+ORDER BY pa.alias;
+
+
+-- name: DeletePermissionAlias :execrows
+DELETE FROM permission_aliases
+WHERE alias = $1;
@@ -0,0 +1,31 @@
+-- name: UpsertPermissionDenial :one
+-- Denies a permission to an account regardless of its roles
+INSERT INTO permission_denials (
+  user_id, permission_id, reason, denied_by
+) VALUES ( $1, $2, $3, $4 )
+ON CONFLICT (user_id, permission_id) DO UPDATE
+SET reason = EXCLUDED.reason,
+  denied_by = EXCLUDED.denied_by
+RETURNING *;
+
+
+-- name: GetUserPermissionDenials :many
+-- Returns the permissions denied to an account together with their names
+SELECT pd.user_id, pd.permission_id, p.name AS permission, pd.reason, pd.denied_by, pd.created_at
+FROM permission_denials pd
+JOIN permissions p ON p.id = pd.permission_id
+WHERE pd.user_id = $1
+ORDER BY p.name;
+
+
+-- name: GetUserDeniedPermissionNames :many
+-- Returns the names of the permissions denied to an account
+SELECT p.name
+FROM permission_denials pd
+JOIN permissions p ON p.id = pd.permission_id
+WHERE pd.user_id = $1;
+
+
+-- name: DeletePermissionDenial :execrows
+DELETE FROM permission_denials
+WHERE user_id = $1 AND permission_id = $2;
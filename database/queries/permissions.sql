@@ -28,6 +28,21 @@ WHERE user_id = $1;
 SELECT permission FROM user_permissions_view
 WHERE user_id = $1;
 
+-- name: GetAccountAuthorization :one
+-- Returns an account together with the names of its roles, of the
+-- permissions granted through them and of the permissions denied to it so
+-- that a request can be authenticated in a single round trip
+SELECT sqlc.embed(a),
+  ARRAY(SELECT urv.name FROM user_roles_view urv WHERE urv.user_id = a.id)::text[] AS roles,
+  ARRAY(SELECT upv.permission FROM user_permissions_view upv WHERE upv.user_id = a.id)::text[] AS permissions,
+  ARRAY(
+    SELECT p.name FROM permission_denials pd
+    JOIN permissions p ON p.id = pd.permission_id
+    WHERE pd.user_id = a.id
+  )::text[] AS denied_permissions
+FROM accounts a
+WHERE a.id = $1;
+
 -- name: UpdatePermission :one
 UPDATE permissions
   SET name = COALESCE($2, name),
@@ -49,3 +64,14 @@ RETURNING *;
 DELETE FROM role_permissions
 WHERE role_id = $1 AND permission_id = $2;
 
+
+-- name: GetPermissionsByNames :many
+-- Returns the permissions matching the given names
+SELECT * FROM permissions
+WHERE name = ANY(@names::text[]);
+
+
+-- name: GetPermissionNames :many
+-- Returns the names of every permission
+SELECT name FROM permissions
+ORDER BY name;
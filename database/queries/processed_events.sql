@@ -0,0 +1,17 @@
+-- name: MarkEventProcessed :execrows
+-- Records an event as processed by a consumer. No row is affected when the
+-- consumer processed it before
+INSERT INTO processed_events (consumer, event_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: IsEventProcessed :one
+SELECT EXISTS (
+  SELECT 1 FROM processed_events
+  WHERE consumer = $1 AND event_id = $2
+);
+
+-- name: PruneProcessedEvents :execrows
+-- Removes processed events that are older than the retention period
+DELETE FROM processed_events
+WHERE processed_at < NOW() - make_interval(days => @retention_days::int);
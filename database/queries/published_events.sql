@@ -0,0 +1,19 @@
+-- name: CreatePublishedEvent :exec
+INSERT INTO published_events (exchange, routing_key, event_type, account_id, payload)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListPublishedEventsForReplay :many
+-- Returns the events published within [published_from, published_to) oldest
+-- first, optionally limited to some event types and to a single account
+SELECT * FROM published_events
+WHERE published_at >= @published_from::timestamp
+  AND published_at < @published_to::timestamp
+  AND (cardinality(@event_types::text[]) = 0 OR event_type = ANY(@event_types::text[]))
+  AND (sqlc.narg(account_id)::uuid IS NULL OR account_id = sqlc.narg(account_id))
+ORDER BY published_at, id
+LIMIT @max_events::int;
+
+-- name: PrunePublishedEvents :execrows
+-- Removes events that are older than the retention period
+DELETE FROM published_events
+WHERE published_at < NOW() - make_interval(days => @retention_days::int);
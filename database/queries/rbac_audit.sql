@@ -0,0 +1,17 @@
+-- name: CreateRBACAuditEntry :one
+-- Records a change made to roles, permissions or their assignments
+INSERT INTO rbac_audit_log (
+  action, actor_id, target_type, target_id, before, after
+) VALUES ( $1, $2, $3, $4, $5, $6 )
+RETURNING *;
+
+
+-- name: GetRBACAuditEntries :many
+-- Returns audit entries newest first optionally filtered by target and actor
+SELECT * FROM rbac_audit_log
+WHERE (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
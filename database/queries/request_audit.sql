@@ -0,0 +1,24 @@
+-- name: CreateRequestAuditEntry :exec
+-- Records a state changing request
+INSERT INTO request_audit_log (
+  request_id, actor_id, method, route, path, resource_ids, status,
+  latency_ms, ip_address, user_agent, occurred_at
+) VALUES ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11 );
+
+
+-- name: GetRequestAuditEntries :many
+-- Returns recorded requests newest first optionally filtered by actor, route
+-- and method
+SELECT * FROM request_audit_log
+WHERE (sqlc.narg(actor_id)::uuid IS NULL OR actor_id = sqlc.narg(actor_id))
+  AND (sqlc.narg(route)::text IS NULL OR route = sqlc.narg(route))
+  AND (sqlc.narg(method)::text IS NULL OR method = sqlc.narg(method))
+ORDER BY occurred_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+
+-- name: PruneRequestAuditEntries :execrows
+-- Removes recorded requests that are older than the retention period
+DELETE FROM request_audit_log
+WHERE occurred_at < NOW() - make_interval(days => @retention_days::int);
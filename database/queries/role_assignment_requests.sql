@@ -0,0 +1,39 @@
+-- name: CreateRoleAssignmentRequest :one
+-- Records a request to assign a role that requires approval, within an
+-- institution when institution_id is set
+INSERT INTO role_assignment_requests (
+  user_id, role_id, requested_by, reason, institution_id
+) VALUES ( $1, $2, $3, $4, $5 )
+RETURNING *;
+
+
+-- name: GetRoleAssignmentRequestByID :one
+SELECT * FROM role_assignment_requests
+WHERE id = $1;
+
+
+-- name: GetRoleAssignmentRequests :many
+-- Returns role assignment requests newest first optionally filtered by status
+SELECT * FROM role_assignment_requests
+WHERE (sqlc.narg(status)::role_assignment_request_status IS NULL OR status = sqlc.narg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+
+-- name: ReviewRoleAssignmentRequest :one
+-- Approves or rejects a pending request. Requests that were already reviewed
+-- are left untouched
+UPDATE role_assignment_requests
+  SET status = $2,
+  reviewed_by = $3,
+  review_note = $4,
+  reviewed_at = NOW()
+  WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+
+-- name: GetRoleAssignmentApprovers :many
+-- Returns the accounts allowed to review role assignment requests
+SELECT DISTINCT user_id FROM user_permissions_view
+WHERE permission IN ('review:role_assignment:any', '*');
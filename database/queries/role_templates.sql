@@ -0,0 +1,65 @@
+-- name: CreateRoleTemplate :one
+-- Creates a role template
+INSERT INTO role_templates (
+  name, description
+) VALUES ( $1, $2 )
+RETURNING *;
+
+
+-- name: GetRoleTemplateByID :one
+-- Retrieves a role template specified by its id
+SELECT * FROM role_templates WHERE id = $1;
+
+
+-- name: GetAllRoleTemplates :many
+-- Retrieves a list of role templates
+SELECT * FROM role_templates
+ORDER BY name
+LIMIT $1
+OFFSET $2;
+
+
+-- name: UpdateRoleTemplate :one
+-- Updates the name and description of a role template
+UPDATE role_templates
+  SET
+    name = COALESCE(sqlc.narg(name), name),
+    description = COALESCE(sqlc.narg(description), description),
+    updated_at = NOW()
+  WHERE id = sqlc.arg(id)
+RETURNING *;
+
+
+-- name: DeleteRoleTemplate :execrows
+-- Deletes a role template. Roles created from it are left untouched
+DELETE FROM role_templates WHERE id = $1;
+
+
+-- name: AddRoleTemplatePermission :exec
+-- Adds a permission to a role template
+INSERT INTO role_template_permissions (
+  template_id, permission_id
+) VALUES ( $1, $2 )
+ON CONFLICT DO NOTHING;
+
+
+-- name: ClearRoleTemplatePermissions :exec
+-- Removes every permission from a role template
+DELETE FROM role_template_permissions WHERE template_id = $1;
+
+
+-- name: GetRoleTemplatePermissions :many
+-- Retrieves the permissions carried by a role template
+SELECT p.* FROM permissions p
+JOIN role_template_permissions tp ON tp.permission_id = p.id
+WHERE tp.template_id = $1
+ORDER BY p.name;
+
+
+-- name: CopyRoleTemplatePermissions :execrows
+-- Grants a role every permission carried by a role template
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT sqlc.arg(role_id)::uuid, permission_id
+FROM role_template_permissions
+WHERE template_id = sqlc.arg(template_id)
+ON CONFLICT DO NOTHING;
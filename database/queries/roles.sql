@@ -9,19 +9,20 @@ RETURNING *;
 
 -- name: GetRoleByID :one
 -- Retrieves a role specified by its id
-SELECT * FROM roles WHERE id = $1;
+SELECT * FROM roles WHERE id = $1 AND deleted_at IS NULL;
 
 
 -- name: GetAllRoles :many
 -- Retrieves a list of roles
 SELECT * FROM roles 
+WHERE deleted_at IS NULL
 LIMIT $1
 OFFSET $2;
 
 
 -- name: GetRoleByName :one
 SELECT * FROM roles 
-WHERE name = $1;
+WHERE name = $1 AND deleted_at IS NULL;
 
 
 -- name: GetAllUserRoles :many
@@ -37,7 +38,7 @@ SELECT name FROM user_roles_view WHERE user_id = $1;
 UPDATE roles
   SET name =  COALESCE($2, name),
   description = COALESCE($3, description)
-  WHERE id = $1
+  WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 
@@ -59,3 +60,101 @@ RETURNING *;
 -- Revokes a role from a user
 DELETE FROM user_roles
   WHERE user_id = $1 AND role_id = $2;
+
+
+-- name: SetRoleActive :one
+-- Activates or deactivates a role. Permissions granted through an inactive
+-- role are ignored
+UPDATE roles
+  SET is_active = $2,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+
+-- name: CountRoleAssignments :one
+-- Returns the number of accounts a role is assigned to
+SELECT COUNT(*) FROM user_roles WHERE role_id = $1;
+
+
+-- name: ReassignRoleAccounts :execrows
+-- Assigns the target role to every account holding the source role
+INSERT INTO user_roles (user_id, role_id)
+SELECT user_id, @to_role_id::uuid FROM user_roles
+WHERE role_id = @from_role_id::uuid
+ON CONFLICT DO NOTHING;
+
+
+-- name: DeleteRolePermissions :execrows
+-- Removes every permission granted to a role
+DELETE FROM role_permissions
+WHERE role_id = $1;
+
+
+-- name: DeleteRole :execrows
+-- Marks a role deleted. Its permissions are kept so that restoring it brings
+-- them back, its assignments are revoked with RevokeRoleAssignments
+UPDATE roles
+  SET deleted_at = NOW(),
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL;
+
+
+-- name: RevokeRoleAssignments :execrows
+-- Revokes a role from every account holding it, including the accounts
+-- holding it within an institution
+WITH revoked_institution_roles AS (
+  DELETE FROM institution_user_roles WHERE role_id = $1
+)
+DELETE FROM user_roles
+WHERE role_id = $1;
+
+
+-- name: ListDeletedRoles :many
+-- Retrieves the deleted roles, most recently deleted first
+SELECT * FROM roles
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1
+OFFSET $2;
+
+
+-- name: CountDeletedRoles :one
+SELECT COUNT(*) FROM roles WHERE deleted_at IS NOT NULL;
+
+
+-- name: RestoreRole :one
+-- Restores a deleted role without the assignments revoked when it was
+-- deleted
+UPDATE roles
+  SET deleted_at = NULL,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING *;
+
+
+-- name: CreateScopedRole :one
+-- Creates a role that may be scoped to a single institution
+INSERT INTO roles (
+  name, description, institution_id
+) VALUES ( $1, $2, $3 )
+RETURNING *;
+
+
+-- name: SetRoleRequiresApproval :one
+-- Marks whether assigning a role needs a second approver
+UPDATE roles
+  SET requires_approval = $2,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING *;
+
+
+-- name: GetRoleAccounts :many
+-- Retrieves the accounts a role is currently assigned to
+SELECT a.* FROM accounts a
+JOIN user_roles ur ON ur.user_id = a.id
+WHERE ur.role_id = $1
+ORDER BY a.created_at, a.id
+LIMIT $2
+OFFSET $3;
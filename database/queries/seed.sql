@@ -0,0 +1,33 @@
+-- name: SeedPermission :execrows
+-- Creates a permission declared in the seed file unless it already exists
+INSERT INTO permissions (
+  name, description
+) VALUES ( $1, $2 )
+ON CONFLICT (name) DO NOTHING;
+
+
+-- name: SeedRole :execrows
+-- Creates a role declared in the seed file unless it already exists
+INSERT INTO roles (
+  name, description, is_default
+) VALUES ( $1, $2, $3 )
+ON CONFLICT (name) WHERE deleted_at IS NULL DO NOTHING;
+
+
+-- name: SeedRolePermission :execrows
+-- Grants a permission to a role by name unless already granted
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = @role_name AND r.deleted_at IS NULL AND p.name = @permission_name
+ON CONFLICT DO NOTHING;
+
+
+-- name: SeedRoleAllPermissions :execrows
+-- Grants every known permission to a role by name
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+CROSS JOIN permissions p
+WHERE r.name = $1 AND r.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
@@ -60,7 +60,9 @@ SET
   use_count = use_count + 1
 WHERE id = $1;
 
--- name: UpdateServiceToken :exec
+-- name: UpdateServiceToken :execrows
+-- Updates a token and bumps its version. When a version is given the token
+-- is only updated if it is still at that version
 UPDATE service_tokens
 SET
   name = $2,
@@ -70,8 +72,10 @@ SET
   rotation_policy = $6,
   ip_whitelist = $7,
   user_agent_pattern = $8,
-  metadata = $9
-WHERE id = $1;
+  metadata = $9,
+  version = version + 1
+WHERE id = $1
+  AND (sqlc.narg(version)::bigint IS NULL OR version = sqlc.narg(version));
 
 -- name: DeleteServiceToken :exec
 DELETE FROM service_tokens
@@ -40,3 +40,16 @@ OFFSET $3;
 -- Deletes streak milestone by ID
 DELETE FROM streak_milestones WHERE id = $1;
 
+
+-- name: UpdateStreakMilestone :one
+-- Updates the provided fields of a streak milestone. Deactivated milestones
+-- are no longer awarded but the achievements already earned are kept
+UPDATE streak_milestones
+  SET
+    days_required = COALESCE(sqlc.narg(days_required)::smallint, days_required),
+    bonus_points = COALESCE(sqlc.narg(bonus_points)::smallint, bonus_points),
+    title = COALESCE(sqlc.narg(title)::varchar, title),
+    description = COALESCE(sqlc.narg(description)::text, description),
+    is_active = COALESCE(sqlc.narg(is_active)::boolean, is_active)
+  WHERE id = @id
+RETURNING *;
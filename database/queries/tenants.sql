@@ -0,0 +1,17 @@
+-- name: SetCurrentTenant :exec
+-- Restricts the connection to the accounts of a tenant until it is set
+-- again, see verisafe_current_tenant. '*' lets it see every tenant and an
+-- empty tenant lifts the restriction
+SELECT set_config('verisafe.tenant', @tenant::text, false);
+
+-- name: EnforceTenancy :exec
+-- Keeps the connection from seeing any account while it serves no tenant,
+-- see verisafe_every_tenant_visible
+SELECT set_config('verisafe.tenancy', 'enforced', false);
+
+-- name: CurrentRoleBypassesRowSecurity :one
+-- Reports whether the database role Verisafe connects as ignores row level
+-- security, which tenant isolation relies on
+SELECT (rolsuper OR rolbypassrls)::boolean AS bypasses
+FROM pg_roles
+WHERE rolname = current_user;
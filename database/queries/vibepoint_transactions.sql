@@ -0,0 +1,24 @@
+-- name: GetVibepointTransaction :one
+SELECT * FROM vibepoint_transactions
+WHERE id = $1;
+
+-- name: ListVibepointTransactionsForAccount :many
+-- Returns the ledger of an account from the most recent entry to the oldest
+SELECT * FROM vibepoint_transactions
+WHERE account_id = $1
+ORDER BY awarded_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountVibepointTransactionsForAccount :one
+SELECT count(*) FROM vibepoint_transactions
+WHERE account_id = $1;
+
+-- name: ReverseVibepointTransaction :one
+-- Appends an entry cancelling out the points of an earlier one
+INSERT INTO vibepoint_transactions (
+  account_id, awarding_reason, points_awarded, awarded_by, activity_id, actor_id, reversal_of
+)
+SELECT account_id, @reason::text, -points_awarded, @awarded_by::varchar, activity_id, @actor_id::uuid, id
+FROM vibepoint_transactions
+WHERE vibepoint_transactions.id = @id
+RETURNING *;
@@ -0,0 +1,94 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (owner_id, name, url, secret, event_types)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWebhookEndpointByID :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: GetAllWebhookEndpoints :many
+SELECT * FROM webhook_endpoints
+ORDER BY created_at DESC
+LIMIT $1
+OFFSET $2;
+
+-- name: GetActiveWebhookEndpointsForEvent :many
+-- Returns active endpoints subscribed to the event type. Endpoints without
+-- any event types receive every event
+SELECT * FROM webhook_endpoints
+WHERE is_active
+  AND (cardinality(event_types) = 0 OR @event_type::text = ANY(event_types));
+
+-- name: UpdateWebhookEndpoint :one
+UPDATE webhook_endpoints
+  SET
+    name = COALESCE(sqlc.narg(name)::varchar, name),
+    url = COALESCE(sqlc.narg(url)::text, url),
+    event_types = COALESCE(sqlc.narg(event_types)::text[], event_types),
+    is_active = COALESCE(sqlc.narg(is_active)::boolean, is_active),
+    updated_at = NOW()
+  WHERE id = $1
+RETURNING *;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE FROM webhook_endpoints
+WHERE id = $1;
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (endpoint_id, event_type, payload)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ClaimDueWebhookDeliveries :many
+-- Leases up to @batch_size due deliveries by pushing their next attempt into
+-- the future so that concurrent dispatchers do not pick the same rows
+UPDATE webhook_deliveries
+  SET next_attempt_at = NOW() + make_interval(secs => @lease_seconds::int)
+  WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at
+    LIMIT @batch_size::int
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING *;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+  SET
+    status = 'succeeded',
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = NULL,
+    delivered_at = NOW()
+  WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- Records a failed attempt. The delivery is retried after @retry_in_seconds
+-- unless @status moves it into the terminal 'failed' state
+UPDATE webhook_deliveries
+  SET
+    status = @status::webhook_delivery_status,
+    attempts = attempts + 1,
+    last_status_code = $2,
+    last_error = $3,
+    next_attempt_at = NOW() + make_interval(secs => @retry_in_seconds::int)
+  WHERE id = $1;
+
+-- name: GetWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE endpoint_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: RetryWebhookDelivery :one
+-- Schedules a delivery for immediate redelivery with a fresh attempt budget
+UPDATE webhook_deliveries
+  SET
+    status = 'pending',
+    attempts = 0,
+    next_attempt_at = NOW()
+  WHERE id = $1 AND endpoint_id = $2
+RETURNING *;
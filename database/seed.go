@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// The roles, permissions and role mappings every environment is expected to
+// have. It is applied on startup and through the seed command.
+//
+//go:embed seed/rbac.json
+var defaultRBACSeed []byte
+
+// SeedPermission describes a permission declared in the seed file
+type SeedPermission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// SeedRole describes a role declared in the seed file together with the
+// permissions it should carry. Roles marked with all_permissions are granted
+// every permission known at the time the seed runs.
+type SeedRole struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	IsDefault      bool     `json:"is_default"`
+	AllPermissions bool     `json:"all_permissions"`
+	Permissions    []string `json:"permissions"`
+}
+
+// RBACSeed is the declarative set of roles and permissions to seed
+type RBACSeed struct {
+	Permissions []SeedPermission `json:"permissions"`
+	Roles       []SeedRole       `json:"roles"`
+}
+
+// LoadRBACSeed reads the seed file at path. When path is empty the seed
+// bundled with the binary is used instead.
+func LoadRBACSeed(path string) (*RBACSeed, error) {
+	data := defaultRBACSeed
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read seed file: %w", err)
+		}
+	}
+
+	var seed RBACSeed
+	if err := json.Unmarshal(data, &seed); err != nil {
+		return nil, fmt.Errorf("failed to parse seed file: %w", err)
+	}
+
+	if err := seed.Validate(); err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+// Validate makes sure names are present and unique and that every permission
+// mapped onto a role is declared in the seed
+func (s *RBACSeed) Validate() error {
+	permissions := map[string]bool{}
+	for _, permission := range s.Permissions {
+		name := strings.TrimSpace(permission.Name)
+		if name == "" {
+			return fmt.Errorf("seed permissions must have a name")
+		}
+		if permissions[name] {
+			return fmt.Errorf("permission %q is declared more than once", name)
+		}
+		permissions[name] = true
+	}
+
+	roles := map[string]bool{}
+	for _, role := range s.Roles {
+		name := strings.TrimSpace(role.Name)
+		if name == "" {
+			return fmt.Errorf("seed roles must have a name")
+		}
+		if roles[name] {
+			return fmt.Errorf("role %q is declared more than once", name)
+		}
+		roles[name] = true
+
+		for _, permission := range role.Permissions {
+			if !permissions[permission] {
+				return fmt.Errorf("role %q references undeclared permission %q", name, permission)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Applies the seed to the database. Seeding is additive and idempotent,
+// existing roles and permissions are left untouched and only missing ones
+// and missing role mappings are created, so it is safe to run on every
+// startup.
+func RunRBACSeed(ctx context.Context, logger *slog.Logger, pool *pgxpool.Pool, seed *RBACSeed) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin seed transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	var createdPermissions, createdRoles, createdMappings int64
+
+	for _, permission := range seed.Permissions {
+		description := permission.Description
+		created, err := repo.SeedPermission(ctx, repository.SeedPermissionParams{
+			Name:        permission.Name,
+			Description: &description,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed permission %q: %w", permission.Name, err)
+		}
+		createdPermissions += created
+	}
+
+	for _, role := range seed.Roles {
+		description := role.Description
+		created, err := repo.SeedRole(ctx, repository.SeedRoleParams{
+			Name:        role.Name,
+			Description: &description,
+			IsDefault:   role.IsDefault,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed role %q: %w", role.Name, err)
+		}
+		createdRoles += created
+
+		if role.AllPermissions {
+			created, err := repo.SeedRoleAllPermissions(ctx, role.Name)
+			if err != nil {
+				return fmt.Errorf("failed to grant all permissions to role %q: %w", role.Name, err)
+			}
+			createdMappings += created
+			continue
+		}
+
+		for _, permission := range role.Permissions {
+			created, err := repo.SeedRolePermission(ctx, repository.SeedRolePermissionParams{
+				RoleName:       role.Name,
+				PermissionName: permission,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to grant %q to role %q: %w", permission, role.Name, err)
+			}
+			createdMappings += created
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit seed transaction: %w", err)
+	}
+
+	logger.Info("RBAC seed applied successfully",
+		slog.Int64("permissions_created", createdPermissions),
+		slog.Int64("roles_created", createdRoles),
+		slog.Int64("role_permissions_created", createdMappings),
+	)
+	return nil
+}
@@ -0,0 +1,238 @@
+// Package accountsync applies account updates other services emit.
+//
+// OVERVIEW:
+// Services that keep accounts of their own, e.g. the legacy registrar,
+// publish account.upserted events to an exchange Verisafe consumes through
+// the AccountSyncEventBus. The Consumer creates the account when it does not
+// exist yet, applies the details the event carries and links or unlinks the
+// account from institutions.
+//
+// IDEMPOTENCY:
+// Events are delivered at least once. Every event is applied through the
+// Deduplicator, which records it within the transaction that applies it as
+// processed by the account_sync:<source_service_id> consumer. Events that
+// were already recorded are acknowledged without being applied again.
+// Linking an account that is already linked and unlinking one that is not
+// are no-ops as well.
+package accountsync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/eventdedupe"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+)
+
+// The only account sync event type, others are acknowledged and ignored
+const upsertedEventType = "account.upserted"
+
+// Consumer applies account sync events
+type Consumer struct {
+	bus          *eventbus.AccountSyncEventBus
+	userEventBus *eventbus.UserEventBus
+	deduplicator *eventdedupe.Deduplicator
+	logger       *slog.Logger
+}
+
+// NewConsumer creates a new Consumer. Call Start to begin consuming events.
+func NewConsumer(bus *eventbus.AccountSyncEventBus, userEventBus *eventbus.UserEventBus, deduplicator *eventdedupe.Deduplicator, logger *slog.Logger) *Consumer {
+	return &Consumer{
+		bus:          bus,
+		userEventBus: userEventBus,
+		deduplicator: deduplicator,
+		logger:       logger,
+	}
+}
+
+// Start consumes account sync events until the context is cancelled
+func (c *Consumer) Start(ctx context.Context) {
+	if err := c.bus.OnAccountSync(c.apply); err != nil {
+		c.logger.Error("Failed to subscribe to account sync events", slog.Any("error", err))
+	}
+	c.logger.Info("Account sync consumer started")
+
+	<-ctx.Done()
+	c.bus.Close()
+	c.logger.Info("Account sync consumer stopped")
+}
+
+// apply applies an account sync event unless it was applied before. Returning
+// an error has the event redelivered. Other services know nothing of
+// tenants, so synced accounts are looked up across every tenant
+func (c *Consumer) apply(ctx context.Context, event eventbus.AccountSyncEvent) error {
+	ctx = tenancy.WithAllTenants(ctx)
+	meta := event.Metadata
+	if meta.EventType != upsertedEventType {
+		c.logger.Warn("Ignoring account sync event of an unknown type",
+			slog.String("event_type", meta.EventType),
+			slog.String("event_id", meta.EventID),
+		)
+		return nil
+	}
+	if meta.EventID == "" || meta.SourceServiceID == "" {
+		return fmt.Errorf("account sync event is missing its event_id or source_service_id")
+	}
+
+	var (
+		account repository.Account
+		created bool
+	)
+	applied, err := c.deduplicator.Once(ctx, "account_sync:"+meta.SourceServiceID, meta.EventID, func(ctx context.Context, tx pgx.Tx) error {
+		repo := repository.New(tx)
+
+		var err error
+		account, created, err = upsertAccount(ctx, repo, event.Account)
+		if err != nil {
+			return err
+		}
+		for _, institutionID := range event.LinkInstitutionIDs {
+			if err := c.link(ctx, repo, account, institutionID); err != nil {
+				return err
+			}
+		}
+		for _, institutionID := range event.UnlinkInstitutionIDs {
+			if err := c.unlink(ctx, repo, account, institutionID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !applied {
+		c.logger.Info("Skipping account sync event that was already applied",
+			slog.String("source_service_id", meta.SourceServiceID),
+			slog.String("event_id", meta.EventID),
+		)
+		return nil
+	}
+
+	c.logger.Info("Applied account sync event",
+		slog.String("source_service_id", meta.SourceServiceID),
+		slog.String("event_id", meta.EventID),
+		slog.Any("account_id", account.ID),
+		slog.Bool("created", created),
+	)
+
+	if created {
+		err = c.userEventBus.PublishUserCreated(ctx, account, meta.RequestID)
+	} else {
+		err = c.userEventBus.PublishUserUpdated(ctx, account, meta.RequestID)
+	}
+	if err != nil {
+		c.logger.Error("Failed to publish synced account", slog.Any("error", err))
+	}
+	return nil
+}
+
+// upsertAccount applies the synced details to the account, creating it when
+// no account has its ID or email
+func upsertAccount(ctx context.Context, repo *repository.Queries, synced eventbus.AccountSyncAccount) (repository.Account, bool, error) {
+	var (
+		account repository.Account
+		err     error
+	)
+	switch {
+	case synced.ID != nil:
+		account, err = repo.GetAccountByID(ctx, *synced.ID)
+	case synced.Email != "":
+		account, err = repo.GetAccountByEmail(ctx, synced.Email)
+	default:
+		return repository.Account{}, false, fmt.Errorf("synced account has neither an id nor an email")
+	}
+
+	created := false
+	if errors.Is(err, pgx.ErrNoRows) {
+		if synced.ID != nil || synced.Email == "" || synced.Name == "" {
+			return repository.Account{}, false, fmt.Errorf("synced account does not exist and cannot be created without an email and name")
+		}
+		account, err = repo.CreateAccount(ctx, repository.CreateAccountParams{
+			Email:     synced.Email,
+			Name:      synced.Name,
+			Type:      repository.AccountTypeHuman,
+			AvatarUrl: synced.AvatarURL,
+		})
+		if err != nil {
+			return repository.Account{}, false, fmt.Errorf("failed to create synced account: %w", err)
+		}
+		created = true
+	} else if err != nil {
+		return repository.Account{}, false, fmt.Errorf("failed to retrieve synced account: %w", err)
+	}
+
+	account, err = repo.SyncAccountDetails(ctx, repository.SyncAccountDetailsParams{
+		ID:         account.ID,
+		Email:      synced.Email,
+		Name:       synced.Name,
+		Username:   synced.Username,
+		AvatarUrl:  synced.AvatarURL,
+		Phone:      synced.Phone,
+		NationalID: synced.NationalID,
+	})
+	if err != nil {
+		return repository.Account{}, false, fmt.Errorf("failed to update synced account %s: %w", account.ID, err)
+	}
+	return account, created, nil
+}
+
+// link adds the account to an institution as a member. Institutions that do
+// not exist or are archived are skipped
+func (c *Consumer) link(ctx context.Context, repo *repository.Queries, account repository.Account, institutionID int32) error {
+	institution, err := repo.GetInstitution(ctx, institutionID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && institution.ArchivedAt.Valid) {
+		c.logger.Warn("Skipping link to an unknown or archived institution",
+			slog.Any("account_id", account.ID),
+			slog.Int("institution_id", int(institutionID)),
+		)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve institution %d: %w", institutionID, err)
+	}
+
+	if _, err := repo.AddAccountInstitution(ctx, repository.AddAccountInstitutionParams{
+		AccountID:      account.ID,
+		InstitutionID:  institutionID,
+		MembershipRole: repository.InstitutionMembershipRoleMember,
+	}); err != nil {
+		return fmt.Errorf("failed to link account %s to institution %d: %w", account.ID, institutionID, err)
+	}
+	return nil
+}
+
+// unlink removes the account from an institution. Owners are managed within
+// Verisafe and are never unlinked
+func (c *Consumer) unlink(ctx context.Context, repo *repository.Queries, account repository.Account, institutionID int32) error {
+	membership, err := repo.GetInstitutionMembership(ctx, repository.GetInstitutionMembershipParams{
+		AccountID:     account.ID,
+		InstitutionID: institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to retrieve membership of account %s in institution %d: %w", account.ID, institutionID, err)
+	}
+	if membership.MembershipRole == repository.InstitutionMembershipRoleOwner {
+		c.logger.Warn("Skipping unlink of an institution owner",
+			slog.Any("account_id", account.ID),
+			slog.Int("institution_id", int(institutionID)),
+		)
+		return nil
+	}
+
+	if err := repo.RemoveAccountInstitution(ctx, repository.RemoveAccountInstitutionParams{
+		AccountID:     account.ID,
+		InstitutionID: institutionID,
+	}); err != nil {
+		return fmt.Errorf("failed to unlink account %s from institution %d: %w", account.ID, institutionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Package adminstream relays security and account events to the admin
+// dashboard as they happen.
+//
+// OVERVIEW:
+// The Hub subscribes to the security and user event buses once and fans
+// every event out to the dashboards connected to GET /api/v1/admin/events/stream,
+// which receive them as Server-Sent Events. Events are only relayed while a
+// dashboard is connected, there is no history to catch up on, the audit logs
+// and the event journal keep that.
+//
+// SLOW SUBSCRIBERS:
+// Every subscriber has a bounded buffer. A subscriber that falls so far
+// behind that its buffer fills up is disconnected rather than silently
+// missing events, the dashboard reconnects after the retry delay.
+package adminstream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+// Topics events are relayed under
+const (
+	TopicSecurity = "security"
+	TopicAccount  = "account"
+)
+
+// Topics lists every topic events are relayed under
+var Topics = []string{TopicSecurity, TopicAccount}
+
+// How many events a subscriber may fall behind before it is disconnected
+const subscriberBuffer = 64
+
+// Event is an event relayed to the dashboard
+type Event struct {
+	ID    string
+	Topic string
+	Type  string
+	Data  json.RawMessage
+}
+
+// Hub fans the relayed events out to the subscribers. A nil Hub is safe to
+// use, it relays nothing
+type Hub struct {
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+type subscriber struct {
+	events chan Event
+	topics []string
+}
+
+// NewHub creates a new Hub. Call Start to begin relaying events.
+func NewHub(logger *slog.Logger) *Hub {
+	return &Hub{
+		logger:      logger,
+		subscribers: map[*subscriber]struct{}{},
+	}
+}
+
+// Start subscribes to the security and user event buses
+func (h *Hub) Start(security *eventbus.SecurityEventBus, users *eventbus.UserEventBus) error {
+	if err := security.OnSecurityEvent(func(ctx context.Context, event eventbus.SecurityEvent) error {
+		h.Broadcast(TopicSecurity, event.Metadata.EventID, event.Metadata.EventType, event)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return users.OnUserEvent(func(ctx context.Context, event eventbus.UserEvent) error {
+		h.Broadcast(TopicAccount, event.Metadata.EventID, event.Metadata.EventType, event)
+		return nil
+	})
+}
+
+// Subscribe returns the events relayed under the given topics from now on.
+// The channel is closed when the subscriber falls behind or the hub is
+// closed. Call cancel once the events are no longer needed
+func (h *Hub) Subscribe(topics []string) (events <-chan Event, cancel func()) {
+	sub := &subscriber{
+		events: make(chan Event, subscriberBuffer),
+		topics: topics,
+	}
+
+	if h == nil {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	h.subscribers[sub] = struct{}{}
+
+	return sub.events, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(sub)
+	}
+}
+
+// Broadcast relays an event to the subscribers of its topic
+func (h *Hub) Broadcast(topic, id, eventType string, event any) {
+	if h == nil {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Failed to encode relayed event",
+			slog.String("event_type", eventType),
+			slog.Any("error", err),
+		)
+		return
+	}
+	relayed := Event{ID: id, Topic: topic, Type: eventType, Data: data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		if !slices.Contains(sub.topics, topic) {
+			continue
+		}
+		select {
+		case sub.events <- relayed:
+		default:
+			h.logger.Warn("Disconnecting admin stream subscriber that fell behind")
+			h.remove(sub)
+		}
+	}
+}
+
+// Close disconnects every subscriber
+func (h *Hub) Close() {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
+
+// remove forgets a subscriber and closes its channel. h.mu must be held
+func (h *Hub) remove(sub *subscriber) {
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// CreateAdminUsage describes the create-admin command
+const CreateAdminUsage = `Usage: verisafe create-admin -email <email> [options]
+
+Grants a role to the account with the email, creating the account when there
+is none. The account is linked on its first sign in with a provider using
+the same email.
+
+Options:
+  -email <email>    Email of the account, required
+  -name <name>      Name of the account when it is created, defaults to the email
+  -role <role>      Role granted to the account (default "Administrator")
+  -tenant <tenant>  Tenant the account is created in when tenancy is enabled,
+                    defaults to TENANT_DEFAULT
+`
+
+// ErrCreateAdminUsage is returned when the create-admin command is used
+// wrongly, the caller should show CreateAdminUsage
+var ErrCreateAdminUsage = errors.New("invalid create-admin command")
+
+// CreateAdmin runs the create-admin command, writing what it did to out, so
+// that the first administrator of an installation is set up without
+// hand-written SQL. Running it again for the same account changes nothing
+func CreateAdmin(ctx context.Context, logger *slog.Logger, config *config.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	email := flags.String("email", "", "")
+	name := flags.String("name", "", "")
+	roleName := flags.String("role", "Administrator", "")
+	tenant := flags.String("tenant", "", "")
+	if err := parseFlags(flags, args, ErrCreateAdminUsage); err != nil {
+		return err
+	}
+
+	*email = utils.NormalizeEmail(*email)
+	if *email == "" {
+		return fmt.Errorf("%w: -email is required", ErrCreateAdminUsage)
+	}
+	if *name == "" {
+		*name = *email
+	}
+	if config.TenancyConfig.Enabled {
+		if *tenant == "" {
+			*tenant = config.TenancyConfig.DefaultTenant
+		}
+		if !config.IsTenant(*tenant) {
+			return fmt.Errorf("unknown tenant %q, expected TENANT_DEFAULT or one of TENANTS", *tenant)
+		}
+	} else if *tenant != "" {
+		return fmt.Errorf("%w: -tenant requires TENANCY_ENABLED", ErrCreateAdminUsage)
+	}
+
+	pool, err := newConnectionPool(logger, config, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	// Accounts take the tenant of the connection they are created with
+	if *tenant != "" {
+		if err := repo.SetCurrentTenant(ctx, *tenant); err != nil {
+			return fmt.Errorf("failed to switch to tenant %q: %w", *tenant, err)
+		}
+	}
+
+	account, created, err := ensureAccount(ctx, repo, *email, *name, repository.AccountTypeHuman)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Fprintf(out, "Created account %s for %s\n", account.ID, account.Email)
+	}
+
+	granted, err := grantRole(ctx, repo, account, *roleName)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	if granted {
+		fmt.Fprintf(out, "Granted the %s role to account %s\n", *roleName, account.ID)
+	} else {
+		fmt.Fprintf(out, "Account %s already holds the %s role\n", account.ID, *roleName)
+	}
+	return nil
+}
+
+// ensureAccount returns the account with the email, creating it with the
+// type when there is none. An existing account of another type is refused
+func ensureAccount(ctx context.Context, repo *repository.Queries, email, name string, accountType repository.AccountType) (account repository.Account, created bool, err error) {
+	account, err = repo.GetAccountByEmail(ctx, email)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		account, err = repo.CreateAccount(ctx, repository.CreateAccountParams{
+			Email: email,
+			Name:  name,
+			Type:  accountType,
+		})
+		if err != nil {
+			return account, false, fmt.Errorf("failed to create account %s: %w", email, err)
+		}
+		return account, true, nil
+	case err != nil:
+		return account, false, fmt.Errorf("failed to look up account %s: %w", email, err)
+	case account.Type != accountType:
+		return account, false, fmt.Errorf("account %s is a %s account, expected a %s account", account.ID, account.Type, accountType)
+	case account.DeactivatedAt != nil:
+		return account, false, fmt.Errorf("account %s is deactivated", account.ID)
+	}
+	return account, false, nil
+}
+
+// grantRole grants a role to an account unless it already holds it,
+// reporting whether it was granted. The grant is recorded in the RBAC audit
+// log without an actor, since no account made it
+func grantRole(ctx context.Context, repo *repository.Queries, account repository.Account, roleName string) (bool, error) {
+	role, err := repo.GetRoleByName(ctx, roleName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("role %q does not exist, run verisafe seed first", roleName)
+	} else if err != nil {
+		return false, fmt.Errorf("failed to look up role: %w", err)
+	}
+
+	held, err := repo.GetAllUserRoleNames(ctx, account.ID)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the roles of the account: %w", err)
+	}
+	if slices.Contains(held, role.Name) {
+		return false, nil
+	}
+
+	if _, err := repo.AssignRole(ctx, repository.AssignRoleParams{
+		UserID: account.ID,
+		RoleID: role.ID,
+	}); err != nil {
+		return false, fmt.Errorf("failed to assign role: %w", err)
+	}
+
+	after, _ := json.Marshal(map[string]any{"role_id": role.ID, "role_name": role.Name})
+	if _, err := repo.CreateRBACAuditEntry(ctx, repository.CreateRBACAuditEntryParams{
+		Action:     handlers.AuthzActionRoleAssigned,
+		TargetType: handlers.AuthzTargetAccount,
+		TargetID:   account.ID.String(),
+		After:      after,
+	}); err != nil {
+		return false, fmt.Errorf("failed to record role assignment: %w", err)
+	}
+	return true, nil
+}
@@ -6,49 +6,121 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/opencrafts-io/verisafe/database"
+	"github.com/opencrafts-io/verisafe/internal/accountsync"
+	"github.com/opencrafts-io/verisafe/internal/adminstream"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/bodylog"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/dbstats"
 	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/eventdedupe"
+	"github.com/opencrafts-io/verisafe/internal/eventjournal"
+	"github.com/opencrafts-io/verisafe/internal/gamification"
+	"github.com/opencrafts-io/verisafe/internal/graphqlapi"
+	"github.com/opencrafts-io/verisafe/internal/grpcapi"
+	"github.com/opencrafts-io/verisafe/internal/ipintel"
+	"github.com/opencrafts-io/verisafe/internal/leaderboard"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/requestaudit"
+	"github.com/opencrafts-io/verisafe/internal/secrets"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+	"github.com/opencrafts-io/verisafe/internal/webhooks"
 )
 
 type App struct {
 	config               *config.Config
 	logger               *slog.Logger
 	pool                 *pgxpool.Pool
+	replicaPool          *pgxpool.Pool
 	userEventBus         *eventbus.UserEventBus
 	notificationEventBus *eventbus.NotificationEventBus
 	institutionEventBus  *eventbus.InstitutionEventBus
+	authzEventBus        *eventbus.AuthzEventBus
+	roleEventBus         *eventbus.RoleEventBus
+	leaderboardEventBus  *eventbus.LeaderboardEventBus
+	securityEventBus     *eventbus.SecurityEventBus
+	accountSyncEventBus  *eventbus.AccountSyncEventBus
+	accountSync          *accountsync.Consumer
+	eventDeduplicator    *eventdedupe.Deduplicator
+	webhookDispatcher    *webhooks.Dispatcher
+	eventJournal         *eventjournal.Journal
+	leaderboardRefresher *leaderboard.Refresher
+	leaderboardSnapshots *leaderboard.Snapshotter
+	seasonCloser         *leaderboard.SeasonCloser
+	rankWatcher          *leaderboard.RankWatcher
+	leaderboardLive      *leaderboard.LiveFeed
+	completionPartitions *gamification.PartitionMaintainer
+	policyEngine         *authz.Engine
+	permissionCache      *authz.PermissionCache
+	usageTracker         *authz.UsageTracker
+	requestAudit         *requestaudit.Recorder
+	clientIPResolver     *ipintel.Resolver
+	adminStream          *adminstream.Hub
+	bodyLogSampler       *bodylog.Sampler
+	graphqlSchema        *graphql.Schema
+	grpcServer           *grpcapi.Server
+	secretRefresher      *secrets.Refresher
+	reloader             *config.Reloader
+	tokenRotation        *utils.TokenRotationScheduler
+	// Set once the app starts shutting down, reported by /readyz
+	draining atomic.Bool
 }
 
 // Returns a new instance of the application
 // with a connection instance to the database pool
 func New(logger *slog.Logger, config *config.Config) (*App, error) {
 
-	dbConfig, err := pgxpool.ParseConfig(fmt.Sprintf(
-		"postgresql://%s:%s@%s:%d/%s?sslmode=disable",
-		config.DatabaseConfig.DatabaseUser,
-		config.DatabaseConfig.DatabasePassword,
-		config.DatabaseConfig.DatabaseHost,
-		config.DatabaseConfig.DatabasePort,
-		config.DatabaseConfig.DatabaseName,
-	))
+	// Secrets fetched from secret stores are fetched again in the
+	// background, rotated database passwords apply to new connections
+	secretRefresher := secrets.NewRefresher(
+		config.NewSecretResolver(),
+		config.SecretRefs,
+		config.SecretValues(),
+		time.Duration(config.SecretsConfig.RefreshIntervalSeconds)*time.Second,
+		logger,
+		"DB_PASSWORD",
+	)
+
+	connPool, err := newConnectionPool(logger, config, secretRefresher)
 	if err != nil {
 		return nil, err
 	}
 
-	dbConfig.MaxConns = config.DatabaseConfig.DatabasePoolMaxConnections
-	dbConfig.MinConns = config.DatabaseConfig.DatabasePoolMinConnections
-	dbConfig.MaxConnLifetime = time.Hour * time.Duration(config.DatabaseConfig.DatabasePoolMaxConnectionLifetime)
-
-	connPool, err := pgxpool.NewWithConfig(context.Background(), dbConfig)
+	// Read-only queries are only sent to a replica when one is configured
+	replicaPool, err := newReplicaPool(logger, config)
 	if err != nil {
 		return nil, err
 	}
 
+	// Tenants are isolated by row level security, which is not enforced for
+	// roles allowed to bypass it
+	if config.TenancyConfig.Enabled {
+		bypasses, err := repository.New(connPool).CurrentRoleBypassesRowSecurity(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("could not check the database role for row level security: %w", err)
+		}
+		if bypasses {
+			return nil, errors.New("tenancy is enabled but the database role bypasses row level security, connect as a role without SUPERUSER or BYPASSRLS")
+		}
+	}
+
+	// Pool statistics are exported so that operators see a pool running out
+	// of connections before requests start failing
+	metrics.WatchDBPool("primary", connPool)
+	if replicaPool != nil {
+		metrics.WatchDBPool("replica", replicaPool)
+	}
+
 	userEventBus, err := eventbus.NewUserEventBus(config, logger)
 	if err != nil {
 		return nil, err
@@ -64,37 +136,239 @@ func New(logger *slog.Logger, config *config.Config) (*App, error) {
 		return nil, err
 	}
 
+	authzEventBus, err := eventbus.NewAuthzEventBus(config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	roleEventBus, err := eventbus.NewRoleEventBus(config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboardEventBus, err := eventbus.NewLeaderboardEventBus(config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	securityEventBus, err := eventbus.NewSecurityEventBus(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	authzEventBus.SetSecurityEventBus(securityEventBus)
+
+	webhookDispatcher := webhooks.NewDispatcher(config, connPool, logger)
+	userEventBus.SetWebhookEnqueuer(webhookDispatcher)
+	institutionEventBus.SetWebhookEnqueuer(webhookDispatcher)
+	authzEventBus.SetWebhookEnqueuer(webhookDispatcher)
+	roleEventBus.SetWebhookEnqueuer(webhookDispatcher)
+	leaderboardEventBus.SetWebhookEnqueuer(webhookDispatcher)
+
+	eventJournal := eventjournal.NewJournal(config, connPool, logger)
+	userEventBus.SetEventJournal(eventJournal)
+	notificationEventBus.SetEventJournal(eventJournal)
+	institutionEventBus.SetEventJournal(eventJournal)
+	authzEventBus.SetEventJournal(eventJournal)
+	roleEventBus.SetEventJournal(eventJournal)
+	leaderboardEventBus.SetEventJournal(eventJournal)
+	securityEventBus.SetEventJournal(eventJournal)
+
+	eventDeduplicator := eventdedupe.NewDeduplicator(config, connPool, logger)
+
+	// Account sync events are only consumed when enabled
+	var accountSyncEventBus *eventbus.AccountSyncEventBus
+	var accountSync *accountsync.Consumer
+	if config.AccountSyncConfig.Enabled {
+		accountSyncEventBus, err = eventbus.NewAccountSyncEventBus(config, logger)
+		if err != nil {
+			return nil, err
+		}
+		accountSync = accountsync.NewConsumer(accountSyncEventBus, userEventBus, eventDeduplicator, logger)
+	}
+
+	policyEngine := authz.NewEngine(config, connPool, logger)
+	permissionCache := authz.NewPermissionCache(
+		time.Duration(config.AuthorizationConfig.PermissionCacheTTLSeconds) * time.Second,
+	)
+
+	// Events are only relayed to the admin dashboard when enabled
+	var adminStream *adminstream.Hub
+	if config.AdminStreamConfig.Enabled {
+		adminStream = adminstream.NewHub(logger)
+	}
+
+	// Bodies are only ever logged when enabled
+	var bodyLogSampler *bodylog.Sampler
+	if config.BodyLoggingConfig.Enabled {
+		bodyLogSampler = bodylog.NewSampler(config, connPool, logger)
+	}
+
+	// The GraphQL API is only served when enabled
+	var graphqlSchema *graphql.Schema
+	if config.GraphQLConfig.Enabled {
+		schema, err := graphqlapi.NewSchema(logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the GraphQL schema: %w", err)
+		}
+		graphqlSchema = &schema
+	}
+
+	// Core operations are only served over gRPC when enabled
+	var grpcServer *grpcapi.Server
+	if config.GRPCConfig.Enabled {
+		grpcServer, err = grpcapi.NewServer(config, connPool, policyEngine, permissionCache, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clientIPResolver, err := ipintel.NewResolver(config, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	// State changing requests are only recorded when enabled
+	var requestAudit *requestaudit.Recorder
+	if config.RequestAuditConfig.Enabled {
+		requestAudit = requestaudit.NewRecorder(config, connPool, logger)
+	}
+
 	return &App{
 		config:               config,
 		logger:               logger,
 		pool:                 connPool,
+		replicaPool:          replicaPool,
 		userEventBus:         userEventBus,
 		notificationEventBus: notificationEventBus,
 		institutionEventBus:  institutionEventBus,
+		authzEventBus:        authzEventBus,
+		roleEventBus:         roleEventBus,
+		leaderboardEventBus:  leaderboardEventBus,
+		securityEventBus:     securityEventBus,
+		accountSyncEventBus:  accountSyncEventBus,
+		accountSync:          accountSync,
+		eventDeduplicator:    eventDeduplicator,
+		webhookDispatcher:    webhookDispatcher,
+		eventJournal:         eventJournal,
+		leaderboardRefresher: leaderboard.NewRefresher(config, connPool, logger),
+		leaderboardSnapshots: leaderboard.NewSnapshotter(config, connPool, logger),
+		seasonCloser:         leaderboard.NewSeasonCloser(config, connPool, leaderboardEventBus, logger),
+		rankWatcher:          leaderboard.NewRankWatcher(config, connPool, leaderboardEventBus, logger),
+		leaderboardLive:      leaderboard.NewLiveFeed(config, connPool, logger),
+		completionPartitions: gamification.NewPartitionMaintainer(config, connPool, logger),
+		policyEngine:         policyEngine,
+		permissionCache:      permissionCache,
+		usageTracker:         authz.NewUsageTracker(),
+		requestAudit:         requestAudit,
+		clientIPResolver:     clientIPResolver,
+		adminStream:          adminStream,
+		bodyLogSampler:       bodyLogSampler,
+		graphqlSchema:        graphqlSchema,
+		grpcServer:           grpcServer,
+		secretRefresher:      secretRefresher,
+		reloader:             newReloader(config),
+		tokenRotation:        utils.NewTokenRotationScheduler(repository.New(connPool), logger),
 	}, nil
 }
 
 // Starts the application server
 func (a *App) Start(ctx context.Context) error {
 
-	database.RunGooseMigrations(a.logger, a.pool)
+	// Servers starting together wait for each other to migrate, followers
+	// leave migrating to the leader altogether
+	if a.config.DatabaseConfig.AutoMigrate && a.config.DatabaseConfig.MigrationLeader {
+		database.RunGooseMigrations(ctx, a.logger, a.pool)
+	} else if pending, err := database.HasPendingMigrations(ctx, a.pool); err != nil {
+		a.logger.Warn("Failed to check for pending migrations", slog.Any("error", err))
+	} else if pending {
+		a.logger.Warn("The database has pending migrations, apply them with `verisafe migrate up`")
+	}
 
-	allowedOrigins := []string{
-		"http://localhost:1337",
-		"https://academia.opencrafts.io",
+	if a.config.DatabaseConfig.RBACSeedOnStartup {
+		if err := seedRBAC(ctx, a.logger, a.config, a.pool); err != nil {
+			return err
+		}
 	}
 
 	middlewares := middleware.CreateStack(
+		middleware.RequestID(),
+		middleware.WithClientInfo(a.clientIPResolver),
 		middleware.Logging(a.logger),
+		middleware.LoadShedding(a.reloader, a.pool, a.logger),
+		middleware.WithTenant(a.config),
 		middleware.WithDBConnection(a.logger, a.pool),
-		middleware.CORSMiddleware(allowedOrigins),
+		middleware.WithReadReplica(a.logger, a.replicaPool),
+		middleware.WithPermissionCache(a.permissionCache),
+		middleware.WithPolicyEngine(a.policyEngine),
+		middleware.WithUsageTracker(a.usageTracker),
+		middleware.WithSecurityEventBus(a.securityEventBus),
+		middleware.CORSMiddleware(a.reloader),
+		middleware.CSRFProtection(a.config,
+			// Protected by the OAuth state instead, providers cannot send
+			// the CSRF token along
+			"/api/v1/auth/{provider}/callback",
+			"/auth/{provider}/callback",
+		),
+		// Innermost so that they see the route the router matched
+		middleware.AuditMutations(a.requestAudit),
+		middleware.LogSampledBodies(a.bodyLogSampler, a.logger),
+		middleware.SlowRequests(a.logger,
+			time.Duration(a.config.SlowRequestConfig.ThresholdMilliseconds)*time.Millisecond,
+		),
 	)
-	router := a.loadRoutes()
+	router, internalRouter := a.loadRoutes()
+
+	// Background jobs outlive ctx until the servers are drained
+	jobs := newBackgroundJobs(ctx)
+	defer jobs.stop()
+	jobs.run(a.webhookDispatcher.Start)
+	jobs.run(a.eventJournal.Start)
+	jobs.run(a.eventDeduplicator.Start)
+	jobs.run(a.policyEngine.Start)
+	jobs.run(a.leaderboardRefresher.Start)
+	jobs.run(a.leaderboardSnapshots.Start)
+	jobs.run(a.seasonCloser.Start)
+	jobs.run(a.rankWatcher.Start)
+	jobs.run(a.leaderboardLive.Start)
+	jobs.run(a.completionPartitions.Start)
+	jobs.run(a.secretRefresher.Start)
+	jobs.run(a.tokenRotation.StartScheduler)
+	jobs.run(a.watchReloads)
+	if a.accountSync != nil {
+		jobs.run(a.accountSync.Start)
+	}
+	if a.requestAudit != nil {
+		jobs.run(a.requestAudit.Start)
+	}
+	if a.bodyLogSampler != nil {
+		jobs.run(a.bodyLogSampler.Start)
+	}
+	if a.adminStream != nil {
+		if err := a.adminStream.Start(a.securityEventBus, a.userEventBus); err != nil {
+			a.logger.Error("Failed to relay events to the admin stream", slog.Any("error", err))
+		}
+	}
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", a.config.AppConfig.Address, a.config.AppConfig.Port),
 		Handler: middlewares(router),
 	}
+	// Event streams and live leaderboard WebSockets never end on their own,
+	// shutting down waits for every request to end
+	srv.RegisterOnShutdown(a.adminStream.Close)
+	srv.RegisterOnShutdown(a.leaderboardLive.Close)
+
+	// Metrics, health, admin and debug routes are served on a port of their
+	// own when configured so that they are never reachable from the public
+	// ingress
+	var internalSrv *http.Server
+	if a.config.AppConfig.InternalPort != 0 {
+		internalSrv = &http.Server{
+			Addr:    fmt.Sprintf("%s:%d", a.config.AppConfig.InternalAddress, a.config.AppConfig.InternalPort),
+			Handler: middlewares(internalRouter),
+		}
+		internalSrv.RegisterOnShutdown(a.adminStream.Close)
+	}
 
 	errCh := make(chan error, 1)
 
@@ -107,6 +381,28 @@ func (a *App) Start(ctx context.Context) error {
 		close(errCh)
 	}()
 
+	// Errors of the listeners besides the public one
+	listenerErrCh := make(chan error, 2)
+	if internalSrv != nil {
+		go func() {
+			err := internalSrv.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				listenerErrCh <- fmt.Errorf("failed to listen and serve internal routes: %w", err)
+			}
+		}()
+		a.logger.Info("internal server running",
+			slog.String("Address", a.config.AppConfig.InternalAddress),
+			slog.Int("port", a.config.AppConfig.InternalPort),
+		)
+	}
+	if a.grpcServer != nil {
+		go func() {
+			if err := a.grpcServer.ListenAndServe(); err != nil {
+				listenerErrCh <- fmt.Errorf("failed to serve gRPC: %w", err)
+			}
+		}()
+	}
+
 	a.logger.Info("server running",
 		slog.String("Address", a.config.AppConfig.Address),
 		slog.Int("port", a.config.AppConfig.Port),
@@ -118,14 +414,107 @@ func (a *App) Start(ctx context.Context) error {
 		break
 	case err := <-errCh:
 		return err
+	case err := <-listenerErrCh:
+		srv.Close()
+		return err
 	}
 
-	sCtx, cancel := context.WithTimeout(context.Background(), time.Second*15)
-	defer cancel()
+	a.shutdown(srv, internalSrv, jobs)
+	return nil
+}
+
+// Creates the database connection pool from the application configuration.
+// Connections are opened with the latest database password of the secret
+// refresher when one is given
+func newConnectionPool(logger *slog.Logger, config *config.Config, secretRefresher *secrets.Refresher) (*pgxpool.Pool, error) {
+	dbConfig, err := pgxpool.ParseConfig(fmt.Sprintf(
+		"postgresql://%s:%s@%s:%d/%s?sslmode=disable",
+		config.DatabaseConfig.DatabaseUser,
+		config.DatabaseConfig.DatabasePassword,
+		config.DatabaseConfig.DatabaseHost,
+		config.DatabaseConfig.DatabasePort,
+		config.DatabaseConfig.DatabaseName,
+	))
+	if err != nil {
+		return nil, err
+	}
 
-	srv.Shutdown(sCtx)	
-	a.userEventBus.Close()
-	a.institutionEventBus.Close()
-	a.notificationEventBus.Close()
+	configurePool(logger, config, dbConfig, "primary")
+	if secretRefresher != nil {
+		dbConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+			if password, ok := secretRefresher.Get("DB_PASSWORD"); ok {
+				connConfig.Password = password
+			}
+			return nil
+		}
+	}
+	return pgxpool.NewWithConfig(context.Background(), dbConfig)
+}
+
+// Creates the read replica connection pool, nil when no replica is
+// configured
+func newReplicaPool(logger *slog.Logger, config *config.Config) (*pgxpool.Pool, error) {
+	if config.DatabaseConfig.ReadReplicaDSN == "" {
+		return nil, nil
+	}
+
+	dbConfig, err := pgxpool.ParseConfig(config.DatabaseConfig.ReadReplicaDSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_READ_REPLICA_DSN: %w", err)
+	}
+
+	configurePool(logger, config, dbConfig, "replica")
+	return pgxpool.NewWithConfig(context.Background(), dbConfig)
+}
+
+// Applies the pool settings shared by the primary and the replica pools
+func configurePool(logger *slog.Logger, config *config.Config, dbConfig *pgxpool.Config, name string) {
+	dbConfig.MaxConns = config.DatabaseConfig.DatabasePoolMaxConnections
+	dbConfig.MinConns = config.DatabaseConfig.DatabasePoolMinConnections
+	dbConfig.MaxConnLifetime = time.Hour * time.Duration(config.DatabaseConfig.DatabasePoolMaxConnectionLifetime)
+	// Measures every query and counts the queries requests make for the
+	// slow request logs
+	dbConfig.ConnConfig.Tracer = &dbstats.Tracer{
+		Pool:               name,
+		SlowQueryThreshold: time.Duration(config.SlowRequestConfig.QueryThresholdMilliseconds) * time.Millisecond,
+		Logger:             logger,
+		RequestID: func(ctx context.Context) string {
+			requestID, _ := ctx.Value(middleware.RequestIDContextKey).(string)
+			return requestID
+		},
+	}
+	// Connections are restricted to the accounts of the tenant they are
+	// acquired for
+	if config.TenancyConfig.Enabled {
+		tenancy.ConfigurePool(dbConfig)
+	}
+}
+
+// Seed migrates the database, applies the RBAC seed and creates the initial
+// administrator and service token without starting the server so that fresh
+// environments can be prepared ahead of time
+func Seed(ctx context.Context, logger *slog.Logger, config *config.Config) error {
+	pool, err := newConnectionPool(logger, config, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	database.RunGooseMigrations(ctx, logger, pool)
+	if err := seedRBAC(ctx, logger, config, pool); err != nil {
+		return err
+	}
+	return seedAccounts(ctx, logger, config, pool)
+}
+
+// Loads the configured RBAC seed and applies it
+func seedRBAC(ctx context.Context, logger *slog.Logger, config *config.Config, pool *pgxpool.Pool) error {
+	seed, err := database.LoadRBACSeed(config.DatabaseConfig.RBACSeedFile)
+	if err != nil {
+		return fmt.Errorf("failed to load rbac seed: %w", err)
+	}
+	if err := database.RunRBACSeed(ctx, logger, pool, seed); err != nil {
+		return fmt.Errorf("failed to apply rbac seed: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Roles held by the accounts created when bootstrapping
+const (
+	bootstrapAdminRole = "Administrator"
+	bootstrapBotRole   = "bot"
+)
+
+// seedAccounts creates the initial administrator and the bot account holding
+// the initial service token configured with the SEED_ variables, so that a
+// fresh environment can be used without hand-written SQL. Like the RBAC seed
+// it only adds what is missing and running it again has no effect
+func seedAccounts(ctx context.Context, logger *slog.Logger, config *config.Config, pool *pgxpool.Pool) error {
+	boot := config.BootstrapConfig
+	if boot.AdminEmail == "" && boot.BotEmail == "" {
+		return nil
+	}
+
+	req := handlers.ServiceTokenRequest{
+		Name:          boot.ServiceTokenName,
+		ExpiresInDays: &boot.ServiceTokenExpiresInDays,
+	}
+	for _, scope := range boot.ServiceTokenScopes {
+		scope = strings.TrimSpace(scope)
+		if !utils.IsValidServiceTokenScope(scope) {
+			return fmt.Errorf("invalid SEED_SERVICE_TOKEN_SCOPES scope %q", scope)
+		}
+		req.Scopes = append(req.Scopes, scope)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin seed transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	// Accounts take the tenant of the connection they are created with
+	if config.TenancyConfig.Enabled {
+		if err := repo.SetCurrentTenant(ctx, config.TenancyConfig.DefaultTenant); err != nil {
+			return fmt.Errorf("failed to switch to the default tenant: %w", err)
+		}
+	}
+
+	if boot.AdminEmail != "" {
+		email := utils.NormalizeEmail(boot.AdminEmail)
+		if _, err := seedAccount(ctx, logger, repo, email, boot.AdminName, repository.AccountTypeHuman, bootstrapAdminRole); err != nil {
+			return err
+		}
+	}
+
+	if boot.BotEmail != "" {
+		email := utils.NormalizeEmail(boot.BotEmail)
+		bot, err := seedAccount(ctx, logger, repo, email, boot.BotName, repository.AccountTypeBot, bootstrapBotRole)
+		if err != nil {
+			return err
+		}
+		if err := seedServiceToken(ctx, logger, repo, bot, boot.ServiceToken, req); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit seed transaction: %w", err)
+	}
+	return nil
+}
+
+// seedAccount makes sure the account with the email exists and holds a role
+// and returns it
+func seedAccount(ctx context.Context, logger *slog.Logger, repo *repository.Queries, email, name string, accountType repository.AccountType, roleName string) (repository.Account, error) {
+	if name == "" {
+		name = email
+	}
+	account, created, err := ensureAccount(ctx, repo, email, name, accountType)
+	if err != nil {
+		return account, err
+	}
+	if created {
+		logger.Info("Seeded account",
+			slog.String("account_id", account.ID.String()),
+			slog.String("email", account.Email),
+			slog.String("type", string(account.Type)),
+		)
+	}
+
+	granted, err := grantRole(ctx, repo, account, roleName)
+	if err != nil {
+		return account, err
+	}
+	if granted {
+		logger.Info("Seeded role assignment",
+			slog.String("account_id", account.ID.String()),
+			slog.String("role", roleName),
+		)
+	}
+	return account, nil
+}
+
+// seedServiceToken stores the hash of the initial service token for the bot
+// account, unless it was stored already
+func seedServiceToken(ctx context.Context, logger *slog.Logger, repo *repository.Queries, bot repository.Account, token string, req handlers.ServiceTokenRequest) error {
+	existing, err := repo.GetServiceTokenByHash(ctx, utils.HashToken(token))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to look up the initial service token: %w", err)
+	case existing.AccountID != bot.ID:
+		return fmt.Errorf("SEED_SERVICE_TOKEN is held by another account than %s", bot.Email)
+	default:
+		return nil
+	}
+
+	// Revoked, expired and used up tokens are not found above but keep
+	// their hash, which is unique
+	serviceToken, err := createServiceToken(ctx, repo, bot, token, req)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return fmt.Errorf("SEED_SERVICE_TOKEN was revoked, expired or used up, set a new one")
+	} else if err != nil {
+		return err
+	}
+
+	logger.Info("Seeded service token",
+		slog.String("service_token_id", serviceToken.ID.String()),
+		slog.String("account_id", bot.ID.String()),
+	)
+	return nil
+}
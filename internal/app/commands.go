@@ -0,0 +1,20 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// parseFlags parses the options of a command, wrapping problems in its usage
+// error so that the caller shows how the command is used
+func parseFlags(flags *flag.FlagSet, args []string, errUsage error) error {
+	flags.SetOutput(io.Discard)
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// Paths of the routes that are only served on the internal listener when it
+// is configured. Paths ending in a slash match every route below them
+var internalRoutePaths = []string{
+	"/metrics",
+	"/health",
+	"/readyz",
+	apiV1Prefix + "/admin/",
+	"/debug/",
+}
+
+// Paths of the routes served on both listeners so that probes can use either
+var sharedRoutePaths = map[string]bool{
+	"/ping": true,
+}
+
+// routePath returns the path of a route pattern. Patterns may start with a
+// method, e.g. GET /metrics
+func routePath(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+// isInternalRoute reports whether the route a pattern names is only served
+// on the internal listener
+func isInternalRoute(pattern string) bool {
+	pattern = routePath(pattern)
+	for _, path := range internalRoutePaths {
+		if pattern == path || (strings.HasSuffix(path, "/") && strings.HasPrefix(pattern, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictRoutes serves the internal routes of the router when internal is
+// set and every other route otherwise, the shared routes are served either
+// way. Requests for the routes of the other listener are answered with 404
+// Not Found as if the routes did not exist. Routes are told apart by the
+// pattern the router matches rather than the path so that paths the router
+// would clean up cannot slip through
+func restrictRoutes(router *http.ServeMux, internal bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := router.Handler(r)
+		if pattern != "" && !sharedRoutePaths[routePath(pattern)] && isInternalRoute(pattern) != internal {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Not found"})
+			return
+		}
+		router.ServeHTTP(w, r)
+	})
+}
+
+// registerDebugRoutes serves the Go runtime profiles. They are only
+// registered along with the internal listener as they must never be public
+func registerDebugRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /debug/pprof/", pprof.Index)
+	router.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	router.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	router.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	router.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+}
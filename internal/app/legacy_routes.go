@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+)
+
+// Prefix every API route is served under
+const apiV1Prefix = "/api/v1"
+
+// Routes that were served without a version before every route moved under
+// /api/v1. Patterns ending in a slash match every route below them
+var legacyRoutePatterns = []string{
+	"/accounts/",
+	"/activity/",
+	"/auth/",
+	"/institutions/",
+	"/leaderboard/",
+	"/permissions",
+	"/permissions/",
+	"/roles",
+	"/roles/",
+	"/socials/",
+	"/streaks/",
+	"/users/",
+	"/vibepoints/",
+}
+
+// registerLegacyRoutes keeps serving the unversioned routes by handing every
+// request to the /api/v1 route that succeeded it. Responses tell clients to
+// move on through the Deprecation and Link headers. The aliases are
+// temporary and can be turned off through LEGACY_ROUTES
+func registerLegacyRoutes(router *http.ServeMux) {
+	alias := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := apiV1Prefix + r.URL.Path
+
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		versioned := r.Clone(r.Context())
+		versioned.URL.Path = successor
+		if r.URL.RawPath != "" {
+			versioned.URL.RawPath = apiV1Prefix + r.URL.RawPath
+		}
+		router.ServeHTTP(w, versioned)
+
+		// Middlewares wrapping the router, like the request audit, look at
+		// the route that served the request rather than the alias
+		r.Pattern = versioned.Pattern
+		for _, name := range middleware.PatternWildcards(versioned.Pattern) {
+			r.SetPathValue(name, versioned.PathValue(name))
+		}
+	})
+
+	for _, pattern := range legacyRoutePatterns {
+		router.Handle(pattern, alias)
+	}
+}
@@ -0,0 +1,93 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/opencrafts-io/verisafe/database"
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// MigrateUsage describes the migrate command
+const MigrateUsage = `Usage: verisafe migrate <command>
+
+Commands:
+  up             Apply every pending migration
+  down           Roll the latest applied migration back
+  status         List the migrations and whether they were applied
+  create <name>  Write an empty migration to database/migrations
+`
+
+// ErrMigrateUsage is returned when the migrate command is used wrongly, the
+// caller should show MigrateUsage
+var ErrMigrateUsage = errors.New("invalid migrate command")
+
+// Migrate runs a migrate command, writing what it did to out, so that
+// operators decide when the schema changes rather than every server start
+func Migrate(ctx context.Context, logger *slog.Logger, config *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", ErrMigrateUsage)
+	}
+
+	// Creating a migration only writes a file, no database is needed
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return fmt.Errorf("%w: create takes the name of the migration", ErrMigrateUsage)
+		}
+		path, err := database.CreateMigration(database.MigrationsDir, args[1])
+		if err != nil {
+			return fmt.Errorf("failed to create migration: %w", err)
+		}
+		fmt.Fprintf(out, "Created %s\n", path)
+		return nil
+	}
+
+	pool, err := newConnectionPool(logger, config, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	switch args[0] {
+	case "up":
+		results, err := database.MigrateUp(ctx, pool)
+		for _, result := range results {
+			fmt.Fprintln(out, result)
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Fprintln(out, "The database is up to date")
+		}
+	case "down":
+		result, err := database.MigrateDown(ctx, pool)
+		if result != nil {
+			fmt.Fprintln(out, result)
+		}
+		return err
+	case "status":
+		statuses, err := database.MigrationStatus(ctx, pool)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIGRATION\tSTATE\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := ""
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", filepath.Base(status.Source.Path), status.State, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("%w: unknown command %q", ErrMigrateUsage, args[0])
+	}
+	return nil
+}
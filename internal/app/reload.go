@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// newReloader creates the reloader of the configuration the app starts with
+func newReloader(cfg *config.Config) *config.Reloader {
+	return config.NewReloader(cfg)
+}
+
+// OnReload registers a function called with the new configuration whenever
+// it is reloaded
+func (a *App) OnReload(listener func(*config.Config)) {
+	a.reloader.OnReload(listener)
+}
+
+// watchReloads reloads the configuration whenever Verisafe receives SIGHUP,
+// until the context is cancelled
+func (a *App) watchReloads(ctx context.Context) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangups:
+			a.reload()
+		}
+	}
+}
+
+// reload applies the settings that can change without a restart and tells
+// which changed settings wait for the next start
+func (a *App) reload() {
+	applied, pending, err := a.reloader.Reload()
+	if err != nil {
+		a.logger.Error("Failed to reload configuration, keeping the current one", slog.Any("error", err))
+		return
+	}
+
+	a.logger.Info("Reloaded configuration", slog.Any("applied", applied))
+	if len(pending) > 0 {
+		a.logger.Warn("Changed settings only apply on the next start", slog.Any("sections", pending))
+	}
+}
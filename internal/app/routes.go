@@ -4,19 +4,24 @@ import (
 	"net/http"
 
 	"github.com/opencrafts-io/verisafe/internal/auth"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
 )
 
-func (a *App) loadRoutes() http.Handler {
+// loadRoutes returns the handlers of the public and the internal listener.
+// The internal handler is nil when no internal listener is configured
+func (a *App) loadRoutes() (http.Handler, http.Handler) {
 	router := http.NewServeMux()
 
 	auth, err := auth.NewAuthenticator(a.config, a.userEventBus, a.logger)
 	if err != nil {
 		a.logger.Error("Failed to initialize authenticator", "error", err)
 		// Return a simple error handler if auth initialization fails
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		unavailable := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
 		})
+		return unavailable, unavailable
 	}
 	accountHandler := handlers.AccountHandler{
 		Logger:       a.logger,
@@ -25,18 +30,76 @@ func (a *App) loadRoutes() http.Handler {
 	}
 	serviceTokenHandler := handlers.ServiceTokenHandler{Logger: a.logger, Cfg: a.config}
 	socialHandler := handlers.SocialHandler{Logger: a.logger}
-	roleHandler := handlers.RoleHandler{Logger: a.logger}
-	permHandler := handlers.PermissionHandler{Logger: a.logger}
+	roleHandler := handlers.RoleHandler{
+		Logger:               a.logger,
+		Cfg:                  a.config,
+		PolicyEngine:         a.policyEngine,
+		AuthzEventBus:        a.authzEventBus,
+		RoleEventBus:         a.roleEventBus,
+		NotificationEventBus: a.notificationEventBus,
+	}
+	permHandler := handlers.PermissionHandler{
+		Logger:        a.logger,
+		PolicyEngine:  a.policyEngine,
+		AuthzEventBus: a.authzEventBus,
+	}
 	institutionHandler := handlers.InstitutionHandler{
+		Logger:               a.logger,
+		Cfg:                  a.config,
+		InstitutionEventBus:  a.institutionEventBus,
+		NotificationEventBus: a.notificationEventBus,
+	}
+	leaderboardHandler := handlers.LeaderBoardHandler{Logger: a.logger, Cfg: a.config, Live: a.leaderboardLive}
+	activityHandler := handlers.ActivityHandler{Logger: a.logger}
+	streakhanlder := handlers.StreakHandler{Logger: a.logger, Cfg: a.config, NotificationEventBus: a.notificationEventBus}
+	webhookHandler := handlers.WebhookHandler{Logger: a.logger, Cfg: a.config}
+	healthHandler := handlers.HealthHandler{
+		EventBuses: map[string]eventbus.HealthReporter{
+			"user":         a.userEventBus,
+			"institution":  a.institutionEventBus,
+			"notification": a.notificationEventBus,
+			"authz":        a.authzEventBus,
+			"role":         a.roleEventBus,
+			"leaderboard":  a.leaderboardEventBus,
+			"security":     a.securityEventBus,
+		},
+	}
+	if a.accountSyncEventBus != nil {
+		healthHandler.EventBuses["account_sync"] = a.accountSyncEventBus
+	}
+	readinessHandler := handlers.ReadinessHandler{
+		Primary:  a.pool,
+		Replica:  a.replicaPool,
+		Draining: &a.draining,
+	}
+	eventReplayHandler := handlers.EventReplayHandler{Logger: a.logger, Journal: a.eventJournal}
+	// Event buses refuse to start with an invalid signing configuration, so
+	// the error was already reported
+	eventSigner, _ := eventbus.NewEventSigner(a.config)
+	eventSigningHandler := handlers.EventSigningHandler{Signer: eventSigner}
+	deadLetterHandler := handlers.DeadLetterHandler{
+		Logger:      a.logger,
+		DeadLetters: eventbus.NewDeadLetterQueue(a.config, eventSigner, a.logger),
+	}
+	requestAuditHandler := handlers.RequestAuditHandler{Logger: a.logger}
+	deletedRecordsHandler := handlers.DeletedRecordsHandler{
 		Logger:              a.logger,
 		InstitutionEventBus: a.institutionEventBus,
+		AuthzEventBus:       a.authzEventBus,
+	}
+	openAPIHandler := handlers.OpenAPIHandler{SwaggerUI: a.config.AppConfig.SwaggerUI}
+	policyHandler := handlers.AuthorizationPolicyHandler{
+		Logger: a.logger,
+		Cfg:    a.config,
+		Engine: a.policyEngine,
 	}
-	leaderboardHandler := handlers.LeaderBoardHandler{Logger: a.logger}
-	activityHandler := handlers.ActivityHandler{Logger: a.logger}
-	streakhanlder := handlers.StreakHandler{Logger: a.logger, NotificationEventBus: a.notificationEventBus}
 
 	// ping handler
 	router.HandleFunc("GET /ping", handlers.PingHandler)
+	router.HandleFunc("GET /health", healthHandler.GetHealth)
+	router.HandleFunc("GET /readyz", readinessHandler.GetReadiness)
+	router.Handle("GET /metrics", metrics.Handler())
+	openAPIHandler.RegisterRoutes(router)
 
 	// Auth handlers
 	auth.RegisterRoutes(router)
@@ -51,5 +114,33 @@ func (a *App) loadRoutes() http.Handler {
 	leaderboardHandler.RegisterLeaderBoardHandlers(a.config, router)
 	activityHandler.RegisterHadlers(a.config, router)
 	streakhanlder.RegisterRoutes(a.config, router)
-	return router
+	webhookHandler.RegisterRoutes(router)
+	policyHandler.RegisterRoutes(router)
+	eventReplayHandler.RegisterRoutes(a.config, router)
+	eventSigningHandler.RegisterRoutes(router)
+	deadLetterHandler.RegisterRoutes(a.config, router)
+	requestAuditHandler.RegisterRoutes(a.config, router)
+	deletedRecordsHandler.RegisterRoutes(a.config, router)
+	if a.adminStream != nil {
+		adminStreamHandler := handlers.AdminStreamHandler{Logger: a.logger, Hub: a.adminStream}
+		adminStreamHandler.RegisterRoutes(a.config, router)
+	}
+	if a.bodyLogSampler != nil {
+		bodyLoggingHandler := handlers.BodyLoggingHandler{Logger: a.logger, Cfg: a.config, Sampler: a.bodyLogSampler}
+		bodyLoggingHandler.RegisterRoutes(router)
+	}
+	if a.graphqlSchema != nil {
+		graphqlHandler := handlers.GraphQLHandler{Logger: a.logger, Schema: *a.graphqlSchema}
+		graphqlHandler.RegisterRoutes(a.config, router)
+	}
+
+	if a.config.AppConfig.LegacyRoutes {
+		registerLegacyRoutes(router)
+	}
+
+	if a.config.AppConfig.InternalPort == 0 {
+		return router, nil
+	}
+	registerDebugRoutes(router)
+	return restrictRoutes(router, false), restrictRoutes(router, true)
 }
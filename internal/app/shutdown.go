@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// backgroundJobs runs the jobs working next to the servers. They get a
+// context of their own, stopped once the servers are drained, so that the
+// requests still being served can rely on them
+type backgroundJobs struct {
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+// newBackgroundJobs returns the jobs of the app, carrying the values of ctx
+// but not its cancellation
+func newBackgroundJobs(ctx context.Context) *backgroundJobs {
+	jobs := &backgroundJobs{}
+	jobs.ctx, jobs.stop = context.WithCancel(context.WithoutCancel(ctx))
+	return jobs
+}
+
+// run starts a job, which must return once its context is cancelled
+func (j *backgroundJobs) run(job func(context.Context)) {
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		job(j.ctx)
+	}()
+}
+
+// stopAndWait stops the jobs and waits for them to return. It reports
+// whether they did before ctx was done
+func (j *backgroundJobs) stopAndWait(ctx context.Context) bool {
+	j.stop()
+	return waitFor(ctx, j.wg.Wait)
+}
+
+// waitFor calls fn and waits for it to return. It reports whether it did
+// before ctx was done, fn is left running otherwise
+func waitFor(ctx context.Context, fn func()) bool {
+	done := make(chan struct{})
+	go func() {
+		fn()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// shutdown drains the app once it is asked to stop. /readyz reports the
+// instance as draining for SHUTDOWN_DRAIN_DELAY while requests are still
+// served, then the listeners close and the requests in flight, followed by
+// the background jobs, get what is left of SHUTDOWN_DRAIN_TIMEOUT to end.
+// The event buses and database pools are closed last, since both are used
+// until then
+func (a *App) shutdown(srv, internalSrv *http.Server, jobs *backgroundJobs) {
+	delay := time.Duration(a.config.ShutdownConfig.DrainDelaySeconds) * time.Second
+	timeout := time.Duration(a.config.ShutdownConfig.DrainTimeoutSeconds) * time.Second
+	a.logger.Info("Shutting down",
+		slog.Duration("drain_delay", delay),
+		slog.Duration("drain_timeout", timeout),
+	)
+
+	// Clients holding a connection open are made to reconnect, to another
+	// instance once the load balancer notices
+	a.draining.Store(true)
+	srv.SetKeepAlivesEnabled(false)
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	sCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// The internal listener closes last so that metrics are scraped and
+	// probes answered while the others drain
+	shutdownServer(sCtx, a.logger, "public", srv)
+	if a.grpcServer != nil {
+		a.grpcServer.Shutdown(sCtx)
+	}
+	if internalSrv != nil {
+		shutdownServer(sCtx, a.logger, "internal", internalSrv)
+	}
+
+	if !jobs.stopAndWait(sCtx) {
+		a.logger.Warn("Background jobs did not stop within the drain timeout")
+	}
+
+	a.userEventBus.Close()
+	a.institutionEventBus.Close()
+	a.notificationEventBus.Close()
+	a.authzEventBus.Close()
+	a.roleEventBus.Close()
+	a.leaderboardEventBus.Close()
+	a.securityEventBus.Close()
+	a.clientIPResolver.Close()
+
+	// Closing a pool waits for its connections to be released, which
+	// requests that were cut off may never do
+	closePools := func() {
+		if a.replicaPool != nil {
+			a.replicaPool.Close()
+		}
+		a.pool.Close()
+	}
+	if !waitFor(sCtx, closePools) {
+		a.logger.Warn("Database connections were still in use at the end of the drain timeout")
+	}
+	a.logger.Info("Shut down")
+}
+
+// shutdownServer stops a server accepting connections and waits for its
+// requests to end, closing the connections still open once ctx is done
+func shutdownServer(ctx context.Context, logger *slog.Logger, name string, srv *http.Server) {
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Warn("Requests were cut off at the end of the drain timeout",
+			slog.String("server", name),
+			slog.Any("error", err),
+		)
+		srv.Close()
+	}
+}
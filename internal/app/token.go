@@ -0,0 +1,162 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// TokenUsage describes the token command
+const TokenUsage = `Usage: verisafe token create -account <id|email> -name <name> [options]
+
+Creates a service token for a bot account and prints it. The token is only
+shown once, Verisafe keeps its hash.
+
+Options:
+  -account <id|email>    ID or email of the bot account, required
+  -name <name>           Name of the token, required
+  -description <text>    Description of the token
+  -scopes <a,b>          Comma separated scopes of the token
+  -expires-in-days <n>   Days until the token expires, at most 3650 (default 365)
+  -max-uses <n>          Number of times the token can be used, unlimited by default
+`
+
+// ErrTokenUsage is returned when the token command is used wrongly, the
+// caller should show TokenUsage
+var ErrTokenUsage = errors.New("invalid token command")
+
+// Token runs a token command, writing the created token to out, so that the
+// first service token of a bot account is created without a user token
+func Token(ctx context.Context, logger *slog.Logger, config *config.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", ErrTokenUsage)
+	}
+	if args[0] != "create" {
+		return fmt.Errorf("%w: unknown command %q", ErrTokenUsage, args[0])
+	}
+
+	flags := flag.NewFlagSet("token create", flag.ContinueOnError)
+	accountRef := flags.String("account", "", "")
+	name := flags.String("name", "", "")
+	description := flags.String("description", "", "")
+	scopes := flags.String("scopes", "", "")
+	expiresInDays := flags.Int("expires-in-days", 365, "")
+	maxUses := flags.Int("max-uses", 0, "")
+	if err := parseFlags(flags, args[1:], ErrTokenUsage); err != nil {
+		return err
+	}
+	if *accountRef == "" {
+		return fmt.Errorf("%w: -account is required", ErrTokenUsage)
+	}
+
+	// The options are held to the rules of tokens created through the API
+	req := handlers.ServiceTokenRequest{
+		Name:          *name,
+		ExpiresInDays: expiresInDays,
+	}
+	if *description != "" {
+		req.Description = description
+	}
+	if *maxUses != 0 {
+		req.MaxUses = maxUses
+	}
+	for scope := range strings.SplitSeq(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope == "" {
+			continue
+		}
+		if !utils.IsValidServiceTokenScope(scope) {
+			return fmt.Errorf("%w: invalid scope %q", ErrTokenUsage, scope)
+		}
+		req.Scopes = append(req.Scopes, scope)
+	}
+	if fields := utils.ValidationErrors(req); fields != nil {
+		var problems []string
+		for field, problem := range fields {
+			problems = append(problems, fmt.Sprintf("-%s %s", strings.ReplaceAll(field, "_", "-"), problem))
+		}
+		slices.Sort(problems)
+		return fmt.Errorf("%w: %s", ErrTokenUsage, strings.Join(problems, ", "))
+	}
+
+	pool, err := newConnectionPool(logger, config, nil)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	var account repository.Account
+	if id, parseErr := uuid.Parse(*accountRef); parseErr == nil {
+		account, err = repo.GetAccountByID(ctx, id)
+	} else {
+		account, err = repo.GetAccountByEmail(ctx, *accountRef)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("account %q does not exist", *accountRef)
+	} else if err != nil {
+		return fmt.Errorf("failed to look up account: %w", err)
+	}
+	if account.Type != repository.AccountTypeBot {
+		return fmt.Errorf("account %s is a %s account, only bot accounts can hold service tokens", account.ID, account.Type)
+	}
+
+	token, err := utils.GenerateServiceToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	serviceToken, err := createServiceToken(ctx, repo, account, token, req)
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Created service token %s for account %s, expiring %s\n",
+		serviceToken.ID, account.ID, serviceToken.ExpiresAt.UTC().Format(time.RFC3339))
+	fmt.Fprintln(out, token)
+	return nil
+}
+
+// createServiceToken stores the hash of a token for a bot account, expiring
+// after the days of the request
+func createServiceToken(ctx context.Context, repo *repository.Queries, account repository.Account, token string, req handlers.ServiceTokenRequest) (repository.ServiceToken, error) {
+	expiresAt := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+	params := repository.CreateServiceTokenParams{
+		AccountID:   account.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		TokenHash:   utils.HashToken(token),
+		ExpiresAt:   &expiresAt,
+		Scopes:      req.Scopes,
+	}
+	if req.MaxUses != nil {
+		uses := int32(*req.MaxUses)
+		params.MaxUses = &uses
+	}
+
+	serviceToken, err := repo.CreateServiceToken(ctx, params)
+	if err != nil {
+		return serviceToken, fmt.Errorf("failed to create service token: %w", err)
+	}
+	return serviceToken, nil
+}
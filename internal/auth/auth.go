@@ -51,25 +51,36 @@ func NewAuthenticator(cfg *config.Config, userEventBus *eventbus.UserEventBus, l
 	store.Options.Path = "/"
 	store.Options.HttpOnly = true
 
-	if cfg.AuthenticationConfig.Environment == "production" || cfg.AuthenticationConfig.Environment == "staging" {
+	// Lax keeps the cookie out of cross-site requests but for top-level
+	// navigations, which is all the provider redirects need. Browsers only
+	// accept SameSite=None on secure cookies
+	store.Options.Secure = cfg.SecureCookies()
+	store.Options.SameSite = http.SameSiteLaxMode
+	if cfg.AuthenticationConfig.CookieSameSite == "none" {
 		store.Options.Secure = true
 		store.Options.SameSite = http.SameSiteNoneMode
-	} else {
-		store.Options.Secure = false
-		store.Options.SameSite = http.SameSiteLaxMode
 	}
 
 	gothic.Store = store
 
 	address := ""
 
+	// Providers only redirect to the callback URLs registered with them, so
+	// callbacks keep using the unversioned route for as long as it is served
+	callbackPath := "/api/v1/auth/{oauth}/callback"
+	if cfg.AppConfig.LegacyRoutes {
+		callbackPath = "/auth/{oauth}/callback"
+	}
+
 	if cfg.AuthenticationConfig.Environment == "development" {
-		address = fmt.Sprintf("%s/auth/{oauth}/callback",
+		address = fmt.Sprintf("%s%s",
 			cfg.AuthenticationConfig.AuthAddress,
+			callbackPath,
 		)
 	} else {
-		address = fmt.Sprintf("%s/auth/{oauth}/callback",
+		address = fmt.Sprintf("%s%s",
 			cfg.AuthenticationConfig.AuthAddress,
+			callbackPath,
 		)
 
 	}
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/google/uuid"
@@ -26,10 +28,19 @@ const authPlatformWebValue = "auth.platform.value.web"
 const authPlatformMobileValue = "auth.platform.value.mobile"
 const authRedirectKey = "auth.redirect.key"
 
+// Number of random bytes in the nonce of the OAuth state
+const stateNonceBytes = 16
+
+// ErrEmailDomainBlocked is returned when signing up with an email whose domain
+// is in the configured blocklist
+var ErrEmailDomainBlocked = errors.New("email domain is not allowed")
+
 // StateData represents the encoded state information passed during OAuth flow
 type StateData struct {
 	Platform    string
 	RedirectURI string
+	// The tenant the login was started on, empty when tenancy is disabled
+	Tenant string
 }
 
 type appleUserJSON struct {
@@ -41,13 +52,14 @@ type appleUserJSON struct {
 }
 
 func (a *Auth) RegisterRoutes(router *http.ServeMux) {
-	router.HandleFunc("GET /auth/{provider}", a.LoginHandler)
-	router.HandleFunc("/auth/{provider}/callback", a.CallbackHandler)
-	router.HandleFunc("GET /auth/{provider}/logout", a.LogoutHandler)
-	router.HandleFunc("POST /auth/token/refresh", a.RefreshTokenHandler)
+	router.HandleFunc("GET /api/v1/auth/{provider}", a.LoginHandler)
+	router.HandleFunc("/api/v1/auth/{provider}/callback", a.CallbackHandler)
+	router.HandleFunc("GET /api/v1/auth/{provider}/logout", a.LogoutHandler)
+	router.HandleFunc("POST /api/v1/auth/token/refresh", a.RefreshTokenHandler)
+	router.HandleFunc("GET /api/v1/auth/csrf", a.CSRFTokenHandler)
 
 	// Secret management
-	// router.Handle("GET /auth/generate/token",
+	// router.Handle("GET /api/v1/auth/generate/token",
 	// 	middleware.CreateStack(
 	// 		middleware.IsAuthenticated(a.config, a.logger),
 	// 		middleware.HasPermission([]string{"create:service_token:own"}),
@@ -79,10 +91,24 @@ func (a *Auth) LoginHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Programming error: missing redirect_uri", http.StatusBadRequest)
 			return
 		}
+		// The redirect receives the tokens of the account signing in
+		if !a.config.WebRedirectAllowed(redirectURI) {
+			a.logger.Warn("Refused web login redirect", "redirect_uri", redirectURI)
+			http.Error(w, "redirect_uri is not allowed", http.StatusBadRequest)
+			return
+		}
 	}
 
-	// encode platform + redirect_uri into state
-	stateData := fmt.Sprintf("%s|%s", platform, redirectURI)
+	// encode platform + tenant + redirect_uri into state. The nonce makes the
+	// state unguessable, gothic rejects callbacks whose state differs from the
+	// one kept in the session cookie of the browser that started the flow
+	nonce := make([]byte, stateNonceBytes)
+	if _, err := rand.Read(nonce); err != nil {
+		a.logger.Error("Failed to generate state nonce", "error", err)
+		http.Error(w, "Failed to initiate login", http.StatusInternalServerError)
+		return
+	}
+	stateData := fmt.Sprintf("%s|%s|%s|%s", platform, base64.RawURLEncoding.EncodeToString(nonce), middleware.GetTenant(r.Context()), redirectURI)
 	state := base64.URLEncoding.EncodeToString([]byte(stateData))
 
 	a.logger.Info("Initiating OAuth login",
@@ -119,6 +145,16 @@ func (a *Auth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Invalid request", http.StatusBadRequest)
 			return
 		}
+
+		// Providers posting the callback from their own site, like Apple,
+		// do not get the SameSite=Lax session cookie sent along. Repeating
+		// the callback as a top-level GET brings the cookie back
+		if _, err := r.Cookie(gothic.SessionName); err != nil {
+			callback := *r.URL
+			callback.RawQuery = r.PostForm.Encode()
+			http.Redirect(w, r, callback.String(), http.StatusSeeOther)
+			return
+		}
 	}
 
 	provider, err := GetProviderName(r)
@@ -146,10 +182,32 @@ func (a *Auth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 	user, err := a.completeOAuthAuth(w, r)
 	if err != nil {
 		a.logger.Error("OAuth authentication failed", slog.Any("error", err))
+		middleware.ReportSecurityEvent(r, eventbus.SecurityLoginFailed, eventbus.SecurityActivity{
+			Outcome:  eventbus.OutcomeFailure,
+			Severity: eventbus.SeverityWarning,
+			Method:   provider,
+			Reason:   "oauth authentication failed",
+		})
 		http.Error(w, "Authentication flow failed", http.StatusInternalServerError)
 		return
 	}
 
+	// Providers call back on a single host, the login belongs to the tenant
+	// it was started on. The state can be trusted now that gothic matched it
+	// against the session of the browser that started the flow
+	if a.config.TenancyConfig.Enabled && stateData.Tenant != middleware.GetTenant(r.Context()) {
+		if !a.config.IsTenant(stateData.Tenant) {
+			http.Error(w, "Unknown tenant", http.StatusBadRequest)
+			return
+		}
+		r, err = middleware.SwitchTenant(r, stateData.Tenant)
+		if err != nil {
+			a.logger.Error("Failed to switch to the tenant of the login", slog.Any("error", err))
+			http.Error(w, "Failed to establish database connection", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if provider == "apple" {
 		if user.FirstName == "" && (appleData.Name.FirstName != "" || appleData.Name.LastName != "") {
 			user.FirstName = appleData.Name.FirstName
@@ -169,6 +227,19 @@ func (a *Auth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Handle account creation or retrieval
 	account, err := a.handleAccountManagement(r, repo, user)
+	if errors.Is(err, ErrEmailDomainBlocked) {
+		a.logger.Warn("Rejected signup from blocked email domain",
+			slog.String("domain", utils.EmailDomain(user.Email)),
+		)
+		middleware.ReportSecurityEvent(r, eventbus.SecurityLoginFailed, eventbus.SecurityActivity{
+			Outcome:  eventbus.OutcomeFailure,
+			Severity: eventbus.SeverityWarning,
+			Method:   provider,
+			Reason:   "email domain blocked",
+		})
+		http.Error(w, "Accounts cannot be created with this email domain", http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		a.logger.Error("Account management failed", slog.Any("error", err))
 		http.Error(w, "Failed to manage account", http.StatusInternalServerError)
@@ -197,6 +268,14 @@ func (a *Auth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to generate tokens", http.StatusInternalServerError)
 		return
 	}
+
+	middleware.ReportSecurityEvent(r, eventbus.SecurityLoginSucceeded, eventbus.SecurityActivity{
+		Outcome:   eventbus.OutcomeSuccess,
+		Severity:  eventbus.SeverityInfo,
+		AccountID: account.ID.String(),
+		ActorID:   account.ID.String(),
+		Method:    provider,
+	})
 }
 
 // parseStateData extracts and validates the state parameter from the request
@@ -211,15 +290,22 @@ func (a *Auth) parseStateData(r *http.Request) (*StateData, error) {
 		return nil, errors.New("invalid state")
 	}
 
-	parts := strings.SplitN(string(stateBytes), "|", 2)
-	if len(parts) != 2 {
-		return nil, errors.New("malformed state")
-	}
-
-	return &StateData{
-		Platform:    parts[0],
-		RedirectURI: parts[1],
-	}, nil
+	// States issued before tenants were recorded carry no tenant
+	parts := strings.SplitN(string(stateBytes), "|", 4)
+	switch len(parts) {
+	case 3:
+		return &StateData{
+			Platform:    parts[0],
+			RedirectURI: parts[2],
+		}, nil
+	case 4:
+		return &StateData{
+			Platform:    parts[0],
+			Tenant:      parts[2],
+			RedirectURI: parts[3],
+		}, nil
+	}
+	return nil, errors.New("malformed state")
 }
 
 // completeOAuthAuth completes the OAuth authentication flow using Goth
@@ -254,10 +340,25 @@ func (a *Auth) handleAccountManagement(r *http.Request, repo *repository.Queries
 		return repository.Account{}, fmt.Errorf("failed to check user existence: %w", err)
 	}
 
+	// A successful OAuth login reactivates a deactivated account
+	if err == nil && account.DeactivatedAt != nil {
+		if err := repo.ReactivateAccount(r.Context(), account.ID); err != nil {
+			return repository.Account{}, fmt.Errorf("failed to reactivate account: %w", err)
+		}
+		account.DeactivatedAt = nil
+		a.logger.Info("Deactivated account reactivated on login",
+			slog.String("account_id", account.ID.String()),
+		)
+	}
+
 	// Create user if they don't exist
 	if errors.Is(err, pgx.ErrNoRows) {
+		if utils.IsEmailDomainBlocked(user.Email, a.config.AuthenticationConfig.BlockedEmailDomains) {
+			return repository.Account{}, ErrEmailDomainBlocked
+		}
+
 		userParams := repository.CreateAccountParams{
-			Email:     user.Email,
+			Email:     utils.NormalizeEmail(user.Email),
 			Name:      strings.Join([]string{user.FirstName, user.LastName}, " "),
 			Type:      repository.AccountTypeHuman,
 			AvatarUrl: &user.AvatarURL,
@@ -268,9 +369,25 @@ func (a *Auth) handleAccountManagement(r *http.Request, repo *repository.Queries
 			return repository.Account{}, fmt.Errorf("failed to create account: %w", err)
 		}
 
+		// Emails returned by the OAuth provider are verified so the account
+		// joins every institution that verified the email's domain
+		institutions, err := repo.JoinInstitutionsByEmailDomain(r.Context(), repository.JoinInstitutionsByEmailDomainParams{
+			AccountID: account.ID,
+			Domain:    utils.EmailDomain(account.Email),
+		})
+		if err != nil {
+			return repository.Account{}, fmt.Errorf("failed to join institutions by email domain: %w", err)
+		}
+		if len(institutions) > 0 {
+			a.logger.Info("Account joined institutions by email domain",
+				slog.String("account_id", account.ID.String()),
+				slog.Any("institutions", institutions),
+			)
+		}
+
 		// Publish user created event
 		if a.eventBus != nil {
-			requestID := eventbus.GenerateRequestID()
+			requestID := middleware.GetRequestID(r.Context())
 			if err := a.eventBus.PublishUserCreated(r.Context(), account, requestID); err != nil {
 				a.logger.Error("Failed to publish user created event",
 					slog.String("error", err.Error()),
@@ -344,7 +461,7 @@ func (a *Auth) handleSocialAccountManagement(r *http.Request, repo *repository.Q
 
 		// Publish user updated event for existing users
 		if a.eventBus != nil {
-			requestID := eventbus.GenerateRequestID()
+			requestID := middleware.GetRequestID(r.Context())
 			if err := a.eventBus.PublishUserUpdated(r.Context(), account, requestID); err != nil {
 				a.logger.Error("Failed to publish user updated event",
 					slog.String("error", err.Error()),
@@ -361,32 +478,43 @@ func (a *Auth) handleSocialAccountManagement(r *http.Request, repo *repository.Q
 
 // generateTokensAndRedirect generates JWT tokens and redirects based on platform
 func (a *Auth) generateTokensAndRedirect(w http.ResponseWriter, r *http.Request, account repository.Account, stateData *StateData) error {
-	token, err := utils.GenerateJWT(account.ID, *a.config)
+	token, err := utils.GenerateJWT(account.ID, account.TenantID, *a.config)
 	if err != nil {
 		return fmt.Errorf("failed to generate JWT token: %w", err)
 	}
 
-	refreshToken, err := utils.GenerateJWT(account.ID, *a.config, utils.UserRefreshToken)
+	refreshToken, err := utils.GenerateJWT(account.ID, account.TenantID, *a.config, utils.UserRefreshToken)
 	if err != nil {
 		return fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
 	// Redirect based on platform
-	if stateData.Platform == authPlatformWebValue {
-		// Web: redirect back to client
-		finalURL := fmt.Sprintf("%s?token=%s&refresh_token=%s", stateData.RedirectURI, token, refreshToken)
-		http.Redirect(w, r, finalURL, http.StatusFound)
-		return nil
-	}
-
-	if stateData.Platform == authPlatformMobileValue {
+	var target string
+	switch stateData.Platform {
+	case authPlatformWebValue:
+		// Web: redirect back to client. The allowed origins may have changed
+		// since the login started
+		if !a.config.WebRedirectAllowed(stateData.RedirectURI) {
+			return fmt.Errorf("redirect to %q is not allowed", stateData.RedirectURI)
+		}
+		target = stateData.RedirectURI
+	case authPlatformMobileValue:
 		// Mobile: use deep link
-		finalURL := fmt.Sprintf("academia://callback?token=%s&refresh_token=%s", token, refreshToken)
-		http.Redirect(w, r, finalURL, http.StatusFound)
-		return nil
+		target = a.config.AuthenticationConfig.MobileCallbackURL
+	default:
+		return errors.New("unknown platform")
 	}
 
-	return errors.New("unknown platform")
+	finalURL, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid redirect %q: %w", target, err)
+	}
+	query := finalURL.Query()
+	query.Set("token", token)
+	query.Set("refresh_token", refreshToken)
+	finalURL.RawQuery = query.Encode()
+	http.Redirect(w, r, finalURL.String(), http.StatusFound)
+	return nil
 }
 
 // LogoutHandler logs the user out from the OAuth provider and clears Goth's session data.
@@ -419,16 +547,12 @@ func (a *Auth) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 
 	// The refresh_token request payload
 	type RefreshTokenRequestData struct {
-		RefreshToken string `json:"refresh_token"`
+		RefreshToken string `json:"refresh_token" validate:"required"`
 	}
 
 	var refreshTokenData RefreshTokenRequestData
 
-	if err := json.NewDecoder(r.Body).Decode(&refreshTokenData); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &refreshTokenData) {
 		return
 	}
 
@@ -437,6 +561,12 @@ func (a *Auth) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		a.logger.Error("Failed to validate refresh token", slog.Any("token", refreshTokenData.RefreshToken))
+		middleware.ReportSecurityEvent(r, eventbus.SecurityLoginFailed, eventbus.SecurityActivity{
+			Outcome:  eventbus.OutcomeFailure,
+			Severity: eventbus.SeverityWarning,
+			Method:   "refresh_token",
+			Reason:   "invalid refresh token",
+		})
 		json.NewEncoder(w).Encode(map[string]any{"error": "We couldn't validate your refresh token at the moment"})
 		return
 	}
@@ -453,8 +583,45 @@ func (a *Auth) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 
 	}
 
+	// Deactivated accounts may only regain access through an OAuth login
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		a.logger.Error("Failed to get DB connection", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	account, err := repository.New(conn).GetAccountByID(r.Context(), userID)
+	if err != nil {
+		a.logger.Error("Failed to retrieve account for refresh token",
+			slog.Any("error", err),
+			slog.String("account_id", userID.String()),
+		)
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't validate your refresh token at the moment",
+		})
+		return
+	}
+	if account.DeactivatedAt != nil {
+		middleware.ReportSecurityEvent(r, eventbus.SecurityLoginFailed, eventbus.SecurityActivity{
+			Outcome:   eventbus.OutcomeFailure,
+			Severity:  eventbus.SeverityWarning,
+			AccountID: account.ID.String(),
+			Method:    "refresh_token",
+			Reason:    "account deactivated",
+		})
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "This account has been deactivated. Sign in again to reactivate it",
+		})
+		return
+	}
+
 	// Generate jwt and refresh token
-	token, err := utils.GenerateJWT(userID, *a.config)
+	token, err := utils.GenerateJWT(userID, account.TenantID, *a.config)
 	if err != nil {
 		a.logger.Error("Failed to generate user access token",
 			slog.Any("raw", userID.String()),
@@ -466,7 +633,7 @@ func (a *Auth) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	refreshToken, err := utils.GenerateJWT(userID, *a.config, utils.UserRefreshToken)
+	refreshToken, err := utils.GenerateJWT(userID, account.TenantID, *a.config, utils.UserRefreshToken)
 	if err != nil {
 		a.logger.Error("Failed to generate user refresh token",
 			slog.Any("raw", userID.String()),
@@ -483,3 +650,25 @@ func (a *Auth) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 		"refresh_token": refreshToken,
 	})
 }
+
+// CSRFTokenHandler returns the CSRF token of the browser, issuing one in the
+// verisafe_csrf cookie when it has none. Browsers send the token back in the
+// X-CSRF-Token header of state-changing requests relying on cookies
+func (a *Auth) CSRFTokenHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	token, err := middleware.IssueCSRFToken(w, r)
+	if err != nil {
+		a.logger.Error("Failed to issue CSRF token", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "Failed to issue CSRF token",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"csrf_token": token,
+	})
+}
@@ -0,0 +1,129 @@
+package authz
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Reasons reported alongside every decision of a Checker
+const (
+	ReasonRole            = "role"
+	ReasonInstitutionRole = "institution_role"
+	ReasonPolicy          = "policy"
+	ReasonDenied          = "denied"
+	ReasonDenyRule        = "deny_rule"
+	ReasonUnknownSubject  = "unknown_subject"
+)
+
+// Check asks whether a subject may perform an action on a resource
+type Check struct {
+	Subject    uuid.UUID
+	Permission string
+	// The account owning the resource, nil when the resource has no owner
+	ResourceOwnerID *uuid.UUID
+	// The institution the resource belongs to, nil when not institution bound
+	InstitutionID *int32
+}
+
+// checkSubject caches what is known about a subject across checks
+type checkSubject struct {
+	account     repository.Account
+	permissions []string
+	denied      []string
+	scoped      map[int32][]string
+}
+
+// Checker decides permission checks on behalf of downstream services. The
+// subjects it loads are kept for the lifetime of the Checker so that a batch
+// of checks about the same subject loads it once
+type Checker struct {
+	engine   *Engine
+	db       repository.DBTX
+	repo     *repository.Queries
+	subjects map[uuid.UUID]*checkSubject
+}
+
+// NewChecker creates a Checker loading subjects through db
+func (e *Engine) NewChecker(db repository.DBTX) *Checker {
+	return &Checker{
+		engine:   e,
+		db:       db,
+		repo:     repository.New(db),
+		subjects: map[uuid.UUID]*checkSubject{},
+	}
+}
+
+// Check allows the check if the subject holds the permission through a
+// global role, through a role scoped to the resource's institution or through
+// an authorization policy. The reason names which of them decided
+func (c *Checker) Check(ctx context.Context, check Check) (bool, string, error) {
+	subject, ok := c.subjects[check.Subject]
+	if !ok {
+		authorization, err := c.repo.GetAccountAuthorization(ctx, check.Subject)
+		if errors.Is(err, pgx.ErrNoRows) {
+			c.subjects[check.Subject] = nil
+			return false, ReasonUnknownSubject, nil
+		}
+		if err != nil {
+			return false, "", err
+		}
+
+		subject = &checkSubject{
+			account:     authorization.Account,
+			permissions: authorization.Permissions,
+			denied:      authorization.DeniedPermissions,
+			scoped:      map[int32][]string{},
+		}
+		c.subjects[check.Subject] = subject
+	}
+
+	if subject == nil || subject.account.DeactivatedAt != nil {
+		return false, ReasonUnknownSubject, nil
+	}
+
+	if IsDenied(subject.denied, check.Permission) {
+		return false, ReasonDenyRule, nil
+	}
+
+	if HasPermission(subject.permissions, check.Permission) {
+		return true, ReasonRole, nil
+	}
+
+	if institutionID := check.InstitutionID; institutionID != nil {
+		scoped, ok := subject.scoped[*institutionID]
+		if !ok {
+			var err error
+			scoped, err = c.repo.GetUserInstitutionPermissionNames(ctx,
+				repository.GetUserInstitutionPermissionNamesParams{
+					UserID:        subject.account.ID,
+					InstitutionID: *institutionID,
+				})
+			if err != nil {
+				return false, "", err
+			}
+			subject.scoped[*institutionID] = scoped
+		}
+		if HasPermission(scoped, check.Permission) {
+			return true, ReasonInstitutionRole, nil
+		}
+	}
+
+	allowed, err := c.engine.Evaluate(ctx, c.db, check.Permission, Attributes{
+		SubjectID:       subject.account.ID,
+		SubjectType:     subject.account.Type,
+		ResourceOwnerID: check.ResourceOwnerID,
+		InstitutionID:   check.InstitutionID,
+	})
+	if err != nil {
+		return false, "", err
+	}
+	if allowed {
+		return true, ReasonPolicy, nil
+	}
+
+	return false, ReasonDenied, nil
+}
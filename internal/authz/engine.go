@@ -0,0 +1,174 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Engine caches the active authorization policies and evaluates them
+type Engine struct {
+	pool           *pgxpool.Pool
+	logger         *slog.Logger
+	reloadInterval time.Duration
+
+	mu       sync.RWMutex
+	policies map[string][]Policy
+	aliases  map[string]string
+	loadedAt time.Time
+}
+
+// NewEngine creates a new policy Engine. Call Start to load the policies and
+// keep them up to date
+func NewEngine(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Engine {
+	reloadInterval := time.Duration(cfg.AuthorizationConfig.PolicyReloadIntervalSeconds) * time.Second
+	if reloadInterval <= 0 {
+		reloadInterval = 30 * time.Second
+	}
+
+	return &Engine{
+		pool:           pool,
+		logger:         logger,
+		reloadInterval: reloadInterval,
+		policies:       map[string][]Policy{},
+		aliases:        map[string]string{},
+	}
+}
+
+// Start loads the policies and reloads them periodically until the context is
+// cancelled so that changes made by other instances are picked up
+func (e *Engine) Start(ctx context.Context) {
+	if err := e.Reload(ctx); err != nil {
+		e.logger.Error("Failed to load authorization policies", slog.Any("error", err))
+	}
+
+	ticker := time.NewTicker(e.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Reload(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Error("Failed to reload authorization policies", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Reload replaces the cached policies and permission aliases with the ones
+// stored in the database. Policies whose conditions cannot be parsed are
+// skipped
+func (e *Engine) Reload(ctx context.Context) error {
+	repo := repository.New(e.pool)
+	rows, err := repo.GetActiveAuthorizationPolicies(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve authorization policies: %w", err)
+	}
+
+	aliasRows, err := repo.GetPermissionAliases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve permission aliases: %w", err)
+	}
+	aliases := make(map[string]string, len(aliasRows))
+	for _, row := range aliasRows {
+		aliases[row.Alias] = row.Permission
+	}
+
+	policies := map[string][]Policy{}
+	for _, row := range rows {
+		conditions, err := ParseConditions(row.Conditions)
+		if err == nil {
+			err = conditions.Validate()
+		}
+		if err != nil {
+			e.logger.Warn("Skipping invalid authorization policy",
+				slog.String("policy_id", row.ID.String()),
+				slog.String("policy", row.Name),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		policies[row.Permission] = append(policies[row.Permission], Policy{
+			ID:         row.ID,
+			Name:       row.Name,
+			Permission: row.Permission,
+			Conditions: conditions,
+		})
+	}
+
+	e.mu.Lock()
+	e.policies = policies
+	e.aliases = aliases
+	e.loadedAt = time.Now()
+	e.mu.Unlock()
+
+	return nil
+}
+
+// Policies returns the cached policies granting the permission
+func (e *Engine) Policies(permission string) []Policy {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.policies[permission]
+}
+
+// ResolvePermission returns the permission replacing a deprecated permission
+// name and logs a warning naming the caller so that it can be migrated. Names
+// that are not aliases are returned unchanged
+func (e *Engine) ResolvePermission(permission, caller string) string {
+	e.mu.RLock()
+	replacement, ok := e.aliases[permission]
+	e.mu.RUnlock()
+	if !ok {
+		return permission
+	}
+
+	e.logger.Warn("Deprecated permission name used",
+		slog.String("permission", permission),
+		slog.String("replacement", replacement),
+		slog.String("caller", caller),
+	)
+	return replacement
+}
+
+// LoadedAt returns when the policies were last loaded
+func (e *Engine) LoadedAt() time.Time {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.loadedAt
+}
+
+// Evaluate reports whether any policy grants the permission for the given
+// attributes. Institution membership is looked up through db
+func (e *Engine) Evaluate(ctx context.Context, db repository.DBTX, permission string, attrs Attributes) (bool, error) {
+	for _, policy := range e.Policies(permission) {
+		if !policy.Conditions.matchesStatic(attrs) {
+			continue
+		}
+
+		if policy.Conditions.SubjectInInstitution {
+			member, err := repository.New(db).IsAccountInInstitution(ctx, repository.IsAccountInInstitutionParams{
+				AccountID:     attrs.SubjectID,
+				InstitutionID: *attrs.InstitutionID,
+			})
+			if err != nil {
+				return false, fmt.Errorf("failed to check institution membership: %w", err)
+			}
+			if !member {
+				continue
+			}
+		}
+
+		return true, nil
+	}
+	return false, nil
+}
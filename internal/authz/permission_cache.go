@@ -0,0 +1,117 @@
+package authz
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResolvedPermissions are the role and permission names granted to an
+// account through its global roles together with the permissions explicitly
+// denied to it
+type ResolvedPermissions struct {
+	Roles       []string
+	Permissions []string
+	Denied      []string
+}
+
+type cachedPermissions struct {
+	resolved  ResolvedPermissions
+	expiresAt time.Time
+}
+
+// PermissionCache keeps the resolved permissions of recently seen accounts in
+// memory so that authenticating a request does not need to query roles and
+// permissions every time.
+//
+// Entries are dropped when the roles of an account change and the whole cache
+// is cleared whenever roles or permissions themselves change. Both only apply
+// to the instance making the change, other instances keep serving their
+// entries until they expire after PERMISSION_CACHE_TTL, at most five minutes.
+//
+// A nil *PermissionCache is valid and caches nothing.
+type PermissionCache struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	entries map[uuid.UUID]cachedPermissions
+}
+
+// NewPermissionCache creates a cache whose entries live for ttl. A non
+// positive ttl disables caching and returns nil
+func NewPermissionCache(ttl time.Duration) *PermissionCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &PermissionCache{
+		ttl:     ttl,
+		entries: map[uuid.UUID]cachedPermissions{},
+	}
+}
+
+// Get returns the cached permissions of an account if present and fresh
+func (c *PermissionCache) Get(accountID uuid.UUID) (ResolvedPermissions, bool) {
+	if c == nil {
+		return ResolvedPermissions{}, false
+	}
+
+	c.mu.RLock()
+	entry, ok := c.entries[accountID]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return ResolvedPermissions{}, false
+	}
+	return entry.resolved, true
+}
+
+// Set caches the resolved permissions of an account
+func (c *PermissionCache) Set(accountID uuid.UUID, resolved ResolvedPermissions) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Sweep expired entries lazily so the cache does not grow unbounded with
+	// accounts that are no longer active
+	if len(c.entries) > 0 && len(c.entries)%1024 == 0 {
+		for id, entry := range c.entries {
+			if now.After(entry.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+	}
+
+	c.entries[accountID] = cachedPermissions{
+		resolved:  resolved,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// Invalidate drops the cached permissions of the given accounts
+func (c *PermissionCache) Invalidate(accountIDs ...uuid.UUID) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range accountIDs {
+		delete(c.entries, id)
+	}
+}
+
+// InvalidateAll clears the cache. Used when a change to a role or permission
+// may affect any number of accounts
+func (c *PermissionCache) InvalidateAll() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[uuid.UUID]cachedPermissions{}
+}
@@ -0,0 +1,115 @@
+package authz
+
+import (
+	"strings"
+)
+
+// Wildcard stands for every value of a permission segment
+const Wildcard = "*"
+
+// Permissions are made of colon separated segments such as
+// "read:service_token:own". A granted permission may use Wildcard in place of
+// any segment to cover every value of that segment, "read:*:own" covers
+// reading every resource the caller owns while "*:service_token:any" covers
+// every action on any service token. A lone Wildcard covers every permission.
+//
+// Wildcards are only meaningful in granted permissions, required permissions
+// are always matched literally.
+
+// MatchPermission reports whether the granted permission covers the required
+// one
+func MatchPermission(granted, required string) bool {
+	if granted == required || granted == Wildcard {
+		return true
+	}
+	if !strings.Contains(granted, Wildcard) {
+		return false
+	}
+
+	grantedSegments := strings.Split(granted, ":")
+	requiredSegments := strings.Split(required, ":")
+	if len(grantedSegments) != len(requiredSegments) {
+		return false
+	}
+	for i, segment := range grantedSegments {
+		if segment != Wildcard && segment != requiredSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchingPermission returns the most specific granted permission covering
+// the required one.
+//
+// Precedence is deterministic: an exact grant always wins, otherwise the grant
+// with the fewest wildcards wins and ties are broken by preferring literal
+// segments further to the left, so "read:*:own" beats "*:role:own", and
+// finally by lexical order.
+func MatchingPermission(granted []string, required string) (string, bool) {
+	var best string
+	found := false
+	for _, candidate := range granted {
+		if !MatchPermission(candidate, required) {
+			continue
+		}
+		if candidate == required {
+			return candidate, true
+		}
+		if !found || morePrecise(candidate, best) {
+			best = candidate
+			found = true
+		}
+	}
+	return best, found
+}
+
+// HasPermission reports whether any of the granted permissions covers the
+// required one
+func HasPermission(granted []string, required string) bool {
+	_, ok := MatchingPermission(granted, required)
+	return ok
+}
+
+// IsDenied reports whether any of the denied permissions covers the required
+// one. Denials follow deny-wins semantics: a denied permission is refused even
+// when a role, an institution role or an authorization policy grants it
+func IsDenied(denied []string, required string) bool {
+	for _, permission := range denied {
+		if MatchPermission(permission, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// morePrecise reports whether permission a is more specific than b. Both are
+// expected to match the same required permission
+func morePrecise(a, b string) bool {
+	if a == Wildcard || b == Wildcard {
+		return b == Wildcard && a != Wildcard
+	}
+
+	aSegments, bSegments := strings.Split(a, ":"), strings.Split(b, ":")
+	aWildcards, bWildcards := countWildcards(aSegments), countWildcards(bSegments)
+	if aWildcards != bWildcards {
+		return aWildcards < bWildcards
+	}
+	for i := range aSegments {
+		aWild, bWild := aSegments[i] == Wildcard, bSegments[i] == Wildcard
+		if aWild != bWild {
+			return bWild
+		}
+	}
+	return a < b
+}
+
+func countWildcards(segments []string) int {
+	count := 0
+	for _, segment := range segments {
+		if segment == Wildcard {
+			count++
+		}
+	}
+	return count
+}
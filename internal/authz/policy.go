@@ -0,0 +1,108 @@
+// Package authz evaluates attribute based authorization policies.
+//
+// OVERVIEW:
+// Role based permissions answer "may this account ever do X". Policies answer
+// "may this account do X to this particular resource". A policy grants a
+// single permission to callers who do not hold it through their roles but
+// satisfy every condition of the policy, for example "accounts may read the
+// roles of their own account" or "human members of an institution may list
+// its accounts".
+//
+// Policies live in the authorization_policies table and are cached by the
+// Engine which reloads them periodically and whenever they are changed
+// through the API.
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Account types a policy may be restricted to
+var knownAccountTypes = []repository.AccountType{
+	repository.AccountTypeHuman,
+	repository.AccountTypeService,
+	repository.AccountTypeBot,
+	repository.AccountTypeOrganization,
+}
+
+// Conditions are the attribute checks a policy requires. Every condition that
+// is set must hold for the policy to grant its permission
+type Conditions struct {
+	// The caller must own the resource being accessed
+	SubjectIsOwner bool `json:"subject_is_owner,omitempty"`
+	// The caller must be a member of the institution being accessed
+	SubjectInInstitution bool `json:"subject_in_institution,omitempty"`
+	// The caller's account must be of one of these types
+	AccountTypes []repository.AccountType `json:"account_types,omitempty"`
+}
+
+// IsEmpty reports whether no condition is set
+func (c Conditions) IsEmpty() bool {
+	return !c.SubjectIsOwner && !c.SubjectInInstitution && len(c.AccountTypes) == 0
+}
+
+// Validate reports whether the conditions can be evaluated. Empty conditions
+// are rejected since they would grant the permission to everyone
+func (c Conditions) Validate() error {
+	if c.IsEmpty() {
+		return fmt.Errorf("a policy must declare at least one condition")
+	}
+	for _, accountType := range c.AccountTypes {
+		if !slices.Contains(knownAccountTypes, accountType) {
+			return fmt.Errorf("unknown account type %q", accountType)
+		}
+	}
+	return nil
+}
+
+// ParseConditions decodes the conditions stored alongside a policy
+func ParseConditions(raw []byte) (Conditions, error) {
+	var conditions Conditions
+	if len(raw) == 0 {
+		return conditions, nil
+	}
+	if err := json.Unmarshal(raw, &conditions); err != nil {
+		return conditions, fmt.Errorf("invalid policy conditions: %w", err)
+	}
+	return conditions, nil
+}
+
+// Policy is a parsed authorization policy
+type Policy struct {
+	ID         uuid.UUID
+	Name       string
+	Permission string
+	Conditions Conditions
+}
+
+// Attributes describe the caller and the resource a permission is checked
+// against
+type Attributes struct {
+	SubjectID   uuid.UUID
+	SubjectType repository.AccountType
+	// The account owning the resource, nil when the resource has no owner
+	ResourceOwnerID *uuid.UUID
+	// The institution the resource belongs to, nil when not institution bound
+	InstitutionID *int32
+}
+
+// matchesStatic evaluates the conditions that do not require a database
+// lookup
+func (c Conditions) matchesStatic(attrs Attributes) bool {
+	if c.SubjectIsOwner &&
+		(attrs.ResourceOwnerID == nil || *attrs.ResourceOwnerID != attrs.SubjectID) {
+		return false
+	}
+	if c.SubjectInInstitution && attrs.InstitutionID == nil {
+		return false
+	}
+	if len(c.AccountTypes) > 0 && !slices.Contains(c.AccountTypes, attrs.SubjectType) {
+		return false
+	}
+	return true
+}
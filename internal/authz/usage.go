@@ -0,0 +1,90 @@
+package authz
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// PermissionUsage counts the requests a permission let through on a route
+type PermissionUsage struct {
+	Permission string    `json:"permission"`
+	Route      string    `json:"route"`
+	Count      uint64    `json:"count"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+type usageKey struct {
+	permission string
+	route      string
+}
+
+// UsageTracker counts which permissions are actually exercised and on which
+// routes so that over-broad roles can be pruned.
+//
+// Counters live in memory, are kept per instance and start over whenever the
+// service restarts.
+//
+// A nil *UsageTracker is valid and records nothing.
+type UsageTracker struct {
+	since   time.Time
+	mu      sync.Mutex
+	entries map[usageKey]*PermissionUsage
+}
+
+// NewUsageTracker creates an empty tracker
+func NewUsageTracker() *UsageTracker {
+	return &UsageTracker{
+		since:   time.Now(),
+		entries: map[usageKey]*PermissionUsage{},
+	}
+}
+
+// Record counts a request the permission was exercised for on the route
+func (t *UsageTracker) Record(permission, route string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := usageKey{permission: permission, route: route}
+	entry, ok := t.entries[key]
+	if !ok {
+		entry = &PermissionUsage{Permission: permission, Route: route}
+		t.entries[key] = entry
+	}
+	entry.Count++
+	entry.LastUsedAt = time.Now()
+}
+
+// Since returns when the tracker started counting
+func (t *UsageTracker) Since() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.since
+}
+
+// Snapshot returns a copy of the counters ordered by permission then route
+func (t *UsageTracker) Snapshot() []PermissionUsage {
+	if t == nil {
+		return []PermissionUsage{}
+	}
+
+	t.mu.Lock()
+	usage := make([]PermissionUsage, 0, len(t.entries))
+	for _, entry := range t.entries {
+		usage = append(usage, *entry)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Permission != usage[j].Permission {
+			return usage[i].Permission < usage[j].Permission
+		}
+		return usage[i].Route < usage[j].Route
+	})
+	return usage
+}
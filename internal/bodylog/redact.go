@@ -0,0 +1,132 @@
+package bodylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Placeholders replacing redacted values
+const (
+	redactedSecret = "[REDACTED]"
+	redactedEmail  = "[REDACTED_EMAIL]"
+	redactedPhone  = "[REDACTED_PHONE]"
+	redactedToken  = "[REDACTED_TOKEN]"
+)
+
+// Parts of the names of fields whose values are redacted whole
+var sensitiveFieldNames = []string{
+	"token",
+	"secret",
+	"password",
+	"api_key",
+	"apikey",
+	"authorization",
+	"ticket",
+	"otp",
+	"national_id",
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// International numbers and local numbers starting with 0, e.g.
+	// +254712345678 and 0712345678
+	phonePattern  = regexp.MustCompile(`(?:\+|\b0)\d{9,14}\b`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+	// Fields of JSON bodies cut off too early to be parsed
+	sensitiveJSONFieldPattern = regexp.MustCompile(
+		`(?i)("[^"]*(?:` + strings.Join(sensitiveFieldNames, "|") + `)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"?`,
+	)
+)
+
+// Redact returns a body with its sensitive values replaced so that it can
+// be logged. truncated tells that the body was cut off, in which case JSON
+// bodies cannot be parsed and are redacted as text
+func Redact(body []byte, contentType string, truncated bool) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if !truncated && decoder.Decode(&value) == nil {
+			redacted, err := json.Marshal(redactValue(value))
+			if err == nil {
+				return string(redacted)
+			}
+		}
+		return redactText(sensitiveJSONFieldPattern.ReplaceAllString(string(body), `$1"`+redactedSecret+`"`))
+
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil || truncated {
+			return redactText(string(body))
+		}
+		for name, list := range values {
+			for i, value := range list {
+				if isSensitiveField(name) {
+					list[i] = redactedSecret
+				} else {
+					list[i] = redactText(value)
+				}
+			}
+		}
+		return values.Encode()
+
+	case strings.HasPrefix(mediaType, "text/"):
+		return redactText(string(body))
+	}
+
+	return fmt.Sprintf("[%d bytes of %s omitted]", len(body), contentType)
+}
+
+// redactValue redacts a decoded JSON value
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for name, field := range value {
+			if isSensitiveField(name) {
+				value[name] = redactedSecret
+				continue
+			}
+			value[name] = redactValue(field)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = redactValue(item)
+		}
+		return value
+	case string:
+		return redactText(value)
+	}
+	return value
+}
+
+// redactText replaces the emails, phone numbers and tokens within a text
+func redactText(text string) string {
+	text = jwtPattern.ReplaceAllString(text, redactedToken)
+	text = bearerPattern.ReplaceAllString(text, "Bearer "+redactedToken)
+	text = emailPattern.ReplaceAllString(text, redactedEmail)
+	return phonePattern.ReplaceAllString(text, redactedPhone)
+}
+
+// isSensitiveField reports whether the value of a field is redacted whole
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range sensitiveFieldNames {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Package bodylog samples the request and response bodies of chosen routes
+// into the logs while a problem is diagnosed.
+//
+// OVERVIEW:
+// Bodies are never logged by default. An admin turns the logging on for a
+// route through PUT /api/v1/admin/diagnostics/body-logging, naming the share
+// of its requests to log and for how long. The rules live in the
+// body_logging_rules table, every instance reloads them periodically and
+// rules turn themselves off once they expire.
+//
+// REDACTION:
+// Bodies are redacted before they are logged. Values of fields named like
+// tokens, secrets and passwords are replaced whole, emails, phone numbers
+// and JWTs are replaced wherever they appear. Bodies that are neither JSON,
+// forms nor text are not logged, only their size is.
+package bodylog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Rule has the bodies of a route logged
+type Rule struct {
+	// Route pattern whose bodies are logged e.g. PATCH /api/v1/roles/{id}
+	Route string
+	// Share of the requests of the route that are logged
+	SampleRate float64
+	ExpiresAt  time.Time
+}
+
+// Sampler keeps the rules in force in memory so that requests can be checked
+// against them without a database round trip. A nil *Sampler is valid and
+// samples nothing
+type Sampler struct {
+	pool           *pgxpool.Pool
+	logger         *slog.Logger
+	reloadInterval time.Duration
+	maxBodyBytes   int
+
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// NewSampler creates a new Sampler. Call Start to load the rules and keep
+// them up to date
+func NewSampler(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Sampler {
+	reloadInterval := time.Duration(cfg.BodyLoggingConfig.ReloadIntervalSeconds) * time.Second
+	if reloadInterval <= 0 {
+		reloadInterval = 15 * time.Second
+	}
+	maxBodyBytes := cfg.BodyLoggingConfig.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = 4096
+	}
+
+	return &Sampler{
+		pool:           pool,
+		logger:         logger,
+		reloadInterval: reloadInterval,
+		maxBodyBytes:   maxBodyBytes,
+		rules:          map[string]Rule{},
+	}
+}
+
+// Start loads the rules and reloads them periodically until the context is
+// cancelled
+func (s *Sampler) Start(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		s.logger.Error("Failed to load body logging rules", slog.Any("error", err))
+	}
+
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to reload body logging rules", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Reload replaces the rules in memory with the rules in force
+func (s *Sampler) Reload(ctx context.Context) error {
+	rows, err := repository.New(s.pool).ListActiveBodyLoggingRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	rules := make(map[string]Rule, len(rows))
+	for _, row := range rows {
+		rules[row.Route] = Rule{
+			Route:      row.Route,
+			SampleRate: row.SampleRate,
+			ExpiresAt:  row.ExpiresAt.Time,
+		}
+	}
+
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+	return nil
+}
+
+// Active reports whether the bodies of any route are logged, so that
+// requests only have their bodies captured while they may be logged
+func (s *Sampler) Active() bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rules) > 0
+}
+
+// Rule returns the rule in force for a route pattern
+func (s *Sampler) Rule(route string) (Rule, bool) {
+	if s == nil {
+		return Rule{}, false
+	}
+
+	s.mu.RLock()
+	rule, ok := s.rules[route]
+	s.mu.RUnlock()
+
+	if !ok || time.Now().After(rule.ExpiresAt) {
+		return Rule{}, false
+	}
+	return rule, true
+}
+
+// MaxBodyBytes returns the most bytes of a body that are logged
+func (s *Sampler) MaxBodyBytes() int {
+	return s.maxBodyBytes
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// OriginAllowed reports whether an origin matches one of the allowed
+// origins. A wildcard origin matches origins with the same scheme and port
+// whose host ends in the domain following the wildcard
+func OriginAllowed(allowedOrigins []string, origin string) bool {
+	if slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin) {
+		return true
+	}
+
+	for _, allowed := range allowedOrigins {
+		scheme, domain, ok := strings.Cut(allowed, "://*.")
+		if !ok {
+			continue
+		}
+		subdomain, ok := strings.CutPrefix(origin, scheme+"://")
+		if !ok {
+			continue
+		}
+		subdomain, ok = strings.CutSuffix(subdomain, "."+domain)
+		if ok && validSubdomain(subdomain) {
+			return true
+		}
+	}
+	return false
+}
+
+// validSubdomain reports whether the labels preceding an allowed domain are
+// made of nothing but letters, digits, hyphens and dots
+func validSubdomain(subdomain string) bool {
+	if subdomain == "" || strings.HasPrefix(subdomain, ".") || strings.HasSuffix(subdomain, ".") {
+		return false
+	}
+	for _, c := range subdomain {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// WebRedirectAllowed reports whether web sign ins may redirect to a URL,
+// which receives the tokens of the account signing in
+func (c *Config) WebRedirectAllowed(redirectURI string) bool {
+	u, err := url.Parse(redirectURI)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+		return false
+	}
+	return OriginAllowed(c.AuthenticationConfig.WebRedirectOrigins, u.Scheme+"://"+u.Host)
+}
+
+// validateOrigin checks that an origin of a setting is a scheme and host,
+// optionally with a wildcard subdomain
+func validateOrigin(setting, origin string) error {
+	u, err := url.Parse(strings.Replace(origin, "://*.", "://wildcard.", 1))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.User != nil || strings.Contains(u.Host, "*") {
+		return fmt.Errorf("invalid %s origin %q, expected e.g. https://example.com or https://*.example.com", setting, origin)
+	}
+	return nil
+}
+
+// isLoopback reports whether a host names the machine Verisafe runs on
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validateClients checks the URLs and identifiers of the clients Verisafe
+// signs in and notifies. Production only accepts HTTPS origins other than
+// the loopback ones, which suit development
+func validateClients(c *Config, v *validator) {
+	production := c.AuthenticationConfig.Environment == "production"
+
+	v.required("JWT_ISSUER", c.JWTConfig.Issuer)
+	v.required("JWT_AUDIENCE", c.JWTConfig.Audience)
+
+	if u, err := url.Parse(c.AuthenticationConfig.MobileCallbackURL); err != nil || u.Scheme == "" ||
+		(u.Host == "" && u.Opaque == "") {
+		v.addf("invalid AUTH_MOBILE_CALLBACK_URL %q, expected a deep link e.g. academia://callback", c.AuthenticationConfig.MobileCallbackURL)
+	}
+
+	for _, origin := range c.AuthenticationConfig.WebRedirectOrigins {
+		if origin == "*" {
+			v.addf("invalid AUTH_WEB_REDIRECT_ORIGINS, * would hand tokens to any site")
+			continue
+		}
+		if err := validateOrigin("AUTH_WEB_REDIRECT_ORIGINS", origin); err != nil {
+			v.check(err)
+			continue
+		}
+		if production {
+			u, _ := url.Parse(origin)
+			if u.Scheme != "https" || isLoopback(u.Hostname()) {
+				v.addf("invalid AUTH_WEB_REDIRECT_ORIGINS origin %q, production only allows HTTPS origins other than localhost", origin)
+			}
+		}
+	}
+
+	if production {
+		if u, err := url.Parse(c.AuthenticationConfig.AuthAddress); err == nil && u.Scheme != "https" {
+			v.addf("invalid AUTH_ADDRESS %q, production requires HTTPS", c.AuthenticationConfig.AuthAddress)
+		}
+	}
+
+	if err := uuid.Validate(c.NotificationConfig.OneSignalAppID); err != nil {
+		v.addf("invalid ONESIGNAL_APP_ID %q, expected the UUID of a OneSignal app", c.NotificationConfig.OneSignalAppID)
+	}
+	if err := uuid.Validate(c.NotificationConfig.OneSignalAndroidChannelID); err != nil {
+		v.addf("invalid ONESIGNAL_ANDROID_CHANNEL_ID %q, expected the UUID of a OneSignal channel", c.NotificationConfig.OneSignalAndroidChannelID)
+	}
+	if u, err := url.Parse(c.NotificationConfig.ProfileURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf("invalid NOTIFICATION_PROFILE_URL %q, expected e.g. https://opencrafts.io/profile", c.NotificationConfig.ProfileURL)
+	}
+}
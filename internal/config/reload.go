@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/joho/godotenv"
+)
+
+// The environment file settings are read from besides the environment
+const envFile = ".env"
+
+var (
+	envFileMu sync.Mutex
+	// Variables set in the environment of the process, which take
+	// precedence over the environment file on every load
+	processEnv map[string]bool
+	// Variables last set from the environment file
+	envFileVars map[string]bool
+)
+
+// loadEnvFile sets the variables of the environment file that are not set
+// in the environment of the process. Variables removed from the file since
+// the previous load are unset
+func loadEnvFile() error {
+	envFileMu.Lock()
+	defer envFileMu.Unlock()
+
+	if processEnv == nil {
+		processEnv = map[string]bool{}
+		for _, variable := range os.Environ() {
+			name, _, _ := strings.Cut(variable, "=")
+			processEnv[name] = true
+		}
+	}
+
+	vars, err := godotenv.Read(envFile)
+	if err != nil {
+		return err
+	}
+	for name := range envFileVars {
+		if _, ok := vars[name]; !ok {
+			os.Unsetenv(name)
+		}
+	}
+	envFileVars = map[string]bool{}
+	for name, value := range vars {
+		if processEnv[name] {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+		envFileVars[name] = true
+	}
+	return nil
+}
+
+// Reloader keeps the configuration Verisafe runs with and reloads the
+// settings that can change without a restart:
+//
+//   - CORS_ALLOWED_ORIGINS, CORS_MAX_AGE and CORS_ALLOW_CREDENTIALS
+//   - CONCURRENCY_LIMITS, CONCURRENCY_LIMIT_DEFAULT,
+//     LOAD_SHED_POOL_SATURATION and LOAD_SHED_RETRY_AFTER
+//   - LOG_LEVEL and LOG_REQUEST_SAMPLE_RATE
+//
+// Every other setting shapes how Verisafe is put together at startup and
+// only changes on the next start.
+type Reloader struct {
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+// NewReloader creates a new Reloader starting from a loaded configuration
+func NewReloader(cfg *Config) *Reloader {
+	r := &Reloader{}
+	r.current.Store(cfg)
+	return r
+}
+
+// Current returns the configuration as of the last reload. It must not be
+// modified
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// OnReload registers a function called with the new configuration after
+// every successful reload
+func (r *Reloader) OnReload(listener func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// Reload reads the configuration again and applies the settings that can
+// change without a restart. An invalid configuration is rejected as a whole
+// and the current one kept. It returns the sections of the configuration
+// that were applied, e.g. CORSConfig, and those that changed but only apply
+// on the next start
+func (r *Reloader) Reload() (applied, pending []string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := loadEnvFile(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", envFile, err)
+	}
+	fresh, err := processConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current := r.current.Load()
+	next := *current
+	next.CORSConfig = fresh.CORSConfig
+	next.LoadSheddingConfig = fresh.LoadSheddingConfig
+	next.LogConfig = fresh.LogConfig
+	// The format is chosen when the log handler is created
+	next.LogConfig.Format = current.LogConfig.Format
+	applied = changedSections(current, &next)
+
+	// Whatever still differs once the reloaded sections are taken over
+	// waits for a restart
+	fresh.CORSConfig = current.CORSConfig
+	fresh.LoadSheddingConfig = current.LoadSheddingConfig
+	fresh.LogConfig.Level = current.LogConfig.Level
+	fresh.LogConfig.RequestSampleRate = current.LogConfig.RequestSampleRate
+	pending = changedSections(current, fresh)
+
+	r.current.Store(&next)
+	for _, listener := range r.listeners {
+		listener(&next)
+	}
+	return applied, pending, nil
+}
+
+// changedSections returns the names of the sections that differ between two
+// configurations, e.g. CORSConfig
+func changedSections(a, b *Config) []string {
+	var changed []string
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := range va.NumField() {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, va.Type().Field(i).Name)
+		}
+	}
+	return changed
+}
+
+// LogLevel returns the least severe level logged
+func (c *Config) LogLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogConfig.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
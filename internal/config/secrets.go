@@ -0,0 +1,82 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/secrets"
+)
+
+// How long fetching every secret at startup may take
+const secretsLoadTimeout = 30 * time.Second
+
+// secretSettings returns the settings that may name a secret in a secret
+// store instead of holding it, keyed by environment variable
+func (c *Config) secretSettings() map[string]*string {
+	return map[string]*string{
+		"API_SECRET":               &c.JWTConfig.ApiSecret,
+		"SESSION_SECRET":           &c.AuthenticationConfig.SessionSecret,
+		"GOOGLE_CLIENT_SECRET":     &c.AuthenticationConfig.GoogleClientSecret,
+		"SPOTIFY_CLIENT_SECRET":    &c.AuthenticationConfig.SpotifyClientSecret,
+		"APPLE_PRIVATE_KEY_BASE64": &c.AuthenticationConfig.ApplePrivateKeyBase64,
+		"DB_PASSWORD":              &c.DatabaseConfig.DatabasePassword,
+		"DB_READ_REPLICA_DSN":      &c.DatabaseConfig.ReadReplicaDSN,
+		"RABBITMQ_PASSWORD":        &c.RabbitMQConfig.RabbitMQPass,
+		"EVENT_SIGNING_KEY":        &c.EventSigningConfig.Key,
+		"SEED_SERVICE_TOKEN":       &c.BootstrapConfig.ServiceToken,
+	}
+}
+
+// NewSecretResolver creates a resolver fetching secrets from the configured
+// secret stores
+func (c *Config) NewSecretResolver() *secrets.Resolver {
+	return secrets.NewResolver(secrets.Options{
+		VaultAddress:   c.SecretsConfig.VaultAddress,
+		VaultToken:     c.SecretsConfig.VaultToken,
+		VaultTokenFile: c.SecretsConfig.VaultTokenFile,
+		VaultNamespace: c.SecretsConfig.VaultNamespace,
+	})
+}
+
+// SecretValues returns the fetched values of the settings naming a secret,
+// keyed by setting
+func (c *Config) SecretValues() map[string]string {
+	settings := c.secretSettings()
+	values := make(map[string]string, len(c.SecretRefs))
+	for setting := range c.SecretRefs {
+		values[setting] = *settings[setting]
+	}
+	return values
+}
+
+// resolveSecrets replaces the settings naming a secret with the secret,
+// recording their references in SecretRefs. Every secret that cannot be
+// fetched is reported
+func (c *Config) resolveSecrets() error {
+	c.SecretRefs = map[string]string{}
+
+	var resolver *secrets.Resolver
+	var errs []error
+	ctx, cancel := context.WithTimeout(context.Background(), secretsLoadTimeout)
+	defer cancel()
+
+	for setting, value := range c.secretSettings() {
+		if !secrets.IsRef(*value) {
+			continue
+		}
+		if resolver == nil {
+			resolver = c.NewSecretResolver()
+		}
+
+		secret, err := resolver.Resolve(ctx, *value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch %s: %w", setting, err))
+			continue
+		}
+		c.SecretRefs[setting] = *value
+		*value = secret
+	}
+	return errors.Join(errs...)
+}
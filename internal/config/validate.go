@@ -0,0 +1,197 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// Service tokens generated by Verisafe hold 44 characters besides their
+// prefix, a token supplied by an operator must not be much weaker
+const minBootstrapTokenLength = 32
+
+// Other instances serve revoked permissions from their cache until it
+// expires, so it may not be kept for long
+const maxPermissionCacheTTLSeconds = 300
+
+// ValidationError lists every problem found with the configuration, so that
+// they can all be fixed before the next start rather than one at a time
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid configuration, %d problem(s):\n  - %s",
+		len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// validator collects the problems found with the configuration
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+// check records the problem reported by a validation function, if any
+func (v *validator) check(err error) {
+	if err != nil {
+		v.problems = append(v.problems, err.Error())
+	}
+}
+
+func (v *validator) required(name, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.addf("%s is required", name)
+	}
+}
+
+// port checks that a port can be listened on or connected to. Zero is only
+// accepted for optional ports, where it turns the listener off
+func (v *validator) port(name string, port int, optional bool) {
+	if optional && port == 0 {
+		return
+	}
+	if port < 1 || port > 65535 {
+		v.addf("invalid %s %d, expected a port between 1 and 65535", name, port)
+	}
+}
+
+func (v *validator) atLeast(name string, value, least int) {
+	if value < least {
+		v.addf("invalid %s %d, expected at least %d", name, value, least)
+	}
+}
+
+// Validate checks that every setting Verisafe needs is present and within
+// range. It returns a *ValidationError listing every problem found
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	// Tokens and sessions cannot be signed without their secrets
+	v.required("API_SECRET", c.JWTConfig.ApiSecret)
+	v.required("SESSION_SECRET", c.AuthenticationConfig.SessionSecret)
+	v.atLeast("EXPIRE_DELTA", c.JWTConfig.ExpireDelta, 1)
+	v.atLeast("REFRESH_EXPIRE_DELTA", c.JWTConfig.RefreshExpireDelta, 1)
+	v.atLeast("AUTH_MAX_AGE", c.AuthenticationConfig.MaxAge, 0)
+
+	// OAuth providers call back on the auth address
+	if c.AuthenticationConfig.AuthAddress == "" {
+		v.addf("AUTH_ADDRESS is required")
+	} else if u, err := url.Parse(c.AuthenticationConfig.AuthAddress); err != nil ||
+		(u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf("invalid AUTH_ADDRESS %q, expected e.g. https://verisafe.opencrafts.io", c.AuthenticationConfig.AuthAddress)
+	}
+	switch c.AuthenticationConfig.CookieSameSite {
+	case "lax", "none":
+	default:
+		v.addf("invalid AUTH_COOKIE_SAMESITE %q, expected lax or none", c.AuthenticationConfig.CookieSameSite)
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogConfig.Level)); err != nil {
+		v.addf("invalid LOG_LEVEL %q, expected debug, info, warn or error", c.LogConfig.Level)
+	}
+	switch c.LogConfig.Format {
+	case "json", "text":
+	default:
+		v.addf("invalid LOG_FORMAT %q, expected json or text", c.LogConfig.Format)
+	}
+	if c.LogConfig.RequestSampleRate < 0 || c.LogConfig.RequestSampleRate > 1 {
+		v.addf("invalid LOG_REQUEST_SAMPLE_RATE %g, expected between 0 and 1", c.LogConfig.RequestSampleRate)
+	}
+
+	v.port("VERISAFE_PORT", c.AppConfig.Port, false)
+	v.port("VERISAFE_INTERNAL_PORT", c.AppConfig.InternalPort, true)
+	if c.AppConfig.InternalPort != 0 && c.AppConfig.InternalPort == c.AppConfig.Port &&
+		c.AppConfig.InternalAddress == c.AppConfig.Address {
+		v.addf("VERISAFE_INTERNAL_PORT %d is already used by VERISAFE_PORT", c.AppConfig.InternalPort)
+	}
+
+	// The password is optional, the server may trust local connections
+	db := c.DatabaseConfig
+	v.required("DB_HOST", db.DatabaseHost)
+	v.required("DB_USER", db.DatabaseUser)
+	v.required("DB_NAME", db.DatabaseName)
+	v.port("DB_PORT", int(db.DatabasePort), false)
+	v.atLeast("DB_MAX_CON", int(db.DatabasePoolMaxConnections), 1)
+	v.atLeast("DB_POOL_MIN_CON", int(db.DatabasePoolMinConnections), 0)
+	if db.DatabasePoolMaxConnections > 0 && db.DatabasePoolMinConnections > db.DatabasePoolMaxConnections {
+		v.addf("invalid DB_POOL_MIN_CON %d, expected at most DB_MAX_CON %d",
+			db.DatabasePoolMinConnections, db.DatabasePoolMaxConnections)
+	}
+	v.atLeast("DB_POOL_MAX_LIFETIME", db.DatabasePoolMaxConnectionLifetime, 0)
+
+	switch c.EventBusConfig.Backend {
+	case "", "rabbitmq":
+		v.required("RABBITMQ_ADDRESS", c.RabbitMQConfig.RabbitMQAddress)
+		v.port("RABBITMQ_PORT", c.RabbitMQConfig.RabbitMQPort, false)
+	case "kafka", "nats", "memory":
+	default:
+		v.addf("invalid EVENTBUS_BACKEND %q, expected rabbitmq, kafka, nats or memory", c.EventBusConfig.Backend)
+	}
+
+	v.check(validateCORSOrigins(c.CORSConfig.AllowedOrigins, c.CORSConfig.AllowCredentials))
+	v.atLeast("CORS_MAX_AGE", c.CORSConfig.MaxAgeSeconds, 0)
+	v.check(validateLoadShedding(c.LoadSheddingConfig.ConcurrencyLimits, c.LoadSheddingConfig.PoolSaturation))
+	v.atLeast("CONCURRENCY_LIMIT_DEFAULT", c.LoadSheddingConfig.DefaultConcurrencyLimit, 0)
+	v.atLeast("SHUTDOWN_DRAIN_DELAY", c.ShutdownConfig.DrainDelaySeconds, 0)
+	v.atLeast("SHUTDOWN_DRAIN_TIMEOUT", c.ShutdownConfig.DrainTimeoutSeconds, 1)
+
+	if c.GRPCConfig.Enabled {
+		if c.GRPCConfig.CertFile == "" || c.GRPCConfig.KeyFile == "" || c.GRPCConfig.ClientCAFile == "" {
+			v.addf("GRPC_TLS_CERT_FILE, GRPC_TLS_KEY_FILE and GRPC_CLIENT_CA_FILE are required when GRPC_ENABLED is set")
+		}
+	}
+
+	if c.BodyLoggingConfig.Enabled {
+		v.atLeast("BODY_LOGGING_MAX_DURATION", c.BodyLoggingConfig.MaxDurationMinutes, 1)
+	}
+
+	// Retention periods and sizes where zero has a meaning of its own, or
+	// falls back to the default
+	v.atLeast("SLOW_REQUEST_THRESHOLD", c.SlowRequestConfig.ThresholdMilliseconds, 0)
+	v.atLeast("SLOW_QUERY_THRESHOLD", c.SlowRequestConfig.QueryThresholdMilliseconds, 0)
+	v.atLeast("EVENT_JOURNAL_RETENTION", c.EventJournalConfig.RetentionDays, 0)
+	v.atLeast("EVENT_DEDUPE_RETENTION", c.EventDedupeConfig.RetentionDays, 0)
+	v.atLeast("REQUEST_AUDIT_RETENTION", c.RequestAuditConfig.RetentionDays, 0)
+	v.atLeast("ACTIVITY_COMPLETION_RETENTION_MONTHS", c.ActivityCompletionConfig.RetentionMonths, 0)
+	v.atLeast("ACTIVITY_COMPLETION_ARCHIVE_AFTER_MONTHS", c.ActivityCompletionConfig.ArchiveAfterMonths, 0)
+	v.atLeast("INSTITUTION_INVITATION_TTL", c.InstitutionConfig.InvitationTTLHours, 1)
+	v.atLeast("SECRETS_REFRESH_INTERVAL", c.SecretsConfig.RefreshIntervalSeconds, 0)
+	if ttl := c.AuthorizationConfig.PermissionCacheTTLSeconds; ttl < 0 || ttl > maxPermissionCacheTTLSeconds {
+		v.addf("invalid PERMISSION_CACHE_TTL %d, expected between 0 and %d", ttl, maxPermissionCacheTTLSeconds)
+	}
+
+	v.check(validateTenants(c))
+	validateClients(c, v)
+
+	// The initial service token is held to the rules of tokens created
+	// through the API
+	boot := c.BootstrapConfig
+	if boot.BotEmail != "" && boot.ServiceToken == "" {
+		v.addf("SEED_SERVICE_TOKEN is required when SEED_BOT_EMAIL is set")
+	}
+	if boot.ServiceToken != "" {
+		if boot.BotEmail == "" {
+			v.addf("SEED_BOT_EMAIL is required when SEED_SERVICE_TOKEN is set")
+		}
+		if len(boot.ServiceToken) < minBootstrapTokenLength {
+			v.addf("SEED_SERVICE_TOKEN is too short, expected at least %d characters", minBootstrapTokenLength)
+		}
+		v.required("SEED_SERVICE_TOKEN_NAME", boot.ServiceTokenName)
+		if len(boot.ServiceTokenName) > 100 {
+			v.addf("SEED_SERVICE_TOKEN_NAME is too long, expected at most 100 characters")
+		}
+		if boot.ServiceTokenExpiresInDays < 1 || boot.ServiceTokenExpiresInDays > 3650 {
+			v.addf("invalid SEED_SERVICE_TOKEN_EXPIRES_IN_DAYS %d, expected between 1 and 3650", boot.ServiceTokenExpiresInDays)
+		}
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package dbstats measures the database queries Verisafe makes.
+//
+// The Tracer is installed on the connection pools and sees every query. It
+// exports the duration, rows and failures of every query as metrics, labelled
+// with the name of the sqlc query, and logs the queries slower than a
+// threshold along with the ID of the request that made them.
+//
+// It also counts the queries whose context carries a Counter, which the slow
+// request middleware adds to every request, so that a request making a query
+// per item it returns stands out in the logs. Queries made in the background
+// are not counted.
+package dbstats
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+)
+
+const (
+	counterKey    = "dbstats.counter"
+	queryTraceKey = "dbstats.query_trace"
+)
+
+// unnamedQuery labels the queries that were not generated by sqlc, such as
+// those of migrations
+const unnamedQuery = "unnamed"
+
+// maxExemplarRequestIDLength keeps exemplars within the 128 characters
+// Prometheus allows for their labels
+const maxExemplarRequestIDLength = 64
+
+// Counter counts queries and the time spent waiting for them. It is safe for
+// concurrent use
+type Counter struct {
+	queries  atomic.Int64
+	duration atomic.Int64
+}
+
+// Queries returns how many queries were made
+func (c *Counter) Queries() int64 {
+	return c.queries.Load()
+}
+
+// Duration returns the time spent waiting for the queries
+func (c *Counter) Duration() time.Duration {
+	return time.Duration(c.duration.Load())
+}
+
+// WithCounter returns a context counting the queries made with it
+func WithCounter(ctx context.Context) (context.Context, *Counter) {
+	counter := &Counter{}
+	return context.WithValue(ctx, counterKey, counter), counter
+}
+
+// queryTrace is what the Tracer remembers of a query until it ends
+type queryTrace struct {
+	name  string
+	start time.Time
+}
+
+// Tracer measures the queries made on a connection pool
+type Tracer struct {
+	// Pool labels the metrics of the queries, e.g. primary or replica
+	Pool string
+	// Queries taking longer than SlowQueryThreshold are logged, zero turns
+	// the logs off
+	SlowQueryThreshold time.Duration
+	Logger             *slog.Logger
+	// RequestID returns the ID of the request a query was made for, empty
+	// for queries made in the background
+	RequestID func(ctx context.Context) string
+}
+
+var _ pgx.QueryTracer = (*Tracer)(nil)
+
+func (t *Tracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryTraceKey, queryTrace{
+		name:  QueryName(data.SQL),
+		start: time.Now(),
+	})
+}
+
+func (t *Tracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	trace, ok := ctx.Value(queryTraceKey).(queryTrace)
+	if !ok {
+		return
+	}
+	elapsed := time.Since(trace.start)
+
+	if counter, ok := ctx.Value(counterKey).(*Counter); ok {
+		counter.queries.Add(1)
+		counter.duration.Add(int64(elapsed))
+	}
+
+	if data.Err != nil {
+		metrics.DBQueryErrors.WithLabelValues(t.Pool, trace.name).Inc()
+	} else {
+		metrics.DBQueryRows.WithLabelValues(t.Pool, trace.name).Observe(float64(data.CommandTag.RowsAffected()))
+	}
+
+	duration := metrics.DBQueryDuration.WithLabelValues(t.Pool, trace.name)
+	if t.SlowQueryThreshold <= 0 || elapsed <= t.SlowQueryThreshold {
+		duration.Observe(elapsed.Seconds())
+		return
+	}
+
+	// Slow queries are rare enough to link their observations to the
+	// request that made them
+	requestID := ""
+	if t.RequestID != nil {
+		requestID = t.RequestID(ctx)
+	}
+	if requestID != "" && len(requestID) <= maxExemplarRequestIDLength {
+		duration.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed.Seconds(), prometheus.Labels{"request_id": requestID})
+	} else {
+		duration.Observe(elapsed.Seconds())
+	}
+
+	attrs := []any{
+		slog.String("pool", t.Pool),
+		slog.String("query", trace.name),
+		slog.Int64("duration_ms", elapsed.Milliseconds()),
+		slog.Int64("threshold_ms", t.SlowQueryThreshold.Milliseconds()),
+		slog.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.Any("error", data.Err))
+	}
+	t.Logger.Warn("Slow query", attrs...)
+}
+
+// QueryName returns the name sqlc gave a query, which its SQL starts with
+// e.g. "-- name: GetAccountByID :one", or "unnamed" for other queries
+func QueryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return unnamedQuery
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	if name == "" {
+		return unnamedQuery
+	}
+	return name
+}
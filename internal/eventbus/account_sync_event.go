@@ -0,0 +1,40 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountSyncEventMetaData describes an account sync event. EventID must be
+// unique among the events of the source service, it is used to skip events
+// that are delivered more than once
+type AccountSyncEventMetaData struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Timestamp       time.Time `json:"timestamp"`
+	SourceServiceID string    `json:"source_service_id"`
+	RequestID       string    `json:"request_id"`
+}
+
+// AccountSyncAccount holds the account details another service emitted.
+// The account is looked up by ID when set and by email otherwise, details
+// that are left out are not changed
+type AccountSyncAccount struct {
+	ID         *uuid.UUID `json:"id"`
+	Email      string     `json:"email"`
+	Name       string     `json:"name"`
+	Username   *string    `json:"username"`
+	AvatarURL  *string    `json:"avatar_url"`
+	Phone      *string    `json:"phone"`
+	NationalID *string    `json:"national_id"`
+}
+
+// AccountSyncEvent is an account update emitted by another service, e.g.
+// the legacy registrar
+type AccountSyncEvent struct {
+	Account              AccountSyncAccount       `json:"account"`
+	LinkInstitutionIDs   []int32                  `json:"link_institution_ids"`
+	UnlinkInstitutionIDs []int32                  `json:"unlink_institution_ids"`
+	Metadata             AccountSyncEventMetaData `json:"meta"`
+}
@@ -0,0 +1,75 @@
+// Documentation for the account sync eventbus
+//
+// OVERVIEW:
+// Unlike the other event buses the AccountSyncEventBus consumes events rather
+// than publishing them. Other services, e.g. the legacy registrar, emit
+// account updates to an exchange of their own which Verisafe binds a durable
+// queue to, named after the exchange and the configured routing key.
+//
+// EVENT TYPES:
+// - account.upserted: Creates the account or updates its details and links
+//   or unlinks it from institutions
+//
+// MESSAGE DELIVERY:
+// Events are delivered at least once, handlers must be idempotent. Events
+// that cannot be decoded or keep failing are dead-lettered.
+
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+type AccountSyncEventBus struct {
+	bus        EventBus
+	routingKey string
+	logger     *slog.Logger
+}
+
+// NewAccountSyncEventBus creates a new AccountSyncEventBus instance.
+func NewAccountSyncEventBus(cfg *config.Config, logger *slog.Logger) (*AccountSyncEventBus, error) {
+	bus, err := newEventBus(
+		cfg,
+		cfg.AccountSyncConfig.Exchange,
+		ExchangeType(cfg.AccountSyncConfig.ExchangeType),
+		logger,
+	)
+
+	if err != nil {
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	return &AccountSyncEventBus{
+		bus:        bus,
+		routingKey: cfg.AccountSyncConfig.RoutingKey,
+		logger:     logger,
+	}, nil
+}
+
+// OnAccountSync hands every account sync event to the handler. Events that
+// cannot be decoded are rejected without calling it
+func (b *AccountSyncEventBus) OnAccountSync(handler func(ctx context.Context, event AccountSyncEvent) error) error {
+	return b.bus.Subscribe(b.routingKey, func(ctx context.Context, body []byte) error {
+		var event AccountSyncEvent
+		if err := json.Unmarshal(body, &event); err != nil {
+			return fmt.Errorf("decode account sync event: %w", err)
+		}
+		return handler(ctx, event)
+	})
+}
+
+// Health reports the state of the bus's broker connection
+func (b *AccountSyncEventBus) Health() Health {
+	return b.bus.Health()
+}
+
+// Close cancels the internal context, signalling all active handlers to stop.
+func (b *AccountSyncEventBus) Close() {
+	b.bus.Close()
+}
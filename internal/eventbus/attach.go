@@ -0,0 +1,163 @@
+package eventbus
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// attachingEventBus runs on an in-memory stand-in while the broker cannot be
+// reached and keeps trying to connect in the background. Once the broker is
+// reachable the connected bus takes over, the subscriptions made in the
+// meantime are moved to it and the stand-in is closed.
+//
+// Events published while running on the stand-in never reach the broker,
+// they can be replayed from the event journal once the bus is attached.
+type attachingEventBus struct {
+	cfg          *config.Config
+	exchange     string
+	exchangeType ExchangeType
+	logger       *slog.Logger
+
+	mu                sync.RWMutex
+	bus               EventBus
+	attached          bool
+	closed            bool
+	subscriptions     []subscription
+	disconnectedSince time.Time
+	done              chan struct{}
+}
+
+// newAttachingEventBus starts running on the stand-in and attaches to the
+// broker as soon as it can be reached
+func newAttachingEventBus(cfg *config.Config, exchange string, exchangeType ExchangeType, standIn EventBus, logger *slog.Logger) *attachingEventBus {
+	ab := &attachingEventBus{
+		cfg:               cfg,
+		exchange:          exchange,
+		exchangeType:      exchangeType,
+		logger:            logger,
+		bus:               standIn,
+		disconnectedSince: time.Now(),
+		done:              make(chan struct{}),
+	}
+	go ab.attachLoop()
+	return ab
+}
+
+// attachLoop tries to connect to the broker with exponential backoff until it
+// succeeds or the bus is closed
+func (ab *attachingEventBus) attachLoop() {
+	delay := reconnectMinDelay
+	for {
+		select {
+		case <-ab.done:
+			return
+		case <-time.After(delay):
+		}
+
+		bus, err := connectEventBus(ab.cfg, ab.exchange, ab.exchangeType, ab.logger)
+		if err != nil {
+			delay = min(delay*2, reconnectMaxDelay)
+			ab.logger.Warn("Event bus still unavailable, running in degraded mode",
+				slog.String("exchange", ab.exchange),
+				slog.Duration("retry_in", delay),
+				slog.Any("error", err),
+			)
+			continue
+		}
+
+		if ab.attach(bus) {
+			ab.logger.Info("Event bus attached to the broker",
+				slog.String("exchange", ab.exchange),
+				slog.Duration("degraded_for", time.Since(ab.disconnectedSince)),
+			)
+		}
+		return
+	}
+}
+
+// attach makes the connected bus take over from the stand-in. It reports
+// false when the bus was closed in the meantime
+func (ab *attachingEventBus) attach(bus EventBus) bool {
+	ab.mu.Lock()
+	if ab.closed {
+		ab.mu.Unlock()
+		bus.Close()
+		return false
+	}
+
+	for _, s := range ab.subscriptions {
+		if err := bus.Subscribe(s.routingKey, s.handler); err != nil {
+			ab.logger.Error("Failed to move subscription to the attached event bus",
+				slog.String("exchange", ab.exchange),
+				slog.String("routing_key", s.routingKey),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	standIn := ab.bus
+	ab.bus = bus
+	ab.attached = true
+	ab.mu.Unlock()
+
+	standIn.Close()
+	return true
+}
+
+// current returns the bus events are published through right now
+func (ab *attachingEventBus) current() EventBus {
+	ab.mu.RLock()
+	defer ab.mu.RUnlock()
+	return ab.bus
+}
+
+// Publish publishes the event through the connected bus, or through the
+// stand-in until the broker is reachable
+func (ab *attachingEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	return ab.current().Publish(ctx, routingKey, event)
+}
+
+// Subscribe subscribes the handler on the current bus and remembers the
+// subscription so that it can be moved to the connected bus
+func (ab *attachingEventBus) Subscribe(routingKey string, handler Handler) error {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+
+	if !ab.attached {
+		ab.subscriptions = append(ab.subscriptions, subscription{routingKey, handler})
+	}
+	return ab.bus.Subscribe(routingKey, handler)
+}
+
+// Health reports the state of the connected bus, or the stand-in as
+// disconnected since startup until the broker is reachable
+func (ab *attachingEventBus) Health() Health {
+	ab.mu.RLock()
+	defer ab.mu.RUnlock()
+
+	health := ab.bus.Health()
+	if !ab.attached {
+		since := ab.disconnectedSince
+		health.DisconnectedSince = &since
+	}
+	return health
+}
+
+// Close stops trying to attach and closes the current bus
+func (ab *attachingEventBus) Close() {
+	ab.mu.Lock()
+	if ab.closed {
+		ab.mu.Unlock()
+		return
+	}
+	ab.closed = true
+	close(ab.done)
+	bus := ab.bus
+	ab.mu.Unlock()
+
+	bus.Close()
+}
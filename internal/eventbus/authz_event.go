@@ -0,0 +1,33 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuthzEventMetadata contains crucial information about the event itself.
+type AuthzEventMetadata struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Timestamp       time.Time `json:"timestamp"`
+	SourceServiceID string    `json:"source_service_id"`
+	RequestID       string    `json:"request_id"`
+}
+
+// AuthzChange describes a single change made to roles, permissions or their
+// assignments. Before and After hold the state of the target around the
+// change and are empty when the target did not exist at that point.
+type AuthzChange struct {
+	Action     string          `json:"action"`
+	ActorID    string          `json:"actor_id"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+}
+
+// AuthzEvent defines the payload for authorization change events.
+type AuthzEvent struct {
+	Change   AuthzChange        `json:"change"`
+	Metadata AuthzEventMetadata `json:"meta"`
+}
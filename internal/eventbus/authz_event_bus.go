@@ -0,0 +1,135 @@
+// Documentation for the authz eventbus
+//
+// OVERVIEW:
+// The AuthzEventBus notifies downstream services whenever roles, permissions or
+// their assignments change so that cached authorization decisions can be
+// dropped and SIEM tooling can track privilege changes.
+//
+// EXCHANGE TYPE: Fanout
+// Events are published to the verisafe.authz.exchange fanout exchange, every
+// queue bound to it receives a copy of each event.
+//
+// EVENT TYPES:
+// - authz.changed: Published after any role or permission change has been
+//   committed. The payload carries the action, the actor, the target and the
+//   state of the target before and after the change.
+//
+// Every event is also forwarded to webhook endpoints subscribed to
+// authz.changed and reported to the SecurityEventBus as
+// security.permission.changed.
+
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// AuthzEventBus provides a type-safe API for authorization change events.
+type AuthzEventBus struct {
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+	webhooks    WebhookEnqueuer
+	security    *SecurityEventBus
+}
+
+// NewAuthzEventBus creates a new AuthzEventBus instance.
+func NewAuthzEventBus(cfg *config.Config, logger *slog.Logger) (*AuthzEventBus, error) {
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.AuthzExchange, defaultAuthzExchange),
+		FanoutExchangeType,
+		logger,
+	)
+
+	if err != nil {
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	return &AuthzEventBus{
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
+	}, nil
+}
+
+// PublishAuthzChanged publishes an authorization changed event to the event bus
+func (b *AuthzEventBus) PublishAuthzChanged(ctx context.Context, change AuthzChange, requestID string) error {
+	event := AuthzEvent{
+		Change: change,
+		Metadata: AuthzEventMetadata{
+			EventID:         newEventID(),
+			EventType:       "authz.changed",
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := b.routingKeys.resolve("authz.changed", "")
+	b.logger.Info("Publishing authz changed event",
+		slog.String("routing_key", routingKey),
+		slog.String("action", change.Action),
+		slog.String("target_type", change.TargetType),
+		slog.String("target_id", change.TargetID),
+		slog.String("request_id", requestID),
+	)
+
+	if b.webhooks != nil {
+		if err := b.webhooks.Enqueue(ctx, event.Metadata.EventType, event); err != nil {
+			b.logger.Error("Failed to enqueue authz event webhooks",
+				slog.String("request_id", requestID),
+				slog.Any("error", err),
+			)
+		}
+	}
+	if b.security != nil {
+		details, _ := json.Marshal(change)
+		activity := SecurityActivity{
+			Outcome:  OutcomeSuccess,
+			Severity: SeverityWarning,
+			ActorID:  change.ActorID,
+			Details:  details,
+		}
+		if accountID := event.eventAccountID(); accountID != nil {
+			activity.AccountID = accountID.String()
+		}
+		b.security.Report(SecurityPermissionChanged, activity, requestID)
+	}
+	return b.bus.Publish(ctx, routingKey, event)
+}
+
+// SetSecurityEventBus makes the bus report every event it publishes as a
+// permission change to the security event stream
+func (b *AuthzEventBus) SetSecurityEventBus(security *SecurityEventBus) {
+	b.security = security
+}
+
+// SetWebhookEnqueuer makes the bus forward every event it publishes to the
+// registered webhook endpoints
+func (b *AuthzEventBus) SetWebhookEnqueuer(webhooks WebhookEnqueuer) {
+	b.webhooks = webhooks
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *AuthzEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *AuthzEventBus) Health() Health {
+	return b.bus.Health()
+}
+
+// Close cancels the internal context, signalling all active handlers to stop.
+func (b *AuthzEventBus) Close() {
+	b.bus.Close()
+}
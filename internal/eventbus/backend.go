@@ -0,0 +1,155 @@
+package eventbus
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// Event bus backends selectable through EVENTBUS_BACKEND
+const (
+	RabbitMQBackend = "rabbitmq"
+	KafkaBackend    = "kafka"
+	NATSBackend     = "nats"
+	MemoryBackend   = "memory"
+)
+
+// newEventBus creates the event bus for an exchange on the configured
+// backend, wrapped by wrapEventBus. Unless the event bus is required, a
+// broker that cannot be reached is not fatal: Verisafe starts in degraded
+// mode with an in-memory bus standing in for it, which reports itself as
+// disconnected and whose events never leave the process. The broker is
+// attached automatically as soon as it can be reached.
+func newEventBus(cfg *config.Config, exchange string, exchangeType ExchangeType, logger *slog.Logger) (EventBus, error) {
+	signer, err := NewEventSigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	bus, err := connectEventBus(cfg, exchange, exchangeType, logger)
+	if err == nil {
+		return wrapEventBus(cfg, signer, bus), nil
+	}
+	if cfg.EventBusConfig.Required || errors.Is(err, errUnknownBackend) {
+		return nil, err
+	}
+
+	logger.Error("Event bus unavailable, running in degraded mode until the broker can be reached",
+		slog.Bool("alert", true),
+		slog.String("backend", cfg.EventBusConfig.Backend),
+		slog.String("exchange", exchange),
+		slog.Any("error", err),
+	)
+	standIn := NewMemoryEventBus(exchange, exchangeType, logger)
+	standIn.standIn = true
+	return wrapEventBus(cfg, signer, newAttachingEventBus(cfg, exchange, exchangeType, standIn, logger)), nil
+}
+
+// wrapEventBus instruments a connected bus, wraps its events in CloudEvents
+// envelopes when enabled and signs them when a signer is configured. Events
+// are signed last so the signature covers the published body
+func wrapEventBus(cfg *config.Config, signer *EventSigner, bus EventBus) EventBus {
+	if signer != nil {
+		bus = &signingEventBus{EventBus: bus, signer: signer}
+	}
+	if cfg.EventBusConfig.CloudEvents {
+		bus = &cloudEventsEventBus{EventBus: bus}
+	}
+	return newInstrumentedEventBus(bus)
+}
+
+// errUnknownBackend is returned when EVENTBUS_BACKEND names no backend
+var errUnknownBackend = errors.New("unknown event bus backend")
+
+// connectEventBus creates the event bus for an exchange on the configured
+// backend and connects it to the broker
+func connectEventBus(cfg *config.Config, exchange string, exchangeType ExchangeType, logger *slog.Logger) (EventBus, error) {
+	switch cfg.EventBusConfig.Backend {
+	case "", RabbitMQBackend:
+		bus, err := NewRabbitMQEventBus(rabbitMQURL(cfg), exchange, exchangeType, logger)
+		if err != nil {
+			return nil, err
+		}
+		return bus, nil
+	case KafkaBackend:
+		bus, err := NewKafkaEventBus(
+			cfg.KafkaConfig.Brokers,
+			exchange,
+			exchangeType,
+			cfg.KafkaConfig.TopicMapping,
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return bus, nil
+	case NATSBackend:
+		bus, err := NewNATSEventBus(
+			cfg.NATSConfig.URL,
+			cfg.NATSConfig.Stream,
+			cfg.NATSConfig.SubjectPrefix,
+			exchange,
+			exchangeType,
+			cfg.NATSConfig.SubjectMapping,
+			logger,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return bus, nil
+	case MemoryBackend:
+		return MemoryEventBusFor(exchange, exchangeType, logger), nil
+	default:
+		return nil, fmt.Errorf("%w %q", errUnknownBackend, cfg.EventBusConfig.Backend)
+	}
+}
+
+// rabbitMQURL returns the URL of the configured RabbitMQ broker
+func rabbitMQURL(cfg *config.Config) string {
+	return fmt.Sprintf("amqp://%s:%s@%s:%d/",
+		cfg.RabbitMQConfig.RabbitMQUser,
+		cfg.RabbitMQConfig.RabbitMQPass,
+		cfg.RabbitMQConfig.RabbitMQAddress,
+		cfg.RabbitMQConfig.RabbitMQPort,
+	)
+}
+
+// routes reports whether an event published with key is routed to a
+// subscription made with routingKey, the way a RabbitMQ exchange of the given
+// type would route it. Fanout exchanges route every event, topic exchanges
+// support the * and # wildcards and direct exchanges need an exact match.
+// Backends without exchanges use it to filter consumed events
+func routes(exchangeType ExchangeType, routingKey, key string) bool {
+	switch exchangeType {
+	case FanoutExchangeType:
+		return true
+	case TopicExchangeType:
+		return matchTopic(strings.Split(routingKey, "."), strings.Split(key, "."))
+	default:
+		return routingKey == key
+	}
+}
+
+// matchTopic matches the words of a routing key against the words of a topic
+// pattern, where * matches exactly one word and # zero or more words
+func matchTopic(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopic(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopic(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && pattern[0] == words[0] && matchTopic(pattern[1:], words[1:])
+	}
+}
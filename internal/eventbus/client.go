@@ -2,12 +2,13 @@ package eventbus
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
 	"time"
 
+	"github.com/opencrafts-io/verisafe/internal/metrics"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -18,25 +19,91 @@ const (
 	FanoutExchangeType ExchangeType = "fanout"
 	TopicExchangeType  ExchangeType = "topic"
 
-	reconnectDelay = 5 * time.Second
+	// Delay before the first reconnect attempt. The delay doubles after every
+	// failed attempt up to reconnectMaxDelay.
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = time.Minute
+
+	// How many events are kept in memory while the bus is disconnected. They
+	// are published once the connection is restored.
+	publishBufferSize = 1000
+
+	// How many times a publish the broker did not confirm is attempted before
+	// the event is dead-lettered, the delay before the first retry (doubling
+	// on every retry) and how long to wait for a confirmation.
+	publishMaxAttempts    = 3
+	publishRetryDelay     = 200 * time.Millisecond
+	publishConfirmTimeout = 5 * time.Second
+
+	// Events that could not be published and consumed events that were
+	// rejected end up on the dead-letter exchange. Every event bus shares it
+	// and the dead-letter queue keeps them for inspection.
+	deadLetterExchange = "verisafe.dead-letter.exchange"
+	deadLetterQueue    = "io.opencrafts.verisafe.dead-letter"
+
+	// How many times a consumed event is handed to its handler before it is
+	// rejected, and the delay before the first retry. The delay doubles on
+	// every retry.
+	handlerMaxAttempts = 5
+	handlerRetryDelay  = time.Second
+
+	// How many unacknowledged deliveries a consumer may hold at once
+	consumerPrefetch = 10
 )
 
+// ErrPublishBufferFull is returned when an event is published while the bus
+// is disconnected and the publish buffer is already full.
+var ErrPublishBufferFull = errors.New("eventbus: disconnected and publish buffer is full")
+
+// errPublishNacked is returned when the broker refuses to take an event
+var errPublishNacked = errors.New("eventbus: broker did not confirm the event")
+
+// Handler processes a consumed event. Returning an error makes the event bus
+// hand the event to the handler again, so handlers should be idempotent.
+type Handler func(ctx context.Context, event []byte) error
+
 // EventBus is an interface that defines the contract for any event bus implementation.
 type EventBus interface {
 	Publish(ctx context.Context, routingKey string, event any) error
-	Subscribe(routingKey string, handler func(event []byte)) error
+	Subscribe(routingKey string, handler Handler) error
+	Health() Health
 	Close()
 }
 
+// Health describes the state of an event bus connection.
+type Health struct {
+	Exchange          string     `json:"exchange"`
+	Connected         bool       `json:"connected"`
+	BufferedPublishes int        `json:"buffered_publishes"`
+	DroppedPublishes  int64      `json:"dropped_publishes"`
+	Reconnects        int64      `json:"reconnects"`
+	DeadLettered      int64      `json:"dead_lettered"`
+	DisconnectedSince *time.Time `json:"disconnected_since,omitempty"`
+}
+
+// HealthReporter is implemented by event buses that can report the state of
+// their connection.
+type HealthReporter interface {
+	Health() Health
+}
+
 // subscription holds the info needed to re-register a consumer after reconnect.
 type subscription struct {
 	routingKey string
-	handler    func([]byte)
+	handler    Handler
+}
+
+// pendingPublish is an event waiting for the connection to be restored.
+type pendingPublish struct {
+	routingKey string
+	body       []byte
+	headers    amqp.Table
 }
 
 // RabbitMQEventBus is a concrete implementation of EventBus that uses RabbitMQ.
 // It maintains a dedicated publish channel and creates a new channel per subscriber.
-// It automatically reconnects on connection loss.
+// It automatically reconnects on connection loss, buffering the events
+// published in the meantime.
 type RabbitMQEventBus struct {
 	amqpURI      string
 	exchange     string
@@ -45,10 +112,22 @@ type RabbitMQEventBus struct {
 
 	mu        sync.RWMutex
 	conn      *amqp.Connection
-	publishCh *amqp.Channel // dedicated channel for publishing
+	publishCh *amqp.Channel // dedicated channel for publishing, nil while disconnected
 
 	subscriptions []subscription // kept so we can re-subscribe on reconnect
 
+	pending        []pendingPublish // published while disconnected
+	dropped        int64            // published while the buffer was full
+	reconnects     int64
+	deadLettered   int64
+	disconnectedAt time.Time
+
+	// Handlers run with handlerCtx, which is cancelled by Close. Close waits
+	// for consumers to finish the event they are handling.
+	handlerCtx    context.Context
+	cancelHandler context.CancelFunc
+	consumers     sync.WaitGroup
+
 	done chan struct{} // closed when Close() is called
 }
 
@@ -56,39 +135,47 @@ type RabbitMQEventBus struct {
 // It connects to RabbitMQ and declares a durable exchange, then starts a
 // background goroutine that reconnects automatically on connection loss.
 func NewRabbitMQEventBus(amqpURI, exchange string, exchangeType ExchangeType, logger *slog.Logger) (*RabbitMQEventBus, error) {
+	handlerCtx, cancelHandler := context.WithCancel(context.Background())
 	eb := &RabbitMQEventBus{
-		amqpURI:      amqpURI,
-		exchange:     exchange,
-		exchangeType: exchangeType,
-		logger:       logger,
-		done:         make(chan struct{}),
+		amqpURI:       amqpURI,
+		exchange:      exchange,
+		exchangeType:  exchangeType,
+		logger:        logger,
+		handlerCtx:    handlerCtx,
+		cancelHandler: cancelHandler,
+		done:          make(chan struct{}),
 	}
 
-	if err := eb.connect(); err != nil {
+	conn, publishCh, err := eb.connect()
+	if err != nil {
+		cancelHandler()
 		return nil, err
 	}
+	eb.conn = conn
+	eb.publishCh = publishCh
 
 	go eb.reconnectLoop()
 
 	return eb, nil
 }
 
-// connect establishes the AMQP connection, declares the exchange, and opens
-// a dedicated publish channel. It is called both on initial startup and after
-// a connection drop is detected.
-func (eb *RabbitMQEventBus) connect() error {
+// connect establishes the AMQP connection, declares the exchange and the
+// dead-letter exchange, and opens a dedicated publish channel in confirm
+// mode. It is called both on initial startup and after a connection drop is
+// detected.
+func (eb *RabbitMQEventBus) connect() (*amqp.Connection, *amqp.Channel, error) {
 	conn, err := amqp.DialConfig(eb.amqpURI, amqp.Config{
 		Heartbeat: 10 * time.Second,
 		Locale:    "en_US",
 	})
 	if err != nil {
-		return fmt.Errorf("amqp dial: %w", err)
+		return nil, nil, fmt.Errorf("amqp dial: %w", err)
 	}
 
 	publishCh, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return fmt.Errorf("open publish channel: %w", err)
+		return nil, nil, fmt.Errorf("open publish channel: %w", err)
 	}
 
 	if err = publishCh.ExchangeDeclare(
@@ -101,64 +188,111 @@ func (eb *RabbitMQEventBus) connect() error {
 		nil,
 	); err != nil {
 		conn.Close()
-		return fmt.Errorf("declare exchange: %w", err)
+		return nil, nil, fmt.Errorf("declare exchange: %w", err)
 	}
 
-	eb.mu.Lock()
-	eb.conn = conn
-	eb.publishCh = publishCh
-	eb.mu.Unlock()
+	if err = declareDeadLetter(publishCh); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
 
+	if err = publishCh.Confirm(false); err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("enable publisher confirms: %w", err)
+	}
+
+	return conn, publishCh, nil
+}
+
+// declareDeadLetter declares the dead-letter exchange and a queue that keeps
+// every event routed to it
+func declareDeadLetter(ch *amqp.Channel) error {
+	if err := ch.ExchangeDeclare(
+		deadLetterExchange,
+		string(TopicExchangeType),
+		true,  // durable
+		false, // auto-deleted
+		false, // internal
+		false, // no-wait
+		nil,
+	); err != nil {
+		return fmt.Errorf("declare dead-letter exchange: %w", err)
+	}
+
+	if _, err := ch.QueueDeclare(deadLetterQueue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("declare dead-letter queue: %w", err)
+	}
+
+	if err := ch.QueueBind(deadLetterQueue, "#", deadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("bind dead-letter queue: %w", err)
+	}
 	return nil
 }
 
-// reconnectLoop watches for connection-level close notifications and
-// re-establishes the connection (and all subscriptions) automatically.
+// reconnectLoop watches for connection and publish channel close
+// notifications and re-establishes the connection (and all subscriptions)
+// automatically, backing off exponentially between failed attempts.
 func (eb *RabbitMQEventBus) reconnectLoop() {
 	for {
 		eb.mu.RLock()
 		conn := eb.conn
+		publishCh := eb.publishCh
 		eb.mu.RUnlock()
 
 		connClose := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClose := publishCh.NotifyClose(make(chan *amqp.Error, 1))
 
+		var amqpErr *amqp.Error
 		select {
 		case <-eb.done:
 			return
-		case amqpErr, ok := <-connClose:
+		case err, ok := <-connClose:
 			if !ok {
 				// channel closed without error — shutting down cleanly
 				return
 			}
-			eb.logger.Warn("eventbus connection lost, reconnecting",
-				slog.Any("error", amqpErr),
-				slog.Duration("delay", reconnectDelay),
-			)
+			amqpErr = err
+		case err := <-chClose:
+			// The broker closed the publish channel while the connection
+			// survived. Start over with a fresh connection.
+			amqpErr = err
+			conn.Close()
 		}
 
-		// Back-off then reconnect.
 		select {
 		case <-eb.done:
 			return
-		case <-time.After(reconnectDelay):
+		default:
 		}
 
+		eb.markDisconnected()
+		eb.logger.Warn("eventbus connection lost, reconnecting",
+			slog.String("exchange", eb.exchange),
+			slog.Any("error", amqpErr),
+		)
+
+		delay := reconnectMinDelay
 		for {
-			if err := eb.connect(); err != nil {
-				eb.logger.Error("eventbus reconnect failed, retrying",
-					slog.Any("error", err),
-					slog.Duration("delay", reconnectDelay),
-				)
-				select {
-				case <-eb.done:
-					return
-				case <-time.After(reconnectDelay):
-				}
-				continue
+			select {
+			case <-eb.done:
+				return
+			case <-time.After(delay):
 			}
-			eb.logger.Info("eventbus reconnected successfully")
-			break
+
+			conn, publishCh, err := eb.connect()
+			if err == nil {
+				eb.markConnected(conn, publishCh)
+				break
+			}
+
+			delay = min(delay*2, reconnectMaxDelay)
+			eb.logger.Error("eventbus reconnect failed, retrying",
+				slog.String("exchange", eb.exchange),
+				slog.Any("error", err),
+				slog.Duration("delay", delay),
+			)
 		}
+		eb.logger.Info("eventbus reconnected successfully", slog.String("exchange", eb.exchange))
 
 		// Re-register all existing subscriptions on the new connection.
 		eb.mu.RLock()
@@ -177,25 +311,117 @@ func (eb *RabbitMQEventBus) reconnectLoop() {
 	}
 }
 
+// markDisconnected makes publishes go to the buffer until the connection is
+// restored
+func (eb *RabbitMQEventBus) markDisconnected() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	eb.publishCh = nil
+	if eb.disconnectedAt.IsZero() {
+		eb.disconnectedAt = time.Now()
+	}
+}
+
+// markConnected switches the bus over to a new connection and publishes the
+// events buffered while it was disconnected. The lock is held while the
+// buffer is flushed so buffered events go out before new ones.
+func (eb *RabbitMQEventBus) markConnected(conn *amqp.Connection, publishCh *amqp.Channel) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	eb.conn = conn
+	eb.publishCh = publishCh
+	eb.reconnects++
+	eb.disconnectedAt = time.Time{}
+
+	for i, p := range eb.pending {
+		if err := eb.publish(context.Background(), publishCh, eb.exchange, p.routingKey, p.body, p.headers); err != nil {
+			eb.logger.Error("eventbus failed to flush buffered events",
+				slog.String("exchange", eb.exchange),
+				slog.Int("remaining", len(eb.pending)-i),
+				slog.Any("error", err),
+			)
+			eb.pending = eb.pending[i:]
+			return
+		}
+	}
+	if len(eb.pending) > 0 {
+		eb.logger.Info("eventbus flushed buffered events",
+			slog.String("exchange", eb.exchange),
+			slog.Int("count", len(eb.pending)),
+		)
+	}
+	eb.pending = nil
+}
+
 // Publish serialises the event and sends it to the RabbitMQ exchange using
-// the dedicated publish channel.
+// the dedicated publish channel, waiting for the broker to confirm it.
+// While the bus is disconnected the event is buffered and published once the
+// connection is restored. Publishes the broker does not confirm are retried
+// with backoff and, once every attempt failed, the event is routed to the
+// dead-letter exchange and an error is returned.
 func (eb *RabbitMQEventBus) Publish(ctx context.Context, routingKey string, event any) error {
-	body, err := json.Marshal(event)
+	body, signature, err := encodeEvent(event)
 	if err != nil {
-		return fmt.Errorf("marshal event: %w", err)
+		return err
+	}
+	var headers amqp.Table
+	if len(signature) > 0 {
+		headers = amqp.Table{}
+		for key, value := range signature {
+			headers[key] = value
+		}
 	}
 
-	eb.mu.RLock()
-	ch := eb.publishCh
-	eb.mu.RUnlock()
+	delay := publishRetryDelay
+	for attempt := 1; ; attempt++ {
+		eb.mu.RLock()
+		ch := eb.publishCh
+		eb.mu.RUnlock()
 
-	if ch == nil {
-		return fmt.Errorf("eventbus: not connected")
+		if ch == nil {
+			return eb.buffer(routingKey, body, headers)
+		}
+
+		err = eb.publish(ctx, ch, eb.exchange, routingKey, body, headers)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, amqp.ErrClosed) {
+			return eb.buffer(routingKey, body, headers)
+		}
+		if attempt >= publishMaxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		eb.logger.Warn("eventbus publish failed, retrying",
+			slog.String("exchange", eb.exchange),
+			slog.String("routing_key", routingKey),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		delay *= 2
 	}
 
-	return ch.PublishWithContext(
+	eb.deadLetter(routingKey, body, err)
+	return fmt.Errorf("publish %q: %w", routingKey, err)
+}
+
+// publish sends an already serialised event on the given channel and waits
+// for the broker to confirm it
+func (eb *RabbitMQEventBus) publish(ctx context.Context, ch *amqp.Channel, exchange, routingKey string, body []byte, headers amqp.Table) error {
+	ctx, cancel := context.WithTimeout(ctx, publishConfirmTimeout)
+	defer cancel()
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(
 		ctx,
-		eb.exchange,
+		exchange,
 		routingKey,
 		false, // mandatory
 		false, // immediate
@@ -203,14 +429,113 @@ func (eb *RabbitMQEventBus) Publish(ctx context.Context, routingKey string, even
 			ContentType:  "application/json",
 			Body:         body,
 			DeliveryMode: amqp.Persistent,
+			Headers:      headers,
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errPublishNacked
+	}
+	return nil
+}
+
+// deadLetter routes an event that could not be published to the dead-letter
+// exchange. The original exchange, routing key and failure are kept in the
+// message headers
+func (eb *RabbitMQEventBus) deadLetter(routingKey string, body []byte, cause error) {
+	eb.mu.Lock()
+	eb.deadLettered++
+	ch := eb.publishCh
+	eb.mu.Unlock()
+
+	metrics.EventsDeadLettered.WithLabelValues(eb.exchange).Inc()
+
+	// Logged as an error with alert set so log based alerting picks it up
+	eb.logger.Error("eventbus dead-lettered event",
+		slog.Bool("alert", true),
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+		slog.Any("error", cause),
+	)
+
+	if ch == nil {
+		return
+	}
+	headers := amqp.Table{
+		"x-original-exchange":    eb.exchange,
+		"x-original-routing-key": routingKey,
+		"x-failure-reason":       cause.Error(),
+	}
+	if err := eb.publish(context.Background(), ch, deadLetterExchange, routingKey, body, headers); err != nil {
+		eb.logger.Error("eventbus failed to dead-letter event, the event is lost",
+			slog.Bool("alert", true),
+			slog.String("exchange", eb.exchange),
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// buffer keeps an event until the connection is restored
+func (eb *RabbitMQEventBus) buffer(routingKey string, body []byte, headers amqp.Table) error {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	if eb.publishCh != nil {
+		// Reconnected in the meantime
+		return eb.publish(context.Background(), eb.publishCh, eb.exchange, routingKey, body, headers)
+	}
+
+	if len(eb.pending) >= publishBufferSize {
+		eb.dropped++
+		return ErrPublishBufferFull
+	}
+	eb.pending = append(eb.pending, pendingPublish{routingKey: routingKey, body: body, headers: headers})
+	eb.logger.Warn("eventbus disconnected, buffering event",
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+		slog.Int("buffered", len(eb.pending)),
+	)
+	return nil
+}
+
+// Health reports whether the bus is connected and how many events are
+// waiting for the connection to be restored
+func (eb *RabbitMQEventBus) Health() Health {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	h := Health{
+		Exchange:          eb.exchange,
+		Connected:         eb.publishCh != nil,
+		BufferedPublishes: len(eb.pending),
+		DroppedPublishes:  eb.dropped,
+		Reconnects:        eb.reconnects,
+		DeadLettered:      eb.deadLettered,
+	}
+	if !eb.disconnectedAt.IsZero() {
+		since := eb.disconnectedAt
+		h.DisconnectedSince = &since
+	}
+	return h
 }
 
 // Subscribe declares a durable queue, binds it to the exchange with the given
 // routing key, and begins consuming messages in a background goroutine.
 // Each subscriber gets its own AMQP channel (required by RabbitMQ).
-func (eb *RabbitMQEventBus) Subscribe(routingKey string, handler func(event []byte)) error {
+//
+// An event is acknowledged once its handler succeeds. A failing handler is
+// retried with exponential backoff, after handlerMaxAttempts attempts the
+// event is rejected and moved to the dead-letter exchange. Events still being retried when the
+// bus is closed are requeued so they are not lost.
+func (eb *RabbitMQEventBus) Subscribe(routingKey string, handler Handler) error {
 	eb.mu.Lock()
 	eb.subscriptions = append(eb.subscriptions, subscription{routingKey, handler})
 	eb.mu.Unlock()
@@ -221,7 +546,7 @@ func (eb *RabbitMQEventBus) Subscribe(routingKey string, handler func(event []by
 // startConsumer opens a fresh channel and wires up a consumer for the given
 // routing key. It also watches for channel-level close events and logs them
 // (reconnection is handled at the connection level by reconnectLoop).
-func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte)) error {
+func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler Handler) error {
 	eb.mu.RLock()
 	conn := eb.conn
 	eb.mu.RUnlock()
@@ -236,7 +561,14 @@ func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte
 		return fmt.Errorf("open consumer channel: %w", err)
 	}
 
-	queueName := fmt.Sprintf("io.opencrafts.gossip-monger.%s", routingKey)
+	if err := ch.Qos(consumerPrefetch, 0, false); err != nil {
+		ch.Close()
+		return fmt.Errorf("set consumer prefetch: %w", err)
+	}
+
+	// Queues are named after the exchange as well so the same routing key
+	// can be consumed from several exchanges
+	queueName := fmt.Sprintf("io.opencrafts.verisafe.%s.%s", eb.exchange, routingKey)
 
 	q, err := ch.QueueDeclare(
 		queueName,
@@ -244,7 +576,10 @@ func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte
 		false, // do NOT auto-delete — keeps the queue alive between consumer restarts
 		false, // not exclusive
 		false, // no-wait
-		nil,
+		amqp.Table{
+			// rejected events are moved to the dead-letter exchange
+			"x-dead-letter-exchange": deadLetterExchange,
+		},
 	)
 	if err != nil {
 		ch.Close()
@@ -270,7 +605,9 @@ func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte
 		return fmt.Errorf("consume queue %q: %w", q.Name, err)
 	}
 
+	eb.consumers.Add(1)
 	go func() {
+		defer eb.consumers.Done()
 		chClose := ch.NotifyClose(make(chan *amqp.Error, 1))
 		for {
 			select {
@@ -279,13 +616,7 @@ func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte
 					// msgs channel closed — connection was lost; reconnectLoop will handle it.
 					return
 				}
-				handler(d.Body)
-				if err := d.Ack(false); err != nil {
-					eb.logger.Error("eventbus ack failed",
-						slog.String("routing_key", routingKey),
-						slog.Any("error", err),
-					)
-				}
+				eb.deliver(routingKey, handler, d)
 
 			case amqpErr, ok := <-chClose:
 				if ok {
@@ -306,14 +637,100 @@ func (eb *RabbitMQEventBus) startConsumer(routingKey string, handler func([]byte
 	return nil
 }
 
+// deliver hands a delivery to its handler, retrying failed attempts, and then
+// acknowledges or rejects it
+func (eb *RabbitMQEventBus) deliver(routingKey string, handler Handler, d amqp.Delivery) {
+	delay := handlerRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := eb.runHandler(handler, d.Body)
+		if err == nil {
+			if err := d.Ack(false); err != nil {
+				eb.logger.Error("eventbus ack failed",
+					slog.String("routing_key", routingKey),
+					slog.Any("error", err),
+				)
+			}
+			return
+		}
+
+		if eb.handlerCtx.Err() != nil {
+			// Shutting down — give the event back to the broker.
+			eb.requeue(routingKey, d)
+			return
+		}
+
+		if attempt >= handlerMaxAttempts {
+			eb.logger.Error("eventbus handler failed, dead-lettering event",
+				slog.String("routing_key", routingKey),
+				slog.Int("attempts", attempt),
+				slog.Any("error", err),
+			)
+			metrics.EventsDeadLettered.WithLabelValues(eb.exchange).Inc()
+			if err := d.Nack(false, false); err != nil {
+				eb.logger.Error("eventbus nack failed",
+					slog.String("routing_key", routingKey),
+					slog.Any("error", err),
+				)
+			}
+			return
+		}
+
+		eb.logger.Warn("eventbus handler failed, retrying",
+			slog.String("routing_key", routingKey),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-eb.done:
+			eb.requeue(routingKey, d)
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// requeue gives a delivery back to the broker so another consumer can handle it
+func (eb *RabbitMQEventBus) requeue(routingKey string, d amqp.Delivery) {
+	if err := d.Nack(false, true); err != nil {
+		eb.logger.Error("eventbus requeue failed",
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// runHandler runs a handler, turning a panic into an error so a single bad
+// event cannot take the consumer down
+func (eb *RabbitMQEventBus) runHandler(handler Handler, body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(eb.handlerCtx, body)
+}
+
 // Close gracefully shuts down the event bus, stopping all consumers and
-// closing the AMQP connection.
+// closing the AMQP connection. Handlers are asked to stop through their
+// context and Close waits for the events being handled to be settled.
 func (eb *RabbitMQEventBus) Close() {
 	close(eb.done)
+	eb.cancelHandler()
+	eb.consumers.Wait()
 
 	eb.mu.Lock()
 	defer eb.mu.Unlock()
 
+	if len(eb.pending) > 0 {
+		eb.logger.Warn("eventbus closed with buffered events that were never published",
+			slog.String("exchange", eb.exchange),
+			slog.Int("count", len(eb.pending)),
+		)
+	}
+
 	if eb.publishCh != nil {
 		eb.publishCh.Close()
 	}
@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	// CloudEvents specification version of the envelope
+	cloudEventsSpecVersion = "1.0"
+	// Source of every event Verisafe publishes, the type of an event is its
+	// event type prefixed with the source
+	cloudEventsSource = "io.opencrafts.verisafe"
+)
+
+// CloudEvent is the CloudEvents 1.0 JSON envelope events are wrapped in when
+// EVENTBUS_CLOUDEVENTS is enabled. Data holds the event as it would be
+// published without the envelope
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// cloudEventsEventBus wraps every event published through it in a CloudEvents
+// envelope before handing it to the wrapped bus
+type cloudEventsEventBus struct {
+	EventBus
+}
+
+// Publish wraps the event and publishes the envelope
+func (cb *cloudEventsEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	envelope, err := newCloudEvent(event, routingKey)
+	if err != nil {
+		return err
+	}
+	return cb.EventBus.Publish(ctx, routingKey, envelope)
+}
+
+// newCloudEvent wraps an event in a CloudEvents envelope. The event's ID, type
+// and time are taken from its metadata, its subject is the account it is about
+func newCloudEvent(event any, routingKey string) (CloudEvent, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return CloudEvent{}, fmt.Errorf("marshal event: %w", err)
+	}
+
+	var metadata struct {
+		Meta struct {
+			EventID   string    `json:"event_id"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"meta"`
+	}
+	_ = json.Unmarshal(data, &metadata)
+	id := metadata.Meta.EventID
+	if id == "" {
+		id = newEventID()
+	}
+	published := metadata.Meta.Timestamp
+	if published.IsZero() {
+		published = time.Now()
+	}
+
+	envelope := CloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              id,
+		Source:          cloudEventsSource,
+		Type:            cloudEventsSource + "." + eventTypeOf(json.RawMessage(data), routingKey),
+		Time:            published.UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+	if e, ok := event.(accountEvent); ok {
+		if accountID := e.eventAccountID(); accountID != nil {
+			envelope.Subject = accountID.String()
+		}
+	}
+	return envelope, nil
+}
+
+// unwrapCloudEvent returns the event inside a CloudEvents envelope, or the
+// body itself when it is not wrapped in one, so that consumers read events
+// the same way whether EVENTBUS_CLOUDEVENTS is enabled or not
+func unwrapCloudEvent(body []byte) []byte {
+	var envelope struct {
+		SpecVersion string          `json:"specversion"`
+		Data        json.RawMessage `json:"data"`
+	}
+	if json.Unmarshal(body, &envelope) != nil || envelope.SpecVersion == "" || len(envelope.Data) == 0 {
+		return body
+	}
+	return envelope.Data
+}
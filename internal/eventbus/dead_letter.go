@@ -0,0 +1,310 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrDeadLettersUnsupported is returned when the configured backend has
+	// no dead-letter queue
+	ErrDeadLettersUnsupported = errors.New("dead-letter queue is only available on the rabbitmq backend")
+	// ErrDeadLetterNotFound is returned when no dead-lettered event has the
+	// requested ID
+	ErrDeadLetterNotFound = errors.New("dead-lettered event not found")
+)
+
+// DeadLetter is an event kept in the dead-letter queue. Events published
+// without a broker confirmation keep the exchange and routing key they were
+// headed for, events rejected by a consumer keep the queue that rejected
+// them. ID is the event's event_id, or a hash of its body for events without
+// one
+type DeadLetter struct {
+	ID                 string          `json:"id"`
+	OriginalExchange   string          `json:"original_exchange"`
+	OriginalRoutingKey string          `json:"original_routing_key"`
+	OriginalQueue      string          `json:"original_queue,omitempty"`
+	Reason             string          `json:"reason"`
+	DeadLetteredAt     *time.Time      `json:"dead_lettered_at,omitempty"`
+	Redelivered        bool            `json:"redelivered"`
+	Body               json.RawMessage `json:"body"`
+}
+
+// DeadLetterQueue lets operators inspect, requeue and discard the events in
+// Verisafe's dead-letter queue without access to the broker's management
+// tools.
+//
+// Events are read without being acknowledged and go back to the queue when
+// the channel reading them is closed, so inspecting the queue never loses
+// events. Every operation uses a connection of its own.
+type DeadLetterQueue struct {
+	url     string
+	enabled bool
+	signer  *EventSigner
+	logger  *slog.Logger
+}
+
+// NewDeadLetterQueue creates a new DeadLetterQueue. Requeued events are
+// signed with the signer, which may be nil
+func NewDeadLetterQueue(cfg *config.Config, signer *EventSigner, logger *slog.Logger) *DeadLetterQueue {
+	backend := cfg.EventBusConfig.Backend
+	return &DeadLetterQueue{
+		url:     rabbitMQURL(cfg),
+		enabled: backend == "" || backend == RabbitMQBackend,
+		signer:  signer,
+		logger:  logger,
+	}
+}
+
+// Name returns the name of the dead-letter queue
+func (q *DeadLetterQueue) Name() string {
+	return deadLetterQueue
+}
+
+// List returns up to limit events from the head of the queue together with
+// the number of events the queue holds
+func (q *DeadLetterQueue) List(ctx context.Context, limit int) ([]DeadLetter, int, error) {
+	var (
+		deadLetters []DeadLetter
+		depth       int
+	)
+	err := q.withChannel(func(ch *amqp.Channel) error {
+		queue, err := ch.QueueDeclarePassive(deadLetterQueue, true, false, false, false, nil)
+		if err != nil {
+			return fmt.Errorf("inspect dead-letter queue: %w", err)
+		}
+		depth = queue.Messages
+
+		for len(deadLetters) < limit {
+			msg, ok, err := ch.Get(deadLetterQueue, false)
+			if err != nil {
+				return fmt.Errorf("read dead-letter queue: %w", err)
+			}
+			if !ok {
+				break
+			}
+			deadLetters = append(deadLetters, newDeadLetter(msg))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return deadLetters, depth, nil
+}
+
+// Get returns the event with the given ID
+func (q *DeadLetterQueue) Get(ctx context.Context, id string) (DeadLetter, error) {
+	var deadLetter DeadLetter
+	err := q.withChannel(func(ch *amqp.Channel) error {
+		msg, err := findDeadLetter(ch, id)
+		if err != nil {
+			return err
+		}
+		deadLetter = newDeadLetter(msg)
+		return nil
+	})
+	return deadLetter, err
+}
+
+// Requeue publishes the event with the given ID to where it was headed and
+// removes it from the queue once the broker confirmed the publish. Events
+// rejected by a consumer are published straight to the queue that rejected
+// them
+func (q *DeadLetterQueue) Requeue(ctx context.Context, id string) (DeadLetter, error) {
+	var deadLetter DeadLetter
+	err := q.withChannel(func(ch *amqp.Channel) error {
+		msg, err := findDeadLetter(ch, id)
+		if err != nil {
+			return err
+		}
+		deadLetter = newDeadLetter(msg)
+
+		exchange, routingKey := deadLetter.OriginalExchange, deadLetter.OriginalRoutingKey
+		if deadLetter.OriginalQueue != "" {
+			exchange, routingKey = "", deadLetter.OriginalQueue
+		}
+
+		var headers amqp.Table
+		if q.signer != nil {
+			headers = amqp.Table{}
+			for key, value := range q.signer.Sign(msg.Body, time.Now().Unix()) {
+				headers[key] = value
+			}
+		}
+
+		if err := ch.Confirm(false); err != nil {
+			return fmt.Errorf("enable publisher confirms: %w", err)
+		}
+		publishCtx, cancel := context.WithTimeout(ctx, publishConfirmTimeout)
+		defer cancel()
+
+		confirmation, err := ch.PublishWithDeferredConfirmWithContext(publishCtx, exchange, routingKey, false, false, amqp.Publishing{
+			ContentType:  "application/json",
+			Body:         msg.Body,
+			DeliveryMode: amqp.Persistent,
+			Headers:      headers,
+		})
+		if err != nil {
+			return fmt.Errorf("requeue dead-lettered event: %w", err)
+		}
+		acked, err := confirmation.WaitContext(publishCtx)
+		if err != nil {
+			return fmt.Errorf("requeue dead-lettered event: %w", err)
+		}
+		if !acked {
+			return fmt.Errorf("requeue dead-lettered event: %w", errPublishNacked)
+		}
+
+		if err := msg.Ack(false); err != nil {
+			return fmt.Errorf("remove requeued event from the dead-letter queue: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	q.logger.Info("Requeued dead-lettered event",
+		slog.String("id", deadLetter.ID),
+		slog.String("exchange", deadLetter.OriginalExchange),
+		slog.String("routing_key", deadLetter.OriginalRoutingKey),
+		slog.String("queue", deadLetter.OriginalQueue),
+	)
+	return deadLetter, nil
+}
+
+// Discard removes the event with the given ID from the queue
+func (q *DeadLetterQueue) Discard(ctx context.Context, id string) (DeadLetter, error) {
+	var deadLetter DeadLetter
+	err := q.withChannel(func(ch *amqp.Channel) error {
+		msg, err := findDeadLetter(ch, id)
+		if err != nil {
+			return err
+		}
+		deadLetter = newDeadLetter(msg)
+
+		if err := msg.Ack(false); err != nil {
+			return fmt.Errorf("discard dead-lettered event: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return DeadLetter{}, err
+	}
+
+	q.logger.Warn("Discarded dead-lettered event",
+		slog.String("id", deadLetter.ID),
+		slog.String("exchange", deadLetter.OriginalExchange),
+		slog.String("routing_key", deadLetter.OriginalRoutingKey),
+		slog.String("reason", deadLetter.Reason),
+	)
+	return deadLetter, nil
+}
+
+// withChannel runs fn on a channel of a new connection to the broker. Events
+// read on the channel and not acknowledged go back to the queue once fn
+// returns
+func (q *DeadLetterQueue) withChannel(fn func(ch *amqp.Channel) error) error {
+	if !q.enabled {
+		return ErrDeadLettersUnsupported
+	}
+
+	conn, err := amqp.Dial(q.url)
+	if err != nil {
+		return fmt.Errorf("connect to broker: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("open channel: %w", err)
+	}
+	defer ch.Close()
+
+	return fn(ch)
+}
+
+// findDeadLetter reads the queue until it finds the event with the given ID.
+// Events read on the same channel are not delivered again, so every event is
+// looked at once at most
+func findDeadLetter(ch *amqp.Channel, id string) (amqp.Delivery, error) {
+	for {
+		msg, ok, err := ch.Get(deadLetterQueue, false)
+		if err != nil {
+			return amqp.Delivery{}, fmt.Errorf("read dead-letter queue: %w", err)
+		}
+		if !ok {
+			return amqp.Delivery{}, ErrDeadLetterNotFound
+		}
+		if deadLetterID(msg) == id {
+			return msg, nil
+		}
+	}
+}
+
+// newDeadLetter describes a message read from the dead-letter queue
+func newDeadLetter(msg amqp.Delivery) DeadLetter {
+	deadLetter := DeadLetter{
+		ID:                 deadLetterID(msg),
+		OriginalExchange:   headerString(msg.Headers, "x-original-exchange"),
+		OriginalRoutingKey: headerString(msg.Headers, "x-original-routing-key"),
+		Reason:             headerString(msg.Headers, "x-failure-reason"),
+		Redelivered:        msg.Redelivered,
+		Body:               msg.Body,
+	}
+	if deadLetter.OriginalRoutingKey == "" {
+		deadLetter.OriginalRoutingKey = msg.RoutingKey
+	}
+	if !msg.Timestamp.IsZero() {
+		timestamp := msg.Timestamp
+		deadLetter.DeadLetteredAt = &timestamp
+	}
+
+	// Events rejected by a consumer carry the x-death header RabbitMQ adds,
+	// the most recent death comes first
+	if deaths, ok := msg.Headers["x-death"].([]any); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			deadLetter.OriginalExchange = headerString(death, "exchange")
+			deadLetter.OriginalQueue = headerString(death, "queue")
+			deadLetter.Reason = headerString(death, "reason")
+			if keys, ok := death["routing-keys"].([]any); ok && len(keys) > 0 {
+				if key, ok := keys[0].(string); ok {
+					deadLetter.OriginalRoutingKey = key
+				}
+			}
+			if timestamp, ok := death["time"].(time.Time); ok {
+				deadLetter.DeadLetteredAt = &timestamp
+			}
+		}
+	}
+	return deadLetter
+}
+
+// deadLetterID identifies a dead-lettered event by its event_id, its message
+// ID or a hash of its body, in that order
+func deadLetterID(msg amqp.Delivery) string {
+	if id := EventID(msg.Body); id != "" {
+		return id
+	}
+	if msg.MessageId != "" {
+		return msg.MessageId
+	}
+	sum := sha256.Sum256(msg.Body)
+	return hex.EncodeToString(sum[:16])
+}
+
+// headerString returns a header as a string, or "" when it is missing
+func headerString(headers amqp.Table, key string) string {
+	value, _ := headers[key].(string)
+	return value
+}
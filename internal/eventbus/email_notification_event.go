@@ -0,0 +1,16 @@
+package eventbus
+
+// EmailNotificationEvent asks gossip-monger to deliver an email
+type EmailNotificationEvent struct {
+	Email EmailPayload              `json:"email"`
+	Meta  NotificationEventMetadata `json:"meta"`
+}
+
+// EmailPayload contains the email details
+type EmailPayload struct {
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	// Optional link the email points the recipient to
+	ActionURL string `json:"action_url,omitempty"`
+}
@@ -0,0 +1,34 @@
+package eventbus
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// newEventID returns a unique ID for an event. The ID is part of the event's
+// metadata and is kept when the event is replayed
+func newEventID() string {
+	return uuid.New().String()
+}
+
+// EventID returns the ID of a serialised event, which consumers use to
+// recognise events they already handled. The ID is read from the event's
+// metadata or from its CloudEvents envelope. Events published before events
+// carried an ID have none
+func EventID(body []byte) string {
+	var event struct {
+		SpecVersion string `json:"specversion"`
+		ID          string `json:"id"`
+		Meta        struct {
+			EventID string `json:"event_id"`
+		} `json:"meta"`
+	}
+	if json.Unmarshal(body, &event) != nil {
+		return ""
+	}
+	if event.SpecVersion != "" {
+		return event.ID
+	}
+	return event.Meta.EventID
+}
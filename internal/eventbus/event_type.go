@@ -0,0 +1,42 @@
+package eventbus
+
+import "encoding/json"
+
+// typedEvent is implemented by the events published by the typed event buses
+type typedEvent interface {
+	eventType() string
+}
+
+func (e UserEvent) eventType() string                   { return e.Metadata.EventType }
+func (e AuthzEvent) eventType() string                  { return e.Metadata.EventType }
+func (e RoleEvent) eventType() string                   { return e.Metadata.EventType }
+func (e SeasonClosedEvent) eventType() string           { return e.Metadata.EventType }
+func (e RankTopEnteredEvent) eventType() string         { return e.Metadata.EventType }
+func (e RankOvertakenEvent) eventType() string          { return e.Metadata.EventType }
+func (e InstitutionEvent) eventType() string            { return e.Metadata.EventType }
+func (e InstitutionJoinRequestEvent) eventType() string { return e.Metadata.EventType }
+func (e NotificationEvent) eventType() string           { return e.Meta.EventType }
+func (e EmailNotificationEvent) eventType() string      { return e.Meta.EventType }
+func (e SecurityEvent) eventType() string               { return e.Metadata.EventType }
+
+// eventTypeOf returns the type of a published event. Serialised events carry
+// their type in their metadata. The routing key is used for events without a
+// type
+func eventTypeOf(event any, routingKey string) string {
+	switch e := event.(type) {
+	case typedEvent:
+		if eventType := e.eventType(); eventType != "" {
+			return eventType
+		}
+	case json.RawMessage:
+		var envelope struct {
+			Meta struct {
+				EventType string `json:"event_type"`
+			} `json:"meta"`
+		}
+		if json.Unmarshal(e, &envelope) == nil && envelope.Meta.EventType != "" {
+			return envelope.Meta.EventType
+		}
+	}
+	return routingKey
+}
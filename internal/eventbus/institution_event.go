@@ -7,6 +7,7 @@ import (
 )
 
 type InstitutionEventMetaData struct {
+	EventID         string    `json:"event_id"`
 	EventType       string    `json:"event_type"`
 	Timestamp       time.Time `json:"timestamp"`
 	SourceServiceID string    `json:"source_service_id"`
@@ -17,3 +18,8 @@ type InstitutionEvent struct {
 	Institution repository.Institution   `json:"institution"`
 	Metadata    InstitutionEventMetaData `json:"meta"`
 }
+
+type InstitutionJoinRequestEvent struct {
+	JoinRequest repository.InstitutionJoinRequest `json:"join_request"`
+	Metadata    InstitutionEventMetaData          `json:"meta"`
+}
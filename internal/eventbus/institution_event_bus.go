@@ -18,10 +18,15 @@
 // The UserEventBus publishes three primary user lifecycle events:
 // - institution.created: Published when an institution is created
 // - institution.updated: Published when an institution is modified
-// - institution.deleted: Published when an institution is deleted
+// - institution.deleted: Published when an institution is deleted, institutions are archived
+//   rather than removed and every account is unlinked from them
+// - institution.restored: Published when an archived institution is restored
+// - institution.join_request.approved: Published when a request to join an institution is approved
+// - institution.join_request.rejected: Published when a request to join an institution is rejected
 //
 // Each event contains the complete institution information and metadata including timestamp,
-// source service identifier, and a request ID for distributed tracing and correlation.
+// source service identifier, and a request ID for distributed tracing and correlation. Every
+// event is also forwarded to webhook endpoints subscribed to its event type.
 //
 // MESSAGE DELIVERY:
 // Since its assummed at the moment that there is only one consumer to this kind of information,
@@ -41,34 +46,30 @@ import (
 )
 
 type InstitutionEventBus struct {
-	bus    EventBus
-	logger *slog.Logger
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+	webhooks    WebhookEnqueuer
 }
 
 // NewInstitutionEventBus creates a new UserEventBus instance.
 func NewInstitutionEventBus(cfg *config.Config, logger *slog.Logger) (*InstitutionEventBus, error) {
-	rabbitMQConnString := fmt.Sprintf("amqp://%s:%s@%s:%d/",
-		cfg.RabbitMQConfig.RabbitMQUser,
-		cfg.RabbitMQConfig.RabbitMQPass,
-		cfg.RabbitMQConfig.RabbitMQAddress,
-		cfg.RabbitMQConfig.RabbitMQPort,
-	)
-
-	rabbitMQBus, err := NewRabbitMQEventBus(
-		rabbitMQConnString,
-		"professor.exchange",
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.InstitutionExchange, defaultInstitutionExchange),
 		DirectExchangeType,
 		logger,
 	)
 
 	if err != nil {
-		logger.Error("Failed to initialize RabbitMQ event bus", "error", err)
-		return nil, fmt.Errorf("failed to initialize RabbitMQ event bus: %w", err)
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
 	}
 
 	return &InstitutionEventBus{
-		bus:    rabbitMQBus,
-		logger: logger,
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
 	}, nil
 }
 
@@ -77,6 +78,7 @@ func (b *InstitutionEventBus) PublishInstitutionCreated(ctx context.Context, ins
 	event := InstitutionEvent{
 		Institution: institution,
 		Metadata: InstitutionEventMetaData{
+			EventID:         newEventID(),
 			EventType:       "institution.created",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -84,13 +86,14 @@ func (b *InstitutionEventBus) PublishInstitutionCreated(ctx context.Context, ins
 		},
 	}
 
-	routingKey := "institution.events"
+	routingKey := b.routingKeys.resolve("institution.created", "institution.events")
 	b.logger.Info("Publishing institution created event",
 		slog.String("routing_key", routingKey),
 		slog.Any("institution_id", institution.InstitutionID),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event.Metadata, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
@@ -99,6 +102,7 @@ func (b *InstitutionEventBus) PublishInstitutionUpdated(ctx context.Context, ins
 	event := InstitutionEvent{
 		Institution: institution,
 		Metadata: InstitutionEventMetaData{
+			EventID:         newEventID(),
 			EventType:       "institution.updated",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -106,13 +110,14 @@ func (b *InstitutionEventBus) PublishInstitutionUpdated(ctx context.Context, ins
 		},
 	}
 
-	routingKey := "institution.events"
+	routingKey := b.routingKeys.resolve("institution.updated", "institution.events")
 	b.logger.Info("Publishing institution updated event",
 		slog.String("routing_key", routingKey),
 		slog.Any("institution_id", institution.InstitutionID),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event.Metadata, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
@@ -121,6 +126,7 @@ func (b *InstitutionEventBus) PublishInstitutionDeleted(ctx context.Context, ins
 	event := InstitutionEvent{
 		Institution: institution,
 		Metadata: InstitutionEventMetaData{
+			EventID:         newEventID(),
 			EventType:       "institution.deleted",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -128,16 +134,99 @@ func (b *InstitutionEventBus) PublishInstitutionDeleted(ctx context.Context, ins
 		},
 	}
 
-	routingKey := "institution.events"
+	routingKey := b.routingKeys.resolve("institution.deleted", "institution.events")
 	b.logger.Info("Publishing institution deleted event",
 		slog.String("routing_key", routingKey),
 		slog.Any("institution_id", institution.InstitutionID),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event.Metadata, event)
+	return b.bus.Publish(ctx, routingKey, event)
+}
+
+// PublishInstitutionRestored publishes an institution restored event to the event bus
+func (b *InstitutionEventBus) PublishInstitutionRestored(ctx context.Context, institution repository.Institution, requestID string) error {
+	event := InstitutionEvent{
+		Institution: institution,
+		Metadata: InstitutionEventMetaData{
+			EventID:         newEventID(),
+			EventType:       "institution.restored",
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := b.routingKeys.resolve("institution.restored", "institution.events")
+	b.logger.Info("Publishing institution restored event",
+		slog.String("routing_key", routingKey),
+		slog.Any("institution_id", institution.InstitutionID),
+		slog.String("request_id", requestID),
+	)
+
+	b.forwardToWebhooks(ctx, event.Metadata, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
+// PublishJoinRequestDecided publishes the outcome of a request to join an
+// institution to the event bus
+func (b *InstitutionEventBus) PublishJoinRequestDecided(ctx context.Context, request repository.InstitutionJoinRequest, requestID string) error {
+	event := InstitutionJoinRequestEvent{
+		JoinRequest: request,
+		Metadata: InstitutionEventMetaData{
+			EventID:         newEventID(),
+			EventType:       "institution.join_request." + string(request.Status),
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := b.routingKeys.resolve(event.Metadata.EventType, "institution.events")
+	b.logger.Info("Publishing institution join request decided event",
+		slog.String("routing_key", routingKey),
+		slog.Any("institution_id", request.InstitutionID),
+		slog.Any("join_request_id", request.ID),
+		slog.String("request_id", requestID),
+	)
+
+	b.forwardToWebhooks(ctx, event.Metadata, event)
+	return b.bus.Publish(ctx, routingKey, event)
+}
+
+// SetWebhookEnqueuer makes the bus forward every institution event it
+// publishes to the registered webhook endpoints
+func (b *InstitutionEventBus) SetWebhookEnqueuer(webhooks WebhookEnqueuer) {
+	b.webhooks = webhooks
+}
+
+// forwardToWebhooks hands the event over to the webhook subsystem.
+// Failures are logged and never prevent the event from reaching the broker
+func (b *InstitutionEventBus) forwardToWebhooks(ctx context.Context, meta InstitutionEventMetaData, event any) {
+	if b.webhooks == nil {
+		return
+	}
+	if err := b.webhooks.Enqueue(ctx, meta.EventType, event); err != nil {
+		b.logger.Error("Failed to enqueue institution event webhooks",
+			slog.String("event_type", meta.EventType),
+			slog.String("request_id", meta.RequestID),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *InstitutionEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *InstitutionEventBus) Health() Health {
+	return b.bus.Health()
+}
+
 // Close cancels the internal context, signalling all active handlers to stop.
 func (b *InstitutionEventBus) Close() {
 	b.bus.Close()
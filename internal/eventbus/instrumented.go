@@ -0,0 +1,41 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+)
+
+// instrumentedEventBus records how many events are published through the
+// wrapped bus, how long publishing them takes and how many fail
+type instrumentedEventBus struct {
+	EventBus
+	exchange string
+}
+
+// newInstrumentedEventBus wraps bus so that its publishes are measured and
+// exports the state of its connection
+func newInstrumentedEventBus(bus EventBus) *instrumentedEventBus {
+	exchange := bus.Health().Exchange
+	metrics.WatchEventBus(exchange,
+		func() bool { return bus.Health().Connected },
+		func() int { return bus.Health().BufferedPublishes },
+	)
+	return &instrumentedEventBus{EventBus: bus, exchange: exchange}
+}
+
+// Publish publishes the event through the wrapped bus and records the outcome
+func (ib *instrumentedEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	start := time.Now()
+	err := ib.EventBus.Publish(ctx, routingKey, event)
+
+	eventType := eventTypeOf(event, routingKey)
+	metrics.EventPublishDuration.WithLabelValues(ib.exchange, eventType).Observe(time.Since(start).Seconds())
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	metrics.EventsPublished.WithLabelValues(ib.exchange, eventType, outcome).Inc()
+	return err
+}
@@ -0,0 +1,131 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// EventJournal keeps the events published to the event bus so that they can
+// be published again for consumers that lost them
+type EventJournal interface {
+	Record(ctx context.Context, event JournaledEvent) error
+	// Register makes the journaled events of an exchange replayable through
+	// the given bus
+	Register(exchange string, bus Replayer)
+}
+
+// JournaledEvent is a published event as kept by an EventJournal
+type JournaledEvent struct {
+	Exchange   string
+	RoutingKey string
+	EventType  string
+	AccountID  *uuid.UUID
+	Payload    json.RawMessage
+}
+
+// Replayer publishes journaled events again
+type Replayer interface {
+	Replay(ctx context.Context, routingKey string, payload json.RawMessage) error
+}
+
+// accountEvent is implemented by events about a single account so that they
+// can be replayed for that account
+type accountEvent interface {
+	eventAccountID() *uuid.UUID
+}
+
+// journalingEventBus records every event published through it before
+// handing it over to the wrapped bus
+type journalingEventBus struct {
+	EventBus
+	exchange string
+	journal  EventJournal
+	logger   *slog.Logger
+}
+
+// newJournalingEventBus wraps bus so that the events published through it are
+// recorded in the journal, and registers it as the bus replaying them
+func newJournalingEventBus(bus EventBus, journal EventJournal, logger *slog.Logger) *journalingEventBus {
+	jb := &journalingEventBus{
+		EventBus: bus,
+		exchange: bus.Health().Exchange,
+		journal:  journal,
+		logger:   logger,
+	}
+	journal.Register(jb.exchange, jb)
+	return jb
+}
+
+// Publish records the event and publishes it. Failing to record an event is
+// logged and never prevents it from reaching the broker
+func (jb *journalingEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	eventType := eventTypeOf(event, routingKey)
+
+	var accountID *uuid.UUID
+	if e, ok := event.(accountEvent); ok {
+		accountID = e.eventAccountID()
+	}
+
+	if err := jb.journal.Record(ctx, JournaledEvent{
+		Exchange:   jb.exchange,
+		RoutingKey: routingKey,
+		EventType:  eventType,
+		AccountID:  accountID,
+		Payload:    payload,
+	}); err != nil {
+		jb.logger.Error("Failed to journal event",
+			slog.String("exchange", jb.exchange),
+			slog.String("event_type", eventType),
+			slog.Any("error", err),
+		)
+	}
+
+	return jb.EventBus.Publish(ctx, routingKey, json.RawMessage(payload))
+}
+
+// Replay publishes a journaled event again without recording it a second time
+func (jb *journalingEventBus) Replay(ctx context.Context, routingKey string, payload json.RawMessage) error {
+	return jb.EventBus.Publish(ctx, routingKey, payload)
+}
+
+func (e UserEvent) eventAccountID() *uuid.UUID { return &e.User.ID }
+
+func (e RoleEvent) eventAccountID() *uuid.UUID { return parseAccountID(e.Assignment.AccountID) }
+
+func (e AuthzEvent) eventAccountID() *uuid.UUID {
+	if e.Change.TargetType != "account" {
+		return nil
+	}
+	return parseAccountID(e.Change.TargetID)
+}
+
+func (e RankTopEnteredEvent) eventAccountID() *uuid.UUID { return &e.Position.AccountID }
+
+func (e RankOvertakenEvent) eventAccountID() *uuid.UUID { return &e.Position.AccountID }
+
+func (e InstitutionJoinRequestEvent) eventAccountID() *uuid.UUID { return &e.JoinRequest.AccountID }
+
+func (e NotificationEvent) eventAccountID() *uuid.UUID {
+	return parseAccountID(e.Notification.TargetUserID)
+}
+
+func (e SecurityEvent) eventAccountID() *uuid.UUID { return parseAccountID(e.Activity.AccountID) }
+
+// parseAccountID parses an account id kept as a string, returning nil when
+// it is not a valid id
+func parseAccountID(id string) *uuid.UUID {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
@@ -0,0 +1,360 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// Topic every event that could not be published or handled ends up on
+	deadLetterTopic = "verisafe.dead-letter"
+
+	// How long to wait for a broker when the bus is created
+	kafkaDialTimeout = 10 * time.Second
+
+	// How long a publish waits for other events to batch with. Publishes
+	// are synchronous so this adds directly to their latency.
+	kafkaBatchTimeout = 10 * time.Millisecond
+)
+
+// KafkaEventBus is an implementation of EventBus that uses Kafka.
+//
+// Exchanges and routing keys are mapped onto topics: an event goes to the
+// topic mapped to its routing key, else to the topic mapped to the bus's
+// exchange, else to a topic named after the exchange. The routing key is used
+// as the message key and recorded in the event-type header so consumers of a
+// shared topic can tell events apart.
+type KafkaEventBus struct {
+	brokers      []string
+	exchange     string
+	exchangeType ExchangeType
+	topics       map[string]string
+	logger       *slog.Logger
+
+	writer *kafka.Writer
+
+	mu           sync.Mutex
+	readers      []*kafka.Reader
+	publishErr   bool // whether the last publish failed
+	failingSince time.Time
+	deadLettered int64
+
+	// Handlers run with handlerCtx, which is cancelled by Close. Close waits
+	// for consumers to finish the event they are handling.
+	handlerCtx    context.Context
+	cancelHandler context.CancelFunc
+	consumers     sync.WaitGroup
+}
+
+// NewKafkaEventBus creates and returns a new KafkaEventBus instance. It fails
+// when none of the brokers can be reached.
+func NewKafkaEventBus(brokers []string, exchange string, exchangeType ExchangeType, topics map[string]string, logger *slog.Logger) (*KafkaEventBus, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no kafka brokers configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaDialTimeout)
+	defer cancel()
+
+	var dialErr error
+	for _, broker := range brokers {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			dialErr = err
+			continue
+		}
+		conn.Close()
+		dialErr = nil
+		break
+	}
+	if dialErr != nil {
+		return nil, fmt.Errorf("dial kafka: %w", dialErr)
+	}
+
+	handlerCtx, cancelHandler := context.WithCancel(context.Background())
+	eb := &KafkaEventBus{
+		brokers:      brokers,
+		exchange:     exchange,
+		exchangeType: exchangeType,
+		topics:       topics,
+		logger:       logger,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			MaxAttempts:            publishMaxAttempts,
+			WriteBackoffMin:        publishRetryDelay,
+			BatchTimeout:           kafkaBatchTimeout,
+			AllowAutoTopicCreation: true,
+		},
+		handlerCtx:    handlerCtx,
+		cancelHandler: cancelHandler,
+	}
+
+	logger.Info("eventbus connected",
+		slog.String("backend", "kafka"),
+		slog.String("exchange", exchange),
+	)
+	return eb, nil
+}
+
+// topic returns the topic events with the given routing key are published to
+func (eb *KafkaEventBus) topic(routingKey string) string {
+	if topic, ok := eb.topics[routingKey]; ok {
+		return topic
+	}
+	if topic, ok := eb.topics[eb.exchange]; ok {
+		return topic
+	}
+	return eb.exchange
+}
+
+// Publish serialises the event to JSON and publishes it once every in-sync
+// replica has it. The writer retries failed writes, once every attempt failed
+// the event is published to the dead-letter topic and an error is returned.
+func (eb *KafkaEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	body, signature, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	headers := []kafka.Header{
+		{Key: "event-type", Value: []byte(routingKey)},
+		{Key: "content-type", Value: []byte("application/json")},
+	}
+	for key, value := range signature {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	err = eb.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   eb.topic(routingKey),
+		Key:     []byte(routingKey),
+		Value:   body,
+		Headers: headers,
+	})
+
+	eb.mu.Lock()
+	if err == nil {
+		eb.publishErr = false
+		eb.failingSince = time.Time{}
+	} else if !eb.publishErr {
+		eb.publishErr = true
+		eb.failingSince = time.Now()
+	}
+	eb.mu.Unlock()
+
+	if err != nil {
+		eb.deadLetter(routingKey, body, err)
+		return fmt.Errorf("publish %q: %w", routingKey, err)
+	}
+	return nil
+}
+
+// deadLetter publishes an event that could not be published or handled to
+// the dead-letter topic. The original topic, routing key and failure are kept
+// in the message headers
+func (eb *KafkaEventBus) deadLetter(routingKey string, body []byte, cause error) {
+	eb.mu.Lock()
+	eb.deadLettered++
+	eb.mu.Unlock()
+
+	metrics.EventsDeadLettered.WithLabelValues(eb.exchange).Inc()
+
+	// Logged as an error with alert set so log based alerting picks it up
+	eb.logger.Error("eventbus dead-lettered event",
+		slog.Bool("alert", true),
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+		slog.Any("error", cause),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishConfirmTimeout)
+	defer cancel()
+
+	err := eb.writer.WriteMessages(ctx, kafka.Message{
+		Topic: deadLetterTopic,
+		Key:   []byte(routingKey),
+		Value: body,
+		Headers: []kafka.Header{
+			{Key: "x-original-topic", Value: []byte(eb.topic(routingKey))},
+			{Key: "x-original-routing-key", Value: []byte(routingKey)},
+			{Key: "x-failure-reason", Value: []byte(cause.Error())},
+		},
+	})
+	if err != nil {
+		eb.logger.Error("eventbus failed to dead-letter event, the event is lost",
+			slog.Bool("alert", true),
+			slog.String("exchange", eb.exchange),
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// Health reports whether the last publish succeeded. Publishes are not
+// buffered so the buffered and reconnect counters are always zero
+func (eb *KafkaEventBus) Health() Health {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	h := Health{
+		Exchange:     eb.exchange,
+		Connected:    !eb.publishErr,
+		DeadLettered: eb.deadLettered,
+	}
+	if !eb.failingSince.IsZero() {
+		since := eb.failingSince
+		h.DisconnectedSince = &since
+	}
+	return h
+}
+
+// Subscribe joins the consumer group io.opencrafts.verisafe.<exchange>.<routing key>
+// on the topic the routing key maps to and begins consuming messages in a
+// background goroutine. Messages are filtered the way the bus's exchange type
+// routes them in RabbitMQ.
+//
+// An offset is committed once its handler succeeds. A failing handler is
+// retried with exponential backoff, after handlerMaxAttempts attempts the
+// event is published to the dead-letter topic and committed. Events still
+// being retried when the bus is closed are not committed so they are
+// consumed again.
+func (eb *KafkaEventBus) Subscribe(routingKey string, handler Handler) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: eb.brokers,
+		GroupID: fmt.Sprintf("io.opencrafts.verisafe.%s.%s", eb.exchange, routingKey),
+		Topic:   eb.topic(routingKey),
+	})
+
+	eb.mu.Lock()
+	eb.readers = append(eb.readers, reader)
+	eb.mu.Unlock()
+
+	eb.consumers.Add(1)
+	go func() {
+		defer eb.consumers.Done()
+
+		for {
+			m, err := reader.FetchMessage(eb.handlerCtx)
+			if err != nil {
+				if eb.handlerCtx.Err() != nil {
+					return
+				}
+				eb.logger.Error("eventbus fetch failed",
+					slog.String("routing_key", routingKey),
+					slog.Any("error", err),
+				)
+				select {
+				case <-eb.handlerCtx.Done():
+					return
+				case <-time.After(reconnectMinDelay):
+				}
+				continue
+			}
+
+			if !routes(eb.exchangeType, routingKey, string(m.Key)) {
+				eb.commit(reader, routingKey, m)
+				continue
+			}
+			if !eb.deliver(routingKey, handler, m) {
+				return
+			}
+			eb.commit(reader, routingKey, m)
+		}
+	}()
+
+	eb.logger.Info("eventbus subscribed",
+		slog.String("backend", "kafka"),
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+	)
+	return nil
+}
+
+// deliver hands a message to its handler, retrying failed attempts and
+// dead-lettering the message once every attempt failed. It returns false when
+// the bus was closed before the message was settled
+func (eb *KafkaEventBus) deliver(routingKey string, handler Handler, m kafka.Message) bool {
+	delay := handlerRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := eb.runHandler(handler, m.Value)
+		if err == nil {
+			return true
+		}
+
+		if eb.handlerCtx.Err() != nil {
+			return false
+		}
+
+		if attempt >= handlerMaxAttempts {
+			eb.logger.Error("eventbus handler failed, dead-lettering event",
+				slog.String("routing_key", routingKey),
+				slog.Int("attempts", attempt),
+				slog.Any("error", err),
+			)
+			eb.deadLetter(routingKey, m.Value, err)
+			return true
+		}
+
+		eb.logger.Warn("eventbus handler failed, retrying",
+			slog.String("routing_key", routingKey),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-eb.handlerCtx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// commit commits the offset of a settled message
+func (eb *KafkaEventBus) commit(reader *kafka.Reader, routingKey string, m kafka.Message) {
+	if err := reader.CommitMessages(context.Background(), m); err != nil {
+		eb.logger.Error("eventbus commit failed",
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// runHandler runs a handler, turning a panic into an error so a single bad
+// event cannot take the consumer down
+func (eb *KafkaEventBus) runHandler(handler Handler, body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(eb.handlerCtx, body)
+}
+
+// Close stops all consumers, waiting for the events being handled, and
+// flushes the writer.
+func (eb *KafkaEventBus) Close() {
+	eb.cancelHandler()
+	eb.consumers.Wait()
+
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	for _, reader := range eb.readers {
+		if err := reader.Close(); err != nil {
+			eb.logger.Error("eventbus failed to close reader", slog.Any("error", err))
+		}
+	}
+	if err := eb.writer.Close(); err != nil {
+		eb.logger.Error("eventbus failed to close writer", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,65 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// LeaderboardEventMetadata contains crucial information about the event itself.
+type LeaderboardEventMetadata struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Timestamp       time.Time `json:"timestamp"`
+	SourceServiceID string    `json:"source_service_id"`
+	RequestID       string    `json:"request_id"`
+}
+
+// SeasonRecap summarizes a closed season. Standings only holds the top of
+// the final standings, the complete standings are available through the API.
+type SeasonRecap struct {
+	Season       repository.LeaderboardSeason                   `json:"season"`
+	Participants int64                                          `json:"participants"`
+	Standings    []repository.ListLeaderboardSeasonStandingsRow `json:"standings"`
+}
+
+// SeasonClosedEvent defines the payload for season.closed events.
+type SeasonClosedEvent struct {
+	Recap    SeasonRecap              `json:"recap"`
+	Metadata LeaderboardEventMetadata `json:"meta"`
+}
+
+// Leaderboard scopes rank change events are reported on
+const (
+	// Ranks among every ranked account
+	LeaderboardScopeGlobal = "global"
+	// Rivals are limited to the accounts the overtaken account follows
+	LeaderboardScopeFriends = "friends"
+)
+
+// RankPosition is the standing of an account before and after a rank change.
+// OldRank is nil when the account was not ranked before.
+type RankPosition struct {
+	AccountID  uuid.UUID `json:"account_id"`
+	OldRank    *int64    `json:"old_rank"`
+	NewRank    int64     `json:"new_rank"`
+	VibePoints int64     `json:"vibe_points"`
+}
+
+// RankTopEnteredEvent defines the payload for rank.top_entered events.
+type RankTopEnteredEvent struct {
+	Scope    string                   `json:"scope"`
+	Top      int64                    `json:"top"`
+	Position RankPosition             `json:"position"`
+	Metadata LeaderboardEventMetadata `json:"meta"`
+}
+
+// RankOvertakenEvent defines the payload for rank.overtaken events. Position
+// is the account that was overtaken and Rival the account that overtook it.
+type RankOvertakenEvent struct {
+	Scope    string                   `json:"scope"`
+	Position RankPosition             `json:"position"`
+	Rival    RankPosition             `json:"rival"`
+	Metadata LeaderboardEventMetadata `json:"meta"`
+}
@@ -0,0 +1,172 @@
+// Documentation for the leaderboard eventbus
+//
+// OVERVIEW:
+// The LeaderboardEventBus tells clients about changes to the vibe point
+// leaderboards so that they can show recaps and notifications without
+// polling Verisafe.
+//
+// EXCHANGE TYPE: Topic
+// Events are published to the verisafe.leaderboard.exchange topic exchange
+// using the event type as the routing key. Consumers may bind to a single
+// event type, to season.# to receive every season event or to rank.# to
+// receive every rank change.
+//
+// EVENT TYPES:
+// - season.closed: Published after a season ended, its standings were
+//   archived and its end action was applied
+// - rank.top_entered: Published when an account moves into the top of the
+//   global leaderboard
+// - rank.overtaken: Published when an account is overtaken by a rival, once
+//   per scope. On the friends scope the rival is an account it follows
+//
+// Every event is also forwarded to webhook endpoints subscribed to its event
+// type.
+
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// LeaderboardEventBus provides a type-safe API for leaderboard events.
+type LeaderboardEventBus struct {
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+	webhooks    WebhookEnqueuer
+}
+
+// NewLeaderboardEventBus creates a new LeaderboardEventBus instance.
+func NewLeaderboardEventBus(cfg *config.Config, logger *slog.Logger) (*LeaderboardEventBus, error) {
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.LeaderboardExchange, defaultLeaderboardExchange),
+		TopicExchangeType,
+		logger,
+	)
+
+	if err != nil {
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	return &LeaderboardEventBus{
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
+	}, nil
+}
+
+// PublishSeasonClosed publishes a season closed event to the event bus
+func (b *LeaderboardEventBus) PublishSeasonClosed(ctx context.Context, recap SeasonRecap, requestID string) error {
+	eventType := "season.closed"
+	event := SeasonClosedEvent{
+		Recap: recap,
+		Metadata: LeaderboardEventMetadata{
+			EventID:         newEventID(),
+			EventType:       eventType,
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	b.logger.Info("Publishing leaderboard event",
+		slog.String("event_type", eventType),
+		slog.String("season_id", recap.Season.ID.String()),
+		slog.String("request_id", requestID),
+	)
+	return b.publish(ctx, eventType, event, requestID)
+}
+
+// PublishRankTopEntered publishes an event telling that an account entered
+// the top ranks of a leaderboard
+func (b *LeaderboardEventBus) PublishRankTopEntered(ctx context.Context, scope string, top int64, position RankPosition, requestID string) error {
+	eventType := "rank.top_entered"
+	event := RankTopEnteredEvent{
+		Scope:    scope,
+		Top:      top,
+		Position: position,
+		Metadata: LeaderboardEventMetadata{
+			EventID:         newEventID(),
+			EventType:       eventType,
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	b.logger.Info("Publishing leaderboard event",
+		slog.String("event_type", eventType),
+		slog.String("scope", scope),
+		slog.String("account_id", position.AccountID.String()),
+		slog.String("request_id", requestID),
+	)
+	return b.publish(ctx, eventType, event, requestID)
+}
+
+// PublishRankOvertaken publishes an event telling that an account was
+// overtaken by a rival
+func (b *LeaderboardEventBus) PublishRankOvertaken(ctx context.Context, scope string, position RankPosition, rival RankPosition, requestID string) error {
+	eventType := "rank.overtaken"
+	event := RankOvertakenEvent{
+		Scope:    scope,
+		Position: position,
+		Rival:    rival,
+		Metadata: LeaderboardEventMetadata{
+			EventID:         newEventID(),
+			EventType:       eventType,
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	b.logger.Info("Publishing leaderboard event",
+		slog.String("event_type", eventType),
+		slog.String("scope", scope),
+		slog.String("account_id", position.AccountID.String()),
+		slog.String("rival_id", rival.AccountID.String()),
+		slog.String("request_id", requestID),
+	)
+	return b.publish(ctx, eventType, event, requestID)
+}
+
+func (b *LeaderboardEventBus) publish(ctx context.Context, eventType string, event any, requestID string) error {
+	if b.webhooks != nil {
+		if err := b.webhooks.Enqueue(ctx, eventType, event); err != nil {
+			b.logger.Error("Failed to enqueue leaderboard event webhooks",
+				slog.String("request_id", requestID),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return b.bus.Publish(ctx, b.routingKeys.resolve(eventType, eventType), event)
+}
+
+// SetWebhookEnqueuer makes the bus forward every event it publishes to the
+// registered webhook endpoints
+func (b *LeaderboardEventBus) SetWebhookEnqueuer(webhooks WebhookEnqueuer) {
+	b.webhooks = webhooks
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *LeaderboardEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *LeaderboardEventBus) Health() Health {
+	return b.bus.Health()
+}
+
+// Close cancels the internal context, signalling all active handlers to stop.
+func (b *LeaderboardEventBus) Close() {
+	b.bus.Close()
+}
@@ -0,0 +1,163 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// How many events a MemoryEventBus keeps, the oldest are dropped first
+const memoryCaptureSize = 1000
+
+// CapturedEvent is an event published to a MemoryEventBus
+type CapturedEvent struct {
+	RoutingKey  string
+	Body        json.RawMessage
+	PublishedAt time.Time
+}
+
+// MemoryEventBus is an implementation of EventBus that keeps events in
+// memory, for local development and tests that should not need a broker.
+//
+// Published events are captured for assertions and handed synchronously to
+// the subscriptions they are routed to, the way the exchange type would route
+// them. Handlers are run once, failures are only logged.
+type MemoryEventBus struct {
+	exchange     string
+	exchangeType ExchangeType
+	logger       *slog.Logger
+	// Set when the bus stands in for a broker that could not be reached, in
+	// which case it reports itself as disconnected
+	standIn bool
+
+	mu            sync.RWMutex
+	events        []CapturedEvent
+	dropped       int64
+	subscriptions []subscription
+}
+
+var (
+	memoryBusesMu sync.Mutex
+	memoryBuses   = map[string]*MemoryEventBus{}
+)
+
+// NewMemoryEventBus creates and returns a new MemoryEventBus instance.
+func NewMemoryEventBus(exchange string, exchangeType ExchangeType, logger *slog.Logger) *MemoryEventBus {
+	return &MemoryEventBus{
+		exchange:     exchange,
+		exchangeType: exchangeType,
+		logger:       logger,
+	}
+}
+
+// MemoryEventBusFor returns the bus the memory backend uses for an exchange.
+// Every event bus of an exchange shares it, like they would share the
+// exchange on a broker, so tests can inspect the events published through
+// the typed event buses.
+func MemoryEventBusFor(exchange string, exchangeType ExchangeType, logger *slog.Logger) *MemoryEventBus {
+	memoryBusesMu.Lock()
+	defer memoryBusesMu.Unlock()
+
+	if eb, ok := memoryBuses[exchange]; ok {
+		return eb
+	}
+	eb := NewMemoryEventBus(exchange, exchangeType, logger)
+	memoryBuses[exchange] = eb
+	return eb
+}
+
+// Publish captures the event and hands it to the matching subscriptions
+func (eb *MemoryEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	eb.mu.Lock()
+	if len(eb.events) >= memoryCaptureSize {
+		eb.events = eb.events[1:]
+		eb.dropped++
+	}
+	eb.events = append(eb.events, CapturedEvent{
+		RoutingKey:  routingKey,
+		Body:        body,
+		PublishedAt: time.Now(),
+	})
+	subs := make([]subscription, len(eb.subscriptions))
+	copy(subs, eb.subscriptions)
+	eb.mu.Unlock()
+
+	for _, s := range subs {
+		if !routes(eb.exchangeType, s.routingKey, routingKey) {
+			continue
+		}
+		if err := eb.runHandler(ctx, s.handler, body); err != nil {
+			eb.logger.Error("eventbus handler failed",
+				slog.String("exchange", eb.exchange),
+				slog.String("routing_key", routingKey),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return nil
+}
+
+// runHandler runs a handler, turning a panic into an error
+func (eb *MemoryEventBus) runHandler(ctx context.Context, handler Handler, body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, body)
+}
+
+// Subscribe hands every event published from now on with a matching routing
+// key to the handler
+func (eb *MemoryEventBus) Subscribe(routingKey string, handler Handler) error {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.subscriptions = append(eb.subscriptions, subscription{routingKey, handler})
+	return nil
+}
+
+// Events returns the captured events, oldest first
+func (eb *MemoryEventBus) Events() []CapturedEvent {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	events := make([]CapturedEvent, len(eb.events))
+	copy(events, eb.events)
+	return events
+}
+
+// Reset forgets the captured events
+func (eb *MemoryEventBus) Reset() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.events = nil
+	eb.dropped = 0
+}
+
+// Health reports the bus as connected unless it stands in for a broker that
+// could not be reached
+func (eb *MemoryEventBus) Health() Health {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+
+	return Health{
+		Exchange:         eb.exchange,
+		Connected:        !eb.standIn,
+		DroppedPublishes: eb.dropped,
+	}
+}
+
+// Close removes every subscription
+func (eb *MemoryEventBus) Close() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.subscriptions = nil
+}
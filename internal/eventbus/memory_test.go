@@ -0,0 +1,62 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+func TestMemoryEventBusCapturesTypedEvents(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cfg := &config.Config{}
+	cfg.EventBusConfig.Backend = eventbus.MemoryBackend
+
+	leaderboardBus, err := eventbus.NewLeaderboardEventBus(cfg, logger)
+	if err != nil {
+		t.Fatalf("Could not create leaderboard event bus: %v", err)
+	}
+	defer leaderboardBus.Close()
+
+	memoryBus := eventbus.MemoryEventBusFor("verisafe.leaderboard.exchange", eventbus.TopicExchangeType, logger)
+	memoryBus.Reset()
+
+	var handled []string
+	err = memoryBus.Subscribe("rank.#", func(ctx context.Context, event []byte) error {
+		var payload struct {
+			Meta struct {
+				EventType string `json:"event_type"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(event, &payload); err != nil {
+			return err
+		}
+		handled = append(handled, payload.Meta.EventType)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Could not subscribe: %v", err)
+	}
+
+	position := eventbus.RankPosition{AccountID: uuid.New(), NewRank: 3, VibePoints: 120}
+	if err := leaderboardBus.PublishRankTopEntered(context.Background(), eventbus.LeaderboardScopeGlobal, 10, position, "request"); err != nil {
+		t.Fatalf("Could not publish rank.top_entered: %v", err)
+	}
+
+	events := memoryBus.Events()
+	if len(events) != 1 || events[0].RoutingKey != "rank.top_entered" {
+		t.Fatalf("Expected a single rank.top_entered event, got %+v", events)
+	}
+	if len(handled) != 1 || handled[0] != "rank.top_entered" {
+		t.Errorf("Subscription to rank.# handled %v, want [rank.top_entered]", handled)
+	}
+
+	if health := leaderboardBus.Health(); !health.Connected {
+		t.Errorf("Memory event bus reported itself as disconnected")
+	}
+}
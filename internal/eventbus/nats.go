@@ -0,0 +1,397 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+)
+
+const (
+	// How long a JetStream consumer waits for an event to be acknowledged
+	// before redelivering it. Handlers in progress keep extending it.
+	natsAckWait = time.Minute
+
+	// Subject, relative to the subject prefix, events that could not be
+	// published or handled end up on
+	natsDeadLetterSubject = "dead-letter"
+)
+
+// natsDurableReplacer turns an exchange and routing key into characters
+// allowed in a durable consumer name
+var natsDurableReplacer = strings.NewReplacer(".", "_", "*", "any", "#", "all", ">", "all", " ", "_")
+
+// NATSEventBus is an implementation of EventBus that uses NATS JetStream.
+//
+// Every event bus shares one stream covering every subject under the subject
+// prefix. An event is published on <prefix>.<exchange>.<routing key>, or on
+// <prefix>.<mapped subject> when its routing key is mapped, with the exchange
+// and routing key recorded in the exchange and event-type headers.
+type NATSEventBus struct {
+	conn         *nats.Conn
+	js           jetstream.JetStream
+	stream       string
+	prefix       string
+	exchange     string
+	exchangeType ExchangeType
+	subjects     map[string]string
+	logger       *slog.Logger
+
+	mu             sync.Mutex
+	consumes       []jetstream.ConsumeContext
+	deadLettered   int64
+	disconnectedAt time.Time
+	closed         bool
+
+	// Handlers run with handlerCtx, which is cancelled by Close. Close waits
+	// for consumers to finish the event they are handling.
+	handlerCtx    context.Context
+	cancelHandler context.CancelFunc
+	consumers     sync.WaitGroup
+}
+
+// NewNATSEventBus connects to NATS and creates the stream if it is missing.
+// The connection is restored automatically when it is lost.
+func NewNATSEventBus(url, stream, prefix, exchange string, exchangeType ExchangeType, subjects map[string]string, logger *slog.Logger) (*NATSEventBus, error) {
+	handlerCtx, cancelHandler := context.WithCancel(context.Background())
+	eb := &NATSEventBus{
+		stream:        stream,
+		prefix:        prefix,
+		exchange:      exchange,
+		exchangeType:  exchangeType,
+		subjects:      subjects,
+		logger:        logger,
+		handlerCtx:    handlerCtx,
+		cancelHandler: cancelHandler,
+	}
+
+	conn, err := nats.Connect(url,
+		nats.Name("verisafe"),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(reconnectMinDelay),
+		nats.DisconnectErrHandler(eb.onDisconnect),
+		nats.ReconnectHandler(eb.onReconnect),
+	)
+	if err != nil {
+		cancelHandler()
+		return nil, fmt.Errorf("connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		cancelHandler()
+		return nil, fmt.Errorf("create jetstream context: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishConfirmTimeout)
+	defer cancel()
+	if _, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{prefix + ".>"},
+		Storage:  jetstream.FileStorage,
+	}); err != nil {
+		conn.Close()
+		cancelHandler()
+		return nil, fmt.Errorf("declare stream %q: %w", stream, err)
+	}
+
+	eb.conn = conn
+	eb.js = js
+
+	logger.Info("eventbus connected",
+		slog.String("backend", "nats"),
+		slog.String("exchange", exchange),
+		slog.String("stream", stream),
+	)
+	return eb, nil
+}
+
+func (eb *NATSEventBus) onDisconnect(_ *nats.Conn, err error) {
+	eb.mu.Lock()
+	if eb.disconnectedAt.IsZero() {
+		eb.disconnectedAt = time.Now()
+	}
+	eb.mu.Unlock()
+
+	eb.logger.Warn("eventbus disconnected, reconnecting",
+		slog.String("exchange", eb.exchange),
+		slog.Any("error", err),
+	)
+}
+
+func (eb *NATSEventBus) onReconnect(_ *nats.Conn) {
+	eb.mu.Lock()
+	eb.disconnectedAt = time.Time{}
+	eb.mu.Unlock()
+
+	eb.logger.Info("eventbus reconnected", slog.String("exchange", eb.exchange))
+}
+
+// subject returns the subject events with the given routing key are
+// published on
+func (eb *NATSEventBus) subject(routingKey string) string {
+	if subject, ok := eb.subjects[routingKey]; ok {
+		return eb.prefix + "." + subject
+	}
+	return eb.prefix + "." + eb.exchange + "." + routingKey
+}
+
+// Publish serialises the event to JSON and publishes it once the stream
+// stored it. Publishes the server does not acknowledge are retried with
+// backoff and, once every attempt failed, the event is published on the
+// dead-letter subject and an error is returned.
+func (eb *NATSEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	body, signature, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(eb.subject(routingKey))
+	msg.Data = body
+	msg.Header.Set("exchange", eb.exchange)
+	msg.Header.Set("event-type", routingKey)
+	msg.Header.Set("content-type", "application/json")
+	for key, value := range signature {
+		msg.Header.Set(key, value)
+	}
+
+	delay := publishRetryDelay
+	for attempt := 1; ; attempt++ {
+		pubCtx, cancel := context.WithTimeout(ctx, publishConfirmTimeout)
+		_, err = eb.js.PublishMsg(pubCtx, msg)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= publishMaxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		eb.logger.Warn("eventbus publish failed, retrying",
+			slog.String("exchange", eb.exchange),
+			slog.String("routing_key", routingKey),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+
+	eb.deadLetter(routingKey, body, err)
+	return fmt.Errorf("publish %q: %w", routingKey, err)
+}
+
+// deadLetter publishes an event that could not be published or handled on
+// the dead-letter subject. The original subject, routing key and failure are
+// kept in the message headers
+func (eb *NATSEventBus) deadLetter(routingKey string, body []byte, cause error) {
+	eb.mu.Lock()
+	eb.deadLettered++
+	eb.mu.Unlock()
+
+	metrics.EventsDeadLettered.WithLabelValues(eb.exchange).Inc()
+
+	// Logged as an error with alert set so log based alerting picks it up
+	eb.logger.Error("eventbus dead-lettered event",
+		slog.Bool("alert", true),
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+		slog.Any("error", cause),
+	)
+
+	msg := nats.NewMsg(eb.prefix + "." + natsDeadLetterSubject)
+	msg.Data = body
+	msg.Header.Set("x-original-subject", eb.subject(routingKey))
+	msg.Header.Set("x-original-routing-key", routingKey)
+	msg.Header.Set("x-failure-reason", cause.Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishConfirmTimeout)
+	defer cancel()
+	if _, err := eb.js.PublishMsg(ctx, msg); err != nil {
+		eb.logger.Error("eventbus failed to dead-letter event, the event is lost",
+			slog.Bool("alert", true),
+			slog.String("exchange", eb.exchange),
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// Health reports whether the bus is connected and how often it reconnected
+func (eb *NATSEventBus) Health() Health {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	h := Health{
+		Exchange:     eb.exchange,
+		Connected:    eb.conn.IsConnected(),
+		Reconnects:   int64(eb.conn.Stats().Reconnects),
+		DeadLettered: eb.deadLettered,
+	}
+	if !eb.disconnectedAt.IsZero() {
+		since := eb.disconnectedAt
+		h.DisconnectedSince = &since
+	}
+	return h
+}
+
+// Subscribe creates a durable JetStream consumer for the routing key and
+// begins consuming events. Consumers only receive events published after
+// they were first created. Events are filtered the way the bus's exchange type
+// routes them in RabbitMQ.
+//
+// An event is acknowledged once its handler succeeds. A failing handler is
+// retried with exponential backoff, after handlerMaxAttempts attempts the
+// event is published on the dead-letter subject and acknowledged. Events
+// still being retried when the bus is closed are negatively acknowledged so
+// they are redelivered.
+func (eb *NATSEventBus) Subscribe(routingKey string, handler Handler) error {
+	filter := eb.prefix + ".>"
+	if subject, ok := eb.subjects[routingKey]; ok {
+		filter = eb.prefix + "." + subject
+	} else if len(eb.subjects) == 0 {
+		filter = eb.prefix + "." + eb.exchange + ".>"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), publishConfirmTimeout)
+	defer cancel()
+	consumer, err := eb.js.CreateOrUpdateConsumer(ctx, eb.stream, jetstream.ConsumerConfig{
+		Durable:       natsDurableReplacer.Replace(fmt.Sprintf("verisafe_%s_%s", eb.exchange, routingKey)),
+		FilterSubject: filter,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       natsAckWait,
+		DeliverPolicy: jetstream.DeliverNewPolicy,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer: %w", err)
+	}
+
+	consume, err := consumer.Consume(func(msg jetstream.Msg) {
+		eb.mu.Lock()
+		if eb.closed {
+			eb.mu.Unlock()
+			eb.settle(routingKey, msg.Nak)
+			return
+		}
+		eb.consumers.Add(1)
+		eb.mu.Unlock()
+		defer eb.consumers.Done()
+
+		headers := msg.Headers()
+		if headers.Get("exchange") != eb.exchange || !routes(eb.exchangeType, routingKey, headers.Get("event-type")) {
+			eb.settle(routingKey, msg.Ack)
+			return
+		}
+		eb.deliver(routingKey, handler, msg)
+	})
+	if err != nil {
+		return fmt.Errorf("consume: %w", err)
+	}
+
+	eb.mu.Lock()
+	eb.consumes = append(eb.consumes, consume)
+	eb.mu.Unlock()
+
+	eb.logger.Info("eventbus subscribed",
+		slog.String("backend", "nats"),
+		slog.String("exchange", eb.exchange),
+		slog.String("routing_key", routingKey),
+	)
+	return nil
+}
+
+// deliver hands a message to its handler, retrying failed attempts, and then
+// acknowledges it
+func (eb *NATSEventBus) deliver(routingKey string, handler Handler, msg jetstream.Msg) {
+	delay := handlerRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := eb.runHandler(handler, msg.Data())
+		if err == nil {
+			eb.settle(routingKey, msg.Ack)
+			return
+		}
+
+		if eb.handlerCtx.Err() != nil {
+			// Shutting down — have the server redeliver the event.
+			eb.settle(routingKey, msg.Nak)
+			return
+		}
+
+		if attempt >= handlerMaxAttempts {
+			eb.logger.Error("eventbus handler failed, dead-lettering event",
+				slog.String("routing_key", routingKey),
+				slog.Int("attempts", attempt),
+				slog.Any("error", err),
+			)
+			eb.deadLetter(routingKey, msg.Data(), err)
+			eb.settle(routingKey, msg.Ack)
+			return
+		}
+
+		eb.logger.Warn("eventbus handler failed, retrying",
+			slog.String("routing_key", routingKey),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+
+		select {
+		case <-eb.handlerCtx.Done():
+			eb.settle(routingKey, msg.Nak)
+			return
+		case <-time.After(delay):
+		}
+		// Keep the server from redelivering the event while it is retried
+		eb.settle(routingKey, msg.InProgress)
+		delay *= 2
+	}
+}
+
+// settle acknowledges, rejects or extends a message, logging failures
+func (eb *NATSEventBus) settle(routingKey string, ack func() error) {
+	if err := ack(); err != nil {
+		eb.logger.Error("eventbus ack failed",
+			slog.String("routing_key", routingKey),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// runHandler runs a handler, turning a panic into an error so a single bad
+// event cannot take the consumer down
+func (eb *NATSEventBus) runHandler(handler Handler, body []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(eb.handlerCtx, body)
+}
+
+// Close stops all consumers, waiting for the events being handled, and
+// closes the NATS connection.
+func (eb *NATSEventBus) Close() {
+	eb.mu.Lock()
+	eb.closed = true
+	consumes := eb.consumes
+	eb.mu.Unlock()
+
+	for _, consume := range consumes {
+		consume.Stop()
+	}
+	eb.cancelHandler()
+	eb.consumers.Wait()
+
+	eb.conn.Close()
+}
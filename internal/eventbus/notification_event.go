@@ -39,6 +39,7 @@ type NotificationButton struct {
 
 // EventMetadata contains metadata about the event
 type NotificationEventMetadata struct {
+	EventID         string    `json:"event_id"`
 	EventType       string    `json:"event_type"`
 	SourceServiceID string    `json:"source_service_id"`
 	RequestID       string    `json:"request_id"`
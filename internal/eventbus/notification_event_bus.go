@@ -10,34 +10,29 @@ import (
 )
 
 type NotificationEventBus struct {
-	bus    EventBus
-	logger *slog.Logger
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
 }
 
 // NewUserEventBus creates a new UserEventBus instance.
 func NewNotificationEventBus(cfg *config.Config, logger *slog.Logger) (*NotificationEventBus, error) {
-	rabbitMQConnString := fmt.Sprintf("amqp://%s:%s@%s:%d/",
-		cfg.RabbitMQConfig.RabbitMQUser,
-		cfg.RabbitMQConfig.RabbitMQPass,
-		cfg.RabbitMQConfig.RabbitMQAddress,
-		cfg.RabbitMQConfig.RabbitMQPort,
-	)
-
-	rabbitMQBus, err := NewRabbitMQEventBus(
-		rabbitMQConnString,
-		"gossip-monger.exchange",
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.NotificationExchange, defaultNotificationExchange),
 		DirectExchangeType,
 		logger,
 	)
 
 	if err != nil {
-		logger.Error("Failed to initialize RabbitMQ event bus", "error", err)
-		return nil, fmt.Errorf("failed to initialize RabbitMQ event bus: %w", err)
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
 	}
 
 	return &NotificationEventBus{
-		bus:    rabbitMQBus,
-		logger: logger,
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
 	}, nil
 }
 
@@ -50,6 +45,7 @@ func (neb *NotificationEventBus) PublishPushNotificationRequested(
 	event := NotificationEvent{
 		Notification: notification,
 		Meta: NotificationEventMetadata{
+			EventID:         newEventID(),
 			EventType:       "notification.requested",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -57,7 +53,7 @@ func (neb *NotificationEventBus) PublishPushNotificationRequested(
 		},
 	}
 
-	routingKey := "gossip-monger.notification.requested"
+	routingKey := neb.routingKeys.resolve("notification.requested", "gossip-monger.notification.requested")
 	neb.logger.Info("Publishing push notification requested event",
 		slog.String("routing_key", routingKey),
 		slog.String("request_id", requestID),
@@ -66,6 +62,43 @@ func (neb *NotificationEventBus) PublishPushNotificationRequested(
 	return neb.bus.Publish(ctx, routingKey, event)
 }
 
+// PublishEmailNotificationRequested publishes an event to request an email
+// to be sent via gossip-monger
+func (neb *NotificationEventBus) PublishEmailNotificationRequested(
+	ctx context.Context,
+	email EmailPayload, requestID string,
+) error {
+	event := EmailNotificationEvent{
+		Email: email,
+		Meta: NotificationEventMetadata{
+			EventID:         newEventID(),
+			EventType:       "email.requested",
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := neb.routingKeys.resolve("email.requested", "gossip-monger.email.requested")
+	neb.logger.Info("Publishing email notification requested event",
+		slog.String("routing_key", routingKey),
+		slog.String("request_id", requestID),
+	)
+
+	return neb.bus.Publish(ctx, routingKey, event)
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *NotificationEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *NotificationEventBus) Health() Health {
+	return b.bus.Health()
+}
+
 // Close cancels the internal context, signalling all active handlers to stop.
 func (b *NotificationEventBus) Close() {
 	b.bus.Close()
@@ -0,0 +1,31 @@
+package eventbus
+
+import (
+	"time"
+)
+
+// RoleEventMetadata contains crucial information about the event itself.
+type RoleEventMetadata struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Timestamp       time.Time `json:"timestamp"`
+	SourceServiceID string    `json:"source_service_id"`
+	RequestID       string    `json:"request_id"`
+}
+
+// RoleAssignment describes a role being granted to or taken from an account.
+// InstitutionID is only set for assignments that apply within a single
+// institution.
+type RoleAssignment struct {
+	AccountID     string `json:"account_id"`
+	RoleID        string `json:"role_id"`
+	RoleName      string `json:"role_name,omitempty"`
+	InstitutionID *int32 `json:"institution_id,omitempty"`
+	ActorID       string `json:"actor_id"`
+}
+
+// RoleEvent defines the payload for role assignment events.
+type RoleEvent struct {
+	Assignment RoleAssignment    `json:"assignment"`
+	Metadata   RoleEventMetadata `json:"meta"`
+}
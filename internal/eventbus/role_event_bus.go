@@ -0,0 +1,122 @@
+// Documentation for the role eventbus
+//
+// OVERVIEW:
+// The RoleEventBus tells downstream services such as the gradebook the moment
+// an account gains or loses a role so that they can adjust access without
+// polling Verisafe.
+//
+// EXCHANGE TYPE: Topic
+// Events are published to the verisafe.role.exchange topic exchange using the
+// event type as the routing key. Consumers may bind to a single event type or
+// to role.# to receive every role event.
+//
+// EVENT TYPES:
+// - role.assigned: Published after a role has been granted to an account
+// - role.revoked: Published after a role has been taken from an account
+//
+// Each event carries the account, the role, the institution for institution
+// scoped assignments and the account that made the change. Every event is
+// also forwarded to webhook endpoints subscribed to its event type.
+
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// RoleEventBus provides a type-safe API for role assignment events.
+type RoleEventBus struct {
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+	webhooks    WebhookEnqueuer
+}
+
+// NewRoleEventBus creates a new RoleEventBus instance.
+func NewRoleEventBus(cfg *config.Config, logger *slog.Logger) (*RoleEventBus, error) {
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.RoleExchange, defaultRoleExchange),
+		TopicExchangeType,
+		logger,
+	)
+
+	if err != nil {
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	return &RoleEventBus{
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
+	}, nil
+}
+
+// PublishRoleAssigned publishes a role assigned event to the event bus
+func (b *RoleEventBus) PublishRoleAssigned(ctx context.Context, assignment RoleAssignment, requestID string) error {
+	return b.publish(ctx, "role.assigned", assignment, requestID)
+}
+
+// PublishRoleRevoked publishes a role revoked event to the event bus
+func (b *RoleEventBus) PublishRoleRevoked(ctx context.Context, assignment RoleAssignment, requestID string) error {
+	return b.publish(ctx, "role.revoked", assignment, requestID)
+}
+
+func (b *RoleEventBus) publish(ctx context.Context, eventType string, assignment RoleAssignment, requestID string) error {
+	event := RoleEvent{
+		Assignment: assignment,
+		Metadata: RoleEventMetadata{
+			EventID:         newEventID(),
+			EventType:       eventType,
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := b.routingKeys.resolve(eventType, eventType)
+	b.logger.Info("Publishing role event",
+		slog.String("routing_key", routingKey),
+		slog.String("account_id", assignment.AccountID),
+		slog.String("role_id", assignment.RoleID),
+		slog.String("request_id", requestID),
+	)
+
+	if b.webhooks != nil {
+		if err := b.webhooks.Enqueue(ctx, eventType, event); err != nil {
+			b.logger.Error("Failed to enqueue role event webhooks",
+				slog.String("request_id", requestID),
+				slog.Any("error", err),
+			)
+		}
+	}
+	return b.bus.Publish(ctx, routingKey, event)
+}
+
+// SetWebhookEnqueuer makes the bus forward every event it publishes to the
+// registered webhook endpoints
+func (b *RoleEventBus) SetWebhookEnqueuer(webhooks WebhookEnqueuer) {
+	b.webhooks = webhooks
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *RoleEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *RoleEventBus) Health() Health {
+	return b.bus.Health()
+}
+
+// Close cancels the internal context, signalling all active handlers to stop.
+func (b *RoleEventBus) Close() {
+	b.bus.Close()
+}
@@ -0,0 +1,64 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Security event types
+const (
+	SecurityLoginSucceeded     = "security.login.succeeded"
+	SecurityLoginFailed        = "security.login.failed"
+	SecurityTokenCreated       = "security.token.created"
+	SecurityTokenRotated       = "security.token.rotated"
+	SecurityTokenRevoked       = "security.token.revoked"
+	SecurityPermissionChanged  = "security.permission.changed"
+	SecuritySuspiciousActivity = "security.suspicious_activity"
+)
+
+// Outcomes of security events
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Severities of security events
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// SecurityEventMetadata contains crucial information about the event itself.
+type SecurityEventMetadata struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Timestamp       time.Time `json:"timestamp"`
+	SourceServiceID string    `json:"source_service_id"`
+	RequestID       string    `json:"request_id"`
+}
+
+// SecurityActivity describes something security relevant that happened.
+// AccountID is the account the activity concerns and ActorID the account
+// that caused it, both are empty when they are not known. Method names how
+// the client authenticated, e.g. google, refresh_token or api_key. Country
+// and ASN describe the client's address when the GeoIP databases know it.
+type SecurityActivity struct {
+	Outcome   string          `json:"outcome"`
+	Severity  string          `json:"severity"`
+	AccountID string          `json:"account_id,omitempty"`
+	ActorID   string          `json:"actor_id,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	IPAddress string          `json:"ip_address,omitempty"`
+	Country   string          `json:"country,omitempty"`
+	ASN       uint            `json:"asn,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+}
+
+// SecurityEvent defines the payload for security audit events.
+type SecurityEvent struct {
+	Activity SecurityActivity      `json:"activity"`
+	Metadata SecurityEventMetadata `json:"meta"`
+}
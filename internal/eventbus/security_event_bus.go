@@ -0,0 +1,144 @@
+// Documentation for the security eventbus
+//
+// OVERVIEW:
+// The SecurityEventBus publishes a dedicated stream of security relevant
+// activity for SIEM ingestion, so that security tooling does not have to
+// follow every other event stream Verisafe publishes.
+//
+// EXCHANGE TYPE: Topic
+// Events are published to the verisafe.security.exchange topic exchange
+// using the verisafe.security.events routing key. RABBITMQ_ROUTING_KEYS can
+// give individual event types routing keys of their own.
+//
+// EVENT TYPES:
+// - security.login.succeeded: An account signed in
+// - security.login.failed: A sign in, token refresh or API key was rejected
+// - security.token.created: A service token was created
+// - security.token.rotated: A service token was rotated
+// - security.token.revoked: A service token was revoked
+// - security.permission.changed: Roles, permissions or their assignments
+//   changed, published for every authz.changed event
+// - security.suspicious_activity: A request was rejected in a way that
+//   suggests misuse, e.g. a revoked service token or one used from an IP
+//   address outside its allowlist
+//
+// Report publishes events in the background so that requests never wait for
+// the broker, failures are logged.
+
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// Routing key security events are published with
+const SecurityEventsRoutingKey = "verisafe.security.events"
+
+// SecurityEventBus provides a type-safe API for security audit events.
+type SecurityEventBus struct {
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+}
+
+// NewSecurityEventBus creates a new SecurityEventBus instance.
+func NewSecurityEventBus(cfg *config.Config, logger *slog.Logger) (*SecurityEventBus, error) {
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.SecurityExchange, defaultSecurityExchange),
+		TopicExchangeType,
+		logger,
+	)
+
+	if err != nil {
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	return &SecurityEventBus{
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
+	}, nil
+}
+
+// PublishSecurityEvent publishes a security event to the event bus
+func (b *SecurityEventBus) PublishSecurityEvent(ctx context.Context, eventType string, activity SecurityActivity, requestID string) error {
+	event := SecurityEvent{
+		Activity: activity,
+		Metadata: SecurityEventMetadata{
+			EventID:         newEventID(),
+			EventType:       eventType,
+			Timestamp:       time.Now(),
+			SourceServiceID: "io.opencrafts.verisafe",
+			RequestID:       requestID,
+		},
+	}
+
+	routingKey := b.routingKeys.resolve(eventType, SecurityEventsRoutingKey)
+	b.logger.Info("Publishing security event",
+		slog.String("routing_key", routingKey),
+		slog.String("event_type", eventType),
+		slog.String("outcome", activity.Outcome),
+		slog.String("severity", activity.Severity),
+		slog.String("request_id", requestID),
+	)
+
+	return b.bus.Publish(ctx, routingKey, event)
+}
+
+// Report publishes a security event in the background. It is safe to call on
+// a nil bus, which reports nothing
+func (b *SecurityEventBus) Report(eventType string, activity SecurityActivity, requestID string) {
+	if b == nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := b.PublishSecurityEvent(ctx, eventType, activity, requestID); err != nil {
+			b.logger.Error("Failed to publish security event",
+				slog.String("event_type", eventType),
+				slog.String("request_id", requestID),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
+
+// OnSecurityEvent hands every security event published to the exchange to
+// the handler, whatever its routing key and whichever instance published it.
+// Events that cannot be decoded are rejected without calling it
+func (b *SecurityEventBus) OnSecurityEvent(handler func(ctx context.Context, event SecurityEvent) error) error {
+	return b.bus.Subscribe("#", func(ctx context.Context, body []byte) error {
+		var event SecurityEvent
+		if err := json.Unmarshal(unwrapCloudEvent(body), &event); err != nil {
+			return fmt.Errorf("decode security event: %w", err)
+		}
+		return handler(ctx, event)
+	})
+}
+
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *SecurityEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *SecurityEventBus) Health() Health {
+	return b.bus.Health()
+}
+
+// Close cancels the internal context, signalling all active handlers to stop.
+func (b *SecurityEventBus) Close() {
+	b.bus.Close()
+}
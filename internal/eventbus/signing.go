@@ -0,0 +1,162 @@
+package eventbus
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+)
+
+// Algorithms events can be signed with
+const (
+	HMACSigning    = "hmac-sha256"
+	Ed25519Signing = "ed25519"
+)
+
+const (
+	// Header carrying the unix timestamp the event was signed at
+	SignatureTimestampHeader = "x-verisafe-timestamp"
+	// Header carrying the signature over "<timestamp>.<body>", either
+	// v1=<hex hmac> or ed25519=<base64 signature>
+	SignatureHeader = "x-verisafe-signature"
+	// Header carrying the configured id of the signing key, if any
+	SignatureKeyIDHeader = "x-verisafe-key-id"
+)
+
+// EventSigner signs serialised events so that consumers can verify they were
+// published by Verisafe and not changed in transit
+type EventSigner struct {
+	algorithm  string
+	keyID      string
+	secret     []byte
+	privateKey ed25519.PrivateKey
+}
+
+// NewEventSigner creates the signer configured through EVENT_SIGNING_*. It
+// returns nil when events are not signed
+func NewEventSigner(cfg *config.Config) (*EventSigner, error) {
+	signing := cfg.EventSigningConfig
+	signer := &EventSigner{algorithm: signing.Algorithm, keyID: signing.KeyID}
+
+	switch signing.Algorithm {
+	case "":
+		return nil, nil
+	case HMACSigning:
+		if signing.Key == "" {
+			return nil, fmt.Errorf("event signing: EVENT_SIGNING_KEY is required")
+		}
+		signer.secret = []byte(signing.Key)
+	case Ed25519Signing:
+		key, err := base64.StdEncoding.DecodeString(signing.Key)
+		if err != nil {
+			return nil, fmt.Errorf("event signing: EVENT_SIGNING_KEY is not base64 encoded: %w", err)
+		}
+		switch len(key) {
+		case ed25519.SeedSize:
+			signer.privateKey = ed25519.NewKeyFromSeed(key)
+		case ed25519.PrivateKeySize:
+			signer.privateKey = ed25519.PrivateKey(key)
+		default:
+			return nil, fmt.Errorf("event signing: EVENT_SIGNING_KEY must be an ed25519 seed or private key")
+		}
+	default:
+		return nil, fmt.Errorf("event signing: unknown algorithm %q", signing.Algorithm)
+	}
+	return signer, nil
+}
+
+// Algorithm returns the algorithm events are signed with
+func (s *EventSigner) Algorithm() string {
+	return s.algorithm
+}
+
+// KeyID returns the configured id of the signing key
+func (s *EventSigner) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the key Ed25519 signatures are verified with. It is nil
+// for HMAC signatures
+func (s *EventSigner) PublicKey() ed25519.PublicKey {
+	if s.privateKey == nil {
+		return nil
+	}
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Sign returns the headers carrying the signature of a serialised event.
+// The signature is computed over "<timestamp>.<body>"
+func (s *EventSigner) Sign(body []byte, timestamp int64) map[string]string {
+	signed := []byte(strconv.FormatInt(timestamp, 10) + ".")
+	signed = append(signed, body...)
+
+	var signature string
+	if s.privateKey != nil {
+		signature = "ed25519=" + base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, signed))
+	} else {
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(signed)
+		signature = "v1=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	headers := map[string]string{
+		SignatureTimestampHeader: strconv.FormatInt(timestamp, 10),
+		SignatureHeader:          signature,
+	}
+	if s.keyID != "" {
+		headers[SignatureKeyIDHeader] = s.keyID
+	}
+	return headers
+}
+
+// signedEvent is an event serialised by the signing bus together with the
+// headers carrying its signature. It marshals to the serialised event so
+// that buses without message headers publish the event unchanged
+type signedEvent struct {
+	body    json.RawMessage
+	headers map[string]string
+}
+
+func (e signedEvent) MarshalJSON() ([]byte, error) {
+	return e.body, nil
+}
+
+// encodeEvent serialises an event and returns the headers it is published
+// with, if any
+func encodeEvent(event any) ([]byte, map[string]string, error) {
+	if e, ok := event.(signedEvent); ok {
+		return e.body, e.headers, nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal event: %w", err)
+	}
+	return body, nil, nil
+}
+
+// signingEventBus signs every event published through it before handing it
+// to the wrapped bus
+type signingEventBus struct {
+	EventBus
+	signer *EventSigner
+}
+
+// Publish serialises and signs the event and publishes it with its signature
+func (sb *signingEventBus) Publish(ctx context.Context, routingKey string, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	return sb.EventBus.Publish(ctx, routingKey, signedEvent{
+		body:    body,
+		headers: sb.signer.Sign(body, time.Now().Unix()),
+	})
+}
@@ -0,0 +1,34 @@
+package eventbus
+
+// Exchanges the event buses publish to unless RABBITMQ_*_EXCHANGE configures
+// others
+const (
+	defaultUserExchange         = "verisafe.exchange"
+	defaultAuthzExchange        = "verisafe.authz.exchange"
+	defaultRoleExchange         = "verisafe.role.exchange"
+	defaultLeaderboardExchange  = "verisafe.leaderboard.exchange"
+	defaultInstitutionExchange  = "professor.exchange"
+	defaultNotificationExchange = "gossip-monger.exchange"
+	defaultSecurityExchange     = "verisafe.security.exchange"
+)
+
+// exchangeName returns the configured exchange of an event bus, or its
+// default exchange when none is configured
+func exchangeName(configured, defaultExchange string) string {
+	if configured == "" {
+		return defaultExchange
+	}
+	return configured
+}
+
+// routingKeys maps event types to the routing keys they are published with
+// instead of their default ones, configured through RABBITMQ_ROUTING_KEYS
+type routingKeys map[string]string
+
+// resolve returns the routing key an event type is published with
+func (r routingKeys) resolve(eventType, defaultRoutingKey string) string {
+	if routingKey, ok := r[eventType]; ok {
+		return routingKey
+	}
+	return defaultRoutingKey
+}
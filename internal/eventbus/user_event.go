@@ -8,6 +8,7 @@ import (
 
 // UserEventMetadata contains crucial information about the event itself.
 type UserEventMetadata struct {
+	EventID         string    `json:"event_id"`
 	EventType       string    `json:"event_type"`
 	Timestamp       time.Time `json:"timestamp"`
 	SourceServiceID string    `json:"source_service_id"`
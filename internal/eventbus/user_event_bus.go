@@ -40,6 +40,7 @@ package eventbus
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"time"
@@ -51,34 +52,30 @@ import (
 
 // UserEventBus provides a type-safe API for user events.
 type UserEventBus struct {
-	bus    EventBus
-	logger *slog.Logger
+	bus         EventBus
+	routingKeys routingKeys
+	logger      *slog.Logger
+	webhooks    WebhookEnqueuer
 }
 
 // NewUserEventBus creates a new UserEventBus instance.
 func NewUserEventBus(cfg *config.Config, logger *slog.Logger) (*UserEventBus, error) {
-	rabbitMQConnString := fmt.Sprintf("amqp://%s:%s@%s:%d/",
-		cfg.RabbitMQConfig.RabbitMQUser,
-		cfg.RabbitMQConfig.RabbitMQPass,
-		cfg.RabbitMQConfig.RabbitMQAddress,
-		cfg.RabbitMQConfig.RabbitMQPort,
-	)
-
-	rabbitMQBus, err := NewRabbitMQEventBus(
-		rabbitMQConnString,
-		cfg.RabbitMQConfig.Exchange,
+	bus, err := newEventBus(
+		cfg,
+		exchangeName(cfg.RabbitMQConfig.Exchange, defaultUserExchange),
 		FanoutExchangeType,
 		logger,
 	)
 
 	if err != nil {
-		logger.Error("Failed to initialize RabbitMQ event bus", "error", err)
-		return nil, fmt.Errorf("failed to initialize RabbitMQ event bus: %w", err)
+		logger.Error("Failed to initialize event bus", "error", err)
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
 	}
 
 	return &UserEventBus{
-		bus:    rabbitMQBus,
-		logger: logger,
+		bus:         bus,
+		logger:      logger,
+		routingKeys: cfg.RabbitMQConfig.RoutingKeys,
 	}, nil
 }
 
@@ -87,6 +84,7 @@ func (b *UserEventBus) PublishUserCreated(ctx context.Context, user repository.A
 	event := UserEvent{
 		User: user,
 		Metadata: UserEventMetadata{
+			EventID:         newEventID(),
 			EventType:       "user.created",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -94,13 +92,14 @@ func (b *UserEventBus) PublishUserCreated(ctx context.Context, user repository.A
 		},
 	}
 
-	routingKey := ""
+	routingKey := b.routingKeys.resolve("user.created", "")
 	b.logger.Info("Publishing user created event",
 		slog.String("routing_key", routingKey),
 		slog.String("user_id", user.ID.String()),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
@@ -109,6 +108,7 @@ func (b *UserEventBus) PublishUserUpdated(ctx context.Context, user repository.A
 	event := UserEvent{
 		User: user,
 		Metadata: UserEventMetadata{
+			EventID:         newEventID(),
 			EventType:       "user.updated",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -116,13 +116,14 @@ func (b *UserEventBus) PublishUserUpdated(ctx context.Context, user repository.A
 		},
 	}
 
-	routingKey := ""
+	routingKey := b.routingKeys.resolve("user.updated", "")
 	b.logger.Info("Publishing user updated event",
 		slog.String("routing_key", routingKey),
 		slog.String("user_id", user.ID.String()),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
@@ -131,6 +132,7 @@ func (b *UserEventBus) PublishUserDeleted(ctx context.Context, user repository.A
 	event := UserEvent{
 		User: user,
 		Metadata: UserEventMetadata{
+			EventID:         newEventID(),
 			EventType:       "user.deleted",
 			Timestamp:       time.Now(),
 			SourceServiceID: "io.opencrafts.verisafe",
@@ -138,21 +140,67 @@ func (b *UserEventBus) PublishUserDeleted(ctx context.Context, user repository.A
 		},
 	}
 
-	routingKey := ""
+	routingKey := b.routingKeys.resolve("user.deleted", "")
 	b.logger.Info("Publishing user deleted event",
 		slog.String("routing_key", routingKey),
 		slog.String("user_id", user.ID.String()),
 		slog.String("request_id", requestID),
 	)
 
+	b.forwardToWebhooks(ctx, event)
 	return b.bus.Publish(ctx, routingKey, event)
 }
 
+// OnUserEvent hands every user event published to the exchange to the
+// handler, whichever instance published it. Events that cannot be decoded are
+// rejected without calling it
+func (b *UserEventBus) OnUserEvent(handler func(ctx context.Context, event UserEvent) error) error {
+	return b.bus.Subscribe("#", func(ctx context.Context, body []byte) error {
+		var event UserEvent
+		if err := json.Unmarshal(unwrapCloudEvent(body), &event); err != nil {
+			return fmt.Errorf("decode user event: %w", err)
+		}
+		return handler(ctx, event)
+	})
+}
+
+// SetWebhookEnqueuer makes the bus forward every user event it publishes to
+// the registered webhook endpoints
+func (b *UserEventBus) SetWebhookEnqueuer(webhooks WebhookEnqueuer) {
+	b.webhooks = webhooks
+}
+
+// forwardToWebhooks hands the event over to the webhook subsystem.
+// Failures are logged and never prevent the event from reaching the broker
+func (b *UserEventBus) forwardToWebhooks(ctx context.Context, event UserEvent) {
+	if b.webhooks == nil {
+		return
+	}
+	if err := b.webhooks.Enqueue(ctx, event.Metadata.EventType, event); err != nil {
+		b.logger.Error("Failed to enqueue user event webhooks",
+			slog.String("event_type", event.Metadata.EventType),
+			slog.String("request_id", event.Metadata.RequestID),
+			slog.Any("error", err),
+		)
+	}
+}
+
 // GenerateRequestID generates a unique request ID for event tracking
 func GenerateRequestID() string {
 	return uuid.New().String()
 }
 
+// SetEventJournal makes the bus record every event it publishes so that it
+// can be replayed later
+func (b *UserEventBus) SetEventJournal(journal EventJournal) {
+	b.bus = newJournalingEventBus(b.bus, journal, b.logger)
+}
+
+// Health reports the state of the bus's broker connection
+func (b *UserEventBus) Health() Health {
+	return b.bus.Health()
+}
+
 // Close cancels the internal context, signalling all active handlers to stop.
 func (b *UserEventBus) Close() {
 	b.bus.Close()
@@ -0,0 +1,11 @@
+package eventbus
+
+import (
+	"context"
+)
+
+// WebhookEnqueuer forwards published events to partners that are not
+// connected to the broker through registered HTTPS webhook endpoints
+type WebhookEnqueuer interface {
+	Enqueue(ctx context.Context, eventType string, event any) error
+}
@@ -0,0 +1,166 @@
+// Package eventdedupe keeps consumers from handling an event more than once.
+//
+// OVERVIEW:
+// Brokers deliver events at least once and the event journal publishes
+// events again on request, so consumers see some events several times. Every
+// event carries an event_id in its metadata which stays the same across
+// redeliveries and replays. The Deduplicator records the IDs of the events a
+// consumer handled in the processed_events table and skips events it
+// recorded before.
+//
+// USAGE:
+// Handler wraps an eventbus.Handler and records an event once the handler
+// handled it. Events delivered again while the handler still runs are not
+// recognised, handlers should tolerate that. Once runs a function within the
+// transaction that records the event instead, so the event is recorded if
+// and only if the function's changes are committed.
+//
+// RETENTION:
+// Processed events older than the retention period are pruned in the
+// background, an event delivered again after that is handled again.
+package eventdedupe
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// How often processed events past the retention period are pruned
+const pruneInterval = time.Hour
+
+// Deduplicator records the events consumers handled
+type Deduplicator struct {
+	pool          *pgxpool.Pool
+	logger        *slog.Logger
+	retentionDays int32
+}
+
+// NewDeduplicator creates a new Deduplicator. Call Start to begin pruning
+// processed events.
+func NewDeduplicator(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Deduplicator {
+	retentionDays := cfg.EventDedupeConfig.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = 30
+	}
+
+	return &Deduplicator{
+		pool:          pool,
+		logger:        logger,
+		retentionDays: int32(retentionDays),
+	}
+}
+
+// Once runs fn within a transaction unless the consumer processed the event
+// before, and records the event within the same transaction. It reports
+// whether fn ran. Nothing is recorded when fn fails
+func (d *Deduplicator) Once(ctx context.Context, consumer, eventID string, fn func(ctx context.Context, tx pgx.Tx) error) (bool, error) {
+	tx, err := d.pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	recorded, err := repository.New(tx).MarkEventProcessed(ctx, repository.MarkEventProcessedParams{
+		Consumer: consumer,
+		EventID:  eventID,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to record processed event: %w", err)
+	}
+	if recorded == 0 {
+		return false, nil
+	}
+
+	if err := fn(ctx, tx); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// Handler wraps an event handler so that it skips events the consumer
+// processed before. Events are recorded once the handler succeeded, events
+// without an ID are always handled
+func (d *Deduplicator) Handler(consumer string, handler eventbus.Handler) eventbus.Handler {
+	return func(ctx context.Context, event []byte) error {
+		eventID := eventbus.EventID(event)
+		if eventID == "" {
+			return handler(ctx, event)
+		}
+
+		repo := repository.New(d.pool)
+		processed, err := repo.IsEventProcessed(ctx, repository.IsEventProcessedParams{
+			Consumer: consumer,
+			EventID:  eventID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check whether event %s was processed: %w", eventID, err)
+		}
+		if processed {
+			d.logger.Info("Skipping event that was already processed",
+				slog.String("consumer", consumer),
+				slog.String("event_id", eventID),
+			)
+			return nil
+		}
+
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+
+		// The event was handled, failing to record it only means it may be
+		// handled again
+		if _, err := repo.MarkEventProcessed(ctx, repository.MarkEventProcessedParams{
+			Consumer: consumer,
+			EventID:  eventID,
+		}); err != nil {
+			d.logger.Error("Failed to record processed event",
+				slog.String("consumer", consumer),
+				slog.String("event_id", eventID),
+				slog.Any("error", err),
+			)
+		}
+		return nil
+	}
+}
+
+// Start prunes processed events past the retention period until the context
+// is cancelled
+func (d *Deduplicator) Start(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	d.logger.Info("Processed event pruner started",
+		slog.Int("retention_days", int(d.retentionDays)),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Processed event pruner stopped")
+			return
+		case <-ticker.C:
+			pruned, err := repository.New(d.pool).PruneProcessedEvents(ctx, d.retentionDays)
+			if err != nil {
+				if ctx.Err() == nil {
+					d.logger.Error("Failed to prune processed events", slog.Any("error", err))
+				}
+				continue
+			}
+			if pruned > 0 {
+				d.logger.Info("Pruned processed events", slog.Int64("count", pruned))
+			}
+		}
+	}
+}
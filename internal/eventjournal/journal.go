@@ -0,0 +1,220 @@
+// Package eventjournal keeps the events Verisafe publishes so that they can
+// be published again.
+//
+// OVERVIEW:
+// Downstream services occasionally lose messages, e.g. when a queue was
+// purged or a consumer acknowledged events it failed to process. Every typed
+// event bus records the events it publishes in the published_events table
+// through the Journal and registers itself as the bus replaying them.
+//
+// REPLAY:
+// Replay selects journaled events by type, time range and account and
+// publishes them again oldest first, through the exchange and with the
+// routing key they were first published with. Replayed events are identical
+// to the original ones, consumers should use the event_id of the event
+// metadata to recognise events they already handled.
+//
+// RETENTION:
+// Events older than the retention period are pruned in the background.
+package eventjournal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// How often events past the retention period are pruned
+const pruneInterval = time.Hour
+
+// Journal records published events and replays them
+type Journal struct {
+	pool            *pgxpool.Pool
+	logger          *slog.Logger
+	retentionDays   int32
+	maxReplayEvents int
+
+	mu    sync.RWMutex
+	buses map[string]eventbus.Replayer // keyed by exchange
+}
+
+// ReplayFilter selects the events to replay. Events of every type are
+// replayed when EventTypes is empty and events about every account when
+// AccountID is nil
+type ReplayFilter struct {
+	EventTypes []string
+	From       time.Time
+	To         time.Time
+	AccountID  *uuid.UUID
+	Limit      int
+}
+
+// ReplayResult tells how a replay went. Truncated is set when more events
+// matched the filter than the limit allowed to replay
+type ReplayResult struct {
+	Matched   int  `json:"matched"`
+	Replayed  int  `json:"replayed"`
+	Failed    int  `json:"failed"`
+	Truncated bool `json:"truncated"`
+}
+
+// NewJournal creates a new Journal. Call Start to begin pruning old events.
+func NewJournal(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Journal {
+	retentionDays := cfg.EventJournalConfig.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = 30
+	}
+	maxReplayEvents := cfg.EventJournalConfig.MaxReplayEvents
+	if maxReplayEvents <= 0 {
+		maxReplayEvents = 10000
+	}
+
+	return &Journal{
+		pool:            pool,
+		logger:          logger,
+		retentionDays:   int32(retentionDays),
+		maxReplayEvents: maxReplayEvents,
+		buses:           map[string]eventbus.Replayer{},
+	}
+}
+
+// Record stores a published event
+func (j *Journal) Record(ctx context.Context, event eventbus.JournaledEvent) error {
+	accountID := pgtype.UUID{}
+	if event.AccountID != nil {
+		accountID = pgtype.UUID{Bytes: *event.AccountID, Valid: true}
+	}
+
+	if err := repository.New(j.pool).CreatePublishedEvent(ctx, repository.CreatePublishedEventParams{
+		Exchange:   event.Exchange,
+		RoutingKey: event.RoutingKey,
+		EventType:  event.EventType,
+		AccountID:  accountID,
+		Payload:    event.Payload,
+	}); err != nil {
+		return fmt.Errorf("failed to record published event: %w", err)
+	}
+	return nil
+}
+
+// Register makes the events published to an exchange replayable through bus
+func (j *Journal) Register(exchange string, bus eventbus.Replayer) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.buses[exchange] = bus
+}
+
+// MaxReplayEvents returns the most events a single replay publishes
+func (j *Journal) MaxReplayEvents() int {
+	return j.maxReplayEvents
+}
+
+// Replay publishes the events matching the filter again, oldest first. A
+// limit that is not set or above MaxReplayEvents is lowered to it. Events
+// that fail to publish are logged and counted, they do not stop the replay
+func (j *Journal) Replay(ctx context.Context, filter ReplayFilter) (ReplayResult, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > j.maxReplayEvents {
+		limit = j.maxReplayEvents
+	}
+
+	accountID := pgtype.UUID{}
+	if filter.AccountID != nil {
+		accountID = pgtype.UUID{Bytes: *filter.AccountID, Valid: true}
+	}
+	eventTypes := filter.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	// One more event than the limit is read to tell whether the replay was
+	// truncated
+	events, err := repository.New(j.pool).ListPublishedEventsForReplay(ctx, repository.ListPublishedEventsForReplayParams{
+		PublishedFrom: pgtype.Timestamp{Time: filter.From.UTC(), Valid: true},
+		PublishedTo:   pgtype.Timestamp{Time: filter.To.UTC(), Valid: true},
+		EventTypes:    eventTypes,
+		AccountID:     accountID,
+		MaxEvents:     int32(limit + 1),
+	})
+	if err != nil {
+		return ReplayResult{}, fmt.Errorf("failed to list published events: %w", err)
+	}
+
+	result := ReplayResult{}
+	if len(events) > limit {
+		events = events[:limit]
+		result.Truncated = true
+	}
+	result.Matched = len(events)
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		bus, ok := j.buses[event.Exchange]
+		if !ok {
+			result.Failed++
+			j.logger.Error("No event bus replays the exchange of a journaled event",
+				slog.Int64("event_id", event.ID),
+				slog.String("exchange", event.Exchange),
+			)
+			continue
+		}
+
+		if err := bus.Replay(ctx, event.RoutingKey, event.Payload); err != nil {
+			result.Failed++
+			j.logger.Error("Failed to replay event",
+				slog.Int64("event_id", event.ID),
+				slog.String("event_type", event.EventType),
+				slog.Any("error", err),
+			)
+			continue
+		}
+		result.Replayed++
+	}
+
+	return result, nil
+}
+
+// Start prunes events past the retention period until the context is
+// cancelled
+func (j *Journal) Start(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	j.logger.Info("Event journal pruner started",
+		slog.Int("retention_days", int(j.retentionDays)),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			j.logger.Info("Event journal pruner stopped")
+			return
+		case <-ticker.C:
+			pruned, err := repository.New(j.pool).PrunePublishedEvents(ctx, j.retentionDays)
+			if err != nil {
+				if ctx.Err() == nil {
+					j.logger.Error("Failed to prune published events", slog.Any("error", err))
+				}
+				continue
+			}
+			if pruned > 0 {
+				j.logger.Info("Pruned published events", slog.Int64("count", pruned))
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Package gamification maintains the data recorded as accounts complete
+// activities.
+//
+// PARTITIONS:
+// Activity completions are stored in activity_completions, partitioned by the
+// month they were made in. Queries bounded by completion time only scan the
+// months they need, so streak checks and statistics do not slow down as
+// completions pile up. The PartitionMaintainer creates the partitions of the
+// coming months ahead of time. Completions made in a month without a
+// partition land in a default partition and are moved to their month's
+// partition once it is created.
+//
+// ARCHIVAL:
+// When an archival period is configured, the partitions of the months before
+// it are moved to activity_completions_archive and dropped, keeping the
+// partitioned table down to the months streaks and statistics read. Archived
+// completions are kept for audits and exports but no longer listed.
+//
+// RETENTION:
+// When a retention period is configured, the partitions of the months before
+// it are dropped as a whole instead of deleting completions row by row, and
+// archived completions of those months are deleted. Streaks, achievements and
+// the vibe points completions earned are kept.
+package gamification
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// How often partitions are created ahead and old partitions archived or
+// dropped
+const partitionMaintenanceInterval = 6 * time.Hour
+
+// PartitionMaintainer keeps the monthly activity completion partitions ahead
+// of time, archives the ones past the archival period and drops the ones past
+// the retention period
+type PartitionMaintainer struct {
+	pool               *pgxpool.Pool
+	logger             *slog.Logger
+	monthsAhead        int32
+	archiveAfterMonths int32
+	retentionMonths    int32
+}
+
+// NewPartitionMaintainer creates a new PartitionMaintainer. Call Start to
+// begin maintaining partitions.
+func NewPartitionMaintainer(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *PartitionMaintainer {
+	monthsAhead := cfg.ActivityCompletionConfig.PartitionsAhead
+	if monthsAhead <= 0 {
+		monthsAhead = 3
+	}
+	archiveAfterMonths := cfg.ActivityCompletionConfig.ArchiveAfterMonths
+	if archiveAfterMonths < 0 {
+		archiveAfterMonths = 0
+	}
+	retentionMonths := cfg.ActivityCompletionConfig.RetentionMonths
+	if retentionMonths < 0 {
+		retentionMonths = 0
+	}
+
+	return &PartitionMaintainer{
+		pool:               pool,
+		logger:             logger,
+		monthsAhead:        int32(monthsAhead),
+		archiveAfterMonths: int32(archiveAfterMonths),
+		retentionMonths:    int32(retentionMonths),
+	}
+}
+
+// Start maintains the partitions right away and then on every interval until
+// the context is cancelled
+func (m *PartitionMaintainer) Start(ctx context.Context) {
+	ticker := time.NewTicker(partitionMaintenanceInterval)
+	defer ticker.Stop()
+
+	m.logger.Info("Activity completion partition maintainer started",
+		slog.Int("months_ahead", int(m.monthsAhead)),
+		slog.Int("archive_after_months", int(m.archiveAfterMonths)),
+		slog.Int("retention_months", int(m.retentionMonths)),
+	)
+	m.maintain(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.Info("Activity completion partition maintainer stopped")
+			return
+		case <-ticker.C:
+			m.maintain(ctx)
+		}
+	}
+}
+
+// maintain creates the partitions of the coming months, archives the ones past
+// the archival period and drops the ones past the retention period
+func (m *PartitionMaintainer) maintain(ctx context.Context) {
+	repo := repository.New(m.pool)
+
+	created, err := repo.CreateActivityCompletionPartitions(ctx, m.monthsAhead)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("Failed to create activity completion partitions", slog.Any("error", err))
+		}
+		return
+	}
+	if created > 0 {
+		m.logger.Info("Created activity completion partitions", slog.Int("count", int(created)))
+	}
+
+	if m.archiveAfterMonths > 0 {
+		archived, err := repo.ArchiveActivityCompletionPartitions(ctx, m.archiveAfterMonths)
+		if err != nil {
+			if ctx.Err() == nil {
+				m.logger.Error("Failed to archive activity completion partitions", slog.Any("error", err))
+			}
+			return
+		}
+		for _, partition := range archived {
+			m.logger.Info("Archived activity completion partition",
+				slog.String("partition", partition.ArchivedPartition),
+				slog.Int64("completions", partition.ArchivedRows),
+			)
+		}
+	}
+
+	if m.retentionMonths == 0 {
+		return
+	}
+
+	dropped, err := repo.DropExpiredActivityCompletionPartitions(ctx, m.retentionMonths)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("Failed to drop expired activity completion partitions", slog.Any("error", err))
+		}
+		return
+	}
+	if len(dropped) > 0 {
+		m.logger.Info("Dropped expired activity completion partitions", slog.Any("partitions", dropped))
+	}
+
+	pruned, err := repo.PruneArchivedActivityCompletions(ctx, m.retentionMonths)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.logger.Error("Failed to prune archived activity completions", slog.Any("error", err))
+		}
+		return
+	}
+	if pruned > 0 {
+		m.logger.Info("Pruned archived activity completions", slog.Int64("completions", pruned))
+	}
+}
@@ -0,0 +1,506 @@
+// Package graphqlapi serves accounts, institutions, roles and the
+// leaderboard over GraphQL.
+//
+// OVERVIEW:
+// Frontends often need nested data, e.g. an account together with its
+// institutions and socials, which takes several round trips over the REST
+// API. POST /api/v1/graphql answers such queries in one request. The
+// GraphQL API reads the same tables as the REST API and is read only.
+//
+// PERMISSIONS:
+// Callers must be authenticated. Permissions are checked per field rather
+// than per request, mirroring the permissions of the REST endpoints serving
+// the same data. A field the caller may not read resolves to null and the
+// response carries an error naming its path, the other fields are still
+// answered.
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Most items a list field returns at once
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
+
+var (
+	// errForbidden resolves the fields the caller may not read
+	errForbidden = errors.New("You do not have the necessary permissions to read this field")
+	// errUnavailable resolves the fields that failed to load, the cause is
+	// logged rather than shown to the caller
+	errUnavailable = errors.New("We couldn't load this field at the moment")
+)
+
+// resolver loads the data behind the schema through the database connection
+// of the request
+type resolver struct {
+	logger *slog.Logger
+}
+
+// NewSchema builds the GraphQL schema
+func NewSchema(logger *slog.Logger) (graphql.Schema, error) {
+	res := &resolver{logger: logger}
+
+	permissionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Permission",
+		Fields: graphql.Fields{
+			"id":   {Type: graphql.NewNonNull(graphql.ID), Resolve: field(func(p repository.RolePermissionsView) any { return p.PermissionID })},
+			"name": {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(p repository.RolePermissionsView) any { return p.PermissionName })},
+		},
+	})
+
+	roleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Role",
+		Fields: graphql.Fields{
+			"id":               {Type: graphql.NewNonNull(graphql.ID), Resolve: field(func(r repository.Role) any { return r.ID })},
+			"name":             {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(r repository.Role) any { return r.Name })},
+			"description":      {Type: graphql.String, Resolve: field(func(r repository.Role) any { return r.Description })},
+			"isDefault":        {Type: graphql.NewNonNull(graphql.Boolean), Resolve: field(func(r repository.Role) any { return r.IsDefault })},
+			"isActive":         {Type: graphql.NewNonNull(graphql.Boolean), Resolve: field(func(r repository.Role) any { return r.IsActive })},
+			"requiresApproval": {Type: graphql.NewNonNull(graphql.Boolean), Resolve: field(func(r repository.Role) any { return r.RequiresApproval })},
+			"institutionId":    {Type: graphql.Int, Resolve: field(func(r repository.Role) any { return r.InstitutionID })},
+			"permissions": {
+				Type:    graphql.NewList(graphql.NewNonNull(permissionType)),
+				Resolve: requirePermission("read:role:any", res.rolePermissions),
+			},
+		},
+	})
+
+	institutionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Institution",
+		Fields: graphql.Fields{
+			"id":             {Type: graphql.NewNonNull(graphql.Int), Resolve: field(func(i repository.Institution) any { return i.InstitutionID })},
+			"name":           {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(i repository.Institution) any { return i.Name })},
+			"webPages":       {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: field(func(i repository.Institution) any { return i.WebPages })},
+			"domains":        {Type: graphql.NewList(graphql.NewNonNull(graphql.String)), Resolve: field(func(i repository.Institution) any { return i.Domains })},
+			"alphaTwoCode":   {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.AlphaTwoCode })},
+			"country":        {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.Country })},
+			"stateProvince":  {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.StateProvince })},
+			"logoUrl":        {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.LogoUrl })},
+			"primaryColor":   {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.PrimaryColor })},
+			"secondaryColor": {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.SecondaryColor })},
+			"website":        {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.Website })},
+			"timezone":       {Type: graphql.String, Resolve: field(func(i repository.Institution) any { return i.Timezone })},
+		},
+	})
+
+	// Tokens of socials are never exposed
+	socialType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Social",
+		Fields: graphql.Fields{
+			"provider":  {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(s repository.Social) any { return s.Provider })},
+			"email":     {Type: graphql.String, Resolve: field(func(s repository.Social) any { return s.Email })},
+			"name":      {Type: graphql.String, Resolve: field(func(s repository.Social) any { return s.Name })},
+			"nickName":  {Type: graphql.String, Resolve: field(func(s repository.Social) any { return s.NickName })},
+			"avatarUrl": {Type: graphql.String, Resolve: field(func(s repository.Social) any { return s.AvatarUrl })},
+		},
+	})
+
+	listArgs := graphql.FieldConfigArgument{
+		"limit":  {Type: graphql.Int, DefaultValue: defaultListLimit},
+		"offset": {Type: graphql.Int, DefaultValue: 0},
+	}
+
+	accountType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Account",
+		Fields: graphql.Fields{
+			"id":         {Type: graphql.NewNonNull(graphql.ID), Resolve: field(func(a repository.Account) any { return a.ID })},
+			"name":       {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(a repository.Account) any { return a.Name })},
+			"username":   {Type: graphql.String, Resolve: field(func(a repository.Account) any { return a.Username })},
+			"avatarUrl":  {Type: graphql.String, Resolve: field(func(a repository.Account) any { return a.AvatarUrl })},
+			"bio":        {Type: graphql.String, Resolve: field(func(a repository.Account) any { return a.Bio })},
+			"type":       {Type: graphql.NewNonNull(graphql.String), Resolve: field(func(a repository.Account) any { return a.Type })},
+			"vibePoints": {Type: graphql.NewNonNull(graphql.Int), Resolve: field(func(a repository.Account) any { return a.VibePoints })},
+			"vibeRank":   {Type: graphql.Int, Resolve: res.vibeRank},
+			"createdAt":  {Type: graphql.DateTime, Resolve: field(func(a repository.Account) any { return timestamp(a.CreatedAt) })},
+			"email": {
+				Type:    graphql.String,
+				Resolve: requireOwnerOrPermission("read:account:own", "read:account:any", field(func(a repository.Account) any { return a.Email })),
+			},
+			"phone": {
+				Type:    graphql.String,
+				Resolve: requireOwnerOrPermission("read:account:own", "read:account:any", field(func(a repository.Account) any { return a.Phone })),
+			},
+			"institutions": {
+				Type:    graphql.NewList(graphql.NewNonNull(institutionType)),
+				Args:    listArgs,
+				Resolve: requireOwnerOrPermission("read:account:own", "read:account:any", res.accountInstitutions),
+			},
+			"socials": {
+				Type:    graphql.NewList(graphql.NewNonNull(socialType)),
+				Resolve: requireOwnerOrPermission("read:account:own", "read:account:any", res.accountSocials),
+			},
+			"roles": {
+				Type:    graphql.NewList(graphql.NewNonNull(roleType)),
+				Resolve: requireOwnerOrPermission("read:account:own", "read:role:any", res.accountRoles),
+			},
+		},
+	})
+
+	leaderboardEntryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LeaderboardEntry",
+		Fields: graphql.Fields{
+			"rank":       {Type: graphql.NewNonNull(graphql.Int), Resolve: field(func(e repository.AccountVibepointRank) any { return e.VibeRank })},
+			"vibePoints": {Type: graphql.NewNonNull(graphql.Int), Resolve: field(func(e repository.AccountVibepointRank) any { return e.VibePoints })},
+			"account": {
+				Type: graphql.NewNonNull(accountType),
+				Resolve: field(func(e repository.AccountVibepointRank) any {
+					return repository.Account{
+						ID:         e.ID,
+						Email:      e.Email,
+						Name:       e.Name,
+						Username:   e.Username,
+						AvatarUrl:  e.AvatarUrl,
+						VibePoints: e.VibePoints,
+						CreatedAt:  e.CreatedAt,
+						UpdatedAt:  e.UpdatedAt,
+						Type:       repository.AccountTypeHuman,
+					}
+				}),
+			},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type:    accountType,
+				Resolve: requirePermission("read:account:own", res.me),
+			},
+			"account": {
+				Type:    accountType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: res.account,
+			},
+			"institution": {
+				Type:    institutionType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: res.institution,
+			},
+			"institutions": {
+				Type:    graphql.NewList(graphql.NewNonNull(institutionType)),
+				Args:    listArgs,
+				Resolve: requirePermission("list:institutions:any", res.institutions),
+			},
+			"role": {
+				Type:    roleType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: requirePermission("read:role:any", res.role),
+			},
+			"roles": {
+				Type:    graphql.NewList(graphql.NewNonNull(roleType)),
+				Args:    listArgs,
+				Resolve: requirePermission("read:role:any", res.roles),
+			},
+			"leaderboard": {
+				Type:    graphql.NewList(graphql.NewNonNull(leaderboardEntryType)),
+				Args:    listArgs,
+				Resolve: res.leaderboard,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// field resolves a field from the value of its parent
+func field[S any](get func(S) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		source, ok := p.Source.(S)
+		if !ok {
+			return nil, nil
+		}
+		return get(source), nil
+	}
+}
+
+// requirePermission lets a field resolve only when the caller holds the
+// permission
+func requirePermission(permission string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if !middleware.CallerHasPermission(p.Context, permission) {
+			return nil, errForbidden
+		}
+		recordUsage(p, permission)
+		return resolve(p)
+	}
+}
+
+// requireOwnerOrPermission lets a field of an account resolve when the
+// account is the caller's own and the caller holds ownPermission, or when the
+// caller holds anyPermission
+func requireOwnerOrPermission(ownPermission, anyPermission string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		account, ok := p.Source.(repository.Account)
+		if !ok {
+			return nil, nil
+		}
+
+		permission := anyPermission
+		if callerID, ok := callerID(p.Context); ok && callerID == account.ID {
+			permission = ownPermission
+		}
+		if !middleware.CallerHasPermission(p.Context, permission) {
+			return nil, errForbidden
+		}
+		recordUsage(p, permission)
+		return resolve(p)
+	}
+}
+
+// recordUsage counts the permission a field was resolved with against the
+// field, e.g. graphql Account.email
+func recordUsage(p graphql.ResolveParams, permission string) {
+	middleware.GetUsageTracker(p.Context).Record(permission, "graphql "+p.Info.ParentType.Name()+"."+p.Info.FieldName)
+}
+
+// callerID returns the id of the authenticated caller
+func callerID(ctx context.Context) (uuid.UUID, bool) {
+	claims, ok := ctx.Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(claims.Subject)
+	return id, err == nil
+}
+
+// repo returns the queries over the database connection of the request
+func (res *resolver) repo(ctx context.Context) (*repository.Queries, error) {
+	conn, err := middleware.GetDBConnFromContext(ctx)
+	if err != nil {
+		res.logger.Error("Error while processing request", slog.Any("error", err))
+		return nil, errUnavailable
+	}
+	return repository.New(conn), nil
+}
+
+// failed logs why a field failed to load. Missing rows resolve to null
+func (res *resolver) failed(p graphql.ResolveParams, err error) (any, error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	res.logger.Error("Failed to resolve GraphQL field",
+		slog.String("request_id", middleware.GetRequestID(p.Context)),
+		slog.String("field", p.Info.ParentType.Name()+"."+p.Info.FieldName),
+		slog.Any("error", err),
+	)
+	return nil, errUnavailable
+}
+
+// page reads the limit and offset arguments of a list field
+func page(p graphql.ResolveParams) (limit, offset int32) {
+	limit, offset = defaultListLimit, 0
+	if value, ok := p.Args["limit"].(int); ok && value > 0 {
+		limit = int32(min(value, maxListLimit))
+	}
+	if value, ok := p.Args["offset"].(int); ok && value > 0 {
+		offset = int32(value)
+	}
+	return limit, offset
+}
+
+// timestamp returns the time of a timestamp, nil when it is not set
+func timestamp(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	return &ts.Time
+}
+
+func (res *resolver) me(p graphql.ResolveParams) (any, error) {
+	if account, ok := p.Context.Value(middleware.AuthUserAccount).(repository.Account); ok {
+		return account, nil
+	}
+	return nil, nil
+}
+
+func (res *resolver) account(p graphql.ResolveParams) (any, error) {
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return nil, errors.New("id must be a UUID")
+	}
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	account, err := repo.GetAccountByID(p.Context, id)
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return account, nil
+}
+
+func (res *resolver) vibeRank(p graphql.ResolveParams) (any, error) {
+	account, ok := p.Source.(repository.Account)
+	if !ok {
+		return nil, nil
+	}
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	rank, err := repo.GetLeaderBoardRankForUser(p.Context, account.ID)
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return rank.VibeRank, nil
+}
+
+func (res *resolver) accountInstitutions(p graphql.ResolveParams) (any, error) {
+	account := p.Source.(repository.Account)
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := page(p)
+	institutions, err := repo.ListInstitutionsForAccount(p.Context, repository.ListInstitutionsForAccountParams{
+		AccountID: account.ID,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return institutions, nil
+}
+
+func (res *resolver) accountSocials(p graphql.ResolveParams) (any, error) {
+	account := p.Source.(repository.Account)
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	socials, err := repo.GetAllAccountSocials(p.Context, account.ID)
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return socials, nil
+}
+
+func (res *resolver) accountRoles(p graphql.ResolveParams) (any, error) {
+	account := p.Source.(repository.Account)
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := repo.GetAllUserRoles(p.Context, account.ID)
+	if err != nil {
+		return res.failed(p, err)
+	}
+
+	roles := make([]repository.Role, 0, len(assigned))
+	for _, userRole := range assigned {
+		role, err := repo.GetRoleByID(p.Context, userRole.RoleID)
+		if err != nil {
+			return res.failed(p, err)
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (res *resolver) rolePermissions(p graphql.ResolveParams) (any, error) {
+	role, ok := p.Source.(repository.Role)
+	if !ok {
+		return nil, nil
+	}
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := repo.GetRolePermissions(p.Context, role.ID)
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return permissions, nil
+}
+
+func (res *resolver) institution(p graphql.ResolveParams) (any, error) {
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	institution, err := repo.GetInstitution(p.Context, int32(p.Args["id"].(int)))
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return institution, nil
+}
+
+func (res *resolver) institutions(p graphql.ResolveParams) (any, error) {
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := page(p)
+	institutions, err := repo.ListInstitutions(p.Context, repository.ListInstitutionsParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return institutions, nil
+}
+
+func (res *resolver) role(p graphql.ResolveParams) (any, error) {
+	id, err := uuid.Parse(p.Args["id"].(string))
+	if err != nil {
+		return nil, errors.New("id must be a UUID")
+	}
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	role, err := repo.GetRoleByID(p.Context, id)
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return role, nil
+}
+
+func (res *resolver) roles(p graphql.ResolveParams) (any, error) {
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := page(p)
+	roles, err := repo.GetAllRoles(p.Context, repository.GetAllRolesParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return roles, nil
+}
+
+func (res *resolver) leaderboard(p graphql.ResolveParams) (any, error) {
+	repo, err := res.repo(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	limit, offset := page(p)
+	entries, err := repo.GetLeaderboard(p.Context, repository.GetLeaderboardParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return res.failed(p, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,214 @@
+// Package grpcapi serves core operations to internal services over gRPC.
+//
+// OVERVIEW:
+// Internal services validate tokens, read accounts and check permissions
+// while serving most of their own requests. The gRPC API serves these
+// operations on a port of its own without the JSON and HTTP/1 overhead of the
+// REST API. proto/verisafe/v1/verisafe.proto defines the service.
+//
+// AUTHENTICATION:
+// Clients authenticate with a TLS client certificate signed by the
+// configured client CA, calls carry no bearer token. A client whose
+// certificate is accepted may call every method. The common name of the
+// certificate names the client in logs and metrics and can be restricted to
+// a list of known clients.
+//
+// TENANCY:
+// When tenancy is enabled calls name their tenant in the metadata key of the
+// tenant header, else they are made to the default tenant. The database
+// connections of a call only see the accounts of its tenant and tokens are
+// only validated for the tenant they were issued for.
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	verisafev1 "github.com/opencrafts-io/verisafe/proto/verisafe/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Context key of the common name of the calling client's certificate
+const clientContextKey = "grpcapi.client"
+
+// Server serves the VerisafeService to internal services
+type Server struct {
+	verisafev1.UnimplementedVerisafeServiceServer
+
+	cfg    *config.Config
+	pool   *pgxpool.Pool
+	engine *authz.Engine
+	cache  *authz.PermissionCache
+	logger *slog.Logger
+
+	server         *grpc.Server
+	allowedClients map[string]bool
+}
+
+// NewServer creates a new Server presenting the configured certificate and
+// requiring client certificates signed by the configured client CA. Call
+// ListenAndServe to start serving
+func NewServer(
+	cfg *config.Config,
+	pool *pgxpool.Pool,
+	engine *authz.Engine,
+	cache *authz.PermissionCache,
+	logger *slog.Logger,
+) (*Server, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.GRPCConfig.CertFile, cfg.GRPCConfig.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the gRPC server certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(cfg.GRPCConfig.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the gRPC client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in the gRPC client CA %s", cfg.GRPCConfig.ClientCAFile)
+	}
+
+	allowedClients := map[string]bool{}
+	for _, client := range cfg.GRPCConfig.AllowedClients {
+		allowedClients[client] = true
+	}
+
+	s := &Server{
+		cfg:            cfg,
+		pool:           pool,
+		engine:         engine,
+		cache:          cache,
+		logger:         logger,
+		allowedClients: allowedClients,
+	}
+
+	s.server = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		})),
+		grpc.ChainUnaryInterceptor(s.intercept),
+	)
+	verisafev1.RegisterVerisafeServiceServer(s.server, s)
+	healthpb.RegisterHealthServer(s.server, health.NewServer())
+
+	return s, nil
+}
+
+// ListenAndServe serves calls on the configured address until Shutdown is
+// called
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.cfg.GRPCConfig.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.GRPCConfig.Address, err)
+	}
+
+	s.logger.Info("gRPC server running", slog.String("address", s.cfg.GRPCConfig.Address))
+	return s.server.Serve(listener)
+}
+
+// Shutdown stops accepting calls and waits for the running calls to end.
+// Calls still running when the context is done are cancelled
+func (s *Server) Shutdown(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// intercept turns away clients that are not allowed to call and calls made to
+// a tenant that is not served, then logs and measures every call
+func (s *Server) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	client := certificateName(ctx)
+
+	var resp any
+	var err error
+	tenant := s.callTenant(ctx)
+	switch {
+	case len(s.allowedClients) > 0 && !s.allowedClients[client]:
+		err = status.Error(codes.PermissionDenied, "This client is not allowed to call Verisafe")
+	case s.cfg.TenancyConfig.Enabled && !s.cfg.IsTenant(tenant):
+		err = status.Error(codes.InvalidArgument, "Unknown tenant")
+	default:
+		ctx = context.WithValue(ctx, clientContextKey, client)
+		if s.cfg.TenancyConfig.Enabled {
+			ctx = tenancy.WithTenant(ctx, tenant)
+		}
+		resp, err = handler(ctx, req)
+	}
+
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	metrics.GRPCRequests.WithLabelValues(info.FullMethod, code.String()).Inc()
+	metrics.GRPCRequestDuration.WithLabelValues(info.FullMethod).Observe(elapsed.Seconds())
+
+	s.logger.Info("gRPC call handled",
+		slog.String("method", info.FullMethod),
+		slog.String("client", client),
+		slog.String("code", code.String()),
+		slog.Int64("duration_ns", elapsed.Nanoseconds()),
+	)
+	return resp, err
+}
+
+// callTenant returns the tenant a call names in its metadata, else the
+// default tenant
+func (s *Server) callTenant(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, tenant := range md.Get(s.cfg.TenancyConfig.Header) {
+		if tenant = strings.TrimSpace(tenant); tenant != "" {
+			return tenant
+		}
+	}
+	return s.cfg.TenancyConfig.DefaultTenant
+}
+
+// certificateName returns the common name of the certificate the client
+// presented
+func certificateName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName
+}
+
+// clientName returns the name of the client making the call
+func clientName(ctx context.Context) string {
+	client, _ := ctx.Value(clientContextKey).(string)
+	return client
+}
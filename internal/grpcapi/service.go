@@ -0,0 +1,244 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+	verisafev1 "github.com/opencrafts-io/verisafe/proto/verisafe/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Most institution members listed at once
+const (
+	defaultMemberLimit = 10
+	maxMemberLimit     = 100
+)
+
+// ValidateToken validates an access token and returns the account it was
+// issued to along with its roles and permissions
+func (s *Server) ValidateToken(ctx context.Context, req *verisafev1.ValidateTokenRequest) (*verisafev1.ValidateTokenResponse, error) {
+	claims, err := utils.ValidateJWT(req.GetToken(), s.cfg.JWTConfig.ApiSecret)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	// Tokens are only honoured by the tenant they were issued for
+	if s.cfg.TenancyConfig.Enabled && claims.Tenant != tenancy.FromContext(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "This token was issued for another tenant")
+	}
+	accountID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "We couldn't decode this token")
+	}
+
+	account, resolved, err := s.loadAuthorization(ctx, accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.Unauthenticated, "The account this token was issued to no longer exists")
+	}
+	if err != nil {
+		return nil, s.internalError("Failed to load account for token", err)
+	}
+	if account.DeactivatedAt != nil {
+		return nil, status.Error(codes.Unauthenticated, "This account has been deactivated")
+	}
+
+	return &verisafev1.ValidateTokenResponse{
+		Account:           toAccount(account),
+		ExpiresAt:         timestamppb.New(claims.ExpiresAt.Time),
+		Roles:             resolved.Roles,
+		Permissions:       resolved.Permissions,
+		DeniedPermissions: resolved.Denied,
+	}, nil
+}
+
+// GetAccount returns an account
+func (s *Server) GetAccount(ctx context.Context, req *verisafev1.GetAccountRequest) (*verisafev1.GetAccountResponse, error) {
+	accountID, err := uuid.Parse(req.GetAccountId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "account_id must be an account id")
+	}
+
+	account, err := repository.New(s.pool).GetAccountByID(ctx, accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, status.Error(codes.NotFound, "Account not found")
+	}
+	if err != nil {
+		return nil, s.internalError("Failed to load account", err)
+	}
+
+	return &verisafev1.GetAccountResponse{Account: toAccount(account)}, nil
+}
+
+// CheckPermission decides whether a subject may perform an action the same
+// way POST /api/v1/authz/check does
+func (s *Server) CheckPermission(ctx context.Context, req *verisafev1.CheckPermissionRequest) (*verisafev1.CheckPermissionResponse, error) {
+	subject, err := uuid.Parse(req.GetSubject())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "subject must be an account id")
+	}
+	if req.GetPermission() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission is required")
+	}
+
+	check := authz.Check{
+		Subject:       subject,
+		Permission:    s.engine.ResolvePermission(req.GetPermission(), clientName(ctx)),
+		InstitutionID: req.InstitutionId,
+	}
+	if req.ResourceOwnerId != nil {
+		ownerID, err := uuid.Parse(req.GetResourceOwnerId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "resource_owner_id must be an account id")
+		}
+		check.ResourceOwnerID = &ownerID
+	}
+
+	allowed, reason, err := s.engine.NewChecker(s.pool).Check(ctx, check)
+	if err != nil {
+		return nil, s.internalError("Failed to evaluate authorization check", err,
+			slog.String("subject", check.Subject.String()),
+			slog.String("permission", check.Permission),
+		)
+	}
+
+	resp := &verisafev1.CheckPermissionResponse{
+		Allowed: allowed,
+		Reason:  reason,
+	}
+	if check.Permission != req.GetPermission() {
+		resp.ReplacedBy = check.Permission
+	}
+	return resp, nil
+}
+
+// ListInstitutionMembers returns the members of an institution ranked by
+// membership role
+func (s *Server) ListInstitutionMembers(ctx context.Context, req *verisafev1.ListInstitutionMembersRequest) (*verisafev1.ListInstitutionMembersResponse, error) {
+	limit := req.GetLimit()
+	switch {
+	case limit < 0:
+		return nil, status.Error(codes.InvalidArgument, "limit may not be negative")
+	case limit == 0:
+		limit = defaultMemberLimit
+	case limit > maxMemberLimit:
+		limit = maxMemberLimit
+	}
+	if req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset may not be negative")
+	}
+
+	repo := repository.New(s.pool)
+	if _, err := repo.GetInstitution(ctx, req.GetInstitutionId()); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Institution not found")
+		}
+		return nil, s.internalError("Failed to load institution", err)
+	}
+
+	members, err := repo.ListInstitutionMembers(ctx, repository.ListInstitutionMembersParams{
+		InstitutionID: req.GetInstitutionId(),
+		Limit:         limit,
+		Offset:        req.GetOffset(),
+	})
+	if err != nil {
+		return nil, s.internalError("Failed to list institution members", err)
+	}
+
+	total, err := repo.CountInstitutionMembers(ctx, req.GetInstitutionId())
+	if err != nil {
+		return nil, s.internalError("Failed to count institution members", err)
+	}
+
+	resp := &verisafev1.ListInstitutionMembersResponse{
+		Members: make([]*verisafev1.InstitutionMember, 0, len(members)),
+		Total:   total,
+	}
+	for _, member := range members {
+		resp.Members = append(resp.Members, &verisafev1.InstitutionMember{
+			AccountId:      member.AccountID.String(),
+			Name:           member.Name,
+			Username:       member.Username,
+			AvatarUrl:      member.AvatarUrl,
+			MembershipRole: string(member.MembershipRole),
+		})
+	}
+	return resp, nil
+}
+
+// loadAuthorization returns an account along with its roles and permissions.
+// Permissions are taken from the permission cache when fresh, otherwise they
+// are loaded with the account in a single query and cached unless the
+// account is deactivated
+func (s *Server) loadAuthorization(ctx context.Context, accountID uuid.UUID) (repository.Account, authz.ResolvedPermissions, error) {
+	repo := repository.New(s.pool)
+	if resolved, ok := s.cache.Get(accountID); ok {
+		account, err := repo.GetAccountByID(ctx, accountID)
+		return account, resolved, err
+	}
+
+	authorization, err := repo.GetAccountAuthorization(ctx, accountID)
+	if err != nil {
+		return repository.Account{}, authz.ResolvedPermissions{}, err
+	}
+	resolved := authz.ResolvedPermissions{
+		Roles:       authorization.Roles,
+		Permissions: authorization.Permissions,
+		Denied:      authorization.DeniedPermissions,
+	}
+	if authorization.Account.DeactivatedAt == nil {
+		s.cache.Set(accountID, resolved)
+	}
+	return authorization.Account, resolved, nil
+}
+
+// internalError logs the cause of a failed call and returns the error shown
+// to the client in its place
+func (s *Server) internalError(msg string, err error, attrs ...any) error {
+	s.logger.Error(msg, append([]any{slog.Any("error", err)}, attrs...)...)
+	return status.Error(codes.Internal, "We couldn't complete this request at the moment please try again later")
+}
+
+// toAccount converts an account to its protobuf message
+func toAccount(account repository.Account) *verisafev1.Account {
+	return &verisafev1.Account{
+		Id:            account.ID.String(),
+		Email:         account.Email,
+		Name:          account.Name,
+		Username:      account.Username,
+		AvatarUrl:     account.AvatarUrl,
+		Bio:           account.Bio,
+		Phone:         account.Phone,
+		Type:          string(account.Type),
+		VibePoints:    account.VibePoints,
+		CreatedAt:     timestamp(account.CreatedAt),
+		UpdatedAt:     timestamp(account.UpdatedAt),
+		DeletedAt:     optionalTimestamp(account.DeletedAt),
+		DeactivatedAt: optionalTimestamp(account.DeactivatedAt),
+	}
+}
+
+// timestamp converts a database timestamp, nil when it is null
+func timestamp(t pgtype.Timestamp) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
+
+// optionalTimestamp converts an optional time, nil when it is unset
+func optionalTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,220 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+	verisafev1 "github.com/opencrafts-io/verisafe/proto/verisafe/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newTestConfig returns the configuration of an instance serving the acme
+// and globex tenants
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.JWTConfig.ApiSecret = "test-secret"
+	cfg.JWTConfig.ExpireDelta = 1
+	cfg.TenancyConfig.Enabled = true
+	cfg.TenancyConfig.DefaultTenant = "acme"
+	cfg.TenancyConfig.Tenants = []string{"globex"}
+	cfg.TenancyConfig.Header = "X-Tenant-ID"
+	return cfg
+}
+
+// call makes a call to the tenant through the interceptor, an empty tenant
+// names none
+func call[Resp any](s *Server, tenant string, method func(context.Context) (Resp, error)) (Resp, error) {
+	ctx := context.Background()
+	if tenant != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-tenant-id", tenant))
+	}
+
+	var zero Resp
+	resp, err := s.intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test"},
+		func(ctx context.Context, _ any) (any, error) {
+			return method(ctx)
+		})
+	if err != nil {
+		return zero, err
+	}
+	return resp.(Resp), nil
+}
+
+func TestInterceptResolvesTenant(t *testing.T) {
+	s := &Server{cfg: newTestConfig(), logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	tenantOf := func(ctx context.Context) (string, error) {
+		return tenancy.FromContext(ctx), nil
+	}
+
+	tests := []struct {
+		name   string
+		tenant string
+		want   string
+		code   codes.Code
+	}{
+		{"named tenant", "globex", "globex", codes.OK},
+		{"default tenant", "", "acme", codes.OK},
+		{"unknown tenant", "initech", "", codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := call(s, tt.tenant, tenantOf)
+			if status.Code(err) != tt.code {
+				t.Fatalf("Expected %s, got %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected the call to be made to %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// newTestServer returns a server whose connections only see the accounts of
+// the tenant of the call, along with an acme account
+func newTestServer(t *testing.T) (*Server, repository.Account) {
+	t.Helper()
+	ctx := context.Background()
+	cfg := newTestConfig()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	admin := testdb.New(t)
+
+	tx, err := admin.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Could not begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+	if err := repo.SetCurrentTenant(ctx, "acme"); err != nil {
+		t.Fatalf("Could not switch to acme: %v", err)
+	}
+	account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+		Email: "ada@acme.example",
+		Name:  "Ada",
+		Type:  repository.AccountTypeHuman,
+	})
+	if err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Could not commit account: %v", err)
+	}
+
+	poolConfig := testdb.Unprivileged(t, admin)
+	tenancy.ConfigurePool(poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("Could not open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return &Server{
+		cfg:    cfg,
+		pool:   pool,
+		engine: authz.NewEngine(cfg, pool, logger),
+		logger: logger,
+	}, account
+}
+
+func TestValidateTokenIsScopedToTenant(t *testing.T) {
+	s, account := newTestServer(t)
+
+	issue := func(tenant string) string {
+		token, err := utils.GenerateJWT(account.ID, tenant, *s.cfg)
+		if err != nil {
+			t.Fatalf("Could not issue token: %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		tenant string
+		code   codes.Code
+	}{
+		{"own tenant", issue("acme"), "acme", codes.OK},
+		{"another tenant", issue("acme"), "globex", codes.Unauthenticated},
+		// The claim matches the call, but the account cannot be seen from
+		// the tenant
+		{"claim of another tenant", issue("globex"), "globex", codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := call(s, tt.tenant, func(ctx context.Context) (*verisafev1.ValidateTokenResponse, error) {
+				return s.ValidateToken(ctx, &verisafev1.ValidateTokenRequest{Token: tt.token})
+			})
+			if status.Code(err) != tt.code {
+				t.Fatalf("Expected %s, got %v", tt.code, err)
+			}
+			if err == nil && resp.GetAccount().GetId() != account.ID.String() {
+				t.Errorf("Expected the account %s, got %s", account.ID, resp.GetAccount().GetId())
+			}
+		})
+	}
+}
+
+func TestGetAccountIsScopedToTenant(t *testing.T) {
+	s, account := newTestServer(t)
+
+	tests := []struct {
+		tenant string
+		code   codes.Code
+	}{
+		{"acme", codes.OK},
+		{"globex", codes.NotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			_, err := call(s, tt.tenant, func(ctx context.Context) (*verisafev1.GetAccountResponse, error) {
+				return s.GetAccount(ctx, &verisafev1.GetAccountRequest{AccountId: account.ID.String()})
+			})
+			if status.Code(err) != tt.code {
+				t.Errorf("Expected %s, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestCheckPermissionIsScopedToTenant(t *testing.T) {
+	s, account := newTestServer(t)
+
+	tests := []struct {
+		tenant string
+		reason string
+	}{
+		{"globex", authz.ReasonUnknownSubject},
+		{"acme", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tenant, func(t *testing.T) {
+			resp, err := call(s, tt.tenant, func(ctx context.Context) (*verisafev1.CheckPermissionResponse, error) {
+				return s.CheckPermission(ctx, &verisafev1.CheckPermissionRequest{
+					Subject:    account.ID.String(),
+					Permission: "read:account:" + uuid.NewString(),
+				})
+			})
+			if err != nil {
+				t.Fatalf("Could not check permission: %v", err)
+			}
+			if tt.reason != "" && resp.GetReason() != tt.reason {
+				t.Errorf("Expected the reason %q, got %q", tt.reason, resp.GetReason())
+			}
+			if tt.reason == "" && resp.GetReason() == authz.ReasonUnknownSubject {
+				t.Errorf("Expected the subject to be known to its tenant")
+			}
+		})
+	}
+}
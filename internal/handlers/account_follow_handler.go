@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// followPathValues parses the caller and the account in the path of a follow
+// request. It writes the error response and reports false if either is invalid
+func followPathValues(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't identify your account please sign in again",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if callerID == accountID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You cannot follow your own account",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return callerID, accountID, true
+}
+
+// Follows another account so that it shows up on the caller's friends
+// leaderboard. Following an account twice has no effect
+func (ah *AccountHandler) FollowAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	callerID, accountID, ok := followPathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	account, err := repo.GetAccountByID(r.Context(), accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The account you want to follow does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if account.Type != repository.AccountTypeHuman {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only human accounts can be followed",
+		})
+		return
+	}
+
+	followed, err := repo.FollowAccount(r.Context(), repository.FollowAccountParams{
+		FollowerID: callerID,
+		FolloweeID: accountID,
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to follow account",
+			slog.Any("error", err),
+			slog.String("follower_id", callerID.String()),
+			slog.String("followee_id", accountID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if followed == 0 {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"message": "You are now following this account"})
+}
+
+// Stops following another account
+func (ah *AccountHandler) UnfollowAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	callerID, accountID, ok := followPathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	removed, err := repo.UnfollowAccount(r.Context(), repository.UnfollowAccountParams{
+		FollowerID: callerID,
+		FolloweeID: accountID,
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to unfollow account",
+			slog.Any("error", err),
+			slog.String("follower_id", callerID.String()),
+			slog.String("followee_id", accountID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You are not following this account",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "You are no longer following this account"})
+}
+
+// Returns how many accounts follow an account and how many it follows
+func (ah *AccountHandler) GetFollowCounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	counts, err := repo.GetFollowCounts(r.Context(), accountID)
+	if err != nil {
+		ah.Logger.Error("Failed to count follows", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(counts)
+}
@@ -0,0 +1,500 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Actions a guardian may restrict on a managed account
+const (
+	// The managed account may not update its own profile
+	GuardianRestrictionProfileUpdates = "profile_updates"
+	// The managed account may not deactivate itself
+	GuardianRestrictionAccountDeactivation = "account_deactivation"
+	// The managed account may not request its own deletion
+	GuardianRestrictionAccountDeletion = "account_deletion"
+)
+
+var supportedGuardianRestrictions = []string{
+	GuardianRestrictionProfileUpdates,
+	GuardianRestrictionAccountDeactivation,
+	GuardianRestrictionAccountDeletion,
+}
+
+// normalizeGuardianRestrictions validates, lower cases and de-duplicates the
+// requested restrictions. It reports false if any of them is not supported
+func normalizeGuardianRestrictions(restrictions []string) ([]string, bool) {
+	normalized := []string{}
+	for _, restriction := range restrictions {
+		restriction = strings.ToLower(strings.TrimSpace(restriction))
+		if !slices.Contains(supportedGuardianRestrictions, restriction) {
+			return nil, false
+		}
+		if !slices.Contains(normalized, restriction) {
+			normalized = append(normalized, restriction)
+		}
+	}
+	return normalized, true
+}
+
+// isAccountRestricted reports whether a guardian restricted the account from
+// performing the given action on its own
+func isAccountRestricted(ctx context.Context, repo *repository.Queries, accountID uuid.UUID, restriction string) (bool, error) {
+	return repo.IsAccountRestricted(ctx, repository.IsAccountRestrictedParams{
+		ManagedID:   accountID,
+		Restriction: restriction,
+	})
+}
+
+// CreateGuardianLinkRequest is the body expected when linking a guardian to a
+// managed account
+type CreateGuardianLinkRequest struct {
+	GuardianID   uuid.UUID `json:"guardian_id" validate:"required"`
+	ManagedID    uuid.UUID `json:"managed_id" validate:"required"`
+	Relationship string    `json:"relationship" validate:"max=50"`
+	Restrictions []string  `json:"restrictions"`
+}
+
+// UpdateGuardianRestrictionsRequest is the body expected when a guardian
+// changes the restrictions placed on a managed account
+type UpdateGuardianRestrictionsRequest struct {
+	Restrictions []string `json:"restrictions"`
+}
+
+// Links a guardian to a managed account
+func (ah *AccountHandler) CreateGuardianLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	var req CreateGuardianLinkRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if req.GuardianID == req.ManagedID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "An account cannot be its own guardian",
+		})
+		return
+	}
+
+	req.Relationship = strings.ToLower(strings.TrimSpace(req.Relationship))
+	if req.Relationship == "" {
+		req.Relationship = "guardian"
+	}
+
+	restrictions, ok := normalizeGuardianRestrictions(req.Restrictions)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":                  "One or more of the provided restrictions is not supported",
+			"supported_restrictions": supportedGuardianRestrictions,
+		})
+		return
+	}
+
+	var createdBy pgtype.UUID
+	if id, err := uuid.Parse(claims.Subject); err == nil {
+		createdBy = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	for _, id := range []uuid.UUID{req.GuardianID, req.ManagedID} {
+		account, err := repo.GetAccountByID(r.Context(), id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Both the guardian and the managed account must exist",
+			})
+			return
+		}
+		if err != nil {
+			ah.Logger.Error("Failed to retrieve account", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		if account.Type != repository.AccountTypeHuman {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Guardian links may only be created between human accounts",
+			})
+			return
+		}
+	}
+
+	link, err := repo.CreateGuardianLink(r.Context(), repository.CreateGuardianLinkParams{
+		GuardianID:   req.GuardianID,
+		ManagedID:    req.ManagedID,
+		Relationship: req.Relationship,
+		Restrictions: restrictions,
+		CreatedBy:    createdBy,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This guardian is already linked to the account",
+			})
+			return
+		}
+		ah.Logger.Error("Failed to create guardian link",
+			slog.Any("error", err),
+			slog.String("guardian_id", req.GuardianID.String()),
+			slog.String("managed_id", req.ManagedID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't link the guardian at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// Removes a guardian from a managed account
+func (ah *AccountHandler) DeleteGuardianLink(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	managedID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	guardianID, ok := utils.PathUUID(w, r, "guardian_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	removed, err := repo.DeleteGuardianLink(r.Context(), repository.DeleteGuardianLinkParams{
+		GuardianID: guardianID,
+		ManagedID:  managedID,
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to remove guardian link",
+			slog.Any("error", err),
+			slog.String("guardian_id", guardianID.String()),
+			slog.String("managed_id", managedID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This account is not managed by the specified guardian",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Guardian successfully removed from account"})
+}
+
+// Retrieves the guardians of any account
+func (ah *AccountHandler) GetAccountGuardians(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	ah.writeAccountGuardians(w, r, accountID)
+}
+
+// Retrieves the guardians of the caller's account
+func (ah *AccountHandler) GetPersonalGuardians(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	accountID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	ah.writeAccountGuardians(w, r, accountID)
+}
+
+// writeAccountGuardians responds with the guardians of the account
+func (ah *AccountHandler) writeAccountGuardians(w http.ResponseWriter, r *http.Request, accountID uuid.UUID) {
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	guardians, err := repo.GetAccountGuardians(r.Context(), accountID)
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account guardians",
+			slog.Any("error", err),
+			slog.String("account_id", accountID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(guardians)
+}
+
+// Retrieves the accounts managed by the caller
+func (ah *AccountHandler) GetManagedAccounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	guardianID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	managed, err := repo.GetManagedAccounts(r.Context(), guardianID)
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve managed accounts",
+			slog.Any("error", err),
+			slog.String("guardian_id", guardianID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(managed)
+}
+
+// Retrieves a single account managed by the caller
+func (ah *AccountHandler) GetManagedAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	guardianID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	managedID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	link, err := repo.GetGuardianLink(r.Context(), repository.GetGuardianLinkParams{
+		GuardianID: guardianID,
+		ManagedID:  managedID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You do not manage the specified account",
+		})
+		return
+	}
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve guardian link", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	account, err := repo.GetAccountByID(r.Context(), managedID)
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve managed account", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"account":      account,
+		"relationship": link.Relationship,
+		"restrictions": link.Restrictions,
+	})
+}
+
+// Replaces the restrictions the caller placed on an account they manage
+func (ah *AccountHandler) UpdateManagedAccountRestrictions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	guardianID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	managedID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateGuardianRestrictionsRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	restrictions, ok := normalizeGuardianRestrictions(req.Restrictions)
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":                  "One or more of the provided restrictions is not supported",
+			"supported_restrictions": supportedGuardianRestrictions,
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	link, err := repo.UpdateGuardianRestrictions(r.Context(), repository.UpdateGuardianRestrictionsParams{
+		GuardianID:   guardianID,
+		ManagedID:    managedID,
+		Restrictions: restrictions,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You do not manage the specified account",
+		})
+		return
+	}
+	if err != nil {
+		ah.Logger.Error("Failed to update guardian restrictions",
+			slog.Any("error", err),
+			slog.String("guardian_id", guardianID.String()),
+			slog.String("managed_id", managedID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't update the restrictions at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(link)
+}
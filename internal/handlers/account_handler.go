@@ -14,10 +14,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/opencrafts-io/verisafe/internal/config"
 	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
 	"github.com/opencrafts-io/verisafe/internal/repository"
 	"github.com/opencrafts-io/verisafe/internal/utils"
 )
@@ -29,62 +31,68 @@ type AccountHandler struct {
 }
 
 func (ah *AccountHandler) RegisterHandlers(router *http.ServeMux) {
-	router.Handle("POST /accounts/bot/create",
+	router.Handle("POST /api/v1/accounts/bot/create",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"create:account:any"}),
 		)(http.HandlerFunc(ah.CreateBotAccount)),
 	)
 
-	router.Handle("GET /accounts/fanout",
+	router.Handle("GET /api/v1/accounts/fanout",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"create:account:any"}),
 		)(http.HandlerFunc(ah.FanoutAccouts)),
 	)
 
-	router.Handle("GET /accounts/me",
+	router.Handle("GET /api/v1/accounts/me",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"read:account:own"}),
 		)(http.HandlerFunc(ah.GetPersonalAccount)),
 	)
 
-	router.Handle("GET /accounts/all",
+	router.Handle("GET /api/v1/accounts/all",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"read:account:any"}),
 		)(http.HandlerFunc(ah.GetAllUserAccounts)),
 	)
 
-	router.Handle("PATCH /accounts/me",
+	router.Handle("PATCH /api/v1/accounts/me",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"update:account:own"}),
 		)(http.HandlerFunc(ah.UpdatePersonalAccount)),
 	)
 
-	router.Handle("POST /accounts/deletion-request",
+	router.Handle("POST /api/v1/accounts/deletion-request",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"update:account:own"}),
 		)(http.HandlerFunc(ah.MarkAccountForDeletion)),
 	)
-	router.Handle("POST /accounts/recovery",
+	router.Handle("POST /api/v1/accounts/me/deactivate",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"update:account:own"}),
+		)(http.HandlerFunc(ah.DeactivatePersonalAccount)),
+	)
+	router.Handle("POST /api/v1/accounts/recovery",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"update:account:own"}),
 		)(http.HandlerFunc(ah.RecoverAccountFromDeletion)),
 	)
 
-	router.Handle("PATCH /accounts/me/phone",
+	router.Handle("PATCH /api/v1/accounts/me/phone",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"update:account:own"}),
 		)(http.HandlerFunc(ah.VerifyPhone)),
 	)
 
-	router.Handle("GET /accounts/search/email",
+	router.Handle("GET /api/v1/accounts/search/email",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"read:account:any"}),
@@ -92,7 +100,7 @@ func (ah *AccountHandler) RegisterHandlers(router *http.ServeMux) {
 		)(http.HandlerFunc(ah.SearchAccountsByEmail)),
 	)
 
-	router.Handle("GET /accounts/search/name",
+	router.Handle("GET /api/v1/accounts/search/name",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"read:account:any"}),
@@ -100,30 +108,147 @@ func (ah *AccountHandler) RegisterHandlers(router *http.ServeMux) {
 		)(http.HandlerFunc(ah.SearchAccountsByName)),
 	)
 
-	router.Handle("GET /accounts/search/username",
+	router.Handle("GET /api/v1/accounts/search/username",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
 			middleware.HasPermission([]string{"read:account:any"}),
 			middleware.PaginationMiddleware(10, 100),
 		)(http.HandlerFunc(ah.SearchAccountsByUsername)),
 	)
+
+	router.Handle("POST /api/v1/accounts/{id}/convert-type",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"update:account:type"}),
+		)(http.HandlerFunc(ah.ConvertAccountType)),
+	)
+
+	router.Handle("GET /api/v1/accounts/{id}/type-transitions",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account:type_transitions"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(ah.GetAccountTypeTransitions)),
+	)
+
+	router.Handle("GET /api/v1/accounts/tags",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account_tag:any"}),
+		)(http.HandlerFunc(ah.GetAllAccountTags)),
+	)
+
+	router.Handle("GET /api/v1/accounts/{id}/tags",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account_tag:any"}),
+		)(http.HandlerFunc(ah.GetAccountTags)),
+	)
+
+	router.Handle("POST /api/v1/accounts/{id}/tags",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"create:account_tag:any"}),
+		)(http.HandlerFunc(ah.AddAccountTags)),
+	)
+
+	router.Handle("DELETE /api/v1/accounts/{id}/tags/{tag}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"delete:account_tag:any"}),
+		)(http.HandlerFunc(ah.RemoveAccountTag)),
+	)
+
+	router.Handle("POST /api/v1/accounts/guardians",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"create:guardian_link:any"}),
+		)(http.HandlerFunc(ah.CreateGuardianLink)),
+	)
+
+	router.Handle("GET /api/v1/accounts/{id}/guardians",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:guardian_link:any"}),
+		)(http.HandlerFunc(ah.GetAccountGuardians)),
+	)
+
+	router.Handle("DELETE /api/v1/accounts/{id}/guardians/{guardian_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"delete:guardian_link:any"}),
+		)(http.HandlerFunc(ah.DeleteGuardianLink)),
+	)
+
+	router.Handle("GET /api/v1/accounts/me/guardians",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account:own"}),
+		)(http.HandlerFunc(ah.GetPersonalGuardians)),
+	)
+
+	router.Handle("GET /api/v1/accounts/me/managed",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account:own"}),
+		)(http.HandlerFunc(ah.GetManagedAccounts)),
+	)
+
+	router.Handle("GET /api/v1/accounts/me/managed/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account:own"}),
+		)(http.HandlerFunc(ah.GetManagedAccount)),
+	)
+
+	router.Handle("PUT /api/v1/accounts/me/managed/{id}/restrictions",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"update:account:own"}),
+		)(http.HandlerFunc(ah.UpdateManagedAccountRestrictions)),
+	)
+
+	router.Handle("POST /api/v1/accounts/{id}/follow",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+		)(http.HandlerFunc(ah.FollowAccount)),
+	)
+
+	router.Handle("DELETE /api/v1/accounts/{id}/follow",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+		)(http.HandlerFunc(ah.UnfollowAccount)),
+	)
+
+	router.Handle("GET /api/v1/accounts/{id}/follows",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+		)(http.HandlerFunc(ah.GetFollowCounts)),
+	)
+
+	router.Handle("GET /api/v1/admin/accounts/stats",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ah.Cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:account_stats:any"}),
+		)(http.HandlerFunc(ah.GetAccountStats)),
+	)
 }
 
 // BotAccountRequest represents the request to create a bot account with enhanced service token
 type BotAccountRequest struct {
 	Account struct {
 		Email     string  `json:"email" validate:"required,email"`
-		Name      string  `json:"name" validate:"required,min=1,max=100"`
+		Name      string  `json:"name" validate:"notblank,max=100"`
 		AvatarUrl *string `json:"avatar_url"`
 	} `json:"account"`
 	ServiceToken struct {
-		Name             string          `json:"name" validate:"required,min=1,max=100"`
+		Name             string          `json:"name" validate:"notblank,max=100"`
 		Description      *string         `json:"description"`
 		ExpiresInDays    *int            `json:"expires_in_days" validate:"omitempty,min=1,max=3650"`
 		Scopes           []string        `json:"scopes"`
 		MaxUses          *int            `json:"max_uses" validate:"omitempty,min=1"`
 		RotationPolicy   *RotationPolicy `json:"rotation_policy"`
-		IPWhitelist      []string        `json:"ip_whitelist"`
+		IPWhitelist      []string        `json:"ip_whitelist" validate:"dive,ipv4"`
 		UserAgentPattern *string         `json:"user_agent_pattern"`
 		Metadata         map[string]any  `json:"metadata"`
 	} `json:"service_token"`
@@ -164,28 +289,14 @@ func (ah *AccountHandler) CreateBotAccount(w http.ResponseWriter, r *http.Reques
 
 	// Parse request
 	var req BotAccountRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ah.Logger.Error("Failed to parse request body", slog.String("error", err.Error()))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Invalid request body",
-		})
-		return
-	}
-
-	// Validate request
-	if req.Account.Email == "" || req.Account.Name == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Email and name are required",
-		})
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	if req.ServiceToken.Name == "" {
+	if utils.IsEmailDomainBlocked(req.Account.Email, ah.Cfg.AuthenticationConfig.BlockedEmailDomains) {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Service token name is required",
+			"error": "Accounts cannot be created with this email domain",
 		})
 		return
 	}
@@ -215,13 +326,21 @@ func (ah *AccountHandler) CreateBotAccount(w http.ResponseWriter, r *http.Reques
 
 	// Create bot account
 	accData := repository.CreateAccountParams{
-		Email:     req.Account.Email,
+		Email:     utils.NormalizeEmail(req.Account.Email),
 		Name:      req.Account.Name,
 		Type:      repository.AccountTypeBot,
 		AvatarUrl: req.Account.AvatarUrl,
 	}
 
 	created, err := repo.CreateAccount(r.Context(), accData)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "An account with this email already exists",
+		})
+		return
+	}
 	if err != nil {
 		ah.Logger.Error("Failed to create account",
 			slog.Any("error", err),
@@ -502,9 +621,7 @@ func (ah *AccountHandler) GetPersonalAccount(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
@@ -534,20 +651,31 @@ func (ah *AccountHandler) GetPersonalAccount(w http.ResponseWriter, r *http.Requ
 		})
 		return
 	}
+	w.Header().Set("ETag", utils.VersionETag(user.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(user)
 }
 
 func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.Request) {
 	var accData repository.UpdateAccountDetailsParams
-	if err := json.NewDecoder(r.Body).Decode(&accData); err != nil || accData.Name == "" {
-		ah.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &accData) {
 		return
 	}
+	version, ok := utils.ExpectedVersion(w, r, accData.Version)
+	if !ok {
+		return
+	}
+	accData.Version = version
+	if accData.Email != "" {
+		accData.Email = utils.NormalizeEmail(accData.Email)
+		if utils.IsEmailDomainBlocked(accData.Email, ah.Cfg.AuthenticationConfig.BlockedEmailDomains) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This email domain is not allowed",
+			})
+			return
+		}
+	}
 	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
 
 	// Check if the user is indeed the owner of the account
@@ -574,7 +702,24 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 	defer tx.Rollback(r.Context())
 	repo := repository.New(tx)
 
-	err = repo.UpdateAccountDetails(r.Context(), accData)
+	restricted, err := isAccountRestricted(r.Context(), repo, accData.ID, GuardianRestrictionProfileUpdates)
+	if err != nil {
+		ah.Logger.Error("Failed to check guardian restrictions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	if restricted {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Your guardian has restricted profile updates on this account",
+		})
+		return
+	}
+
+	updatedRows, err := repo.UpdateAccountDetails(r.Context(), accData)
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -584,6 +729,13 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 		return
 	}
 	updated, err := repo.GetAccountByID(r.Context(), accData.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Account not found",
+		})
+		return
+	}
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -592,6 +744,17 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 		})
 		return
 	}
+	// Nothing was updated although the account exists, so it is no longer
+	// at the version the client expected
+	if updatedRows == 0 {
+		w.Header().Set("ETag", utils.VersionETag(updated.Version))
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":   "Your account was updated by someone else, fetch it again and retry",
+			"version": updated.Version,
+		})
+		return
+	}
 
 	if err = tx.Commit(r.Context()); err != nil {
 		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
@@ -603,7 +766,7 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 	}
 
 	go func() {
-		eventRequestID := eventbus.GenerateRequestID()
+		eventRequestID := middleware.GetRequestID(r.Context())
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -619,6 +782,7 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 
 	}()
 
+	w.Header().Set("ETag", utils.VersionETag(updated.Version))
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updated)
 }
@@ -627,12 +791,7 @@ func (ah *AccountHandler) UpdatePersonalAccount(w http.ResponseWriter, r *http.R
 // Use a provider such as AT or One Signal etc
 func (ah *AccountHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
 	var accData repository.UpdateAccountPhoneNumberParams
-	if err := json.NewDecoder(r.Body).Decode(&accData); err != nil || len(accData.Phone) < 5 {
-		ah.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &accData) {
 		return
 	}
 	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
@@ -688,7 +847,7 @@ func (ah *AccountHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	go func() {
-		eventRequestID := eventbus.GenerateRequestID()
+		eventRequestID := middleware.GetRequestID(r.Context())
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -705,7 +864,18 @@ func (ah *AccountHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(updated)
 }
 
-// SearchAccountsByEmail handles searching for accounts by email address
+// searchTagFilter returns the optional "tag" query parameter used to narrow
+// account searches down to accounts carrying that tag
+func searchTagFilter(r *http.Request) *string {
+	tag := normalizeAccountTag(r.URL.Query().Get("tag"))
+	if tag == "" {
+		return nil
+	}
+	return &tag
+}
+
+// SearchAccountsByEmail handles searching for accounts by email address,
+// tolerating typos and listing the closest matches first
 func (ah *AccountHandler) SearchAccountsByEmail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -723,7 +893,7 @@ func (ah *AccountHandler) SearchAccountsByEmail(w http.ResponseWriter, r *http.R
 	pagination := middleware.GetPagination(r.Context())
 
 	// Get database connection
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -733,25 +903,14 @@ func (ah *AccountHandler) SearchAccountsByEmail(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Begin transaction
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-	defer tx.Rollback(r.Context())
-
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Search accounts by email
 	accounts, err := repo.SearchAccountByEmail(r.Context(), repository.SearchAccountByEmailParams{
 		Email:  query,
 		Limit:  int32(pagination.Limit),
 		Offset: int32(pagination.Offset),
+		Tag:    searchTagFilter(r),
 	})
 	if err != nil {
 		ah.Logger.Error("Failed to search accounts by email", slog.Any("error", err))
@@ -762,16 +921,6 @@ func (ah *AccountHandler) SearchAccountsByEmail(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Commit transaction
-	if err = tx.Commit(r.Context()); err != nil {
-		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-
 	// Prepare response
 	response := map[string]any{
 		"accounts": accounts,
@@ -788,7 +937,8 @@ func (ah *AccountHandler) SearchAccountsByEmail(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(response)
 }
 
-// SearchAccountsByName handles searching for accounts by name
+// SearchAccountsByName handles searching for accounts by name, tolerating
+// typos and listing the closest matches first
 func (ah *AccountHandler) SearchAccountsByName(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -806,7 +956,7 @@ func (ah *AccountHandler) SearchAccountsByName(w http.ResponseWriter, r *http.Re
 	pagination := middleware.GetPagination(r.Context())
 
 	// Get database connection
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -816,25 +966,14 @@ func (ah *AccountHandler) SearchAccountsByName(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Begin transaction
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-	defer tx.Rollback(r.Context())
-
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Search accounts by name
 	accounts, err := repo.SearchAccountByName(r.Context(), repository.SearchAccountByNameParams{
 		Name:   query,
 		Limit:  int32(pagination.Limit),
 		Offset: int32(pagination.Offset),
+		Tag:    searchTagFilter(r),
 	})
 	if err != nil {
 		ah.Logger.Error("Failed to search accounts by name", slog.Any("error", err))
@@ -845,16 +984,6 @@ func (ah *AccountHandler) SearchAccountsByName(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Commit transaction
-	if err = tx.Commit(r.Context()); err != nil {
-		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-
 	// Prepare response
 	response := map[string]any{
 		"accounts": accounts,
@@ -873,10 +1002,14 @@ func (ah *AccountHandler) SearchAccountsByName(w http.ResponseWriter, r *http.Re
 
 func (ah *AccountHandler) GetAllUserAccounts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if pagination.UsesCursor(r) {
+		ah.getAccountsPage(w, r)
+		return
+	}
 	// Get pagination from context
 	pagination := middleware.GetPagination(r.Context())
 	// Get database connection
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -886,19 +1019,7 @@ func (ah *AccountHandler) GetAllUserAccounts(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Begin transaction
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-	defer tx.Rollback(r.Context())
-
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Search accounts by username
 	accounts, err := repo.GetAllAccounts(r.Context(), repository.GetAllAccountsParams{
@@ -914,9 +1035,25 @@ func (ah *AccountHandler) GetAllUserAccounts(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Commit transaction
-	if err = tx.Commit(r.Context()); err != nil {
-		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// getAccountsPage serves GetAllUserAccounts to clients paging with a cursor,
+// which stays fast however deep they page
+func (ah *AccountHandler) getAccountsPage(w http.ResponseWriter, r *http.Request) {
+	params, err := pagination.ParseCursorParams(r, 10, 100)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The cursor is invalid, use the next_cursor of a previous page",
+		})
+		return
+	}
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "We ran into a problem while servicing your request please try again later",
@@ -924,11 +1061,29 @@ func (ah *AccountHandler) GetAllUserAccounts(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	accounts, err := repository.New(conn).ListAccountsAfter(r.Context(), repository.ListAccountsAfterParams{
+		AfterCreatedAt: params.AfterCreatedAt(),
+		AfterID:        params.AfterID(),
+		PageSize:       params.FetchLimit(),
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to get accounts page", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	accounts, next := pagination.CursorPage(accounts, params, func(account repository.Account) pagination.Cursor {
+		return pagination.NewCursor(account.CreatedAt, account.ID)
+	})
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(accounts)
+	json.NewEncoder(w).Encode(pagination.BuildCursorResponse(r, accounts, params, next))
 }
 
-// SearchAccountsByUsername handles searching for accounts by username
+// SearchAccountsByUsername handles searching for accounts by username,
+// tolerating typos and listing the closest matches first
 func (ah *AccountHandler) SearchAccountsByUsername(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -946,7 +1101,7 @@ func (ah *AccountHandler) SearchAccountsByUsername(w http.ResponseWriter, r *htt
 	pagination := middleware.GetPagination(r.Context())
 
 	// Get database connection
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ah.Logger.Error("Error while processing request", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -956,25 +1111,14 @@ func (ah *AccountHandler) SearchAccountsByUsername(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Begin transaction
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-	defer tx.Rollback(r.Context())
-
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Search accounts by username
 	accounts, err := repo.SearchAccountByUsername(r.Context(), repository.SearchAccountByUsernameParams{
 		Username: query,
 		Limit:    int32(pagination.Limit),
 		Offset:   int32(pagination.Offset),
+		Tag:      searchTagFilter(r),
 	})
 	if err != nil {
 		ah.Logger.Error("Failed to search accounts by username", slog.Any("error", err))
@@ -985,16 +1129,6 @@ func (ah *AccountHandler) SearchAccountsByUsername(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Commit transaction
-	if err = tx.Commit(r.Context()); err != nil {
-		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-
 	// Prepare response
 	response := map[string]any{
 		"accounts": accounts,
@@ -1052,6 +1186,24 @@ func (ah *AccountHandler) MarkAccountForDeletion(w http.ResponseWriter, r *http.
 		return
 	}
 
+	restricted, err := isAccountRestricted(r.Context(), repo, id, GuardianRestrictionAccountDeletion)
+	if err != nil {
+		ah.Logger.Error("Failed to check guardian restrictions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	if restricted {
+		tx.Rollback(r.Context())
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Your guardian has restricted deleting this account",
+		})
+		return
+	}
+
 	err = repo.MarkAccountForDeletion(r.Context(), id)
 	if err != nil {
 		ah.Logger.Error(
@@ -1121,7 +1273,7 @@ func (ah *AccountHandler) RecoverAccountFromDeletion(w http.ResponseWriter, r *h
 		return
 	}
 
-	err = repo.MarkAccountForRecovery(r.Context(), id)
+	_, err = repo.MarkAccountForRecovery(r.Context(), id)
 	if err != nil {
 		ah.Logger.Error(
 			"Error while attempting to recover account from deletion",
@@ -1148,3 +1300,107 @@ func (ah *AccountHandler) RecoverAccountFromDeletion(w http.ResponseWriter, r *h
 		"message": "Account recovery was successful. All access has been restored",
 	})
 }
+
+// Deactivates the caller's account. The account's data is retained but it
+// can no longer be used to call the API and is hidden from searches and
+// leaderboards. Signing in again through any OAuth provider reactivates it
+func (ah *AccountHandler) DeactivatePersonalAccount(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into an error while trying to deactivate your account",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error attempting to prepare transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into an error while trying to deactivate your account",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	restricted, err := isAccountRestricted(r.Context(), repo, id, GuardianRestrictionAccountDeactivation)
+	if err != nil {
+		ah.Logger.Error("Failed to check guardian restrictions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	if restricted {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Your guardian has restricted deactivating this account",
+		})
+		return
+	}
+
+	if err = repo.DeactivateAccount(r.Context(), id); err != nil {
+		ah.Logger.Error("Error while attempting to deactivate account", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't deactivate your account at the moment please try again later",
+		})
+		return
+	}
+
+	updated, err := repo.GetAccountByID(r.Context(), id)
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't deactivate your account at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	go func() {
+		eventRequestID := middleware.GetRequestID(r.Context())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := ah.UserEventBus.PublishUserUpdated(ctx, updated, eventRequestID); err != nil {
+			ah.Logger.Error("Failed to publish user updated event",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("event_data", updated),
+				slog.Any("error", err),
+			)
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": "Your account has been deactivated. Sign in again at any time to reactivate it.",
+	})
+}
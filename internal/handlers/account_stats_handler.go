@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// AccountStatsResponse is the payload served to the operations dashboards
+type AccountStatsResponse struct {
+	Total           int64                                      `json:"total"`
+	TotalsByType    map[repository.AccountType]int64           `json:"totals_by_type"`
+	DailySignups    []repository.GetDailySignupsRow            `json:"daily_signups"`
+	WeeklySignups   []repository.GetWeeklySignupsRow           `json:"weekly_signups"`
+	ActiveUsers     map[string]int64                           `json:"active_users"`
+	SocialProviders []repository.GetSocialProviderBreakdownRow `json:"social_providers"`
+}
+
+// queryIntInRange reads an integer query parameter falling back to def when
+// it is missing or outside [1, max]
+func queryIntInRange(r *http.Request, key string, def, max int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || v < 1 || v > max {
+		return def
+	}
+	return v
+}
+
+// Returns aggregated account statistics.
+// The optional "days" (default 30) and "weeks" (default 12) query parameters
+// control how far back the signup series go
+func (ah *AccountHandler) GetAccountStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	days := queryIntInRange(r, "days", 30, 365)
+	weeks := queryIntInRange(r, "weeks", 12, 104)
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	stats := AccountStatsResponse{
+		TotalsByType: map[repository.AccountType]int64{},
+		ActiveUsers:  map[string]int64{},
+	}
+
+	fail := func(msg string, err error) {
+		ah.Logger.Error(msg, slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+	}
+
+	byType, err := repo.GetAccountCountsByType(r.Context())
+	if err != nil {
+		fail("Failed to count accounts by type", err)
+		return
+	}
+	for _, row := range byType {
+		stats.TotalsByType[row.Type] = row.Total
+		stats.Total += row.Total
+	}
+
+	if stats.DailySignups, err = repo.GetDailySignups(r.Context(), int32(days)); err != nil {
+		fail("Failed to retrieve daily signups", err)
+		return
+	}
+
+	if stats.WeeklySignups, err = repo.GetWeeklySignups(r.Context(), int32(weeks)); err != nil {
+		fail("Failed to retrieve weekly signups", err)
+		return
+	}
+
+	for label, window := range map[string]int32{"last_day": 1, "last_7_days": 7, "last_30_days": 30} {
+		count, err := repo.GetActiveAccountsCount(r.Context(), window)
+		if err != nil {
+			fail("Failed to count active accounts", err)
+			return
+		}
+		stats.ActiveUsers[label] = count
+	}
+
+	if stats.SocialProviders, err = repo.GetSocialProviderBreakdown(r.Context()); err != nil {
+		fail("Failed to retrieve social provider breakdown", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Tags are short lower case labels such as "beta-tester" or "flagged"
+var accountTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]{0,49}$`)
+
+// normalizeAccountTag trims and lower cases a tag so that "Beta-Tester" and
+// "beta-tester" are treated as the same label
+func normalizeAccountTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// AccountTagsRequest is the body expected when tagging an account
+type AccountTagsRequest struct {
+	Tags []string `json:"tags" validate:"min=1"`
+}
+
+// Retrieves all tags currently in use alongside the number of tagged accounts
+func (ah *AccountHandler) GetAllAccountTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	tags, err := repo.GetAllAccountTags(r.Context())
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account tags", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Retrieves the tags attached to an account
+func (ah *AccountHandler) GetAccountTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	tags, err := repo.GetAccountTags(r.Context(), accountID)
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account tags",
+			slog.Any("error", err),
+			slog.String("account_id", accountID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Attaches one or more tags to an account. Tags already present on the
+// account are left untouched
+func (ah *AccountHandler) AddAccountTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req AccountTagsRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	for i, tag := range req.Tags {
+		req.Tags[i] = normalizeAccountTag(tag)
+		if !accountTagPattern.MatchString(req.Tags[i]) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Tags may only contain lower case letters, digits, '-', '_', '.' or ':' and be at most 50 characters long",
+			})
+			return
+		}
+	}
+
+	var createdBy pgtype.UUID
+	if id, err := uuid.Parse(claims.Subject); err == nil {
+		createdBy = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	if _, err := repo.GetAccountByID(r.Context(), accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The account you are trying to tag does not exist",
+			})
+			return
+		}
+		ah.Logger.Error("Failed to retrieve account", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	for _, tag := range req.Tags {
+		if err := repo.AddAccountTag(r.Context(), repository.AddAccountTagParams{
+			AccountID: accountID,
+			Tag:       tag,
+			CreatedBy: createdBy,
+		}); err != nil {
+			ah.Logger.Error("Failed to tag account",
+				slog.Any("error", err),
+				slog.String("account_id", accountID.String()),
+				slog.String("tag", tag),
+			)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't tag this account at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	tags, err := repo.GetAccountTags(r.Context(), accountID)
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account tags", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Removes a tag from an account
+func (ah *AccountHandler) RemoveAccountTag(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	tag := normalizeAccountTag(r.PathValue("tag"))
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	removed, err := repo.RemoveAccountTag(r.Context(), repository.RemoveAccountTagParams{
+		AccountID: accountID,
+		Tag:       tag,
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to remove account tag",
+			slog.Any("error", err),
+			slog.String("account_id", accountID.String()),
+			slog.String("tag", tag),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This account does not carry the specified tag",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Tag successfully removed from account"})
+}
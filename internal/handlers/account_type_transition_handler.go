@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// allowedAccountTypeTransitions lists, for every account type, the types an
+// account may be converted into.
+//
+// Humans and organizations may switch between each other since both are
+// backed by real people signing in through a social provider. Bots and
+// services are both machine identities authenticated with service tokens so
+// they may only switch between each other. Crossing between a human-backed
+// account and a machine account is never allowed because the credentials,
+// socials and leaderboard data attached to either side would no longer make
+// sense.
+var allowedAccountTypeTransitions = map[repository.AccountType][]repository.AccountType{
+	repository.AccountTypeHuman:        {repository.AccountTypeOrganization},
+	repository.AccountTypeOrganization: {repository.AccountTypeHuman},
+	repository.AccountTypeBot:          {repository.AccountTypeService},
+	repository.AccountTypeService:      {repository.AccountTypeBot},
+}
+
+// ValidateAccountTypeTransition reports whether an account of type from may
+// be converted into type to.
+func ValidateAccountTypeTransition(from, to repository.AccountType) error {
+	allowed, ok := allowedAccountTypeTransitions[from]
+	if !ok {
+		return fmt.Errorf("unknown account type %q", from)
+	}
+	if _, ok := allowedAccountTypeTransitions[to]; !ok {
+		return fmt.Errorf("unknown account type %q", to)
+	}
+	if from == to {
+		return fmt.Errorf("account is already of type %q", to)
+	}
+	if !slices.Contains(allowed, to) {
+		return fmt.Errorf("converting a %q account into a %q account is not allowed", from, to)
+	}
+	return nil
+}
+
+// ConvertAccountTypeRequest is the body expected when converting an account's
+// type. Both confirmations must be supplied for the conversion to proceed.
+type ConvertAccountTypeRequest struct {
+	ToType repository.AccountType `json:"to_type" validate:"required"`
+	Reason string                 `json:"reason" validate:"notblank"`
+	// Must match the email of the account being converted
+	ConfirmEmail string `json:"confirm_email" validate:"required"`
+	// Must be explicitly set to true by the caller
+	AcknowledgeConsequences bool `json:"acknowledge_consequences" validate:"required"`
+}
+
+// Converts an account from one type to another after validating the
+// transition and the caller's confirmations. Every conversion is recorded in
+// the account type transitions audit trail.
+func (ah *AccountHandler) ConvertAccountType(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	performedBy, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		ah.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	if performedBy == accountID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You cannot convert the type of your own account",
+		})
+		return
+	}
+
+	var req ConvertAccountTypeRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	account, err := repo.GetAccountByID(r.Context(), accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The account you are trying to convert does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(req.ConfirmEmail), account.Email) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The confirmation email does not match the account being converted",
+		})
+		return
+	}
+
+	if account.DeletedAt != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Accounts scheduled for deletion cannot be converted",
+		})
+		return
+	}
+
+	if err := ValidateAccountTypeTransition(account.Type, req.ToType); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	updated, err := repo.UpdateAccountType(r.Context(), repository.UpdateAccountTypeParams{
+		ID:   account.ID,
+		Type: req.ToType,
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to update account type",
+			slog.Any("error", err),
+			slog.String("account_id", account.ID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't convert this account at the moment please try again later",
+		})
+		return
+	}
+
+	transition, err := repo.CreateAccountTypeTransition(r.Context(),
+		repository.CreateAccountTypeTransitionParams{
+			AccountID:   account.ID,
+			FromType:    account.Type,
+			ToType:      req.ToType,
+			Reason:      req.Reason,
+			PerformedBy: performedBy,
+		})
+	if err != nil {
+		ah.Logger.Error("Failed to record account type transition",
+			slog.Any("error", err),
+			slog.String("account_id", account.ID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't convert this account at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ah.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	ah.Logger.Info("Account type converted",
+		slog.String("account_id", account.ID.String()),
+		slog.String("from_type", string(account.Type)),
+		slog.String("to_type", string(req.ToType)),
+		slog.String("performed_by", performedBy.String()),
+	)
+
+	go func() {
+		eventRequestID := middleware.GetRequestID(r.Context())
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := ah.UserEventBus.PublishUserUpdated(
+			ctx,
+			updated, eventRequestID); err != nil {
+			ah.Logger.Error("Failed to publish user updated event",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("event_data", updated),
+				slog.Any("error", err),
+			)
+		}
+	}()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"account":    updated,
+		"transition": transition,
+	})
+}
+
+// Retrieves the type transition history of an account
+func (ah *AccountHandler) GetAccountTypeTransitions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+
+	accountID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+
+	transitions, err := repo.GetAccountTypeTransitions(r.Context(),
+		repository.GetAccountTypeTransitionsParams{
+			AccountID: accountID,
+			Limit:     int32(pagination.Limit),
+			Offset:    int32(pagination.Offset),
+		})
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve account type transitions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(transitions)
+}
@@ -2,14 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/opencrafts-io/verisafe/internal/config"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type ActivityHandler struct {
@@ -17,40 +19,42 @@ type ActivityHandler struct {
 }
 
 func (ah *ActivityHandler) RegisterHadlers(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("POST /activity/add", middleware.CreateStack(
+	router.Handle("POST /api/v1/activity/add", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.CreateActivity)))
-	router.Handle("GET /activity/all", middleware.CreateStack(
+	router.Handle("GET /api/v1/activity/all", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.GetAllActivities)))
-	router.Handle("GET /activity/active", middleware.CreateStack(
+	router.Handle("GET /api/v1/activity/active", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.GetAllActiveActivities)))
-	router.Handle("GET /activity/inactive", middleware.CreateStack(
+	router.Handle("GET /api/v1/activity/inactive", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.GetAllInactiveActivities)))
-	router.Handle("PATCH /activity/{id}", middleware.CreateStack(
+	router.Handle("PATCH /api/v1/activity/{id}", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.UpdateActivity)))
-	router.Handle("DELETE /activity/{id}", middleware.CreateStack(
+	router.Handle("DELETE /api/v1/activity/{id}", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.DeleteActivity)))
 
 	// Activity completions
-	router.Handle("GET /users/activity/completions/for-user/{id}", middleware.CreateStack(
+	router.Handle("GET /api/v1/users/activity/completions/for-user/{id}", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ah.Logger),
 	)(http.HandlerFunc(ah.GetAllUserActivityCompletions)))
 
+	// Analytics
+	router.Handle("GET /api/v1/admin/gamification/stats", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, ah.Logger),
+		middleware.HasPermission([]string{"read:gamification_stats:any"}),
+	)(http.HandlerFunc(ah.GetGamificationStats)))
+
 }
 
 func (ah *ActivityHandler) GetAllUserActivityCompletions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		ah.Logger.Error("Failed to parse user's uuid from id path parameter", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]any{"error": "Please check your request body and try that again"})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 	conn, err := middleware.GetDBConnFromContext(r.Context())
@@ -60,15 +64,7 @@ func (ah *ActivityHandler) GetAllUserActivityCompletions(w http.ResponseWriter,
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
@@ -115,16 +111,8 @@ func (ah *ActivityHandler) GetAllUserActivityCompletions(w http.ResponseWriter,
 func (ah *ActivityHandler) DeleteActivity(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		ah.Logger.Error("Failed to parse request path parameter", slog.Any("error", err),
-			slog.Any("value", rawID),
-		)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -166,29 +154,17 @@ func (ah *ActivityHandler) DeleteActivity(w http.ResponseWriter, r *http.Request
 func (ah *ActivityHandler) UpdateActivity(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		ah.Logger.Error("Failed to parse request path parameter", slog.Any("error", err),
-			slog.Any("value", rawID),
-		)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
 	requestBody := repository.UpdateActivityParams{}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		ah.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &requestBody) {
 		return
 	}
+
 	requestBody.ID = id
 
 	conn, err := middleware.GetDBConnFromContext(r.Context())
@@ -208,6 +184,13 @@ func (ah *ActivityHandler) UpdateActivity(w http.ResponseWriter, r *http.Request
 	repo := repository.New(tx)
 
 	activity, err := repo.UpdateActivity(r.Context(), requestBody)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The activity you are trying to update does not exist",
+		})
+		return
+	}
 	if err != nil {
 		ah.Logger.Error("Failed to update activity", slog.Any("error", err), slog.Any("activity", requestBody))
 		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
@@ -234,15 +217,7 @@ func (ah *ActivityHandler) GetAllInactiveActivities(w http.ResponseWriter, r *ht
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
@@ -289,15 +264,7 @@ func (ah *ActivityHandler) GetAllActiveActivities(w http.ResponseWriter, r *http
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
@@ -344,16 +311,13 @@ func (ah *ActivityHandler) GetAllActivities(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		ah.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
+	repo := repository.New(conn)
+
+	if pagination.UsesCursor(r) {
+		ah.getActivitiesPage(w, r, repo)
 		return
 	}
 
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
-
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
 
@@ -390,17 +354,44 @@ func (ah *ActivityHandler) GetAllActivities(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(response)
 }
 
+// getActivitiesPage serves GetAllActivities to clients paging with a cursor,
+// which stays fast however deep they page
+func (ah *ActivityHandler) getActivitiesPage(w http.ResponseWriter, r *http.Request, repo *repository.Queries) {
+	params, err := pagination.ParseCursorParams(r, 10, 100)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "The cursor is invalid, use the next_cursor of a previous page",
+		})
+		return
+	}
+
+	activities, err := repo.ListActivitiesAfter(r.Context(), repository.ListActivitiesAfterParams{
+		AfterCreatedAt: params.AfterCreatedAt(),
+		AfterID:        params.AfterID(),
+		PageSize:       params.FetchLimit(),
+	})
+	if err != nil {
+		ah.Logger.Error("Failed to retrieve activities page", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide activities at the moment.",
+		})
+		return
+	}
+
+	activities, next := pagination.CursorPage(activities, params, func(activity repository.Activity) pagination.Cursor {
+		return pagination.NewCursor(activity.CreatedAt, activity.ID)
+	})
+	json.NewEncoder(w).Encode(pagination.BuildCursorResponse(r, activities, params, next))
+}
+
 func (ah *ActivityHandler) CreateActivity(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	requestBody := repository.CreateActivityParams{}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		ah.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &requestBody) {
 		return
 	}
 
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/adminstream"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+)
+
+// How often a comment is sent down idle streams so that proxies do not close
+// them
+const streamHeartbeatInterval = 15 * time.Second
+
+// How long browsers wait before reconnecting a closed stream
+const streamRetryMilliseconds = 3000
+
+// AdminStreamHandler relays security and account events to the admin
+// dashboard as Server-Sent Events
+type AdminStreamHandler struct {
+	Logger *slog.Logger
+	Hub    *adminstream.Hub
+}
+
+// RegisterRoutes registers the admin event stream routes
+func (ah *AdminStreamHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("GET /api/v1/admin/events/stream",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ah.Logger),
+			middleware.HasPermission([]string{"read:event_stream:any"}),
+		)(http.HandlerFunc(ah.StreamEvents)),
+	)
+}
+
+// Streams security and account events as Server-Sent Events for as long as
+// the client stays connected. The topics query parameter selects the topics,
+// security and account, to stream, both by default
+func (ah *AdminStreamHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	topics := adminstream.Topics
+	if raw := r.URL.Query().Get("topics"); raw != "" {
+		topics = nil
+		for _, topic := range strings.Split(raw, ",") {
+			topic = strings.TrimSpace(topic)
+			if !slices.Contains(adminstream.Topics, topic) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "Please provide topics out of " + strings.Join(adminstream.Topics, ", "),
+				})
+				return
+			}
+			topics = append(topics, topic)
+		}
+	}
+
+	// Streams stay open for as long as the dashboard does, they should not
+	// hold a database connection or a concurrency slot all along
+	middleware.ReleaseDBConnection(r.Context())
+	middleware.ReleaseConcurrencySlot(r.Context())
+
+	events, cancel := ah.Hub.Subscribe(topics)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keeps nginx from buffering the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetryMilliseconds)
+	if err := rc.Flush(); err != nil {
+		ah.Logger.Error("Failed to start event stream", slog.Any("error", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
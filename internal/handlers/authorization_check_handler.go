@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Reasons reported alongside every authorization decision
+const (
+	AuthorizationReasonRole            = authz.ReasonRole
+	AuthorizationReasonInstitutionRole = authz.ReasonInstitutionRole
+	AuthorizationReasonPolicy          = authz.ReasonPolicy
+	AuthorizationReasonDenied          = authz.ReasonDenied
+	AuthorizationReasonDenyRule        = authz.ReasonDenyRule
+	AuthorizationReasonUnknownSubject  = authz.ReasonUnknownSubject
+)
+
+// AuthorizationCheckResource identifies the resource a permission is checked
+// against. Both fields are optional
+type AuthorizationCheckResource struct {
+	OwnerID       *uuid.UUID `json:"owner_id"`
+	InstitutionID *int32     `json:"institution_id"`
+}
+
+// AuthorizationCheck asks whether a subject may perform an action
+type AuthorizationCheck struct {
+	Subject    uuid.UUID                  `json:"subject" validate:"required"`
+	Permission string                     `json:"permission" validate:"required"`
+	Resource   AuthorizationCheckResource `json:"resource"`
+}
+
+// AuthorizationCheckRequest is the body expected by the batch check endpoint
+type AuthorizationCheckRequest struct {
+	// At most 100 checks are accepted in a single request
+	Checks []AuthorizationCheck `json:"checks" validate:"min=1,max=100,dive"`
+}
+
+// AuthorizationCheckResult is the decision for a single check
+type AuthorizationCheckResult struct {
+	AuthorizationCheck
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+	// Set when the checked permission is deprecated and names the permission
+	// it was evaluated as
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// Evaluates a batch of permission checks on behalf of downstream services.
+// Each check is allowed if the subject holds the permission through a global
+// role, through a role scoped to the resource's institution or through an
+// authorization policy
+func (ph *AuthorizationPolicyHandler) CheckAuthorization(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req AuthorizationCheckRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	var caller string
+	if claims, ok := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims); ok {
+		caller = claims.Subject
+	}
+
+	checker := ph.Engine.NewChecker(conn)
+	results := make([]AuthorizationCheckResult, 0, len(req.Checks))
+
+	for _, check := range req.Checks {
+		requested := check.Permission
+		check.Permission = ph.Engine.ResolvePermission(requested, caller)
+
+		allowed, reason, err := checker.Check(r.Context(), authz.Check{
+			Subject:         check.Subject,
+			Permission:      check.Permission,
+			ResourceOwnerID: check.Resource.OwnerID,
+			InstitutionID:   check.Resource.InstitutionID,
+		})
+		if err != nil {
+			ph.Logger.Error("Failed to evaluate authorization check",
+				slog.Any("error", err),
+				slog.String("subject", check.Subject.String()),
+				slog.String("permission", check.Permission),
+			)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+
+		result := AuthorizationCheckResult{
+			AuthorizationCheck: check,
+			Allowed:            allowed,
+			Reason:             reason,
+		}
+		if check.Permission != requested {
+			result.Permission = requested
+			result.ReplacedBy = check.Permission
+		}
+		results = append(results, result)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"results": results})
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+type AuthorizationPolicyHandler struct {
+	Logger *slog.Logger
+	Cfg    *config.Config
+	Engine *authz.Engine
+}
+
+// Registers all the necessary routes associated with this handler group
+func (ph *AuthorizationPolicyHandler) RegisterRoutes(router *http.ServeMux) {
+	router.Handle("POST /api/v1/authz/check",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"check:authorization:any"}),
+		)(http.HandlerFunc(ph.CheckAuthorization)),
+	)
+
+	router.Handle("GET /api/v1/authz/audit",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"read:authz:audit"}),
+			middleware.PaginationMiddleware(20, 100),
+		)(http.HandlerFunc(ph.GetRBACAuditLog)),
+	)
+
+	router.Handle("GET /api/v1/admin/authz/usage",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"read:authz:usage"}),
+		)(http.HandlerFunc(ph.GetPermissionUsage)),
+	)
+
+	router.Handle("POST /api/v1/authz/policies",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"create:policy:any"}),
+		)(http.HandlerFunc(ph.CreatePolicy)),
+	)
+
+	router.Handle("GET /api/v1/authz/policies",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"read:policy:any"}),
+		)(http.HandlerFunc(ph.GetAllPolicies)),
+	)
+
+	router.Handle("GET /api/v1/authz/policies/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"read:policy:any"}),
+		)(http.HandlerFunc(ph.GetPolicy)),
+	)
+
+	router.Handle("PATCH /api/v1/authz/policies/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"update:policy:any"}),
+		)(http.HandlerFunc(ph.UpdatePolicy)),
+	)
+
+	router.Handle("DELETE /api/v1/authz/policies/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"delete:policy:any"}),
+		)(http.HandlerFunc(ph.DeletePolicy)),
+	)
+
+	router.Handle("POST /api/v1/authz/policies/reload",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"update:policy:any"}),
+		)(http.HandlerFunc(ph.ReloadPolicies)),
+	)
+
+	router.Handle("GET /api/v1/authz/aliases",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"read:permission:any"}),
+		)(http.HandlerFunc(ph.GetPermissionAliases)),
+	)
+
+	router.Handle("POST /api/v1/authz/aliases",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"update:permission:any"}),
+		)(http.HandlerFunc(ph.CreatePermissionAlias)),
+	)
+
+	router.Handle("DELETE /api/v1/authz/aliases/{alias}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(ph.Cfg, ph.Logger),
+			middleware.HasPermission([]string{"update:permission:any"}),
+		)(http.HandlerFunc(ph.DeletePermissionAlias)),
+	)
+}
+
+// AuthorizationPolicyResponse is the API representation of a policy
+type AuthorizationPolicyResponse struct {
+	ID          uuid.UUID        `json:"id"`
+	Name        string           `json:"name"`
+	Description *string          `json:"description"`
+	Permission  string           `json:"permission"`
+	Conditions  json.RawMessage  `json:"conditions"`
+	IsActive    bool             `json:"is_active"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+func newAuthorizationPolicyResponse(policy repository.AuthorizationPolicy) AuthorizationPolicyResponse {
+	return AuthorizationPolicyResponse{
+		ID:          policy.ID,
+		Name:        policy.Name,
+		Description: policy.Description,
+		Permission:  policy.Permission,
+		Conditions:  json.RawMessage(policy.Conditions),
+		IsActive:    policy.IsActive,
+		CreatedAt:   policy.CreatedAt,
+		UpdatedAt:   policy.UpdatedAt,
+	}
+}
+
+// CreateAuthorizationPolicyRequest is the body expected when creating a policy
+type CreateAuthorizationPolicyRequest struct {
+	Name        string           `json:"name" validate:"notblank"`
+	Description *string          `json:"description"`
+	Permission  string           `json:"permission" validate:"notblank"`
+	Conditions  authz.Conditions `json:"conditions"`
+	IsActive    *bool            `json:"is_active"`
+}
+
+// UpdateAuthorizationPolicyRequest is the body expected when updating a
+// policy. Omitted fields are left untouched
+type UpdateAuthorizationPolicyRequest struct {
+	Name        *string           `json:"name" validate:"omitnil,notblank"`
+	Description *string           `json:"description"`
+	Permission  *string           `json:"permission" validate:"omitnil,notblank"`
+	Conditions  *authz.Conditions `json:"conditions"`
+	IsActive    *bool             `json:"is_active"`
+}
+
+// reloadPolicies refreshes the engine after a policy was changed so that the
+// change applies immediately on this instance
+func (ph *AuthorizationPolicyHandler) reloadPolicies() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := ph.Engine.Reload(ctx); err != nil {
+		ph.Logger.Error("Failed to reload authorization policies", slog.Any("error", err))
+	}
+}
+
+// Creates an authorization policy
+func (ph *AuthorizationPolicyHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateAuthorizationPolicyRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Permission = strings.TrimSpace(req.Permission)
+
+	if err := req.Conditions.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	conditions, err := json.Marshal(req.Conditions)
+	if err != nil {
+		ph.Logger.Error("Failed to encode policy conditions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	policy, err := repo.CreateAuthorizationPolicy(r.Context(), repository.CreateAuthorizationPolicyParams{
+		Name:        req.Name,
+		Description: req.Description,
+		Permission:  req.Permission,
+		Conditions:  conditions,
+		IsActive:    isActive,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A policy with this name already exists",
+			})
+			return
+		}
+		ph.Logger.Error("Failed to create authorization policy", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	ph.reloadPolicies()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newAuthorizationPolicyResponse(policy))
+}
+
+// Retrieves all authorization policies
+func (ph *AuthorizationPolicyHandler) GetAllPolicies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	policies, err := repo.GetAllAuthorizationPolicies(r.Context())
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve authorization policies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]AuthorizationPolicyResponse, 0, len(policies))
+	for _, policy := range policies {
+		response = append(response, newAuthorizationPolicyResponse(policy))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Retrieves a single authorization policy
+func (ph *AuthorizationPolicyHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	policy, err := repo.GetAuthorizationPolicyByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The policy you are requesting does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve authorization policy", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newAuthorizationPolicyResponse(policy))
+}
+
+// Updates an authorization policy
+func (ph *AuthorizationPolicyHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateAuthorizationPolicyRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	params := repository.UpdateAuthorizationPolicyParams{
+		ID:          id,
+		Description: req.Description,
+		IsActive:    req.IsActive,
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		params.Name = &name
+	}
+	if req.Permission != nil {
+		permission := strings.TrimSpace(*req.Permission)
+		params.Permission = &permission
+	}
+	if req.Conditions != nil {
+		if err := req.Conditions.Validate(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		conditions, err := json.Marshal(req.Conditions)
+		if err != nil {
+			ph.Logger.Error("Failed to encode policy conditions", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We ran into a problem while servicing your request please try again later",
+			})
+			return
+		}
+		params.Conditions = conditions
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	policy, err := repo.UpdateAuthorizationPolicy(r.Context(), params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The policy you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A policy with this name already exists",
+			})
+			return
+		}
+		ph.Logger.Error("Failed to update authorization policy", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	ph.reloadPolicies()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newAuthorizationPolicyResponse(policy))
+}
+
+// Deletes an authorization policy
+func (ph *AuthorizationPolicyHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	deleted, err := repo.DeleteAuthorizationPolicy(r.Context(), id)
+	if err != nil {
+		ph.Logger.Error("Failed to delete authorization policy", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The policy you are trying to delete does not exist",
+		})
+		return
+	}
+
+	ph.reloadPolicies()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Policy successfully deleted"})
+}
+
+// Reloads the policies from the database on this instance. Other instances
+// pick up changes on their next periodic reload
+func (ph *AuthorizationPolicyHandler) ReloadPolicies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := ph.Engine.Reload(r.Context()); err != nil {
+		ph.Logger.Error("Failed to reload authorization policies", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't reload the policies at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":   "Policies successfully reloaded",
+		"loaded_at": ph.Engine.LoadedAt(),
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Actions recorded in the RBAC audit log and published as authz.changed
+// events
+const (
+	AuthzActionRoleAssigned            = "role.assigned"
+	AuthzActionRoleRevoked             = "role.revoked"
+	AuthzActionInstitutionRoleAssigned = "institution_role.assigned"
+	AuthzActionInstitutionRoleRevoked  = "institution_role.revoked"
+	AuthzActionRoleCreated             = "role.created"
+	AuthzActionRoleUpdated             = "role.updated"
+	AuthzActionRoleDeleted             = "role.deleted"
+	AuthzActionRoleRestored            = "role.restored"
+	AuthzActionRoleActivated           = "role.activated"
+	AuthzActionRoleDeactivated         = "role.deactivated"
+	AuthzActionPermissionGranted       = "permission.granted"
+	AuthzActionPermissionRevoked       = "permission.revoked"
+	AuthzActionPermissionUpdated       = "permission.updated"
+	AuthzActionPermissionDenied        = "permission.denied"
+	AuthzActionPermissionDenialRemoved = "permission.denial_removed"
+)
+
+// Kinds of objects an RBAC change can target
+const (
+	AuthzTargetAccount    = "account"
+	AuthzTargetRole       = "role"
+	AuthzTargetPermission = "permission"
+)
+
+// newAuthzChange describes a change made by the caller of r. before and after
+// are serialized as is and left out when nil
+func newAuthzChange(r *http.Request, action, targetType, targetID string, before, after any) eventbus.AuthzChange {
+	change := eventbus.AuthzChange{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if claims, ok := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims); ok {
+		change.ActorID = claims.Subject
+	}
+	if before != nil {
+		change.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		change.After, _ = json.Marshal(after)
+	}
+	return change
+}
+
+// recordAuthzChange writes the change to the RBAC audit log. It should be
+// called with the transaction making the change so that the entry is only
+// kept when the change is committed
+func recordAuthzChange(ctx context.Context, repo *repository.Queries, change eventbus.AuthzChange) error {
+	var actorID pgtype.UUID
+	if id, err := uuid.Parse(change.ActorID); err == nil {
+		actorID = pgtype.UUID{Bytes: id, Valid: true}
+	}
+
+	_, err := repo.CreateRBACAuditEntry(ctx, repository.CreateRBACAuditEntryParams{
+		Action:     change.Action,
+		ActorID:    actorID,
+		TargetType: change.TargetType,
+		TargetID:   change.TargetID,
+		Before:     change.Before,
+		After:      change.After,
+	})
+	return err
+}
+
+// publishAuthzChange notifies downstream services of a change committed
+// while serving the request
+func publishAuthzChange(r *http.Request, bus *eventbus.AuthzEventBus, logger *slog.Logger, change eventbus.AuthzChange) {
+	if bus == nil {
+		return
+	}
+
+	eventRequestID := middleware.GetRequestID(r.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := bus.PublishAuthzChanged(ctx, change, eventRequestID); err != nil {
+			logger.Error("Failed to publish authz changed event",
+				slog.Any("request_id", eventRequestID),
+				slog.Any("event_data", change),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
+
+// RBACAuditEntryResponse is the API representation of an audit log entry
+type RBACAuditEntryResponse struct {
+	ID         int64            `json:"id"`
+	Action     string           `json:"action"`
+	ActorID    pgtype.UUID      `json:"actor_id"`
+	TargetType string           `json:"target_type"`
+	TargetID   string           `json:"target_id"`
+	Before     json.RawMessage  `json:"before"`
+	After      json.RawMessage  `json:"after"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+func newRBACAuditEntryResponse(entry repository.RbacAuditLog) RBACAuditEntryResponse {
+	return RBACAuditEntryResponse{
+		ID:         entry.ID,
+		Action:     entry.Action,
+		ActorID:    entry.ActorID,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Before:     entry.Before,
+		After:      entry.After,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
+
+// Retrieves the RBAC audit log newest first. Entries may be filtered by
+// target_type, target_id and actor_id
+func (ph *AuthorizationPolicyHandler) GetRBACAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+	query := r.URL.Query()
+
+	params := repository.GetRBACAuditEntriesParams{
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	}
+	if targetType := query.Get("target_type"); targetType != "" {
+		params.TargetType = &targetType
+	}
+	if targetID := query.Get("target_id"); targetID != "" {
+		params.TargetID = &targetID
+	}
+	if rawActorID := query.Get("actor_id"); rawActorID != "" {
+		actorID, err := uuid.Parse(rawActorID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Please provide a valid actor id",
+			})
+			return
+		}
+		params.ActorID = pgtype.UUID{Bytes: actorID, Valid: true}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	entries, err := repo.GetRBACAuditEntries(r.Context(), params)
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve rbac audit log", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]RBACAuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newRBACAuditEntryResponse(entry))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/bodylog"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Route patterns bodies can be logged for, a method and a path
+var bodyLoggingRoutePattern = regexp.MustCompile(`^(GET|POST|PUT|PATCH|DELETE) /\S*$`)
+
+// BodyLoggingHandler lets administrators turn the logging of the request and
+// response bodies of a route on and off while they diagnose a problem
+type BodyLoggingHandler struct {
+	Logger  *slog.Logger
+	Cfg     *config.Config
+	Sampler *bodylog.Sampler
+}
+
+// BodyLoggingRuleResponse is the API representation of a body logging rule
+type BodyLoggingRuleResponse struct {
+	ID         uuid.UUID          `json:"id"`
+	Route      string             `json:"route"`
+	SampleRate float64            `json:"sample_rate"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	CreatedBy  *uuid.UUID         `json:"created_by"`
+	CreatedAt  pgtype.Timestamp   `json:"created_at"`
+}
+
+func newBodyLoggingRuleResponse(rule repository.BodyLoggingRule) BodyLoggingRuleResponse {
+	response := BodyLoggingRuleResponse{
+		ID:         rule.ID,
+		Route:      rule.Route,
+		SampleRate: rule.SampleRate,
+		ExpiresAt:  rule.ExpiresAt,
+		CreatedAt:  rule.CreatedAt,
+	}
+	if rule.CreatedBy.Valid {
+		createdBy := uuid.UUID(rule.CreatedBy.Bytes)
+		response.CreatedBy = &createdBy
+	}
+	return response
+}
+
+// SetBodyLoggingRuleRequest is the body expected when turning on the logging
+// of the bodies of a route
+type SetBodyLoggingRuleRequest struct {
+	// Route pattern as registered e.g. PATCH /api/v1/roles/{id}
+	Route           string  `json:"route" validate:"notblank"`
+	SampleRate      float64 `json:"sample_rate" validate:"gt=0,lte=1"`
+	DurationMinutes int32   `json:"duration_minutes" validate:"min=1"`
+}
+
+// RegisterRoutes registers the body logging routes
+func (bh *BodyLoggingHandler) RegisterRoutes(router *http.ServeMux) {
+	router.Handle("GET /api/v1/admin/diagnostics/body-logging",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(bh.Cfg, bh.Logger),
+			middleware.HasPermission([]string{"read:diagnostics:any"}),
+		)(http.HandlerFunc(bh.ListBodyLoggingRules)),
+	)
+
+	router.Handle("PUT /api/v1/admin/diagnostics/body-logging",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(bh.Cfg, bh.Logger),
+			middleware.HasPermission([]string{"manage:diagnostics:any"}),
+		)(http.HandlerFunc(bh.SetBodyLoggingRule)),
+	)
+
+	router.Handle("DELETE /api/v1/admin/diagnostics/body-logging/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(bh.Cfg, bh.Logger),
+			middleware.HasPermission([]string{"manage:diagnostics:any"}),
+		)(http.HandlerFunc(bh.DeleteBodyLoggingRule)),
+	)
+}
+
+// reloadRules refreshes the sampler after a rule was changed so that the
+// change applies immediately on this instance
+func (bh *BodyLoggingHandler) reloadRules() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := bh.Sampler.Reload(ctx); err != nil {
+		bh.Logger.Error("Failed to reload body logging rules", slog.Any("error", err))
+	}
+}
+
+// Lists the routes whose bodies are logged at the moment
+func (bh *BodyLoggingHandler) ListBodyLoggingRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		bh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	rules, err := repository.New(conn).ListActiveBodyLoggingRules(r.Context())
+	if err != nil {
+		bh.Logger.Error("Failed to retrieve body logging rules", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]BodyLoggingRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, newBodyLoggingRuleResponse(rule))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Turns on the logging of the bodies of a route for a while, replacing the
+// rule already in place for the route
+func (bh *BodyLoggingHandler) SetBodyLoggingRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req SetBodyLoggingRuleRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Route = strings.TrimSpace(req.Route)
+	if !bodyLoggingRoutePattern.MatchString(req.Route) {
+		utils.WriteValidationErrors(w, map[string]string{
+			"route": "must be a method and a route path e.g. PATCH /api/v1/roles/{id}",
+		})
+		return
+	}
+	if maxDuration := bh.Cfg.BodyLoggingConfig.MaxDurationMinutes; int(req.DurationMinutes) > maxDuration {
+		utils.WriteValidationErrors(w, map[string]string{
+			"duration_minutes": fmt.Sprintf("must be at most %d", maxDuration),
+		})
+		return
+	}
+
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		bh.Logger.Error("Failed to parse caller id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		bh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	rule, err := repository.New(conn).UpsertBodyLoggingRule(r.Context(), repository.UpsertBodyLoggingRuleParams{
+		Route:           req.Route,
+		SampleRate:      req.SampleRate,
+		DurationMinutes: req.DurationMinutes,
+		CreatedBy:       pgtype.UUID{Bytes: callerID, Valid: true},
+	})
+	if err != nil {
+		bh.Logger.Error("Failed to save body logging rule", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	bh.Logger.Warn("Body logging turned on",
+		slog.String("route", rule.Route),
+		slog.Float64("sample_rate", rule.SampleRate),
+		slog.Time("expires_at", rule.ExpiresAt.Time),
+		slog.String("actor_id", callerID.String()),
+	)
+	bh.reloadRules()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newBodyLoggingRuleResponse(rule))
+}
+
+// Turns off the logging of the bodies of a route before its rule expires
+func (bh *BodyLoggingHandler) DeleteBodyLoggingRule(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		bh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	deleted, err := repository.New(conn).DeleteBodyLoggingRule(r.Context(), id)
+	if err != nil {
+		bh.Logger.Error("Failed to delete body logging rule", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The body logging rule you are trying to delete does not exist",
+		})
+		return
+	}
+
+	bh.reloadRules()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,635 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// Maximum number of custom roles a single institution may define
+const maxInstitutionCustomRoles = 25
+
+// CustomRoleRequest is the body expected when creating or updating a custom
+// role. Omitting permissions on update leaves them unchanged
+type CustomRoleRequest struct {
+	Name        *string  `json:"name" validate:"omitnil,notblank"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// CustomRoleResponse is a custom role alongside the names of the permissions
+// it grants
+type CustomRoleResponse struct {
+	repository.Role
+	Permissions []string `json:"permissions"`
+}
+
+// resolveCustomRolePermissions looks up the named permissions returning the
+// names that are not on the custom role allow-list
+func resolveCustomRolePermissions(ctx context.Context, repo *repository.Queries, names []string) ([]repository.Permission, []string, error) {
+	allowed, err := repo.GetCustomRolePermissionAllowlist(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var permissions []repository.Permission
+	var disallowed []string
+	for _, name := range names {
+		index := slices.IndexFunc(allowed, func(p repository.Permission) bool { return p.Name == name })
+		switch {
+		case index < 0:
+			disallowed = append(disallowed, name)
+		case !slices.ContainsFunc(permissions, func(p repository.Permission) bool { return p.Name == name }):
+			permissions = append(permissions, allowed[index])
+		}
+	}
+	return permissions, disallowed, nil
+}
+
+// setCustomRolePermissions replaces the permissions granted by a role
+func setCustomRolePermissions(ctx context.Context, repo *repository.Queries, roleID uuid.UUID, permissions []repository.Permission) error {
+	if _, err := repo.DeleteRolePermissions(ctx, roleID); err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if _, err := repo.AssignRolePermission(ctx, repository.AssignRolePermissionParams{
+			RoleID:       roleID,
+			PermissionID: permission.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// customRoleResponse loads the permission names granted by a custom role
+func customRoleResponse(ctx context.Context, repo *repository.Queries, role repository.Role) (CustomRoleResponse, error) {
+	rows, err := repo.GetRolePermissions(ctx, role.ID)
+	if err != nil {
+		return CustomRoleResponse{}, err
+	}
+
+	permissions := make([]string, 0, len(rows))
+	for _, row := range rows {
+		permissions = append(permissions, row.PermissionName)
+	}
+	return CustomRoleResponse{Role: role, Permissions: permissions}, nil
+}
+
+// Lists the permissions institution admins may grant through custom roles
+func (rh *RoleHandler) GetCustomRolePermissionAllowlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	permissions, err := repository.New(conn).GetCustomRolePermissionAllowlist(r.Context())
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve custom role permission allow-list", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(permissions)
+}
+
+// Adds a permission to the custom role allow-list
+func (rh *RoleHandler) AllowCustomRolePermission(w http.ResponseWriter, r *http.Request) {
+	rh.updateCustomRolePermissionAllowlist(w, r, true)
+}
+
+// Removes a permission from the custom role allow-list. Custom roles already
+// granting it keep it until they are updated
+func (rh *RoleHandler) DisallowCustomRolePermission(w http.ResponseWriter, r *http.Request) {
+	rh.updateCustomRolePermissionAllowlist(w, r, false)
+}
+
+func (rh *RoleHandler) updateCustomRolePermissionAllowlist(w http.ResponseWriter, r *http.Request, allow bool) {
+	w.Header().Set("Content-Type", "application/json")
+	permissionID, ok := utils.PathUUID(w, r, "permission_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	if allow {
+		_, err = repo.AllowCustomRolePermission(r.Context(), permissionID)
+	} else {
+		_, err = repo.DisallowCustomRolePermission(r.Context(), permissionID)
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The permission you specified does not exist",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to update custom role permission allow-list", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Allow-list successfully updated"})
+}
+
+// Lists the custom roles of an institution
+func (rh *RoleHandler) GetInstitutionCustomRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "institution_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	roles, err := repo.GetInstitutionCustomRoles(r.Context(), &institutionID)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve custom roles", slog.Any("error", err), slog.Any("institution", institutionID))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]CustomRoleResponse, 0, len(roles))
+	for _, role := range roles {
+		custom, err := customRoleResponse(r.Context(), repo, role)
+		if err != nil {
+			rh.Logger.Error("Failed to retrieve role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		response = append(response, custom)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Creates a custom role scoped to an institution. Only permissions on the
+// allow-list may be granted and an institution may define at most
+// maxInstitutionCustomRoles roles
+func (rh *RoleHandler) CreateInstitutionCustomRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "institution_id")
+	if !ok {
+		return
+	}
+
+	var req CustomRoleRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	// Only optional when updating
+	if req.Name == nil {
+		utils.WriteValidationErrors(w, map[string]string{"name": "is required"})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	permissions, disallowed, err := resolveCustomRolePermissions(r.Context(), repo, req.Permissions)
+	if err != nil {
+		rh.Logger.Error("Failed to resolve permissions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if len(disallowed) > 0 {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":       "Custom roles may only grant allow-listed permissions",
+			"permissions": disallowed,
+		})
+		return
+	}
+
+	count, err := repo.CountInstitutionCustomRoles(r.Context(), &institutionID)
+	if err != nil {
+		rh.Logger.Error("Failed to count custom roles", slog.Any("error", err), slog.Any("institution", institutionID))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if count >= maxInstitutionCustomRoles {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("An institution may define at most %d custom roles", maxInstitutionCustomRoles),
+		})
+		return
+	}
+
+	role, err := repo.CreateCustomRole(r.Context(), repository.CreateCustomRoleParams{
+		Name:          strings.TrimSpace(*req.Name),
+		Description:   req.Description,
+		InstitutionID: &institutionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505": // unique_violation
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "A role with this name already exists, please provide a different name",
+				})
+				return
+			case "23503": // foreign_key_violation
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "The institution you specified does not exist",
+				})
+				return
+			}
+		}
+		rh.Logger.Error("Failed to create custom role", slog.Any("error", err), slog.Any("institution", institutionID))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err := setCustomRolePermissions(r.Context(), repo, role.ID, permissions); err != nil {
+		rh.Logger.Error("Failed to grant custom role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response, err := customRoleResponse(r.Context(), repo, role)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionRoleCreated, AuthzTargetRole, role.ID.String(), nil, response)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Updates the name, description or permissions of a custom role
+func (rh *RoleHandler) UpdateInstitutionCustomRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "institution_id")
+	if !ok {
+		return
+	}
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
+		return
+	}
+
+	var req CustomRoleRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	existing, err := repo.GetInstitutionCustomRole(r.Context(), repository.GetInstitutionCustomRoleParams{
+		ID:            roleID,
+		InstitutionID: &institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The custom role you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve custom role", slog.Any("error", err), slog.String("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	before, err := customRoleResponse(r.Context(), repo, existing)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role permissions", slog.Any("error", err), slog.String("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	name := existing.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
+	role, err := repo.UpdateRole(r.Context(), repository.UpdateRoleParams{
+		ID:          roleID,
+		Name:        name,
+		Description: req.Description,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A role with this name already exists, please provide a different name",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to update custom role", slog.Any("error", err), slog.String("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if req.Permissions != nil {
+		permissions, disallowed, err := resolveCustomRolePermissions(r.Context(), repo, req.Permissions)
+		if err != nil {
+			rh.Logger.Error("Failed to resolve permissions", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		if len(disallowed) > 0 {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":       "Custom roles may only grant allow-listed permissions",
+				"permissions": disallowed,
+			})
+			return
+		}
+		if err := setCustomRolePermissions(r.Context(), repo, role.ID, permissions); err != nil {
+			rh.Logger.Error("Failed to grant custom role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	response, err := customRoleResponse(r.Context(), repo, role)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionRoleUpdated, AuthzTargetRole, role.ID.String(), before, response)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Deletes a custom role together with its assignments. The role itself is
+// soft deleted and can be restored by an administrator
+func (rh *RoleHandler) DeleteInstitutionCustomRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "institution_id")
+	if !ok {
+		return
+	}
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	role, err := repo.GetInstitutionCustomRole(r.Context(), repository.GetInstitutionCustomRoleParams{
+		ID:            roleID,
+		InstitutionID: &institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The custom role you are trying to delete does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve custom role", slog.Any("error", err), slog.String("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	before, err := customRoleResponse(r.Context(), repo, role)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role permissions", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if _, err := repo.RevokeRoleAssignments(r.Context(), role.ID); err != nil {
+		rh.Logger.Error("Failed to revoke custom role assignments", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if _, err := repo.DeleteRole(r.Context(), role.ID); err != nil {
+		rh.Logger.Error("Failed to delete custom role", slog.Any("error", err), slog.String("role", role.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionRoleDeleted, AuthzTargetRole, role.ID.String(), before, nil)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Custom role successfully deleted"})
+}
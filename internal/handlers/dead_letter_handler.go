@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+)
+
+// How many dead-lettered events are listed unless a limit is given, and the
+// most that may be listed at once
+const (
+	defaultDeadLetterLimit = 20
+	maxDeadLetterLimit     = 100
+)
+
+// DeadLetterHandler lets administrators resolve events that ended up in the
+// dead-letter queue
+type DeadLetterHandler struct {
+	Logger      *slog.Logger
+	DeadLetters *eventbus.DeadLetterQueue
+}
+
+// DeadLetterListResponse lists the events at the head of the dead-letter
+// queue. Count is the number of events the queue holds
+type DeadLetterListResponse struct {
+	Queue    string                `json:"queue"`
+	Count    int                   `json:"count"`
+	Messages []eventbus.DeadLetter `json:"messages"`
+}
+
+// RegisterRoutes registers the dead-letter queue routes
+func (dh *DeadLetterHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("GET /api/v1/admin/dead-letters",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:dead_letter:any"}),
+		)(http.HandlerFunc(dh.ListDeadLetters)),
+	)
+
+	router.Handle("GET /api/v1/admin/dead-letters/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:dead_letter:any"}),
+		)(http.HandlerFunc(dh.GetDeadLetter)),
+	)
+
+	router.Handle("POST /api/v1/admin/dead-letters/{id}/requeue",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"manage:dead_letter:any"}),
+		)(http.HandlerFunc(dh.RequeueDeadLetter)),
+	)
+
+	router.Handle("DELETE /api/v1/admin/dead-letters/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"manage:dead_letter:any"}),
+		)(http.HandlerFunc(dh.DiscardDeadLetter)),
+	)
+}
+
+// Lists the events at the head of the dead-letter queue without removing
+// them
+func (dh *DeadLetterHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit := defaultDeadLetterLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxDeadLetterLimit {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": fmt.Sprintf("limit must be between 1 and %d", maxDeadLetterLimit),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	messages, count, err := dh.DeadLetters.List(r.Context(), limit)
+	if err != nil {
+		dh.writeError(w, err)
+		return
+	}
+	if messages == nil {
+		messages = []eventbus.DeadLetter{}
+	}
+
+	json.NewEncoder(w).Encode(DeadLetterListResponse{
+		Queue:    dh.DeadLetters.Name(),
+		Count:    count,
+		Messages: messages,
+	})
+}
+
+// Returns a single dead-lettered event without removing it
+func (dh *DeadLetterHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	deadLetter, err := dh.DeadLetters.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		dh.writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deadLetter)
+}
+
+// Publishes a dead-lettered event again and removes it from the queue
+func (dh *DeadLetterHandler) RequeueDeadLetter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	deadLetter, err := dh.DeadLetters.Requeue(r.Context(), r.PathValue("id"))
+	if err != nil {
+		dh.writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(deadLetter)
+}
+
+// Removes a dead-lettered event from the queue for good
+func (dh *DeadLetterHandler) DiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := dh.DeadLetters.Discard(r.Context(), r.PathValue("id")); err != nil {
+		dh.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError responds with the status matching a dead-letter queue error
+func (dh *DeadLetterHandler) writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, eventbus.ErrDeadLetterNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "No dead-lettered event has this id",
+		})
+	case errors.Is(err, eventbus.ErrDeadLettersUnsupported):
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The dead-letter queue is only available on the rabbitmq backend",
+		})
+	default:
+		dh.Logger.Error("Failed to access the dead-letter queue", slog.Any("error", err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't reach the dead-letter queue at the moment please try again later",
+		})
+	}
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// DeletedRecordsHandler lets administrators list the accounts, institutions,
+// roles and activities that were deleted and restore them. Deleted accounts
+// are the ones their owners marked for deletion and deleted institutions are
+// the archived ones
+type DeletedRecordsHandler struct {
+	Logger              *slog.Logger
+	InstitutionEventBus *eventbus.InstitutionEventBus
+	AuthzEventBus       *eventbus.AuthzEventBus
+}
+
+// RegisterRoutes registers the deleted records routes
+func (dh *DeletedRecordsHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("GET /api/v1/admin/deleted/accounts",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:deleted:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(dh.ListDeletedAccounts)))
+
+	router.Handle("GET /api/v1/admin/deleted/institutions",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:deleted:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(dh.ListDeletedInstitutions)))
+
+	router.Handle("GET /api/v1/admin/deleted/roles",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:deleted:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(dh.ListDeletedRoles)))
+
+	router.Handle("GET /api/v1/admin/deleted/activities",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"read:deleted:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(dh.ListDeletedActivities)))
+
+	router.Handle("POST /api/v1/admin/deleted/accounts/{id}/restore",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"restore:deleted:any"}),
+		)(http.HandlerFunc(dh.RestoreAccount)))
+
+	router.Handle("POST /api/v1/admin/deleted/institutions/{id}/restore",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"restore:deleted:any"}),
+		)(http.HandlerFunc(dh.RestoreInstitution)))
+
+	router.Handle("POST /api/v1/admin/deleted/roles/{id}/restore",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"restore:deleted:any"}),
+		)(http.HandlerFunc(dh.RestoreRole)))
+
+	router.Handle("POST /api/v1/admin/deleted/activities/{id}/restore",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, dh.Logger),
+			middleware.HasPermission([]string{"restore:deleted:any"}),
+		)(http.HandlerFunc(dh.RestoreActivity)))
+}
+
+// writeInternalError reports a failure the client can do nothing about
+func (dh *DeletedRecordsHandler) writeInternalError(w http.ResponseWriter, msg string, err error) {
+	dh.Logger.Error(msg, slog.Any("error", err))
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": "We couldn't complete this request at the moment please try again later",
+	})
+}
+
+// Retrieves the accounts marked for deletion, most recently marked first
+func (dh *DeletedRecordsHandler) ListDeletedAccounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+	repo := repository.New(conn)
+	p := middleware.GetPagination(r.Context())
+
+	accounts, err := repo.ListDeletedAccounts(r.Context(), repository.ListDeletedAccountsParams{
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset),
+	})
+	if err != nil {
+		dh.writeInternalError(w, "Failed to list deleted accounts", err)
+		return
+	}
+
+	totalCount, err := repo.CountDeletedAccounts(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Failed to count deleted accounts", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, accounts, p.Limit, p.Offset))
+}
+
+// Retrieves the archived institutions, most recently archived first
+func (dh *DeletedRecordsHandler) ListDeletedInstitutions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+	repo := repository.New(conn)
+	p := middleware.GetPagination(r.Context())
+
+	institutions, err := repo.ListArchivedInstitutions(r.Context(), repository.ListArchivedInstitutionsParams{
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset),
+	})
+	if err != nil {
+		dh.writeInternalError(w, "Failed to list archived institutions", err)
+		return
+	}
+
+	totalCount, err := repo.CountArchivedInstitutions(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Failed to count archived institutions", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, institutions, p.Limit, p.Offset))
+}
+
+// Retrieves the deleted roles, most recently deleted first
+func (dh *DeletedRecordsHandler) ListDeletedRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+	repo := repository.New(conn)
+	p := middleware.GetPagination(r.Context())
+
+	roles, err := repo.ListDeletedRoles(r.Context(), repository.ListDeletedRolesParams{
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset),
+	})
+	if err != nil {
+		dh.writeInternalError(w, "Failed to list deleted roles", err)
+		return
+	}
+
+	totalCount, err := repo.CountDeletedRoles(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Failed to count deleted roles", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, roles, p.Limit, p.Offset))
+}
+
+// Retrieves the deleted activities, most recently deleted first
+func (dh *DeletedRecordsHandler) ListDeletedActivities(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+	repo := repository.New(conn)
+	p := middleware.GetPagination(r.Context())
+
+	activities, err := repo.ListDeletedActivities(r.Context(), repository.ListDeletedActivitiesParams{
+		Limit:  int32(p.Limit),
+		Offset: int32(p.Offset),
+	})
+	if err != nil {
+		dh.writeInternalError(w, "Failed to list deleted activities", err)
+		return
+	}
+
+	totalCount, err := repo.CountDeletedActivities(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Failed to count deleted activities", err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, activities, p.Limit, p.Offset))
+}
+
+// Restores an account marked for deletion, the same way its owner would by
+// recovering it
+func (dh *DeletedRecordsHandler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+	repo := repository.New(conn)
+
+	restored, err := repo.MarkAccountForRecovery(r.Context(), id)
+	if err != nil {
+		dh.writeInternalError(w, "Failed to restore account", err)
+		return
+	}
+	if restored == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The account does not exist or is not marked for deletion",
+		})
+		return
+	}
+
+	account, err := repo.GetAccountByID(r.Context(), id)
+	if err != nil {
+		dh.writeInternalError(w, "Failed to retrieve restored account", err)
+		return
+	}
+
+	dh.Logger.Info("Account restored", slog.String("account", id.String()))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(account)
+}
+
+// Restores an archived institution. Accounts unlinked on archival are not
+// linked back
+func (dh *DeletedRecordsHandler) RestoreInstitution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+
+	institution, err := repository.New(conn).RestoreInstitution(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The institution does not exist or is not archived",
+		})
+		return
+	}
+	if err != nil {
+		dh.writeInternalError(w, "Failed to restore institution", err)
+		return
+	}
+
+	if dh.InstitutionEventBus != nil {
+		requestID := middleware.GetRequestID(r.Context())
+		_ = dh.InstitutionEventBus.PublishInstitutionRestored(r.Context(), institution, requestID)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(institution)
+}
+
+// Restores a deleted role together with the permissions it granted. The
+// assignments revoked when it was deleted are not restored
+func (dh *DeletedRecordsHandler) RestoreRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while beginning transaction", err)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	role, err := repo.RestoreRole(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role does not exist or is not deleted",
+		})
+		return
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Another role already has the name of this role",
+		})
+		return
+	}
+	if err != nil {
+		dh.writeInternalError(w, "Failed to restore role", err)
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionRoleRestored, AuthzTargetRole, id.String(), nil, role)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		dh.writeInternalError(w, "Failed to record rbac audit entry", err)
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		dh.writeInternalError(w, "Error while committing transaction", err)
+		return
+	}
+
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, dh.AuthzEventBus, dh.Logger, change)
+
+	dh.Logger.Info("Role restored", slog.String("role", id.String()), slog.String("name", role.Name))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(role)
+}
+
+// Restores a deleted activity. It stays inactive until it is activated again
+func (dh *DeletedRecordsHandler) RestoreActivity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		dh.writeInternalError(w, "Error while processing request", err)
+		return
+	}
+
+	activity, err := repository.New(conn).RestoreActivity(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The activity does not exist or is not deleted",
+		})
+		return
+	}
+	if err != nil {
+		dh.writeInternalError(w, "Failed to restore activity", err)
+		return
+	}
+
+	dh.Logger.Info("Activity restored", slog.String("activity", id.String()))
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(activity)
+}
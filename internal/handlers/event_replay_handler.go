@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventjournal"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// EventReplayHandler lets administrators publish journaled events again
+type EventReplayHandler struct {
+	Logger  *slog.Logger
+	Journal *eventjournal.Journal
+}
+
+// ReplayEventsRequest is the body expected when replaying events. To
+// defaults to now, EventTypes to every type and Limit to the most events a
+// replay may publish
+type ReplayEventsRequest struct {
+	EventTypes []string   `json:"event_types"`
+	From       time.Time  `json:"from" validate:"required"`
+	To         *time.Time `json:"to"`
+	AccountID  *uuid.UUID `json:"account_id"`
+	Limit      int        `json:"limit" validate:"min=0"`
+}
+
+// RegisterRoutes registers the event replay routes
+func (eh *EventReplayHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("POST /api/v1/admin/events/replay",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, eh.Logger),
+			middleware.HasPermission([]string{"replay:event:any"}),
+		)(http.HandlerFunc(eh.ReplayEvents)),
+	)
+}
+
+// Publishes the journaled events matching the filters again, oldest first
+func (eh *EventReplayHandler) ReplayEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req ReplayEventsRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	if !to.After(req.From) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Please provide a from time that is before the to time",
+		})
+		return
+	}
+
+	maxEvents := eh.Journal.MaxReplayEvents()
+	if req.Limit > maxEvents {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("limit must be between 1 and %d", maxEvents),
+		})
+		return
+	}
+
+	eventTypes := []string{}
+	for _, eventType := range req.EventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if eventType != "" {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	result, err := eh.Journal.Replay(r.Context(), eventjournal.ReplayFilter{
+		EventTypes: eventTypes,
+		From:       req.From,
+		To:         to,
+		AccountID:  req.AccountID,
+		Limit:      req.Limit,
+	})
+	if err != nil {
+		eh.Logger.Error("Failed to replay events", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	eh.Logger.Info("Replayed events",
+		slog.Any("event_types", eventTypes),
+		slog.Time("from", req.From),
+		slog.Time("to", to),
+		slog.Int("replayed", result.Replayed),
+		slog.Int("failed", result.Failed),
+	)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+// EventSigningHandler publishes the key Ed25519 event signatures are
+// verified with
+type EventSigningHandler struct {
+	Signer *eventbus.EventSigner
+}
+
+// EventSigningKeyResponse is the public key consumers verify signed events
+// with
+type EventSigningKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id,omitempty"`
+	PublicKey string `json:"public_key"`
+}
+
+// RegisterRoutes registers the event signing routes. The public key is not
+// secret so the route is not authenticated
+func (eh *EventSigningHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /api/v1/events/signing-key", eh.GetSigningKey)
+}
+
+// Returns the base64 encoded Ed25519 public key events are signed with.
+// HMAC signed events share their secret out of band, so there is no key to
+// return when events are signed with HMAC or not signed at all
+func (eh *EventSigningHandler) GetSigningKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if eh.Signer == nil || eh.Signer.PublicKey() == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Events are not signed with a public key",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventSigningKeyResponse{
+		Algorithm: eh.Signer.Algorithm(),
+		KeyID:     eh.Signer.KeyID(),
+		PublicKey: base64.StdEncoding.EncodeToString(eh.Signer.PublicKey()),
+	})
+}
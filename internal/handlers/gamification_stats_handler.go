@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// GamificationStatsResponse summarizes how accounts engage with activities,
+// streaks and milestones
+type GamificationStatsResponse struct {
+	Days                    int                                         `json:"days"`
+	Participants            int64                                       `json:"participants"`
+	DailyActiveParticipants []repository.GetDailyActiveParticipantsRow  `json:"daily_active_participants"`
+	Activities              []repository.GetActivityCompletionCountsRow `json:"activities"`
+	Streaks                 repository.GetStreakSummaryRow              `json:"streaks"`
+	Milestones              []repository.GetMilestoneAttainmentRatesRow `json:"milestones"`
+}
+
+// Returns aggregated gamification statistics.
+// The optional "days" query parameter (default 30) controls the window
+// participants and completions are counted over. Streak and milestone
+// statistics always cover every account
+func (ah *ActivityHandler) GetGamificationStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	days := queryIntInRange(r, "days", 30, 365)
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		ah.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	stats := GamificationStatsResponse{Days: days}
+
+	fail := func(msg string, err error) {
+		ah.Logger.Error(msg, slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+	}
+
+	if stats.Participants, err = repo.GetGamificationParticipantsCount(r.Context(), int32(days)); err != nil {
+		fail("Failed to count gamification participants", err)
+		return
+	}
+
+	if stats.DailyActiveParticipants, err = repo.GetDailyActiveParticipants(r.Context(), int32(days)); err != nil {
+		fail("Failed to retrieve daily active participants", err)
+		return
+	}
+
+	if stats.Activities, err = repo.GetActivityCompletionCounts(r.Context(), int32(days)); err != nil {
+		fail("Failed to retrieve activity completion counts", err)
+		return
+	}
+
+	if stats.Streaks, err = repo.GetStreakSummary(r.Context()); err != nil {
+		fail("Failed to summarize streaks", err)
+		return
+	}
+
+	if stats.Milestones, err = repo.GetMilestoneAttainmentRates(r.Context()); err != nil {
+		fail("Failed to retrieve milestone attainment rates", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
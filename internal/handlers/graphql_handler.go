@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// GraphQLHandler answers GraphQL queries over accounts, institutions, roles
+// and the leaderboard
+type GraphQLHandler struct {
+	Logger *slog.Logger
+	Schema graphql.Schema
+}
+
+// GraphQLRequest is a GraphQL query along with its variables
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"notblank"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// GraphQLResponse is the answer to a GraphQL query
+type GraphQLResponse struct {
+	Data any `json:"data"`
+	// The fields that could not be resolved along with their path
+	Errors []gqlerrors.FormattedError `json:"errors,omitempty"`
+}
+
+// RegisterRoutes registers the GraphQL route. Permissions are checked per
+// field by the schema
+func (gh *GraphQLHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("POST /api/v1/graphql",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, gh.Logger),
+		)(http.HandlerFunc(gh.ExecuteQuery)),
+	)
+}
+
+// ExecuteQuery runs a GraphQL query. Fields the caller may not read or that failed
+// to load are null and named in errors, the query is still answered with 200
+func (gh *GraphQLHandler) ExecuteQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req GraphQLRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         gh.Schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        r.Context(),
+	})
+	if result.HasErrors() {
+		gh.Logger.Debug("GraphQL query answered with errors",
+			slog.String("request_id", middleware.GetRequestID(r.Context())),
+			slog.Any("errors", result.Errors),
+		)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Data:   result.Data,
+		Errors: result.Errors,
+	})
+}
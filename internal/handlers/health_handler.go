@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+// HealthHandler reports the state of the connections Verisafe relies on to
+// deliver events
+type HealthHandler struct {
+	EventBuses map[string]eventbus.HealthReporter
+}
+
+// HealthResponse is the payload served by the health endpoint. Status is
+// "degraded" when any event bus is disconnected
+type HealthResponse struct {
+	Status     string                     `json:"status"`
+	EventBuses map[string]eventbus.Health `json:"event_buses"`
+}
+
+// Returns the state of every event bus. Verisafe keeps serving requests
+// while an event bus is disconnected so the endpoint always responds with 200
+func (hh *HealthHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := HealthResponse{
+		Status:     "ok",
+		EventBuses: map[string]eventbus.Health{},
+	}
+	for name, bus := range hh.EventBuses {
+		health := bus.Health()
+		if !health.Connected {
+			response.Status = "degraded"
+		}
+		response.EventBuses[name] = health
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// maxInstitutionMetadataSize caps the size of the free form metadata stored
+// on an institution
+const maxInstitutionMetadataSize = 16 << 10
+
+// institutionColorPattern matches hex colors such as "#0af" or "#00aaff"
+var institutionColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// institutionBranding holds the branding fields of an institution. Empty
+// fields are left unchecked
+type institutionBranding struct {
+	LogoURL        string
+	PrimaryColor   string
+	SecondaryColor string
+	Website        string
+	Timezone       string
+}
+
+// validate ensures the branding can be rendered safely by client apps
+func (b institutionBranding) validate() error {
+	if b.LogoURL != "" && !isWebURL(b.LogoURL) {
+		return errors.New("logo_url must be an http or https url")
+	}
+	if b.Website != "" && !isWebURL(b.Website) {
+		return errors.New("website must be an http or https url")
+	}
+	if b.PrimaryColor != "" && !institutionColorPattern.MatchString(b.PrimaryColor) {
+		return errors.New("primary_color must be a hex color such as #1a73e8")
+	}
+	if b.SecondaryColor != "" && !institutionColorPattern.MatchString(b.SecondaryColor) {
+		return errors.New("secondary_color must be a hex color such as #1a73e8")
+	}
+	if b.Timezone != "" {
+		if _, err := time.LoadLocation(b.Timezone); err != nil || b.Timezone == "Local" {
+			return errors.New("timezone must be an IANA time zone such as Africa/Nairobi")
+		}
+	}
+	return nil
+}
+
+// normalizeInstitutionMetadata checks that metadata is a JSON object. Missing
+// or null metadata is returned as nil
+func normalizeInstitutionMetadata(metadata json.RawMessage) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(metadata)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil, nil
+	}
+	if len(trimmed) > maxInstitutionMetadataSize {
+		return nil, errors.New("metadata must not exceed 16KB")
+	}
+	if trimmed[0] != '{' {
+		return nil, errors.New("metadata must be a JSON object")
+	}
+	return trimmed, nil
+}
+
+func isWebURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,595 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// CreateDepartmentRequest creates a department within an institution
+type CreateDepartmentRequest struct {
+	Name        string  `json:"name" validate:"notblank"`
+	Description *string `json:"description"`
+}
+
+// UpdateDepartmentRequest changes the provided fields of a department
+type UpdateDepartmentRequest struct {
+	Name        *string `json:"name" validate:"omitnil,notblank"`
+	Description *string `json:"description"`
+}
+
+// departmentPathValues parses the institution and department ids of a
+// department route. It writes the error response and reports false when
+// either id is malformed
+func departmentPathValues(w http.ResponseWriter, r *http.Request) (int32, uuid.UUID, bool) {
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return 0, uuid.Nil, false
+	}
+	departmentID, ok := utils.PathUUID(w, r, "department_id")
+	if !ok {
+		return 0, uuid.Nil, false
+	}
+	return institutionID, departmentID, true
+}
+
+// Creates a department within an institution. Only admins and owners may
+// create departments
+func (ih *InstitutionHandler) CreateInstitutionDepartment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req CreateDepartmentRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may manage its departments",
+		})
+		return
+	}
+
+	department, err := repo.CreateInstitutionDepartment(r.Context(), repository.CreateInstitutionDepartmentParams{
+		InstitutionID: institutionID,
+		Name:          req.Name,
+		Description:   req.Description,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A department with this name already exists in this institution",
+			})
+			return
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The institution you are trying to update does not exist",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to create department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(department)
+}
+
+// Lists the departments of an institution
+func (ih *InstitutionHandler) GetInstitutionDepartments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	departments, err := repo.ListInstitutionDepartments(r.Context(), institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve departments", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(departments)
+}
+
+// Retrieves a single department of an institution
+func (ih *InstitutionHandler) GetInstitutionDepartment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	department, err := repo.GetInstitutionDepartment(r.Context(), repository.GetInstitutionDepartmentParams{
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are looking for does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(department)
+}
+
+// Updates the name or description of a department. Only admins and owners
+// may update departments
+func (ih *InstitutionHandler) UpdateInstitutionDepartment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateDepartmentRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may manage its departments",
+		})
+		return
+	}
+
+	department, err := repo.UpdateInstitutionDepartment(r.Context(), repository.UpdateInstitutionDepartmentParams{
+		Name:          req.Name,
+		Description:   req.Description,
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A department with this name already exists in this institution",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to update department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(department)
+}
+
+// Deletes a department along with its member assignments. Only admins and
+// owners may delete departments
+func (ih *InstitutionHandler) DeleteInstitutionDepartment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may manage its departments",
+		})
+		return
+	}
+
+	removed, err := repo.DeleteInstitutionDepartment(r.Context(), repository.DeleteInstitutionDepartmentParams{
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to delete department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are trying to delete does not exist",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Department deleted successfully",
+	})
+}
+
+// Lists the members of a department. Only members of the institution may
+// list them
+func (ih *InstitutionHandler) GetDepartmentMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+	p := middleware.GetPagination(r.Context())
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole == "" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only members of this institution may list its department members",
+		})
+		return
+	}
+
+	if _, err := repo.GetInstitutionDepartment(r.Context(), repository.GetInstitutionDepartmentParams{
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	}); errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are looking for does not exist",
+		})
+		return
+	} else if err != nil {
+		ih.Logger.Error("Failed to retrieve department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	members, err := repo.ListDepartmentMembers(r.Context(), repository.ListDepartmentMembersParams{
+		DepartmentID: departmentID,
+		Limit:        int32(p.Limit),
+		Offset:       int32(p.Offset),
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve department members", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	totalCount, err := repo.CountDepartmentMembers(r.Context(), departmentID)
+	if err != nil {
+		ih.Logger.Error("Failed to count department members", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, members, p.Limit, p.Offset))
+}
+
+// Assigns a member of the institution to one of its departments. Only admins
+// and owners may assign members
+func (ih *InstitutionHandler) AddDepartmentMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+	accountID, ok := utils.PathUUID(w, r, "account_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may manage its departments",
+		})
+		return
+	}
+
+	if _, err := repo.GetInstitutionDepartment(r.Context(), repository.GetInstitutionDepartmentParams{
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	}); errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are looking for does not exist",
+		})
+		return
+	} else if err != nil {
+		ih.Logger.Error("Failed to retrieve department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	err = repo.AddDepartmentMember(r.Context(), repository.AddDepartmentMemberParams{
+		DepartmentID:  departmentID,
+		AccountID:     accountID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Only members of this institution may be assigned to its departments",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to add department member", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Member assigned to department successfully",
+	})
+}
+
+// Removes a member from a department. Only admins and owners may remove
+// members
+func (ih *InstitutionHandler) RemoveDepartmentMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, departmentID, ok := departmentPathValues(w, r)
+	if !ok {
+		return
+	}
+	accountID, ok := utils.PathUUID(w, r, "account_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may manage its departments",
+		})
+		return
+	}
+
+	if _, err := repo.GetInstitutionDepartment(r.Context(), repository.GetInstitutionDepartmentParams{
+		ID:            departmentID,
+		InstitutionID: institutionID,
+	}); errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The department you are looking for does not exist",
+		})
+		return
+	} else if err != nil {
+		ih.Logger.Error("Failed to retrieve department", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	removed, err := repo.RemoveDepartmentMember(r.Context(), repository.RemoveDepartmentMemberParams{
+		DepartmentID: departmentID,
+		AccountID:    accountID,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to remove department member", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This account is not a member of the department",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Member removed from department successfully",
+	})
+}
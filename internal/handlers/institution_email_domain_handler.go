@@ -0,0 +1,409 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+const (
+	// domainVerificationHost is prepended to a domain to build the name of
+	// the TXT record proving ownership of the domain
+	domainVerificationHost = "_verisafe"
+	// domainVerificationPrefix prefixes the token inside the TXT record
+	domainVerificationPrefix = "verisafe-verification="
+)
+
+// lookupTXT resolves the TXT records of a host
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+// CreateInstitutionEmailDomainRequest registers an email domain for an
+// institution
+type CreateInstitutionEmailDomainRequest struct {
+	Domain string `json:"domain" validate:"notblank"`
+}
+
+// DomainVerificationRecord describes the DNS record that proves ownership of
+// an email domain
+type DomainVerificationRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// InstitutionEmailDomainResponse is an email domain along with the DNS record
+// expected to verify it
+type InstitutionEmailDomainResponse struct {
+	repository.InstitutionEmailDomain
+	Verified  bool                     `json:"verified"`
+	DNSRecord DomainVerificationRecord `json:"dns_record"`
+}
+
+func newInstitutionEmailDomainResponse(domain repository.InstitutionEmailDomain) InstitutionEmailDomainResponse {
+	return InstitutionEmailDomainResponse{
+		InstitutionEmailDomain: domain,
+		Verified:               domain.VerifiedAt.Valid,
+		DNSRecord: DomainVerificationRecord{
+			Type:  "TXT",
+			Name:  domainVerificationHost + "." + domain.Domain,
+			Value: domainVerificationPrefix + domain.VerificationToken,
+		},
+	}
+}
+
+// normalizeEmailDomain lower cases a domain and strips a leading "@" so that
+// both "@uon.ac.ke" and "UON.ac.ke" register the same domain. An empty string
+// is returned for values that are not domains
+func normalizeEmailDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+	domain = strings.TrimSuffix(domain, ".")
+	if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@/: \t") {
+		return ""
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 {
+			return ""
+		}
+	}
+	return domain
+}
+
+// Registers an email domain for an institution. The domain only takes part in
+// auto-join once verified through DNS. Only owners may register domains
+func (ih *InstitutionHandler) CreateInstitutionEmailDomain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req CreateInstitutionEmailDomainRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.Domain = normalizeEmailDomain(req.Domain)
+	if req.Domain == "" {
+		utils.WriteValidationErrors(w, map[string]string{"domain": "must be a valid domain e.g. example.edu"})
+		return
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		ih.Logger.Error("Failed to generate domain verification token", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	callerID, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole != repository.InstitutionMembershipRoleOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only owners of this institution may register email domains",
+		})
+		return
+	}
+
+	domain, err := repo.CreateInstitutionEmailDomain(r.Context(), repository.CreateInstitutionEmailDomainParams{
+		InstitutionID:     institutionID,
+		Domain:            req.Domain,
+		VerificationToken: hex.EncodeToString(token),
+		CreatedBy:         pgtype.UUID{Bytes: callerID, Valid: true},
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This domain is already registered for this institution",
+			})
+			return
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The institution you are trying to update does not exist",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to register institution email domain", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newInstitutionEmailDomainResponse(domain))
+}
+
+// Lists the email domains registered for an institution along with their
+// verification records
+func (ih *InstitutionHandler) GetInstitutionEmailDomains(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may view its email domains",
+		})
+		return
+	}
+
+	domains, err := repo.GetInstitutionEmailDomains(r.Context(), institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution email domains", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]InstitutionEmailDomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		response = append(response, newInstitutionEmailDomainResponse(domain))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Verifies an email domain by looking up its TXT record. Once verified,
+// accounts signing up with an email on the domain join the institution
+// automatically
+func (ih *InstitutionHandler) VerifyInstitutionEmailDomain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+	domainID, ok := utils.PathUUID(w, r, "domain_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole != repository.InstitutionMembershipRoleOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only owners of this institution may verify email domains",
+		})
+		return
+	}
+
+	domain, err := repo.GetInstitutionEmailDomainByID(r.Context(), domainID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && domain.InstitutionID != institutionID) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The email domain you are trying to verify does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution email domain", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if domain.VerifiedAt.Valid {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(newInstitutionEmailDomainResponse(domain))
+		return
+	}
+
+	expected := newInstitutionEmailDomainResponse(domain).DNSRecord
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	records, err := lookupTXT(ctx, expected.Name)
+	if err != nil {
+		ih.Logger.Info("Domain verification lookup failed",
+			slog.String("domain", domain.Domain),
+			slog.Any("error", err),
+		)
+	}
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected.Value {
+			found = true
+			break
+		}
+	}
+	if !found {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":      "We couldn't find the verification record for this domain. DNS changes may take a while to propagate",
+			"dns_record": expected,
+		})
+		return
+	}
+
+	domain, err = repo.VerifyInstitutionEmailDomain(r.Context(), domain.ID)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This domain has already been verified by another institution",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to verify institution email domain", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newInstitutionEmailDomainResponse(domain))
+}
+
+// Removes an email domain from an institution. Accounts that already joined
+// through the domain stay members
+func (ih *InstitutionHandler) DeleteInstitutionEmailDomain(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+	domainID, ok := utils.PathUUID(w, r, "domain_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole != repository.InstitutionMembershipRoleOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only owners of this institution may remove email domains",
+		})
+		return
+	}
+
+	removed, err := repo.DeleteInstitutionEmailDomain(r.Context(), repository.DeleteInstitutionEmailDomainParams{
+		ID:            domainID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to remove institution email domain", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if removed == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The email domain you are trying to remove does not exist",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Email domain removed successfully",
+	})
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -12,79 +13,239 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/opencrafts-io/verisafe/internal/config"
 	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type InstitutionHandler struct {
-	Logger              *slog.Logger
-	InstitutionEventBus *eventbus.InstitutionEventBus
+	Logger               *slog.Logger
+	Cfg                  *config.Config
+	InstitutionEventBus  *eventbus.InstitutionEventBus
+	NotificationEventBus *eventbus.NotificationEventBus
 }
 
 func (ih *InstitutionHandler) RegisterInstitutionHadlers(cfg *config.Config, router *http.ServeMux) {
 	// Register endpoints using the new pattern
-	router.Handle("POST /institutions/register", middleware.CreateStack(
+	router.Handle("POST /api/v1/institutions/register", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, ih.Logger),
 		middleware.HasPermission([]string{"create:institutions:any"}),
 	)(http.HandlerFunc(ih.RegisterInstitution)))
 
-	router.Handle("GET /institutions/fanout",
+	router.Handle("GET /api/v1/institutions/fanout",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
 			middleware.HasPermission([]string{"create:institutions:any"}),
 		)(http.HandlerFunc(ih.FanoutInstitutions)))
 
-	router.Handle("PATCH /institutions/update/{id}",
+	router.Handle("PATCH /api/v1/institutions/update/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
-			middleware.HasPermission([]string{"update:institutions:any"}),
+			middleware.HasInstitutionPermission([]string{"update:institutions:any"}, "id"),
 		)(http.HandlerFunc(ih.UpdateInstitutionDetails)))
 
-	router.Handle("GET /institutions/find/{id}",
+	router.Handle("GET /api/v1/institutions/find/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.Cacheable(5 * time.Minute),
 		)(http.HandlerFunc(ih.GetInstitutionByID)))
 
-	router.Handle("GET /institutions/all",
+	router.Handle("GET /api/v1/institutions/all",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
 			middleware.HasPermission([]string{"list:institutions:any"}),
+			middleware.PaginationMiddleware(10, 100),
+			middleware.Cacheable(5 * time.Minute),
 		)(http.HandlerFunc(ih.GetAllInstitutions)))
 
-	router.Handle("GET /institutions/search",
+	router.Handle("GET /api/v1/institutions/search",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
+			middleware.Cacheable(5 * time.Minute),
 		)(http.HandlerFunc(ih.SearchInstitutions)))
 
-	router.Handle("DELETE /institutions/delete/{id}",
+	router.Handle("DELETE /api/v1/institutions/delete/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
 			middleware.HasPermission([]string{"delete:institutions:any"}),
 		)(http.HandlerFunc(ih.DeleteInstitution)))
 
-	// Institution account management
-	// TODO: (erick) Add fine permissions for both admin and the user in question
-	router.Handle("POST /institutions/account",
+	router.Handle("POST /api/v1/institutions/restore/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.HasPermission([]string{"delete:institutions:any"}),
+		)(http.HandlerFunc(ih.RestoreInstitution)))
+
+	// Institution account management. Accounts join through invitations or
+	// join requests and may leave on their own while managing other members
+	// depends on the caller's membership role
+	router.Handle("POST /api/v1/institutions/account",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
 		)(http.HandlerFunc(ih.AddAcountInstitution)))
 
-	router.Handle("DELETE /institutions/account",
+	router.Handle("DELETE /api/v1/institutions/account",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
 		)(http.HandlerFunc(ih.RemoveAccountInstitution)))
 
-	router.Handle("GET /institutions/for-account",
+	router.Handle("GET /api/v1/institutions/for-account",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
 		)(http.HandlerFunc((ih.ListInstitutionForAccount))))
 
-	router.Handle("GET /institutions/accounts",
+	router.Handle("GET /api/v1/institutions/accounts",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
 		)(http.HandlerFunc(ih.ListAccountsForInstitution)))
+
+	router.Handle("GET /api/v1/institutions/members/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(ih.ListInstitutionMembers)))
+
+	router.Handle("PATCH /api/v1/institutions/members/{id}/{account_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.UpdateMemberRole)))
+
+	// Bulk member imports run in the background. The status route is keyed by
+	// the import alone as a nested path would clash with the department routes
+	router.Handle("POST /api/v1/institutions/{id}/members/import",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.ImportInstitutionMembers)))
+
+	router.Handle("GET /api/v1/institutions/imports/{job_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.GetInstitutionMemberImport)))
+
+	// Invitations
+	router.Handle("POST /api/v1/institutions/invitations/accept",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.AcceptInstitutionInvitation)))
+
+	// The invitations of an institution are nested below it, which overlaps
+	// routes like /api/v1/institutions/domains/{id} that the router cannot
+	// register alongside them. They are served by a router of their own for
+	// the requests that no such route matches
+	institutionRoutes := http.NewServeMux()
+	router.Handle("POST /api/v1/institutions/{id}/{relation}", institutionRoutes)
+	router.Handle("GET /api/v1/institutions/{id}/{relation}", institutionRoutes)
+	router.Handle("DELETE /api/v1/institutions/{id}/{relation}/{relation_id}", institutionRoutes)
+
+	institutionRoutes.Handle("POST /api/v1/institutions/{id}/invitations",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.CreateInstitutionInvitation)))
+
+	institutionRoutes.Handle("GET /api/v1/institutions/{id}/invitations",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(ih.GetInstitutionInvitations)))
+
+	institutionRoutes.Handle("DELETE /api/v1/institutions/{id}/invitations/{invitation_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.RevokeInstitutionInvitation)))
+
+	// Join requests
+	router.Handle("POST /api/v1/institutions/join-requests/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.RequestToJoinInstitution)))
+
+	router.Handle("GET /api/v1/institutions/join-requests/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(ih.GetInstitutionJoinRequests)))
+
+	router.Handle("POST /api/v1/institutions/join-requests/{id}/{request_id}/approve",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.ApproveInstitutionJoinRequest)))
+
+	router.Handle("POST /api/v1/institutions/join-requests/{id}/{request_id}/reject",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.RejectInstitutionJoinRequest)))
+
+	// Email domains used to link new accounts automatically
+	router.Handle("POST /api/v1/institutions/domains/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.CreateInstitutionEmailDomain)))
+
+	router.Handle("GET /api/v1/institutions/domains/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.GetInstitutionEmailDomains)))
+
+	router.Handle("POST /api/v1/institutions/domains/{id}/{domain_id}/verify",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.VerifyInstitutionEmailDomain)))
+
+	router.Handle("DELETE /api/v1/institutions/domains/{id}/{domain_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.DeleteInstitutionEmailDomain)))
+
+	// Departments
+	router.Handle("POST /api/v1/institutions/departments/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.CreateInstitutionDepartment)))
+
+	router.Handle("GET /api/v1/institutions/departments/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.Cacheable(5 * time.Minute),
+		)(http.HandlerFunc(ih.GetInstitutionDepartments)))
+
+	router.Handle("GET /api/v1/institutions/departments/{id}/{department_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.Cacheable(5 * time.Minute),
+		)(http.HandlerFunc(ih.GetInstitutionDepartment)))
+
+	router.Handle("PATCH /api/v1/institutions/departments/{id}/{department_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.UpdateInstitutionDepartment)))
+
+	router.Handle("DELETE /api/v1/institutions/departments/{id}/{department_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.DeleteInstitutionDepartment)))
+
+	router.Handle("GET /api/v1/institutions/departments/{id}/{department_id}/members",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(ih.GetDepartmentMembers)))
+
+	router.Handle("PUT /api/v1/institutions/departments/{id}/{department_id}/members/{account_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.AddDepartmentMember)))
+
+	router.Handle("DELETE /api/v1/institutions/departments/{id}/{department_id}/members/{account_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, ih.Logger),
+		)(http.HandlerFunc(ih.RemoveDepartmentMember)))
 }
 
 // POST /institutions/register
@@ -102,11 +263,29 @@ func (ih *InstitutionHandler) RegisterInstitution(w http.ResponseWriter, r *http
 	repo := repository.New(tx)
 
 	var req repository.CreateInstitutionParams
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ih.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	branding := institutionBranding{
+		LogoURL:        stringValue(req.LogoUrl),
+		PrimaryColor:   stringValue(req.PrimaryColor),
+		SecondaryColor: stringValue(req.SecondaryColor),
+		Website:        stringValue(req.Website),
+		Timezone:       stringValue(req.Timezone),
+	}
+	if err := branding.validate(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	req.Metadata, err = normalizeInstitutionMetadata(req.Metadata)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
 		return
 	}
+	if req.Metadata == nil {
+		req.Metadata = json.RawMessage(`{}`)
+	}
 
 	created, err := repo.CreateInstitution(r.Context(), req)
 	if err != nil {
@@ -121,7 +300,7 @@ func (ih *InstitutionHandler) RegisterInstitution(w http.ResponseWriter, r *http
 		return
 	}
 	if ih.InstitutionEventBus != nil {
-		requestID := eventbus.GenerateRequestID()
+		requestID := middleware.GetRequestID(r.Context())
 		_ = ih.InstitutionEventBus.PublishInstitutionCreated(r.Context(), created, requestID)
 	}
 
@@ -144,20 +323,33 @@ func (ih *InstitutionHandler) UpdateInstitutionDetails(w http.ResponseWriter, r
 	repo := repository.New(tx)
 
 	// Extract ID from URL
-	idStr := r.PathValue("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, `{"error":"invalid institution id"}`, http.StatusBadRequest)
+	id, ok := utils.PathInt32(w, r, "id")
+	if !ok {
 		return
 	}
 
 	var req repository.UpdateInstitutionParams
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ih.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.InstitutionID = id
+
+	branding := institutionBranding{
+		LogoURL:        req.LogoUrl,
+		PrimaryColor:   req.PrimaryColor,
+		SecondaryColor: req.SecondaryColor,
+		Website:        req.Website,
+		Timezone:       req.Timezone,
+	}
+	if err := branding.validate(); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
+		return
+	}
+	req.Metadata, err = normalizeInstitutionMetadata(req.Metadata)
+	if err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":%q}`, err.Error()), http.StatusBadRequest)
 		return
 	}
-	req.InstitutionID = int32(id)
 
 	updated, err := repo.UpdateInstitution(r.Context(), req)
 	if err != nil {
@@ -172,7 +364,7 @@ func (ih *InstitutionHandler) UpdateInstitutionDetails(w http.ResponseWriter, r
 		return
 	}
 	if ih.InstitutionEventBus != nil {
-		requestID := eventbus.GenerateRequestID()
+		requestID := middleware.GetRequestID(r.Context())
 		_ = ih.InstitutionEventBus.PublishInstitutionUpdated(r.Context(), updated, requestID)
 	}
 
@@ -190,14 +382,12 @@ func (ih *InstitutionHandler) GetInstitutionByID(w http.ResponseWriter, r *http.
 	}
 	repo := repository.New(conn)
 
-	idStr := r.PathValue("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, `{"error":"invalid institution id"}`, http.StatusBadRequest)
+	id, ok := utils.PathInt32(w, r, "id")
+	if !ok {
 		return
 	}
 
-	institution, err := repo.GetInstitution(r.Context(), int32(id))
+	institution, err := repo.GetInstitution(r.Context(), id)
 	if err != nil {
 		ih.Logger.Error("Failed to get institution", slog.Any("error", err))
 		http.Error(w, `{"error":"institution not found"}`, http.StatusNotFound)
@@ -210,7 +400,7 @@ func (ih *InstitutionHandler) GetInstitutionByID(w http.ResponseWriter, r *http.
 // GET /institutions/all
 func (ih *InstitutionHandler) GetAllInstitutions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ih.Logger.Error("Error while processing request", slog.Any("error", err))
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -231,10 +421,19 @@ func (ih *InstitutionHandler) GetAllInstitutions(w http.ResponseWriter, r *http.
 		return
 	}
 
-	json.NewEncoder(w).Encode(institutions)
+	totalCount, err := repo.GetInstitutionsCount(r.Context())
+	if err != nil {
+		ih.Logger.Error("Failed to count institutions", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to fetch institutions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, institutions, p.Limit, p.Offset))
 }
 
 // DELETE /institutions/delete/{id}
+// Institutions are archived rather than removed. Archiving unlinks every
+// account and revokes pending invitations
 func (ih *InstitutionHandler) DeleteInstitution(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	conn, err := middleware.GetDBConnFromContext(r.Context())
@@ -248,22 +447,27 @@ func (ih *InstitutionHandler) DeleteInstitution(w http.ResponseWriter, r *http.R
 	defer tx.Rollback(r.Context())
 	repo := repository.New(tx)
 
-	idStr := r.PathValue("id")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, `{"error":"invalid institution id"}`, http.StatusBadRequest)
+	id, ok := utils.PathInt32(w, r, "id")
+	if !ok {
 		return
 	}
 
-	institution, err := repo.GetInstitution(r.Context(), int32(id))
+	institution, err := repo.ArchiveInstitution(r.Context(), id)
 	if err != nil {
-		ih.Logger.Error("Failed to get institution", slog.Any("error", err))
+		ih.Logger.Error("Failed to archive institution", slog.Any("error", err))
 		http.Error(w, `{"error":"institution not found"}`, http.StatusNotFound)
 		return
 	}
 
-	if err := repo.DeleteInstitution(r.Context(), int32(id)); err != nil {
-		ih.Logger.Error("Failed to delete institution", slog.Any("error", err))
+	unlinked, err := repo.UnlinkInstitutionAccounts(r.Context(), id)
+	if err != nil {
+		ih.Logger.Error("Failed to unlink institution accounts", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to delete institution"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if err := repo.RevokeInstitutionInvitations(r.Context(), id); err != nil {
+		ih.Logger.Error("Failed to revoke institution invitations", slog.Any("error", err))
 		http.Error(w, `{"error":"failed to delete institution"}`, http.StatusInternalServerError)
 		return
 	}
@@ -274,18 +478,60 @@ func (ih *InstitutionHandler) DeleteInstitution(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	ih.Logger.Info("Institution archived",
+		slog.Any("institution_id", institution.InstitutionID),
+		slog.Int64("unlinked_accounts", unlinked),
+	)
+
 	if ih.InstitutionEventBus != nil {
-		requestID := eventbus.GenerateRequestID()
+		requestID := middleware.GetRequestID(r.Context())
 		_ = ih.InstitutionEventBus.PublishInstitutionDeleted(r.Context(), institution, requestID)
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// POST /institutions/restore/{id}
+// Restores an archived institution. Accounts unlinked on archival are not
+// linked back
+func (ih *InstitutionHandler) RestoreInstitution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	id, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	institution, err := repo.RestoreInstitution(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"archived institution not found"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to restore institution", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to restore institution"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if ih.InstitutionEventBus != nil {
+		requestID := middleware.GetRequestID(r.Context())
+		_ = ih.InstitutionEventBus.PublishInstitutionRestored(r.Context(), institution, requestID)
+	}
+
+	json.NewEncoder(w).Encode(institution)
+}
+
 func (ih *InstitutionHandler) SearchInstitutions(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ih.Logger.Error("DB connection missing", slog.Any("error", err))
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -314,7 +560,15 @@ func (ih *InstitutionHandler) SearchInstitutions(w http.ResponseWriter, r *http.
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(institutions); err != nil {
+	totalCount, err := repo.CountInstitutionsByName(r.Context(), q)
+	if err != nil {
+		ih.Logger.Error("Failed to count search results", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to search institutions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	response := pagination.BuildLimitOffsetResponse(r, totalCount, institutions, p.Limit, p.Offset)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
 		ih.Logger.Error("Failed to encode response", slog.Any("error", err))
 	}
 }
@@ -334,9 +588,23 @@ func (ih *InstitutionHandler) AddAcountInstitution(w http.ResponseWriter, r *htt
 	repo := repository.New(tx)
 
 	var req repository.AddAccountInstitutionParams
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ih.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	if req.MembershipRole == "" {
+		req.MembershipRole = repository.InstitutionMembershipRoleMember
+	}
+
+	// Linking accounts directly is reserved to the institution's admins and
+	// owners. Everyone else asks to join through a join request
+	_, callerRole, err := callerMembershipRole(r, repo, req.InstitutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if !canManageMember(callerRole, req.MembershipRole) {
+		http.Error(w, `{"error":"you are not allowed to manage the members of that organization"}`, http.StatusForbidden)
 		return
 	}
 
@@ -394,13 +662,20 @@ func (ih *InstitutionHandler) ListInstitutionForAccount(w http.ResponseWriter, r
 		return
 	}
 
-	json.NewEncoder(w).Encode(institutions)
+	totalCount, err := repo.CountInstitutionsForAccount(r.Context(), id)
+	if err != nil {
+		ih.Logger.Error("Failed to count institutions", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to fetch institutions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, institutions, p.Limit, p.Offset))
 }
 
 // Get accounts that are registered to an institution
 func (ih *InstitutionHandler) ListAccountsForInstitution(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		ih.Logger.Error("Error while processing request", slog.Any("error", err))
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
@@ -435,7 +710,14 @@ func (ih *InstitutionHandler) ListAccountsForInstitution(w http.ResponseWriter,
 		return
 	}
 
-	json.NewEncoder(w).Encode(institutions)
+	totalCount, err := repo.CountInstitutionMembers(r.Context(), int32(id))
+	if err != nil {
+		ih.Logger.Error("Failed to count institution accounts", slog.Any("error", err))
+		http.Error(w, `{"error":"failed to fetch institutions"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, institutions, p.Limit, p.Offset))
 }
 
 // Remove an account from an institution
@@ -453,9 +735,45 @@ func (ih *InstitutionHandler) RemoveAccountInstitution(w http.ResponseWriter, r
 	repo := repository.New(tx)
 
 	var req repository.RemoveAccountInstitutionParams
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		ih.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	membership, err := repo.GetInstitutionMembership(r.Context(), repository.GetInstitutionMembershipParams{
+		AccountID:     req.AccountID,
+		InstitutionID: req.InstitutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		http.Error(w, `{"error":"that account is not a member of that organization"}`, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Accounts may always leave while removing someone else requires
+	// outranking them
+	callerID, callerRole, err := callerMembershipRole(r, repo, req.InstitutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if req.AccountID != callerID && !canManageMember(callerRole, membership.MembershipRole) {
+		http.Error(w, `{"error":"you are not allowed to manage the members of that organization"}`, http.StatusForbidden)
+		return
+	}
+
+	lastOwner, err := isLastInstitutionOwner(r.Context(), repo, membership)
+	if err != nil {
+		ih.Logger.Error("Failed to count institution owners", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	if lastOwner {
+		http.Error(w, `{"error":"an organization must keep at least one owner"}`, http.StatusConflict)
 		return
 	}
 
@@ -504,6 +822,9 @@ func (ih *InstitutionHandler) FanoutInstitutions(w http.ResponseWriter, r *http.
 	batchSize := 1000
 	totalBatches := (int(institutionCount) + batchSize - 1) / batchSize
 
+	// Every event published by the fanout is traced back to this request
+	requestID := middleware.GetRequestID(r.Context())
+
 	var publishedCount int64
 	workerCount := 5
 	semaphore := make(chan struct{}, workerCount)
@@ -540,7 +861,6 @@ func (ih *InstitutionHandler) FanoutInstitutions(w http.ResponseWriter, r *http.
 
 			// Publish each institution
 			for _, institution := range institutions {
-				requestID := eventbus.GenerateRequestID()
 				if err := ih.InstitutionEventBus.PublishInstitutionCreated(ctx, institution, requestID); err != nil {
 					ih.Logger.Error("Error publishing institution",
 						slog.Any("error", err),
@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// CreateInstitutionInvitationRequest invites an email address to join an
+// institution
+type CreateInstitutionInvitationRequest struct {
+	Email          string                               `json:"email" validate:"required,email"`
+	MembershipRole repository.InstitutionMembershipRole `json:"membership_role" validate:"omitempty,oneof=owner admin member"`
+}
+
+// AcceptInstitutionInvitationRequest carries the token received by email
+type AcceptInstitutionInvitationRequest struct {
+	Token string `json:"token" validate:"notblank"`
+}
+
+// Invites an email address to join an institution. The invitee receives a
+// signed token by email which links their account to the institution once
+// accepted. Only admins and owners may invite and only owners may invite
+// admins or owners
+func (ih *InstitutionHandler) CreateInstitutionInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req CreateInstitutionInvitationRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.Email = utils.NormalizeEmail(req.Email)
+	if req.MembershipRole == "" {
+		req.MembershipRole = repository.InstitutionMembershipRoleMember
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	callerID, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, req.MembershipRole) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You are not allowed to invite members with this role to this institution",
+		})
+		return
+	}
+
+	institution, err := repo.GetInstitution(r.Context(), institutionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The institution you are inviting to does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if institution.ArchivedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You can no longer invite members to this institution as it has been archived",
+		})
+		return
+	}
+
+	// A new invitation replaces any earlier one still pending for the email
+	err = repo.RevokePendingInstitutionInvitations(r.Context(), repository.RevokePendingInstitutionInvitationsParams{
+		InstitutionID: institution.InstitutionID,
+		Email:         req.Email,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to revoke pending invitations", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	ttl := time.Duration(ih.Cfg.InstitutionConfig.InvitationTTLHours) * time.Hour
+	invitation, err := repo.CreateInstitutionInvitation(r.Context(), repository.CreateInstitutionInvitationParams{
+		InstitutionID:  institution.InstitutionID,
+		Email:          req.Email,
+		MembershipRole: req.MembershipRole,
+		InvitedBy:      pgtype.UUID{Bytes: callerID, Valid: true},
+		ExpiresAt:      pgtype.Timestamp{Time: time.Now().Add(ttl), Valid: true},
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to create institution invitation", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ih.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	ih.sendInstitutionInvitation(r.Context(), institution, invitation)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+// Lists the invitations of an institution that can still be accepted
+func (ih *InstitutionHandler) GetInstitutionInvitations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may view its invitations",
+		})
+		return
+	}
+
+	pagination := middleware.GetPagination(r.Context())
+	invitations, err := repo.GetPendingInstitutionInvitations(r.Context(), repository.GetPendingInstitutionInvitationsParams{
+		InstitutionID: institutionID,
+		Limit:         int32(pagination.Limit),
+		Offset:        int32(pagination.Offset),
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution invitations", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(invitations)
+}
+
+// Revokes a pending invitation so its token can no longer be accepted
+func (ih *InstitutionHandler) RevokeInstitutionInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+	invitationID, ok := utils.PathUUID(w, r, "invitation_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may revoke its invitations",
+		})
+		return
+	}
+
+	revoked, err := repo.RevokeInstitutionInvitation(r.Context(), repository.RevokeInstitutionInvitationParams{
+		ID:            invitationID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to revoke institution invitation", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if revoked == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "No pending invitation matches the one you are trying to revoke",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Invitation successfully revoked"})
+}
+
+// Accepts an invitation on behalf of the caller. The invitation must have been
+// sent to the caller's email address and still be pending
+func (ih *InstitutionHandler) AcceptInstitutionInvitation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var req AcceptInstitutionInvitationRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	invitationID, err := utils.ParseInvitationToken(strings.TrimSpace(req.Token), ih.Cfg.JWTConfig.ApiSecret)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This invitation link is invalid",
+		})
+		return
+	}
+
+	account := r.Context().Value(middleware.AuthUserAccount).(repository.Account)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	invitation, err := repo.GetInstitutionInvitationByID(r.Context(), invitationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This invitation does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution invitation", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if invitation.Email != utils.NormalizeEmail(account.Email) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This invitation was sent to a different email address",
+		})
+		return
+	}
+
+	invitation, err = repo.AcceptInstitutionInvitation(r.Context(), repository.AcceptInstitutionInvitationParams{
+		ID:         invitation.ID,
+		AcceptedBy: pgtype.UUID{Bytes: account.ID, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This invitation has expired or is no longer valid",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to accept institution invitation", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	membership, err := repo.AddAccountInstitution(r.Context(), repository.AddAccountInstitutionParams{
+		AccountID:      account.ID,
+		InstitutionID:  invitation.InstitutionID,
+		MembershipRole: invitation.MembershipRole,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to link account to institution", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	// Existing members are only ever promoted by an invitation
+	if membershipRanks[invitation.MembershipRole] > membershipRanks[membership.MembershipRole] {
+		_, err = repo.UpdateInstitutionMembershipRole(r.Context(), repository.UpdateInstitutionMembershipRoleParams{
+			AccountID:      account.ID,
+			InstitutionID:  invitation.InstitutionID,
+			MembershipRole: invitation.MembershipRole,
+		})
+		if err != nil {
+			ih.Logger.Error("Failed to update membership role", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		membership.MembershipRole = invitation.MembershipRole
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ih.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(membership)
+}
+
+// sendInstitutionInvitation emails the signed invitation token to the invitee
+func (ih *InstitutionHandler) sendInstitutionInvitation(ctx context.Context, institution repository.Institution, invitation repository.InstitutionInvitation) {
+	if ih.NotificationEventBus == nil {
+		return
+	}
+
+	token := utils.SignInvitationToken(invitation.ID, ih.Cfg.JWTConfig.ApiSecret)
+	link := ih.Cfg.InstitutionConfig.InvitationURL + "?token=" + url.QueryEscape(token)
+	email := eventbus.EmailPayload{
+		To:      []string{invitation.Email},
+		Subject: fmt.Sprintf("You have been invited to join %s", institution.Name),
+		Body: fmt.Sprintf("You have been invited to join %s on Academia as a %s. The invitation expires on %s.",
+			institution.Name, invitation.MembershipRole, invitation.ExpiresAt.Time.Format("2 January 2006"),
+		),
+		ActionURL: link,
+	}
+
+	eventRequestID := middleware.GetRequestID(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := ih.NotificationEventBus.PublishEmailNotificationRequested(ctx, email, eventRequestID); err != nil {
+			ih.Logger.Error("Failed to publish institution invitation email",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("invitation_id", invitation.ID),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// JoinInstitutionRequest is the optional body accepted when asking to join an
+// institution
+type JoinInstitutionRequest struct {
+	Message *string `json:"message"`
+}
+
+// ReviewJoinRequest is the optional body accepted when approving or rejecting
+// a join request
+type ReviewJoinRequest struct {
+	Note *string `json:"note"`
+}
+
+// Asks to join an institution. The caller becomes a member once an admin or
+// owner of the institution approves the request
+func (ih *InstitutionHandler) RequestToJoinInstitution(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body JoinInstitutionRequest
+	if !utils.DecodeAndValidate(w, r, &body) {
+		return
+	}
+
+	account := r.Context().Value(middleware.AuthUserAccount).(repository.Account)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	institution, err := repo.GetInstitution(r.Context(), institutionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The institution you are trying to join does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if institution.ArchivedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You can no longer join this institution as it has been archived",
+		})
+		return
+	}
+
+	member, err := repo.IsAccountInInstitution(r.Context(), repository.IsAccountInInstitutionParams{
+		AccountID:     account.ID,
+		InstitutionID: institution.InstitutionID,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if member {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You are already a member of this institution",
+		})
+		return
+	}
+
+	request, err := repo.CreateInstitutionJoinRequest(r.Context(), repository.CreateInstitutionJoinRequestParams{
+		InstitutionID: institution.InstitutionID,
+		AccountID:     account.ID,
+		Message:       body.Message,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Your request to join this institution is already awaiting approval",
+			})
+			return
+		}
+		ih.Logger.Error("Failed to create institution join request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	managers, err := repo.GetInstitutionManagers(r.Context(), institution.InstitutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution managers", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ih.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	recipients := make([]string, 0, len(managers))
+	for _, manager := range managers {
+		recipients = append(recipients, manager.String())
+	}
+	ih.sendInstitutionNotification(r.Context(), eventbus.NotificationPayload{
+		Headings: map[string]string{
+			"en": "New request to join",
+		},
+		Contents: map[string]string{
+			"en": fmt.Sprintf("%s would like to join %s", account.Name, institution.Name),
+		},
+		IncludeExternalUserIds: recipients,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(request)
+}
+
+// Lists the join requests of an institution newest first. Requests may be
+// filtered by status
+func (ih *InstitutionHandler) GetInstitutionJoinRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	pagination := middleware.GetPagination(r.Context())
+	params := repository.GetInstitutionJoinRequestsParams{
+		InstitutionID: institutionID,
+		Limit:         int32(pagination.Limit),
+		Offset:        int32(pagination.Offset),
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch repository.InstitutionJoinRequestStatus(status) {
+		case repository.InstitutionJoinRequestStatusPending,
+			repository.InstitutionJoinRequestStatusApproved,
+			repository.InstitutionJoinRequestStatusRejected:
+			params.Status = repository.NullInstitutionJoinRequestStatus{
+				InstitutionJoinRequestStatus: repository.InstitutionJoinRequestStatus(status),
+				Valid:                        true,
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Status must be one of pending, approved or rejected",
+			})
+			return
+		}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may view its join requests",
+		})
+		return
+	}
+
+	requests, err := repo.GetInstitutionJoinRequests(r.Context(), params)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution join requests", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(requests)
+}
+
+// Approves a pending join request and links the account as a member
+func (ih *InstitutionHandler) ApproveInstitutionJoinRequest(w http.ResponseWriter, r *http.Request) {
+	ih.reviewInstitutionJoinRequest(w, r, repository.InstitutionJoinRequestStatusApproved)
+}
+
+// Rejects a pending join request
+func (ih *InstitutionHandler) RejectInstitutionJoinRequest(w http.ResponseWriter, r *http.Request) {
+	ih.reviewInstitutionJoinRequest(w, r, repository.InstitutionJoinRequestStatusRejected)
+}
+
+// reviewInstitutionJoinRequest settles a pending join request on behalf of an
+// admin or owner of the institution, publishes the decision and lets the
+// requester know about it
+func (ih *InstitutionHandler) reviewInstitutionJoinRequest(w http.ResponseWriter, r *http.Request,
+	status repository.InstitutionJoinRequestStatus,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+	requestID, ok := utils.PathUUID(w, r, "request_id")
+	if !ok {
+		return
+	}
+
+	var body ReviewJoinRequest
+	if !utils.DecodeAndValidate(w, r, &body) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	callerID, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may review its join requests",
+		})
+		return
+	}
+
+	request, err := repo.GetInstitutionJoinRequestByID(r.Context(), requestID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && request.InstitutionID != institutionID) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The join request you are trying to review does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution join request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	request, err = repo.ReviewInstitutionJoinRequest(r.Context(), repository.ReviewInstitutionJoinRequestParams{
+		ID:         request.ID,
+		Status:     status,
+		ReviewedBy: pgtype.UUID{Bytes: callerID, Valid: true},
+		ReviewNote: body.Note,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This join request has already been reviewed",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to review institution join request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if status == repository.InstitutionJoinRequestStatusApproved {
+		_, err = repo.AddAccountInstitution(r.Context(), repository.AddAccountInstitutionParams{
+			AccountID:      request.AccountID,
+			InstitutionID:  request.InstitutionID,
+			MembershipRole: repository.InstitutionMembershipRoleMember,
+		})
+		if err != nil {
+			ih.Logger.Error("Failed to link account to institution", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	institution, err := repo.GetInstitution(r.Context(), request.InstitutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ih.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	if ih.InstitutionEventBus != nil {
+		eventRequestID := middleware.GetRequestID(r.Context())
+		if err := ih.InstitutionEventBus.PublishJoinRequestDecided(r.Context(), request, eventRequestID); err != nil {
+			ih.Logger.Error("Failed to publish join request decision",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	outcome := fmt.Sprintf("Your request to join %s was approved", institution.Name)
+	if status == repository.InstitutionJoinRequestStatusRejected {
+		outcome = fmt.Sprintf("Your request to join %s was declined", institution.Name)
+	}
+	ih.sendInstitutionNotification(r.Context(), eventbus.NotificationPayload{
+		Headings: map[string]string{
+			"en": "Join request reviewed",
+		},
+		Contents: map[string]string{
+			"en": outcome,
+		},
+		TargetUserID: request.AccountID.String(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(request)
+}
+
+// sendInstitutionNotification pushes a notification about institution
+// membership through gossip-monger
+func (ih *InstitutionHandler) sendInstitutionNotification(ctx context.Context, notification eventbus.NotificationPayload) {
+	if ih.NotificationEventBus == nil {
+		return
+	}
+	if notification.TargetUserID == "" && len(notification.IncludeExternalUserIds) == 0 {
+		return
+	}
+
+	fillNotificationDefaults(ih.Cfg, &notification)
+
+	eventRequestID := middleware.GetRequestID(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := ih.NotificationEventBus.PublishPushNotificationRequested(ctx, notification, eventRequestID); err != nil {
+			ih.Logger.Error("Failed to publish institution notification",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
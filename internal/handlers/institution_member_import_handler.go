@@ -0,0 +1,591 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+const (
+	// Largest CSV accepted by a member import
+	maxMemberImportBytes = 1 << 20
+	// Largest number of emails accepted by a member import
+	maxMemberImportRows = 5000
+	// How many emails are imported together, progress is recorded after each
+	// batch
+	memberImportBatchSize = 250
+	// Upper bound on the time a member import may run
+	memberImportTimeout = time.Hour
+)
+
+// MemberImportError describes an email a member import could not process
+type MemberImportError struct {
+	Email string `json:"email"`
+	Error string `json:"error"`
+}
+
+// InstitutionMemberImportResponse reports the progress of a member import
+type InstitutionMemberImportResponse struct {
+	ID            uuid.UUID                                `json:"id"`
+	InstitutionID int32                                    `json:"institution_id"`
+	RequestedBy   pgtype.UUID                              `json:"requested_by"`
+	Status        repository.InstitutionMemberImportStatus `json:"status"`
+	Total         int32                                    `json:"total"`
+	Processed     int32                                    `json:"processed"`
+	Linked        int32                                    `json:"linked"`
+	Invited       int32                                    `json:"invited"`
+	Skipped       int32                                    `json:"skipped"`
+	Errors        json.RawMessage                          `json:"errors"`
+	CreatedAt     pgtype.Timestamp                         `json:"created_at"`
+	StartedAt     pgtype.Timestamp                         `json:"started_at"`
+	FinishedAt    pgtype.Timestamp                         `json:"finished_at"`
+}
+
+func newInstitutionMemberImportResponse(job repository.InstitutionMemberImport) InstitutionMemberImportResponse {
+	return InstitutionMemberImportResponse{
+		ID:            job.ID,
+		InstitutionID: job.InstitutionID,
+		RequestedBy:   job.RequestedBy,
+		Status:        job.Status,
+		Total:         job.Total,
+		Processed:     job.Processed,
+		Linked:        job.Linked,
+		Invited:       job.Invited,
+		Skipped:       job.Skipped,
+		Errors:        job.Errors,
+		CreatedAt:     job.CreatedAt,
+		StartedAt:     job.StartedAt,
+		FinishedAt:    job.FinishedAt,
+	}
+}
+
+// memberImportOutcome is what happened to a single imported email
+type memberImportOutcome int
+
+const (
+	memberImportLinked memberImportOutcome = iota
+	memberImportInvited
+	memberImportSkipped
+)
+
+// parseMemberImportCSV reads the emails of a member import. Emails are taken
+// from the "email" column when the first row is a header and from the first
+// column otherwise. Duplicates are dropped and malformed emails are reported
+// as errors
+func parseMemberImportCSV(r io.Reader) ([]string, []MemberImportError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("the file is not a valid CSV: %w", err)
+	}
+
+	column := 0
+	if len(records) > 0 {
+		for i, cell := range records[0] {
+			if strings.EqualFold(strings.TrimSpace(cell), "email") {
+				column = i
+				records = records[1:]
+				break
+			}
+		}
+	}
+
+	emails := []string{}
+	failures := []MemberImportError{}
+	seen := map[string]bool{}
+	for _, record := range records {
+		if column >= len(record) {
+			continue
+		}
+		raw := strings.TrimSpace(record[column])
+		if raw == "" {
+			continue
+		}
+		email := utils.NormalizeEmail(raw)
+		if strings.HasPrefix(email, "@") || utils.EmailDomain(email) == "" {
+			failures = append(failures, MemberImportError{Email: raw, Error: "not a valid email address"})
+			continue
+		}
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+
+	if len(emails) > maxMemberImportRows {
+		return nil, nil, fmt.Errorf("a single import may contain at most %d emails", maxMemberImportRows)
+	}
+	return emails, failures, nil
+}
+
+// Imports members from a CSV of emails. Accounts that already exist are
+// linked to the institution while unknown emails are invited. The import runs
+// in the background and its progress is reported by GetInstitutionMemberImport
+func (ih *InstitutionHandler) ImportInstitutionMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	// The CSV is either uploaded as the "file" field of a multipart form or
+	// sent as the raw request body
+	r.Body = http.MaxBytesReader(w, r.Body, maxMemberImportBytes)
+	var source io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Please upload the CSV as the file field of the form",
+			})
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+
+	emails, failures, err := parseMemberImportCSV(source)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(emails) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The file does not contain any valid email address",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	pool, err := middleware.GetDBPoolFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	callerID, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only admins and owners of this institution may import members",
+		})
+		return
+	}
+
+	institution, err := repo.GetInstitution(r.Context(), institutionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The institution you are importing members into does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if institution.ArchivedAt.Valid {
+		w.WriteHeader(http.StatusGone)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "You can no longer import members into this institution as it has been archived",
+		})
+		return
+	}
+
+	initialErrors, err := json.Marshal(failures)
+	if err != nil {
+		ih.Logger.Error("Failed to encode import errors", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	job, err := repo.CreateInstitutionMemberImport(r.Context(), repository.CreateInstitutionMemberImportParams{
+		InstitutionID: institution.InstitutionID,
+		RequestedBy:   pgtype.UUID{Bytes: callerID, Valid: true},
+		Emails:        emails,
+		Total:         int32(len(emails)),
+		Errors:        initialErrors,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to create member import", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	// The import outlives the request but stays within its tenant
+	go ih.runMemberImport(tenancy.WithTenant(context.Background(), middleware.GetTenant(r.Context())),
+		pool, institution, job, failures)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(newInstitutionMemberImportResponse(job))
+}
+
+// Reports the progress of a member import
+func (ih *InstitutionHandler) GetInstitutionMemberImport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	jobID, ok := utils.PathUUID(w, r, "job_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	job, err := repo.GetInstitutionMemberImport(r.Context(), jobID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The import you are looking for does not exist",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve member import", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	_, callerRole, err := callerMembershipRole(r, repo, job.InstitutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !canManageMember(callerRole, repository.InstitutionMembershipRoleMember) {
+		// Imports of other institutions are reported as missing
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The import you are looking for does not exist",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newInstitutionMemberImportResponse(job))
+}
+
+// runMemberImport processes every email of a member import and records its
+// progress as it goes. The connections it acquires are restricted to the
+// tenant of ctx
+func (ih *InstitutionHandler) runMemberImport(ctx context.Context, pool *pgxpool.Pool, institution repository.Institution,
+	job repository.InstitutionMemberImport, failures []MemberImportError,
+) {
+	ctx, cancel := context.WithTimeout(ctx, memberImportTimeout)
+	defer cancel()
+	repo := repository.New(pool)
+	logger := ih.Logger.With(slog.String("import_id", job.ID.String()))
+
+	if err := repo.StartInstitutionMemberImport(ctx, job.ID); err != nil {
+		logger.Error("Failed to start member import", slog.Any("error", err))
+		return
+	}
+
+	progress := repository.UpdateInstitutionMemberImportProgressParams{ID: job.ID}
+	saveProgress := func() {
+		progress.Errors, _ = json.Marshal(failures)
+		if err := repo.UpdateInstitutionMemberImportProgress(ctx, progress); err != nil {
+			logger.Error("Failed to record member import progress", slog.Any("error", err))
+		}
+	}
+
+	for start := 0; start < len(job.Emails) && ctx.Err() == nil; start += memberImportBatchSize {
+		batch := job.Emails[start:min(start+memberImportBatchSize, len(job.Emails))]
+
+		linked, skipped, invitations, err := ih.importMemberBatch(ctx, pool, institution, job, batch)
+		if err == nil {
+			progress.Linked += linked
+			progress.Skipped += skipped
+			progress.Invited += int32(len(invitations))
+			progress.Processed += int32(len(batch))
+			for _, invitation := range invitations {
+				ih.sendInstitutionInvitation(ctx, institution, invitation)
+			}
+		} else {
+			// A batch fails as a whole, e.g. when one of its accounts joined the
+			// institution in the meantime, so its emails are retried one by one
+			// to tell them apart
+			logger.Warn("Failed to import member batch, importing its emails one by one",
+				slog.Int("size", len(batch)),
+				slog.Any("error", err),
+			)
+			for _, email := range batch {
+				outcome, err := ih.importMember(ctx, pool, institution, job, email)
+				switch {
+				case err != nil:
+					logger.Error("Failed to import member", slog.String("email", email), slog.Any("error", err))
+					failures = append(failures, MemberImportError{Email: email, Error: "could not be imported"})
+				case outcome == memberImportLinked:
+					progress.Linked++
+				case outcome == memberImportInvited:
+					progress.Invited++
+				case outcome == memberImportSkipped:
+					progress.Skipped++
+				}
+				progress.Processed++
+				if ctx.Err() != nil {
+					break
+				}
+			}
+		}
+		saveProgress()
+	}
+
+	status := repository.InstitutionMemberImportStatusCompleted
+	if ctx.Err() != nil {
+		status = repository.InstitutionMemberImportStatusFailed
+	}
+	// The import context may have expired so the final status is recorded
+	// with a fresh one
+	finishCtx, finishCancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer finishCancel()
+	if err := repo.FinishInstitutionMemberImport(finishCtx, repository.FinishInstitutionMemberImportParams{
+		ID:     job.ID,
+		Status: status,
+	}); err != nil {
+		logger.Error("Failed to finish member import", slog.Any("error", err))
+		return
+	}
+
+	logger.Info("Member import finished",
+		slog.String("status", string(status)),
+		slog.Int("linked", int(progress.Linked)),
+		slog.Int("invited", int(progress.Invited)),
+		slog.Int("skipped", int(progress.Skipped)),
+		slog.Int("errors", len(failures)),
+	)
+}
+
+// importMemberBatch imports a batch of emails at once: accounts that exist are
+// linked to the institution and the other emails are invited, both with a
+// single statement rather than one per email. It reports how many accounts were linked
+// and skipped as existing members along with the invitations to send once the
+// batch is committed
+func (ih *InstitutionHandler) importMemberBatch(ctx context.Context, pool *pgxpool.Pool, institution repository.Institution,
+	job repository.InstitutionMemberImport, emails []string,
+) (int32, int32, []repository.InstitutionInvitation, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	accounts, err := repo.ListAccountsByEmails(ctx, emails)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	accountIDs := make([]uuid.UUID, 0, len(accounts))
+	for _, account := range accounts {
+		accountIDs = append(accountIDs, account.ID)
+	}
+	memberIDs, err := repo.ListInstitutionMemberIDs(ctx, repository.ListInstitutionMemberIDsParams{
+		InstitutionID: institution.InstitutionID,
+		AccountIds:    accountIDs,
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	members := make(map[uuid.UUID]bool, len(memberIDs))
+	for _, id := range memberIDs {
+		members[id] = true
+	}
+
+	var skipped int32
+	known := make(map[string]bool, len(accounts))
+	links := []uuid.UUID{}
+	for _, account := range accounts {
+		known[account.Email] = true
+		if members[account.ID] {
+			skipped++
+			continue
+		}
+		links = append(links, account.ID)
+	}
+	if len(links) > 0 {
+		if _, err := repo.AddAccountInstitutions(ctx, repository.AddAccountInstitutionsParams{
+			AccountIds:    links,
+			InstitutionID: institution.InstitutionID,
+		}); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	ttl := time.Duration(ih.Cfg.InstitutionConfig.InvitationTTLHours) * time.Hour
+	expiresAt := pgtype.Timestamp{Time: time.Now().Add(ttl), Valid: true}
+	invitees := []string{}
+	invitations := []repository.InstitutionInvitation{}
+	rows := []repository.CreateInstitutionInvitationsParams{}
+	for _, email := range emails {
+		if known[email] {
+			continue
+		}
+		invitation := repository.InstitutionInvitation{
+			ID:             uuid.New(),
+			InstitutionID:  institution.InstitutionID,
+			Email:          email,
+			MembershipRole: repository.InstitutionMembershipRoleMember,
+			InvitedBy:      job.RequestedBy,
+			ExpiresAt:      expiresAt,
+		}
+		invitees = append(invitees, email)
+		invitations = append(invitations, invitation)
+		rows = append(rows, repository.CreateInstitutionInvitationsParams{
+			ID:            invitation.ID,
+			InstitutionID: invitation.InstitutionID,
+			Email:         invitation.Email,
+			InvitedBy:     invitation.InvitedBy,
+			ExpiresAt:     invitation.ExpiresAt,
+		})
+	}
+	if len(rows) > 0 {
+		// New invitations replace any earlier ones still pending for the emails
+		if err := repo.RevokePendingInstitutionInvitationsForEmails(ctx, repository.RevokePendingInstitutionInvitationsForEmailsParams{
+			InstitutionID: institution.InstitutionID,
+			Emails:        invitees,
+		}); err != nil {
+			return 0, 0, nil, err
+		}
+		if _, err := repo.CreateInstitutionInvitations(ctx, rows); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, 0, nil, err
+	}
+	return int32(len(links)), skipped, invitations, nil
+}
+
+// importMember links the account owning the email to the institution or
+// invites the email when no account exists yet. Existing members are skipped.
+// It is used for the emails of batches that failed to import as a whole
+func (ih *InstitutionHandler) importMember(ctx context.Context, pool *pgxpool.Pool, institution repository.Institution,
+	job repository.InstitutionMemberImport, email string,
+) (memberImportOutcome, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	account, err := repo.GetAccountByEmail(ctx, email)
+	if err == nil {
+		member, err := repo.IsAccountInInstitution(ctx, repository.IsAccountInInstitutionParams{
+			AccountID:     account.ID,
+			InstitutionID: institution.InstitutionID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if member {
+			return memberImportSkipped, nil
+		}
+		if _, err := repo.AddAccountInstitution(ctx, repository.AddAccountInstitutionParams{
+			AccountID:      account.ID,
+			InstitutionID:  institution.InstitutionID,
+			MembershipRole: repository.InstitutionMembershipRoleMember,
+		}); err != nil {
+			return 0, err
+		}
+		return memberImportLinked, tx.Commit(ctx)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	// A new invitation replaces any earlier one still pending for the email
+	if err := repo.RevokePendingInstitutionInvitations(ctx, repository.RevokePendingInstitutionInvitationsParams{
+		InstitutionID: institution.InstitutionID,
+		Email:         email,
+	}); err != nil {
+		return 0, err
+	}
+	ttl := time.Duration(ih.Cfg.InstitutionConfig.InvitationTTLHours) * time.Hour
+	invitation, err := repo.CreateInstitutionInvitation(ctx, repository.CreateInstitutionInvitationParams{
+		InstitutionID:  institution.InstitutionID,
+		Email:          email,
+		MembershipRole: repository.InstitutionMembershipRoleMember,
+		InvitedBy:      job.RequestedBy,
+		ExpiresAt:      pgtype.Timestamp{Time: time.Now().Add(ttl), Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	ih.sendInstitutionInvitation(ctx, institution, invitation)
+	return memberImportInvited, nil
+}
@@ -0,0 +1,152 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// createAccount creates an account in a tenant
+func createAccount(t *testing.T, pool *pgxpool.Pool, tenant, email string) repository.Account {
+	t.Helper()
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Could not begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	if err := repo.SetCurrentTenant(ctx, tenant); err != nil {
+		t.Fatalf("Could not switch to tenant %s: %v", tenant, err)
+	}
+	account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+		Email: email,
+		Name:  email,
+		Type:  repository.AccountTypeHuman,
+	})
+	if err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Could not commit account: %v", err)
+	}
+	return account
+}
+
+func TestImportInstitutionMembersStaysWithinTenant(t *testing.T) {
+	ctx := context.Background()
+	admin := testdb.New(t)
+	adminRepo := repository.New(admin)
+
+	caller := createAccount(t, admin, "acme", "owner@acme.example")
+	member := createAccount(t, admin, "acme", "member@acme.example")
+	outsider := createAccount(t, admin, "globex", "outsider@globex.example")
+	institution, err := adminRepo.CreateInstitution(ctx, repository.CreateInstitutionParams{
+		Name: "Acme University",
+	})
+	if err != nil {
+		t.Fatalf("Could not create institution: %v", err)
+	}
+
+	// Requests are served by connections row level security applies to
+	poolConfig := testdb.Unprivileged(t, admin)
+	tenancy.ConfigurePool(poolConfig)
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("Could not open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	reqCtx := tenancy.WithTenant(ctx, "acme")
+	conn, err := pool.Acquire(reqCtx)
+	if err != nil {
+		t.Fatalf("Could not acquire connection: %v", err)
+	}
+	defer conn.Release()
+	reqCtx = context.WithValue(reqCtx, middleware.DBConnectionContextKey, conn)
+	reqCtx = context.WithValue(reqCtx, middleware.DBPoolContextKey, pool)
+	reqCtx = context.WithValue(reqCtx, middleware.AuthUserClaims, &utils.VerisafeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: caller.ID.String()},
+		Tenant:           "acme",
+	})
+	reqCtx = context.WithValue(reqCtx, middleware.AuthUserPerms, []string{"manage:institution_members:any"})
+
+	body := strings.NewReader("email\n" + member.Email + "\n" + outsider.Email + "\n")
+	req := httptest.NewRequestWithContext(reqCtx, http.MethodPost, "/api/v1/institutions/import", body)
+	req.SetPathValue("id", strconv.Itoa(int(institution.InstitutionID)))
+	rr := httptest.NewRecorder()
+
+	cfg := &config.Config{}
+	cfg.InstitutionConfig.InvitationTTLHours = 24
+	ih := handlers.InstitutionHandler{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Cfg:    cfg,
+	}
+	ih.ImportInstitutionMembers(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+	var accepted handlers.InstitutionMemberImportResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &accepted); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+
+	// The import runs in the background
+	var job repository.InstitutionMemberImport
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(50 * time.Millisecond) {
+		job, err = adminRepo.GetInstitutionMemberImport(ctx, accepted.ID)
+		if err != nil {
+			t.Fatalf("Could not get member import: %v", err)
+		}
+		if job.FinishedAt.Valid {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the member import to finish, got %s", job.Status)
+		}
+	}
+
+	if job.Status != repository.InstitutionMemberImportStatusCompleted {
+		t.Fatalf("Expected the member import to complete, got %s", job.Status)
+	}
+	// The account of another tenant is unknown to acme so its email is
+	// invited rather than linked
+	if job.Linked != 1 || job.Invited != 1 {
+		t.Errorf("Expected 1 linked and 1 invited, got %d linked and %d invited", job.Linked, job.Invited)
+	}
+	for _, account := range []struct {
+		account repository.Account
+		member  bool
+	}{{member, true}, {outsider, false}} {
+		got, err := adminRepo.IsAccountInInstitution(ctx, repository.IsAccountInInstitutionParams{
+			AccountID:     account.account.ID,
+			InstitutionID: institution.InstitutionID,
+		})
+		if err != nil {
+			t.Fatalf("Could not check membership: %v", err)
+		}
+		if got != account.member {
+			t.Errorf("Expected %s to be a member: %t, got %t", account.account.Email, account.member, got)
+		}
+	}
+}
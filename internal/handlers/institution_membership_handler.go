@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// UpdateMembershipRoleRequest changes the membership role of an institution
+// member
+type UpdateMembershipRoleRequest struct {
+	MembershipRole repository.InstitutionMembershipRole `json:"membership_role" validate:"oneof=owner admin member"`
+}
+
+// membershipRanks orders membership roles from the least to the most
+// privileged
+var membershipRanks = map[repository.InstitutionMembershipRole]int{
+	repository.InstitutionMembershipRoleMember: 1,
+	repository.InstitutionMembershipRoleAdmin:  2,
+	repository.InstitutionMembershipRoleOwner:  3,
+}
+
+// callerMembershipRole returns the caller's id and membership role within the
+// institution. Holders of manage:institution_members:any act as owners of
+// every institution. An empty role means the caller is not a member
+func callerMembershipRole(r *http.Request, repo *repository.Queries, institutionID int32) (uuid.UUID, repository.InstitutionMembershipRole, error) {
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	if middleware.CallerHasPermission(r.Context(), "manage:institution_members:any") {
+		return callerID, repository.InstitutionMembershipRoleOwner, nil
+	}
+
+	membership, err := repo.GetInstitutionMembership(r.Context(), repository.GetInstitutionMembershipParams{
+		AccountID:     callerID,
+		InstitutionID: institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return callerID, "", nil
+	}
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	return callerID, membership.MembershipRole, nil
+}
+
+// canManageMember reports whether a caller holding callerRole may add, remove
+// or change a member holding role. Owners manage everyone while admins only
+// manage plain members
+func canManageMember(callerRole, role repository.InstitutionMembershipRole) bool {
+	switch callerRole {
+	case repository.InstitutionMembershipRoleOwner:
+		return true
+	case repository.InstitutionMembershipRoleAdmin:
+		return role == repository.InstitutionMembershipRoleMember
+	}
+	return false
+}
+
+// isLastInstitutionOwner reports whether the membership belongs to the only
+// owner left in its institution
+func isLastInstitutionOwner(ctx context.Context, repo *repository.Queries, membership repository.AccountInstitution) (bool, error) {
+	if membership.MembershipRole != repository.InstitutionMembershipRoleOwner {
+		return false, nil
+	}
+	owners, err := repo.CountInstitutionOwners(ctx, membership.InstitutionID)
+	if err != nil {
+		return false, err
+	}
+	return owners <= 1, nil
+}
+
+// Lists the members of an institution together with their membership roles.
+// Only members of the institution may list them
+func (ih *InstitutionHandler) ListInstitutionMembers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	repo := repository.New(conn)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole == "" {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only members of this institution may list its members",
+		})
+		return
+	}
+
+	p := middleware.GetPagination(r.Context())
+	members, err := repo.ListInstitutionMembers(r.Context(), repository.ListInstitutionMembersParams{
+		InstitutionID: institutionID,
+		Limit:         int32(p.Limit),
+		Offset:        int32(p.Offset),
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to list institution members", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	totalCount, err := repo.CountInstitutionMembers(r.Context(), institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to count institution members", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pagination.BuildLimitOffsetResponse(r, totalCount, members, p.Limit, p.Offset))
+}
+
+// Changes the membership role of a member. Only owners may change roles and
+// an institution always keeps at least one owner
+func (ih *InstitutionHandler) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	institutionID, ok := utils.PathInt32(w, r, "id")
+	if !ok {
+		return
+	}
+	accountID, ok := utils.PathUUID(w, r, "account_id")
+	if !ok {
+		return
+	}
+
+	var req UpdateMembershipRoleRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ih.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	_, callerRole, err := callerMembershipRole(r, repo, institutionID)
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if callerRole != repository.InstitutionMembershipRoleOwner {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only owners of this institution may change membership roles",
+		})
+		return
+	}
+
+	membership, err := repo.GetInstitutionMembership(r.Context(), repository.GetInstitutionMembershipParams{
+		AccountID:     accountID,
+		InstitutionID: institutionID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This account is not a member of the institution",
+		})
+		return
+	}
+	if err != nil {
+		ih.Logger.Error("Failed to retrieve institution membership", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if req.MembershipRole != repository.InstitutionMembershipRoleOwner {
+		lastOwner, err := isLastInstitutionOwner(r.Context(), repo, membership)
+		if err != nil {
+			ih.Logger.Error("Failed to count institution owners", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		if lastOwner {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "An institution must keep at least one owner",
+			})
+			return
+		}
+	}
+
+	updated, err := repo.UpdateInstitutionMembershipRole(r.Context(), repository.UpdateInstitutionMembershipRoleParams{
+		AccountID:      accountID,
+		InstitutionID:  institutionID,
+		MembershipRole: req.MembershipRole,
+	})
+	if err != nil {
+		ih.Logger.Error("Failed to update membership role", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ih.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// institutionRolePathValues parses the user, role and institution ids shared
+// by the institution scoped role endpoints. It writes the error response and
+// reports false when any of them is malformed
+func institutionRolePathValues(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, int32, bool) {
+	userID, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+	institutionID, ok := utils.PathInt32(w, r, "institution_id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, 0, false
+	}
+	return userID, roleID, institutionID, true
+}
+
+// Assigns a role to a user that only applies within the given institution
+func (rh *RoleHandler) AssignInstitutionRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, roleID, institutionID, ok := institutionRolePathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	role, err := repo.GetRoleByID(r.Context(), roleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are trying to assign does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !role.IsActive {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Inactive roles cannot be assigned",
+		})
+		return
+	}
+	if role.InstitutionID != nil && *role.InstitutionID != institutionID {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This role is scoped to a different institution",
+		})
+		return
+	}
+	if role.RequiresApproval {
+		rh.requestRoleAssignment(w, r, tx, repo, userID, role, &institutionID)
+		return
+	}
+
+	assignment, err := repo.AssignInstitutionRole(r.Context(), repository.AssignInstitutionRoleParams{
+		UserID:        userID,
+		RoleID:        roleID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505": // unique_violation
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "The user already holds this role within the institution",
+				})
+				return
+			case "23503": // foreign_key_violation
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "The user or institution you specified does not exist",
+				})
+				return
+			}
+		}
+		rh.Logger.Error("Failed to assign institution role to user",
+			slog.Any("error", err),
+			slog.Any("role", roleID.String()),
+			slog.Any("user", userID.String()),
+			slog.Any("institution", institutionID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionInstitutionRoleAssigned, AuthzTargetAccount, userID.String(), nil,
+		map[string]any{"role_id": role.ID, "role_name": role.Name, "institution_id": institutionID},
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+	publishRoleEvent(r, rh.RoleEventBus, rh.Logger, true, eventbus.RoleAssignment{
+		AccountID:     userID.String(),
+		RoleID:        role.ID.String(),
+		RoleName:      role.Name,
+		InstitutionID: &institutionID,
+		ActorID:       change.ActorID,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(assignment)
+}
+
+// Revokes an institution scoped role from a user
+func (rh *RoleHandler) RevokeInstitutionRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, roleID, institutionID, ok := institutionRolePathValues(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	revoked, err := repo.RevokeInstitutionRole(r.Context(), repository.RevokeInstitutionRoleParams{
+		UserID:        userID,
+		RoleID:        roleID,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		rh.Logger.Error("Failed to revoke institution role from user",
+			slog.Any("error", err),
+			slog.Any("role", roleID.String()),
+			slog.Any("user", userID.String()),
+			slog.Any("institution", institutionID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if revoked == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The user does not hold this role within the institution",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionInstitutionRoleRevoked, AuthzTargetAccount, userID.String(),
+		map[string]any{"role_id": roleID, "institution_id": institutionID}, nil,
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+	publishRoleEvent(r, rh.RoleEventBus, rh.Logger, false, eventbus.RoleAssignment{
+		AccountID:     userID.String(),
+		RoleID:        roleID.String(),
+		InstitutionID: &institutionID,
+		ActorID:       change.ActorID,
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Role successfully revoked"})
+}
+
+// Retrieves the institution scoped roles of a user
+func (rh *RoleHandler) GetUserInstitutionRoles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	roles, err := repo.GetUserInstitutionRoles(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve institution roles", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(roles)
+}
@@ -0,0 +1,115 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/handlers"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// newCallerRequest returns a request made by the caller over a connection of
+// pool
+func newCallerRequest(t *testing.T, pool *pgxpool.Pool, caller repository.Account, body string) *http.Request {
+	t.Helper()
+	conn, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Could not acquire connection: %v", err)
+	}
+	t.Cleanup(conn.Release)
+
+	ctx := context.WithValue(context.Background(), middleware.DBConnectionContextKey, conn)
+	ctx = context.WithValue(ctx, middleware.AuthUserClaims, &utils.VerisafeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: caller.ID.String()},
+	})
+	return httptest.NewRequestWithContext(ctx, http.MethodPost, "/", strings.NewReader(body))
+}
+
+func TestAssignInstitutionRoleRequiringApproval(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	repo := repository.New(pool)
+
+	requester := createAccount(t, pool, "default", "requester@example.edu")
+	reviewer := createAccount(t, pool, "default", "reviewer@example.edu")
+	member := createAccount(t, pool, "default", "member@example.edu")
+	institution, err := repo.CreateInstitution(ctx, repository.CreateInstitutionParams{
+		Name: "Example University",
+	})
+	if err != nil {
+		t.Fatalf("Could not create institution: %v", err)
+	}
+	role, err := repo.CreateRole(ctx, repository.CreateRoleParams{Name: "Bursar"})
+	if err != nil {
+		t.Fatalf("Could not create role: %v", err)
+	}
+	if _, err := repo.SetRoleRequiresApproval(ctx, repository.SetRoleRequiresApprovalParams{
+		ID:               role.ID,
+		RequiresApproval: true,
+	}); err != nil {
+		t.Fatalf("Could not require approval: %v", err)
+	}
+
+	rh := handlers.RoleHandler{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Cfg:    &config.Config{},
+	}
+	assignedRoles := func() []repository.GetUserInstitutionRolesRow {
+		t.Helper()
+		roles, err := repo.GetUserInstitutionRoles(ctx, member.ID)
+		if err != nil {
+			t.Fatalf("Could not get institution roles: %v", err)
+		}
+		return roles
+	}
+
+	req := newCallerRequest(t, pool, requester, "")
+	req.SetPathValue("user_id", member.ID.String())
+	req.SetPathValue("role_id", role.ID.String())
+	req.SetPathValue("institution_id", strconv.Itoa(int(institution.InstitutionID)))
+	rr := httptest.NewRecorder()
+	rh.AssignInstitutionRole(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusAccepted, rr.Code, rr.Body.String())
+	}
+	var request repository.RoleAssignmentRequest
+	if err := json.Unmarshal(rr.Body.Bytes(), &request); err != nil {
+		t.Fatalf("Could not decode response: %v", err)
+	}
+	if request.Status != repository.RoleAssignmentRequestStatusPending {
+		t.Errorf("Expected a pending request, got %s", request.Status)
+	}
+	if request.InstitutionID == nil || *request.InstitutionID != institution.InstitutionID {
+		t.Errorf("Expected the request to be scoped to institution %d, got %v", institution.InstitutionID, request.InstitutionID)
+	}
+	if roles := assignedRoles(); len(roles) != 0 {
+		t.Fatalf("Expected the role not to be assigned before approval, got %v", roles)
+	}
+
+	req = newCallerRequest(t, pool, reviewer, "{}")
+	req.SetPathValue("id", request.ID.String())
+	rr = httptest.NewRecorder()
+	rh.ApproveRoleAssignmentRequest(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+	roles := assignedRoles()
+	if len(roles) != 1 || roles[0].RoleID != role.ID || roles[0].InstitutionID != institution.InstitutionID {
+		t.Errorf("Expected the role to be assigned within the institution once approved, got %v", roles)
+	}
+}
@@ -2,58 +2,108 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/leaderboard"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type LeaderBoardHandler struct {
 	Logger *slog.Logger
+	Cfg    *config.Config
+	Live   *leaderboard.LiveFeed
 }
 
 func (lh *LeaderBoardHandler) RegisterLeaderBoardHandlers(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("GET /leaderboard/global", middleware.CreateStack(
+	router.Handle("GET /api/v1/leaderboard/global", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, lh.Logger),
 	)(http.HandlerFunc(lh.GetGlobalLeaderBoard)))
-	router.Handle("GET /leaderboard/global/{user}", middleware.CreateStack(
+	router.Handle("GET /api/v1/leaderboard/global/{user}", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, lh.Logger),
 	)(http.HandlerFunc(lh.GetGlobalUserRank)))
+	router.Handle("GET /api/v1/leaderboard/friends", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetFriendsLeaderBoard)))
+	router.Handle("GET /api/v1/leaderboard/history/{user}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetUserRankHistory)))
+
+	// Live updates, the WebSocket is authenticated by a ticket instead
+	router.Handle("POST /api/v1/leaderboard/live/tickets", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.CreateLiveTicket)))
+	router.HandleFunc("GET /api/v1/leaderboard/live", lh.StreamLiveLeaderboard)
+
+	// Seasons
+	router.Handle("POST /api/v1/leaderboard/seasons", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+		middleware.HasPermission([]string{"manage:leaderboard_season:any"}),
+	)(http.HandlerFunc(lh.CreateLeaderboardSeason)))
+	router.Handle("GET /api/v1/leaderboard/seasons", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetLeaderboardSeasons)))
+	router.Handle("GET /api/v1/leaderboard/seasons/{id}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetLeaderboardSeason)))
+	router.Handle("GET /api/v1/leaderboard/seasons/{id}/standings", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetLeaderboardSeasonStandings)))
+	router.Handle("DELETE /api/v1/leaderboard/seasons/{id}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+		middleware.HasPermission([]string{"manage:leaderboard_season:any"}),
+	)(http.HandlerFunc(lh.DeleteLeaderboardSeason)))
+
+	// Vibe point ledger
+	router.Handle("GET /api/v1/vibepoints/ledger/me", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+	)(http.HandlerFunc(lh.GetPersonalVibepointLedger)))
+	router.Handle("GET /api/v1/vibepoints/ledger/{user}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+		middleware.HasPermission([]string{"read:vibepoint_ledger:any"}),
+	)(http.HandlerFunc(lh.GetVibepointLedger)))
+	router.Handle("POST /api/v1/vibepoints/ledger/reverse/{id}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, lh.Logger),
+		middleware.HasPermission([]string{"reverse:vibepoint_transaction:any"}),
+	)(http.HandlerFunc(lh.ReverseVibepointTransaction)))
 
 }
 
 func (lh *LeaderBoardHandler) GetGlobalUserRank(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		lh.Logger.Error("Error while processing request", slog.Any("error", err))
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		lh.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
-	idStr := r.PathValue("user")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		http.Error(w, `{"error":"invalid user id"}`, http.StatusBadRequest)
+	id, ok := utils.PathUUID(w, r, "user")
+	if !ok {
 		return
 	}
 
 	leaderboardRank, err := repo.GetLeaderBoardRankForUser(r.Context(), id)
-
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Bots and deactivated accounts are not ranked, new accounts are
+		// once the leaderboard is next refreshed
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "This account is not ranked on the leaderboard yet",
+		})
+		return
+	}
 	if err != nil {
 		lh.Logger.Error("Failed to retrieve leaderboard", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -68,22 +118,14 @@ func (lh *LeaderBoardHandler) GetGlobalUserRank(w http.ResponseWriter, r *http.R
 // Returns the global leaderboard using the limit offset scheme
 func (lh *LeaderBoardHandler) GetGlobalLeaderBoard(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	conn, err := middleware.GetDBConnFromContext(r.Context())
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
 	if err != nil {
 		lh.Logger.Error("Error while processing request", slog.Any("error", err))
 		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		lh.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
@@ -115,3 +157,125 @@ func (lh *LeaderBoardHandler) GetGlobalLeaderBoard(w http.ResponseWriter, r *htt
 	response := pagination.BuildPaginatedResponse(r, totalCount, leaderboard, pageParams)
 	json.NewEncoder(w).Encode(response)
 }
+
+// Returns the accounts the caller follows ranked by vibe points. Each entry
+// carries both its rank among the caller's friends and its global rank
+func (lh *LeaderBoardHandler) GetFriendsLeaderBoard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		lh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	pageParams := pagination.ParsePageParams(r)
+
+	totalCount, err := repo.GetFriendsLeaderboardCount(r.Context(), callerID)
+	if err != nil {
+		lh.Logger.Error("Failed to get friends leaderboard count", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the friends leaderboard at the moment",
+		})
+		return
+	}
+
+	leaderboard, err := repo.GetFriendsLeaderboard(r.Context(), repository.GetFriendsLeaderboardParams{
+		FollowerID: callerID,
+		Limit:      int32(pageParams.PageSize),
+		Offset:     int32(pageParams.Offset),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve friends leaderboard", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the friends leaderboard at the moment",
+		})
+		return
+	}
+
+	response := pagination.BuildPaginatedResponse(r, totalCount, leaderboard, pageParams)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Bounds of the history window in days
+const (
+	defaultRankHistoryDays = 30
+	maxRankHistoryDays     = 365
+)
+
+// RankHistoryResponse describes how an account moved on the global
+// leaderboard. RankChange is positive when the account moved up and is null
+// when no snapshot was recorded within the window
+type RankHistoryResponse struct {
+	AccountID    uuid.UUID                        `json:"account_id"`
+	Days         int                              `json:"days"`
+	RankChange   *int64                           `json:"rank_change"`
+	PointsChange *int64                           `json:"points_change"`
+	Snapshots    []repository.LeaderboardSnapshot `json:"snapshots"`
+}
+
+// Returns the daily global rank snapshots of an account over the last
+// `days` days (default 30, at most 365)
+func (lh *LeaderBoardHandler) GetUserRankHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "user")
+	if !ok {
+		return
+	}
+
+	days := defaultRankHistoryDays
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 || parsed > maxRankHistoryDays {
+			http.Error(w, `{"error":"days must be between 1 and 365"}`, http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	snapshots, err := repo.GetLeaderboardRankHistory(r.Context(), repository.GetLeaderboardRankHistoryParams{
+		AccountID: id,
+		Days:      int32(days),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve rank history", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the rank history at the moment",
+		})
+		return
+	}
+
+	response := RankHistoryResponse{
+		AccountID: id,
+		Days:      days,
+		Snapshots: snapshots,
+	}
+	if len(snapshots) > 0 {
+		first, last := snapshots[0], snapshots[len(snapshots)-1]
+		rankChange := first.VibeRank - last.VibeRank
+		pointsChange := last.VibePoints - first.VibePoints
+		response.RankChange = &rankChange
+		response.PointsChange = &pointsChange
+	}
+	json.NewEncoder(w).Encode(response)
+}
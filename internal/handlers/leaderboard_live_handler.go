@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+const (
+	// How often idle live leaderboard connections are pinged so that
+	// proxies do not close them
+	livePingInterval = 30 * time.Second
+	// How long writing a message to a live leaderboard client may take
+	liveWriteTimeout = 10 * time.Second
+)
+
+// LiveTicketResponse is the body of the response to a live leaderboard
+// ticket request
+type LiveTicketResponse struct {
+	// Passed as the ticket query parameter of GET /api/v1/leaderboard/live.
+	// It can be used once
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateLiveTicket issues a short lived ticket the caller opens the live
+// leaderboard WebSocket with, as WebSocket clients cannot always send an
+// Authorization header
+func (lh *LeaderBoardHandler) CreateLiveTicket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		lh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	ticket := make([]byte, 32)
+	if _, err := rand.Read(ticket); err != nil {
+		lh.Logger.Error("Failed to generate live leaderboard ticket", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't connect you to the live leaderboard at the moment",
+		})
+		return
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(ticket)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	expiresAt, err := repo.CreateLeaderboardLiveTicket(r.Context(), repository.CreateLeaderboardLiveTicketParams{
+		TicketHash: utils.HashToken(encoded),
+		AccountID:  callerID,
+		TtlSeconds: int32(max(lh.Cfg.LeaderboardConfig.LiveTicketTTLSeconds, 1)),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to create live leaderboard ticket", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't connect you to the live leaderboard at the moment",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(LiveTicketResponse{
+		Ticket:    encoded,
+		ExpiresAt: expiresAt.Time,
+	})
+}
+
+// StreamLiveLeaderboard upgrades the connection to a WebSocket and pushes
+// the changes within the top ranks and to the caller's own rank until the
+// client disconnects. The caller is identified by the ticket query
+// parameter, a ticket from POST /api/v1/leaderboard/live/tickets
+func (lh *LeaderBoardHandler) StreamLiveLeaderboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	ticket := r.URL.Query().Get("ticket")
+	if ticket == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Please provide a ticket to connect to the live leaderboard",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	accountID, err := repo.RedeemLeaderboardLiveTicket(r.Context(), utils.HashToken(ticket))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Your ticket is invalid or has expired please request a new one",
+			})
+			return
+		}
+		lh.Logger.Error("Failed to redeem live leaderboard ticket", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't connect you to the live leaderboard at the moment",
+		})
+		return
+	}
+
+	// Connections stay open for as long as the app shows the leaderboard,
+	// they should not hold a database connection or a concurrency slot all
+	// along
+	middleware.ReleaseDBConnection(r.Context())
+	middleware.ReleaseConcurrencySlot(r.Context())
+	w.Header().Del("Content-Type")
+
+	ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		OriginPatterns: lh.Cfg.CORSConfig.AllowedOrigins,
+	})
+	if err != nil {
+		lh.Logger.Warn("Failed to accept live leaderboard connection", slog.Any("error", err))
+		return
+	}
+	defer ws.CloseNow()
+
+	updates, cancel := lh.Live.Subscribe(accountID)
+	defer cancel()
+
+	// Clients only ever receive, reading is left to the library so that it
+	// answers pings and notices when the client goes away
+	ctx := ws.CloseRead(r.Context())
+	ping := time.NewTicker(livePingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				ws.Close(websocket.StatusTryAgainLater, "Live updates were interrupted please reconnect")
+				return
+			}
+			writeCtx, cancelWrite := context.WithTimeout(ctx, liveWriteTimeout)
+			err := wsjson.Write(writeCtx, ws, update)
+			cancelWrite()
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, liveWriteTimeout)
+			err := ws.Ping(pingCtx)
+			cancelPing()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// CreateLeaderboardSeasonRequest is the body expected when scheduling a
+// leaderboard season
+type CreateLeaderboardSeasonRequest struct {
+	Name         string    `json:"name" validate:"notblank,max=255"`
+	StartsAt     time.Time `json:"starts_at"`
+	EndsAt       time.Time `json:"ends_at"`
+	EndAction    string    `json:"end_action"`
+	DecayPercent int16     `json:"decay_percent"`
+}
+
+// Schedules a new leaderboard season. Seasons may not overlap
+func (lh *LeaderBoardHandler) CreateLeaderboardSeason(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreateLeaderboardSeasonRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.StartsAt.IsZero() || !req.EndsAt.After(req.StartsAt) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "A season must end after it starts",
+		})
+		return
+	}
+
+	endAction := repository.LeaderboardSeasonEndAction(strings.ToLower(strings.TrimSpace(req.EndAction)))
+	switch endAction {
+	case "":
+		endAction = repository.LeaderboardSeasonEndActionKeep
+	case repository.LeaderboardSeasonEndActionKeep,
+		repository.LeaderboardSeasonEndActionDecay,
+		repository.LeaderboardSeasonEndActionReset:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "end_action must be one of keep, decay or reset",
+		})
+		return
+	}
+	if endAction == repository.LeaderboardSeasonEndActionDecay && (req.DecayPercent < 1 || req.DecayPercent > 100) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "A decaying season needs a decay_percent between 1 and 100",
+		})
+		return
+	}
+	if endAction != repository.LeaderboardSeasonEndActionDecay {
+		req.DecayPercent = 0
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		lh.Logger.Error("Failed to start transaction", slog.Any("error", err))
+		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	startsAt := pgtype.Timestamp{Time: req.StartsAt, Valid: true}
+	endsAt := pgtype.Timestamp{Time: req.EndsAt, Valid: true}
+
+	overlapping, err := repo.CountOverlappingLeaderboardSeasons(r.Context(), repository.CountOverlappingLeaderboardSeasonsParams{
+		EndsAt:   endsAt,
+		StartsAt: startsAt,
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to check for overlapping seasons", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if overlapping > 0 {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The season overlaps an existing season",
+		})
+		return
+	}
+
+	season, err := repo.CreateLeaderboardSeason(r.Context(), repository.CreateLeaderboardSeasonParams{
+		Name:         req.Name,
+		StartsAt:     startsAt,
+		EndsAt:       endsAt,
+		EndAction:    endAction,
+		DecayPercent: req.DecayPercent,
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to create leaderboard season", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		lh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(season)
+}
+
+// Returns every leaderboard season from the most recent to the oldest
+func (lh *LeaderBoardHandler) GetLeaderboardSeasons(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	pageParams := pagination.ParsePageParams(r)
+
+	totalCount, err := repo.CountLeaderboardSeasons(r.Context())
+	if err != nil {
+		lh.Logger.Error("Failed to count leaderboard seasons", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the leaderboard seasons at the moment",
+		})
+		return
+	}
+
+	seasons, err := repo.ListLeaderboardSeasons(r.Context(), repository.ListLeaderboardSeasonsParams{
+		Limit:  int32(pageParams.PageSize),
+		Offset: int32(pageParams.Offset),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve leaderboard seasons", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the leaderboard seasons at the moment",
+		})
+		return
+	}
+
+	response := pagination.BuildPaginatedResponse(r, totalCount, seasons, pageParams)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Returns a single leaderboard season
+func (lh *LeaderBoardHandler) GetLeaderboardSeason(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	seasonID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	season, err := repo.GetLeaderboardSeason(r.Context(), seasonID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The season does not exist",
+		})
+		return
+	}
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve leaderboard season", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(season)
+}
+
+// Returns the final standings of a closed season
+func (lh *LeaderBoardHandler) GetLeaderboardSeasonStandings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	seasonID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetReadDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	pageParams := pagination.ParsePageParams(r)
+
+	totalCount, err := repo.CountLeaderboardSeasonStandings(r.Context(), seasonID)
+	if err != nil {
+		lh.Logger.Error("Failed to count season standings", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the season standings at the moment",
+		})
+		return
+	}
+
+	standings, err := repo.ListLeaderboardSeasonStandings(r.Context(), repository.ListLeaderboardSeasonStandingsParams{
+		SeasonID: seasonID,
+		Limit:    int32(pageParams.PageSize),
+		Offset:   int32(pageParams.Offset),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve season standings", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the season standings at the moment",
+		})
+		return
+	}
+
+	response := pagination.BuildPaginatedResponse(r, totalCount, standings, pageParams)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Removes a season that has not been closed yet. Closed seasons are kept as
+// their standings and point adjustments are part of the history
+func (lh *LeaderBoardHandler) DeleteLeaderboardSeason(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	seasonID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	season, err := repo.GetLeaderboardSeason(r.Context(), seasonID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The season does not exist",
+		})
+		return
+	}
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve leaderboard season", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if season.ClosedAt.Valid {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Closed seasons cannot be removed",
+		})
+		return
+	}
+
+	removed, err := repo.DeleteLeaderboardSeason(r.Context(), seasonID)
+	if err != nil {
+		lh.Logger.Error("Failed to remove leaderboard season", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if removed == 0 {
+		// The season was closed in the meantime
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Closed seasons cannot be removed",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Season successfully removed"})
+}
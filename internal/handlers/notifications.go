@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+// fillNotificationDefaults fills in the fields every push notification of the
+// Academia clients shares, e.g. the OneSignal app it is pushed through
+func fillNotificationDefaults(cfg *config.Config, notification *eventbus.NotificationPayload) {
+	notification.AppID = cfg.NotificationConfig.OneSignalAppID
+	notification.AndroidChannelID = cfg.NotificationConfig.OneSignalAndroidChannelID
+	notification.IosSound = "default"
+	notification.SmallIcon = "ic_notification"
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/openapi"
+)
+
+// swaggerUIPage renders the OpenAPI document with Swagger UI loaded from a
+// CDN, so that nothing but the document has to be served
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>Verisafe API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// OpenAPIHandler serves the OpenAPI document describing the API and,
+// optionally, Swagger UI to browse it
+type OpenAPIHandler struct {
+	SwaggerUI bool
+}
+
+// RegisterRoutes registers the OpenAPI routes. The document only describes
+// the API so the routes are not authenticated
+func (oh *OpenAPIHandler) RegisterRoutes(router *http.ServeMux) {
+	router.HandleFunc("GET /openapi.json", oh.GetDocument)
+	if oh.SwaggerUI {
+		router.HandleFunc("GET /swagger/{$}", oh.GetSwaggerUI)
+	}
+}
+
+// Returns the OpenAPI 3 document describing every route
+func (oh *OpenAPIHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openapi.Document)
+}
+
+// Returns Swagger UI rendering the OpenAPI document
+func (oh *OpenAPIHandler) GetSwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
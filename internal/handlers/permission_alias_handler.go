@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// CreatePermissionAliasRequest maps a deprecated permission name onto the
+// permission replacing it
+type CreatePermissionAliasRequest struct {
+	Alias      string `json:"alias" validate:"notblank"`
+	Permission string `json:"permission" validate:"notblank"`
+}
+
+// Lists the deprecated permission names and their replacements
+func (ph *AuthorizationPolicyHandler) GetPermissionAliases(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	aliases, err := repository.New(conn).GetPermissionAliases(r.Context())
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve permission aliases", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(aliases)
+}
+
+// Deprecates a permission name in favour of an existing permission. Updating
+// an existing alias points it at the new permission
+func (ph *AuthorizationPolicyHandler) CreatePermissionAlias(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req CreatePermissionAliasRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.Alias = strings.TrimSpace(req.Alias)
+	req.Permission = strings.TrimSpace(req.Permission)
+	if req.Alias == req.Permission {
+		utils.WriteValidationErrors(w, map[string]string{
+			"permission": "must differ from the alias",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	permissions, err := repo.GetPermissionsByNames(r.Context(), []string{req.Alias, req.Permission})
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve permissions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	var replacement *repository.Permission
+	for _, permission := range permissions {
+		if permission.Name == req.Alias {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "An existing permission cannot be used as an alias",
+			})
+			return
+		}
+		replacement = &permission
+	}
+	if replacement == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The replacement permission does not exist",
+		})
+		return
+	}
+
+	alias, err := repo.UpsertPermissionAlias(r.Context(), repository.UpsertPermissionAliasParams{
+		Alias:        req.Alias,
+		PermissionID: replacement.ID,
+	})
+	if err != nil {
+		ph.Logger.Error("Failed to create permission alias", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	ph.reloadPolicies()
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(repository.GetPermissionAliasesRow{
+		Alias:        alias.Alias,
+		PermissionID: alias.PermissionID,
+		Permission:   replacement.Name,
+		CreatedAt:    alias.CreatedAt,
+	})
+}
+
+// Removes an alias. Callers still using the deprecated name stop being
+// authorized through its replacement
+func (ph *AuthorizationPolicyHandler) DeletePermissionAlias(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	deleted, err := repository.New(conn).DeletePermissionAlias(r.Context(), r.PathValue("alias"))
+	if err != nil {
+		ph.Logger.Error("Failed to delete permission alias", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The alias you are trying to delete does not exist",
+		})
+		return
+	}
+
+	ph.reloadPolicies()
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Alias successfully deleted"})
+}
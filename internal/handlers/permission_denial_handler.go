@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// DenyPermissionRequest is the optional body accepted when denying a
+// permission to an account
+type DenyPermissionRequest struct {
+	Reason *string `json:"reason"`
+}
+
+// parseDenialPath reads the account and permission a denial applies to. It
+// writes the error response and reports false when either id is malformed
+func parseDenialPath(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	permID, ok := utils.PathUUID(w, r, "perm_id")
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, permID, true
+}
+
+// Lists the permissions explicitly denied to an account
+func (ph *PermissionHandler) GetUserPermissionDenials(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	denials, err := repository.New(conn).GetUserPermissionDenials(r.Context(), userID)
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve permission denials",
+			slog.Any("error", err),
+			slog.Any("account_id", userID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(denials)
+}
+
+// Denies a permission to an account. The denial overrides every grant of the
+// permission including those inherited from roles
+func (ph *PermissionHandler) DenyUserPermission(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, permID, ok := parseDenialPath(w, r)
+	if !ok {
+		return
+	}
+
+	var body DenyPermissionRequest
+	if !utils.DecodeAndValidate(w, r, &body) {
+		return
+	}
+
+	var deniedBy pgtype.UUID
+	if claims, ok := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims); ok {
+		if id, err := uuid.Parse(claims.Subject); err == nil {
+			deniedBy = pgtype.UUID{Bytes: id, Valid: true}
+		}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	denial, err := repo.UpsertPermissionDenial(r.Context(), repository.UpsertPermissionDenialParams{
+		UserID:       userID,
+		PermissionID: permID,
+		Reason:       body.Reason,
+		DeniedBy:     deniedBy,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The account or permission you specified does not exist",
+			})
+			return
+		}
+		ph.Logger.Error("Failed to deny permission",
+			slog.Any("error", err),
+			slog.Any("account_id", userID),
+			slog.Any("permission", permID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionPermissionDenied, AuthzTargetAccount, userID.String(), nil,
+		map[string]any{"permission_id": permID, "reason": body.Reason},
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		ph.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ph.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	middleware.GetPermissionCache(r.Context()).Invalidate(userID)
+	publishAuthzChange(r, ph.AuthzEventBus, ph.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(denial)
+}
+
+// Lifts a denial so the account falls back to the permissions of its roles
+func (ph *PermissionHandler) RemoveUserPermissionDenial(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, permID, ok := parseDenialPath(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while starting transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	deleted, err := repo.DeletePermissionDenial(r.Context(), repository.DeletePermissionDenialParams{
+		UserID:       userID,
+		PermissionID: permID,
+	})
+	if err != nil {
+		ph.Logger.Error("Failed to remove permission denial",
+			slog.Any("error", err),
+			slog.Any("account_id", userID),
+			slog.Any("permission", permID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This permission is not denied to this account",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionPermissionDenialRemoved, AuthzTargetAccount, userID.String(),
+		map[string]any{"permission_id": permID}, nil,
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		ph.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		ph.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	middleware.GetPermissionCache(r.Context()).Invalidate(userID)
+	publishAuthzChange(r, ph.AuthzEventBus, ph.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Denial successfully removed"})
+}
@@ -6,68 +6,96 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/authz"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type PermissionHandler struct {
-	Logger *slog.Logger
+	Logger        *slog.Logger
+	PolicyEngine  *authz.Engine
+	AuthzEventBus *eventbus.AuthzEventBus
 }
 
 // Registers all the necessary routes associated with this handler group
 func (ph *PermissionHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("POST /permissions/create",
+	router.Handle("POST /api/v1/permissions/create",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"create:permission"}),
 		)(http.HandlerFunc(ph.CreatePermission)),
 	)
 
-	router.Handle("GET /permissions",
+	router.Handle("GET /api/v1/permissions",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"read:permission:any"}),
 			middleware.PaginationMiddleware(10, 100),
+			middleware.Cacheable(time.Minute),
 		)(http.HandlerFunc(ph.GetAllPermissions)),
 	)
 
-	router.Handle("GET /permissions/{id}",
+	router.Handle("GET /api/v1/permissions/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"read:permission:any"}),
+			middleware.Cacheable(time.Minute),
 		)(http.HandlerFunc(ph.GetPermissionByID)),
 	)
 
-	router.Handle("GET /permissions/user/{id}",
+	router.Handle("GET /api/v1/permissions/user/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"read:permission:user"}),
 		)(http.HandlerFunc(ph.GetAllUserPermissions)),
 	)
 
-	router.Handle("PATCH /permissions/{id}",
+	router.Handle("PATCH /api/v1/permissions/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"update:permission:any"}),
 		)(http.HandlerFunc(ph.UpdatePermission)),
 	)
 
-	router.Handle("GET /permissions/assign/{perm_id}/{role_id}",
+	router.Handle("GET /api/v1/permissions/assign/{perm_id}/{role_id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"assign:permission:role"}),
 		)(http.HandlerFunc(ph.AssignRolePermission)),
 	)
 
-	router.Handle("DELETE /permissions/revoke/{perm_id}/{role_id}",
+	router.Handle("DELETE /api/v1/permissions/revoke/{perm_id}/{role_id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,ph.Logger),
 			middleware.HasPermission([]string{"revoke:permission:role"}),
 		)(http.HandlerFunc(ph.RevokeRolePermission)),
 	)
+
+	router.Handle("GET /api/v1/permissions/denials/{user_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,ph.Logger),
+			middleware.HasPermission([]string{"read:permission:user"}),
+		)(http.HandlerFunc(ph.GetUserPermissionDenials)),
+	)
+
+	router.Handle("PUT /api/v1/permissions/denials/{user_id}/{perm_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,ph.Logger),
+			middleware.HasPermission([]string{"manage:permission_denial:any"}),
+		)(http.HandlerFunc(ph.DenyUserPermission)),
+	)
+
+	router.Handle("DELETE /api/v1/permissions/denials/{user_id}/{perm_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,ph.Logger),
+			middleware.HasPermission([]string{"manage:permission_denial:any"}),
+		)(http.HandlerFunc(ph.RemoveUserPermissionDenial)),
+	)
 }
 
 // Creates a permission
@@ -89,12 +117,7 @@ func (ph *PermissionHandler) CreatePermission(w http.ResponseWriter, r *http.Req
 
 	var permData repository.CreatePermissionParams
 
-	if err := json.NewDecoder(r.Body).Decode(&permData); err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &permData) {
 		return
 	}
 
@@ -126,14 +149,8 @@ func (ph *PermissionHandler) CreatePermission(w http.ResponseWriter, r *http.Req
 
 // Retrieves a permission by it's ID
 func (ph *PermissionHandler) GetPermissionByID(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -148,9 +165,7 @@ func (ph *PermissionHandler) GetPermissionByID(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	role, err := repo.GetPermissionByID(r.Context(), id)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -192,9 +207,7 @@ func (ph *PermissionHandler) GetAllPermissions(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	roles, err := repo.GetAllPermissions(r.Context(), repository.GetAllPermissionsParams{
 		Limit:  int32(pagination.Limit),
@@ -216,14 +229,8 @@ func (ph *PermissionHandler) GetAllPermissions(w http.ResponseWriter, r *http.Re
 
 // Retrieves all permissions associated
 func (ph *PermissionHandler) GetAllUserPermissions(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -238,9 +245,7 @@ func (ph *PermissionHandler) GetAllUserPermissions(w http.ResponseWriter, r *htt
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	roles, err := repo.GetUserPermissions(r.Context(), id)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
@@ -276,15 +281,17 @@ func (ph *PermissionHandler) UpdatePermission(w http.ResponseWriter, r *http.Req
 
 	var permData repository.UpdatePermissionParams
 
-	if err := json.NewDecoder(r.Body).Decode(&permData); err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &permData) {
 		return
 	}
 
+	var before any
+	var previousName string
+	if existing, err := repo.GetPermissionByID(r.Context(), permData.ID); err == nil && len(existing) > 0 {
+		before = existing[0]
+		previousName = existing[0].Name
+	}
+
 	created, err := repo.UpdatePermission(r.Context(), permData)
 	if err != nil {
 		ph.Logger.Error("Failed to update permission",
@@ -298,6 +305,37 @@ func (ph *PermissionHandler) UpdatePermission(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Callers still using the previous name keep working through an alias
+	// until they migrate
+	renamed := previousName != "" && previousName != created.Name
+	if renamed {
+		_, err = repo.DeletePermissionAlias(r.Context(), created.Name)
+		if err == nil {
+			_, err = repo.UpsertPermissionAlias(r.Context(), repository.UpsertPermissionAliasParams{
+				Alias:        previousName,
+				PermissionID: created.ID,
+			})
+		}
+		if err != nil {
+			ph.Logger.Error("Failed to alias renamed permission", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	change := newAuthzChange(r, AuthzActionPermissionUpdated, AuthzTargetPermission, created.ID.String(), before, created)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		ph.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		ph.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -307,32 +345,30 @@ func (ph *PermissionHandler) UpdatePermission(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, ph.AuthzEventBus, ph.Logger, change)
+	if renamed && ph.PolicyEngine != nil {
+		if err := ph.PolicyEngine.Reload(r.Context()); err != nil {
+			ph.Logger.Error("Failed to reload permission aliases", slog.Any("error", err))
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(created)
 }
 
+// Grants a permission to a role
+//
 // Some work might be needed to check for both the assign and revoke permission
 // better error handling
 func (ph *PermissionHandler) AssignRolePermission(w http.ResponseWriter, r *http.Request) {
-	rawRoleID := r.PathValue("role_id")
-	roleID, err := uuid.Parse(rawRoleID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
 		return
 	}
 
-	rawPermID := r.PathValue("perm_id")
-	permID, err := uuid.Parse(rawPermID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	permID, ok := utils.PathUUID(w, r, "perm_id")
+	if !ok {
 		return
 	}
 
@@ -368,6 +404,18 @@ func (ph *PermissionHandler) AssignRolePermission(w http.ResponseWriter, r *http
 		return
 	}
 
+	change := newAuthzChange(r, AuthzActionPermissionGranted, AuthzTargetRole, roleID.String(), nil,
+		map[string]any{"permission_id": permID},
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		ph.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		ph.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -377,31 +425,22 @@ func (ph *PermissionHandler) AssignRolePermission(w http.ResponseWriter, r *http
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, ph.AuthzEventBus, ph.Logger, change)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{"message": "Permission successfully assigned"})
 
 }
 
 func (ph *PermissionHandler) RevokeRolePermission(w http.ResponseWriter, r *http.Request) {
-	rawRoleID := r.PathValue("role_id")
-	roleID, err := uuid.Parse(rawRoleID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
 		return
 	}
 
-	rawPermID := r.PathValue("perm_id")
-	permID, err := uuid.Parse(rawPermID)
-	if err != nil {
-		ph.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	permID, ok := utils.PathUUID(w, r, "perm_id")
+	if !ok {
 		return
 	}
 
@@ -437,6 +476,18 @@ func (ph *PermissionHandler) RevokeRolePermission(w http.ResponseWriter, r *http
 		return
 	}
 
+	change := newAuthzChange(r, AuthzActionPermissionRevoked, AuthzTargetRole, roleID.String(),
+		map[string]any{"permission_id": permID}, nil,
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		ph.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		ph.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -446,6 +497,9 @@ func (ph *PermissionHandler) RevokeRolePermission(w http.ResponseWriter, r *http
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, ph.AuthzEventBus, ph.Logger, change)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{"message": "Permission successfully revoked from role"})
 
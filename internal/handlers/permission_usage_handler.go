@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// PermissionUsageResponse reports the permissions exercised since the
+// instance started counting along with those that never were
+type PermissionUsageResponse struct {
+	Since             time.Time               `json:"since"`
+	Usage             []authz.PermissionUsage `json:"usage"`
+	UnusedPermissions []string                `json:"unused_permissions"`
+}
+
+// Reports how often each permission let a request through on every route.
+// Counters are kept per instance so the report only covers the instance that
+// served the request
+func (ph *AuthorizationPolicyHandler) GetPermissionUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		ph.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	permissions, err := repository.New(conn).GetPermissionNames(r.Context())
+	if err != nil {
+		ph.Logger.Error("Failed to retrieve permissions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	tracker := middleware.GetUsageTracker(r.Context())
+	usage := tracker.Snapshot()
+
+	used := make(map[string]bool, len(usage))
+	for _, entry := range usage {
+		used[entry.Permission] = true
+	}
+	unused := []string{}
+	for _, permission := range permissions {
+		if !used[permission] {
+			unused = append(unused, permission)
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(PermissionUsageResponse{
+		Since:             tracker.Since(),
+		Usage:             usage,
+		UnusedPermissions: unused,
+	})
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// How long a pool may take to hand out a connection and answer a ping
+// before it is reported unreachable
+const readinessPingTimeout = 2 * time.Second
+
+// ReadinessHandler reports whether Verisafe can serve requests, i.e. whether
+// its database pools hand out connections
+type ReadinessHandler struct {
+	// The pool every request uses
+	Primary *pgxpool.Pool
+	// The read replica pool, nil when no replica is configured
+	Replica *pgxpool.Pool
+	// Set once Verisafe is shutting down, nil when it never drains
+	Draining *atomic.Bool
+}
+
+// ReadinessResponse is the payload served by the readiness endpoint. Status
+// is "not_ready" when the primary pool is unreachable, "draining" while
+// Verisafe shuts down, and "degraded" when the replica is unreachable or a
+// pool has every connection in use
+type ReadinessResponse struct {
+	Status string                   `json:"status"`
+	Pools  map[string]DBPoolReading `json:"pools"`
+}
+
+// DBPoolReading is the state of a database connection pool
+type DBPoolReading struct {
+	// Whether a connection could be acquired and pinged in time
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	// Whether every connection the pool may open is in use, new requests
+	// then wait for one to be released
+	Saturated               bool    `json:"saturated"`
+	MaxConnections          int32   `json:"max_connections"`
+	TotalConnections        int32   `json:"total_connections"`
+	AcquiredConnections     int32   `json:"acquired_connections"`
+	IdleConnections         int32   `json:"idle_connections"`
+	ConstructingConnections int32   `json:"constructing_connections"`
+	AcquireCount            int64   `json:"acquire_count"`
+	EmptyAcquireCount       int64   `json:"empty_acquire_count"`
+	CanceledAcquireCount    int64   `json:"canceled_acquire_count"`
+	AcquireDurationMs       float64 `json:"acquire_duration_ms"`
+	EmptyAcquireWaitMs      float64 `json:"empty_acquire_wait_ms"`
+}
+
+// Returns the state of the database pools. Responds with 503 when the
+// primary pool cannot hand out a connection or Verisafe is shutting down, so
+// that load balancers stop routing requests to the instance
+func (rh *ReadinessHandler) GetReadiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := ReadinessResponse{
+		Status: "ready",
+		Pools:  map[string]DBPoolReading{},
+	}
+	primary := readPool(r.Context(), rh.Primary)
+	response.Pools["primary"] = primary
+	if !primary.Reachable {
+		response.Status = "not_ready"
+	} else if primary.Saturated {
+		response.Status = "degraded"
+	}
+
+	// Reads fall back to the primary while the replica is unreachable
+	if rh.Replica != nil {
+		replica := readPool(r.Context(), rh.Replica)
+		response.Pools["replica"] = replica
+		if (!replica.Reachable || replica.Saturated) && response.Status == "ready" {
+			response.Status = "degraded"
+		}
+	}
+
+	if rh.Draining != nil && rh.Draining.Load() {
+		response.Status = "draining"
+	}
+
+	if response.Status == "not_ready" || response.Status == "draining" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// readPool pings a pool and reads its statistics. The statistics are read
+// before the ping so that its own connection is not counted
+func readPool(ctx context.Context, pool *pgxpool.Pool) DBPoolReading {
+	stat := pool.Stat()
+	reading := DBPoolReading{
+		Reachable:               true,
+		Saturated:               stat.MaxConns() > 0 && stat.AcquiredConns() >= stat.MaxConns(),
+		MaxConnections:          stat.MaxConns(),
+		TotalConnections:        stat.TotalConns(),
+		AcquiredConnections:     stat.AcquiredConns(),
+		IdleConnections:         stat.IdleConns(),
+		ConstructingConnections: stat.ConstructingConns(),
+		AcquireCount:            stat.AcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		AcquireDurationMs:       float64(stat.AcquireDuration()) / float64(time.Millisecond),
+		EmptyAcquireWaitMs:      float64(stat.EmptyAcquireWaitTime()) / float64(time.Millisecond),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessPingTimeout)
+	defer cancel()
+	if err := pool.Ping(ctx); err != nil {
+		reading.Reachable = false
+		reading.Error = err.Error()
+	}
+	return reading
+}
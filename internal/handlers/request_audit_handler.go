@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// RequestAuditHandler serves the log of state changing requests
+type RequestAuditHandler struct {
+	Logger *slog.Logger
+}
+
+// RequestAuditEntryResponse is the API representation of a recorded request
+type RequestAuditEntryResponse struct {
+	ID          int64             `json:"id"`
+	RequestID   string            `json:"request_id"`
+	ActorID     pgtype.UUID       `json:"actor_id"`
+	Method      string            `json:"method"`
+	Route       string            `json:"route"`
+	Path        string            `json:"path"`
+	ResourceIDs map[string]string `json:"resource_ids"`
+	Status      int32             `json:"status"`
+	LatencyMs   int32             `json:"latency_ms"`
+	IPAddress   *string           `json:"ip_address"`
+	UserAgent   *string           `json:"user_agent"`
+	OccurredAt  pgtype.Timestamp  `json:"occurred_at"`
+}
+
+func newRequestAuditEntryResponse(entry repository.RequestAuditLog) RequestAuditEntryResponse {
+	resourceIDs := map[string]string{}
+	json.Unmarshal(entry.ResourceIds, &resourceIDs)
+
+	return RequestAuditEntryResponse{
+		ID:          entry.ID,
+		RequestID:   entry.RequestID,
+		ActorID:     entry.ActorID,
+		Method:      entry.Method,
+		Route:       entry.Route,
+		Path:        entry.Path,
+		ResourceIDs: resourceIDs,
+		Status:      entry.Status,
+		LatencyMs:   entry.LatencyMs,
+		IPAddress:   entry.IpAddress,
+		UserAgent:   entry.UserAgent,
+		OccurredAt:  entry.OccurredAt,
+	}
+}
+
+// RegisterRoutes registers the request audit routes
+func (rh *RequestAuditHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
+	router.Handle("GET /api/v1/admin/audit/requests",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg, rh.Logger),
+			middleware.HasPermission([]string{"read:request_audit:any"}),
+			middleware.PaginationMiddleware(20, 100),
+		)(http.HandlerFunc(rh.GetRequestAuditLog)),
+	)
+}
+
+// Retrieves the recorded state changing requests newest first. Entries may be
+// filtered by actor_id, route and method
+func (rh *RequestAuditHandler) GetRequestAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+	query := r.URL.Query()
+
+	params := repository.GetRequestAuditEntriesParams{
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	}
+	if route := query.Get("route"); route != "" {
+		params.Route = &route
+	}
+	if method := query.Get("method"); method != "" {
+		method = strings.ToUpper(method)
+		params.Method = &method
+	}
+	if rawActorID := query.Get("actor_id"); rawActorID != "" {
+		actorID, err := uuid.Parse(rawActorID)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Please provide a valid actor id",
+			})
+			return
+		}
+		params.ActorID = pgtype.UUID{Bytes: actorID, Valid: true}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	entries, err := repository.New(conn).GetRequestAuditEntries(r.Context(), params)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve request audit log", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]RequestAuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		response = append(response, newRequestAuditEntryResponse(entry))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
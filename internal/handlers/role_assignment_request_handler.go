@@ -0,0 +1,517 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// ReviewRoleAssignmentRequest is the optional body accepted when approving or
+// rejecting a role assignment request
+type ReviewRoleAssignmentRequest struct {
+	Note *string `json:"note"`
+}
+
+// SetRoleRequiresApprovalRequest toggles the second approver requirement of
+// a role
+type SetRoleRequiresApprovalRequest struct {
+	RequiresApproval *bool `json:"requires_approval" validate:"required"`
+}
+
+// requestRoleAssignment records a pending request to assign a role that
+// requires approval, within the institution when institutionID is set, and
+// lets the approvers know about it. It is called by AssignUserRole and
+// AssignInstitutionRole with the transaction they opened
+func (rh *RoleHandler) requestRoleAssignment(w http.ResponseWriter, r *http.Request,
+	tx pgx.Tx, repo *repository.Queries, userID uuid.UUID, role repository.Role, institutionID *int32,
+) {
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	requesterID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only accounts may request sensitive role assignments",
+		})
+		return
+	}
+
+	var reason *string
+	if value := r.URL.Query().Get("reason"); value != "" {
+		reason = &value
+	}
+
+	request, err := repo.CreateRoleAssignmentRequest(r.Context(), repository.CreateRoleAssignmentRequestParams{
+		UserID:        userID,
+		RoleID:        role.ID,
+		RequestedBy:   requesterID,
+		Reason:        reason,
+		InstitutionID: institutionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "An assignment of this role to this account is already awaiting approval",
+			})
+			return
+		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" { // foreign_key_violation
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The account or institution you specified does not exist",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to create role assignment request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	approvers, err := repo.GetRoleAssignmentApprovers(r.Context())
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role assignment approvers", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	recipients := make([]string, 0, len(approvers))
+	for _, approver := range approvers {
+		// The requester cannot approve their own request so there is no
+		// point in asking them to
+		if approver != requesterID {
+			recipients = append(recipients, approver.String())
+		}
+	}
+	rh.sendRoleAssignmentNotification(r.Context(), eventbus.NotificationPayload{
+		Headings: map[string]string{
+			"en": "Role assignment awaiting approval",
+		},
+		Contents: map[string]string{
+			"en": fmt.Sprintf("Assigning the %s role needs your approval", role.Name),
+		},
+		IncludeExternalUserIds: recipients,
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(request)
+}
+
+// Retrieves role assignment requests newest first. Requests may be filtered
+// by status
+func (rh *RoleHandler) GetRoleAssignmentRequests(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+
+	params := repository.GetRoleAssignmentRequestsParams{
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch repository.RoleAssignmentRequestStatus(status) {
+		case repository.RoleAssignmentRequestStatusPending,
+			repository.RoleAssignmentRequestStatusApproved,
+			repository.RoleAssignmentRequestStatusRejected:
+			params.Status = repository.NullRoleAssignmentRequestStatus{
+				RoleAssignmentRequestStatus: repository.RoleAssignmentRequestStatus(status),
+				Valid:                       true,
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Status must be one of pending, approved or rejected",
+			})
+			return
+		}
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	requests, err := repo.GetRoleAssignmentRequests(r.Context(), params)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role assignment requests", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(requests)
+}
+
+// Approves a pending role assignment request and assigns the role
+func (rh *RoleHandler) ApproveRoleAssignmentRequest(w http.ResponseWriter, r *http.Request) {
+	rh.reviewRoleAssignmentRequest(w, r, repository.RoleAssignmentRequestStatusApproved)
+}
+
+// Rejects a pending role assignment request
+func (rh *RoleHandler) RejectRoleAssignmentRequest(w http.ResponseWriter, r *http.Request) {
+	rh.reviewRoleAssignmentRequest(w, r, repository.RoleAssignmentRequestStatusRejected)
+}
+
+// reviewRoleAssignmentRequest settles a pending request. The reviewer must be
+// a different person from both the requester and the account receiving the
+// role
+func (rh *RoleHandler) reviewRoleAssignmentRequest(w http.ResponseWriter, r *http.Request,
+	status repository.RoleAssignmentRequestStatus,
+) {
+	w.Header().Set("Content-Type", "application/json")
+	requestID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body ReviewRoleAssignmentRequest
+	if !utils.DecodeAndValidate(w, r, &body) {
+		return
+	}
+
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	reviewerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Only accounts may review role assignment requests",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Failed to begin transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	request, err := repo.GetRoleAssignmentRequestByID(r.Context(), requestID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role assignment request you are looking for does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role assignment request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if request.RequestedBy == reviewerID || request.UserID == reviewerID {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Role assignment requests must be reviewed by a second approver",
+		})
+		return
+	}
+
+	reviewed, err := repo.ReviewRoleAssignmentRequest(r.Context(), repository.ReviewRoleAssignmentRequestParams{
+		ID:         requestID,
+		Status:     status,
+		ReviewedBy: pgtype.UUID{Bytes: reviewerID, Valid: true},
+		ReviewNote: body.Note,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This role assignment request has already been reviewed",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to review role assignment request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	role, err := repo.GetRoleByID(r.Context(), request.RoleID)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", request.RoleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	var change *eventbus.AuthzChange
+	if status == repository.RoleAssignmentRequestStatusApproved {
+		if !role.IsActive {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Inactive roles cannot be assigned",
+			})
+			return
+		}
+		if role.InstitutionID != nil && (request.InstitutionID == nil || *role.InstitutionID != *request.InstitutionID) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This role is scoped to a different institution",
+			})
+			return
+		}
+
+		action := AuthzActionRoleAssigned
+		details := map[string]any{
+			"role_id":      role.ID,
+			"role_name":    role.Name,
+			"request_id":   request.ID,
+			"requested_by": request.RequestedBy,
+		}
+		if request.InstitutionID != nil {
+			action = AuthzActionInstitutionRoleAssigned
+			details["institution_id"] = *request.InstitutionID
+			_, err = repo.AssignInstitutionRole(r.Context(), repository.AssignInstitutionRoleParams{
+				UserID:        request.UserID,
+				RoleID:        request.RoleID,
+				InstitutionID: *request.InstitutionID,
+			})
+		} else {
+			_, err = repo.AssignRole(r.Context(), repository.AssignRoleParams{
+				UserID: request.UserID,
+				RoleID: request.RoleID,
+			})
+		}
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "The account already holds this role",
+				})
+				return
+			}
+			rh.Logger.Error("Failed to assign role to user",
+				slog.Any("error", err),
+				slog.Any("role", request.RoleID.String()),
+				slog.Any("user", request.UserID.String()),
+				slog.Any("institution", request.InstitutionID),
+			)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+
+		assigned := newAuthzChange(r, action, AuthzTargetAccount, request.UserID.String(), nil, details)
+		if err := recordAuthzChange(r.Context(), repo, assigned); err != nil {
+			rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		change = &assigned
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	if change != nil {
+		middleware.GetPermissionCache(r.Context()).Invalidate(request.UserID)
+		publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, *change)
+		publishRoleEvent(r, rh.RoleEventBus, rh.Logger, true, eventbus.RoleAssignment{
+			AccountID:     request.UserID.String(),
+			RoleID:        role.ID.String(),
+			RoleName:      role.Name,
+			InstitutionID: request.InstitutionID,
+			ActorID:       change.ActorID,
+		})
+	}
+
+	rh.sendRoleAssignmentNotification(r.Context(), eventbus.NotificationPayload{
+		Headings: map[string]string{
+			"en": fmt.Sprintf("Role assignment %s", status),
+		},
+		Contents: map[string]string{
+			"en": fmt.Sprintf("Your request to assign the %s role was %s", role.Name, status),
+		},
+		TargetUserID: request.RequestedBy.String(),
+	})
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(reviewed)
+}
+
+// Marks whether assigning a role requires a second approver
+func (rh *RoleHandler) SetRoleRequiresApproval(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	roleID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var body SetRoleRequiresApprovalRequest
+	if !utils.DecodeAndValidate(w, r, &body) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Failed to begin transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	before, err := repo.GetRoleByID(r.Context(), roleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	role, err := repo.SetRoleRequiresApproval(r.Context(), repository.SetRoleRequiresApprovalParams{
+		ID:               roleID,
+		RequiresApproval: *body.RequiresApproval,
+	})
+	if err != nil {
+		rh.Logger.Error("Failed to update role", slog.Any("error", err), slog.Any("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	change := newAuthzChange(r, AuthzActionRoleUpdated, AuthzTargetRole, role.ID.String(), before, role)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(role)
+}
+
+// sendRoleAssignmentNotification fills in the app specific fields of the
+// notification and publishes it in the background
+func (rh *RoleHandler) sendRoleAssignmentNotification(ctx context.Context, notification eventbus.NotificationPayload) {
+	if rh.NotificationEventBus == nil {
+		return
+	}
+	if notification.TargetUserID == "" && len(notification.IncludeExternalUserIds) == 0 {
+		return
+	}
+
+	fillNotificationDefaults(rh.Cfg, &notification)
+
+	eventRequestID := middleware.GetRequestID(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := rh.NotificationEventBus.PublishPushNotificationRequested(ctx, notification, eventRequestID); err != nil {
+			rh.Logger.Error("Failed to publish role assignment notification",
+				slog.Any("event_id", eventRequestID),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
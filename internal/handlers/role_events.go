@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+)
+
+// publishRoleEvent notifies downstream services that an account gained or
+// lost a role while serving the request. It should only be called once the
+// change has been committed
+func publishRoleEvent(r *http.Request, bus *eventbus.RoleEventBus, logger *slog.Logger, assigned bool, assignment eventbus.RoleAssignment) {
+	if bus == nil {
+		return
+	}
+
+	eventRequestID := middleware.GetRequestID(r.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		publish := bus.PublishRoleRevoked
+		if assigned {
+			publish = bus.PublishRoleAssigned
+		}
+		if err := publish(ctx, assignment, eventRequestID); err != nil {
+			logger.Error("Failed to publish role event",
+				slog.Any("request_id", eventRequestID),
+				slog.Any("event_data", assignment),
+				slog.Any("error", err),
+			)
+		}
+	}()
+}
@@ -6,70 +6,266 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/authz"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type RoleHandler struct {
-	Logger *slog.Logger
+	Logger               *slog.Logger
+	Cfg                  *config.Config
+	PolicyEngine         *authz.Engine
+	AuthzEventBus        *eventbus.AuthzEventBus
+	RoleEventBus         *eventbus.RoleEventBus
+	NotificationEventBus *eventbus.NotificationEventBus
 }
 
 // Registers all the necessary routes associated with this handler group
 func (rh *RoleHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("POST /roles/create",
+	router.Handle("POST /api/v1/roles/create",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"create:role"}),
 		)(http.HandlerFunc(rh.CreateRole)),
 	)
 
-	router.Handle("GET /roles",
+	router.Handle("GET /api/v1/roles",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"read:role:any"}),
 			middleware.PaginationMiddleware(10, 100),
+			middleware.Cacheable(time.Minute),
 		)(http.HandlerFunc(rh.GetAllRoles)),
 	)
 
-	router.Handle("GET /roles/{id}",
+	router.Handle("POST /api/v1/roles/templates",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"create:role_template:any"}),
+		)(http.HandlerFunc(rh.CreateRoleTemplate)),
+	)
+
+	router.Handle("GET /api/v1/roles/templates",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"read:role_template:any"}),
+			middleware.PaginationMiddleware(10, 100),
+			middleware.Cacheable(time.Minute),
+		)(http.HandlerFunc(rh.GetAllRoleTemplates)),
+	)
+
+	router.Handle("GET /api/v1/roles/templates/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"read:role_template:any"}),
+			middleware.Cacheable(time.Minute),
+		)(http.HandlerFunc(rh.GetRoleTemplateByID)),
+	)
+
+	router.Handle("PATCH /api/v1/roles/templates/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:role_template:any"}),
+		)(http.HandlerFunc(rh.UpdateRoleTemplate)),
+	)
+
+	router.Handle("DELETE /api/v1/roles/templates/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"delete:role_template:any"}),
+		)(http.HandlerFunc(rh.DeleteRoleTemplate)),
+	)
+
+	router.Handle("POST /api/v1/roles/templates/{id}/instantiate",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"instantiate:role_template:any"}),
+		)(http.HandlerFunc(rh.InstantiateRoleTemplate)),
+	)
+
+	router.Handle("GET /api/v1/roles/assignment-requests",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"review:role_assignment:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(rh.GetRoleAssignmentRequests)),
+	)
+
+	router.Handle("POST /api/v1/roles/assignment-requests/{id}/approve",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"review:role_assignment:any"}),
+		)(http.HandlerFunc(rh.ApproveRoleAssignmentRequest)),
+	)
+
+	router.Handle("POST /api/v1/roles/assignment-requests/{id}/reject",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"review:role_assignment:any"}),
+		)(http.HandlerFunc(rh.RejectRoleAssignmentRequest)),
+	)
+
+	router.Handle("PUT /api/v1/roles/custom/permissions/{permission_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:permission:any"}),
+		)(http.HandlerFunc(rh.AllowCustomRolePermission)),
+	)
+
+	router.Handle("DELETE /api/v1/roles/custom/permissions/{permission_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:permission:any"}),
+		)(http.HandlerFunc(rh.DisallowCustomRolePermission)),
+	)
+
+	router.Handle("GET /api/v1/roles/institutions/{institution_id}/custom/permissions",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"manage:custom_role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.GetCustomRolePermissionAllowlist)),
+	)
+
+	router.Handle("GET /api/v1/roles/institutions/{institution_id}/custom",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"manage:custom_role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.GetInstitutionCustomRoles)),
+	)
+
+	router.Handle("POST /api/v1/roles/institutions/{institution_id}/custom",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"manage:custom_role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.CreateInstitutionCustomRole)),
+	)
+
+	router.Handle("PATCH /api/v1/roles/institutions/{institution_id}/custom/{role_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"manage:custom_role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.UpdateInstitutionCustomRole)),
+	)
+
+	router.Handle("DELETE /api/v1/roles/institutions/{institution_id}/custom/{role_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"manage:custom_role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.DeleteInstitutionCustomRole)),
+	)
+
+	router.Handle("GET /api/v1/roles/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"read:role:any"}),
+			middleware.Cacheable(time.Minute),
 		)(http.HandlerFunc(rh.GetRoleByID)),
 	)
 
-	router.Handle("GET /roles/user/{id}",
+	router.Handle("GET /api/v1/roles/user/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
-			middleware.HasPermission([]string{"read:role:any"}),
+			middleware.Authorize(rh.PolicyEngine, rh.Logger, "read:role:any",
+				middleware.PolicyResource{OwnerParam: "id"}),
 		)(http.HandlerFunc(rh.GetAllUserRoles)),
 	)
 
-	router.Handle("GET /roles/permissions/{id}",
+	router.Handle("GET /api/v1/roles/permissions/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"read:role:permissions"}),
+			middleware.Cacheable(time.Minute),
 		)(http.HandlerFunc(rh.GetRolePermissions)),
 	)
 
-	router.Handle("PATCH /roles/{id}",
+	// GET routes below a role overlap the routes of the collections under
+	// /api/v1/roles, such as /api/v1/roles/templates/{id}, which the router
+	// refuses to register together. They are served by a router of their own
+	// that gets the requests none of the collections match
+	roleRoutes := http.NewServeMux()
+	router.Handle("GET /api/v1/roles/{id}/{relation}", roleRoutes)
+
+	roleRoutes.Handle("GET /api/v1/roles/{id}/accounts",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"read:role:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(rh.GetRoleAccounts)),
+	)
+
+	router.Handle("PATCH /api/v1/roles/{id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"update:role:any"}),
 		)(http.HandlerFunc(rh.UpdateRole)),
 	)
 
-	router.Handle("GET /roles/assign/{user_id}/{role_id}",
+	router.Handle("DELETE /api/v1/roles/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"delete:role:any"}),
+		)(http.HandlerFunc(rh.DeleteRole)),
+	)
+
+	router.Handle("POST /api/v1/roles/{id}/deactivate",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:role:any"}),
+		)(http.HandlerFunc(rh.DeactivateRole)),
+	)
+
+	router.Handle("POST /api/v1/roles/{id}/activate",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:role:any"}),
+		)(http.HandlerFunc(rh.ActivateRole)),
+	)
+
+	router.Handle("PUT /api/v1/roles/{id}/approval",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasPermission([]string{"update:role:any"}),
+		)(http.HandlerFunc(rh.SetRoleRequiresApproval)),
+	)
+
+	router.Handle("GET /api/v1/roles/assign/{user_id}/{role_id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"assign:role:any"}),
 		)(http.HandlerFunc(rh.AssignUserRole)),
 	)
 
-	router.Handle("DELETE /roles/revoke/{user_id}/{role_id}",
+	router.Handle("GET /api/v1/roles/user/{id}/institutions",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.Authorize(rh.PolicyEngine, rh.Logger, "read:role:any",
+				middleware.PolicyResource{OwnerParam: "id"}),
+		)(http.HandlerFunc(rh.GetUserInstitutionRoles)),
+	)
+
+	router.Handle("POST /api/v1/roles/assign/{user_id}/{role_id}/institutions/{institution_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"assign:role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.AssignInstitutionRole)),
+	)
+
+	router.Handle("DELETE /api/v1/roles/revoke/{user_id}/{role_id}/institutions/{institution_id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(cfg,rh.Logger),
+			middleware.HasInstitutionPermission([]string{"assign:role:any"}, "institution_id"),
+		)(http.HandlerFunc(rh.RevokeInstitutionRole)),
+	)
+
+	router.Handle("DELETE /api/v1/roles/revoke/{user_id}/{role_id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg,rh.Logger),
 			middleware.HasPermission([]string{"assign:role:any"}),
@@ -96,12 +292,7 @@ func (rh *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 
 	var roleData repository.CreateRoleParams
 
-	if err := json.NewDecoder(r.Body).Decode(&roleData); err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &roleData) {
 		return
 	}
 
@@ -129,14 +320,8 @@ func (rh *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rh *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -151,9 +336,7 @@ func (rh *RoleHandler) GetRoleByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	role, err := repo.GetRoleByID(r.Context(), id)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -192,9 +375,7 @@ func (rh *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	roles, err := repo.GetAllRoles(r.Context(), repository.GetAllRolesParams{
 		Limit:  int32(pagination.Limit),
@@ -215,14 +396,8 @@ func (rh *RoleHandler) GetAllRoles(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rh *RoleHandler) GetAllUserRoles(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -237,9 +412,7 @@ func (rh *RoleHandler) GetAllUserRoles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	roles, err := repo.GetAllUserRoles(r.Context(), id)
 	if err != nil {
@@ -274,11 +447,23 @@ func (rh *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 
 	var roleData repository.UpdateRoleParams
 
-	if err := json.NewDecoder(r.Body).Decode(&roleData); err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &roleData) {
+		return
+	}
+
+	before, err := repo.GetRoleByID(r.Context(), roleData.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
+			"error": "The role you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", roleData.ID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
 		})
 		return
 	}
@@ -293,6 +478,16 @@ func (rh *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	change := newAuthzChange(r, AuthzActionRoleUpdated, AuthzTargetRole, created.ID.String(), before, created)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -302,19 +497,16 @@ func (rh *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(created)
 }
 
 func (rh *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -329,9 +521,7 @@ func (rh *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	roles, err := repo.GetRolePermissions(r.Context(), id)
 	if err != nil {
@@ -350,31 +540,77 @@ func (rh *RoleHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request
 
 }
 
-// Some work might be needed to check for both the assign and revoke roles
-// better error handling
-func (rh *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
-	rawUserID := r.PathValue("user_id")
-	userID, err := uuid.Parse(rawUserID)
+// Retrieves the accounts currently holding a role
+func (rh *RoleHandler) GetRoleAccounts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
 	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
+			"error": "We ran into a problem while servicing your request please try again later",
 		})
 		return
 	}
 
-	rawRoleID := r.PathValue("role_id")
-	roleID, err := uuid.Parse(rawRoleID)
+	repo := repository.New(conn)
+	if _, err := repo.GetRoleByID(r.Context(), id); errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are looking for does not exist",
+		})
+		return
+	} else if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	accounts, err := repo.GetRoleAccounts(r.Context(), repository.GetRoleAccountsParams{
+		RoleID: id,
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	})
 	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
+		rh.Logger.Error("Failed to retrieve role accounts", slog.Any("error", err),
+			slog.Any("role", id.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
+			"error": "We couldn't complete this request at the moment please try again later",
 		})
 		return
 	}
 
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// Assigns a role to a user
+//
+// Some work might be needed to check for both the assign and revoke roles
+// better error handling
+func (rh *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
+		return
+	}
+
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	conn, err := middleware.GetDBConnFromContext(r.Context())
 	if err != nil {
@@ -390,6 +626,41 @@ func (rh *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback(r.Context())
 	repo := repository.New(tx)
 
+	role, err := repo.GetRoleByID(r.Context(), roleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are trying to assign does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if !role.IsActive {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Inactive roles cannot be assigned",
+		})
+		return
+	}
+	if role.InstitutionID != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Institution scoped roles can only be assigned within their institution",
+		})
+		return
+	}
+	if role.RequiresApproval {
+		rh.requestRoleAssignment(w, r, tx, repo, userID, role, nil)
+		return
+	}
+
 	_, err = repo.AssignRole(r.Context(), repository.AssignRoleParams{
 		UserID: userID,
 		RoleID: roleID,
@@ -407,6 +678,18 @@ func (rh *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	change := newAuthzChange(r, AuthzActionRoleAssigned, AuthzTargetAccount, userID.String(), nil,
+		map[string]any{"role_id": role.ID, "role_name": role.Name},
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -416,31 +699,28 @@ func (rh *RoleHandler) AssignUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).Invalidate(userID)
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+	publishRoleEvent(r, rh.RoleEventBus, rh.Logger, true, eventbus.RoleAssignment{
+		AccountID: userID.String(),
+		RoleID:    role.ID.String(),
+		RoleName:  role.Name,
+		ActorID:   change.ActorID,
+	})
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{"message": "Role successfully assigned"})
 
 }
 
 func (rh *RoleHandler) RevokeUserRole(w http.ResponseWriter, r *http.Request) {
-	rawUserID := r.PathValue("user_id")
-	userID, err := uuid.Parse(rawUserID)
-	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	userID, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
 		return
 	}
 
-	rawRoleID := r.PathValue("role_id")
-	roleID, err := uuid.Parse(rawRoleID)
-	if err != nil {
-		rh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	roleID, ok := utils.PathUUID(w, r, "role_id")
+	if !ok {
 		return
 	}
 
@@ -459,6 +739,23 @@ func (rh *RoleHandler) RevokeUserRole(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback(r.Context())
 	repo := repository.New(tx)
 
+	role, err := repo.GetRoleByID(r.Context(), roleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are trying to revoke does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", roleID.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	err = repo.RevokeRole(r.Context(), repository.RevokeRoleParams{
 		UserID: userID,
 		RoleID: roleID,
@@ -476,6 +773,18 @@ func (rh *RoleHandler) RevokeUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	change := newAuthzChange(r, AuthzActionRoleRevoked, AuthzTargetAccount, userID.String(),
+		map[string]any{"role_id": role.ID, "role_name": role.Name}, nil,
+	)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
 	if err = tx.Commit(r.Context()); err != nil {
 		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
 		w.WriteHeader(http.StatusInternalServerError)
@@ -485,7 +794,337 @@ func (rh *RoleHandler) RevokeUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	middleware.GetPermissionCache(r.Context()).Invalidate(userID)
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+	publishRoleEvent(r, rh.RoleEventBus, rh.Logger, false, eventbus.RoleAssignment{
+		AccountID: userID.String(),
+		RoleID:    role.ID.String(),
+		RoleName:  role.Name,
+		ActorID:   change.ActorID,
+	})
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]any{"message": "Role successfully revoked"})
 
 }
+
+// Roles the platform depends on which may never be deleted or deactivated
+var protectedRoleNames = []string{"system", "Administrator"}
+
+// isProtectedRole reports whether a role is required by the platform. Default
+// roles are protected since every new account is expected to receive them
+func isProtectedRole(role repository.Role) bool {
+	return role.IsDefault || slices.Contains(protectedRoleNames, role.Name)
+}
+
+// Deletes a role. Roles that are still assigned to accounts can only be
+// deleted with force=true in which case reassign_to must name an active role
+// the affected accounts are moved to. The role is soft deleted: its
+// remaining assignments are revoked but its permissions are kept so that an
+// administrator can restore it through /api/v1/admin/deleted/roles
+func (rh *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	var reassignTo uuid.UUID
+	if raw := r.URL.Query().Get("reassign_to"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil || parsed == id {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Please provide a valid role to reassign accounts to",
+			})
+			return
+		}
+		reassignTo = parsed
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	role, err := repo.GetRoleByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are trying to delete does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if isProtectedRole(role) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This role is required by the platform and cannot be deleted",
+		})
+		return
+	}
+
+	assignments, err := repo.CountRoleAssignments(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to count role assignments", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	var reassigned int64
+	if assignments > 0 {
+		if !force || reassignTo == uuid.Nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":       "This role is still assigned to accounts. Pass force=true and reassign_to to move them to another role",
+				"assignments": assignments,
+			})
+			return
+		}
+
+		target, err := repo.GetRoleByID(r.Context(), reassignTo)
+		if errors.Is(err, pgx.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The role you are trying to reassign accounts to does not exist",
+			})
+			return
+		}
+		if err != nil {
+			rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", reassignTo.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		if !target.IsActive {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Accounts cannot be reassigned to an inactive role",
+			})
+			return
+		}
+		if target.InstitutionID != nil {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Accounts cannot be reassigned to an institution scoped role",
+			})
+			return
+		}
+
+		reassigned, err = repo.ReassignRoleAccounts(r.Context(), repository.ReassignRoleAccountsParams{
+			ToRoleID:   reassignTo,
+			FromRoleID: id,
+		})
+		if err != nil {
+			rh.Logger.Error("Failed to reassign role accounts",
+				slog.Any("error", err),
+				slog.Any("role", id.String()),
+				slog.Any("reassign_to", reassignTo.String()),
+			)
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't delete this role at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	revoked, err := repo.RevokeRoleAssignments(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to revoke role assignments", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't delete this role at the moment please try again later",
+		})
+		return
+	}
+
+	if _, err := repo.DeleteRole(r.Context(), id); err != nil {
+		rh.Logger.Error("Failed to delete role", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't delete this role at the moment please try again later",
+		})
+		return
+	}
+
+	var after any
+	if reassigned > 0 {
+		after = map[string]any{"accounts_reassigned_to": reassignTo, "accounts_reassigned": reassigned}
+	}
+	change := newAuthzChange(r, AuthzActionRoleDeleted, AuthzTargetRole, id.String(), role, after)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	rh.Logger.Info("Role deleted",
+		slog.String("role", id.String()),
+		slog.String("name", role.Name),
+		slog.Int64("assignments", assignments),
+		slog.Int64("assignments_revoked", revoked),
+	)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":             "Role successfully deleted",
+		"accounts_reassigned": reassigned,
+		"assignments_revoked": revoked,
+	})
+}
+
+// Deactivates a role. The role stays assigned to its accounts but none of
+// its permissions apply until it is activated again
+func (rh *RoleHandler) DeactivateRole(w http.ResponseWriter, r *http.Request) {
+	rh.setRoleActive(w, r, false)
+}
+
+// Activates a previously deactivated role
+func (rh *RoleHandler) ActivateRole(w http.ResponseWriter, r *http.Request) {
+	rh.setRoleActive(w, r, true)
+}
+
+// setRoleActive updates the active state of the role named in the path
+func (rh *RoleHandler) setRoleActive(w http.ResponseWriter, r *http.Request, active bool) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	role, err := repo.GetRoleByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role you are requesting does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if !active && isProtectedRole(role) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "This role is required by the platform and cannot be deactivated",
+		})
+		return
+	}
+
+	updated, err := repo.SetRoleActive(r.Context(), repository.SetRoleActiveParams{
+		ID:       id,
+		IsActive: active,
+	})
+	if err != nil {
+		rh.Logger.Error("Failed to update role state", slog.Any("error", err), slog.Any("role", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again",
+		})
+		return
+	}
+
+	action := AuthzActionRoleDeactivated
+	if active {
+		action = AuthzActionRoleActivated
+	}
+	change := newAuthzChange(r, action, AuthzTargetRole, id.String(), role, updated)
+	if err := recordAuthzChange(r.Context(), repo, change); err != nil {
+		rh.Logger.Error("Failed to record rbac audit entry", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	middleware.GetPermissionCache(r.Context()).InvalidateAll()
+	publishAuthzChange(r, rh.AuthzEventBus, rh.Logger, change)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
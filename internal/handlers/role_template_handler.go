@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// RoleTemplateRequest is the body expected when creating or updating a role
+// template. Omitting permissions on update leaves them unchanged
+type RoleTemplateRequest struct {
+	Name        *string  `json:"name" validate:"omitnil,notblank"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleTemplateResponse is a role template alongside the permissions it
+// carries
+type RoleTemplateResponse struct {
+	repository.RoleTemplate
+	Permissions []repository.Permission `json:"permissions"`
+}
+
+// InstantiateRoleTemplateRequest is the body expected when creating a role
+// from a template. The role is scoped to the institution unless scoped is
+// explicitly set to false and is named after the template and institution
+// unless a name is provided
+type InstantiateRoleTemplateRequest struct {
+	InstitutionID int32   `json:"institution_id" validate:"required"`
+	Name          *string `json:"name"`
+	Description   *string `json:"description"`
+	Scoped        *bool   `json:"scoped"`
+}
+
+// resolveTemplatePermissions looks up the named permissions returning the
+// names that do not exist
+func resolveTemplatePermissions(ctx context.Context, repo *repository.Queries, names []string) ([]repository.Permission, []string, error) {
+	permissions, err := repo.GetPermissionsByNames(ctx, names)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unknown []string
+	for _, name := range names {
+		if !slices.ContainsFunc(permissions, func(p repository.Permission) bool { return p.Name == name }) {
+			unknown = append(unknown, name)
+		}
+	}
+	return permissions, unknown, nil
+}
+
+// setTemplatePermissions replaces the permissions carried by a template
+func setTemplatePermissions(ctx context.Context, repo *repository.Queries, templateID uuid.UUID, permissions []repository.Permission) error {
+	if err := repo.ClearRoleTemplatePermissions(ctx, templateID); err != nil {
+		return err
+	}
+	for _, permission := range permissions {
+		if err := repo.AddRoleTemplatePermission(ctx, repository.AddRoleTemplatePermissionParams{
+			TemplateID:   templateID,
+			PermissionID: permission.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Creates a role template from a name, description and a list of permission
+// names
+func (rh *RoleHandler) CreateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RoleTemplateRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	// Only optional when updating
+	if req.Name == nil {
+		utils.WriteValidationErrors(w, map[string]string{"name": "is required"})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	permissions, unknown, err := resolveTemplatePermissions(r.Context(), repo, req.Permissions)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve permissions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if len(unknown) > 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":   "Some of the permissions you specified do not exist",
+			"unknown": unknown,
+		})
+		return
+	}
+
+	template, err := repo.CreateRoleTemplate(r.Context(), repository.CreateRoleTemplateParams{
+		Name:        strings.TrimSpace(*req.Name),
+		Description: req.Description,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A role template with this name already exists",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to create role template", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err := setTemplatePermissions(r.Context(), repo, template.ID, permissions); err != nil {
+		rh.Logger.Error("Failed to set role template permissions", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(RoleTemplateResponse{RoleTemplate: template, Permissions: permissions})
+}
+
+// Retrieves a list of role templates
+func (rh *RoleHandler) GetAllRoleTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	templates, err := repo.GetAllRoleTemplates(r.Context(), repository.GetAllRoleTemplatesParams{
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	})
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role templates", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(templates)
+}
+
+// Retrieves a role template alongside its permissions
+func (rh *RoleHandler) GetRoleTemplateByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	template, err := repo.GetRoleTemplateByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role template you are looking for does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role template", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	permissions, err := repo.GetRoleTemplatePermissions(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role template permissions", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RoleTemplateResponse{RoleTemplate: template, Permissions: permissions})
+}
+
+// Updates a role template. When permissions are provided they replace the
+// permissions currently carried by the template. Roles previously created
+// from the template are not affected
+func (rh *RoleHandler) UpdateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req RoleTemplateRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		req.Name = &name
+	}
+
+	template, err := repo.UpdateRoleTemplate(r.Context(), repository.UpdateRoleTemplateParams{
+		Name:        req.Name,
+		Description: req.Description,
+		ID:          id,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The role template you are trying to update does not exist",
+			})
+			return
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A role template with this name already exists",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to update role template", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if req.Permissions != nil {
+		permissions, unknown, err := resolveTemplatePermissions(r.Context(), repo, req.Permissions)
+		if err != nil {
+			rh.Logger.Error("Failed to retrieve permissions", slog.Any("error", err))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+		if len(unknown) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":   "Some of the permissions you specified do not exist",
+				"unknown": unknown,
+			})
+			return
+		}
+		if err := setTemplatePermissions(r.Context(), repo, id, permissions); err != nil {
+			rh.Logger.Error("Failed to set role template permissions", slog.Any("error", err), slog.String("template", id.String()))
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "We couldn't complete this request at the moment please try again later",
+			})
+			return
+		}
+	}
+
+	permissions, err := repo.GetRoleTemplatePermissions(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role template permissions", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RoleTemplateResponse{RoleTemplate: template, Permissions: permissions})
+}
+
+// Deletes a role template. Roles previously created from the template are
+// left untouched
+func (rh *RoleHandler) DeleteRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	deleted, err := repo.DeleteRoleTemplate(r.Context(), id)
+	if err != nil {
+		rh.Logger.Error("Failed to delete role template", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role template you are trying to delete does not exist",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Role template successfully deleted"})
+}
+
+// Creates a role for an institution carrying every permission of the
+// template. Changes made to the template later on are not propagated to
+// roles already created from it
+func (rh *RoleHandler) InstantiateRoleTemplate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req InstantiateRoleTemplateRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		rh.Logger.Error("Error while beginning transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	template, err := repo.GetRoleTemplateByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The role template you are trying to use does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve role template", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	institution, err := repo.GetInstitution(r.Context(), req.InstitutionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The institution you specified does not exist",
+		})
+		return
+	}
+	if err != nil {
+		rh.Logger.Error("Failed to retrieve institution", slog.Any("error", err), slog.Any("institution", req.InstitutionID))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	name := fmt.Sprintf("%s - %s", template.Name, institution.Name)
+	if req.Name != nil && strings.TrimSpace(*req.Name) != "" {
+		name = strings.TrimSpace(*req.Name)
+	}
+	description := template.Description
+	if req.Description != nil {
+		description = req.Description
+	}
+	var scope *int32
+	if req.Scoped == nil || *req.Scoped {
+		scope = &institution.InstitutionID
+	}
+
+	role, err := repo.CreateScopedRole(r.Context(), repository.CreateScopedRoleParams{
+		Name:          name,
+		Description:   description,
+		InstitutionID: scope,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "A role with this name already exists, please provide a different name",
+			})
+			return
+		}
+		rh.Logger.Error("Failed to create role from template", slog.Any("error", err), slog.String("template", id.String()))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	copied, err := repo.CopyRoleTemplatePermissions(r.Context(), repository.CopyRoleTemplatePermissionsParams{
+		RoleID:     role.ID,
+		TemplateID: template.ID,
+	})
+	if err != nil {
+		rh.Logger.Error("Failed to copy role template permissions",
+			slog.Any("error", err),
+			slog.String("template", id.String()),
+			slog.String("role", role.ID.String()),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err = tx.Commit(r.Context()); err != nil {
+		rh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	rh.Logger.Info("Role created from template",
+		slog.String("template", template.ID.String()),
+		slog.String("role", role.ID.String()),
+		slog.Any("institution", institution.InstitutionID),
+		slog.Bool("scoped", scope != nil),
+	)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"role":        role,
+		"permissions": copied,
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// reportServiceTokenEvent reports a change to a service token made by the
+// actor to the security event stream
+func reportServiceTokenEvent(r *http.Request, eventType string, token repository.ServiceToken, actorID uuid.UUID) {
+	details, _ := json.Marshal(map[string]any{
+		"service_token_id": token.ID,
+		"name":             token.Name,
+		"expires_at":       token.ExpiresAt,
+	})
+
+	middleware.ReportSecurityEvent(r, eventType, eventbus.SecurityActivity{
+		Outcome:   eventbus.OutcomeSuccess,
+		Severity:  eventbus.SeverityInfo,
+		AccountID: token.AccountID.String(),
+		ActorID:   actorID.String(),
+		Details:   details,
+	})
+}
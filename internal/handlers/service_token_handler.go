@@ -1,19 +1,17 @@
 package handlers
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/repository"
 	"github.com/opencrafts-io/verisafe/internal/utils"
@@ -26,13 +24,13 @@ type ServiceTokenHandler struct {
 
 // ServiceTokenRequest represents the request to create a service token
 type ServiceTokenRequest struct {
-	Name              string                 `json:"name" validate:"required,min=1,max=100"`
+	Name              string                 `json:"name" validate:"notblank,max=100"`
 	Description       *string                `json:"description"`
 	ExpiresInDays     *int                   `json:"expires_in_days" validate:"omitempty,min=1,max=3650"` // Max 10 years
 	Scopes            []string               `json:"scopes"`
 	MaxUses           *int                   `json:"max_uses" validate:"omitempty,min=1"`
 	RotationPolicy    *RotationPolicy        `json:"rotation_policy"`
-	IPWhitelist       []string               `json:"ip_whitelist"`
+	IPWhitelist       []string               `json:"ip_whitelist" validate:"dive,ipv4"`
 	UserAgentPattern  *string                `json:"user_agent_pattern"`
 	Metadata          map[string]interface{} `json:"metadata"`
 }
@@ -54,18 +52,24 @@ type ServiceTokenResponse struct {
 	RotatedAt   *time.Time             `json:"rotated_at"`
 	RevokedAt   *time.Time             `json:"revoked_at"`
 	Metadata    map[string]interface{} `json:"metadata"`
+	// Bumped on every update, see UpdateServiceToken
+	Version     int64                  `json:"version,omitempty"`
 }
 
 // ServiceTokenUpdateRequest represents the request to update a service token
 type ServiceTokenUpdateRequest struct {
-	Name             *string                `json:"name" validate:"omitempty,min=1,max=100"`
+	Name             *string                `json:"name" validate:"omitnil,notblank,max=100"`
 	Description      *string                `json:"description"`
 	Scopes           []string               `json:"scopes"`
 	MaxUses          *int                   `json:"max_uses" validate:"omitempty,min=1"`
 	RotationPolicy   *RotationPolicy        `json:"rotation_policy"`
-	IPWhitelist      []string               `json:"ip_whitelist"`
+	IPWhitelist      []string               `json:"ip_whitelist" validate:"dive,ipv4"`
 	UserAgentPattern *string                `json:"user_agent_pattern"`
 	Metadata         map[string]interface{} `json:"metadata"`
+	// The version the token is expected to be at, the update is rejected
+	// with a 409 when it is at another one. The If-Match header takes
+	// precedence over it
+	Version          *int64                 `json:"version"`
 }
 
 // ServiceTokenStats represents usage statistics for service tokens
@@ -178,8 +182,7 @@ func (sth *ServiceTokenHandler) CreateServiceToken(w http.ResponseWriter, r *htt
 
 	// Parse request
 	var req ServiceTokenRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
@@ -262,6 +265,7 @@ func (sth *ServiceTokenHandler) CreateServiceToken(w http.ResponseWriter, r *htt
 		http.Error(w, "Failed to create service token", http.StatusInternalServerError)
 		return
 	}
+	reportServiceTokenEvent(r, eventbus.SecurityTokenCreated, serviceToken, accountID)
 
 	// Return response
 	response := ServiceTokenResponse{
@@ -332,14 +336,8 @@ func (sth *ServiceTokenHandler) ListServiceTokens(w http.ResponseWriter, r *http
 // GetServiceToken retrieves a specific service token
 func (sth *ServiceTokenHandler) GetServiceToken(w http.ResponseWriter, r *http.Request) {
 	// Extract token ID from URL
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 5 {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
-		return
-	}
-	tokenID, err := uuid.Parse(pathParts[4])
-	if err != nil {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+	tokenID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -366,14 +364,7 @@ func (sth *ServiceTokenHandler) GetServiceToken(w http.ResponseWriter, r *http.R
 	}
 
 	// Verify ownership (unless admin)
-	perms := r.Context().Value(middleware.AuthUserPerms).([]string)
-	isAdmin := false
-	for _, perm := range perms {
-		if perm == "read:service_token:any" {
-			isAdmin = true
-			break
-		}
-	}
+	isAdmin := middleware.CallerHasPermission(r.Context(), "read:service_token:any")
 
 	if !isAdmin && token.AccountID != accountID {
 		http.Error(w, "Access denied", http.StatusForbidden)
@@ -383,20 +374,15 @@ func (sth *ServiceTokenHandler) GetServiceToken(w http.ResponseWriter, r *http.R
 	response := sth.convertToServiceTokenResponse(token)
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", utils.VersionETag(token.Version))
 	json.NewEncoder(w).Encode(response)
 }
 
 // UpdateServiceToken updates a service token
 func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *http.Request) {
 	// Extract token ID from URL
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 5 {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
-		return
-	}
-	tokenID, err := uuid.Parse(pathParts[4])
-	if err != nil {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+	tokenID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -410,8 +396,11 @@ func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *htt
 
 	// Parse request
 	var req ServiceTokenUpdateRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	version, ok := utils.ExpectedVersion(w, r, req.Version)
+	if !ok {
 		return
 	}
 
@@ -440,14 +429,7 @@ func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *htt
 	}
 
 	// Verify ownership (unless admin)
-	perms := r.Context().Value(middleware.AuthUserPerms).([]string)
-	isAdmin := false
-	for _, perm := range perms {
-		if perm == "update:service_token:any" {
-			isAdmin = true
-			break
-		}
-	}
+	isAdmin := middleware.CallerHasPermission(r.Context(), "update:service_token:any")
 
 	if !isAdmin && token.AccountID != accountID {
 		http.Error(w, "Access denied", http.StatusForbidden)
@@ -476,7 +458,7 @@ func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *htt
 	}
 
 	// Update token
-	err = repo.UpdateServiceToken(r.Context(), repository.UpdateServiceTokenParams{
+	updatedRows, err := repo.UpdateServiceToken(r.Context(), repository.UpdateServiceTokenParams{
 		ID:               tokenID,
 		Name:             *req.Name,
 		Description:      req.Description,
@@ -492,16 +474,16 @@ func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *htt
 		IpWhitelist:      req.IPWhitelist,
 		UserAgentPattern: req.UserAgentPattern,
 		Metadata:         metadataJSON,
+		Version:          version,
 	})
 	if err != nil {
 		sth.Logger.Error("Failed to update service token", slog.String("error", err.Error()))
 		http.Error(w, "Failed to update service token", http.StatusInternalServerError)
 		return
 	}
-
-	if err := tx.Commit(r.Context()); err != nil {
-		sth.Logger.Error("Failed to commit transaction", slog.String("error", err.Error()))
-		http.Error(w, "Failed to update service token", http.StatusInternalServerError)
+	if updatedRows == 0 {
+		w.Header().Set("ETag", utils.VersionETag(token.Version))
+		http.Error(w, "Service token was updated by someone else, fetch it again and retry", http.StatusConflict)
 		return
 	}
 
@@ -513,23 +495,24 @@ func (sth *ServiceTokenHandler) UpdateServiceToken(w http.ResponseWriter, r *htt
 		return
 	}
 
+	if err := tx.Commit(r.Context()); err != nil {
+		sth.Logger.Error("Failed to commit transaction", slog.String("error", err.Error()))
+		http.Error(w, "Failed to update service token", http.StatusInternalServerError)
+		return
+	}
+
 	response := sth.convertToServiceTokenResponse(updatedToken)
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", utils.VersionETag(updatedToken.Version))
 	json.NewEncoder(w).Encode(response)
 }
 
 // RotateServiceToken rotates a service token
 func (sth *ServiceTokenHandler) RotateServiceToken(w http.ResponseWriter, r *http.Request) {
 	// Extract token ID from URL
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 6 {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
-		return
-	}
-	tokenID, err := uuid.Parse(pathParts[4])
-	if err != nil {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+	tokenID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -566,14 +549,7 @@ func (sth *ServiceTokenHandler) RotateServiceToken(w http.ResponseWriter, r *htt
 	}
 
 	// Verify ownership (unless admin)
-	perms := r.Context().Value(middleware.AuthUserPerms).([]string)
-	isAdmin := false
-	for _, perm := range perms {
-		if perm == "rotate:service_token:any" {
-			isAdmin = true
-			break
-		}
-	}
+	isAdmin := middleware.CallerHasPermission(r.Context(), "rotate:service_token:any")
 
 	if !isAdmin && token.AccountID != accountID {
 		http.Error(w, "Access denied", http.StatusForbidden)
@@ -605,6 +581,7 @@ func (sth *ServiceTokenHandler) RotateServiceToken(w http.ResponseWriter, r *htt
 		http.Error(w, "Failed to rotate service token", http.StatusInternalServerError)
 		return
 	}
+	reportServiceTokenEvent(r, eventbus.SecurityTokenRotated, token, accountID)
 
 	// Get updated token
 	updatedToken, err := repo.GetServiceTokenByID(r.Context(), tokenID)
@@ -624,14 +601,8 @@ func (sth *ServiceTokenHandler) RotateServiceToken(w http.ResponseWriter, r *htt
 // RevokeServiceToken revokes a service token
 func (sth *ServiceTokenHandler) RevokeServiceToken(w http.ResponseWriter, r *http.Request) {
 	// Extract token ID from URL
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 5 {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
-		return
-	}
-	tokenID, err := uuid.Parse(pathParts[4])
-	if err != nil {
-		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+	tokenID, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
@@ -668,14 +639,7 @@ func (sth *ServiceTokenHandler) RevokeServiceToken(w http.ResponseWriter, r *htt
 	}
 
 	// Verify ownership (unless admin)
-	perms := r.Context().Value(middleware.AuthUserPerms).([]string)
-	isAdmin := false
-	for _, perm := range perms {
-		if perm == "revoke:service_token:any" {
-			isAdmin = true
-			break
-		}
-	}
+	isAdmin := middleware.CallerHasPermission(r.Context(), "revoke:service_token:any")
 
 	if !isAdmin && token.AccountID != accountID {
 		http.Error(w, "Access denied", http.StatusForbidden)
@@ -695,6 +659,7 @@ func (sth *ServiceTokenHandler) RevokeServiceToken(w http.ResponseWriter, r *htt
 		http.Error(w, "Failed to revoke service token", http.StatusInternalServerError)
 		return
 	}
+	reportServiceTokenEvent(r, eventbus.SecurityTokenRevoked, token, accountID)
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -787,24 +752,11 @@ func (sth *ServiceTokenHandler) CleanupExpiredTokens(w http.ResponseWriter, r *h
 
 // generateSecureToken generates a cryptographically secure token
 func (sth *ServiceTokenHandler) generateSecureToken() (string, error) {
-	// Generate 32 bytes of random data
-	bytes := make([]byte, 32)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
-	}
-	
-	// Encode as base64 and add prefix for identification
-	token := "vst_" + base64.URLEncoding.EncodeToString(bytes)
-	return token, nil
+	return utils.GenerateServiceToken()
 }
 
 // validateServiceTokenRequest validates the service token request
 func (sth *ServiceTokenHandler) validateServiceTokenRequest(req *ServiceTokenRequest) error {
-	// Validate name
-	if strings.TrimSpace(req.Name) == "" {
-		return fmt.Errorf("name is required")
-	}
-
 	// Validate scopes if provided
 	for _, scope := range req.Scopes {
 		if !sth.isValidScope(scope) {
@@ -812,13 +764,6 @@ func (sth *ServiceTokenHandler) validateServiceTokenRequest(req *ServiceTokenReq
 		}
 	}
 
-	// Validate IP whitelist if provided
-	for _, ip := range req.IPWhitelist {
-		if !sth.isValidIP(ip) {
-			return fmt.Errorf("invalid IP address: %s", ip)
-		}
-	}
-
 	// Validate user agent pattern if provided
 	if req.UserAgentPattern != nil {
 		if _, err := regexp.Compile(*req.UserAgentPattern); err != nil {
@@ -831,37 +776,7 @@ func (sth *ServiceTokenHandler) validateServiceTokenRequest(req *ServiceTokenReq
 
 // isValidScope validates if a scope is valid
 func (sth *ServiceTokenHandler) isValidScope(scope string) bool {
-	// Add your scope validation logic here
-	// For now, just check if it's not empty and contains only valid characters
-	if strings.TrimSpace(scope) == "" {
-		return false
-	}
-	
-	// Check for valid characters (alphanumeric, colon, dot, underscore, hyphen)
-	matched, _ := regexp.MatchString(`^[a-zA-Z0-9:._-]+$`, scope)
-	return matched
-}
-
-// isValidIP validates if an IP address is valid
-func (sth *ServiceTokenHandler) isValidIP(ip string) bool {
-	// Simple IP validation - you might want to use a more robust library
-	matched, _ := regexp.MatchString(`^(\d{1,3}\.){3}\d{1,3}$`, ip)
-	if !matched {
-		return false
-	}
-	
-	// Check each octet
-	parts := strings.Split(ip, ".")
-	for _, part := range parts {
-		if len(part) > 3 || len(part) == 0 {
-			return false
-		}
-		if part[0] == '0' && len(part) > 1 {
-			return false
-		}
-	}
-	
-	return true
+	return utils.IsValidServiceTokenScope(scope)
 }
 
 // convertToServiceTokenResponse converts a repository ServiceToken to ServiceTokenResponse
@@ -884,6 +799,7 @@ func (sth *ServiceTokenHandler) convertToServiceTokenResponse(token repository.S
 		LastUsedAt: token.LastUsedAt,
 		RotatedAt:  token.RotatedAt,
 		RevokedAt:  token.RevokedAt,
+		Version:    token.Version,
 	}
 
 	if token.Metadata != nil {
@@ -17,13 +17,13 @@ type SocialHandler struct {
 }
 
 func (sh *SocialHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("GET /socials/me",
+	router.Handle("GET /api/v1/socials/me",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, sh.Logger),
 			middleware.HasPermission([]string{"read:account:own"}),
 		)(http.HandlerFunc(sh.GetAllUserSocials)),
 	)
-	router.Handle("GET /socials/user/{user_id}",
+	router.Handle("GET /api/v1/socials/user/{user_id}",
 		middleware.CreateStack(
 			middleware.IsAuthenticated(cfg, sh.Logger),
 			middleware.HasPermission([]string{"read:account:any"}),
@@ -35,14 +35,8 @@ func (sh *SocialHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMu
 // Returns all user socials accounts specified by id
 func (sh *SocialHandler) GetUserIDSocials(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	rawID := r.PathValue("user_id")
-	id, err := uuid.Parse(rawID)
-	if err != nil {
-		sh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	id, ok := utils.PathUUID(w, r, "user_id")
+	if !ok {
 		return
 	}
 
@@ -57,9 +51,7 @@ func (sh *SocialHandler) GetUserIDSocials(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	socials, err := repo.GetAllAccountSocials(r.Context(), id)
 	if err != nil {
@@ -71,15 +63,6 @@ func (sh *SocialHandler) GetUserIDSocials(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err = tx.Commit(r.Context()); err != nil {
-		sh.Logger.Error("Error while committing transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]any{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(socials)
 
@@ -111,9 +94,7 @@ func (sh *SocialHandler) GetAllUserSocials(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	tx, _ := conn.Begin(r.Context())
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	socials, err := repo.GetAllAccountSocials(r.Context(), id)
 	if err != nil {
@@ -125,15 +106,6 @@ func (sh *SocialHandler) GetAllUserSocials(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err = tx.Commit(r.Context()); err != nil {
-		sh.Logger.Error("Error while committing transaction", slog.Any("error", err))
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]any{
-			"error": "We ran into a problem while servicing your request please try again later",
-		})
-		return
-	}
-
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(socials)
 }
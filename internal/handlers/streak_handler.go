@@ -3,51 +3,88 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/opencrafts-io/verisafe/internal/config"
 	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/middleware"
 	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
 	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 type StreakHandler struct {
 	Logger               *slog.Logger
+	Cfg                  *config.Config
 	NotificationEventBus *eventbus.NotificationEventBus
 }
 
 func (sh *StreakHandler) RegisterRoutes(cfg *config.Config, router *http.ServeMux) {
-	router.Handle("POST /users/activity/complete", middleware.CreateStack(
+	router.Handle("POST /api/v1/users/activity/complete", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, sh.Logger),
 	)(http.HandlerFunc(sh.RecordUserActivity)))
-	router.Handle("POST /streaks/milestone/create", middleware.CreateStack(
+	router.Handle("POST /api/v1/streaks/milestone/create", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, sh.Logger),
 	)(http.HandlerFunc(sh.CreateStreakMilestone)))
-	router.Handle("GET /streaks/milestone/active", middleware.CreateStack(
+	router.Handle("GET /api/v1/streaks/milestone/active", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, sh.Logger),
 	)(http.HandlerFunc(sh.GetAllActiveStreakAchievements)))
-	router.Handle("DELETE /streaks/milestone/{id}", middleware.CreateStack(
+	router.Handle("GET /api/v1/streaks/milestone/inactive", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, sh.Logger),
+	)(http.HandlerFunc(sh.GetAllInactiveStreakAchievements)))
+	router.Handle("PATCH /api/v1/streaks/milestone/{id}", middleware.CreateStack(
+		middleware.IsAuthenticated(cfg, sh.Logger),
+	)(http.HandlerFunc(sh.UpdateStreakMilestone)))
+	router.Handle("DELETE /api/v1/streaks/milestone/{id}", middleware.CreateStack(
 		middleware.IsAuthenticated(cfg, sh.Logger),
 	)(http.HandlerFunc(sh.DeleteStreakMilestone)))
 
 }
+
+// SQLSTATEs raised by record_activity_completion when a completion is rejected
+const (
+	activityNotFoundCode    = "VS001"
+	activityDailyLimitCode  = "VS002"
+	activityCoolingDownCode = "VS003"
+)
+
+// RecordUserActivityRequest is the body expected when recording an activity
+// completion
+type RecordUserActivityRequest struct {
+	ActivityID uuid.UUID       `json:"activity_id" validate:"required"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// Records that the caller completed an activity. The completion is always
+// credited to the authenticated account, the activity's daily completion limit
+// and cooldown are enforced by the database
 func (sh *StreakHandler) RecordUserActivity(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	requestBody := repository.RecordActivityCompletionParams{}
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	accountID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		sh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		sh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	var req RecordUserActivityRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
+	requestBody := repository.RecordActivityCompletionParams{
+		AccountID:  accountID,
+		ActivityID: req.ActivityID,
+		Metadata:   req.Metadata,
+	}
 
 	conn, err := middleware.GetDBConnFromContext(r.Context())
 	if err != nil {
@@ -67,6 +104,32 @@ func (sh *StreakHandler) RecordUserActivity(w http.ResponseWriter, r *http.Reque
 
 	completed, err := repo.RecordActivityCompletion(r.Context(), requestBody)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case activityNotFoundCode:
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "The activity does not exist or is no longer active",
+				})
+				return
+			case activityDailyLimitCode:
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "You have reached today's completion limit for this activity",
+				})
+				return
+			case activityCoolingDownCode:
+				if _, err := strconv.Atoi(pgErr.Detail); err == nil {
+					w.Header().Set("Retry-After", pgErr.Detail)
+				}
+				w.WriteHeader(http.StatusTooManyRequests)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "Please wait a little before completing this activity again",
+				})
+				return
+			}
+		}
 		sh.Logger.Error("Failed to record user activity", slog.Any("error", err), slog.Any("activity", requestBody))
 		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
 		return
@@ -81,7 +144,7 @@ func (sh *StreakHandler) RecordUserActivity(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	go sh.sendActivityCompletionNotification(requestBody.AccountID.String(), &completed)
+	go sh.sendActivityCompletionNotification(middleware.GetRequestID(r.Context()), requestBody.AccountID.String(), &completed)
 	json.NewEncoder(w).Encode(map[string]any{"message": "Activity recorded successfully!"})
 }
 
@@ -89,12 +152,7 @@ func (sh *StreakHandler) CreateStreakMilestone(w http.ResponseWriter, r *http.Re
 	w.Header().Set("Content-Type", "application/json")
 	requestBody := repository.CreateStreakMilestoneParams{}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		sh.Logger.Error("Failed to parse request body", slog.Any("error", err))
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Please check your request body and try again",
-		})
+	if !utils.DecodeAndValidate(w, r, &requestBody) {
 		return
 	}
 
@@ -142,15 +200,7 @@ func (sh *StreakHandler) GetAllActiveStreakAchievements(w http.ResponseWriter, r
 		return
 	}
 
-	tx, err := conn.Begin(r.Context())
-	if err != nil {
-		sh.Logger.Error("Failed to start transaction", slog.Any("error", err))
-		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
-		return
-	}
-
-	defer tx.Rollback(r.Context())
-	repo := repository.New(tx)
+	repo := repository.New(conn)
 
 	// Parse pagination params
 	pageParams := pagination.ParsePageParams(r)
@@ -187,12 +237,116 @@ func (sh *StreakHandler) GetAllActiveStreakAchievements(w http.ResponseWriter, r
 
 }
 
-func (sh *StreakHandler) DeleteStreakMilestone(w http.ResponseWriter, r *http.Request) {
-	rawID := r.PathValue("id")
-	id, err := uuid.Parse(rawID)
+// UpdateStreakMilestoneRequest holds the streak milestone fields that can be
+// changed. Fields that are left out keep their current value
+type UpdateStreakMilestoneRequest struct {
+	DaysRequired *int16  `json:"days_required" validate:"omitnil,gt=0"`
+	BonusPoints  *int16  `json:"bonus_points" validate:"omitnil,min=1,max=10"`
+	Title        *string `json:"title" validate:"omitnil,notblank"`
+	Description  *string `json:"description"`
+	IsActive     *bool   `json:"is_active"`
+}
+
+// Returns the streak milestones that have been deactivated
+func (sh *StreakHandler) GetAllInactiveStreakAchievements(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		sh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	pageParams := pagination.ParsePageParams(r)
+
+	totalCount, err := repo.GetAllInactiveStreakMilestoneCount(r.Context())
+	if err != nil {
+		sh.Logger.Error("Failed to get all inactive streak milestones count", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't fetch inactive streak milestone count at the moment",
+		})
+		return
+	}
+
+	active := false
+
+	milestones, err := repo.GetAllStreaksMilestoneByActive(r.Context(), repository.GetAllStreaksMilestoneByActiveParams{
+		Limit:    int32(pageParams.PageSize),
+		Offset:   int32(pageParams.Offset),
+		IsActive: &active,
+	})
+	if err != nil {
+		sh.Logger.Error("Failed to retrieve inactive streak milestones", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide inactive streak milestones at the moment",
+		})
+		return
+	}
+
+	response := pagination.BuildPaginatedResponse(r, totalCount, milestones, pageParams)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Edits a streak milestone. Setting is_active to false retires the milestone
+// without deleting the achievements that were already awarded for it
+func (sh *StreakHandler) UpdateStreakMilestone(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateStreakMilestoneRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		sh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	milestone, err := repo.UpdateStreakMilestone(r.Context(), repository.UpdateStreakMilestoneParams{
+		DaysRequired: req.DaysRequired,
+		BonusPoints:  req.BonusPoints,
+		Title:        req.Title,
+		Description:  req.Description,
+		IsActive:     req.IsActive,
+		ID:           id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The streak milestone you are trying to update does not exist",
+		})
+		return
+	}
 	if err != nil {
-		sh.Logger.Error("Failed to parse uuid from path", slog.Any("error", err))
-		http.Error(w, `{"error":"Please check your request body and try again"}`, http.StatusBadRequest)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "The activity already has a milestone for that number of days",
+			})
+			return
+		}
+		sh.Logger.Error("Failed to update streak milestone", slog.Any("error", err), slog.Any("milestone", req))
+		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(milestone)
+}
+
+func (sh *StreakHandler) DeleteStreakMilestone(w http.ResponseWriter, r *http.Request) {
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -232,6 +386,7 @@ func (sh *StreakHandler) DeleteStreakMilestone(w http.ResponseWriter, r *http.Re
 }
 
 func (sh *StreakHandler) sendActivityCompletionNotification(
+	requestID string,
 	accountID string,
 	result *repository.RecordActivityCompletionRow,
 ) {
@@ -266,7 +421,6 @@ func (sh *StreakHandler) sendActivityCompletionNotification(
 	})
 
 	notification := eventbus.NotificationPayload{
-		AppID: "88ca0bb7-c0d7-4e36-b9e6-ea0e29213593",
 		Headings: map[string]string{
 			"en": heading,
 		},
@@ -277,16 +431,14 @@ func (sh *StreakHandler) sendActivityCompletionNotification(
 		Subtitle: map[string]string{
 			"en": "Keep up the good work!",
 		},
-		AndroidChannelID: "60023d0b-dcd4-41ae-8e58-7eabbf382c8c",
-		IosSound:         "default",
-		SmallIcon:        "ic_notification",
-		URL:              "https://opencrafts.io/profile",
-		Buttons:          buttons,
+		URL:     sh.Cfg.NotificationConfig.ProfileURL,
+		Buttons: buttons,
 	}
+	fillNotificationDefaults(sh.Cfg, &notification)
 
 	// Send notification (with timeout to prevent blocking)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	sh.NotificationEventBus.PublishPushNotificationRequested(ctx, notification, eventbus.GenerateRequestID())
+	sh.NotificationEventBus.PublishPushNotificationRequested(ctx, notification, requestID)
 }
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/middleware/pagination"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+// ReverseVibepointTransactionRequest is the body expected when reversing a
+// vibe point grant
+type ReverseVibepointTransactionRequest struct {
+	Reason string `json:"reason" validate:"notblank"`
+}
+
+// Returns the caller's vibe point ledger
+func (lh *LeaderBoardHandler) GetPersonalVibepointLedger(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	accountID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		lh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	lh.writeVibepointLedger(w, r, accountID)
+}
+
+// Returns the vibe point ledger of any account
+func (lh *LeaderBoardHandler) GetVibepointLedger(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	accountID, ok := utils.PathUUID(w, r, "user")
+	if !ok {
+		return
+	}
+
+	lh.writeVibepointLedger(w, r, accountID)
+}
+
+// writeVibepointLedger writes a page of an account's ledger, most recent
+// entries first
+func (lh *LeaderBoardHandler) writeVibepointLedger(w http.ResponseWriter, r *http.Request, accountID uuid.UUID) {
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+	repo := repository.New(conn)
+
+	pageParams := pagination.ParsePageParams(r)
+
+	totalCount, err := repo.CountVibepointTransactionsForAccount(r.Context(), accountID)
+	if err != nil {
+		lh.Logger.Error("Failed to count vibe point ledger entries", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the vibe point ledger at the moment",
+		})
+		return
+	}
+
+	entries, err := repo.ListVibepointTransactionsForAccount(r.Context(), repository.ListVibepointTransactionsForAccountParams{
+		AccountID: accountID,
+		Limit:     int32(pageParams.PageSize),
+		Offset:    int32(pageParams.Offset),
+	})
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve vibe point ledger", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error": "We couldn't provide the vibe point ledger at the moment",
+		})
+		return
+	}
+
+	response := pagination.BuildPaginatedResponse(r, totalCount, entries, pageParams)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Reverses an erroneous vibe point grant. The original entry is kept and a
+// new entry cancelling out its points is appended to the ledger, which keeps
+// the account's total in sync
+func (lh *LeaderBoardHandler) ReverseVibepointTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+	callerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		lh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	transactionID, ok := utils.PathInt64(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req ReverseVibepointTransactionRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		lh.Logger.Error("Error while processing request", slog.Any("error", err))
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := conn.Begin(r.Context())
+	if err != nil {
+		lh.Logger.Error("Failed to start transaction", slog.Any("error", err))
+		http.Error(w, `{"error":"Cannot process your request at the moment"}`, http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback(r.Context())
+	repo := repository.New(tx)
+
+	original, err := repo.GetVibepointTransaction(r.Context(), transactionID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The vibe point transaction does not exist",
+		})
+		return
+	}
+	if err != nil {
+		lh.Logger.Error("Failed to retrieve vibe point transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if original.ReversalOf != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "A reversal cannot itself be reversed",
+		})
+		return
+	}
+
+	reversal, err := repo.ReverseVibepointTransaction(r.Context(), repository.ReverseVibepointTransactionParams{
+		Reason:    "Reversal: " + req.Reason,
+		AwardedBy: callerID.String(),
+		ActorID:   callerID,
+		ID:        transactionID,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" { // unique_violation
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "This vibe point transaction has already been reversed",
+			})
+			return
+		}
+		lh.Logger.Error("Failed to reverse vibe point transaction",
+			slog.Any("error", err),
+			slog.Int64("transaction_id", transactionID),
+		)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	if err := tx.Commit(r.Context()); err != nil {
+		lh.Logger.Error("Error while committing transaction", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	lh.Logger.Info("Reversed vibe point transaction",
+		slog.Int64("transaction_id", transactionID),
+		slog.String("account_id", original.AccountID.String()),
+		slog.String("actor_id", callerID.String()),
+	)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reversal)
+}
@@ -0,0 +1,499 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+	"github.com/opencrafts-io/verisafe/internal/webhooks"
+)
+
+type WebhookHandler struct {
+	Logger *slog.Logger
+	Cfg    *config.Config
+}
+
+// Registers all the necessary routes associated with this handler group
+func (wh *WebhookHandler) RegisterRoutes(router *http.ServeMux) {
+	router.Handle("POST /api/v1/webhooks",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"create:webhook:any"}),
+		)(http.HandlerFunc(wh.CreateWebhookEndpoint)),
+	)
+
+	router.Handle("GET /api/v1/webhooks",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"read:webhook:any"}),
+			middleware.PaginationMiddleware(10, 100),
+		)(http.HandlerFunc(wh.GetAllWebhookEndpoints)),
+	)
+
+	router.Handle("GET /api/v1/webhooks/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"read:webhook:any"}),
+		)(http.HandlerFunc(wh.GetWebhookEndpoint)),
+	)
+
+	router.Handle("PATCH /api/v1/webhooks/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"update:webhook:any"}),
+		)(http.HandlerFunc(wh.UpdateWebhookEndpoint)),
+	)
+
+	router.Handle("DELETE /api/v1/webhooks/{id}",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"delete:webhook:any"}),
+		)(http.HandlerFunc(wh.DeleteWebhookEndpoint)),
+	)
+
+	router.Handle("GET /api/v1/webhooks/{id}/deliveries",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"read:webhook:any"}),
+			middleware.PaginationMiddleware(20, 100),
+		)(http.HandlerFunc(wh.GetWebhookDeliveries)),
+	)
+
+	router.Handle("POST /api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver",
+		middleware.CreateStack(
+			middleware.IsAuthenticated(wh.Cfg, wh.Logger),
+			middleware.HasPermission([]string{"update:webhook:any"}),
+		)(http.HandlerFunc(wh.RedeliverWebhook)),
+	)
+}
+
+// WebhookEndpointRequest is the body expected when registering or updating
+// a webhook endpoint
+type WebhookEndpointRequest struct {
+	Name       *string  `json:"name" validate:"omitnil,notblank"`
+	Url        *string  `json:"url"`
+	EventTypes []string `json:"event_types"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+// WebhookEndpointResponse is a webhook endpoint as returned to callers.
+// The signing secret is only ever revealed once upon creation
+type WebhookEndpointResponse struct {
+	ID         uuid.UUID        `json:"id"`
+	OwnerID    uuid.UUID        `json:"owner_id"`
+	Name       string           `json:"name"`
+	Url        string           `json:"url"`
+	Secret     string           `json:"secret,omitempty"`
+	EventTypes []string         `json:"event_types"`
+	IsActive   bool             `json:"is_active"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+}
+
+func toWebhookEndpointResponse(endpoint repository.WebhookEndpoint) WebhookEndpointResponse {
+	return WebhookEndpointResponse{
+		ID:         endpoint.ID,
+		OwnerID:    endpoint.OwnerID,
+		Name:       endpoint.Name,
+		Url:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		IsActive:   endpoint.IsActive,
+		CreatedAt:  endpoint.CreatedAt,
+		UpdatedAt:  endpoint.UpdatedAt,
+	}
+}
+
+// validateWebhookURL ensures deliveries only go to HTTPS endpoints.
+// Plain HTTP is tolerated in development to ease local testing
+func (wh *WebhookHandler) validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("please provide a valid webhook url")
+	}
+	if u.Scheme == "https" {
+		return nil
+	}
+	if u.Scheme == "http" && wh.Cfg.AuthenticationConfig.Environment == "development" {
+		return nil
+	}
+	return fmt.Errorf("webhook urls must use https")
+}
+
+// validateWebhookEventTypes ensures all event types may be subscribed to
+func validateWebhookEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !webhooks.IsSupportedEventType(eventType) {
+			return fmt.Errorf("unsupported event type %q, supported event types are %s",
+				eventType, strings.Join(webhooks.SupportedEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// Registers a new webhook endpoint
+func (wh *WebhookHandler) CreateWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := r.Context().Value(middleware.AuthUserClaims).(*utils.VerisafeClaims)
+
+	var req WebhookEndpointRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	// Only optional when updating
+	missing := map[string]string{}
+	if req.Name == nil {
+		missing["name"] = "is required"
+	}
+	if req.Url == nil {
+		missing["url"] = "is required"
+	}
+	if len(missing) > 0 {
+		utils.WriteValidationErrors(w, missing)
+		return
+	}
+
+	if err := wh.validateWebhookURL(*req.Url); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if req.EventTypes == nil {
+		req.EventTypes = []string{}
+	}
+
+	ownerID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		wh.Logger.Error("Error while parsing user id", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		wh.Logger.Error("Failed to generate webhook secret", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	endpoint, err := repo.CreateWebhookEndpoint(r.Context(), repository.CreateWebhookEndpointParams{
+		OwnerID:    ownerID,
+		Name:       strings.TrimSpace(*req.Name),
+		Url:        *req.Url,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		wh.Logger.Error("Failed to create webhook endpoint", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't register this webhook at the moment please try again later",
+		})
+		return
+	}
+
+	response := toWebhookEndpointResponse(endpoint)
+	response.Secret = endpoint.Secret
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Lists all registered webhook endpoints
+func (wh *WebhookHandler) GetAllWebhookEndpoints(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	endpoints, err := repo.GetAllWebhookEndpoints(r.Context(), repository.GetAllWebhookEndpointsParams{
+		Limit:  int32(pagination.Limit),
+		Offset: int32(pagination.Offset),
+	})
+	if err != nil {
+		wh.Logger.Error("Failed to retrieve webhook endpoints", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	response := make([]WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		response = append(response, toWebhookEndpointResponse(endpoint))
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// Retrieves a single webhook endpoint
+func (wh *WebhookHandler) GetWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	endpoint, err := repo.GetWebhookEndpointByID(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The webhook you are requesting does not exist",
+		})
+		return
+	}
+	if err != nil {
+		wh.Logger.Error("Failed to retrieve webhook endpoint", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWebhookEndpointResponse(endpoint))
+}
+
+// Updates a webhook endpoint's name, url, event types or active state
+func (wh *WebhookHandler) UpdateWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	var req WebhookEndpointRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	if req.Url != nil {
+		if err := wh.validateWebhookURL(*req.Url); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if err := validateWebhookEventTypes(req.EventTypes); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	endpoint, err := repo.UpdateWebhookEndpoint(r.Context(), repository.UpdateWebhookEndpointParams{
+		ID:         id,
+		Name:       req.Name,
+		Url:        req.Url,
+		EventTypes: req.EventTypes,
+		IsActive:   req.IsActive,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The webhook you are trying to update does not exist",
+		})
+		return
+	}
+	if err != nil {
+		wh.Logger.Error("Failed to update webhook endpoint", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(toWebhookEndpointResponse(endpoint))
+}
+
+// Deletes a webhook endpoint alongside its delivery log
+func (wh *WebhookHandler) DeleteWebhookEndpoint(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	deleted, err := repo.DeleteWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		wh.Logger.Error("Failed to delete webhook endpoint", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The webhook you are trying to delete does not exist",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]any{"message": "Webhook successfully deleted"})
+}
+
+// Retrieves the delivery log of a webhook endpoint, newest first
+func (wh *WebhookHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	pagination := middleware.GetPagination(r.Context())
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	deliveries, err := repo.GetWebhookDeliveries(r.Context(), repository.GetWebhookDeliveriesParams{
+		EndpointID: id,
+		Limit:      int32(pagination.Limit),
+		Offset:     int32(pagination.Offset),
+	})
+	if err != nil {
+		wh.Logger.Error("Failed to retrieve webhook deliveries", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// Schedules a delivery for immediate redelivery
+func (wh *WebhookHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, ok := utils.PathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := utils.PathInt64(w, r, "delivery_id")
+	if !ok {
+		return
+	}
+
+	conn, err := middleware.GetDBConnFromContext(r.Context())
+	if err != nil {
+		wh.Logger.Error("Error while processing request", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We ran into a problem while servicing your request please try again later",
+		})
+		return
+	}
+
+	repo := repository.New(conn)
+	delivery, err := repo.RetryWebhookDelivery(r.Context(), repository.RetryWebhookDeliveryParams{
+		ID:         deliveryID,
+		EndpointID: id,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "The delivery you are trying to redeliver does not exist",
+		})
+		return
+	}
+	if err != nil {
+		wh.Logger.Error("Failed to schedule webhook redelivery", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We couldn't complete this request at the moment please try again later",
+		})
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
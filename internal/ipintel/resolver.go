@@ -0,0 +1,212 @@
+// Package ipintel tells where the requests Verisafe serves come from.
+//
+// CLIENT ADDRESS:
+// Behind a load balancer or reverse proxy the peer of every connection is the
+// proxy, which reports the client's address in the X-Forwarded-For or
+// X-Real-IP header. These headers are only believed when the peer is one of
+// the trusted proxies, anybody else could send them to pose as another
+// client. X-Forwarded-For is read from the right, skipping the addresses of
+// trusted proxies, so that entries prepended by the client are ignored.
+//
+// ENRICHMENT:
+// The client's address is looked up in local MaxMind GeoIP2 or GeoLite2
+// databases to learn its country and the autonomous system (ASN) announcing
+// it. Either database is optional, lookups are skipped for missing ones.
+package ipintel
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/oschwald/geoip2-golang"
+)
+
+// Info is what is known about the client behind a request
+type Info struct {
+	// Address of the client
+	IP string `json:"ip"`
+	// ISO 3166-1 alpha-2 code of the country the address is located in
+	Country string `json:"country,omitempty"`
+	// Autonomous system announcing the address and the organization
+	// operating it
+	ASN            uint   `json:"asn,omitempty"`
+	ASOrganization string `json:"as_organization,omitempty"`
+}
+
+// Resolver resolves the client address of requests and looks it up in the
+// GeoIP databases. A nil Resolver is safe to use, it takes the peer of the
+// connection for the client and knows nothing about it
+type Resolver struct {
+	logger         *slog.Logger
+	trustedProxies []netip.Prefix
+	countries      *geoip2.Reader
+	asns           *geoip2.Reader
+}
+
+// NewResolver creates a new Resolver, opening the configured GeoIP databases.
+// Close releases them
+func NewResolver(cfg *config.Config, logger *slog.Logger) (*Resolver, error) {
+	res := &Resolver{logger: logger}
+
+	for _, proxy := range cfg.ClientIPConfig.TrustedProxies {
+		prefix, err := parsePrefix(strings.TrimSpace(proxy))
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		res.trustedProxies = append(res.trustedProxies, prefix)
+	}
+
+	var err error
+	if path := cfg.ClientIPConfig.GeoIPCountryDatabase; path != "" {
+		if res.countries, err = geoip2.Open(path); err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP country database: %w", err)
+		}
+	}
+	if path := cfg.ClientIPConfig.GeoIPASNDatabase; path != "" {
+		if res.asns, err = geoip2.Open(path); err != nil {
+			res.Close()
+			return nil, fmt.Errorf("failed to open GeoIP ASN database: %w", err)
+		}
+	}
+
+	return res, nil
+}
+
+// Resolve tells where a request comes from
+func (res *Resolver) Resolve(r *http.Request) Info {
+	return res.Lookup(res.ClientIP(r))
+}
+
+// ClientIP returns the address of the client behind a request. The
+// forwarding headers are only read when the peer is a trusted proxy
+func (res *Resolver) ClientIP(r *http.Request) string {
+	peer := peerAddr(r)
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || res == nil || !res.trusted(addr) {
+		return peer
+	}
+
+	var forwarded []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	// The rightmost address that is not a trusted proxy is the client, the
+	// ones left of it may have been made up by the client
+	client := ""
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		client = hop.Unmap().String()
+		if !res.trusted(hop) {
+			return client
+		}
+	}
+	if client != "" {
+		return client
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return realIP.Unmap().String()
+	}
+	return peer
+}
+
+// Lookup looks an address up in the GeoIP databases. Only the address is
+// set when it is not found or no database is configured
+func (res *Resolver) Lookup(ip string) Info {
+	info := Info{IP: ip}
+	if res == nil || (res.countries == nil && res.asns == nil) {
+		return info
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return info
+	}
+
+	if res.countries != nil {
+		country, err := res.countries.Country(parsed)
+		if err != nil {
+			res.logger.Warn("Failed to look up the country of an address",
+				slog.String("ip", ip),
+				slog.Any("error", err),
+			)
+		} else {
+			info.Country = country.Country.IsoCode
+		}
+	}
+	if res.asns != nil {
+		asn, err := res.asns.ASN(parsed)
+		if err != nil {
+			res.logger.Warn("Failed to look up the ASN of an address",
+				slog.String("ip", ip),
+				slog.Any("error", err),
+			)
+		} else {
+			info.ASN = asn.AutonomousSystemNumber
+			info.ASOrganization = asn.AutonomousSystemOrganization
+		}
+	}
+	return info
+}
+
+// Close releases the GeoIP databases
+func (res *Resolver) Close() error {
+	if res == nil {
+		return nil
+	}
+
+	var errs []error
+	if res.countries != nil {
+		errs = append(errs, res.countries.Close())
+	}
+	if res.asns != nil {
+		errs = append(errs, res.asns.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// trusted reports whether an address belongs to a trusted proxy
+func (res *Resolver) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range res.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerAddr returns the address of the peer of the request's connection
+func peerAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap().String()
+	}
+	return host
+}
+
+// parsePrefix parses a CIDR range or a single address
+func parsePrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
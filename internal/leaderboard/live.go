@@ -0,0 +1,255 @@
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// How many updates a subscriber may fall behind before it is disconnected
+const liveSubscriberBuffer = 16
+
+// How often expired live tickets are deleted
+const liveTicketPruneInterval = time.Minute
+
+// RankChange is a change to the global rank or the vibe points of an
+// account. OldRank and OldVibePoints are nil when the account just entered
+// the top ranks.
+type RankChange struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	OldRank       *int64    `json:"old_rank"`
+	NewRank       int64     `json:"new_rank"`
+	OldVibePoints *int64    `json:"old_vibe_points"`
+	VibePoints    int64     `json:"vibe_points"`
+}
+
+// LiveUpdate holds the rank changes found during a single check that concern
+// a subscriber
+type LiveUpdate struct {
+	Changes []RankChange `json:"changes"`
+}
+
+// LiveFeed pushes rank changes to the clients connected to the live
+// leaderboard.
+//
+// While anybody is subscribed the ranks of the top accounts and of the
+// subscribed accounts are read on every tick and compared with the ranks
+// read on the previous tick. Every subscriber receives the changes within
+// the top ranks and the changes to its own account. Each instance reads the
+// ranks itself, so subscribers receive every change whichever instance they
+// are connected to.
+type LiveFeed struct {
+	pool     *pgxpool.Pool
+	logger   *slog.Logger
+	interval time.Duration
+	topSize  int64
+
+	mu          sync.Mutex
+	subscribers map[*liveSubscriber]struct{}
+	closed      bool
+
+	// The ranks read on the previous tick, only used by Start. Nil while
+	// nobody is subscribed
+	positions map[uuid.UUID]repository.ListLiveLeaderboardPositionsRow
+}
+
+type liveSubscriber struct {
+	accountID uuid.UUID
+	updates   chan LiveUpdate
+}
+
+// NewLiveFeed creates a new LiveFeed. Call Start to begin pushing rank
+// changes.
+func NewLiveFeed(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *LiveFeed {
+	interval := time.Duration(cfg.LeaderboardConfig.LiveIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	topSize := int64(cfg.LeaderboardConfig.LiveTopSize)
+	if topSize <= 0 {
+		topSize = 100
+	}
+
+	return &LiveFeed{
+		pool:        pool,
+		logger:      logger,
+		interval:    interval,
+		topSize:     topSize,
+		subscribers: map[*liveSubscriber]struct{}{},
+	}
+}
+
+// Start checks ranks for changes on every interval and deletes expired
+// tickets until the context is cancelled
+func (lf *LiveFeed) Start(ctx context.Context) {
+	ticker := time.NewTicker(lf.interval)
+	defer ticker.Stop()
+	pruneTicker := time.NewTicker(liveTicketPruneInterval)
+	defer pruneTicker.Stop()
+
+	lf.logger.Info("Live leaderboard started",
+		slog.Duration("interval", lf.interval),
+		slog.Int64("top", lf.topSize),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			lf.logger.Info("Live leaderboard stopped")
+			return
+		case <-ticker.C:
+			lf.check(ctx)
+		case <-pruneTicker.C:
+			if _, err := repository.New(lf.pool).PruneLeaderboardLiveTickets(ctx); err != nil {
+				lf.logger.Error("Failed to prune live leaderboard tickets", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Subscribe returns the updates concerning the account from now on. The
+// channel is closed when the subscriber falls behind or the feed is closed.
+// Call cancel once the updates are no longer needed
+func (lf *LiveFeed) Subscribe(accountID uuid.UUID) (updates <-chan LiveUpdate, cancel func()) {
+	sub := &liveSubscriber{
+		accountID: accountID,
+		updates:   make(chan LiveUpdate, liveSubscriberBuffer),
+	}
+
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	if lf.closed {
+		close(sub.updates)
+		return sub.updates, func() {}
+	}
+	lf.subscribers[sub] = struct{}{}
+
+	return sub.updates, func() {
+		lf.mu.Lock()
+		defer lf.mu.Unlock()
+		lf.remove(sub)
+	}
+}
+
+// Close disconnects every subscriber
+func (lf *LiveFeed) Close() {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+	lf.closed = true
+	for sub := range lf.subscribers {
+		lf.remove(sub)
+	}
+}
+
+// check reads the current ranks and pushes what changed since the previous
+// check to the subscribers. Nothing is pushed on the first check after
+// somebody subscribed as there are no previous ranks to compare with
+func (lf *LiveFeed) check(ctx context.Context) {
+	lf.mu.Lock()
+	subscribed := map[uuid.UUID]bool{}
+	for sub := range lf.subscribers {
+		subscribed[sub.accountID] = true
+	}
+	lf.mu.Unlock()
+
+	if len(subscribed) == 0 {
+		lf.positions = nil
+		return
+	}
+
+	// Accounts that were in the top ranks are read again even when they
+	// dropped out of them, so that subscribers see where they went
+	accountIDs := make([]uuid.UUID, 0, len(subscribed)+len(lf.positions))
+	for accountID := range subscribed {
+		accountIDs = append(accountIDs, accountID)
+	}
+	for accountID := range lf.positions {
+		if !subscribed[accountID] {
+			accountIDs = append(accountIDs, accountID)
+		}
+	}
+
+	rows, err := repository.New(lf.pool).ListLiveLeaderboardPositions(ctx, repository.ListLiveLeaderboardPositionsParams{
+		Top:        lf.topSize,
+		AccountIds: accountIDs,
+	})
+	if err != nil {
+		lf.logger.Error("Failed to read live leaderboard ranks", slog.Any("error", err))
+		return
+	}
+
+	var changes []RankChange
+	positions := make(map[uuid.UUID]repository.ListLiveLeaderboardPositionsRow, len(rows))
+	for _, row := range rows {
+		previous, known := lf.positions[row.AccountID]
+		switch {
+		case known && (previous.VibeRank != row.VibeRank || previous.VibePoints != row.VibePoints):
+			changes = append(changes, RankChange{
+				AccountID:     row.AccountID,
+				OldRank:       &previous.VibeRank,
+				NewRank:       row.VibeRank,
+				OldVibePoints: &previous.VibePoints,
+				VibePoints:    row.VibePoints,
+			})
+		case !known && lf.positions != nil && row.VibeRank <= lf.topSize:
+			changes = append(changes, RankChange{
+				AccountID:  row.AccountID,
+				NewRank:    row.VibeRank,
+				VibePoints: row.VibePoints,
+			})
+		}
+
+		if row.VibeRank <= lf.topSize || subscribed[row.AccountID] {
+			positions[row.AccountID] = row
+		}
+	}
+	lf.positions = positions
+
+	if len(changes) > 0 {
+		lf.push(changes)
+	}
+}
+
+// push sends every subscriber the changes within the top ranks and the
+// changes to its own account
+func (lf *LiveFeed) push(changes []RankChange) {
+	lf.mu.Lock()
+	defer lf.mu.Unlock()
+
+	for sub := range lf.subscribers {
+		var update LiveUpdate
+		for _, change := range changes {
+			wasTop := change.OldRank != nil && *change.OldRank <= lf.topSize
+			if change.NewRank <= lf.topSize || wasTop || change.AccountID == sub.accountID {
+				update.Changes = append(update.Changes, change)
+			}
+		}
+		if len(update.Changes) == 0 {
+			continue
+		}
+
+		select {
+		case sub.updates <- update:
+		default:
+			lf.logger.Warn("Disconnecting live leaderboard subscriber that fell behind",
+				slog.String("account_id", sub.accountID.String()),
+			)
+			lf.remove(sub)
+		}
+	}
+}
+
+// remove forgets a subscriber and closes its channel. lf.mu must be held
+func (lf *LiveFeed) remove(sub *liveSubscriber) {
+	if _, ok := lf.subscribers[sub]; !ok {
+		return
+	}
+	delete(lf.subscribers, sub)
+	close(sub.updates)
+}
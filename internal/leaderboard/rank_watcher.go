@@ -0,0 +1,182 @@
+package leaderboard
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// RankWatcher publishes rank change events.
+//
+// The last global rank of every ranked account is kept in
+// leaderboard_rank_positions. On every tick the current ranks are compared
+// with it to find the accounts that entered the top ranks and the accounts
+// that were overtaken, then the current ranks are recorded. Rank changes are
+// only noticed at tick granularity, an account that climbs and falls back
+// between two ticks produces no event.
+type RankWatcher struct {
+	pool     *pgxpool.Pool
+	logger   *slog.Logger
+	events   *eventbus.LeaderboardEventBus
+	interval time.Duration
+	topSize  int64
+}
+
+// rankChanges holds the rank changes found during a single check
+type rankChanges struct {
+	topEntries []repository.ListLeaderboardTopEntriesRow
+	overtakes  map[string][]repository.ListLeaderboardOvertakesRow
+}
+
+// NewRankWatcher creates a new RankWatcher. Call Start to begin watching
+// ranks.
+func NewRankWatcher(cfg *config.Config, pool *pgxpool.Pool, events *eventbus.LeaderboardEventBus, logger *slog.Logger) *RankWatcher {
+	interval := time.Duration(cfg.LeaderboardConfig.RankWatchIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	topSize := int64(cfg.LeaderboardConfig.RankTopSize)
+	if topSize <= 0 {
+		topSize = 10
+	}
+
+	return &RankWatcher{
+		pool:     pool,
+		logger:   logger,
+		events:   events,
+		interval: interval,
+		topSize:  topSize,
+	}
+}
+
+// Start compares ranks on every interval until the context is cancelled
+func (rw *RankWatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(rw.interval)
+	defer ticker.Stop()
+
+	rw.logger.Info("Leaderboard rank watcher started",
+		slog.Duration("interval", rw.interval),
+		slog.Int64("top", rw.topSize),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			rw.logger.Info("Leaderboard rank watcher stopped")
+			return
+		case <-ticker.C:
+			changes, err := rw.check(ctx)
+			if err != nil {
+				rw.logger.Error("Failed to check leaderboard ranks", slog.Any("error", err))
+				continue
+			}
+			rw.publish(ctx, changes)
+		}
+	}
+}
+
+// check finds the rank changes since the previous check and records the
+// current ranks. Nothing is reported on the very first check as there are no
+// previous ranks to compare with, or when another instance is checking
+func (rw *RankWatcher) check(ctx context.Context) (rankChanges, error) {
+	changes := rankChanges{overtakes: map[string][]repository.ListLeaderboardOvertakesRow{}}
+
+	tx, err := rw.pool.Begin(ctx)
+	if err != nil {
+		return changes, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	locked, err := repo.TryLockLeaderboardRankWatch(ctx)
+	if err != nil {
+		return changes, fmt.Errorf("failed to lock rank watch: %w", err)
+	}
+	if !locked {
+		return changes, nil
+	}
+
+	recorded, err := repo.CountLeaderboardRankPositions(ctx)
+	if err != nil {
+		return changes, fmt.Errorf("failed to count recorded ranks: %w", err)
+	}
+
+	if recorded > 0 {
+		changes.topEntries, err = repo.ListLeaderboardTopEntries(ctx, rw.topSize)
+		if err != nil {
+			return changes, fmt.Errorf("failed to list top entries: %w", err)
+		}
+
+		for _, scope := range []string{eventbus.LeaderboardScopeGlobal, eventbus.LeaderboardScopeFriends} {
+			overtakes, err := repo.ListLeaderboardOvertakes(ctx, scope == eventbus.LeaderboardScopeFriends)
+			if err != nil {
+				return changes, fmt.Errorf("failed to list %s overtakes: %w", scope, err)
+			}
+			changes.overtakes[scope] = overtakes
+		}
+	}
+
+	if _, err := repo.RecordLeaderboardRankPositions(ctx); err != nil {
+		return changes, fmt.Errorf("failed to record ranks: %w", err)
+	}
+	if _, err := repo.PruneLeaderboardRankPositions(ctx); err != nil {
+		return changes, fmt.Errorf("failed to prune recorded ranks: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return changes, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changes, nil
+}
+
+// publish publishes an event for every rank change
+func (rw *RankWatcher) publish(ctx context.Context, changes rankChanges) {
+	if rw.events == nil {
+		return
+	}
+
+	for _, entry := range changes.topEntries {
+		position := eventbus.RankPosition{
+			AccountID:  entry.AccountID,
+			OldRank:    entry.OldRank,
+			NewRank:    entry.NewRank,
+			VibePoints: entry.VibePoints,
+		}
+		if err := rw.events.PublishRankTopEntered(ctx, eventbus.LeaderboardScopeGlobal, rw.topSize, position, eventbus.GenerateRequestID()); err != nil {
+			rw.logger.Error("Failed to publish rank top entered event",
+				slog.String("account_id", entry.AccountID.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	for scope, overtakes := range changes.overtakes {
+		for _, overtake := range overtakes {
+			position := eventbus.RankPosition{
+				AccountID:  overtake.AccountID,
+				OldRank:    &overtake.OldRank,
+				NewRank:    overtake.NewRank,
+				VibePoints: overtake.VibePoints,
+			}
+			rival := eventbus.RankPosition{
+				AccountID:  overtake.RivalID,
+				OldRank:    &overtake.RivalOldRank,
+				NewRank:    overtake.RivalNewRank,
+				VibePoints: overtake.RivalVibePoints,
+			}
+			if err := rw.events.PublishRankOvertaken(ctx, scope, position, rival, eventbus.GenerateRequestID()); err != nil {
+				rw.logger.Error("Failed to publish rank overtaken event",
+					slog.String("account_id", overtake.AccountID.String()),
+					slog.String("scope", scope),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
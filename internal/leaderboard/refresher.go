@@ -0,0 +1,94 @@
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Refresher periodically recomputes the global ranks.
+//
+// The global leaderboard, account_vibepoint_rank, is a materialized view so
+// that reading it does not rank every account. Ranks are as fresh as its last
+// refresh. Refreshes run concurrently with reads, readers see the previous
+// ranks until a refresh commits. Only one instance refreshes at a time, the
+// others skip their tick.
+type Refresher struct {
+	pool     *pgxpool.Pool
+	logger   *slog.Logger
+	interval time.Duration
+}
+
+// NewRefresher creates a new Refresher. Call Start to begin refreshing the
+// leaderboard.
+func NewRefresher(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Refresher {
+	interval := time.Duration(cfg.LeaderboardConfig.RefreshIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &Refresher{
+		pool:     pool,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Start refreshes the leaderboard right away and then on every interval until
+// the context is cancelled
+func (r *Refresher) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Leaderboard refresher started", slog.Duration("interval", r.interval))
+	r.refresh(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Leaderboard refresher stopped")
+			return
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+// refresh recomputes the global ranks unless another instance is already
+// doing so
+func (r *Refresher) refresh(ctx context.Context) {
+	started := time.Now()
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to begin leaderboard refresh", slog.Any("error", err))
+		}
+		return
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	locked, err := repo.TryLockLeaderboardRefresh(ctx)
+	if err != nil {
+		r.logger.Error("Failed to lock leaderboard refresh", slog.Any("error", err))
+		return
+	}
+	if !locked {
+		return
+	}
+	if err := repo.RefreshLeaderboard(ctx); err != nil {
+		if ctx.Err() == nil {
+			r.logger.Error("Failed to refresh leaderboard", slog.Any("error", err))
+		}
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("Failed to commit leaderboard refresh", slog.Any("error", err))
+		return
+	}
+	r.logger.Debug("Refreshed leaderboard", slog.Duration("took", time.Since(started)))
+}
@@ -0,0 +1,161 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// SeasonCloser closes leaderboard seasons once they have ended.
+//
+// Closing a season archives its standings, ranked by the points every account
+// earned during the season, and then applies the season's end action. Points
+// are kept as they are, decayed by the season's decay percent or reset to
+// zero. Decays and resets are appended to the vibe point ledger so totals
+// stay consistent with it. A season.closed event carrying a recap of the
+// season is published once the season is closed.
+type SeasonCloser struct {
+	pool      *pgxpool.Pool
+	logger    *slog.Logger
+	events    *eventbus.LeaderboardEventBus
+	interval  time.Duration
+	recapSize int
+}
+
+// NewSeasonCloser creates a new SeasonCloser. Call Start to begin closing
+// seasons.
+func NewSeasonCloser(cfg *config.Config, pool *pgxpool.Pool, events *eventbus.LeaderboardEventBus, logger *slog.Logger) *SeasonCloser {
+	interval := time.Duration(cfg.LeaderboardConfig.SeasonCheckIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	recapSize := cfg.LeaderboardConfig.SeasonRecapSize
+	if recapSize <= 0 {
+		recapSize = 10
+	}
+
+	return &SeasonCloser{
+		pool:      pool,
+		logger:    logger,
+		events:    events,
+		interval:  interval,
+		recapSize: recapSize,
+	}
+}
+
+// Start closes every season that has ended on every interval until the
+// context is cancelled
+func (c *SeasonCloser) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.logger.Info("Leaderboard season closer started", slog.Duration("interval", c.interval))
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.logger.Info("Leaderboard season closer stopped")
+			return
+		case <-ticker.C:
+			c.closeDueSeasons(ctx)
+		}
+	}
+}
+
+// closeDueSeasons closes seasons one at a time until none is left
+func (c *SeasonCloser) closeDueSeasons(ctx context.Context) {
+	for {
+		recap, err := c.closeNextSeason(ctx)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return
+		}
+		if err != nil {
+			c.logger.Error("Failed to close leaderboard season", slog.Any("error", err))
+			return
+		}
+
+		c.logger.Info("Closed leaderboard season",
+			slog.String("season_id", recap.Season.ID.String()),
+			slog.String("end_action", string(recap.Season.EndAction)),
+			slog.Int64("participants", recap.Participants),
+		)
+		if c.events != nil {
+			requestID := eventbus.GenerateRequestID()
+			if err := c.events.PublishSeasonClosed(ctx, recap, requestID); err != nil {
+				c.logger.Error("Failed to publish season closed event",
+					slog.String("season_id", recap.Season.ID.String()),
+					slog.Any("error", err),
+				)
+			}
+		}
+	}
+}
+
+// closeNextSeason closes the oldest season that has ended. It returns
+// pgx.ErrNoRows when no season is due
+func (c *SeasonCloser) closeNextSeason(ctx context.Context) (eventbus.SeasonRecap, error) {
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return eventbus.SeasonRecap{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	season, err := repo.ClaimDueLeaderboardSeason(ctx)
+	if err != nil {
+		return eventbus.SeasonRecap{}, err
+	}
+
+	participants, err := repo.ArchiveLeaderboardSeasonStandings(ctx, repository.ArchiveLeaderboardSeasonStandingsParams{
+		SeasonID: season.ID,
+		StartsAt: season.StartsAt,
+		EndsAt:   season.EndsAt,
+	})
+	if err != nil {
+		return eventbus.SeasonRecap{}, fmt.Errorf("failed to archive standings of season %s: %w", season.ID, err)
+	}
+
+	var percent int32
+	switch season.EndAction {
+	case repository.LeaderboardSeasonEndActionDecay:
+		percent = int32(season.DecayPercent)
+	case repository.LeaderboardSeasonEndActionReset:
+		percent = 100
+	}
+	if percent > 0 {
+		if _, err := repo.DecayLeaderboardSeasonPoints(ctx, repository.DecayLeaderboardSeasonPointsParams{
+			Reason:   fmt.Sprintf("Season %s: %s", season.EndAction, season.Name),
+			Percent:  percent,
+			SeasonID: season.ID,
+		}); err != nil {
+			return eventbus.SeasonRecap{}, fmt.Errorf("failed to %s points for season %s: %w", season.EndAction, season.ID, err)
+		}
+	}
+
+	standings, err := repo.ListLeaderboardSeasonStandings(ctx, repository.ListLeaderboardSeasonStandingsParams{
+		SeasonID: season.ID,
+		Limit:    int32(c.recapSize),
+		Offset:   0,
+	})
+	if err != nil {
+		return eventbus.SeasonRecap{}, fmt.Errorf("failed to retrieve standings of season %s: %w", season.ID, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return eventbus.SeasonRecap{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return eventbus.SeasonRecap{
+		Season:       season,
+		Participants: participants,
+		Standings:    standings,
+	}, nil
+}
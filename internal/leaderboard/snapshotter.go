@@ -0,0 +1,72 @@
+// Package leaderboard keeps the history behind the vibe point leaderboards.
+//
+// SNAPSHOTS:
+// The global leaderboard only holds the current ranks, so it cannot say where
+// an account stood last week. The Snapshotter records the global rank
+// of every ranked account once per day in leaderboard_snapshots. It refreshes
+// the current day's snapshot on every tick, the last refresh of a day is the
+// one that is kept.
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// Snapshotter periodically records daily leaderboard rank snapshots
+type Snapshotter struct {
+	pool     *pgxpool.Pool
+	logger   *slog.Logger
+	interval time.Duration
+}
+
+// NewSnapshotter creates a new Snapshotter. Call Start to begin recording
+// snapshots.
+func NewSnapshotter(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Snapshotter {
+	interval := time.Duration(cfg.LeaderboardConfig.SnapshotIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &Snapshotter{
+		pool:     pool,
+		logger:   logger,
+		interval: interval,
+	}
+}
+
+// Start records a snapshot right away and then on every interval until the
+// context is cancelled
+func (s *Snapshotter) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.logger.Info("Leaderboard snapshotter started", slog.Duration("interval", s.interval))
+	s.snapshot(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Leaderboard snapshotter stopped")
+			return
+		case <-ticker.C:
+			s.snapshot(ctx)
+		}
+	}
+}
+
+// snapshot records today's rank of every ranked account
+func (s *Snapshotter) snapshot(ctx context.Context) {
+	repo := repository.New(s.pool)
+	recorded, err := repo.SnapshotLeaderboardRanks(ctx)
+	if err != nil {
+		s.logger.Error("Failed to record leaderboard snapshot", slog.Any("error", err))
+		return
+	}
+	s.logger.Debug("Recorded leaderboard snapshot", slog.Int64("accounts", recorded))
+}
@@ -0,0 +1,116 @@
+// Package logs creates the handler Verisafe writes its logs with.
+//
+// FORMATS:
+// Logs are written as JSON, one object per line, for log collectors. The text
+// format writes key=value pairs instead, which is easier to read while
+// developing locally.
+//
+// SAMPLING:
+// Every request served is logged, which makes for most of the logs of a busy
+// instance. Logs written with a context marked by Sampled can be sampled, only
+// a share of them is kept. Warnings and errors are always kept.
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+// Formats logs can be written in
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configure the handler created by NewHandler
+type Options struct {
+	// Either FormatJSON or FormatText
+	Format string
+	// Least severe level logged
+	Level slog.Leveler
+	// Decides which sampled logs are kept, all of them when nil
+	Sampler *Sampler
+}
+
+// NewHandler creates a handler writing logs to w
+func NewHandler(w io.Writer, opts Options) (slog.Handler, error) {
+	handlerOptions := &slog.HandlerOptions{Level: opts.Level}
+
+	var handler slog.Handler
+	switch opts.Format {
+	case FormatJSON, "":
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	case FormatText:
+		handler = slog.NewTextHandler(w, handlerOptions)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	if opts.Sampler == nil {
+		return handler, nil
+	}
+	return &samplingHandler{Handler: handler, sampler: opts.Sampler}, nil
+}
+
+type sampledKey struct{}
+
+// Sampled marks the logs written with the returned context as high volume,
+// so that only a share of them is kept
+func Sampled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sampledKey{}, true)
+}
+
+// Sampler keeps a share of the sampled logs. The share can be changed while
+// logs are written
+type Sampler struct {
+	rate atomic.Uint64
+}
+
+// NewSampler creates a Sampler keeping a share of the sampled logs between
+// 0 and 1
+func NewSampler(rate float64) *Sampler {
+	s := &Sampler{}
+	s.SetRate(rate)
+	return s
+}
+
+// SetRate changes the share of the sampled logs kept
+func (s *Sampler) SetRate(rate float64) {
+	s.rate.Store(math.Float64bits(rate))
+}
+
+// keep decides whether a sampled log is kept
+func (s *Sampler) keep() bool {
+	rate := math.Float64frombits(s.rate.Load())
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// samplingHandler drops the sampled logs the sampler does not keep before
+// they are formatted
+type samplingHandler struct {
+	slog.Handler
+	sampler *Sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if !h.Handler.Enabled(ctx, level) {
+		return false
+	}
+	if level < slog.LevelWarn && ctx != nil && ctx.Value(sampledKey{}) != nil {
+		return h.sampler.keep()
+	}
+	return true
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	dbPoolCollectorsMu sync.Mutex
+	dbPoolCollectors   = map[string]prometheus.Collector{}
+)
+
+// dbPoolCollector exports the statistics of a connection pool, read on every
+// scrape
+type dbPoolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns          *prometheus.Desc
+	totalConns        *prometheus.Desc
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	acquires          *prometheus.Desc
+	emptyAcquires     *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireWait  *prometheus.Desc
+}
+
+// WatchDBPool exports the statistics of a database connection pool under
+// the name of the pool, primary or replica. Watching a pool name again
+// replaces the pool watched under it
+func WatchDBPool(name string, pool *pgxpool.Pool) {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", metric), help, nil, labels)
+	}
+	collector := &dbPoolCollector{
+		pool:              pool,
+		maxConns:          desc("max_connections", "Connections the pool may open at most."),
+		totalConns:        desc("connections", "Connections currently open by the pool, including those being established."),
+		acquiredConns:     desc("acquired_connections", "Connections currently in use."),
+		idleConns:         desc("idle_connections", "Connections currently open and unused."),
+		constructingConns: desc("constructing_connections", "Connections currently being established."),
+		acquires:          desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquires:     desc("empty_acquires_total", "Acquires that had to wait for a connection because none was idle."),
+		canceledAcquires:  desc("canceled_acquires_total", "Acquires given up because their context ended before a connection was available."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquireWait:  desc("empty_acquire_wait_seconds_total", "Total time acquires spent waiting for a connection because none was idle."),
+	}
+
+	dbPoolCollectorsMu.Lock()
+	defer dbPoolCollectorsMu.Unlock()
+	if previous, ok := dbPoolCollectors[name]; ok {
+		prometheus.Unregister(previous)
+	}
+	prometheus.MustRegister(collector)
+	dbPoolCollectors[name] = collector
+}
+
+func (c *dbPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.acquires
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquires
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireWait
+}
+
+func (c *dbPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.constructingConns, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquireWait, prometheus.CounterValue, stat.EmptyAcquireWaitTime().Seconds())
+}
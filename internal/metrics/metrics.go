@@ -0,0 +1,180 @@
+// Package metrics holds the Prometheus metrics Verisafe exports on
+// GET /metrics.
+//
+// Metrics are registered with the default Prometheus registry when the
+// package is loaded, which also exports the Go runtime and process metrics.
+// Every metric is prefixed with verisafe_ and the name of the subsystem it
+// measures.
+package metrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "verisafe"
+
+// Event bus metrics
+var (
+	// Events handed to the event bus, by outcome. An event the bus buffered
+	// while disconnected counts as a success
+	EventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "eventbus",
+		Name:      "events_published_total",
+		Help:      "Events published to the event bus, by exchange, event type and outcome (success or failure).",
+	}, []string{"exchange", "event_type", "outcome"})
+
+	// How long publishing an event took, including broker confirmations and
+	// retries
+	EventPublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "eventbus",
+		Name:      "publish_duration_seconds",
+		Help:      "Time taken to publish an event, by exchange and event type.",
+		Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"exchange", "event_type"})
+
+	// Events that could not be published or handled and were dead-lettered
+	EventsDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "eventbus",
+		Name:      "events_dead_lettered_total",
+		Help:      "Events routed to the dead-letter exchange, by exchange.",
+	}, []string{"exchange"})
+)
+
+// HTTP metrics
+var (
+	// Requests that took longer than the slow request threshold
+	SlowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "slow_requests_total",
+		Help:      "Requests that took longer than the slow request threshold, by method and route.",
+	}, []string{"method", "route"})
+
+	// Database queries made while serving slow requests
+	SlowRequestQueries = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "slow_request_queries",
+		Help:      "Database queries made while serving a slow request, by method and route.",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100, 200, 500},
+	}, []string{"method", "route"})
+
+	// Requests turned away with 503 to protect Verisafe from overload
+	ShedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "shed_requests_total",
+		Help:      "Requests shed with 503 Service Unavailable, by route group and reason (concurrency or db_pool).",
+	}, []string{"group", "reason"})
+)
+
+// gRPC metrics
+var (
+	// Calls handled by the gRPC server, by status code
+	GRPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "Calls handled by the gRPC server, by method and status code.",
+	}, []string{"method", "code"})
+
+	// How long handling a call took
+	GRPCRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle a gRPC call, by method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method"})
+)
+
+// Database metrics
+var (
+	// How long queries took. Observations of slow queries carry the ID of
+	// the request that made them as an exemplar
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "Time taken by a database query, by pool and query name.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"pool", "query"})
+
+	// Rows queries returned or changed
+	DBQueryRows = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_rows",
+		Help:      "Rows returned or affected by a successful database query, by pool and query name.",
+		Buckets:   []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000},
+	}, []string{"pool", "query"})
+
+	// Queries that failed
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "Database queries that failed, by pool and query name.",
+	}, []string{"pool", "query"})
+)
+
+var (
+	eventBusGaugesMu sync.Mutex
+	eventBusGauges   = map[string][]prometheus.Collector{}
+)
+
+// WatchEventBus exports whether the event bus of an exchange is connected to
+// its broker and how many events it buffered while disconnected. Both are
+// read on every scrape. Watching an exchange again replaces its gauges
+func WatchEventBus(exchange string, connected func() bool, buffered func() int) {
+	labels := prometheus.Labels{"exchange": exchange}
+	gauges := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "eventbus",
+			Name:        "connected",
+			Help:        "Whether the event bus of an exchange is connected to its broker (1) or not (0).",
+			ConstLabels: labels,
+		}, func() float64 {
+			if connected() {
+				return 1
+			}
+			return 0
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "eventbus",
+			Name:        "buffered_publishes",
+			Help:        "Events buffered while the event bus of an exchange is disconnected.",
+			ConstLabels: labels,
+		}, func() float64 { return float64(buffered()) }),
+	}
+
+	eventBusGaugesMu.Lock()
+	defer eventBusGaugesMu.Unlock()
+	for _, gauge := range eventBusGauges[exchange] {
+		prometheus.Unregister(gauge)
+	}
+	for _, gauge := range gauges {
+		prometheus.MustRegister(gauge)
+	}
+	eventBusGauges[exchange] = gauges
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format,
+// or in the OpenMetrics format, which carries exemplars, to scrapers asking
+// for it
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(
+		prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}),
+	)
+}
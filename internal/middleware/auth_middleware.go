@@ -7,21 +7,25 @@ import (
 	"log/slog"
 	"net/http"
 	"regexp"
-	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/authz"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
 	"github.com/opencrafts-io/verisafe/internal/repository"
 	"github.com/opencrafts-io/verisafe/internal/utils"
 )
 
 const AuthUserClaims = "middleware.auth.claims"
 const AuthUserPerms = "middleware.auth.perms"
+const AuthUserDeniedPerms = "middleware.auth.denied_perms"
 const AuthUserRoles = "middleware.auth.roles"
 const AuthUserIsPendingDeletion = "middleware.auth.pending_deletion"
+const AuthUserAccount = "middleware.auth.account"
 
 func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 	return func(next http.Handler) http.Handler {
@@ -42,20 +46,9 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 				return
 			}
 
-			tx, err := conn.Begin(r.Context())
-			if err != nil {
-				logger.Error("failed to begin tx", slog.String("err", err.Error()))
-				w.WriteHeader(http.StatusInternalServerError)
-				json.NewEncoder(w).Encode(map[string]any{"error": "Internal server error"})
-				return
-			}
-			defer func() {
-				if err != nil {
-					tx.Rollback(r.Context())
-				}
-			}()
-
-			repo := repository.New(tx)
+			// Authentication only reads, apart from a single statement
+			// stamping service tokens, so it runs on the connection directly
+			repo := repository.New(conn)
 
 			switch {
 			// --- Bearer Token
@@ -67,6 +60,12 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 					json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
 					return
 				}
+				// Tokens are only honoured by the tenant they were issued for
+				if cfg.TenancyConfig.Enabled && parsedClaims.Tenant != GetTenant(ctx) {
+					w.WriteHeader(http.StatusUnauthorized)
+					json.NewEncoder(w).Encode(map[string]any{"error": "This token was issued for another tenant"})
+					return
+				}
 				claims = parsedClaims
 
 			// --- X-API-Key
@@ -75,6 +74,12 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 				hashed := utils.HashToken(apiKey)
 				serviceToken, err := repo.GetServiceTokenByHash(r.Context(), hashed)
 				if err != nil {
+					ReportSecurityEvent(r, eventbus.SecurityLoginFailed, eventbus.SecurityActivity{
+						Outcome:  eventbus.OutcomeFailure,
+						Severity: eventbus.SeverityWarning,
+						Method:   "api_key",
+						Reason:   "unknown api key",
+					})
 					w.WriteHeader(http.StatusUnauthorized)
 					json.NewEncoder(w).Encode(map[string]any{"error": "Invalid or expired API key"})
 					return
@@ -82,6 +87,14 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 
 				// Enhanced validation for service tokens
 				if err := validateServiceToken(serviceToken, r); err != nil {
+					ReportSecurityEvent(r, eventbus.SecuritySuspiciousActivity, eventbus.SecurityActivity{
+						Outcome:   eventbus.OutcomeFailure,
+						Severity:  eventbus.SeverityCritical,
+						AccountID: serviceToken.AccountID.String(),
+						Method:    "api_key",
+						Reason:    err.Error(),
+						Details:   serviceTokenDetails(serviceToken),
+					})
 					w.WriteHeader(http.StatusUnauthorized)
 					json.NewEncoder(w).Encode(map[string]any{"error": err.Error()})
 					return
@@ -115,6 +128,14 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 				// Verify account is a bot account
 				if account.Type != repository.AccountTypeBot {
 					logger.Error("Service token used by non-bot account", slog.String("account_id", account.ID.String()), slog.String("account_type", string(account.Type)))
+					ReportSecurityEvent(r, eventbus.SecuritySuspiciousActivity, eventbus.SecurityActivity{
+						Outcome:   eventbus.OutcomeFailure,
+						Severity:  eventbus.SeverityCritical,
+						AccountID: account.ID.String(),
+						Method:    "api_key",
+						Reason:    "service token used by non-bot account",
+						Details:   serviceTokenDetails(serviceToken),
+					})
 					w.WriteHeader(http.StatusUnauthorized)
 					json.NewEncoder(w).Encode(map[string]any{"error": "Service tokens can only be used by bot accounts"})
 					return
@@ -149,40 +170,59 @@ func IsAuthenticated(cfg *config.Config, logger *slog.Logger) Middleware {
 				return
 			}
 
-			roles, err := repo.GetAllUserRoleNames(r.Context(), subID)
+			// Accounts whose permissions are cached only need their status
+			// loaded, everybody else has it loaded along with their roles and
+			// permissions in a single query
+			cache := GetPermissionCache(r.Context())
+			resolved, cached := cache.Get(subID)
+			var account repository.Account
+			if cached {
+				account, err = repo.GetAccountByID(r.Context(), subID)
+			} else {
+				var authorization repository.GetAccountAuthorizationRow
+				authorization, err = repo.GetAccountAuthorization(r.Context(), subID)
+				account = authorization.Account
+				resolved = authz.ResolvedPermissions{
+					Roles:       authorization.Roles,
+					Permissions: authorization.Permissions,
+					Denied:      authorization.DeniedPermissions,
+				}
+			}
 			if err != nil {
-				logger.Error("Failed to retrieve user roles",
-					slog.Any("error", err),
-					slog.Any("account_id", subID),
-				)
+				logger.Error("Failed to load account for request", slog.Any("error", err))
 				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(map[string]any{"error": "We couldn't retrieve your roles"})
+				json.NewEncoder(w).Encode(map[string]any{"error": "Unauthorized"})
 				return
 			}
-
-			perms, err := repo.GetUserPermissionNames(r.Context(), subID)
-			if err != nil {
-				logger.Error("Failed to retrieve user permissions",
-					slog.Any("error", err),
-					slog.Any("account_id", subID),
-				)
+			if account.DeactivatedAt != nil {
 				w.WriteHeader(http.StatusUnauthorized)
-				json.NewEncoder(w).Encode(map[string]any{"error": "We couldn't retrieve your roles"})
+				json.NewEncoder(w).Encode(map[string]any{
+					"error": "This account has been deactivated. Sign in again to reactivate it",
+				})
 				return
 			}
+			if !cached {
+				cache.Set(subID, resolved)
+			}
+			roles, perms := resolved.Roles, resolved.Permissions
 
 			authContext := context.WithValue(ctx, AuthUserClaims, claims)
 			rolesContext := context.WithValue(authContext, AuthUserRoles, roles)
 			permsContext := context.WithValue(rolesContext, AuthUserPerms, perms)
+			deniedContext := context.WithValue(permsContext, AuthUserDeniedPerms, resolved.Denied)
+			accountContext := context.WithValue(deniedContext, AuthUserAccount, account)
+			setAuditActor(r.Context(), subID)
 
-			next.ServeHTTP(w, r.WithContext(permsContext))
+			next.ServeHTTP(w, r.WithContext(accountContext))
 		})
 	}
 }
 
 // Checks whether the request bearer token has the necessary permission to continue
 // IsAuthenticated must be called before invoking this middleware so that the context
-// is populated with the claims from the decoded jwt
+// is populated with the claims from the decoded jwt. Permissions missing from the
+// caller's roles may still be granted by an authorization policy, which sees no
+// resource here so only its account_types condition can hold
 func HasPermission(permissions []string) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -193,22 +233,157 @@ func HasPermission(permissions []string) Middleware {
 				perms = permsVal.([]string)
 			}
 
+			forbid := func() {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]any{
+					"error": "You do not have the necessary permissions to perform this action",
+				})
+			}
+
+			engine := GetPolicyEngine(r.Context())
+			denied := GetDeniedPermissions(r.Context())
+			required := resolvePermissions(r.Context(), engine, permissions...)
+
 			// Check if the user has the required permissions
-			for _, requiredPermission := range permissions {
-				if !slices.Contains(perms, requiredPermission) {
-					w.WriteHeader(http.StatusForbidden)
-					json.NewEncoder(w).Encode(map[string]any{
-						"error": "You do not have the necessary permissions to perform this action",
-					})
+			for _, requiredPermission := range required {
+				if authz.IsDenied(denied, requiredPermission) {
+					forbid()
+					return
+				}
+				if authz.HasPermission(perms, requiredPermission) {
+					continue
+				}
+				allowed, err := grantedByPolicy(r, engine, requiredPermission, PolicyResource{})
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]any{"error": "Internal server error"})
+					return
+				}
+				if !allowed {
+					forbid()
 					return
 				}
 			}
+			recordUsage(r, required...)
 			// Proceed to the next handler
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// Checks whether the caller holds the necessary permissions either globally or
+// through a role scoped to the institution named by institutionParam. The
+// institution id is read from the path value of that name falling back to the
+// query parameter of the same name. Permissions the caller's roles do not
+// grant may still be granted by an authorization policy for the institution.
+// IsAuthenticated must be called before invoking this middleware
+func HasInstitutionPermission(permissions []string, institutionParam string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var perms []string
+			if permsVal := r.Context().Value(AuthUserPerms); permsVal != nil {
+				perms = permsVal.([]string)
+			}
+
+			forbid := func() {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]any{
+					"error": "You do not have the necessary permissions to perform this action",
+				})
+			}
+
+			engine := GetPolicyEngine(r.Context())
+			denied := GetDeniedPermissions(r.Context())
+			required := resolvePermissions(r.Context(), engine, permissions...)
+			var missing []string
+			for _, requiredPermission := range required {
+				if authz.IsDenied(denied, requiredPermission) {
+					forbid()
+					return
+				}
+				if !authz.HasPermission(perms, requiredPermission) {
+					missing = append(missing, requiredPermission)
+				}
+			}
+			if len(missing) == 0 {
+				recordUsage(r, required...)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			institutionID, err := strconv.ParseInt(requestValue(r, institutionParam), 10, 32)
+			if err != nil {
+				forbid()
+				return
+			}
+
+			claims, ok := r.Context().Value(AuthUserClaims).(*utils.VerisafeClaims)
+			if !ok {
+				forbid()
+				return
+			}
+			userID, err := uuid.Parse(claims.Subject)
+			if err != nil {
+				forbid()
+				return
+			}
+
+			conn, err := GetDBConnFromContext(r.Context())
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]any{"error": "Internal server error"})
+				return
+			}
+
+			scoped, err := repository.New(conn).GetUserInstitutionPermissionNames(r.Context(),
+				repository.GetUserInstitutionPermissionNamesParams{
+					UserID:        userID,
+					InstitutionID: int32(institutionID),
+				})
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]any{"error": "We couldn't retrieve your roles"})
+				return
+			}
+
+			for _, requiredPermission := range missing {
+				if authz.HasPermission(scoped, requiredPermission) {
+					continue
+				}
+				allowed, err := grantedByPolicy(r, engine, requiredPermission,
+					PolicyResource{InstitutionParam: institutionParam})
+				if err != nil {
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(map[string]any{"error": "Internal server error"})
+					return
+				}
+				if !allowed {
+					forbid()
+					return
+				}
+			}
+			recordUsage(r, required...)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetDeniedPermissions returns the permissions explicitly denied to the
+// caller. IsAuthenticated must have been called for the result to be populated
+func GetDeniedPermissions(ctx context.Context) []string {
+	denied, _ := ctx.Value(AuthUserDeniedPerms).([]string)
+	return denied
+}
+
+// CallerHasPermission reports whether the caller holds the permission through
+// their global roles and has not been denied it. Deprecated permission names
+// are resolved through their aliases
+func CallerHasPermission(ctx context.Context, permission string) bool {
+	permission = resolvePermissions(ctx, GetPolicyEngine(ctx), permission)[0]
+	perms, _ := ctx.Value(AuthUserPerms).([]string)
+	return authz.HasPermission(perms, permission) && !authz.IsDenied(GetDeniedPermissions(ctx), permission)
+}
+
 // validateServiceToken performs comprehensive validation of a service token
 func validateServiceToken(token repository.ServiceToken, r *http.Request) error {
 	// Check if token is revoked
@@ -256,33 +431,16 @@ func validateServiceToken(token repository.ServiceToken, r *http.Request) error
 	return nil
 }
 
-// getClientIP extracts the client IP address from the request
-func getClientIP(r *http.Request) string {
-	// Check for forwarded headers first
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if commaIndex := strings.Index(ip, ","); commaIndex != -1 {
-			return strings.TrimSpace(ip[:commaIndex])
-		}
-		return strings.TrimSpace(ip)
-	}
-
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return strings.TrimSpace(ip)
-	}
-
-	if ip := r.Header.Get("X-Client-IP"); ip != "" {
-		return strings.TrimSpace(ip)
-	}
-
-	// Fall back to remote address
-	if r.RemoteAddr != "" {
-		// Remove port if present
-		if colonIndex := strings.LastIndex(r.RemoteAddr, ":"); colonIndex != -1 {
-			return r.RemoteAddr[:colonIndex]
-		}
-		return r.RemoteAddr
-	}
+// serviceTokenDetails describes the service token a security event is about
+func serviceTokenDetails(token repository.ServiceToken) json.RawMessage {
+	details, _ := json.Marshal(map[string]string{
+		"service_token_id": token.ID.String(),
+		"name":             token.Name,
+	})
+	return details
+}
 
-	return ""
+// getClientIP returns the address of the client behind the request
+func getClientIP(r *http.Request) string {
+	return GetClientInfo(r).IP
 }
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/middleware"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestHasPermissionEvaluatesPolicies(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	repo := repository.New(pool)
+
+	if _, err := repo.CreateAuthorizationPolicy(ctx, repository.CreateAuthorizationPolicyParams{
+		Name:       "bots-read-reports",
+		Permission: "read:report:any",
+		Conditions: []byte(`{"account_types": ["bot"]}`),
+		IsActive:   true,
+	}); err != nil {
+		t.Fatalf("Could not create policy: %v", err)
+	}
+	engine := authz.NewEngine(&config.Config{}, pool, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := engine.Reload(ctx); err != nil {
+		t.Fatalf("Could not load policies: %v", err)
+	}
+
+	handler := middleware.CreateStack(
+		middleware.WithPolicyEngine(engine),
+		middleware.HasPermission([]string{"read:report:any"}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		accountType repository.AccountType
+		want        int
+	}{
+		{repository.AccountTypeBot, http.StatusOK},
+		{repository.AccountTypeHuman, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.accountType), func(t *testing.T) {
+			account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+				Email: string(tt.accountType) + "@example.com",
+				Name:  string(tt.accountType),
+				Type:  tt.accountType,
+			})
+			if err != nil {
+				t.Fatalf("Could not create account: %v", err)
+			}
+			conn, err := pool.Acquire(ctx)
+			if err != nil {
+				t.Fatalf("Could not acquire connection: %v", err)
+			}
+			defer conn.Release()
+
+			// The account holds no role granting the permission
+			reqCtx := context.WithValue(ctx, middleware.DBConnectionContextKey, conn)
+			reqCtx = context.WithValue(reqCtx, middleware.AuthUserAccount, account)
+			req := httptest.NewRequestWithContext(reqCtx, http.MethodGet, "/reports", nil)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
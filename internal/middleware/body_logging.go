@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/bodylog"
+)
+
+// cappedBuffer keeps the first bytes written to it and drops the rest
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write always reports the whole of p as written so that writers teeing into
+// the buffer are not interrupted once it is full
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if room := b.limit - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		p = p[:max(room, 0)]
+	}
+	b.buf.Write(p)
+	return n, nil
+}
+
+// bodyCapturingWriter captures the status and the start of the body of a
+// response while writing it
+type bodyCapturingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       *cappedBuffer
+}
+
+func (w *bodyCapturingWriter) WriteHeader(statusCode int) {
+	w.ResponseWriter.WriteHeader(statusCode)
+	w.statusCode = statusCode
+}
+
+func (w *bodyCapturingWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap returns the original http.ResponseWriter so that
+// http.ResponseController can reach it
+func (w *bodyCapturingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// LogSampledBodies logs the redacted request and response bodies of a share
+// of the requests of the routes the sampler has rules for. Bodies are only
+// captured while any route has a rule, and only as far as they are logged.
+// It must wrap the router directly to see the route the request matched
+func LogSampledBodies(sampler *bodylog.Sampler, logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		if sampler == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// WebSocket upgrades hand the connection over and carry no body
+			if !sampler.Active() || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// The route is only known once the router matched it, so bodies
+			// are captured first and the sample is drawn up front
+			roll := rand.Float64()
+			requestBody := &cappedBuffer{limit: sampler.MaxBodyBytes()}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.TeeReader(r.Body, requestBody), r.Body}
+			}
+			wrapped := &bodyCapturingWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+				body:           &cappedBuffer{limit: sampler.MaxBodyBytes()},
+			}
+
+			next.ServeHTTP(wrapped, r)
+
+			rule, ok := sampler.Rule(r.Pattern)
+			if !ok || roll >= rule.SampleRate {
+				return
+			}
+
+			logger.Info("Sampled request bodies",
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("route", r.Pattern),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.String("request_body", bodylog.Redact(
+					requestBody.buf.Bytes(), r.Header.Get("Content-Type"), requestBody.truncated,
+				)),
+				slog.Bool("request_body_truncated", requestBody.truncated),
+				slog.String("response_body", bodylog.Redact(
+					wrapped.body.buf.Bytes(), wrapped.Header().Get("Content-Type"), wrapped.body.truncated,
+				)),
+				slog.Bool("response_body_truncated", wrapped.body.truncated),
+			)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Cacheable lets clients cache the responses of a read endpoint for up to
+// maxAge and revalidate them afterwards. Successful responses carry an ETag
+// derived from their body, requests whose If-None-Match holds the current
+// ETag are answered with 304 Not Modified and no body. A maxAge of zero makes
+// clients revalidate every time.
+//
+// Responses depend on the caller's permissions so they may only be cached by
+// the client itself, never by shared caches
+func Cacheable(maxAge time.Duration) Middleware {
+	cacheControl := "private, no-cache"
+	if maxAge > 0 {
+		cacheControl = fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &cachingWriter{ResponseWriter: w}
+			next.ServeHTTP(cw, r)
+			if cw.status == 0 {
+				cw.status = http.StatusOK
+			}
+
+			if cw.status == http.StatusOK {
+				sum := sha256.Sum256(cw.body.Bytes())
+				etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+				w.Header().Set("ETag", etag)
+				w.Header().Set("Cache-Control", cacheControl)
+				w.Header().Add("Vary", "Authorization, X-API-Key")
+
+				if etagMatches(r.Header.Get("If-None-Match"), etag) {
+					w.Header().Del("Content-Type")
+					w.Header().Del("Content-Length")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+
+			w.WriteHeader(cw.status)
+			w.Write(cw.body.Bytes())
+		})
+	}
+}
+
+// cachingWriter holds the response back until its ETag is known
+type cachingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (cw *cachingWriter) WriteHeader(status int) {
+	if cw.status == 0 {
+		cw.status = status
+	}
+}
+
+func (cw *cachingWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	return cw.body.Write(b)
+}
+
+// etagMatches reports whether an If-None-Match header names the ETag. Weak
+// validators match their strong counterpart as RFC 9110 asks for
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
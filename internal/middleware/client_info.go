@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/ipintel"
+)
+
+const ClientInfoContextKey = "middleware.client_info"
+
+// WithClientInfo resolves the address of the client behind every request,
+// honouring the trusted proxies, and what is known about it from the GeoIP
+// databases. Middlewares and handlers read it through GetClientInfo
+func WithClientInfo(resolver *ipintel.Resolver) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ClientInfoContextKey, resolver.Resolve(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientInfo retrieves what is known about the client behind the request.
+// Outside of WithClientInfo the peer of the connection is taken for the
+// client
+func GetClientInfo(r *http.Request) ipintel.Info {
+	if info, ok := r.Context().Value(ClientInfoContextKey).(ipintel.Info); ok {
+		return info
+	}
+	var resolver *ipintel.Resolver
+	return resolver.Resolve(r)
+}
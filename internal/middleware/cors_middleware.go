@@ -2,20 +2,43 @@ package middleware
 
 import (
 	"net/http"
-	"slices"
+	"strconv"
+
+	"github.com/opencrafts-io/verisafe/internal/config"
 )
 
-func CORSMiddleware(allowedOrigins []string) Middleware {
+// CORSMiddleware lets browsers call the API from the origins allowed in the
+// configuration. Origins like https://*.opencrafts.io allow every subdomain
+// of a domain and * allows every origin. The origins are reloadable
+func CORSMiddleware(settings *config.Reloader) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cfg := settings.Current()
+			allowedOrigins := cfg.CORSConfig.AllowedOrigins
+			allowCredentials := cfg.CORSConfig.AllowCredentials
+			maxAge := strconv.Itoa(cfg.CORSConfig.MaxAgeSeconds)
+			allowedHeaders := "Content-Type, Authorization, X-Request-ID, X-CSRF-Token, If-None-Match, If-Match"
+			if cfg.TenancyConfig.Enabled {
+				allowedHeaders += ", " + cfg.TenancyConfig.Header
+			}
+
 			origin := r.Header.Get("Origin")
+			if origin != "" {
+				w.Header().Add("Vary", "Origin") // prevent caching issues
+			}
 
 			// check if request origin is in the allowed list
-			if slices.Contains(allowedOrigins, origin) {
+			if origin != "" && config.OriginAllowed(allowedOrigins, origin) {
 				w.Header().Set("Access-Control-Allow-Origin", origin)
-				w.Header().Set("Vary", "Origin") // prevent caching issues
-				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+				w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, ETag")
+				if allowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if r.Method == http.MethodOptions && cfg.CORSConfig.MaxAgeSeconds > 0 {
+					w.Header().Set("Access-Control-Max-Age", maxAge)
+				}
 			}
 
 			// Handle preflight request
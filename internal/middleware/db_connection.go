@@ -56,6 +56,19 @@ func GetDBConnFromContext(ctx context.Context) (*pgxpool.Conn, error) {
 	return conn, nil
 }
 
+// ReleaseDBConnection gives the request's database connections, including
+// its read replica connection, back to their pools before the request ends.
+// Long lived handlers, like event streams, call it once they are done with
+// the database
+func ReleaseDBConnection(ctx context.Context) {
+	if conn, err := GetDBConnFromContext(ctx); err == nil {
+		conn.Release()
+	}
+	if rc, ok := ctx.Value(DBReplicaContextKey).(*replicaConnection); ok && rc.conn != nil {
+		rc.conn.Release()
+	}
+}
+
 // GetDBPoolFromContext retrieves the pgxpool.Pool from the request context.
 // Use this when you need to acquire multiple connections (e.g., for concurrent operations).
 func GetDBPoolFromContext(ctx context.Context) (*pgxpool.Pool, error) {
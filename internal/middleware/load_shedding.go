@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+)
+
+// Route group of the routes whose group has no limit of its own
+const defaultRouteGroup = "default"
+
+const ConcurrencySlotContextKey = "middleware.concurrency_slot"
+
+// Paths that are never shed so that probes keep telling how Verisafe is
+// doing while it is under load
+var unshedPaths = map[string]bool{
+	"/ping":    true,
+	"/health":  true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// LoadShedding turns requests away with 503 Service Unavailable and a
+// Retry-After header instead of letting them queue up when Verisafe is
+// overloaded, so that a traffic spike does not slow every request down until
+// clients time out and retry.
+//
+// Requests are shed when their route group is already serving as many
+// requests as its concurrency limit allows, or when the share of database
+// connections in use reached the configured pool saturation. Route groups are
+// named after the first segment of the path after /api/v1, e.g. roles for
+// /api/v1/roles/{id}.
+//
+// The limits are reloadable. Requests served when they are reloaded keep
+// counting towards the previous limits until they end.
+//
+// The middleware must run before WithDBConnection, which waits for a
+// connection to become available
+func LoadShedding(settings *config.Reloader, pool *pgxpool.Pool, logger *slog.Logger) Middleware {
+	var limits atomic.Pointer[sheddingLimits]
+	limits.Store(newSheddingLimits(settings.Current()))
+	settings.OnReload(func(cfg *config.Config) {
+		limits.Store(newSheddingLimits(cfg))
+	})
+
+	shed := func(w http.ResponseWriter, r *http.Request, retryAfter, group, reason string) {
+		metrics.ShedRequests.WithLabelValues(group, reason).Inc()
+		logger.Warn("Shedding request",
+			slog.String("request_id", GetRequestID(r.Context())),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("group", group),
+			slog.String("reason", reason),
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", retryAfter)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "We are handling too many requests at the moment please try again shortly",
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if unshedPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			current := limits.Load()
+			group := routeGroup(r.URL.Path)
+			groupSlots, ok := current.slots[group]
+			if !ok {
+				group = defaultRouteGroup
+				groupSlots = current.slots[group]
+			}
+
+			if poolSaturated(pool, current.poolSaturation) {
+				shed(w, r, current.retryAfter, group, "db_pool")
+				return
+			}
+
+			// Groups without slots are unlimited
+			if groupSlots != nil {
+				select {
+				case groupSlots <- struct{}{}:
+					release := sync.OnceFunc(func() { <-groupSlots })
+					defer release()
+					r = r.WithContext(context.WithValue(r.Context(), ConcurrencySlotContextKey, release))
+				default:
+					shed(w, r, current.retryAfter, group, "concurrency")
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// sheddingLimits are the limits requests are shed at
+type sheddingLimits struct {
+	// Every group has a buffered channel holding a slot per request it may
+	// serve at once. Groups that are not configured share the default slots
+	// so that made up paths cannot create groups of their own
+	slots          map[string]chan struct{}
+	poolSaturation float64
+	retryAfter     string
+}
+
+func newSheddingLimits(cfg *config.Config) *sheddingLimits {
+	sheddingConfig := cfg.LoadSheddingConfig
+	limits := &sheddingLimits{
+		slots:          map[string]chan struct{}{},
+		poolSaturation: sheddingConfig.PoolSaturation,
+		retryAfter:     strconv.Itoa(max(sheddingConfig.RetryAfterSeconds, 1)),
+	}
+	for group, limit := range sheddingConfig.ConcurrencyLimits {
+		limits.slots[group] = make(chan struct{}, limit)
+	}
+	if limit := sheddingConfig.DefaultConcurrencyLimit; limit > 0 {
+		limits.slots[defaultRouteGroup] = make(chan struct{}, limit)
+	}
+	return limits
+}
+
+// ReleaseConcurrencySlot gives the request's slot back to its route group
+// before the request ends. Long lived connections, like event streams and
+// WebSockets, call it so that they do not count towards the concurrency
+// limit for as long as they stay open
+func ReleaseConcurrencySlot(ctx context.Context) {
+	if release, ok := ctx.Value(ConcurrencySlotContextKey).(func()); ok {
+		release()
+	}
+}
+
+// routeGroup returns the first segment of a path after /api/v1, the legacy
+// unversioned paths belong to the same groups as their successors
+func routeGroup(path string) string {
+	path = strings.TrimPrefix(path, "/api/v1")
+	group, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return group
+}
+
+// poolSaturated reports whether the share of database connections in use
+// reached the saturation. A saturation of zero is never reached
+func poolSaturated(pool *pgxpool.Pool, saturation float64) bool {
+	if pool == nil || saturation <= 0 {
+		return false
+	}
+	stat := pool.Stat()
+	if stat.MaxConns() <= 0 {
+		return false
+	}
+	return float64(stat.AcquiredConns()) >= saturation*float64(stat.MaxConns())
+}
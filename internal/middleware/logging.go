@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/logs"
 )
 
 // wrappedWriter is a custom http.ResponseWriter implementation used to capture
@@ -29,6 +31,12 @@ func (w *wrappedWriter) WriteHeader(statusCode int) {
 	w.statusCode = statusCode                // Store the status code for logging.
 }
 
+// Unwrap returns the original http.ResponseWriter so that
+// http.ResponseController can reach it, e.g. to flush event streams.
+func (w *wrappedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // Logging is a middleware function that logs details about incoming HTTP requests
 // and their corresponding responses.
 //
@@ -77,14 +85,23 @@ func logging(logger *slog.Logger, next http.Handler) http.Handler {
 		// - `r.Method`: The HTTP method (GET, POST, etc.).
 		// - `r.URL.Path`: The path component of the request URL.
 		// - `time.Since(start)`: The duration the request took to process.
-		logger.Info(
+		// Requests served without an error make for most of the logs, only
+		// a share of them may be kept
+		ctx := r.Context()
+		if wrapped.statusCode < http.StatusBadRequest {
+			ctx = logs.Sampled(ctx)
+		}
+		logger.InfoContext(
+			ctx,
 			"Request handled",
+			slog.String("request_id", w.Header().Get(RequestIDHeader)),
 			slog.String("method", r.Method),
 			slog.String("path", r.URL.Path),
 			slog.String("host", r.Host),
 			slog.Int64("duration_ns", end.Nanoseconds()),
 			slog.Int("status", wrapped.statusCode),
 			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("client_ip", getClientIP(r)),
 		)
 	})
 }
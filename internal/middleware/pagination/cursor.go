@@ -0,0 +1,157 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrInvalidCursor is returned when a cursor was not issued by Verisafe or
+// was tampered with
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the last row of a page. Keyset paginated queries order
+// their rows newest first by creation time and then by id, so that rows
+// created at the same instant still have a stable order, and continue after
+// the row the cursor points at
+type Cursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// NewCursor returns the cursor pointing at a row
+func NewCursor(createdAt pgtype.Timestamp, id uuid.UUID) Cursor {
+	return Cursor{CreatedAt: createdAt.Time, ID: id}
+}
+
+// Encode returns the opaque form of the cursor handed to clients
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor returned by Encode
+func DecodeCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// CursorParams are the parameters of a keyset paginated request. After is
+// nil for the first page
+type CursorParams struct {
+	Limit int
+	After *Cursor
+}
+
+// AfterCreatedAt returns the creation time of the row the page continues
+// after, invalid for the first page, as passed to keyset paginated queries
+func (p CursorParams) AfterCreatedAt() pgtype.Timestamp {
+	if p.After == nil {
+		return pgtype.Timestamp{}
+	}
+	return pgtype.Timestamp{Time: p.After.CreatedAt, Valid: true}
+}
+
+// AfterID returns the id of the row the page continues after, invalid for
+// the first page, as passed to keyset paginated queries
+func (p CursorParams) AfterID() pgtype.UUID {
+	if p.After == nil {
+		return pgtype.UUID{}
+	}
+	return pgtype.UUID{Bytes: p.After.ID, Valid: true}
+}
+
+// FetchLimit returns how many rows to query, one more than the page holds
+// to tell whether another page follows
+func (p CursorParams) FetchLimit() int32 {
+	return int32(p.Limit + 1)
+}
+
+// CursorPaginatedResponse is the response of keyset paginated endpoints.
+// Next is null on the last page
+type CursorPaginatedResponse struct {
+	Next       *string `json:"next"`
+	NextCursor *string `json:"next_cursor"`
+	Results    any     `json:"results"`
+}
+
+// UsesCursor reports whether a request asked for keyset pagination by
+// sending the cursor parameter, empty for the first page. Endpoints that
+// support both schemes keep paging by offset otherwise
+func UsesCursor(r *http.Request) bool {
+	return r.URL.Query().Has("cursor")
+}
+
+// ParseCursorParams extracts the `cursor` and `limit` parameters of a keyset
+// paginated request. Limits out of bounds fall back to defaultLimit, an
+// invalid cursor is an error the client should be told about
+func ParseCursorParams(r *http.Request, defaultLimit, maxLimit int) (CursorParams, error) {
+	params := CursorParams{Limit: defaultLimit}
+
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= maxLimit {
+			params.Limit = parsed
+		}
+	}
+
+	if encoded := r.URL.Query().Get("cursor"); encoded != "" {
+		after, err := DecodeCursor(encoded)
+		if err != nil {
+			return CursorParams{}, err
+		}
+		params.After = &after
+	}
+	return params, nil
+}
+
+// CursorPage trims the extra row a keyset paginated query fetched and
+// returns the rows of the page along with the cursor of the next page, nil
+// when this is the last one
+func CursorPage[T any](rows []T, params CursorParams, cursorOf func(T) Cursor) ([]T, *Cursor) {
+	if len(rows) <= params.Limit {
+		return rows, nil
+	}
+
+	rows = rows[:params.Limit]
+	next := cursorOf(rows[len(rows)-1])
+	return rows, &next
+}
+
+// BuildCursorResponse creates the response of a keyset paginated endpoint,
+// linking to the page after next when there is one
+func BuildCursorResponse(r *http.Request, results any, params CursorParams, next *Cursor) CursorPaginatedResponse {
+	response := CursorPaginatedResponse{Results: results}
+	if next == nil {
+		return response
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	encoded := next.Encode()
+	nextQuery := r.URL.Query()
+	nextQuery.Set("cursor", encoded)
+	nextQuery.Set("limit", strconv.Itoa(params.Limit))
+	nextURL := fmt.Sprintf("%s://%s%s?%s", scheme, r.Host, r.URL.Path, nextQuery.Encode())
+
+	response.Next = &nextURL
+	response.NextCursor = &encoded
+	return response
+}
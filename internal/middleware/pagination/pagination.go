@@ -79,3 +79,40 @@ func BuildPaginatedResponse(r *http.Request, totalCount int64, results any, para
 		Results:  results,
 	}
 }
+
+// BuildLimitOffsetResponse creates a DRF-style response for endpoints that
+// page with `limit` and `offset` instead of `page` and `page_size`
+func BuildLimitOffsetResponse(r *http.Request, totalCount int64, results any, limit, offset int) PaginatedResponse {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	baseURL := fmt.Sprintf("%s://%s%s", scheme, r.Host, r.URL.Path)
+
+	var next *string
+	var previous *string
+
+	if int64(offset+limit) < totalCount {
+		nextQuery := r.URL.Query()
+		nextQuery.Set("limit", strconv.Itoa(limit))
+		nextQuery.Set("offset", strconv.Itoa(offset+limit))
+		nextURL := fmt.Sprintf("%s?%s", baseURL, nextQuery.Encode())
+		next = &nextURL
+	}
+
+	if offset > 0 {
+		prevOffset := max(offset-limit, 0)
+		prevQuery := r.URL.Query()
+		prevQuery.Set("limit", strconv.Itoa(limit))
+		prevQuery.Set("offset", strconv.Itoa(prevOffset))
+		prevURL := fmt.Sprintf("%s?%s", baseURL, prevQuery.Encode())
+		previous = &prevURL
+	}
+
+	return PaginatedResponse{
+		Count:    totalCount,
+		Next:     next,
+		Previous: previous,
+		Results:  results,
+	}
+}
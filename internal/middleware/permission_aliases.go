@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/utils"
+)
+
+const PolicyEngineContextKey = "authz.middlewares.policy_engine"
+
+// WithPolicyEngine makes the policy engine available to the permission checks
+// so that routes still naming a renamed permission keep working
+func WithPolicyEngine(engine *authz.Engine) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), PolicyEngineContextKey, engine)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetPolicyEngine retrieves the policy engine from the request context. The
+// returned engine may be nil
+func GetPolicyEngine(ctx context.Context) *authz.Engine {
+	engine, _ := ctx.Value(PolicyEngineContextKey).(*authz.Engine)
+	return engine
+}
+
+// resolvePermissions returns the permissions with the deprecated names among
+// them replaced through the aliases of engine, which may be nil
+func resolvePermissions(ctx context.Context, engine *authz.Engine, permissions ...string) []string {
+	if engine == nil {
+		return permissions
+	}
+
+	var caller string
+	if claims, ok := ctx.Value(AuthUserClaims).(*utils.VerisafeClaims); ok {
+		caller = claims.Subject
+	}
+
+	resolved := make([]string, len(permissions))
+	for i, permission := range permissions {
+		resolved[i] = engine.ResolvePermission(permission, caller)
+	}
+	return resolved
+}
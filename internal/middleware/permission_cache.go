@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/authz"
+)
+
+const PermissionCacheContextKey = "authz.middlewares.permission_cache"
+
+// WithPermissionCache makes the permission cache available to IsAuthenticated
+// and to handlers that need to invalidate it
+func WithPermissionCache(cache *authz.PermissionCache) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), PermissionCacheContextKey, cache)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetPermissionCache retrieves the permission cache from the request context.
+// The returned cache may be nil which is safe to use and caches nothing
+func GetPermissionCache(ctx context.Context) *authz.PermissionCache {
+	cache, _ := ctx.Value(PermissionCacheContextKey).(*authz.PermissionCache)
+	return cache
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/authz"
+)
+
+const UsageTrackerContextKey = "authz.middlewares.usage_tracker"
+
+// WithUsageTracker makes the permission usage tracker available to the
+// permission middlewares and to the handler reporting the usage
+func WithUsageTracker(tracker *authz.UsageTracker) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), UsageTrackerContextKey, tracker)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetUsageTracker retrieves the usage tracker from the request context. The
+// returned tracker may be nil which is safe to use and records nothing
+func GetUsageTracker(ctx context.Context) *authz.UsageTracker {
+	tracker, _ := ctx.Value(UsageTrackerContextKey).(*authz.UsageTracker)
+	return tracker
+}
+
+// recordUsage counts the permissions a request was let through with against
+// the route pattern it matched
+func recordUsage(r *http.Request, permissions ...string) {
+	tracker := GetUsageTracker(r.Context())
+	for _, permission := range permissions {
+		tracker.Record(permission, r.Pattern)
+	}
+}
@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/authz"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// PolicyResource names the request values identifying the resource a policy
+// is evaluated against. Each value is read from the path value of that name
+// falling back to the query parameter of the same name. Empty names are
+// ignored
+type PolicyResource struct {
+	// Holds the id of the account owning the resource
+	OwnerParam string
+	// Holds the id of the institution the resource belongs to
+	InstitutionParam string
+}
+
+// requestValue reads a path value falling back to the query parameter of the
+// same name
+func requestValue(r *http.Request, name string) string {
+	if name == "" {
+		return ""
+	}
+	if value := r.PathValue(name); value != "" {
+		return value
+	}
+	return r.URL.Query().Get(name)
+}
+
+// grantedByPolicy reports whether an authorization policy of engine grants the
+// caller the permission for the requested resource. Nothing is granted when
+// engine is nil or the caller's account is unknown
+func grantedByPolicy(r *http.Request, engine *authz.Engine, permission string, resource PolicyResource) (bool, error) {
+	account, ok := r.Context().Value(AuthUserAccount).(repository.Account)
+	if engine == nil || !ok {
+		return false, nil
+	}
+
+	attrs := authz.Attributes{
+		SubjectID:   account.ID,
+		SubjectType: account.Type,
+	}
+	if ownerID, err := uuid.Parse(requestValue(r, resource.OwnerParam)); err == nil {
+		attrs.ResourceOwnerID = &ownerID
+	}
+	if institutionID, err := strconv.ParseInt(requestValue(r, resource.InstitutionParam), 10, 32); err == nil {
+		id := int32(institutionID)
+		attrs.InstitutionID = &id
+	}
+
+	conn, err := GetDBConnFromContext(r.Context())
+	if err != nil {
+		return false, err
+	}
+	return engine.Evaluate(r.Context(), conn, permission, attrs)
+}
+
+// Checks whether the caller holds the permission through their roles or is
+// granted it by an authorization policy for the requested resource.
+// IsAuthenticated must be called before invoking this middleware
+func Authorize(engine *authz.Engine, logger *slog.Logger, permission string, resource PolicyResource) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permission := resolvePermissions(r.Context(), engine, permission)[0]
+			var perms []string
+			if permsVal := r.Context().Value(AuthUserPerms); permsVal != nil {
+				perms = permsVal.([]string)
+			}
+			// Denials win over roles and policies alike
+			if authz.IsDenied(GetDeniedPermissions(r.Context()), permission) {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]any{
+					"error": "You do not have the necessary permissions to perform this action",
+				})
+				return
+			}
+			if authz.HasPermission(perms, permission) {
+				recordUsage(r, permission)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, err := grantedByPolicy(r, engine, permission, resource)
+			if err != nil {
+				logger.Error("Failed to evaluate authorization policies",
+					slog.Any("error", err),
+					slog.String("permission", permission),
+				)
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(map[string]any{"error": "Internal server error"})
+				return
+			}
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]any{
+					"error": "You do not have the necessary permissions to perform this action",
+				})
+				return
+			}
+
+			recordUsage(r, permission)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
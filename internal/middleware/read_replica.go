@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const DBReplicaContextKey = "db.middlewares.replica"
+const DBPrimaryReadsContextKey = "db.middlewares.primary_reads"
+
+// replicaConnection holds the read replica connection of a request. It is
+// only acquired once the request reads from the replica
+type replicaConnection struct {
+	pool   *pgxpool.Pool
+	logger *slog.Logger
+
+	once sync.Once
+	conn *pgxpool.Conn
+}
+
+// acquire returns the replica connection of the request, acquiring it on
+// first use. nil is returned when the replica cannot be reached
+func (rc *replicaConnection) acquire(ctx context.Context) *pgxpool.Conn {
+	rc.once.Do(func() {
+		conn, err := rc.pool.Acquire(ctx)
+		if err != nil {
+			rc.logger.Warn("Failed to acquire read replica connection, reading from the primary",
+				slog.Any("error", err),
+			)
+			return
+		}
+		rc.conn = conn
+	})
+	return rc.conn
+}
+
+// WithReadReplica lets the read-only queries of GET and HEAD requests go to
+// the read replica, see GetReadDBConnFromContext. Other requests always read
+// from the primary so that they see their own writes. A nil pool leaves
+// every read on the primary
+func WithReadReplica(logger *slog.Logger, replica *pgxpool.Pool) Middleware {
+	return func(next http.Handler) http.Handler {
+		if replica == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rc := &replicaConnection{pool: replica, logger: logger}
+			defer func() {
+				if rc.conn != nil {
+					rc.conn.Release()
+				}
+			}()
+
+			ctx := context.WithValue(r.Context(), DBReplicaContextKey, rc)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// WithPrimaryReads returns a context whose reads go to the primary even
+// where GetReadDBConnFromContext would use the replica, for reads that must
+// not lag behind e.g. right after a write
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, DBPrimaryReadsContextKey, true)
+}
+
+// PrimaryReads has every read of the routes it wraps go to the primary
+func PrimaryReads() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithPrimaryReads(r.Context())))
+		})
+	}
+}
+
+// GetReadDBConnFromContext retrieves the connection read-only queries, like
+// searches, listings and leaderboards, should use. It is a read replica
+// connection when a replica is configured and the request may read from it,
+// and the request's primary connection otherwise. Replicas lag behind the
+// primary, never write through this connection nor read what the request
+// just wrote from it
+func GetReadDBConnFromContext(ctx context.Context) (*pgxpool.Conn, error) {
+	if primary, _ := ctx.Value(DBPrimaryReadsContextKey).(bool); !primary {
+		if rc, ok := ctx.Value(DBReplicaContextKey).(*replicaConnection); ok {
+			if conn := rc.acquire(ctx); conn != nil {
+				return conn, nil
+			}
+		}
+	}
+	return GetDBConnFromContext(ctx)
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/requestaudit"
+)
+
+const RequestAuditContextKey = "middleware.request_audit"
+
+// auditActor is filled in by IsAuthenticated so that AuditMutations, which
+// runs outside of it, learns who made the request
+type auditActor struct {
+	id *uuid.UUID
+}
+
+// AuditMutations records every POST, PUT, PATCH and DELETE request served by
+// a route in the request audit log, with the account that made it, the route
+// pattern it matched, its path parameters, its status and how long it took.
+// Entries are written in the background and never hold the response up.
+//
+// The middleware must wrap the router directly, it reads the route pattern
+// and path parameters the router sets on the request. Requests no route
+// matched are not recorded. Nothing is recorded when recorder is nil
+func AuditMutations(recorder *requestaudit.Recorder) Middleware {
+	return func(next http.Handler) http.Handler {
+		if recorder == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !isMutation(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			actor := &auditActor{}
+			r = r.WithContext(context.WithValue(r.Context(), RequestAuditContextKey, actor))
+			wrapped := &wrappedWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r)
+
+			if r.Pattern == "" {
+				return
+			}
+
+			resourceIDs := map[string]string{}
+			for _, name := range PatternWildcards(r.Pattern) {
+				if value := r.PathValue(name); value != "" {
+					resourceIDs[name] = value
+				}
+			}
+
+			recorder.Record(requestaudit.Entry{
+				RequestID:   GetRequestID(r.Context()),
+				ActorID:     actor.id,
+				Method:      r.Method,
+				Route:       r.Pattern,
+				Path:        r.URL.Path,
+				ResourceIDs: resourceIDs,
+				Status:      wrapped.statusCode,
+				Latency:     time.Since(start),
+				IPAddress:   getClientIP(r),
+				UserAgent:   r.UserAgent(),
+				OccurredAt:  start,
+			})
+		})
+	}
+}
+
+// PatternWildcards returns the names of the wildcards of a route pattern,
+// e.g. id and department_id for GET /institutions/{id}/{department_id}
+func PatternWildcards(pattern string) []string {
+	var names []string
+	for {
+		_, rest, found := strings.Cut(pattern, "{")
+		if !found {
+			return names
+		}
+		name, after, found := strings.Cut(rest, "}")
+		if !found {
+			return names
+		}
+		name = strings.TrimSuffix(name, "...")
+		if name != "" && name != "$" {
+			names = append(names, name)
+		}
+		pattern = after
+	}
+}
+
+// setAuditActor tells AuditMutations which account made the request
+func setAuditActor(ctx context.Context, accountID uuid.UUID) {
+	if actor, ok := ctx.Value(RequestAuditContextKey).(*auditActor); ok {
+		actor.id = &accountID
+	}
+}
+
+// isMutation reports whether requests of the method change state
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+// Header carrying the ID of a request across services
+const RequestIDHeader = "X-Request-ID"
+
+const RequestIDContextKey = "middleware.request_id"
+
+// Longest incoming request ID that is propagated, longer ones are replaced
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID so that it can be traced across
+// services. The X-Request-ID of the incoming request is kept when it has one,
+// otherwise a new ID is generated. The ID is returned in the X-Request-ID
+// response header, logged with the request and published with the events
+// the request causes
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = eventbus.GenerateRequestID()
+			}
+
+			w.Header().Set(RequestIDHeader, requestID)
+			ctx := context.WithValue(r.Context(), RequestIDContextKey, requestID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetRequestID retrieves the ID of the request from the context. A new ID is
+// generated when the context carries none, e.g. outside of a request
+func GetRequestID(ctx context.Context) string {
+	if requestID, ok := ctx.Value(RequestIDContextKey).(string); ok {
+		return requestID
+	}
+	return eventbus.GenerateRequestID()
+}
+
+// validRequestID reports whether an incoming request ID is safe to log and
+// return, i.e. short and made of printable ASCII only
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/opencrafts-io/verisafe/internal/eventbus"
+)
+
+const SecurityEventBusContextKey = "middleware.security_event_bus"
+
+// WithSecurityEventBus makes the security event bus available to the
+// middlewares and handlers reporting security events
+func WithSecurityEventBus(bus *eventbus.SecurityEventBus) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), SecurityEventBusContextKey, bus)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetSecurityEventBus retrieves the security event bus from the request
+// context. The returned bus may be nil which is safe to use and reports
+// nothing
+func GetSecurityEventBus(ctx context.Context) *eventbus.SecurityEventBus {
+	bus, _ := ctx.Value(SecurityEventBusContextKey).(*eventbus.SecurityEventBus)
+	return bus
+}
+
+// ReportSecurityEvent reports a security event about a request in the
+// background, adding the client's IP address, its country and ASN and the
+// user agent to the activity and publishing it with the request's ID
+func ReportSecurityEvent(r *http.Request, eventType string, activity eventbus.SecurityActivity) {
+	client := GetClientInfo(r)
+	activity.IPAddress = client.IP
+	activity.Country = client.Country
+	activity.ASN = client.ASN
+	activity.UserAgent = r.Header.Get("User-Agent")
+	GetSecurityEventBus(r.Context()).Report(eventType, activity, GetRequestID(r.Context()))
+}
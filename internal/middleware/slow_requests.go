@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/opencrafts-io/verisafe/internal/dbstats"
+	"github.com/opencrafts-io/verisafe/internal/metrics"
+)
+
+// SlowRequests logs the requests that take longer than threshold along with
+// how many database queries they made and how long those took, and counts
+// them in the verisafe_http_slow_requests_total metric. A request making many
+// queries usually looks up something per item it returns.
+//
+// The middleware must wrap the router directly, it labels requests with the
+// route pattern the router sets on them. Nothing is detected when threshold
+// is zero
+func SlowRequests(logger *slog.Logger, threshold time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		if threshold <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx, counter := dbstats.WithCounter(r.Context())
+			r = r.WithContext(ctx)
+			wrapped := &wrappedWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r)
+
+			// Event streams and WebSockets are meant to last
+			elapsed := time.Since(start)
+			if elapsed <= threshold ||
+				w.Header().Get("Content-Type") == "text/event-stream" ||
+				wrapped.statusCode == http.StatusSwitchingProtocols {
+				return
+			}
+
+			route := r.Pattern
+			if route == "" {
+				route = "unmatched"
+			}
+			metrics.SlowRequests.WithLabelValues(r.Method, route).Inc()
+			metrics.SlowRequestQueries.WithLabelValues(r.Method, route).Observe(float64(counter.Queries()))
+
+			logger.Warn("Slow request",
+				slog.String("request_id", GetRequestID(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Int64("duration_ms", elapsed.Milliseconds()),
+				slog.Int64("threshold_ms", threshold.Milliseconds()),
+				slog.Int64("queries", counter.Queries()),
+				slog.Int64("query_duration_ms", counter.Duration().Milliseconds()),
+			)
+		})
+	}
+}
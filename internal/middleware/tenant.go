@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+)
+
+// WithTenant resolves the tenant a request is made to when tenancy is
+// enabled: the tenant of the host it was made to, else the tenant named in
+// the tenant header, else the default tenant. Requests naming a tenant the
+// instance does not serve are rejected with 400.
+//
+// The database connections of the request are restricted to the accounts of
+// the tenant when they are acquired, see tenancy.ConfigurePool, so it must run
+// before WithDBConnection
+func WithTenant(cfg *config.Config) Middleware {
+	return func(next http.Handler) http.Handler {
+		if !cfg.TenancyConfig.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenant := requestTenant(cfg, r)
+			if !cfg.IsTenant(tenant) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error": "Unknown tenant",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenancy.WithTenant(r.Context(), tenant)))
+		})
+	}
+}
+
+// requestTenant returns the tenant a request names
+func requestTenant(cfg *config.Config, r *http.Request) string {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if tenant, ok := cfg.TenancyConfig.Hosts[strings.ToLower(host)]; ok {
+		return tenant
+	}
+	if tenant := strings.TrimSpace(r.Header.Get(cfg.TenancyConfig.Header)); tenant != "" {
+		return tenant
+	}
+	return cfg.TenancyConfig.DefaultTenant
+}
+
+// GetTenant returns the tenant a request is made to, empty when tenancy is
+// disabled
+func GetTenant(ctx context.Context) string {
+	return tenancy.FromContext(ctx)
+}
+
+// SwitchTenant moves a request to another tenant, restricting its database
+// connection to the accounts of that tenant from then on. It is meant for
+// requests that learn their tenant late, like OAuth callbacks which carry
+// it in their state
+func SwitchTenant(r *http.Request, tenant string) (*http.Request, error) {
+	ctx := tenancy.WithTenant(r.Context(), tenant)
+	if conn, err := GetDBConnFromContext(ctx); err == nil {
+		if err := applyTenant(ctx, conn); err != nil {
+			return r, err
+		}
+	}
+	return r.WithContext(ctx), nil
+}
+
+// applyTenant restricts a connection already acquired to the accounts of the
+// tenant of the request, see verisafe_current_tenant
+func applyTenant(ctx context.Context, conn *pgxpool.Conn) error {
+	tenant := GetTenant(ctx)
+	if tenant == "" {
+		return nil
+	}
+	return repository.New(conn).SetCurrentTenant(ctx, tenant)
+}
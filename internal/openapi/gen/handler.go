@@ -0,0 +1,568 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const paginationPath = middlewarePath + "/pagination"
+
+// How deep calls are followed into the helpers a handler passes its
+// ResponseWriter or Request to
+const maxCallDepth = 3
+
+// handlerInfo is what a handler tells about the requests it takes and the
+// responses it writes
+type handlerInfo struct {
+	requestBody types.Type
+	queryParams map[string]any
+	pathParams  map[string]any
+
+	// Responses by status. A nil schema is a response without a body
+	responses map[int][]any
+	// Statuses responded with an error
+	errors map[int]bool
+	// Statuses responded with a plain text error
+	textErrors map[int]bool
+	// Whether bad requests name the invalid fields of the request body
+	validated bool
+	// Whether the successful response is a stream of Server-Sent Events
+	eventStream bool
+	// Whether updates take the version they expect in If-Match
+	ifMatch bool
+}
+
+// analyzer follows a handler and the helpers it calls
+type analyzer struct {
+	g       *generator
+	h       *handlerInfo
+	visited map[string]bool
+}
+
+// analyzeRoute reads what the handler of a route takes and writes
+func (g *generator) analyzeRoute(rt route) *handlerInfo {
+	h := &handlerInfo{
+		queryParams: map[string]any{},
+		pathParams:  map[string]any{},
+		responses:   map[int][]any{},
+		errors:      map[int]bool{},
+		textErrors:  map[int]bool{},
+	}
+	if rt.handler == nil {
+		return h
+	}
+	a := &analyzer{g: g, h: h, visited: map[string]bool{}}
+	a.function(*rt.handler, 0)
+	return h
+}
+
+// addError records an error response
+func (h *handlerInfo) addError(status int) {
+	h.errors[status] = true
+}
+
+// addResponse records a response, schemas already recorded for the status
+// are not recorded again
+func (h *handlerInfo) addResponse(status int, schema any) {
+	for _, known := range h.responses[status] {
+		if (known == nil && schema == nil) || (known != nil && schema != nil && mustJSON(known) == mustJSON(schema)) {
+			return
+		}
+	}
+	h.responses[status] = append(h.responses[status], schema)
+}
+
+// pathParamSchema returns the schema of a path parameter
+func (h *handlerInfo) pathParamSchema(name string) any {
+	if schema, ok := h.pathParams[name]; ok {
+		return schema
+	}
+	return map[string]any{"type": "string"}
+}
+
+// function follows the body of a function
+func (a *analyzer) function(fn funcDecl, depth int) {
+	key := fn.pkg.PkgPath + "." + receiverName(fn.decl) + "." + fn.decl.Name.Name
+	if a.visited[key] || fn.decl.Body == nil {
+		return
+	}
+	a.visited[key] = true
+
+	f := &funcAnalyzer{
+		analyzer:    a,
+		info:        fn.pkg.TypesInfo,
+		depth:       depth,
+		assignments: assignments(fn.pkg.TypesInfo, fn.decl.Body),
+		hints:       map[ast.Expr]any{},
+	}
+	f.collectHints(fn.decl.Body)
+	f.stmts(fn.decl.Body.List, http.StatusOK)
+}
+
+// funcAnalyzer follows the statements of a single function, keeping track
+// of the status written so far
+type funcAnalyzer struct {
+	*analyzer
+	info  *types.Info
+	depth int
+
+	// The expression last assigned to each variable
+	assignments map[types.Object]ast.Expr
+	// Schemas of path values and query parameters parsed into other types
+	hints map[ast.Expr]any
+}
+
+// assignments maps the variables of a function body to the expression last
+// assigned to them
+func assignments(info *types.Info, body *ast.BlockStmt) map[types.Object]ast.Expr {
+	assigned := map[types.Object]ast.Expr{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) != len(n.Rhs) {
+				return true
+			}
+			for i, lhs := range n.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					if obj := objectOf(info, ident); obj != nil {
+						assigned[obj] = n.Rhs[i]
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			if len(n.Names) != len(n.Values) {
+				return true
+			}
+			for i, name := range n.Names {
+				if obj := objectOf(info, name); obj != nil {
+					assigned[obj] = n.Values[i]
+				}
+			}
+		}
+		return true
+	})
+	return assigned
+}
+
+func objectOf(info *types.Info, ident *ast.Ident) types.Object {
+	if obj := info.Defs[ident]; obj != nil {
+		return obj
+	}
+	return info.Uses[ident]
+}
+
+func (f *funcAnalyzer) stmts(list []ast.Stmt, status int) int {
+	for _, stmt := range list {
+		status = f.stmt(stmt, status)
+	}
+	return status
+}
+
+// stmt follows a statement and returns the status written once it ran.
+// Statuses written in nested blocks do not outlive them
+func (f *funcAnalyzer) stmt(stmt ast.Stmt, status int) int {
+	switch stmt := stmt.(type) {
+	case *ast.BlockStmt:
+		f.stmts(stmt.List, status)
+	case *ast.IfStmt:
+		if stmt.Init != nil {
+			status = f.stmt(stmt.Init, status)
+		}
+		f.node(stmt.Cond, status)
+		f.stmts(stmt.Body.List, status)
+		if stmt.Else != nil {
+			f.stmt(stmt.Else, status)
+		}
+	case *ast.ForStmt:
+		f.stmts(stmt.Body.List, status)
+	case *ast.RangeStmt:
+		f.node(stmt.X, status)
+		f.stmts(stmt.Body.List, status)
+	case *ast.SwitchStmt:
+		if stmt.Init != nil {
+			status = f.stmt(stmt.Init, status)
+		}
+		if stmt.Tag != nil {
+			f.node(stmt.Tag, status)
+		}
+		f.clauses(stmt.Body, status)
+	case *ast.TypeSwitchStmt:
+		if stmt.Init != nil {
+			status = f.stmt(stmt.Init, status)
+		}
+		f.clauses(stmt.Body, status)
+	case *ast.SelectStmt:
+		f.clauses(stmt.Body, status)
+	case *ast.LabeledStmt:
+		return f.stmt(stmt.Stmt, status)
+	default:
+		return f.node(stmt, status)
+	}
+	return status
+}
+
+func (f *funcAnalyzer) clauses(body *ast.BlockStmt, status int) {
+	for _, clause := range body.List {
+		switch clause := clause.(type) {
+		case *ast.CaseClause:
+			for _, expr := range clause.List {
+				f.node(expr, status)
+			}
+			f.stmts(clause.Body, status)
+		case *ast.CommClause:
+			f.stmts(clause.Body, status)
+		}
+	}
+}
+
+// node follows the calls made by a simple statement or expression
+func (f *funcAnalyzer) node(n ast.Node, status int) int {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			f.stmts(n.Body.List, http.StatusOK)
+			return false
+		case *ast.CallExpr:
+			status = f.call(n, status)
+		}
+		return true
+	})
+	return status
+}
+
+// call records what a call tells about the request or the response and
+// returns the status written once it ran
+func (f *funcAnalyzer) call(call *ast.CallExpr, status int) int {
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		receiver := typeString(f.info.TypeOf(sel.X))
+		switch {
+		case receiver == "net/http.ResponseWriter" && sel.Sel.Name == "WriteHeader" && len(call.Args) == 1:
+			if code := intOf(f.info, call.Args[0]); code != 0 {
+				f.h.addStatus(code)
+				return code
+			}
+		case receiver == "*encoding/json.Encoder" && sel.Sel.Name == "Encode" && len(call.Args) == 1:
+			f.encode(call.Args[0], status)
+		case receiver == "net/http.Header" && sel.Sel.Name == "Set" && len(call.Args) == 2:
+			if stringOf(f.info, call.Args[0]) == "Content-Type" && stringOf(f.info, call.Args[1]) == "text/event-stream" {
+				f.h.eventStream = true
+			}
+		case receiver == "*encoding/json.Decoder" && sel.Sel.Name == "Decode" && len(call.Args) == 1:
+			if unary, ok := call.Args[0].(*ast.UnaryExpr); ok {
+				f.h.requestBody = f.info.TypeOf(unary.X)
+			}
+		case receiver == "net/url.Values" && sel.Sel.Name == "Get" && len(call.Args) == 1:
+			if name := stringOf(f.info, call.Args[0]); name != "" {
+				f.h.queryParams[name] = f.hint(call)
+			}
+		case receiver == "*net/http.Request" && sel.Sel.Name == "PathValue" && len(call.Args) == 1:
+			if name := stringOf(f.info, call.Args[0]); name != "" {
+				schema := f.hint(call)
+				if _, ok := f.h.pathParams[name]; !ok || mustJSON(schema) != `{"type":"string"}` {
+					f.h.pathParams[name] = schema
+				}
+			}
+		}
+	}
+
+	callee := calleeOf(f.info, call)
+	if callee == nil || callee.Pkg() == nil {
+		return status
+	}
+	switch callee.Pkg().Path() + "." + callee.Name() {
+	case "net/http.Error":
+		if len(call.Args) == 3 {
+			if code := intOf(f.info, call.Args[2]); code != 0 {
+				f.h.textErrors[code] = true
+			}
+		}
+	case "net/http.Redirect":
+		if len(call.Args) == 4 {
+			if code := intOf(f.info, call.Args[3]); code != 0 {
+				f.h.addStatus(code)
+			}
+		}
+	case "github.com/coder/websocket.Accept":
+		f.h.addStatus(http.StatusSwitchingProtocols)
+		return http.StatusSwitchingProtocols
+	case utilsPath + ".DecodeAndValidate":
+		if len(call.Args) == 3 {
+			if unary, ok := call.Args[2].(*ast.UnaryExpr); ok {
+				f.h.requestBody = f.info.TypeOf(unary.X)
+			}
+		}
+		f.h.validated = true
+	case utilsPath + ".WriteValidationErrors":
+		f.h.validated = true
+	case utilsPath + ".ExpectedVersion":
+		f.h.ifMatch = true
+	case utilsPath + ".PathUUID":
+		f.pathParam(call, map[string]any{"type": "string", "format": "uuid"})
+	case utilsPath + ".PathInt32", utilsPath + ".PathInt64":
+		f.pathParam(call, map[string]any{"type": "integer"})
+	case paginationPath + ".ParseCursorParams":
+		f.h.queryParams["cursor"] = map[string]any{"type": "string"}
+		f.h.queryParams["limit"] = map[string]any{"type": "integer", "minimum": 1}
+	case paginationPath + ".ParsePageParams":
+		f.h.queryParams["page"] = map[string]any{"type": "integer", "default": 1, "minimum": 1}
+		f.h.queryParams["page_size"] = map[string]any{"type": "integer", "default": 10, "minimum": 1, "maximum": 100}
+	}
+
+	// Helpers writing the response or reading the request are followed
+	if f.depth < maxCallDepth && strings.HasPrefix(callee.Pkg().Path(), modulePath) && f.passesRequest(call) {
+		if decl, ok := f.g.funcs[callee.FullName()]; ok {
+			f.analyzer.function(decl, f.depth+1)
+		}
+	}
+	return status
+}
+
+// pathParam records the schema of the path value a utils.Path helper parses.
+// Bad requests then name the malformed path value
+func (f *funcAnalyzer) pathParam(call *ast.CallExpr, schema any) {
+	if len(call.Args) == 3 {
+		if name := stringOf(f.info, call.Args[2]); name != "" {
+			f.h.pathParams[name] = schema
+		}
+	}
+	f.h.validated = true
+}
+
+// collectHints records the schemas of the path values and query parameters
+// a function parses into other types
+func (f *funcAnalyzer) collectHints(body *ast.BlockStmt) {
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		callee := calleeOf(f.info, call)
+		if callee == nil || callee.Pkg() == nil {
+			return true
+		}
+		switch callee.Pkg().Path() + "." + callee.Name() {
+		case "github.com/google/uuid.Parse", "github.com/google/uuid.MustParse":
+			f.hintArg(call, map[string]any{"type": "string", "format": "uuid"})
+		case "strconv.Atoi", "strconv.ParseInt", "strconv.ParseUint":
+			f.hintArg(call, map[string]any{"type": "integer"})
+		case "strconv.ParseBool":
+			f.hintArg(call, map[string]any{"type": "boolean"})
+		case "strconv.ParseFloat":
+			f.hintArg(call, map[string]any{"type": "number"})
+		case "time.Parse":
+			if len(call.Args) == 2 {
+				f.hints[ast.Unparen(call.Args[1])] = map[string]any{"type": "string", "format": "date-time"}
+			}
+		}
+		return true
+	})
+}
+
+// hintArg records the schema of the path value or query parameter a call
+// parses
+func (f *funcAnalyzer) hintArg(call *ast.CallExpr, schema any) {
+	if len(call.Args) > 0 {
+		f.hints[ast.Unparen(call.Args[0])] = schema
+	}
+}
+
+// hint returns the schema of a path value or query parameter, read either
+// from the call parsing it or from the variable it was assigned to
+func (f *funcAnalyzer) hint(call *ast.CallExpr) any {
+	if schema, ok := f.hints[call]; ok {
+		return schema
+	}
+	for obj, expr := range f.assignments {
+		if ast.Unparen(expr) != call {
+			continue
+		}
+		for parsed, schema := range f.hints {
+			if ident, ok := parsed.(*ast.Ident); ok && objectOf(f.info, ident) == obj {
+				return schema
+			}
+		}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// passesRequest reports whether a call is handed the ResponseWriter or the
+// Request
+func (f *funcAnalyzer) passesRequest(call *ast.CallExpr) bool {
+	for _, arg := range call.Args {
+		switch typeString(f.info.TypeOf(arg)) {
+		case "net/http.ResponseWriter", "*net/http.Request":
+			return true
+		}
+	}
+	return false
+}
+
+// addStatus records a status that may be written without a body
+func (h *handlerInfo) addStatus(status int) {
+	if status >= http.StatusBadRequest {
+		h.errors[status] = true
+		return
+	}
+	if _, ok := h.responses[status]; !ok {
+		h.responses[status] = nil
+	}
+}
+
+// encode records the response encoded from expr with the status
+func (f *funcAnalyzer) encode(expr ast.Expr, status int) {
+	if status >= http.StatusBadRequest {
+		f.h.addError(status)
+		return
+	}
+	f.h.addResponse(status, f.responseSchema(expr))
+}
+
+// responseSchema returns the schema of an encoded expression
+func (f *funcAnalyzer) responseSchema(expr ast.Expr) any {
+	expr = ast.Unparen(expr)
+
+	// Variables holding a paginated response or a map literal are followed
+	// to the expression building it, which tells what the response holds
+	if ident, ok := expr.(*ast.Ident); ok {
+		if assigned, ok := f.assignments[objectOf(f.info, ident)]; ok {
+			if _, isLit := ast.Unparen(assigned).(*ast.CompositeLit); isLit || f.isPaginatedResponse(assigned) {
+				expr = ast.Unparen(assigned)
+			}
+		}
+	}
+
+	switch expr := expr.(type) {
+	case *ast.CallExpr:
+		if f.isPaginatedResponse(expr) && len(expr.Args) > 2 {
+			if f.isCursorResponse(expr) {
+				return f.g.cursorPaginatedSchema(f.resultsType(expr.Args[1]))
+			}
+			return f.g.paginatedSchema(f.resultsType(expr.Args[2]))
+		}
+	case *ast.CompositeLit:
+		if _, ok := f.info.TypeOf(expr).Underlying().(*types.Map); ok {
+			return f.mapLiteralSchema(expr)
+		}
+	}
+	return f.g.schemaOf(f.info.TypeOf(expr))
+}
+
+// resultsType returns the type of the results of a paginated response,
+// following variables declared as any to the value assigned to them
+func (f *funcAnalyzer) resultsType(expr ast.Expr) types.Type {
+	t := f.info.TypeOf(expr)
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		if ident, ok := ast.Unparen(expr).(*ast.Ident); ok {
+			if assigned, ok := f.assignments[objectOf(f.info, ident)]; ok {
+				return f.info.TypeOf(assigned)
+			}
+		}
+	}
+	return t
+}
+
+// isPaginatedResponse reports whether expr builds a paginated response
+func (f *funcAnalyzer) isPaginatedResponse(expr ast.Expr) bool {
+	call, ok := ast.Unparen(expr).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	callee := calleeOf(f.info, call)
+	return callee != nil && callee.Pkg() != nil && callee.Pkg().Path() == paginationPath &&
+		strings.HasPrefix(callee.Name(), "Build")
+}
+
+// isCursorResponse reports whether a paginated response builder call builds
+// a keyset paginated response, whose results come right after the request
+func (f *funcAnalyzer) isCursorResponse(call *ast.CallExpr) bool {
+	callee := calleeOf(f.info, call)
+	return callee != nil && callee.Name() == "BuildCursorResponse"
+}
+
+// mapLiteralSchema describes a map literal with constant keys as an object
+// with these properties
+func (f *funcAnalyzer) mapLiteralSchema(lit *ast.CompositeLit) any {
+	properties := map[string]any{}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key := stringOf(f.info, kv.Key)
+		if key == "" {
+			return f.g.schemaOf(f.info.TypeOf(lit))
+		}
+		properties[key] = f.responseSchema(kv.Value)
+	}
+	return map[string]any{"type": "object", "properties": properties}
+}
+
+// responses describes every response a handler writes
+func (g *generator) responses(h *handlerInfo) map[string]any {
+	responses := map[string]any{}
+	statuses := make([]int, 0, len(h.responses))
+	for status := range h.responses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		schemas := h.responses[status]
+		response := map[string]any{"description": http.StatusText(status)}
+		var bodies []any
+		for _, schema := range schemas {
+			if schema != nil {
+				bodies = append(bodies, schema)
+			}
+		}
+		switch len(bodies) {
+		case 0:
+			if status == http.StatusOK && h.eventStream {
+				response["content"] = map[string]any{
+					"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}},
+				}
+			}
+		case 1:
+			response["content"] = jsonContent(bodies[0])
+		default:
+			response["content"] = jsonContent(map[string]any{"oneOf": bodies})
+		}
+		responses[statusKey(status)] = response
+	}
+	for status := range h.errors {
+		schema := g.errorSchema()
+		if status == http.StatusBadRequest && h.validated {
+			schema = g.validationErrorSchema()
+		}
+		responses[statusKey(status)] = map[string]any{
+			"description": http.StatusText(status),
+			"content":     jsonContent(schema),
+		}
+	}
+	for status := range h.textErrors {
+		if _, ok := responses[statusKey(status)]; ok {
+			continue
+		}
+		responses[statusKey(status)] = map[string]any{
+			"description": http.StatusText(status),
+			"content": map[string]any{
+				"text/plain": map[string]any{"schema": map[string]any{"type": "string"}},
+			},
+		}
+	}
+	if len(responses) == 0 {
+		responses["200"] = map[string]any{"description": http.StatusText(http.StatusOK)}
+	}
+	return responses
+}
+
+func jsonContent(schema any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+func statusKey(status int) string {
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,142 @@
+// Command gen writes the OpenAPI document describing Verisafe's HTTP API.
+//
+// Routes are read from the calls registering them on the router. The
+// middleware wrapping a route tells whether it needs authentication and
+// which permissions it needs, the handler serving it tells its request body,
+// its parameters and its responses. Doc comments on handlers become the
+// summaries and descriptions of their operations
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func main() {
+	out := flag.String("o", "openapi.json", "file the document is written to")
+	flag.Parse()
+
+	root, err := moduleRoot()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir: root,
+	}, "./...")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		os.Exit(1)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].PkgPath < pkgs[j].PkgPath })
+
+	document := newGenerator(pkgs).document()
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// moduleRoot returns the directory holding the go.mod of the module the
+// command runs in
+func moduleRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("no go.mod found")
+		}
+		dir = parent
+	}
+}
+
+// document assembles the OpenAPI document from every route registered in
+// the module
+func (g *generator) document() map[string]any {
+	paths := map[string]any{}
+	tags := map[string]bool{}
+	for _, route := range g.routes() {
+		item, ok := paths[route.path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[route.path] = item
+		}
+		for _, method := range route.methods {
+			item[method] = g.operation(route, method)
+		}
+		tags[route.tag] = true
+	}
+
+	var tagList []any
+	for _, tag := range sortedKeys(tags) {
+		tagList = append(tagList, map[string]any{"name": tag})
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Verisafe",
+			"description": "Authentication and authorization service of the Academia platform",
+			"version":     "v1",
+		},
+		"servers": []any{map[string]any{"url": "/"}},
+		"tags":    tagList,
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"apiKeyAuth": map[string]any{
+					"type":        "apiKey",
+					"in":          "header",
+					"name":        "X-API-Key",
+					"description": "Service token",
+				},
+			},
+		},
+	}
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mustJSON returns the JSON encoding of a schema, used to tell schemas apart
+func mustJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("encode schema: %v", err))
+	}
+	return string(data)
+}
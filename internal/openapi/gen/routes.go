@@ -0,0 +1,583 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	modulePath     = "github.com/opencrafts-io/verisafe"
+	middlewarePath = modulePath + "/internal/middleware"
+	apiPrefix      = "/api/v1/"
+)
+
+// generator walks the module for routes and the types they exchange
+type generator struct {
+	pkgs []*packages.Package
+	fset *token.FileSet
+
+	// Declarations of the module's functions by their full name
+	funcs map[string]funcDecl
+	// Doc comments of the module's types and struct fields by position
+	docs map[string]string
+	// Validation rules registered for types without validate tags, by the
+	// qualified name of the type and field name
+	validationRules map[string]map[string]string
+
+	// Component schemas by name and the name given to each named type
+	schemas map[string]any
+	names   map[string]string
+
+	operationIDs map[string]bool
+}
+
+// funcDecl is a function declaration together with the package declaring it
+type funcDecl struct {
+	decl *ast.FuncDecl
+	pkg  *packages.Package
+}
+
+// route is a route registered on the router
+type route struct {
+	methods []string
+	path    string
+	tag     string
+
+	// Names of the path parameters in order
+	pathParams []string
+
+	authenticated bool
+	permissions   []string
+	// Path value or query parameter naming the institution a permission may
+	// be held in
+	institutionParam string
+	// Whether authorization policies are evaluated against the owner of the
+	// resource, which only Authorize names
+	policies bool
+	// Default and maximum limit when the route is paginated by middleware
+	pagination *[2]int
+	// Whether responses carry an ETag clients may revalidate
+	cacheable bool
+
+	handler *funcDecl
+}
+
+func newGenerator(pkgs []*packages.Package) *generator {
+	g := &generator{
+		pkgs:            pkgs,
+		funcs:           map[string]funcDecl{},
+		docs:            map[string]string{},
+		validationRules: map[string]map[string]string{},
+		schemas:         map[string]any{},
+		names:           map[string]string{},
+		operationIDs:    map[string]bool{},
+	}
+	if len(pkgs) > 0 {
+		g.fset = pkgs[0].Fset
+	}
+
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				switch decl := decl.(type) {
+				case *ast.FuncDecl:
+					if fn, ok := pkg.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+						g.funcs[fn.FullName()] = funcDecl{decl: decl, pkg: pkg}
+					}
+				case *ast.GenDecl:
+					g.indexDocs(decl)
+				}
+			}
+			if pkg.PkgPath == utilsPath {
+				g.indexValidationRules(pkg, file)
+			}
+		}
+	}
+	return g
+}
+
+// indexDocs remembers the doc comments of the types and struct fields
+// declared by decl
+func (g *generator) indexDocs(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		typeSpec, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		doc := typeSpec.Doc
+		if doc == nil && len(decl.Specs) == 1 {
+			doc = decl.Doc
+		}
+		if doc != nil {
+			g.docs[g.position(typeSpec.Name.Pos())] = doc.Text()
+		}
+
+		ast.Inspect(typeSpec.Type, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok {
+				return true
+			}
+			doc := field.Doc
+			if doc == nil {
+				doc = field.Comment
+			}
+			if doc != nil {
+				for _, name := range field.Names {
+					g.docs[g.position(name.Pos())] = doc.Text()
+				}
+			}
+			return true
+		})
+	}
+}
+
+// position identifies a declaration across packages
+func (g *generator) position(pos token.Pos) string {
+	p := g.fset.Position(pos)
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// routes returns every route registered on a ServeMux with a constant
+// pattern, ordered by path and method
+func (g *generator) routes() []route {
+	var routes []route
+	for _, pkg := range g.pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || len(call.Args) != 2 {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") {
+					return true
+				}
+				if typeString(pkg.TypesInfo.TypeOf(sel.X)) != "*net/http.ServeMux" {
+					return true
+				}
+				// Routes handed to another router are documented through
+				// the routes of that router
+				if typeString(pkg.TypesInfo.TypeOf(call.Args[1])) == "*net/http.ServeMux" {
+					return true
+				}
+				value := pkg.TypesInfo.Types[call.Args[0]].Value
+				if value == nil || value.Kind() != constant.String {
+					return true
+				}
+				routes = append(routes, g.route(pkg, constant.StringVal(value), call.Args[1]))
+				return true
+			})
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].path < routes[j].path
+	})
+	return routes
+}
+
+// route describes the route registered with the pattern and handler
+func (g *generator) route(pkg *packages.Package, pattern string, handler ast.Expr) route {
+	rt := route{methods: []string{"get", "post"}}
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		rt.methods = []string{strings.ToLower(method)}
+		pattern = strings.TrimSpace(path)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(pattern, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+			if name == "$" {
+				segments = append(segments, "")
+				continue
+			}
+			rt.pathParams = append(rt.pathParams, name)
+			segment = "{" + name + "}"
+		}
+		segments = append(segments, segment)
+	}
+	rt.path = strings.Join(segments, "/")
+	if rt.path == "" {
+		rt.path = "/"
+	}
+
+	rt.tag = "system"
+	if rest, ok := strings.CutPrefix(rt.path, apiPrefix); ok {
+		rt.tag, _, _ = strings.Cut(rest, "/")
+	}
+
+	g.readMiddleware(pkg, handler, &rt)
+	return rt
+}
+
+// readMiddleware reads the middleware wrapping a route and finds the
+// function serving it
+func (g *generator) readMiddleware(pkg *packages.Package, handler ast.Expr, rt *route) {
+	info := pkg.TypesInfo
+	if fn := g.funcOf(info, handler); fn != nil {
+		rt.handler = fn
+		return
+	}
+
+	ast.Inspect(handler, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		// http.HandlerFunc(h) converts the function serving the route
+		if tv, ok := info.Types[call.Fun]; ok && tv.IsType() {
+			if typeString(tv.Type) == "net/http.HandlerFunc" && len(call.Args) == 1 {
+				if fn := g.funcOf(info, call.Args[0]); fn != nil {
+					rt.handler = fn
+				}
+			}
+			return true
+		}
+
+		callee := calleeOf(info, call)
+		if callee == nil || callee.Pkg() == nil || callee.Pkg().Path() != middlewarePath {
+			return true
+		}
+		switch callee.Name() {
+		case "IsAuthenticated":
+			rt.authenticated = true
+		case "HasPermission":
+			rt.permissions = append(rt.permissions, stringsOf(info, call.Args[0])...)
+		case "HasInstitutionPermission":
+			rt.permissions = append(rt.permissions, stringsOf(info, call.Args[0])...)
+			rt.institutionParam = stringOf(info, call.Args[1])
+		case "Authorize":
+			rt.policies = true
+			if permission := stringOf(info, call.Args[2]); permission != "" {
+				rt.permissions = append(rt.permissions, permission)
+			}
+		case "PaginationMiddleware":
+			rt.pagination = &[2]int{intOf(info, call.Args[0]), intOf(info, call.Args[1])}
+		case "Cacheable":
+			rt.cacheable = true
+		}
+		return true
+	})
+}
+
+// funcOf returns the declaration of the function or method expr refers to
+func (g *generator) funcOf(info *types.Info, expr ast.Expr) *funcDecl {
+	var fn *types.Func
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		fn, _ = info.Uses[expr].(*types.Func)
+	case *ast.SelectorExpr:
+		if selection := info.Selections[expr]; selection != nil {
+			fn, _ = selection.Obj().(*types.Func)
+		} else {
+			fn, _ = info.Uses[expr.Sel].(*types.Func)
+		}
+	}
+	if fn == nil {
+		return nil
+	}
+	if decl, ok := g.funcs[fn.FullName()]; ok {
+		return &decl
+	}
+	return nil
+}
+
+// operation describes the route served with the method
+func (g *generator) operation(rt route, method string) map[string]any {
+	h := g.analyzeRoute(rt)
+
+	op := map[string]any{
+		"operationId": g.operationID(rt, method),
+		"tags":        []string{rt.tag},
+	}
+
+	var description []string
+	if rt.handler != nil {
+		name := rt.handler.decl.Name.Name
+		op["summary"] = words(name)
+		if doc := docOf(rt.handler.decl); doc != "" {
+			op["summary"] = summaryOf(doc)
+			if doc != op["summary"] {
+				description = append(description, doc)
+			}
+		}
+	}
+	if len(rt.permissions) > 0 {
+		quoted := make([]string, len(rt.permissions))
+		for i, permission := range rt.permissions {
+			quoted[i] = "`" + permission + "`"
+		}
+		requirement := fmt.Sprintf("Requires %s.", strings.Join(quoted, ", "))
+		if rt.institutionParam != "" {
+			requirement = fmt.Sprintf("Requires %s, held globally or through a role in the institution named by `%s`.",
+				strings.Join(quoted, ", "), rt.institutionParam)
+		}
+		description = append(description, requirement)
+		op["x-permissions"] = rt.permissions
+	}
+	if rt.policies {
+		description = append(description, "Authorization policies are evaluated against the account owning the resource.")
+	}
+	if len(description) > 0 {
+		op["description"] = strings.Join(description, "\n\n")
+	}
+
+	var parameters []any
+	for _, name := range rt.pathParams {
+		parameters = append(parameters, map[string]any{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   h.pathParamSchema(name),
+		})
+	}
+	query := h.queryParams
+	if rt.pagination != nil {
+		query["limit"] = map[string]any{"type": "integer", "default": rt.pagination[0], "minimum": 1, "maximum": rt.pagination[1]}
+		query["offset"] = map[string]any{"type": "integer", "default": 0, "minimum": 0}
+	}
+	for _, name := range sortedKeys(query) {
+		parameters = append(parameters, map[string]any{
+			"name":   name,
+			"in":     "query",
+			"schema": query[name],
+		})
+	}
+	if rt.cacheable {
+		parameters = append(parameters, map[string]any{
+			"name":        "If-None-Match",
+			"in":          "header",
+			"description": "ETag of a cached response, answered with 304 Not Modified while it is current",
+			"schema":      map[string]any{"type": "string"},
+		})
+		h.addStatus(http.StatusNotModified)
+	}
+	if h.ifMatch {
+		parameters = append(parameters, map[string]any{
+			"name":        "If-Match",
+			"in":          "header",
+			"description": "ETag of the version the record is expected to be at, the update is rejected with 409 Conflict when it is at another one",
+			"schema":      map[string]any{"type": "string"},
+		})
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+
+	if h.requestBody != nil && method != "get" && method != "delete" {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": g.schemaOf(h.requestBody)},
+			},
+		}
+	}
+
+	if rt.authenticated {
+		op["security"] = []any{
+			map[string]any{"bearerAuth": []string{}},
+			map[string]any{"apiKeyAuth": []string{}},
+		}
+		h.addError(http.StatusUnauthorized)
+		if len(rt.permissions) > 0 {
+			h.addError(http.StatusForbidden)
+		}
+	}
+	responses := g.responses(h)
+	if success, ok := responses["200"].(map[string]any); ok && rt.cacheable {
+		success["headers"] = map[string]any{
+			"ETag":          map[string]any{"schema": map[string]any{"type": "string"}},
+			"Cache-Control": map[string]any{"schema": map[string]any{"type": "string"}},
+		}
+	}
+	op["responses"] = responses
+	return op
+}
+
+// operationID returns an operation ID no other operation has
+func (g *generator) operationID(rt route, method string) string {
+	name := method + strings.NewReplacer("/", "_", "{", "", "}", "", "-", "_").Replace(rt.path)
+	if rt.handler != nil {
+		name = lowerFirst(rt.handler.decl.Name.Name)
+		if len(rt.methods) > 1 {
+			name += upperFirst(method)
+		}
+		if g.operationIDs[name] {
+			name = lowerFirst(receiverName(rt.handler.decl)) + upperFirst(name)
+		}
+	}
+	g.operationIDs[name] = true
+	return name
+}
+
+// docOf returns the doc comment of a handler without the notes left for
+// maintainers, the route it was written for, which clients read from the
+// path instead, and the name of the handler it may start with
+func docOf(decl *ast.FuncDecl) string {
+	if decl.Doc == nil {
+		return ""
+	}
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(decl.Doc.Text()), "\n\n") {
+		if strings.HasPrefix(paragraph, "TODO") {
+			continue
+		}
+		first, rest, _ := strings.Cut(paragraph, "\n")
+		if method, path, ok := strings.Cut(first, " "); ok && method == strings.ToUpper(method) &&
+			strings.HasPrefix(path, "/") && !strings.Contains(path, " ") {
+			paragraph = strings.TrimSpace(rest)
+		}
+		if paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	doc := strings.Join(paragraphs, "\n\n")
+	if rest, ok := strings.CutPrefix(doc, decl.Name.Name+" "); ok {
+		doc = upperFirst(strings.TrimSpace(rest))
+	}
+	return doc
+}
+
+// summaryOf returns the first sentence of a doc comment
+func summaryOf(doc string) string {
+	paragraph, _, _ := strings.Cut(doc, "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if sentence, _, ok := strings.Cut(paragraph, ". "); ok {
+		paragraph = sentence
+	}
+	return strings.TrimRight(paragraph, ".")
+}
+
+// words spells out a Go identifier, GetRoleByID becomes "Get role by ID"
+func words(name string) string {
+	var out []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || (unicode.IsUpper(runes[i]) &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))) {
+			word := string(runes[start:i])
+			if len(out) > 0 && !isInitialism(word) {
+				word = strings.ToLower(word)
+			}
+			out = append(out, word)
+			start = i
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// isInitialism reports whether a word is spelled in capitals
+func isInitialism(word string) bool {
+	return len(word) > 1 && strings.ToUpper(word) == word
+}
+
+// receiverName returns the name of the type a method is declared on
+func receiverName(decl *ast.FuncDecl) string {
+	if decl.Recv == nil || len(decl.Recv.List) == 0 {
+		return ""
+	}
+	expr := decl.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// lowerFirst lowers the first letter of a name, or the whole initialism it
+// starts with, e.g. CSRFToken becomes csrfToken
+func lowerFirst(s string) string {
+	upper := 0
+	for upper < len(s) && 'A' <= s[upper] && s[upper] <= 'Z' {
+		upper++
+	}
+	switch {
+	case upper == 0:
+		return s
+	case upper == len(s):
+		return strings.ToLower(s)
+	case upper > 1:
+		// The last capital starts the next word
+		upper--
+	}
+	return strings.ToLower(s[:upper]) + s[upper:]
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// calleeOf returns the function or method a call statically calls
+func calleeOf(info *types.Info, call *ast.CallExpr) *types.Func {
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		fn, _ := info.Uses[fun].(*types.Func)
+		return fn
+	case *ast.SelectorExpr:
+		if selection := info.Selections[fun]; selection != nil {
+			fn, _ := selection.Obj().(*types.Func)
+			return fn
+		}
+		fn, _ := info.Uses[fun.Sel].(*types.Func)
+		return fn
+	}
+	return nil
+}
+
+// stringOf returns the value of a constant string expression
+func stringOf(info *types.Info, expr ast.Expr) string {
+	if value := info.Types[expr].Value; value != nil && value.Kind() == constant.String {
+		return constant.StringVal(value)
+	}
+	return ""
+}
+
+// stringsOf returns the constant strings of a slice literal
+func stringsOf(info *types.Info, expr ast.Expr) []string {
+	lit, ok := ast.Unparen(expr).(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	var values []string
+	for _, elt := range lit.Elts {
+		if value := stringOf(info, elt); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// intOf returns the value of a constant integer expression
+func intOf(info *types.Info, expr ast.Expr) int {
+	if value := info.Types[expr].Value; value != nil {
+		if v, ok := constant.Int64Val(constant.ToInt(value)); ok {
+			return int(v)
+		}
+	}
+	return 0
+}
+
+// typeString returns the fully qualified name of a type
+func typeString(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	return types.TypeString(t, nil)
+}
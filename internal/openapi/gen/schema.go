@@ -0,0 +1,343 @@
+package main
+
+import (
+	"go/types"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schemas of types encoding themselves, by their fully qualified name
+var wellKnownSchemas = map[string]map[string]any{
+	"time.Time":                   {"type": "string", "format": "date-time"},
+	"time.Duration":               {"type": "integer", "description": "Duration in nanoseconds"},
+	"encoding/json.RawMessage":    {},
+	"encoding/json.Number":        {"type": "number"},
+	"github.com/google/uuid.UUID": {"type": "string", "format": "uuid"},
+
+	"github.com/jackc/pgx/v5/pgtype.Text":        {"type": "string", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Bool":        {"type": "boolean", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Int2":        {"type": "integer", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Int4":        {"type": "integer", "format": "int32", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Int8":        {"type": "integer", "format": "int64", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Float4":      {"type": "number", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Float8":      {"type": "number", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Numeric":     {"type": "number", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.UUID":        {"type": "string", "format": "uuid", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Date":        {"type": "string", "format": "date", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Timestamp":   {"type": "string", "format": "date-time", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Timestamptz": {"type": "string", "format": "date-time", "nullable": true},
+	"github.com/jackc/pgx/v5/pgtype.Interval":    {"type": "string", "nullable": true},
+}
+
+// errorSchemaName is the component describing the error responses
+const errorSchemaName = "Error"
+
+// errorSchema returns a reference to the schema of error responses
+func (g *generator) errorSchema() any {
+	if _, ok := g.schemas[errorSchemaName]; !ok {
+		g.schemas[errorSchemaName] = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"error": map[string]any{"type": "string"},
+			},
+		}
+	}
+	return ref(errorSchemaName)
+}
+
+// validationErrorSchemaName is the component describing the bad request
+// responses naming the invalid fields of the request body
+const validationErrorSchemaName = "ValidationError"
+
+// validationErrorSchema returns a reference to the schema of bad request
+// responses written by utils.DecodeAndValidate
+func (g *generator) validationErrorSchema() any {
+	if _, ok := g.schemas[validationErrorSchemaName]; !ok {
+		g.schemas[validationErrorSchemaName] = map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"error": map[string]any{"type": "string"},
+				"fields": map[string]any{
+					"type":                 "object",
+					"description":          "What is wrong with each invalid field, keyed by the field's path in the request body",
+					"additionalProperties": map[string]any{"type": "string"},
+				},
+			},
+		}
+	}
+	return ref(validationErrorSchemaName)
+}
+
+// paginatedSchema returns the schema of a paginated response holding
+// results of the given type
+func (g *generator) paginatedSchema(results types.Type) any {
+	nullableString := map[string]any{"type": "string", "nullable": true}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count":    map[string]any{"type": "integer", "format": "int64"},
+			"next":     nullableString,
+			"previous": nullableString,
+			"results":  g.schemaOf(results),
+		},
+	}
+}
+
+// cursorPaginatedSchema returns the schema of a keyset paginated response
+// holding results of the given type
+func (g *generator) cursorPaginatedSchema(results types.Type) any {
+	nullableString := map[string]any{"type": "string", "nullable": true}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"next":        nullableString,
+			"next_cursor": nullableString,
+			"results":     g.schemaOf(results),
+		},
+	}
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// schemaOf returns the schema of the JSON encoding of a type. Named structs
+// are described once as components and referred to
+func (g *generator) schemaOf(t types.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	switch t := t.(type) {
+	case *types.Alias:
+		return g.schemaOf(types.Unalias(t))
+	case *types.Named:
+		return g.namedSchema(t)
+	case *types.Pointer:
+		return nullable(g.schemaOf(t.Elem()))
+	case *types.Slice:
+		return g.listSchema(t.Elem())
+	case *types.Array:
+		return g.listSchema(t.Elem())
+	case *types.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case *types.Struct:
+		return g.structSchema(t, "")
+	case *types.Basic:
+		return basicSchema(t)
+	}
+	return map[string]any{}
+}
+
+// namedSchema returns the schema of a named type
+func (g *generator) namedSchema(t *types.Named) map[string]any {
+	obj := t.Obj()
+	if obj.Pkg() == nil {
+		// error and other predeclared types
+		return map[string]any{"type": "string"}
+	}
+	qualified := obj.Pkg().Path() + "." + obj.Name()
+	if schema, ok := wellKnownSchemas[qualified]; ok {
+		return copySchema(schema)
+	}
+	if marshalsItself(t) {
+		return map[string]any{}
+	}
+
+	switch underlying := t.Underlying().(type) {
+	case *types.Struct:
+		if t.TypeArgs().Len() > 0 {
+			return g.structSchema(underlying, "")
+		}
+		name := g.componentName(obj)
+		if _, ok := g.schemas[name]; !ok {
+			// Registered before it is described so that recursive types
+			// refer to themselves
+			g.schemas[name] = map[string]any{}
+			schema := g.structSchema(underlying, qualified)
+			if doc := g.docs[g.position(obj.Pos())]; doc != "" {
+				schema["description"] = strings.TrimSpace(doc)
+			}
+			g.schemas[name] = schema
+		}
+		return ref(name)
+	case *types.Basic:
+		schema := basicSchema(underlying)
+		if values := enumValues(t); len(values) > 0 {
+			schema["enum"] = values
+		}
+		return schema
+	}
+	return g.schemaOf(t.Underlying())
+}
+
+// componentName returns the name of the component describing a named type,
+// types sharing a name are told apart by the name of their package
+func (g *generator) componentName(obj *types.TypeName) string {
+	qualified := obj.Pkg().Path() + "." + obj.Name()
+	if name, ok := g.names[qualified]; ok {
+		return name
+	}
+
+	name := obj.Name()
+	for _, taken := range g.names {
+		if taken == name {
+			name = upperFirst(obj.Pkg().Name()) + obj.Name()
+			break
+		}
+	}
+	g.names[qualified] = name
+	return name
+}
+
+// structSchema describes the fields of a struct the way encoding/json
+// encodes them, along with the rules fields are validated with. Qualified
+// is the name of the struct's type when it has one
+func (g *generator) structSchema(st *types.Struct, qualified string) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		name, opts, _ := strings.Cut(reflect.StructTag(st.Tag(i)).Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if field.Embedded() && name == "" {
+			embedded := field.Type()
+			if pointer, ok := embedded.(*types.Pointer); ok {
+				embedded = pointer.Elem()
+			}
+			if embeddedStruct, ok := embedded.Underlying().(*types.Struct); ok {
+				if inner, ok := g.structSchema(embeddedStruct, "")["properties"].(map[string]any); ok {
+					for key, value := range inner {
+						if _, ok := properties[key]; !ok {
+							properties[key] = value
+						}
+					}
+				}
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+
+		schema := g.schemaOf(field.Type())
+		if strings.Contains(","+opts+",", ",string,") {
+			schema = map[string]any{"type": "string"}
+		}
+		if rule := g.fieldRule(qualified, field, st.Tag(i)); rule != "" {
+			constrain(schema, rule)
+			if requiredBy(rule) {
+				required = append(required, name)
+			}
+		}
+		if doc := g.docs[g.position(field.Pos())]; doc != "" {
+			if _, isRef := schema["$ref"]; isRef {
+				schema = map[string]any{"allOf": []any{schema}}
+			}
+			schema["description"] = strings.TrimSpace(doc)
+		}
+		properties[name] = schema
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// listSchema describes a slice or an array of the element type, byte slices
+// are encoded as base64 strings
+func (g *generator) listSchema(elem types.Type) map[string]any {
+	if basic, ok := elem.(*types.Basic); ok && basic.Kind() == types.Byte {
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+	return map[string]any{"type": "array", "items": g.schemaOf(elem)}
+}
+
+// basicSchema describes a boolean, a number or a string
+func basicSchema(t *types.Basic) map[string]any {
+	info := t.Info()
+	switch {
+	case info&types.IsBoolean != 0:
+		return map[string]any{"type": "boolean"}
+	case info&types.IsInteger != 0:
+		schema := map[string]any{"type": "integer"}
+		switch t.Kind() {
+		case types.Int32, types.Uint32:
+			schema["format"] = "int32"
+		case types.Int64, types.Uint64:
+			schema["format"] = "int64"
+		}
+		return schema
+	case info&types.IsFloat != 0:
+		return map[string]any{"type": "number"}
+	case info&types.IsString != 0:
+		return map[string]any{"type": "string"}
+	}
+	return map[string]any{}
+}
+
+// enumValues returns the values of the constants declared with a named
+// type in its package
+func enumValues(t *types.Named) []any {
+	scope := t.Obj().Pkg().Scope()
+	var values []string
+	for _, name := range scope.Names() {
+		constant, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !types.Identical(constant.Type(), t) {
+			continue
+		}
+		values = append(values, strings.Trim(constant.Val().ExactString(), `"`))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Strings(values)
+
+	enum := make([]any, len(values))
+	for i, value := range values {
+		enum[i] = value
+	}
+	return enum
+}
+
+// marshalsItself reports whether a type implements json.Marshaler, in which
+// case its encoding cannot be told from its fields
+func marshalsItself(t types.Type) bool {
+	for _, candidate := range []types.Type{t, types.NewPointer(t)} {
+		methods := types.NewMethodSet(candidate)
+		for i := 0; i < methods.Len(); i++ {
+			if methods.At(i).Obj().Name() == "MarshalJSON" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nullable marks a schema as allowing null
+func nullable(schema map[string]any) map[string]any {
+	if _, isRef := schema["$ref"]; isRef {
+		return map[string]any{"allOf": []any{schema}, "nullable": true}
+	}
+	if len(schema) == 0 {
+		return schema
+	}
+	schema["nullable"] = true
+	return schema
+}
+
+func copySchema(schema map[string]any) map[string]any {
+	copied := make(map[string]any, len(schema))
+	for key, value := range schema {
+		copied[key] = value
+	}
+	return copied
+}
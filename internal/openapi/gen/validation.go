@@ -0,0 +1,157 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const utilsPath = modulePath + "/internal/utils"
+
+// indexValidationRules remembers the rules registered for types that cannot
+// carry validate tags, by the qualified name of the type and field name
+func (g *generator) indexValidationRules(pkg *packages.Package, file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "RegisterStructValidationMapRules" {
+			return true
+		}
+		rules, ok := call.Args[0].(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+
+		fields := map[string]string{}
+		for _, elt := range rules.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			field, rule := stringOf(pkg.TypesInfo, kv.Key), stringOf(pkg.TypesInfo, kv.Value)
+			if field != "" {
+				fields[field] = rule
+			}
+		}
+		for _, arg := range call.Args[1:] {
+			named, ok := pkg.TypesInfo.TypeOf(arg).(*types.Named)
+			if !ok || named.Obj().Pkg() == nil {
+				continue
+			}
+			g.validationRules[named.Obj().Pkg().Path()+"."+named.Obj().Name()] = fields
+		}
+		return true
+	})
+}
+
+// fieldRule returns the validation rule of a struct field, from its validate
+// tag or from the rules registered for the struct
+func (g *generator) fieldRule(qualified string, field *types.Var, tag string) string {
+	if rule, ok := reflect.StructTag(tag).Lookup("validate"); ok {
+		return rule
+	}
+	return g.validationRules[qualified][field.Name()]
+}
+
+// requiredBy reports whether a validation rule requires a field to be sent
+func requiredBy(rule string) bool {
+	optional := false
+	for _, tag := range strings.Split(rule, ",") {
+		switch tag {
+		case "omitempty", "omitnil":
+			optional = true
+		case "required", "notblank":
+			return !optional
+		case "dive":
+			return false
+		}
+	}
+	return false
+}
+
+// constrain describes the constraints of a validation rule on a schema.
+// Rules following dive constrain the items of a list
+func constrain(schema map[string]any, rule string) {
+	if _, isRef := schema["$ref"]; isRef {
+		return
+	}
+	if _, isAllOf := schema["allOf"]; isAllOf {
+		return
+	}
+
+	tags := strings.Split(rule, ",")
+	for i, tag := range tags {
+		if tag == "dive" {
+			if items, ok := schema["items"].(map[string]any); ok {
+				constrain(items, strings.Join(tags[i+1:], ","))
+			}
+			return
+		}
+
+		name, param, _ := strings.Cut(tag, "=")
+		switch name {
+		case "email":
+			schema["format"] = "email"
+		case "url", "http_url":
+			schema["format"] = "uri"
+		case "uuid", "uuid4":
+			schema["format"] = "uuid"
+		case "ipv4":
+			schema["format"] = "ipv4"
+		case "oneof":
+			var enum []any
+			for _, value := range strings.Fields(param) {
+				enum = append(enum, value)
+			}
+			schema["enum"] = enum
+		case "notblank":
+			if schema["type"] == "string" {
+				schema["minLength"] = 1
+			}
+		case "len":
+			bound(schema, "min", param, false)
+			bound(schema, "max", param, false)
+		case "min", "gte":
+			bound(schema, "min", param, false)
+		case "max", "lte":
+			bound(schema, "max", param, false)
+		case "gt":
+			bound(schema, "min", param, true)
+		case "lt":
+			bound(schema, "max", param, true)
+		}
+	}
+}
+
+// bound sets the lower or upper bound of a length, a count or a value
+func bound(schema map[string]any, which, param string, exclusive bool) {
+	number, err := strconv.Atoi(param)
+	if err != nil {
+		return
+	}
+
+	switch schema["type"] {
+	case "string":
+		schema[which+"Length"] = number
+	case "array":
+		schema[which+"Items"] = number
+	case "object":
+		schema[which+"Properties"] = number
+	case "integer", "number":
+		key := "minimum"
+		if which == "max" {
+			key = "maximum"
+		}
+		schema[key] = number
+		if exclusive {
+			schema["exclusive"+strings.ToUpper(key[:1])+key[1:]] = true
+		}
+	}
+}
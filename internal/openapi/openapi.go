@@ -0,0 +1,15 @@
+// Package openapi holds the OpenAPI document describing Verisafe's HTTP API.
+//
+// The document is generated from the routes registered on the router, the
+// middleware guarding them and the handlers serving them. Regenerate it with
+// go generate whenever a route, a request or a response changes
+package openapi
+
+import _ "embed"
+
+//go:generate go run ./gen -o openapi.json
+
+// Document is the OpenAPI 3 document describing every route Verisafe serves
+//
+//go:embed openapi.json
+var Document []byte
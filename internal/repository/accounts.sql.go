@@ -9,12 +9,24 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDeletedAccounts = `-- name: CountDeletedAccounts :one
+SELECT count(id) FROM accounts WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedAccounts(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedAccounts)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (email, name, type, avatar_url)
 VALUES ($1, $2, $3, $4)
-RETURNING id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at
+RETURNING id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id
 `
 
 type CreateAccountParams struct {
@@ -48,16 +60,39 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.VibePoints,
 		&i.Phone,
 		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
 	)
 	return i, err
 }
 
+const deactivateAccount = `-- name: DeactivateAccount :exec
+UPDATE accounts
+  SET
+    deactivated_at = NOW(),
+    updated_at = NOW()
+  WHERE id = $1
+  AND deactivated_at IS NULL
+`
+
+// Deactivates an account hiding it from searches and leaderboards
+// until the owner signs in again
+func (q *Queries) DeactivateAccount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, deactivateAccount, id)
+	return err
+}
+
 const getAccountByEmail = `-- name: GetAccountByEmail :one
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts 
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts 
 WHERE lower(email) = lower($1::varchar)
+  AND tenant_id = COALESCE(verisafe_current_tenant(), tenant_id)
+ORDER BY tenant_id = 'default' DESC, created_at
 LIMIT 1
 `
 
+// Emails are unique within a tenant. Connections seeing every tenant get the
+// account of the default tenant first
 func (q *Queries) GetAccountByEmail(ctx context.Context, email string) (Account, error) {
 	row := q.db.QueryRow(ctx, getAccountByEmail, email)
 	var i Account
@@ -77,12 +112,15 @@ func (q *Queries) GetAccountByEmail(ctx context.Context, email string) (Account,
 		&i.VibePoints,
 		&i.Phone,
 		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getAccountByID = `-- name: GetAccountByID :one
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts 
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts 
 WHERE id = $1
 `
 
@@ -105,12 +143,15 @@ func (q *Queries) GetAccountByID(ctx context.Context, id uuid.UUID) (Account, er
 		&i.VibePoints,
 		&i.Phone,
 		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getAccountByUsername = `-- name: GetAccountByUsername :one
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts WHERE lower(username) = lower($1::varchar)
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts WHERE lower(username) = lower($1::varchar)
 `
 
 func (q *Queries) GetAccountByUsername(ctx context.Context, username string) (Account, error) {
@@ -132,12 +173,15 @@ func (q *Queries) GetAccountByUsername(ctx context.Context, username string) (Ac
 		&i.VibePoints,
 		&i.Phone,
 		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getAccountsCount = `-- name: GetAccountsCount :one
-SELECT count(id) FROM accounts WHERE type = 'human'
+SELECT count(id) FROM accounts WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL
 `
 
 // Returns the number of all human accounts in the system
@@ -149,7 +193,7 @@ func (q *Queries) GetAccountsCount(ctx context.Context) (int64, error) {
 }
 
 const getAllAccounts = `-- name: GetAllAccounts :many
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts WHERE type = 'human' 
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL
 LIMIT $1
 OFFSET $2
 `
@@ -185,6 +229,152 @@ func (q *Queries) GetAllAccounts(ctx context.Context, arg GetAllAccountsParams)
 			&i.VibePoints,
 			&i.Phone,
 			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts
+WHERE type = 'human' AND deactivated_at IS NULL AND deleted_at IS NULL
+  AND ($1::timestamp IS NULL
+    OR (created_at, id) < ($1::timestamp, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type ListAccountsAfterParams struct {
+	AfterCreatedAt pgtype.Timestamp `json:"after_created_at"`
+	AfterID        pgtype.UUID      `json:"after_id"`
+	PageSize       int32            `json:"page_size"`
+}
+
+// Returns human accounts newest first, continuing after the account a
+// cursor points at. Both cursor values are null for the first page
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsAfter, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TermsAccepted,
+			&i.Onboarded,
+			&i.Type,
+			&i.NationalID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Bio,
+			&i.VibePoints,
+			&i.Phone,
+			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsByEmails = `-- name: ListAccountsByEmails :many
+SELECT DISTINCT ON (lower(email)) id, lower(email)::varchar AS email
+FROM accounts
+WHERE lower(email) = ANY($1::varchar[])
+ORDER BY lower(email), created_at
+`
+
+type ListAccountsByEmailsRow struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+// Returns the ids of the accounts owning lower cased emails, used by bulk
+// imports to look up a batch of emails at once
+func (q *Queries) ListAccountsByEmails(ctx context.Context, emails []string) ([]ListAccountsByEmailsRow, error) {
+	rows, err := q.db.Query(ctx, listAccountsByEmails, emails)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountsByEmailsRow{}
+	for rows.Next() {
+		var i ListAccountsByEmailsRow
+		if err := rows.Scan(&i.ID, &i.Email); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedAccounts = `-- name: ListDeletedAccounts :many
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1
+OFFSET $2
+`
+
+type ListDeletedAccountsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Returns the accounts marked for deletion, most recently marked first
+func (q *Queries) ListDeletedAccounts(ctx context.Context, arg ListDeletedAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listDeletedAccounts, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TermsAccepted,
+			&i.Onboarded,
+			&i.Type,
+			&i.NationalID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Bio,
+			&i.VibePoints,
+			&i.Phone,
+			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -211,7 +401,7 @@ func (q *Queries) MarkAccountForDeletion(ctx context.Context, id uuid.UUID) erro
 	return err
 }
 
-const markAccountForRecovery = `-- name: MarkAccountForRecovery :exec
+const markAccountForRecovery = `-- name: MarkAccountForRecovery :execrows
 UPDATE accounts
   SET
     deleted_at = NULL
@@ -221,26 +411,63 @@ UPDATE accounts
 `
 
 // Recovers an account from scheduled deletion
-func (q *Queries) MarkAccountForRecovery(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, markAccountForRecovery, id)
+func (q *Queries) MarkAccountForRecovery(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, markAccountForRecovery, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reactivateAccount = `-- name: ReactivateAccount :exec
+UPDATE accounts
+  SET
+    deactivated_at = NULL,
+    updated_at = NOW()
+  WHERE id = $1
+  AND deactivated_at IS NOT NULL
+`
+
+// Reactivates a previously deactivated account
+func (q *Queries) ReactivateAccount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.Exec(ctx, reactivateAccount, id)
 	return err
 }
 
 const searchAccountByEmail = `-- name: SearchAccountByEmail :many
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts 
-WHERE lower(email) LIKE '%' || lower($3::varchar) || '%'
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts 
+WHERE (
+    lower(email) LIKE '%' || lower($3::varchar) || '%'
+    OR lower($3::varchar) <% lower(email)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    $4::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower($4::varchar))
+  )
+ORDER BY
+  lower(email) = lower($3::varchar) DESC,
+  word_similarity(lower($3::varchar), lower(email)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 `
 
 type SearchAccountByEmailParams struct {
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
-	Email  string `json:"email"`
+	Limit  int32   `json:"limit"`
+	Offset int32   `json:"offset"`
+	Email  string  `json:"email"`
+	Tag    *string `json:"tag"`
 }
 
 func (q *Queries) SearchAccountByEmail(ctx context.Context, arg SearchAccountByEmailParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, searchAccountByEmail, arg.Limit, arg.Offset, arg.Email)
+	rows, err := q.db.Query(ctx, searchAccountByEmail,
+		arg.Limit,
+		arg.Offset,
+		arg.Email,
+		arg.Tag,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -264,6 +491,9 @@ func (q *Queries) SearchAccountByEmail(ctx context.Context, arg SearchAccountByE
 			&i.VibePoints,
 			&i.Phone,
 			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -276,20 +506,41 @@ func (q *Queries) SearchAccountByEmail(ctx context.Context, arg SearchAccountByE
 }
 
 const searchAccountByName = `-- name: SearchAccountByName :many
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts 
-WHERE lower(name) LIKE '%' || lower($3::varchar) || '%'
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts 
+WHERE (
+    to_tsvector('simple', name) @@ plainto_tsquery('simple', $3::varchar)
+    OR lower(name) LIKE '%' || lower($3::varchar) || '%'
+    OR lower($3::varchar) <% lower(name)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    $4::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower($4::varchar))
+  )
+ORDER BY
+  lower(name) = lower($3::varchar) DESC,
+  ts_rank(to_tsvector('simple', name), plainto_tsquery('simple', $3::varchar))
+    + word_similarity(lower($3::varchar), lower(name)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 `
 
 type SearchAccountByNameParams struct {
-	Limit  int32  `json:"limit"`
-	Offset int32  `json:"offset"`
-	Name   string `json:"name"`
+	Limit  int32   `json:"limit"`
+	Offset int32   `json:"offset"`
+	Name   string  `json:"name"`
+	Tag    *string `json:"tag"`
 }
 
 func (q *Queries) SearchAccountByName(ctx context.Context, arg SearchAccountByNameParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, searchAccountByName, arg.Limit, arg.Offset, arg.Name)
+	rows, err := q.db.Query(ctx, searchAccountByName,
+		arg.Limit,
+		arg.Offset,
+		arg.Name,
+		arg.Tag,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -313,6 +564,9 @@ func (q *Queries) SearchAccountByName(ctx context.Context, arg SearchAccountByNa
 			&i.VibePoints,
 			&i.Phone,
 			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -325,20 +579,39 @@ func (q *Queries) SearchAccountByName(ctx context.Context, arg SearchAccountByNa
 }
 
 const searchAccountByUsername = `-- name: SearchAccountByUsername :many
-SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at FROM accounts 
-WHERE lower(username) LIKE '%' || lower($3::varchar) || '%'
+SELECT id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id FROM accounts 
+WHERE (
+    lower(username) LIKE '%' || lower($3::varchar) || '%'
+    OR lower($3::varchar) <% lower(username)
+  )
+  AND deactivated_at IS NULL
+  AND deleted_at IS NULL
+  AND (
+    $4::varchar IS NULL
+    OR id IN (SELECT account_id FROM account_tags WHERE tag = lower($4::varchar))
+  )
+ORDER BY
+  lower(username) = lower($3::varchar) DESC,
+  word_similarity(lower($3::varchar), lower(username)) DESC,
+  id
 LIMIT $1
 OFFSET $2
 `
 
 type SearchAccountByUsernameParams struct {
-	Limit    int32  `json:"limit"`
-	Offset   int32  `json:"offset"`
-	Username string `json:"username"`
+	Limit    int32   `json:"limit"`
+	Offset   int32   `json:"offset"`
+	Username string  `json:"username"`
+	Tag      *string `json:"tag"`
 }
 
 func (q *Queries) SearchAccountByUsername(ctx context.Context, arg SearchAccountByUsernameParams) ([]Account, error) {
-	rows, err := q.db.Query(ctx, searchAccountByUsername, arg.Limit, arg.Offset, arg.Username)
+	rows, err := q.db.Query(ctx, searchAccountByUsername,
+		arg.Limit,
+		arg.Offset,
+		arg.Username,
+		arg.Tag,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -362,6 +635,9 @@ func (q *Queries) SearchAccountByUsername(ctx context.Context, arg SearchAccount
 			&i.VibePoints,
 			&i.Phone,
 			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -373,7 +649,68 @@ func (q *Queries) SearchAccountByUsername(ctx context.Context, arg SearchAccount
 	return items, nil
 }
 
-const updateAccountDetails = `-- name: UpdateAccountDetails :exec
+const syncAccountDetails = `-- name: SyncAccountDetails :one
+UPDATE accounts
+  SET
+    email = COALESCE(NULLIF($2::varchar, ''), email),
+    name = COALESCE(NULLIF($3::varchar, ''), name),
+    username = COALESCE($4::varchar, username),
+    avatar_url = COALESCE($5::text, avatar_url),
+    phone = COALESCE($6::varchar, phone),
+    national_id = COALESCE($7::varchar, national_id),
+    updated_at = NOW(),
+    version = version + 1
+  WHERE id = $1
+RETURNING id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id
+`
+
+type SyncAccountDetailsParams struct {
+	ID         uuid.UUID `json:"id"`
+	Email      string    `json:"email"`
+	Name       string    `json:"name"`
+	Username   *string   `json:"username"`
+	AvatarUrl  *string   `json:"avatar_url"`
+	Phone      *string   `json:"phone"`
+	NationalID *string   `json:"national_id"`
+}
+
+// Applies account details another service emitted. Details that are not
+// provided are left untouched
+func (q *Queries) SyncAccountDetails(ctx context.Context, arg SyncAccountDetailsParams) (Account, error) {
+	row := q.db.QueryRow(ctx, syncAccountDetails,
+		arg.ID,
+		arg.Email,
+		arg.Name,
+		arg.Username,
+		arg.AvatarUrl,
+		arg.Phone,
+		arg.NationalID,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TermsAccepted,
+		&i.Onboarded,
+		&i.Type,
+		&i.NationalID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.VibePoints,
+		&i.Phone,
+		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
+	)
+	return i, err
+}
+
+const updateAccountDetails = `-- name: UpdateAccountDetails :execrows
 UPDATE accounts
   SET
     username = COALESCE(NULLIF($2::varchar,''), username),
@@ -384,8 +721,10 @@ UPDATE accounts
     national_id = COALESCE(NULLIF($7::varchar,''), national_id),
     avatar_url = COALESCE(NULLIF($8::text,''), avatar_url),
     bio = COALESCE(NULLIF($9::text,''), bio),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
   WHERE id = $1
+  AND ($10::bigint IS NULL OR version = $10)
 `
 
 type UpdateAccountDetailsParams struct {
@@ -398,10 +737,14 @@ type UpdateAccountDetailsParams struct {
 	NationalID    string    `json:"national_id"`
 	AvatarUrl     string    `json:"avatar_url"`
 	Bio           string    `json:"bio"`
+	Version       *int64    `json:"version"`
 }
 
-func (q *Queries) UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) error {
-	_, err := q.db.Exec(ctx, updateAccountDetails,
+// Updates the details of an account and bumps its version. When a version
+// is given the account is only updated if it is still at that version, so
+// no rows are affected when someone else updated it in the meantime
+func (q *Queries) UpdateAccountDetails(ctx context.Context, arg UpdateAccountDetailsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateAccountDetails,
 		arg.ID,
 		arg.Username,
 		arg.Email,
@@ -411,15 +754,20 @@ func (q *Queries) UpdateAccountDetails(ctx context.Context, arg UpdateAccountDet
 		arg.NationalID,
 		arg.AvatarUrl,
 		arg.Bio,
+		arg.Version,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const updateAccountPhoneNumber = `-- name: UpdateAccountPhoneNumber :exec
 UPDATE accounts
   SET
     phone = COALESCE(NULLIF($2::varchar,''), phone),
-    updated_at = NOW()
+    updated_at = NOW(),
+    version = version + 1
   WHERE id = $1
 `
 
@@ -433,3 +781,46 @@ func (q *Queries) UpdateAccountPhoneNumber(ctx context.Context, arg UpdateAccoun
 	_, err := q.db.Exec(ctx, updateAccountPhoneNumber, arg.ID, arg.Phone)
 	return err
 }
+
+const updateAccountType = `-- name: UpdateAccountType :one
+UPDATE accounts
+  SET
+    type = $2::account_type,
+    updated_at = NOW(),
+    version = version + 1
+  WHERE id = $1
+RETURNING id, email, name, created_at, updated_at, terms_accepted, onboarded, type, national_id, username, avatar_url, bio, vibe_points, phone, deleted_at, deactivated_at, version, tenant_id
+`
+
+type UpdateAccountTypeParams struct {
+	ID   uuid.UUID   `json:"id"`
+	Type AccountType `json:"type"`
+}
+
+// Changes the type of an account. Callers are expected to have validated
+// the transition beforehand and to record it in account_type_transitions
+func (q *Queries) UpdateAccountType(ctx context.Context, arg UpdateAccountTypeParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountType, arg.ID, arg.Type)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TermsAccepted,
+		&i.Onboarded,
+		&i.Type,
+		&i.NationalID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.Bio,
+		&i.VibePoints,
+		&i.Phone,
+		&i.DeletedAt,
+		&i.DeactivatedAt,
+		&i.Version,
+		&i.TenantID,
+	)
+	return i, err
+}
@@ -0,0 +1,50 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestAccountEmailsAreUniquePerTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	create := func(tenant, email string) (repository.Account, error) {
+		t.Helper()
+		if err := repo.SetCurrentTenant(ctx, tenant); err != nil {
+			t.Fatalf("Could not switch to tenant %s: %v", tenant, err)
+		}
+		return repo.CreateAccount(ctx, repository.CreateAccountParams{
+			Email: email,
+			Name:  "Ada",
+			Type:  repository.AccountTypeHuman,
+		})
+	}
+
+	if _, err := create("acme", "ada@example.com"); err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	globex, err := create("globex", "ada@example.com")
+	if err != nil {
+		t.Fatalf("Expected the email to hold an account in another tenant, got %v", err)
+	}
+
+	account, err := repo.GetAccountByEmail(ctx, "Ada@Example.com")
+	if err != nil {
+		t.Fatalf("Could not get account: %v", err)
+	}
+	if account.ID != globex.ID {
+		t.Errorf("Expected the account of globex, got the one of %s", account.TenantID)
+	}
+
+	var pgErr *pgconn.PgError
+	_, err = create("globex", "ADA@example.com")
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Errorf("Expected the email to be unique within a tenant, got %v", err)
+	}
+}
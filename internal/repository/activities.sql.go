@@ -9,8 +9,55 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const archiveActivityCompletionPartitions = `-- name: ArchiveActivityCompletionPartitions :many
+SELECT archived_partition::text, archived_rows::bigint
+FROM archive_activity_completion_partitions(
+  (date_trunc('month', CURRENT_DATE) - make_interval(months => $1::int))::date
+)
+`
+
+type ArchiveActivityCompletionPartitionsRow struct {
+	ArchivedPartition string `json:"archived_partition"`
+	ArchivedRows      int64  `json:"archived_rows"`
+}
+
+// Moves the monthly partitions of activity completions made before the last
+// @archive_after_months whole months to the archive and returns them with
+// their number of completions
+func (q *Queries) ArchiveActivityCompletionPartitions(ctx context.Context, archiveAfterMonths int32) ([]ArchiveActivityCompletionPartitionsRow, error) {
+	rows, err := q.db.Query(ctx, archiveActivityCompletionPartitions, archiveAfterMonths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ArchiveActivityCompletionPartitionsRow{}
+	for rows.Next() {
+		var i ArchiveActivityCompletionPartitionsRow
+		if err := rows.Scan(&i.ArchivedPartition, &i.ArchivedRows); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countDeletedActivities = `-- name: CountDeletedActivities :one
+SELECT COUNT(id) FROM activities WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedActivities(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedActivities)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createActivity = `-- name: CreateActivity :one
 INSERT INTO activities (
   name, 
@@ -18,9 +65,10 @@ INSERT INTO activities (
   category,
   points_awarded, 
   max_daily_completions, 
-  streak_eligible
-) VALUES ( $1, $2, $3, $4, $5, $6 )
-RETURNING id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at
+  streak_eligible,
+  cooldown_seconds
+) VALUES ( $1, $2, $3, $4, $5, $6, $7 )
+RETURNING id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at
 `
 
 type CreateActivityParams struct {
@@ -30,6 +78,7 @@ type CreateActivityParams struct {
 	PointsAwarded       int16   `json:"points_awarded"`
 	MaxDailyCompletions *int16  `json:"max_daily_completions"`
 	StreakEligible      *bool   `json:"streak_eligible"`
+	CooldownSeconds     int32   `json:"cooldown_seconds"`
 }
 
 // Creates an activity.
@@ -43,6 +92,7 @@ func (q *Queries) CreateActivity(ctx context.Context, arg CreateActivityParams)
 		arg.PointsAwarded,
 		arg.MaxDailyCompletions,
 		arg.StreakEligible,
+		arg.CooldownSeconds,
 	)
 	var i Activity
 	err := row.Scan(
@@ -56,22 +106,72 @@ func (q *Queries) CreateActivity(ctx context.Context, arg CreateActivityParams)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CooldownSeconds,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const createActivityCompletionPartitions = `-- name: CreateActivityCompletionPartitions :one
+SELECT create_activity_completion_partitions(CURRENT_DATE, $1::int)::int AS created
+`
+
+// Creates the monthly partitions of activity completions up to
+// @months_ahead months after the current one and returns how many were
+// created
+func (q *Queries) CreateActivityCompletionPartitions(ctx context.Context, monthsAhead int32) (int32, error) {
+	row := q.db.QueryRow(ctx, createActivityCompletionPartitions, monthsAhead)
+	var created int32
+	err := row.Scan(&created)
+	return created, err
+}
+
 const deleteActivity = `-- name: DeleteActivity :exec
-DELETE FROM activities
-WHERE id = $1
+UPDATE activities
+  SET deleted_at = NOW(),
+    is_active = false,
+    updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
 `
 
+// Marks an activity deleted and deactivates it so that it can no longer be
+// completed. Its completions are kept
 func (q *Queries) DeleteActivity(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.Exec(ctx, deleteActivity, id)
 	return err
 }
 
+const dropExpiredActivityCompletionPartitions = `-- name: DropExpiredActivityCompletionPartitions :many
+SELECT dropped::text
+FROM drop_activity_completion_partitions(
+  (date_trunc('month', CURRENT_DATE) - make_interval(months => $1::int))::date
+) AS dropped
+`
+
+// Drops the monthly partitions of activity completions made before the last
+// @retention_months whole months and returns their names
+func (q *Queries) DropExpiredActivityCompletionPartitions(ctx context.Context, retentionMonths int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, dropExpiredActivityCompletionPartitions, retentionMonths)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var dropped string
+		if err := rows.Scan(&dropped); err != nil {
+			return nil, err
+		}
+		items = append(items, dropped)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getActivityByID = `-- name: GetActivityByID :one
-SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at  FROM activities WHERE id = $1
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at  FROM activities WHERE id = $1 AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -90,12 +190,14 @@ func (q *Queries) GetActivityByID(ctx context.Context, id uuid.UUID) (Activity,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CooldownSeconds,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getAllActiveActivities = `-- name: GetAllActiveActivities :many
-SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at FROM activities WHERE is_active = true LIMIT $1 OFFSET $2
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at FROM activities WHERE is_active = true AND deleted_at IS NULL LIMIT $1 OFFSET $2
 `
 
 type GetAllActiveActivitiesParams struct {
@@ -125,6 +227,8 @@ func (q *Queries) GetAllActiveActivities(ctx context.Context, arg GetAllActiveAc
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CooldownSeconds,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -137,7 +241,7 @@ func (q *Queries) GetAllActiveActivities(ctx context.Context, arg GetAllActiveAc
 }
 
 const getAllActiveActivitiesCount = `-- name: GetAllActiveActivitiesCount :one
-SELECT COUNT(id) FROM activities WHERE is_active = true
+SELECT COUNT(id) FROM activities WHERE is_active = true AND deleted_at IS NULL
 `
 
 // Returns all the active activities count in the system
@@ -149,7 +253,7 @@ func (q *Queries) GetAllActiveActivitiesCount(ctx context.Context) (int64, error
 }
 
 const getAllActivities = `-- name: GetAllActivities :many
-SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at FROM activities LIMIT $1 OFFSET $2
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at FROM activities WHERE deleted_at IS NULL LIMIT $1 OFFSET $2
 `
 
 type GetAllActivitiesParams struct {
@@ -179,6 +283,8 @@ func (q *Queries) GetAllActivities(ctx context.Context, arg GetAllActivitiesPara
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CooldownSeconds,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -191,7 +297,7 @@ func (q *Queries) GetAllActivities(ctx context.Context, arg GetAllActivitiesPara
 }
 
 const getAllActivitiesCount = `-- name: GetAllActivitiesCount :one
-SELECT COUNT(id) FROM activities
+SELECT COUNT(id) FROM activities WHERE deleted_at IS NULL
 `
 
 // Returns all activities count regardless of activity status
@@ -203,7 +309,7 @@ func (q *Queries) GetAllActivitiesCount(ctx context.Context) (int64, error) {
 }
 
 const getAllInactiveActivities = `-- name: GetAllInactiveActivities :many
-SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at FROM activities WHERE is_active = false LIMIT $1 OFFSET $2
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at FROM activities WHERE is_active = false AND deleted_at IS NULL LIMIT $1 OFFSET $2
 `
 
 type GetAllInactiveActivitiesParams struct {
@@ -233,6 +339,8 @@ func (q *Queries) GetAllInactiveActivities(ctx context.Context, arg GetAllInacti
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.CooldownSeconds,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -245,7 +353,7 @@ func (q *Queries) GetAllInactiveActivities(ctx context.Context, arg GetAllInacti
 }
 
 const getAllInactiveActivitiesCount = `-- name: GetAllInactiveActivitiesCount :one
-SELECT COUNT(id) FROM activities WHERE is_active = false
+SELECT COUNT(id) FROM activities WHERE is_active = false AND deleted_at IS NULL
 `
 
 // Returns all the inactive activities count in the system
@@ -258,6 +366,7 @@ func (q *Queries) GetAllInactiveActivitiesCount(ctx context.Context) (int64, err
 
 const getAllUserActivityCompletions = `-- name: GetAllUserActivityCompletions :many
 SELECT id, account_id, activity_id, completed_at, completion_date, points_earned, metadata FROM activity_completions WHERE account_id = $1
+ORDER BY completed_at DESC, id DESC
 LIMIT $2 OFFSET $3
 `
 
@@ -310,6 +419,146 @@ func (q *Queries) GetAllUserActivityCompletionsCount(ctx context.Context, accoun
 	return count, err
 }
 
+const listActivitiesAfter = `-- name: ListActivitiesAfter :many
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at FROM activities
+WHERE deleted_at IS NULL
+  AND ($1::timestamp IS NULL
+    OR (created_at, id) < ($1::timestamp, $2::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type ListActivitiesAfterParams struct {
+	AfterCreatedAt pgtype.Timestamp `json:"after_created_at"`
+	AfterID        pgtype.UUID      `json:"after_id"`
+	PageSize       int32            `json:"page_size"`
+}
+
+// Returns all activities newest first, continuing after the activity a
+// cursor points at. Both cursor values are null for the first page
+func (q *Queries) ListActivitiesAfter(ctx context.Context, arg ListActivitiesAfterParams) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, listActivitiesAfter, arg.AfterCreatedAt, arg.AfterID, arg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Activity{}
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Category,
+			&i.PointsAwarded,
+			&i.MaxDailyCompletions,
+			&i.StreakEligible,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CooldownSeconds,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeletedActivities = `-- name: ListDeletedActivities :many
+SELECT id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at FROM activities
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1 OFFSET $2
+`
+
+type ListDeletedActivitiesParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Returns the deleted activities, most recently deleted first
+func (q *Queries) ListDeletedActivities(ctx context.Context, arg ListDeletedActivitiesParams) ([]Activity, error) {
+	rows, err := q.db.Query(ctx, listDeletedActivities, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Activity{}
+	for rows.Next() {
+		var i Activity
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Category,
+			&i.PointsAwarded,
+			&i.MaxDailyCompletions,
+			&i.StreakEligible,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.CooldownSeconds,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pruneArchivedActivityCompletions = `-- name: PruneArchivedActivityCompletions :execrows
+DELETE FROM activity_completions_archive
+WHERE completed_at < date_trunc('month', CURRENT_DATE) - make_interval(months => $1::int)
+`
+
+// Deletes the archived completions made before the last @retention_months
+// whole months
+func (q *Queries) PruneArchivedActivityCompletions(ctx context.Context, retentionMonths int32) (int64, error) {
+	result, err := q.db.Exec(ctx, pruneArchivedActivityCompletions, retentionMonths)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreActivity = `-- name: RestoreActivity :one
+UPDATE activities
+  SET deleted_at = NULL,
+    updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at
+`
+
+// Restores a deleted activity. It stays inactive until it is activated again
+func (q *Queries) RestoreActivity(ctx context.Context, id uuid.UUID) (Activity, error) {
+	row := q.db.QueryRow(ctx, restoreActivity, id)
+	var i Activity
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Category,
+		&i.PointsAwarded,
+		&i.MaxDailyCompletions,
+		&i.StreakEligible,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.CooldownSeconds,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateActivity = `-- name: UpdateActivity :one
 UPDATE activities
   SET 
@@ -320,9 +569,10 @@ UPDATE activities
     max_daily_completions = COALESCE(NULLIF($6::smallint,0), max_daily_completions),
     streak_eligible = COALESCE(NULLIF($7::boolean,false), streak_eligible),
     is_active = COALESCE(NULLIF($8::boolean,false), is_active),
+    cooldown_seconds = COALESCE($9::integer, cooldown_seconds),
     updated_at = NOW()
-  WHERE id = $1
-RETURNING id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, description, category, points_awarded, max_daily_completions, streak_eligible, is_active, created_at, updated_at, cooldown_seconds, deleted_at
 `
 
 type UpdateActivityParams struct {
@@ -334,6 +584,7 @@ type UpdateActivityParams struct {
 	MaxDailyCompletions int16     `json:"max_daily_completions"`
 	StreakEligible      bool      `json:"streak_eligible"`
 	IsActive            bool      `json:"is_active"`
+	CooldownSeconds     *int32    `json:"cooldown_seconds"`
 }
 
 // Updates an activity specified by its ID
@@ -347,6 +598,7 @@ func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams)
 		arg.MaxDailyCompletions,
 		arg.StreakEligible,
 		arg.IsActive,
+		arg.CooldownSeconds,
 	)
 	var i Activity
 	err := row.Scan(
@@ -360,6 +612,8 @@ func (q *Queries) UpdateActivity(ctx context.Context, arg UpdateActivityParams)
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.CooldownSeconds,
+		&i.DeletedAt,
 	)
 	return i, err
 }
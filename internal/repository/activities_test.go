@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestUpdateActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	activity, err := repo.CreateActivity(ctx, repository.CreateActivityParams{
+		Name:          "Daily login",
+		PointsAwarded: 5,
+	})
+	if err != nil {
+		t.Fatalf("Could not create activity: %v", err)
+	}
+
+	updated, err := repo.UpdateActivity(ctx, repository.UpdateActivityParams{
+		ID:            activity.ID,
+		PointsAwarded: 10,
+	})
+	if err != nil {
+		t.Fatalf("Could not update activity: %v", err)
+	}
+	if updated.PointsAwarded != 10 {
+		t.Errorf("Expected 10 points to be awarded, got %d", updated.PointsAwarded)
+	}
+	if updated.Name != "Daily login" {
+		t.Errorf("Expected the name to be kept, got %q", updated.Name)
+	}
+}
+
+func TestUpdateDeletedActivity(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	activity, err := repo.CreateActivity(ctx, repository.CreateActivityParams{
+		Name:          "Daily login",
+		PointsAwarded: 5,
+	})
+	if err != nil {
+		t.Fatalf("Could not create activity: %v", err)
+	}
+	if err := repo.DeleteActivity(ctx, activity.ID); err != nil {
+		t.Fatalf("Could not delete activity: %v", err)
+	}
+
+	_, err = repo.UpdateActivity(ctx, repository.UpdateActivityParams{
+		ID:   activity.ID,
+		Name: "Renamed",
+	})
+	if !errors.Is(err, pgx.ErrNoRows) {
+		t.Fatalf("Expected a deleted activity not to be updated, got %v", err)
+	}
+}
@@ -7,56 +7,181 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/google/uuid"
 )
 
 const addAccountInstitution = `-- name: AddAccountInstitution :one
 WITH ins AS (
-  INSERT INTO account_institutions (account_id, institution_id)
-  VALUES ($1, $2)
+  INSERT INTO account_institutions (account_id, institution_id, membership_role)
+  VALUES ($1, $2, $3)
   ON CONFLICT DO NOTHING
-  RETURNING account_id, institution_id
+  RETURNING account_id, institution_id, membership_role
 )
-SELECT account_id, institution_id FROM ins
+SELECT account_id, institution_id, membership_role FROM ins
 UNION
-SELECT account_id, institution_id FROM account_institutions
+SELECT account_id, institution_id, membership_role FROM account_institutions
 WHERE account_id = $1 AND institution_id = $2
 `
 
 type AddAccountInstitutionParams struct {
-	AccountID     uuid.UUID `json:"account_id"`
-	InstitutionID int32     `json:"institution_id"`
+	AccountID      uuid.UUID                 `json:"account_id"`
+	InstitutionID  int32                     `json:"institution_id"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
 }
 
 type AddAccountInstitutionRow struct {
-	AccountID     uuid.UUID `json:"account_id"`
-	InstitutionID int32     `json:"institution_id"`
+	AccountID      uuid.UUID                 `json:"account_id"`
+	InstitutionID  int32                     `json:"institution_id"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
 }
 
 func (q *Queries) AddAccountInstitution(ctx context.Context, arg AddAccountInstitutionParams) (AddAccountInstitutionRow, error) {
-	row := q.db.QueryRow(ctx, addAccountInstitution, arg.AccountID, arg.InstitutionID)
+	row := q.db.QueryRow(ctx, addAccountInstitution, arg.AccountID, arg.InstitutionID, arg.MembershipRole)
 	var i AddAccountInstitutionRow
-	err := row.Scan(&i.AccountID, &i.InstitutionID)
+	err := row.Scan(&i.AccountID, &i.InstitutionID, &i.MembershipRole)
+	return i, err
+}
+
+const addAccountInstitutions = `-- name: AddAccountInstitutions :execrows
+INSERT INTO account_institutions (account_id, institution_id)
+SELECT unnest($1::uuid[]), $2::int
+`
+
+type AddAccountInstitutionsParams struct {
+	AccountIds    []uuid.UUID `json:"account_ids"`
+	InstitutionID int32       `json:"institution_id"`
+}
+
+// Links accounts to an institution as members in a single statement, COPY
+// is refused on tables under row level security. Unlike
+// AddAccountInstitution it fails on accounts that are already members
+func (q *Queries) AddAccountInstitutions(ctx context.Context, arg AddAccountInstitutionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addAccountInstitutions, arg.AccountIds, arg.InstitutionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const archiveInstitution = `-- name: ArchiveInstitution :one
+UPDATE institutions
+SET archived_at = NOW()
+WHERE institution_id = $1 AND archived_at IS NULL
+RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at
+`
+
+func (q *Queries) ArchiveInstitution(ctx context.Context, institutionID int32) (Institution, error) {
+	row := q.db.QueryRow(ctx, archiveInstitution, institutionID)
+	var i Institution
+	err := row.Scan(
+		&i.InstitutionID,
+		&i.Name,
+		&i.WebPages,
+		&i.Domains,
+		&i.AlphaTwoCode,
+		&i.Country,
+		&i.StateProvince,
+		&i.LogoUrl,
+		&i.PrimaryColor,
+		&i.SecondaryColor,
+		&i.Website,
+		&i.Timezone,
+		&i.Metadata,
+		&i.ArchivedAt,
+	)
 	return i, err
 }
 
+const countArchivedInstitutions = `-- name: CountArchivedInstitutions :one
+SELECT count(*) FROM institutions
+WHERE archived_at IS NOT NULL
+`
+
+func (q *Queries) CountArchivedInstitutions(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countArchivedInstitutions)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countInstitutionMembers = `-- name: CountInstitutionMembers :one
+SELECT count(*) FROM account_institutions
+WHERE institution_id = $1
+`
+
+// Returns the number of accounts linked to an institution
+func (q *Queries) CountInstitutionMembers(ctx context.Context, institutionID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countInstitutionMembers, institutionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countInstitutionOwners = `-- name: CountInstitutionOwners :one
+SELECT count(*) FROM account_institutions
+WHERE institution_id = $1 AND membership_role = 'owner'
+`
+
+// Returns the number of owners of an institution
+func (q *Queries) CountInstitutionOwners(ctx context.Context, institutionID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countInstitutionOwners, institutionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countInstitutionsByName = `-- name: CountInstitutionsByName :one
+SELECT count(*) FROM institutions
+WHERE lower(name) LIKE '%' || lower($1::varchar) || '%'
+  AND archived_at IS NULL
+`
+
+// Returns the number of institutions that are not archived matching a name
+func (q *Queries) CountInstitutionsByName(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRow(ctx, countInstitutionsByName, name)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countInstitutionsForAccount = `-- name: CountInstitutionsForAccount :one
+SELECT count(*) FROM account_institutions
+WHERE account_id = $1
+`
+
+// Returns the number of institutions an account belongs to
+func (q *Queries) CountInstitutionsForAccount(ctx context.Context, accountID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countInstitutionsForAccount, accountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createInstitution = `-- name: CreateInstitution :one
 INSERT INTO institutions (
-    name, web_pages, domains, alpha_two_code, country, state_province
+    name, web_pages, domains, alpha_two_code, country, state_province,
+    logo_url, primary_color, secondary_color, website, timezone, metadata
 ) VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
-RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province
+RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at
 `
 
 type CreateInstitutionParams struct {
-	Name          string   `json:"name"`
-	WebPages      []string `json:"web_pages"`
-	Domains       []string `json:"domains"`
-	AlphaTwoCode  *string  `json:"alpha_two_code"`
-	Country       *string  `json:"country"`
-	StateProvince *string  `json:"state_province"`
+	Name           string          `json:"name"`
+	WebPages       []string        `json:"web_pages"`
+	Domains        []string        `json:"domains"`
+	AlphaTwoCode   *string         `json:"alpha_two_code"`
+	Country        *string         `json:"country"`
+	StateProvince  *string         `json:"state_province"`
+	LogoUrl        *string         `json:"logo_url"`
+	PrimaryColor   *string         `json:"primary_color"`
+	SecondaryColor *string         `json:"secondary_color"`
+	Website        *string         `json:"website"`
+	Timezone       *string         `json:"timezone"`
+	Metadata       json.RawMessage `json:"metadata"`
 }
 
 func (q *Queries) CreateInstitution(ctx context.Context, arg CreateInstitutionParams) (Institution, error) {
@@ -67,6 +192,12 @@ func (q *Queries) CreateInstitution(ctx context.Context, arg CreateInstitutionPa
 		arg.AlphaTwoCode,
 		arg.Country,
 		arg.StateProvince,
+		arg.LogoUrl,
+		arg.PrimaryColor,
+		arg.SecondaryColor,
+		arg.Website,
+		arg.Timezone,
+		arg.Metadata,
 	)
 	var i Institution
 	err := row.Scan(
@@ -77,6 +208,13 @@ func (q *Queries) CreateInstitution(ctx context.Context, arg CreateInstitutionPa
 		&i.AlphaTwoCode,
 		&i.Country,
 		&i.StateProvince,
+		&i.LogoUrl,
+		&i.PrimaryColor,
+		&i.SecondaryColor,
+		&i.Website,
+		&i.Timezone,
+		&i.Metadata,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
@@ -92,7 +230,7 @@ func (q *Queries) DeleteInstitution(ctx context.Context, institutionID int32) er
 }
 
 const getInstitution = `-- name: GetInstitution :one
-SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province FROM institutions
+SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at FROM institutions
 WHERE institution_id = $1 LIMIT 1
 `
 
@@ -107,15 +245,68 @@ func (q *Queries) GetInstitution(ctx context.Context, institutionID int32) (Inst
 		&i.AlphaTwoCode,
 		&i.Country,
 		&i.StateProvince,
+		&i.LogoUrl,
+		&i.PrimaryColor,
+		&i.SecondaryColor,
+		&i.Website,
+		&i.Timezone,
+		&i.Metadata,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
 
+const getInstitutionManagers = `-- name: GetInstitutionManagers :many
+SELECT account_id FROM account_institutions
+WHERE institution_id = $1
+  AND membership_role IN ('owner', 'admin')
+`
+
+// Returns the accounts that administer an institution
+func (q *Queries) GetInstitutionManagers(ctx context.Context, institutionID int32) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, getInstitutionManagers, institutionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var account_id uuid.UUID
+		if err := rows.Scan(&account_id); err != nil {
+			return nil, err
+		}
+		items = append(items, account_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getInstitutionMembership = `-- name: GetInstitutionMembership :one
+SELECT account_id, institution_id, membership_role FROM account_institutions
+WHERE account_id = $1 AND institution_id = $2
+`
+
+type GetInstitutionMembershipParams struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	InstitutionID int32     `json:"institution_id"`
+}
+
+// Returns the membership of an account in an institution
+func (q *Queries) GetInstitutionMembership(ctx context.Context, arg GetInstitutionMembershipParams) (AccountInstitution, error) {
+	row := q.db.QueryRow(ctx, getInstitutionMembership, arg.AccountID, arg.InstitutionID)
+	var i AccountInstitution
+	err := row.Scan(&i.AccountID, &i.InstitutionID, &i.MembershipRole)
+	return i, err
+}
+
 const getInstitutionsCount = `-- name: GetInstitutionsCount :one
 SELECT count(*) from institutions
+WHERE archived_at IS NULL
 `
 
-// Returns the number of all institutions in the system
+// Returns the number of institutions in the system that are not archived
 func (q *Queries) GetInstitutionsCount(ctx context.Context) (int64, error) {
 	row := q.db.QueryRow(ctx, getInstitutionsCount)
 	var count int64
@@ -123,8 +314,28 @@ func (q *Queries) GetInstitutionsCount(ctx context.Context) (int64, error) {
 	return count, err
 }
 
+const isAccountInInstitution = `-- name: IsAccountInInstitution :one
+SELECT EXISTS (
+  SELECT 1 FROM account_institutions
+  WHERE account_id = $1 AND institution_id = $2
+)
+`
+
+type IsAccountInInstitutionParams struct {
+	AccountID     uuid.UUID `json:"account_id"`
+	InstitutionID int32     `json:"institution_id"`
+}
+
+// Reports whether an account is a member of an institution
+func (q *Queries) IsAccountInInstitution(ctx context.Context, arg IsAccountInInstitutionParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isAccountInInstitution, arg.AccountID, arg.InstitutionID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const listAccountsForInstitution = `-- name: ListAccountsForInstitution :many
-SELECT a.id, a.email, a.name, a.created_at, a.updated_at, a.terms_accepted, a.onboarded, a.type, a.national_id, a.username, a.avatar_url, a.bio, a.vibe_points, a.phone, a.deleted_at
+SELECT a.id, a.email, a.name, a.created_at, a.updated_at, a.terms_accepted, a.onboarded, a.type, a.national_id, a.username, a.avatar_url, a.bio, a.vibe_points, a.phone, a.deleted_at, a.deactivated_at, a.version, a.tenant_id
 FROM accounts a
 JOIN account_institutions ai ON a.id = ai.account_id
 WHERE ai.institution_id = $1
@@ -164,6 +375,140 @@ func (q *Queries) ListAccountsForInstitution(ctx context.Context, arg ListAccoun
 			&i.VibePoints,
 			&i.Phone,
 			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listArchivedInstitutions = `-- name: ListArchivedInstitutions :many
+SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at FROM institutions
+WHERE archived_at IS NOT NULL
+ORDER BY archived_at DESC, institution_id
+LIMIT $1 OFFSET $2
+`
+
+type ListArchivedInstitutionsParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Retrieves the archived institutions, most recently archived first
+func (q *Queries) ListArchivedInstitutions(ctx context.Context, arg ListArchivedInstitutionsParams) ([]Institution, error) {
+	rows, err := q.db.Query(ctx, listArchivedInstitutions, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Institution{}
+	for rows.Next() {
+		var i Institution
+		if err := rows.Scan(
+			&i.InstitutionID,
+			&i.Name,
+			&i.WebPages,
+			&i.Domains,
+			&i.AlphaTwoCode,
+			&i.Country,
+			&i.StateProvince,
+			&i.LogoUrl,
+			&i.PrimaryColor,
+			&i.SecondaryColor,
+			&i.Website,
+			&i.Timezone,
+			&i.Metadata,
+			&i.ArchivedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInstitutionMemberIDs = `-- name: ListInstitutionMemberIDs :many
+SELECT account_id FROM account_institutions
+WHERE institution_id = $1
+  AND account_id = ANY($2::uuid[])
+`
+
+type ListInstitutionMemberIDsParams struct {
+	InstitutionID int32       `json:"institution_id"`
+	AccountIds    []uuid.UUID `json:"account_ids"`
+}
+
+// Returns which of the accounts are already members of an institution
+func (q *Queries) ListInstitutionMemberIDs(ctx context.Context, arg ListInstitutionMemberIDsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listInstitutionMemberIDs, arg.InstitutionID, arg.AccountIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []uuid.UUID{}
+	for rows.Next() {
+		var account_id uuid.UUID
+		if err := rows.Scan(&account_id); err != nil {
+			return nil, err
+		}
+		items = append(items, account_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listInstitutionMembers = `-- name: ListInstitutionMembers :many
+SELECT a.id AS account_id, a.name, a.username, a.avatar_url, ai.membership_role
+FROM accounts a
+JOIN account_institutions ai ON a.id = ai.account_id
+WHERE ai.institution_id = $1
+ORDER BY ai.membership_role, a.name
+LIMIT $2
+OFFSET $3
+`
+
+type ListInstitutionMembersParams struct {
+	InstitutionID int32 `json:"institution_id"`
+	Limit         int32 `json:"limit"`
+	Offset        int32 `json:"offset"`
+}
+
+type ListInstitutionMembersRow struct {
+	AccountID      uuid.UUID                 `json:"account_id"`
+	Name           string                    `json:"name"`
+	Username       *string                   `json:"username"`
+	AvatarUrl      *string                   `json:"avatar_url"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
+}
+
+// Returns the members of an institution ranked by membership role
+func (q *Queries) ListInstitutionMembers(ctx context.Context, arg ListInstitutionMembersParams) ([]ListInstitutionMembersRow, error) {
+	rows, err := q.db.Query(ctx, listInstitutionMembers, arg.InstitutionID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListInstitutionMembersRow{}
+	for rows.Next() {
+		var i ListInstitutionMembersRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Name,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.MembershipRole,
 		); err != nil {
 			return nil, err
 		}
@@ -176,7 +521,8 @@ func (q *Queries) ListAccountsForInstitution(ctx context.Context, arg ListAccoun
 }
 
 const listInstitutions = `-- name: ListInstitutions :many
-SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province FROM institutions
+SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at FROM institutions
+WHERE archived_at IS NULL
 ORDER BY institution_id LIMIT $1 OFFSET $2
 `
 
@@ -202,6 +548,13 @@ func (q *Queries) ListInstitutions(ctx context.Context, arg ListInstitutionsPara
 			&i.AlphaTwoCode,
 			&i.Country,
 			&i.StateProvince,
+			&i.LogoUrl,
+			&i.PrimaryColor,
+			&i.SecondaryColor,
+			&i.Website,
+			&i.Timezone,
+			&i.Metadata,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -214,7 +567,7 @@ func (q *Queries) ListInstitutions(ctx context.Context, arg ListInstitutionsPara
 }
 
 const listInstitutionsForAccount = `-- name: ListInstitutionsForAccount :many
-SELECT i.institution_id, i.name, i.web_pages, i.domains, i.alpha_two_code, i.country, i.state_province
+SELECT i.institution_id, i.name, i.web_pages, i.domains, i.alpha_two_code, i.country, i.state_province, i.logo_url, i.primary_color, i.secondary_color, i.website, i.timezone, i.metadata, i.archived_at
 FROM institutions i
 JOIN account_institutions ai ON i.institution_id = ai.institution_id
 WHERE ai.account_id = $1
@@ -246,6 +599,13 @@ func (q *Queries) ListInstitutionsForAccount(ctx context.Context, arg ListInstit
 			&i.AlphaTwoCode,
 			&i.Country,
 			&i.StateProvince,
+			&i.LogoUrl,
+			&i.PrimaryColor,
+			&i.SecondaryColor,
+			&i.Website,
+			&i.Timezone,
+			&i.Metadata,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -272,10 +632,40 @@ func (q *Queries) RemoveAccountInstitution(ctx context.Context, arg RemoveAccoun
 	return err
 }
 
+const restoreInstitution = `-- name: RestoreInstitution :one
+UPDATE institutions
+SET archived_at = NULL
+WHERE institution_id = $1 AND archived_at IS NOT NULL
+RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at
+`
+
+func (q *Queries) RestoreInstitution(ctx context.Context, institutionID int32) (Institution, error) {
+	row := q.db.QueryRow(ctx, restoreInstitution, institutionID)
+	var i Institution
+	err := row.Scan(
+		&i.InstitutionID,
+		&i.Name,
+		&i.WebPages,
+		&i.Domains,
+		&i.AlphaTwoCode,
+		&i.Country,
+		&i.StateProvince,
+		&i.LogoUrl,
+		&i.PrimaryColor,
+		&i.SecondaryColor,
+		&i.Website,
+		&i.Timezone,
+		&i.Metadata,
+		&i.ArchivedAt,
+	)
+	return i, err
+}
+
 const searchInstitutionsByName = `-- name: SearchInstitutionsByName :many
-SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province
+SELECT institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at
 FROM institutions
 WHERE lower(name) LIKE '%' || lower($3::varchar) || '%'
+  AND archived_at IS NULL
 ORDER BY name
 LIMIT $1 OFFSET $2
 `
@@ -303,6 +693,13 @@ func (q *Queries) SearchInstitutionsByName(ctx context.Context, arg SearchInstit
 			&i.AlphaTwoCode,
 			&i.Country,
 			&i.StateProvince,
+			&i.LogoUrl,
+			&i.PrimaryColor,
+			&i.SecondaryColor,
+			&i.Website,
+			&i.Timezone,
+			&i.Metadata,
+			&i.ArchivedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -314,6 +711,20 @@ func (q *Queries) SearchInstitutionsByName(ctx context.Context, arg SearchInstit
 	return items, nil
 }
 
+const unlinkInstitutionAccounts = `-- name: UnlinkInstitutionAccounts :execrows
+DELETE FROM account_institutions
+WHERE institution_id = $1
+`
+
+// Removes every account from an institution
+func (q *Queries) UnlinkInstitutionAccounts(ctx context.Context, institutionID int32) (int64, error) {
+	result, err := q.db.Exec(ctx, unlinkInstitutionAccounts, institutionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateInstitution = `-- name: UpdateInstitution :one
 UPDATE institutions
 SET 
@@ -322,19 +733,31 @@ SET
     domains = COALESCE(NULLIF($3::text[], '{}'), domains),
     alpha_two_code = COALESCE(NULLIF($4::char(2), ''), alpha_two_code),
     country = COALESCE(NULLIF($5::varchar, ''), country),
-    state_province = COALESCE(NULLIF($6::varchar, ''), state_province)
-WHERE institution_id = $7
-RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province
+    state_province = COALESCE(NULLIF($6::varchar, ''), state_province),
+    logo_url = COALESCE(NULLIF($7::text, ''), logo_url),
+    primary_color = COALESCE(NULLIF($8::text, ''), primary_color),
+    secondary_color = COALESCE(NULLIF($9::text, ''), secondary_color),
+    website = COALESCE(NULLIF($10::text, ''), website),
+    timezone = COALESCE(NULLIF($11::text, ''), timezone),
+    metadata = COALESCE($12, metadata)
+WHERE institution_id = $13
+RETURNING institution_id, name, web_pages, domains, alpha_two_code, country, state_province, logo_url, primary_color, secondary_color, website, timezone, metadata, archived_at
 `
 
 type UpdateInstitutionParams struct {
-	Name          string   `json:"name"`
-	WebPages      []string `json:"web_pages"`
-	Domains       []string `json:"domains"`
-	AlphaTwoCode  string   `json:"alpha_two_code"`
-	Country       string   `json:"country"`
-	StateProvince string   `json:"state_province"`
-	InstitutionID int32    `json:"institution_id"`
+	Name           string          `json:"name"`
+	WebPages       []string        `json:"web_pages"`
+	Domains        []string        `json:"domains"`
+	AlphaTwoCode   string          `json:"alpha_two_code"`
+	Country        string          `json:"country"`
+	StateProvince  string          `json:"state_province"`
+	LogoUrl        string          `json:"logo_url"`
+	PrimaryColor   string          `json:"primary_color"`
+	SecondaryColor string          `json:"secondary_color"`
+	Website        string          `json:"website"`
+	Timezone       string          `json:"timezone"`
+	Metadata       json.RawMessage `json:"metadata"`
+	InstitutionID  int32           `json:"institution_id"`
 }
 
 func (q *Queries) UpdateInstitution(ctx context.Context, arg UpdateInstitutionParams) (Institution, error) {
@@ -345,6 +768,12 @@ func (q *Queries) UpdateInstitution(ctx context.Context, arg UpdateInstitutionPa
 		arg.AlphaTwoCode,
 		arg.Country,
 		arg.StateProvince,
+		arg.LogoUrl,
+		arg.PrimaryColor,
+		arg.SecondaryColor,
+		arg.Website,
+		arg.Timezone,
+		arg.Metadata,
 		arg.InstitutionID,
 	)
 	var i Institution
@@ -356,6 +785,34 @@ func (q *Queries) UpdateInstitution(ctx context.Context, arg UpdateInstitutionPa
 		&i.AlphaTwoCode,
 		&i.Country,
 		&i.StateProvince,
+		&i.LogoUrl,
+		&i.PrimaryColor,
+		&i.SecondaryColor,
+		&i.Website,
+		&i.Timezone,
+		&i.Metadata,
+		&i.ArchivedAt,
 	)
 	return i, err
 }
+
+const updateInstitutionMembershipRole = `-- name: UpdateInstitutionMembershipRole :one
+UPDATE account_institutions
+SET membership_role = $3
+WHERE account_id = $1 AND institution_id = $2
+RETURNING account_id, institution_id, membership_role
+`
+
+type UpdateInstitutionMembershipRoleParams struct {
+	AccountID      uuid.UUID                 `json:"account_id"`
+	InstitutionID  int32                     `json:"institution_id"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
+}
+
+// Changes the membership role of an account within an institution
+func (q *Queries) UpdateInstitutionMembershipRole(ctx context.Context, arg UpdateInstitutionMembershipRoleParams) (AccountInstitution, error) {
+	row := q.db.QueryRow(ctx, updateInstitutionMembershipRole, arg.AccountID, arg.InstitutionID, arg.MembershipRole)
+	var i AccountInstitution
+	err := row.Scan(&i.AccountID, &i.InstitutionID, &i.MembershipRole)
+	return i, err
+}
@@ -0,0 +1,133 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestCreateInstitution(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	website := "https://example.edu"
+	institution, err := repo.CreateInstitution(ctx, repository.CreateInstitutionParams{
+		Name:     "Example University",
+		WebPages: []string{website},
+		Domains:  []string{"example.edu"},
+		Website:  &website,
+	})
+	if err != nil {
+		t.Fatalf("Could not create institution: %v", err)
+	}
+	if institution.Name != "Example University" {
+		t.Errorf("Expected the name Example University, got %q", institution.Name)
+	}
+	if len(institution.Domains) != 1 || institution.Domains[0] != "example.edu" {
+		t.Errorf("Expected the domain example.edu, got %v", institution.Domains)
+	}
+	if institution.ArchivedAt.Valid {
+		t.Errorf("Expected a new institution not to be archived, got %v", institution.ArchivedAt.Time)
+	}
+
+	got, err := repo.GetInstitution(ctx, institution.InstitutionID)
+	if err != nil {
+		t.Fatalf("Could not get institution: %v", err)
+	}
+	if got.Name != institution.Name {
+		t.Errorf("Expected to get %q, got %q", institution.Name, got.Name)
+	}
+}
+
+func TestListInstitutionsForAccount(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+		Email: "member@example.edu",
+		Name:  "Member",
+		Type:  repository.AccountTypeHuman,
+	})
+	if err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	institution, err := repo.CreateInstitution(ctx, repository.CreateInstitutionParams{
+		Name: "Example University",
+	})
+	if err != nil {
+		t.Fatalf("Could not create institution: %v", err)
+	}
+	if _, err := repo.AddAccountInstitution(ctx, repository.AddAccountInstitutionParams{
+		AccountID:      account.ID,
+		InstitutionID:  institution.InstitutionID,
+		MembershipRole: repository.InstitutionMembershipRoleMember,
+	}); err != nil {
+		t.Fatalf("Could not add account to institution: %v", err)
+	}
+	if _, err := repo.ArchiveInstitution(ctx, institution.InstitutionID); err != nil {
+		t.Fatalf("Could not archive institution: %v", err)
+	}
+
+	institutions, err := repo.ListInstitutionsForAccount(ctx, repository.ListInstitutionsForAccountParams{
+		AccountID: account.ID,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatalf("Could not list institutions: %v", err)
+	}
+	if len(institutions) != 1 || institutions[0].InstitutionID != institution.InstitutionID {
+		t.Fatalf("Expected the institution of the account, got %v", institutions)
+	}
+	if !institutions[0].ArchivedAt.Valid {
+		t.Errorf("Expected the institution to be listed as archived")
+	}
+}
+
+func TestAddAccountInstitutions(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	institution, err := repo.CreateInstitution(ctx, repository.CreateInstitutionParams{
+		Name: "Example University",
+	})
+	if err != nil {
+		t.Fatalf("Could not create institution: %v", err)
+	}
+	accountIDs := []uuid.UUID{}
+	for _, email := range []string{"ada@example.edu", "grace@example.edu"} {
+		account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+			Email: email,
+			Name:  email,
+			Type:  repository.AccountTypeHuman,
+		})
+		if err != nil {
+			t.Fatalf("Could not create account: %v", err)
+		}
+		accountIDs = append(accountIDs, account.ID)
+	}
+
+	added, err := repo.AddAccountInstitutions(ctx, repository.AddAccountInstitutionsParams{
+		AccountIds:    accountIDs,
+		InstitutionID: institution.InstitutionID,
+	})
+	if err != nil {
+		t.Fatalf("Could not add accounts to institution: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("Expected 2 accounts to be added, got %d", added)
+	}
+
+	members, err := repo.ListInstitutionMemberIDs(ctx, repository.ListInstitutionMemberIDsParams{
+		InstitutionID: institution.InstitutionID,
+		AccountIds:    accountIDs,
+	})
+	if err != nil {
+		t.Fatalf("Could not list members: %v", err)
+	}
+	if len(members) != 2 {
+		t.Errorf("Expected both accounts to be members, got %v", members)
+	}
+}
@@ -9,10 +9,88 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const getFriendsLeaderboard = `-- name: GetFriendsLeaderboard :many
+SELECT r.id, r.email, r.name, r.username, r.vibe_points, r.avatar_url, r.created_at, r.updated_at, r.vibe_rank, r.tenant_id, RANK() OVER (ORDER BY r.vibe_points DESC) AS friend_rank
+FROM account_vibepoint_rank r
+JOIN account_follows f ON f.followee_id = r.id
+WHERE f.follower_id = $1
+ORDER BY r.vibe_points DESC, r.name
+LIMIT $2 OFFSET $3
+`
+
+type GetFriendsLeaderboardParams struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	Limit      int32     `json:"limit"`
+	Offset     int32     `json:"offset"`
+}
+
+type GetFriendsLeaderboardRow struct {
+	ID         uuid.UUID        `json:"id"`
+	Email      string           `json:"email"`
+	Name       string           `json:"name"`
+	Username   *string          `json:"username"`
+	VibePoints int64            `json:"vibe_points"`
+	AvatarUrl  *string          `json:"avatar_url"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+	VibeRank   int64            `json:"vibe_rank"`
+	TenantID   string           `json:"tenant_id"`
+	FriendRank int64            `json:"friend_rank"`
+}
+
+// Get the accounts a user follows ranked by vibe points
+func (q *Queries) GetFriendsLeaderboard(ctx context.Context, arg GetFriendsLeaderboardParams) ([]GetFriendsLeaderboardRow, error) {
+	rows, err := q.db.Query(ctx, getFriendsLeaderboard, arg.FollowerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetFriendsLeaderboardRow{}
+	for rows.Next() {
+		var i GetFriendsLeaderboardRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.Username,
+			&i.VibePoints,
+			&i.AvatarUrl,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.VibeRank,
+			&i.TenantID,
+			&i.FriendRank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFriendsLeaderboardCount = `-- name: GetFriendsLeaderboardCount :one
+SELECT COUNT(*)
+FROM account_vibepoint_rank r
+JOIN account_follows f ON f.followee_id = r.id
+WHERE f.follower_id = $1
+`
+
+func (q *Queries) GetFriendsLeaderboardCount(ctx context.Context, followerID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getFriendsLeaderboardCount, followerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const getGlobalLeaderBoardCount = `-- name: GetGlobalLeaderBoardCount :one
 SELECT COUNT(*) FROM account_vibepoint_rank
+WHERE verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant()
 `
 
 func (q *Queries) GetGlobalLeaderBoardCount(ctx context.Context) (int64, error) {
@@ -23,8 +101,9 @@ func (q *Queries) GetGlobalLeaderBoardCount(ctx context.Context) (int64, error)
 }
 
 const getLeaderBoardRankForUser = `-- name: GetLeaderBoardRankForUser :one
-SELECT id, email, name, username, vibe_points, avatar_url, created_at, updated_at, vibe_rank FROM account_vibepoint_rank
+SELECT id, email, name, username, vibe_points, avatar_url, created_at, updated_at, vibe_rank, tenant_id FROM account_vibepoint_rank
 WHERE id = $1
+  AND (verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant())
 LIMIT 1 OFFSET 0
 `
 
@@ -42,12 +121,15 @@ func (q *Queries) GetLeaderBoardRankForUser(ctx context.Context, id uuid.UUID) (
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.VibeRank,
+		&i.TenantID,
 	)
 	return i, err
 }
 
 const getLeaderboard = `-- name: GetLeaderboard :many
-SELECT id, email, name, username, vibe_points, avatar_url, created_at, updated_at, vibe_rank FROM account_vibepoint_rank
+SELECT id, email, name, username, vibe_points, avatar_url, created_at, updated_at, vibe_rank, tenant_id FROM account_vibepoint_rank
+WHERE verisafe_every_tenant_visible() OR tenant_id = verisafe_current_tenant()
+ORDER BY vibe_rank, id
 LIMIT $1 OFFSET $2
 `
 
@@ -56,7 +138,8 @@ type GetLeaderboardParams struct {
 	Offset int32 `json:"offset"`
 }
 
-// Get top N users ranked by vibe points
+// Get top N users ranked by vibe points. Ranks are kept per tenant, only
+// the accounts of the tenant being served are listed
 func (q *Queries) GetLeaderboard(ctx context.Context, arg GetLeaderboardParams) ([]AccountVibepointRank, error) {
 	rows, err := q.db.Query(ctx, getLeaderboard, arg.Limit, arg.Offset)
 	if err != nil {
@@ -76,6 +159,7 @@ func (q *Queries) GetLeaderboard(ctx context.Context, arg GetLeaderboardParams)
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.VibeRank,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -86,3 +170,27 @@ func (q *Queries) GetLeaderboard(ctx context.Context, arg GetLeaderboardParams)
 	}
 	return items, nil
 }
+
+const refreshLeaderboard = `-- name: RefreshLeaderboard :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY account_vibepoint_rank
+`
+
+// Recomputes the global ranks. Readers keep seeing the previous ranks until
+// the refresh commits
+func (q *Queries) RefreshLeaderboard(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, refreshLeaderboard)
+	return err
+}
+
+const tryLockLeaderboardRefresh = `-- name: TryLockLeaderboardRefresh :one
+SELECT pg_try_advisory_xact_lock(hashtext('leaderboard_refresh'))::boolean AS locked
+`
+
+// Takes a transaction scoped lock so that only one instance refreshes the
+// leaderboard at a time. Returns false when another instance holds the lock
+func (q *Queries) TryLockLeaderboardRefresh(ctx context.Context) (bool, error) {
+	row := q.db.QueryRow(ctx, tryLockLeaderboardRefresh)
+	var locked bool
+	err := row.Scan(&locked)
+	return locked, err
+}
@@ -0,0 +1,12 @@
+package repository_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testdb.Main(m))
+}
@@ -6,6 +6,7 @@ package repository
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -57,6 +58,265 @@ func (ns NullAccountType) Value() (driver.Value, error) {
 	return string(ns.AccountType), nil
 }
 
+type InstitutionJoinRequestStatus string
+
+const (
+	InstitutionJoinRequestStatusPending  InstitutionJoinRequestStatus = "pending"
+	InstitutionJoinRequestStatusApproved InstitutionJoinRequestStatus = "approved"
+	InstitutionJoinRequestStatusRejected InstitutionJoinRequestStatus = "rejected"
+)
+
+func (e *InstitutionJoinRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InstitutionJoinRequestStatus(s)
+	case string:
+		*e = InstitutionJoinRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InstitutionJoinRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullInstitutionJoinRequestStatus struct {
+	InstitutionJoinRequestStatus InstitutionJoinRequestStatus `json:"institution_join_request_status"`
+	Valid                        bool                         `json:"valid"` // Valid is true if InstitutionJoinRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInstitutionJoinRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.InstitutionJoinRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InstitutionJoinRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInstitutionJoinRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InstitutionJoinRequestStatus), nil
+}
+
+type InstitutionMemberImportStatus string
+
+const (
+	InstitutionMemberImportStatusPending   InstitutionMemberImportStatus = "pending"
+	InstitutionMemberImportStatusRunning   InstitutionMemberImportStatus = "running"
+	InstitutionMemberImportStatusCompleted InstitutionMemberImportStatus = "completed"
+	InstitutionMemberImportStatusFailed    InstitutionMemberImportStatus = "failed"
+)
+
+func (e *InstitutionMemberImportStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InstitutionMemberImportStatus(s)
+	case string:
+		*e = InstitutionMemberImportStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InstitutionMemberImportStatus: %T", src)
+	}
+	return nil
+}
+
+type NullInstitutionMemberImportStatus struct {
+	InstitutionMemberImportStatus InstitutionMemberImportStatus `json:"institution_member_import_status"`
+	Valid                         bool                          `json:"valid"` // Valid is true if InstitutionMemberImportStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInstitutionMemberImportStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.InstitutionMemberImportStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InstitutionMemberImportStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInstitutionMemberImportStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InstitutionMemberImportStatus), nil
+}
+
+type InstitutionMembershipRole string
+
+const (
+	InstitutionMembershipRoleOwner  InstitutionMembershipRole = "owner"
+	InstitutionMembershipRoleAdmin  InstitutionMembershipRole = "admin"
+	InstitutionMembershipRoleMember InstitutionMembershipRole = "member"
+)
+
+func (e *InstitutionMembershipRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = InstitutionMembershipRole(s)
+	case string:
+		*e = InstitutionMembershipRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for InstitutionMembershipRole: %T", src)
+	}
+	return nil
+}
+
+type NullInstitutionMembershipRole struct {
+	InstitutionMembershipRole InstitutionMembershipRole `json:"institution_membership_role"`
+	Valid                     bool                      `json:"valid"` // Valid is true if InstitutionMembershipRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullInstitutionMembershipRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.InstitutionMembershipRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.InstitutionMembershipRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullInstitutionMembershipRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.InstitutionMembershipRole), nil
+}
+
+type LeaderboardSeasonEndAction string
+
+const (
+	LeaderboardSeasonEndActionKeep  LeaderboardSeasonEndAction = "keep"
+	LeaderboardSeasonEndActionDecay LeaderboardSeasonEndAction = "decay"
+	LeaderboardSeasonEndActionReset LeaderboardSeasonEndAction = "reset"
+)
+
+func (e *LeaderboardSeasonEndAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = LeaderboardSeasonEndAction(s)
+	case string:
+		*e = LeaderboardSeasonEndAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for LeaderboardSeasonEndAction: %T", src)
+	}
+	return nil
+}
+
+type NullLeaderboardSeasonEndAction struct {
+	LeaderboardSeasonEndAction LeaderboardSeasonEndAction `json:"leaderboard_season_end_action"`
+	Valid                      bool                       `json:"valid"` // Valid is true if LeaderboardSeasonEndAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullLeaderboardSeasonEndAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.LeaderboardSeasonEndAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.LeaderboardSeasonEndAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullLeaderboardSeasonEndAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.LeaderboardSeasonEndAction), nil
+}
+
+type RoleAssignmentRequestStatus string
+
+const (
+	RoleAssignmentRequestStatusPending  RoleAssignmentRequestStatus = "pending"
+	RoleAssignmentRequestStatusApproved RoleAssignmentRequestStatus = "approved"
+	RoleAssignmentRequestStatusRejected RoleAssignmentRequestStatus = "rejected"
+)
+
+func (e *RoleAssignmentRequestStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = RoleAssignmentRequestStatus(s)
+	case string:
+		*e = RoleAssignmentRequestStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for RoleAssignmentRequestStatus: %T", src)
+	}
+	return nil
+}
+
+type NullRoleAssignmentRequestStatus struct {
+	RoleAssignmentRequestStatus RoleAssignmentRequestStatus `json:"role_assignment_request_status"`
+	Valid                       bool                        `json:"valid"` // Valid is true if RoleAssignmentRequestStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullRoleAssignmentRequestStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.RoleAssignmentRequestStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.RoleAssignmentRequestStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullRoleAssignmentRequestStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.RoleAssignmentRequestStatus), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+func (e *WebhookDeliveryStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WebhookDeliveryStatus(s)
+	case string:
+		*e = WebhookDeliveryStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WebhookDeliveryStatus: %T", src)
+	}
+	return nil
+}
+
+type NullWebhookDeliveryStatus struct {
+	WebhookDeliveryStatus WebhookDeliveryStatus `json:"webhook_delivery_status"`
+	Valid                 bool                  `json:"valid"` // Valid is true if WebhookDeliveryStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWebhookDeliveryStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.WebhookDeliveryStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WebhookDeliveryStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWebhookDeliveryStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WebhookDeliveryStatus), nil
+}
+
 type Account struct {
 	ID            uuid.UUID        `json:"id"`
 	Email         string           `json:"email"`
@@ -73,11 +333,31 @@ type Account struct {
 	VibePoints    int64            `json:"vibe_points"`
 	Phone         *string          `json:"phone"`
 	DeletedAt     *time.Time       `json:"deleted_at"`
+	DeactivatedAt *time.Time       `json:"deactivated_at"`
+	Version       int64            `json:"version"`
+	TenantID      string           `json:"tenant_id"`
+}
+
+type AccountFollow struct {
+	FollowerID uuid.UUID        `json:"follower_id"`
+	FolloweeID uuid.UUID        `json:"followee_id"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type AccountGuardian struct {
+	GuardianID   uuid.UUID        `json:"guardian_id"`
+	ManagedID    uuid.UUID        `json:"managed_id"`
+	Relationship string           `json:"relationship"`
+	Restrictions []string         `json:"restrictions"`
+	CreatedBy    pgtype.UUID      `json:"created_by"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+	UpdatedAt    pgtype.Timestamp `json:"updated_at"`
 }
 
 type AccountInstitution struct {
-	AccountID     uuid.UUID `json:"account_id"`
-	InstitutionID int32     `json:"institution_id"`
+	AccountID      uuid.UUID                 `json:"account_id"`
+	InstitutionID  int32                     `json:"institution_id"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
 }
 
 type AccountInstitutionInfo struct {
@@ -93,6 +373,23 @@ type AccountInstitutionInfo struct {
 	InstitutionCountryCode *string          `json:"institution_country_code"`
 }
 
+type AccountTag struct {
+	AccountID uuid.UUID        `json:"account_id"`
+	Tag       string           `json:"tag"`
+	CreatedBy pgtype.UUID      `json:"created_by"`
+	CreatedAt pgtype.Timestamp `json:"created_at"`
+}
+
+type AccountTypeTransition struct {
+	ID          int64            `json:"id"`
+	AccountID   uuid.UUID        `json:"account_id"`
+	FromType    AccountType      `json:"from_type"`
+	ToType      AccountType      `json:"to_type"`
+	Reason      string           `json:"reason"`
+	PerformedBy uuid.UUID        `json:"performed_by"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
 type AccountVibepointRank struct {
 	ID         uuid.UUID        `json:"id"`
 	Email      string           `json:"email"`
@@ -103,6 +400,7 @@ type AccountVibepointRank struct {
 	CreatedAt  pgtype.Timestamp `json:"created_at"`
 	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
 	VibeRank   int64            `json:"vibe_rank"`
+	TenantID   string           `json:"tenant_id"`
 }
 
 type ActiveServiceToken struct {
@@ -140,6 +438,8 @@ type Activity struct {
 	IsActive            *bool            `json:"is_active"`
 	CreatedAt           pgtype.Timestamp `json:"created_at"`
 	UpdatedAt           pgtype.Timestamp `json:"updated_at"`
+	CooldownSeconds     int32            `json:"cooldown_seconds"`
+	DeletedAt           *time.Time       `json:"deleted_at"`
 }
 
 type ActivityCompletion struct {
@@ -152,31 +452,264 @@ type ActivityCompletion struct {
 	Metadata       []byte           `json:"metadata"`
 }
 
-type Institution struct {
-	InstitutionID int32    `json:"institution_id"`
-	Name          string   `json:"name"`
-	WebPages      []string `json:"web_pages"`
-	Domains       []string `json:"domains"`
-	AlphaTwoCode  *string  `json:"alpha_two_code"`
-	Country       *string  `json:"country"`
-	StateProvince *string  `json:"state_province"`
+type ActivityCompletionsArchive struct {
+	ID             int64            `json:"id"`
+	AccountID      uuid.UUID        `json:"account_id"`
+	ActivityID     uuid.UUID        `json:"activity_id"`
+	CompletedAt    pgtype.Timestamp `json:"completed_at"`
+	CompletionDate pgtype.Date      `json:"completion_date"`
+	PointsEarned   int16            `json:"points_earned"`
+	Metadata       []byte           `json:"metadata"`
+	ArchivedAt     pgtype.Timestamp `json:"archived_at"`
 }
 
-type Permission struct {
+type AuthorizationPolicy struct {
 	ID          uuid.UUID        `json:"id"`
 	Name        string           `json:"name"`
 	Description *string          `json:"description"`
+	Permission  string           `json:"permission"`
+	Conditions  []byte           `json:"conditions"`
+	IsActive    bool             `json:"is_active"`
 	CreatedAt   pgtype.Timestamp `json:"created_at"`
 	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
 }
 
-type Role struct {
+type BodyLoggingRule struct {
+	ID         uuid.UUID          `json:"id"`
+	Route      string             `json:"route"`
+	SampleRate float64            `json:"sample_rate"`
+	ExpiresAt  pgtype.Timestamptz `json:"expires_at"`
+	CreatedBy  pgtype.UUID        `json:"created_by"`
+	CreatedAt  pgtype.Timestamp   `json:"created_at"`
+}
+
+type CustomRolePermissionAllowlist struct {
+	PermissionID uuid.UUID        `json:"permission_id"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type DepartmentMember struct {
+	DepartmentID  uuid.UUID        `json:"department_id"`
+	AccountID     uuid.UUID        `json:"account_id"`
+	InstitutionID int32            `json:"institution_id"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type Institution struct {
+	InstitutionID  int32            `json:"institution_id"`
+	Name           string           `json:"name"`
+	WebPages       []string         `json:"web_pages"`
+	Domains        []string         `json:"domains"`
+	AlphaTwoCode   *string          `json:"alpha_two_code"`
+	Country        *string          `json:"country"`
+	StateProvince  *string          `json:"state_province"`
+	LogoUrl        *string          `json:"logo_url"`
+	PrimaryColor   *string          `json:"primary_color"`
+	SecondaryColor *string          `json:"secondary_color"`
+	Website        *string          `json:"website"`
+	Timezone       *string          `json:"timezone"`
+	Metadata       json.RawMessage  `json:"metadata"`
+	ArchivedAt     pgtype.Timestamp `json:"archived_at"`
+}
+
+type InstitutionDepartment struct {
+	ID            uuid.UUID        `json:"id"`
+	InstitutionID int32            `json:"institution_id"`
+	Name          string           `json:"name"`
+	Description   *string          `json:"description"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+	UpdatedAt     pgtype.Timestamp `json:"updated_at"`
+}
+
+type InstitutionEmailDomain struct {
+	ID                uuid.UUID        `json:"id"`
+	InstitutionID     int32            `json:"institution_id"`
+	Domain            string           `json:"domain"`
+	VerificationToken string           `json:"verification_token"`
+	VerifiedAt        pgtype.Timestamp `json:"verified_at"`
+	CreatedBy         pgtype.UUID      `json:"created_by"`
+	CreatedAt         pgtype.Timestamp `json:"created_at"`
+}
+
+type InstitutionInvitation struct {
+	ID             uuid.UUID                 `json:"id"`
+	InstitutionID  int32                     `json:"institution_id"`
+	Email          string                    `json:"email"`
+	MembershipRole InstitutionMembershipRole `json:"membership_role"`
+	InvitedBy      pgtype.UUID               `json:"invited_by"`
+	AcceptedBy     pgtype.UUID               `json:"accepted_by"`
+	ExpiresAt      pgtype.Timestamp          `json:"expires_at"`
+	AcceptedAt     pgtype.Timestamp          `json:"accepted_at"`
+	RevokedAt      pgtype.Timestamp          `json:"revoked_at"`
+	CreatedAt      pgtype.Timestamp          `json:"created_at"`
+}
+
+type InstitutionJoinRequest struct {
+	ID            uuid.UUID                    `json:"id"`
+	InstitutionID int32                        `json:"institution_id"`
+	AccountID     uuid.UUID                    `json:"account_id"`
+	Message       *string                      `json:"message"`
+	Status        InstitutionJoinRequestStatus `json:"status"`
+	ReviewedBy    pgtype.UUID                  `json:"reviewed_by"`
+	ReviewNote    *string                      `json:"review_note"`
+	CreatedAt     pgtype.Timestamp             `json:"created_at"`
+	ReviewedAt    pgtype.Timestamp             `json:"reviewed_at"`
+}
+
+type InstitutionMemberImport struct {
+	ID            uuid.UUID                     `json:"id"`
+	InstitutionID int32                         `json:"institution_id"`
+	RequestedBy   pgtype.UUID                   `json:"requested_by"`
+	Status        InstitutionMemberImportStatus `json:"status"`
+	Emails        []string                      `json:"emails"`
+	Total         int32                         `json:"total"`
+	Processed     int32                         `json:"processed"`
+	Linked        int32                         `json:"linked"`
+	Invited       int32                         `json:"invited"`
+	Skipped       int32                         `json:"skipped"`
+	Errors        json.RawMessage               `json:"errors"`
+	CreatedAt     pgtype.Timestamp              `json:"created_at"`
+	StartedAt     pgtype.Timestamp              `json:"started_at"`
+	FinishedAt    pgtype.Timestamp              `json:"finished_at"`
+}
+
+type InstitutionUserRole struct {
+	UserID        uuid.UUID        `json:"user_id"`
+	RoleID        uuid.UUID        `json:"role_id"`
+	InstitutionID int32            `json:"institution_id"`
+	CreatedAt     pgtype.Timestamp `json:"created_at"`
+}
+
+type LeaderboardLiveTicket struct {
+	TicketHash string           `json:"ticket_hash"`
+	AccountID  uuid.UUID        `json:"account_id"`
+	ExpiresAt  pgtype.Timestamp `json:"expires_at"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type LeaderboardRankPosition struct {
+	AccountID  uuid.UUID        `json:"account_id"`
+	VibeRank   int64            `json:"vibe_rank"`
+	VibePoints int64            `json:"vibe_points"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
+}
+
+type LeaderboardSeason struct {
+	ID           uuid.UUID                  `json:"id"`
+	Name         string                     `json:"name"`
+	StartsAt     pgtype.Timestamp           `json:"starts_at"`
+	EndsAt       pgtype.Timestamp           `json:"ends_at"`
+	EndAction    LeaderboardSeasonEndAction `json:"end_action"`
+	DecayPercent int16                      `json:"decay_percent"`
+	ClosedAt     pgtype.Timestamp           `json:"closed_at"`
+	CreatedAt    pgtype.Timestamp           `json:"created_at"`
+}
+
+type LeaderboardSeasonStanding struct {
+	SeasonID    uuid.UUID `json:"season_id"`
+	AccountID   uuid.UUID `json:"account_id"`
+	FinalRank   int64     `json:"final_rank"`
+	FinalPoints int64     `json:"final_points"`
+}
+
+type LeaderboardSnapshot struct {
+	AccountID    uuid.UUID        `json:"account_id"`
+	SnapshotDate pgtype.Date      `json:"snapshot_date"`
+	VibeRank     int64            `json:"vibe_rank"`
+	VibePoints   int64            `json:"vibe_points"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type Permission struct {
 	ID          uuid.UUID        `json:"id"`
 	Name        string           `json:"name"`
 	Description *string          `json:"description"`
 	CreatedAt   pgtype.Timestamp `json:"created_at"`
 	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
-	IsDefault   bool             `json:"is_default"`
+}
+
+type PermissionAlias struct {
+	Alias        string           `json:"alias"`
+	PermissionID uuid.UUID        `json:"permission_id"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type PermissionDenial struct {
+	UserID       uuid.UUID        `json:"user_id"`
+	PermissionID uuid.UUID        `json:"permission_id"`
+	Reason       *string          `json:"reason"`
+	DeniedBy     pgtype.UUID      `json:"denied_by"`
+	CreatedAt    pgtype.Timestamp `json:"created_at"`
+}
+
+type ProcessedEvent struct {
+	Consumer    string           `json:"consumer"`
+	EventID     string           `json:"event_id"`
+	ProcessedAt pgtype.Timestamp `json:"processed_at"`
+}
+
+type PublishedEvent struct {
+	ID          int64            `json:"id"`
+	Exchange    string           `json:"exchange"`
+	RoutingKey  string           `json:"routing_key"`
+	EventType   string           `json:"event_type"`
+	AccountID   pgtype.UUID      `json:"account_id"`
+	Payload     []byte           `json:"payload"`
+	PublishedAt pgtype.Timestamp `json:"published_at"`
+}
+
+type RbacAuditLog struct {
+	ID         int64            `json:"id"`
+	Action     string           `json:"action"`
+	ActorID    pgtype.UUID      `json:"actor_id"`
+	TargetType string           `json:"target_type"`
+	TargetID   string           `json:"target_id"`
+	Before     []byte           `json:"before"`
+	After      []byte           `json:"after"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+}
+
+type RequestAuditLog struct {
+	ID          int64            `json:"id"`
+	RequestID   string           `json:"request_id"`
+	ActorID     pgtype.UUID      `json:"actor_id"`
+	Method      string           `json:"method"`
+	Route       string           `json:"route"`
+	Path        string           `json:"path"`
+	ResourceIds []byte           `json:"resource_ids"`
+	Status      int32            `json:"status"`
+	LatencyMs   int32            `json:"latency_ms"`
+	IpAddress   *string          `json:"ip_address"`
+	UserAgent   *string          `json:"user_agent"`
+	OccurredAt  pgtype.Timestamp `json:"occurred_at"`
+}
+
+type Role struct {
+	ID               uuid.UUID        `json:"id"`
+	Name             string           `json:"name"`
+	Description      *string          `json:"description"`
+	CreatedAt        pgtype.Timestamp `json:"created_at"`
+	UpdatedAt        pgtype.Timestamp `json:"updated_at"`
+	IsDefault        bool             `json:"is_default"`
+	IsActive         bool             `json:"is_active"`
+	InstitutionID    *int32           `json:"institution_id"`
+	RequiresApproval bool             `json:"requires_approval"`
+	IsCustom         bool             `json:"is_custom"`
+	DeletedAt        *time.Time       `json:"deleted_at"`
+}
+
+type RoleAssignmentRequest struct {
+	ID            uuid.UUID                   `json:"id"`
+	UserID        uuid.UUID                   `json:"user_id"`
+	RoleID        uuid.UUID                   `json:"role_id"`
+	RequestedBy   uuid.UUID                   `json:"requested_by"`
+	Reason        *string                     `json:"reason"`
+	Status        RoleAssignmentRequestStatus `json:"status"`
+	ReviewedBy    pgtype.UUID                 `json:"reviewed_by"`
+	ReviewNote    *string                     `json:"review_note"`
+	CreatedAt     pgtype.Timestamp            `json:"created_at"`
+	ReviewedAt    pgtype.Timestamp            `json:"reviewed_at"`
+	InstitutionID *int32                      `json:"institution_id"`
 }
 
 type RolePermission struct {
@@ -192,6 +725,19 @@ type RolePermissionsView struct {
 	PermissionName  string    `json:"permission_name"`
 }
 
+type RoleTemplate struct {
+	ID          uuid.UUID        `json:"id"`
+	Name        string           `json:"name"`
+	Description *string          `json:"description"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+	UpdatedAt   pgtype.Timestamp `json:"updated_at"`
+}
+
+type RoleTemplatePermission struct {
+	TemplateID   uuid.UUID `json:"template_id"`
+	PermissionID uuid.UUID `json:"permission_id"`
+}
+
 type ServiceToken struct {
 	ID               uuid.UUID          `json:"id"`
 	AccountID        uuid.UUID          `json:"account_id"`
@@ -211,6 +757,7 @@ type ServiceToken struct {
 	UserAgentPattern *string            `json:"user_agent_pattern"`
 	CreatedBy        pgtype.UUID        `json:"created_by"`
 	Metadata         []byte             `json:"metadata"`
+	Version          int64              `json:"version"`
 }
 
 type Social struct {
@@ -293,7 +840,37 @@ type VibepointTransaction struct {
 	ID             int64            `json:"id"`
 	AccountID      uuid.UUID        `json:"account_id"`
 	AwardingReason *string          `json:"awarding_reason"`
-	PointsAwarded  int16            `json:"points_awarded"`
+	PointsAwarded  int32            `json:"points_awarded"`
 	AwardedAt      pgtype.Timestamp `json:"awarded_at"`
 	AwardedBy      *string          `json:"awarded_by"`
+	ActivityID     pgtype.UUID      `json:"activity_id"`
+	ActorID        pgtype.UUID      `json:"actor_id"`
+	ReversalOf     *int64           `json:"reversal_of"`
+	SeasonID       pgtype.UUID      `json:"season_id"`
+}
+
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	EndpointID     uuid.UUID             `json:"endpoint_id"`
+	EventType      string                `json:"event_type"`
+	Payload        []byte                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int32                 `json:"attempts"`
+	LastStatusCode *int32                `json:"last_status_code"`
+	LastError      *string               `json:"last_error"`
+	NextAttemptAt  pgtype.Timestamp      `json:"next_attempt_at"`
+	DeliveredAt    pgtype.Timestamp      `json:"delivered_at"`
+	CreatedAt      pgtype.Timestamp      `json:"created_at"`
+}
+
+type WebhookEndpoint struct {
+	ID         uuid.UUID        `json:"id"`
+	OwnerID    uuid.UUID        `json:"owner_id"`
+	Name       string           `json:"name"`
+	Url        string           `json:"url"`
+	Secret     string           `json:"secret"`
+	EventTypes []string         `json:"event_types"`
+	IsActive   bool             `json:"is_active"`
+	CreatedAt  pgtype.Timestamp `json:"created_at"`
+	UpdatedAt  pgtype.Timestamp `json:"updated_at"`
 }
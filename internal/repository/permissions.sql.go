@@ -57,6 +57,58 @@ func (q *Queries) CreatePermission(ctx context.Context, arg CreatePermissionPara
 	return i, err
 }
 
+const getAccountAuthorization = `-- name: GetAccountAuthorization :one
+SELECT a.id, a.email, a.name, a.created_at, a.updated_at, a.terms_accepted, a.onboarded, a.type, a.national_id, a.username, a.avatar_url, a.bio, a.vibe_points, a.phone, a.deleted_at, a.deactivated_at, a.version, a.tenant_id,
+  ARRAY(SELECT urv.name FROM user_roles_view urv WHERE urv.user_id = a.id)::text[] AS roles,
+  ARRAY(SELECT upv.permission FROM user_permissions_view upv WHERE upv.user_id = a.id)::text[] AS permissions,
+  ARRAY(
+    SELECT p.name FROM permission_denials pd
+    JOIN permissions p ON p.id = pd.permission_id
+    WHERE pd.user_id = a.id
+  )::text[] AS denied_permissions
+FROM accounts a
+WHERE a.id = $1
+`
+
+type GetAccountAuthorizationRow struct {
+	Account           Account  `json:"account"`
+	Roles             []string `json:"roles"`
+	Permissions       []string `json:"permissions"`
+	DeniedPermissions []string `json:"denied_permissions"`
+}
+
+// Returns an account together with the names of its roles, of the
+// permissions granted through them and of the permissions denied to it so
+// that a request can be authenticated in a single round trip
+func (q *Queries) GetAccountAuthorization(ctx context.Context, id uuid.UUID) (GetAccountAuthorizationRow, error) {
+	row := q.db.QueryRow(ctx, getAccountAuthorization, id)
+	var i GetAccountAuthorizationRow
+	err := row.Scan(
+		&i.Account.ID,
+		&i.Account.Email,
+		&i.Account.Name,
+		&i.Account.CreatedAt,
+		&i.Account.UpdatedAt,
+		&i.Account.TermsAccepted,
+		&i.Account.Onboarded,
+		&i.Account.Type,
+		&i.Account.NationalID,
+		&i.Account.Username,
+		&i.Account.AvatarUrl,
+		&i.Account.Bio,
+		&i.Account.VibePoints,
+		&i.Account.Phone,
+		&i.Account.DeletedAt,
+		&i.Account.DeactivatedAt,
+		&i.Account.Version,
+		&i.Account.TenantID,
+		&i.Roles,
+		&i.Permissions,
+		&i.DeniedPermissions,
+	)
+	return i, err
+}
+
 const getAllPermissions = `-- name: GetAllPermissions :many
 SELECT id, name, description, created_at, updated_at FROM permissions
 LIMIT $1
@@ -125,6 +177,64 @@ func (q *Queries) GetPermissionByID(ctx context.Context, id uuid.UUID) ([]Permis
 	return items, nil
 }
 
+const getPermissionNames = `-- name: GetPermissionNames :many
+SELECT name FROM permissions
+ORDER BY name
+`
+
+// Returns the names of every permission
+func (q *Queries) GetPermissionNames(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, getPermissionNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPermissionsByNames = `-- name: GetPermissionsByNames :many
+SELECT id, name, description, created_at, updated_at FROM permissions
+WHERE name = ANY($1::text[])
+`
+
+// Returns the permissions matching the given names
+func (q *Queries) GetPermissionsByNames(ctx context.Context, names []string) ([]Permission, error) {
+	rows, err := q.db.Query(ctx, getPermissionsByNames, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Permission{}
+	for rows.Next() {
+		var i Permission
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getUserPermissionNames = `-- name: GetUserPermissionNames :many
 SELECT permission FROM user_permissions_view
 WHERE user_id = $1
@@ -0,0 +1,199 @@
+package repository_test
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+// generatedQuery is a query of the generated code along with how its method
+// calls it
+type generatedQuery struct {
+	Name string
+	Kind string
+	SQL  string
+	// Number of arguments the query is called with
+	Args int
+	// Number of columns scanned from its rows, -1 when none are
+	Scanned int
+}
+
+var queryHeader = regexp.MustCompile(`(?m)^-- name: (\w+) :(\w+).*$`)
+
+// generatedQueries reads the queries of the generated code
+func generatedQueries(t *testing.T) map[string]generatedQuery {
+	t.Helper()
+	files, err := filepath.Glob("*.sql.go")
+	if err != nil {
+		t.Fatalf("Could not list generated files: %v", err)
+	}
+
+	queries := map[string]generatedQuery{}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatalf("Could not parse %s: %v", file, err)
+		}
+
+		consts := map[string]string{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			spec, ok := n.(*ast.ValueSpec)
+			if !ok || len(spec.Values) != 1 {
+				return true
+			}
+			lit, ok := spec.Values[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err == nil && queryHeader.MatchString(value) {
+				consts[spec.Names[0].Name] = value
+			}
+			return true
+		})
+
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			query := generatedQuery{Name: fn.Name.Name, Args: -1, Scanned: -1}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				sel, ok := call.Fun.(*ast.SelectorExpr)
+				if !ok {
+					return true
+				}
+				switch sel.Sel.Name {
+				case "Query", "QueryRow", "Exec":
+					if len(call.Args) < 2 {
+						return true
+					}
+					if ident, ok := call.Args[1].(*ast.Ident); ok && consts[ident.Name] != "" {
+						header := queryHeader.FindStringSubmatch(consts[ident.Name])
+						query.Kind = header[2]
+						query.SQL = consts[ident.Name]
+						query.Args = len(call.Args) - 2
+					}
+				case "Scan":
+					query.Scanned = len(call.Args)
+				}
+				return true
+			})
+			if query.SQL != "" {
+				queries[query.Name] = query
+			}
+		}
+	}
+	return queries
+}
+
+var (
+	sqlComment     = regexp.MustCompile(`--.*`)
+	sqlWhitespace  = regexp.MustCompile(`\s+`)
+	sqlParameter   = regexp.MustCompile(`sqlc\.n?arg\(\s*'?\w+'?\s*\)|@\w+|\$\d+`)
+	sqlPlaceholder = regexp.MustCompile(`\s*\?\s*`)
+)
+
+// normalizeQuery drops the comments, layout and parameter names of a query
+func normalizeQuery(sql string) string {
+	sql = sqlComment.ReplaceAllString(sql, "")
+	sql = sqlWhitespace.ReplaceAllString(sql, " ")
+	sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
+	sql = sqlParameter.ReplaceAllString(strings.TrimSpace(sql), "?")
+	return sqlPlaceholder.ReplaceAllString(sql, "?")
+}
+
+// The generated code is written by sqlc from database/queries, it must not
+// drift from the queries it was generated from
+func TestGeneratedQueriesMatchSources(t *testing.T) {
+	generated := generatedQueries(t)
+	sources, err := filepath.Glob("../../database/queries/*.sql")
+	if err != nil {
+		t.Fatalf("Could not list queries: %v", err)
+	}
+
+	seen := map[string]bool{}
+	for _, source := range sources {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			t.Fatalf("Could not read %s: %v", source, err)
+		}
+		headers := queryHeader.FindAllStringSubmatchIndex(string(content), -1)
+		for i, header := range headers {
+			name := string(content[header[2]:header[3]])
+			kind := string(content[header[4]:header[5]])
+			end := len(content)
+			if i+1 < len(headers) {
+				end = headers[i+1][0]
+			}
+			seen[name] = true
+			if kind == "copyfrom" {
+				continue
+			}
+
+			query, ok := generated[name]
+			if !ok {
+				t.Errorf("%s: Expected %s to be generated", filepath.Base(source), name)
+				continue
+			}
+			if query.Kind != kind {
+				t.Errorf("%s: Expected %s to be generated as :%s, got :%s", filepath.Base(source), name, kind, query.Kind)
+			}
+
+			// Stars and embedded tables are expanded into their columns
+			want := regexp.QuoteMeta(normalizeQuery(string(content[header[1]:end])))
+			want = strings.ReplaceAll(want, `\*`, `.+?`)
+			want = regexp.MustCompile(`sqlc\\\.embed\\\(\w+\\\)`).ReplaceAllString(want, `.+?`)
+			got := normalizeQuery(queryHeader.ReplaceAllString(query.SQL, ""))
+			if !regexp.MustCompile(`^` + want + `$`).MatchString(got) {
+				t.Errorf("%s: Expected %s to be generated from its query, got\n%s", filepath.Base(source), name, got)
+			}
+		}
+	}
+
+	for name := range generated {
+		if !seen[name] {
+			t.Errorf("Expected %s to be generated from a query of database/queries", name)
+		}
+	}
+}
+
+// Every generated query must be accepted by the schema and called with as
+// many arguments and scanned into as many fields as it has
+func TestGeneratedQueriesPrepare(t *testing.T) {
+	ctx := context.Background()
+	conn, err := testdb.New(t).Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Could not acquire connection: %v", err)
+	}
+	defer conn.Release()
+
+	for name, query := range generatedQueries(t) {
+		t.Run(name, func(t *testing.T) {
+			statement, err := conn.Conn().Prepare(ctx, "", query.SQL)
+			if err != nil {
+				t.Fatalf("Could not prepare %s: %v", name, err)
+			}
+			if len(statement.ParamOIDs) != query.Args {
+				t.Errorf("Expected %d parameters, got %d", query.Args, len(statement.ParamOIDs))
+			}
+			if query.Scanned >= 0 && len(statement.Fields) != query.Scanned {
+				t.Errorf("Expected %d columns, got %d", query.Scanned, len(statement.Fields))
+			}
+		})
+	}
+}
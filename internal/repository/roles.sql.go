@@ -31,11 +31,34 @@ func (q *Queries) AssignRole(ctx context.Context, arg AssignRoleParams) (UserRol
 	return i, err
 }
 
+const countDeletedRoles = `-- name: CountDeletedRoles :one
+SELECT COUNT(*) FROM roles WHERE deleted_at IS NOT NULL
+`
+
+func (q *Queries) CountDeletedRoles(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countDeletedRoles)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRoleAssignments = `-- name: CountRoleAssignments :one
+SELECT COUNT(*) FROM user_roles WHERE role_id = $1
+`
+
+// Returns the number of accounts a role is assigned to
+func (q *Queries) CountRoleAssignments(ctx context.Context, roleID uuid.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countRoleAssignments, roleID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRole = `-- name: CreateRole :one
 INSERT INTO roles ( 
   name, description
 ) VALUES ( $1, $2 )
-RETURNING id, name, description, created_at, updated_at, is_default
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
 `
 
 type CreateRoleParams struct {
@@ -54,12 +77,82 @@ func (q *Queries) CreateRole(ctx context.Context, arg CreateRoleParams) (Role, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const createScopedRole = `-- name: CreateScopedRole :one
+INSERT INTO roles (
+  name, description, institution_id
+) VALUES ( $1, $2, $3 )
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
+`
+
+type CreateScopedRoleParams struct {
+	Name          string  `json:"name"`
+	Description   *string `json:"description"`
+	InstitutionID *int32  `json:"institution_id"`
+}
+
+// Creates a role that may be scoped to a single institution
+func (q *Queries) CreateScopedRole(ctx context.Context, arg CreateScopedRoleParams) (Role, error) {
+	row := q.db.QueryRow(ctx, createScopedRole, arg.Name, arg.Description, arg.InstitutionID)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const deleteRole = `-- name: DeleteRole :execrows
+UPDATE roles
+  SET deleted_at = NOW(),
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
+`
+
+// Marks a role deleted. Its permissions are kept so that restoring it brings
+// them back, its assignments are revoked with RevokeRoleAssignments
+func (q *Queries) DeleteRole(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRole, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteRolePermissions = `-- name: DeleteRolePermissions :execrows
+DELETE FROM role_permissions
+WHERE role_id = $1
+`
+
+// Removes every permission granted to a role
+func (q *Queries) DeleteRolePermissions(ctx context.Context, roleID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteRolePermissions, roleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAllRoles = `-- name: GetAllRoles :many
-SELECT id, name, description, created_at, updated_at, is_default FROM roles 
+SELECT id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at FROM roles 
+WHERE deleted_at IS NULL
 LIMIT $1
 OFFSET $2
 `
@@ -86,6 +179,11 @@ func (q *Queries) GetAllRoles(ctx context.Context, arg GetAllRolesParams) ([]Rol
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.IsDefault,
+			&i.IsActive,
+			&i.InstitutionID,
+			&i.RequiresApproval,
+			&i.IsCustom,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -155,8 +253,63 @@ func (q *Queries) GetAllUserRoles(ctx context.Context, userID uuid.UUID) ([]User
 	return items, nil
 }
 
+const getRoleAccounts = `-- name: GetRoleAccounts :many
+SELECT a.id, a.email, a.name, a.created_at, a.updated_at, a.terms_accepted, a.onboarded, a.type, a.national_id, a.username, a.avatar_url, a.bio, a.vibe_points, a.phone, a.deleted_at, a.deactivated_at, a.version, a.tenant_id FROM accounts a
+JOIN user_roles ur ON ur.user_id = a.id
+WHERE ur.role_id = $1
+ORDER BY a.created_at, a.id
+LIMIT $2
+OFFSET $3
+`
+
+type GetRoleAccountsParams struct {
+	RoleID uuid.UUID `json:"role_id"`
+	Limit  int32     `json:"limit"`
+	Offset int32     `json:"offset"`
+}
+
+// Retrieves the accounts a role is currently assigned to
+func (q *Queries) GetRoleAccounts(ctx context.Context, arg GetRoleAccountsParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, getRoleAccounts, arg.RoleID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Email,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.TermsAccepted,
+			&i.Onboarded,
+			&i.Type,
+			&i.NationalID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Bio,
+			&i.VibePoints,
+			&i.Phone,
+			&i.DeletedAt,
+			&i.DeactivatedAt,
+			&i.Version,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRoleByID = `-- name: GetRoleByID :one
-SELECT id, name, description, created_at, updated_at, is_default FROM roles WHERE id = $1
+SELECT id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at FROM roles WHERE id = $1 AND deleted_at IS NULL
 `
 
 // Retrieves a role specified by its id
@@ -170,13 +323,18 @@ func (q *Queries) GetRoleByID(ctx context.Context, id uuid.UUID) (Role, error) {
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getRoleByName = `-- name: GetRoleByName :one
-SELECT id, name, description, created_at, updated_at, is_default FROM roles 
-WHERE name = $1
+SELECT id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at FROM roles 
+WHERE name = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetRoleByName(ctx context.Context, name string) (Role, error) {
@@ -189,6 +347,11 @@ func (q *Queries) GetRoleByName(ctx context.Context, name string) (Role, error)
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
 	)
 	return i, err
 }
@@ -225,6 +388,102 @@ func (q *Queries) GetRolePermissions(ctx context.Context, roleID uuid.UUID) ([]R
 	return items, nil
 }
 
+const listDeletedRoles = `-- name: ListDeletedRoles :many
+SELECT id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at FROM roles
+WHERE deleted_at IS NOT NULL
+ORDER BY deleted_at DESC, id
+LIMIT $1
+OFFSET $2
+`
+
+type ListDeletedRolesParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// Retrieves the deleted roles, most recently deleted first
+func (q *Queries) ListDeletedRoles(ctx context.Context, arg ListDeletedRolesParams) ([]Role, error) {
+	rows, err := q.db.Query(ctx, listDeletedRoles, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Role{}
+	for rows.Next() {
+		var i Role
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.IsDefault,
+			&i.IsActive,
+			&i.InstitutionID,
+			&i.RequiresApproval,
+			&i.IsCustom,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reassignRoleAccounts = `-- name: ReassignRoleAccounts :execrows
+INSERT INTO user_roles (user_id, role_id)
+SELECT user_id, $1::uuid FROM user_roles
+WHERE role_id = $2::uuid
+ON CONFLICT DO NOTHING
+`
+
+type ReassignRoleAccountsParams struct {
+	ToRoleID   uuid.UUID `json:"to_role_id"`
+	FromRoleID uuid.UUID `json:"from_role_id"`
+}
+
+// Assigns the target role to every account holding the source role
+func (q *Queries) ReassignRoleAccounts(ctx context.Context, arg ReassignRoleAccountsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignRoleAccounts, arg.ToRoleID, arg.FromRoleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreRole = `-- name: RestoreRole :one
+UPDATE roles
+  SET deleted_at = NULL,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NOT NULL
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
+`
+
+// Restores a deleted role without the assignments revoked when it was
+// deleted
+func (q *Queries) RestoreRole(ctx context.Context, id uuid.UUID) (Role, error) {
+	row := q.db.QueryRow(ctx, restoreRole, id)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const revokeRole = `-- name: RevokeRole :exec
 DELETE FROM user_roles
   WHERE user_id = $1 AND role_id = $2
@@ -241,12 +500,97 @@ func (q *Queries) RevokeRole(ctx context.Context, arg RevokeRoleParams) error {
 	return err
 }
 
+const revokeRoleAssignments = `-- name: RevokeRoleAssignments :execrows
+WITH revoked_institution_roles AS (
+  DELETE FROM institution_user_roles WHERE role_id = $1
+)
+DELETE FROM user_roles
+WHERE role_id = $1
+`
+
+// Revokes a role from every account holding it, including the accounts
+// holding it within an institution
+func (q *Queries) RevokeRoleAssignments(ctx context.Context, roleID uuid.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeRoleAssignments, roleID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setRoleActive = `-- name: SetRoleActive :one
+UPDATE roles
+  SET is_active = $2,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
+`
+
+type SetRoleActiveParams struct {
+	ID       uuid.UUID `json:"id"`
+	IsActive bool      `json:"is_active"`
+}
+
+// Activates or deactivates a role. Permissions granted through an inactive
+// role are ignored
+func (q *Queries) SetRoleActive(ctx context.Context, arg SetRoleActiveParams) (Role, error) {
+	row := q.db.QueryRow(ctx, setRoleActive, arg.ID, arg.IsActive)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setRoleRequiresApproval = `-- name: SetRoleRequiresApproval :one
+UPDATE roles
+  SET requires_approval = $2,
+  updated_at = NOW()
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
+`
+
+type SetRoleRequiresApprovalParams struct {
+	ID               uuid.UUID `json:"id"`
+	RequiresApproval bool      `json:"requires_approval"`
+}
+
+// Marks whether assigning a role needs a second approver
+func (q *Queries) SetRoleRequiresApproval(ctx context.Context, arg SetRoleRequiresApprovalParams) (Role, error) {
+	row := q.db.QueryRow(ctx, setRoleRequiresApproval, arg.ID, arg.RequiresApproval)
+	var i Role
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
 const updateRole = `-- name: UpdateRole :one
 UPDATE roles
   SET name =  COALESCE($2, name),
   description = COALESCE($3, description)
-  WHERE id = $1
-RETURNING id, name, description, created_at, updated_at, is_default
+  WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, name, description, created_at, updated_at, is_default, is_active, institution_id, requires_approval, is_custom, deleted_at
 `
 
 type UpdateRoleParams struct {
@@ -265,6 +609,11 @@ func (q *Queries) UpdateRole(ctx context.Context, arg UpdateRoleParams) (Role, e
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.IsDefault,
+		&i.IsActive,
+		&i.InstitutionID,
+		&i.RequiresApproval,
+		&i.IsCustom,
+		&i.DeletedAt,
 	)
 	return i, err
 }
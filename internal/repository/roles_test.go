@@ -0,0 +1,39 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestCreateRoleWithNameOfDeletedRole(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.New(testdb.Tx(t))
+
+	deleted, err := repo.CreateRole(ctx, repository.CreateRoleParams{Name: "Auditor"})
+	if err != nil {
+		t.Fatalf("Could not create role: %v", err)
+	}
+	if _, err := repo.DeleteRole(ctx, deleted.ID); err != nil {
+		t.Fatalf("Could not delete role: %v", err)
+	}
+
+	role, err := repo.CreateRole(ctx, repository.CreateRoleParams{Name: "Auditor"})
+	if err != nil {
+		t.Fatalf("Expected the name of a deleted role to be reused, got %v", err)
+	}
+	if role.ID == deleted.ID {
+		t.Errorf("Expected a new role, got the deleted one")
+	}
+
+	// Both roles cannot be live at once
+	var pgErr *pgconn.PgError
+	_, err = repo.RestoreRole(ctx, deleted.ID)
+	if !errors.As(err, &pgErr) || pgErr.Code != "23505" {
+		t.Errorf("Expected restoring the deleted role to violate the unique name, got %v", err)
+	}
+}
@@ -29,7 +29,7 @@ INSERT INTO service_tokens (
 ) VALUES (
   $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
-RETURNING id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata
+RETURNING id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata, version
 `
 
 type CreateServiceTokenParams struct {
@@ -82,6 +82,7 @@ func (q *Queries) CreateServiceToken(ctx context.Context, arg CreateServiceToken
 		&i.UserAgentPattern,
 		&i.CreatedBy,
 		&i.Metadata,
+		&i.Version,
 	)
 	return i, err
 }
@@ -97,7 +98,7 @@ func (q *Queries) DeleteServiceToken(ctx context.Context, id uuid.UUID) error {
 }
 
 const getServiceTokenByHash = `-- name: GetServiceTokenByHash :one
-SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata FROM service_tokens
+SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata, version FROM service_tokens
 WHERE token_hash = $1
   AND revoked_at IS NULL
   AND (expires_at IS NULL OR expires_at > NOW())
@@ -126,12 +127,13 @@ func (q *Queries) GetServiceTokenByHash(ctx context.Context, tokenHash string) (
 		&i.UserAgentPattern,
 		&i.CreatedBy,
 		&i.Metadata,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getServiceTokenByID = `-- name: GetServiceTokenByID :one
-SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata FROM service_tokens
+SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata, version FROM service_tokens
 WHERE id = $1
 `
 
@@ -157,6 +159,7 @@ func (q *Queries) GetServiceTokenByID(ctx context.Context, id uuid.UUID) (Servic
 		&i.UserAgentPattern,
 		&i.CreatedBy,
 		&i.Metadata,
+		&i.Version,
 	)
 	return i, err
 }
@@ -241,7 +244,7 @@ func (q *Queries) ListActiveServiceTokens(ctx context.Context) ([]ActiveServiceT
 }
 
 const listServiceTokensByAccount = `-- name: ListServiceTokensByAccount :many
-SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata FROM service_tokens
+SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata, version FROM service_tokens
 WHERE account_id = $1
 ORDER BY created_at DESC
 `
@@ -274,6 +277,7 @@ func (q *Queries) ListServiceTokensByAccount(ctx context.Context, accountID uuid
 			&i.UserAgentPattern,
 			&i.CreatedBy,
 			&i.Metadata,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -286,7 +290,7 @@ func (q *Queries) ListServiceTokensByAccount(ctx context.Context, accountID uuid
 }
 
 const listServiceTokensNeedingRotation = `-- name: ListServiceTokensNeedingRotation :many
-SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata FROM service_tokens
+SELECT id, account_id, name, token_hash, created_at, last_used_at, expires_at, rotated_at, revoked_at, description, scopes, max_uses, use_count, rotation_policy, ip_whitelist, user_agent_pattern, created_by, metadata, version FROM service_tokens
 WHERE revoked_at IS NULL
   AND rotation_policy IS NOT NULL
   AND rotation_policy->>'auto_rotate' = 'true'
@@ -323,6 +327,7 @@ func (q *Queries) ListServiceTokensNeedingRotation(ctx context.Context) ([]Servi
 			&i.UserAgentPattern,
 			&i.CreatedBy,
 			&i.Metadata,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -378,7 +383,7 @@ func (q *Queries) RotateServiceToken(ctx context.Context, arg RotateServiceToken
 	return err
 }
 
-const updateServiceToken = `-- name: UpdateServiceToken :exec
+const updateServiceToken = `-- name: UpdateServiceToken :execrows
 UPDATE service_tokens
 SET
   name = $2,
@@ -388,8 +393,10 @@ SET
   rotation_policy = $6,
   ip_whitelist = $7,
   user_agent_pattern = $8,
-  metadata = $9
+  metadata = $9,
+  version = version + 1
 WHERE id = $1
+  AND ($10::bigint IS NULL OR version = $10)
 `
 
 type UpdateServiceTokenParams struct {
@@ -402,10 +409,13 @@ type UpdateServiceTokenParams struct {
 	IpWhitelist      []string  `json:"ip_whitelist"`
 	UserAgentPattern *string   `json:"user_agent_pattern"`
 	Metadata         []byte    `json:"metadata"`
+	Version          *int64    `json:"version"`
 }
 
-func (q *Queries) UpdateServiceToken(ctx context.Context, arg UpdateServiceTokenParams) error {
-	_, err := q.db.Exec(ctx, updateServiceToken,
+// Updates a token and bumps its version. When a version is given the token
+// is only updated if it is still at that version
+func (q *Queries) UpdateServiceToken(ctx context.Context, arg UpdateServiceTokenParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateServiceToken,
 		arg.ID,
 		arg.Name,
 		arg.Description,
@@ -415,8 +425,12 @@ func (q *Queries) UpdateServiceToken(ctx context.Context, arg UpdateServiceToken
 		arg.IpWhitelist,
 		arg.UserAgentPattern,
 		arg.Metadata,
+		arg.Version,
 	)
-	return err
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const updateServiceTokenLastUsed = `-- name: UpdateServiceTokenLastUsed :exec
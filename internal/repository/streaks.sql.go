@@ -189,3 +189,48 @@ func (q *Queries) RecordActivityCompletion(ctx context.Context, arg RecordActivi
 	)
 	return i, err
 }
+
+const updateStreakMilestone = `-- name: UpdateStreakMilestone :one
+UPDATE streak_milestones
+  SET
+    days_required = COALESCE($1::smallint, days_required),
+    bonus_points = COALESCE($2::smallint, bonus_points),
+    title = COALESCE($3::varchar, title),
+    description = COALESCE($4::text, description),
+    is_active = COALESCE($5::boolean, is_active)
+  WHERE id = $6
+RETURNING id, activity_id, days_required, bonus_points, title, description, is_active
+`
+
+type UpdateStreakMilestoneParams struct {
+	DaysRequired *int16    `json:"days_required"`
+	BonusPoints  *int16    `json:"bonus_points"`
+	Title        *string   `json:"title"`
+	Description  *string   `json:"description"`
+	IsActive     *bool     `json:"is_active"`
+	ID           uuid.UUID `json:"id"`
+}
+
+// Updates the provided fields of a streak milestone. Deactivated milestones
+// are no longer awarded but the achievements already earned are kept
+func (q *Queries) UpdateStreakMilestone(ctx context.Context, arg UpdateStreakMilestoneParams) (StreakMilestone, error) {
+	row := q.db.QueryRow(ctx, updateStreakMilestone,
+		arg.DaysRequired,
+		arg.BonusPoints,
+		arg.Title,
+		arg.Description,
+		arg.IsActive,
+		arg.ID,
+	)
+	var i StreakMilestone
+	err := row.Scan(
+		&i.ID,
+		&i.ActivityID,
+		&i.DaysRequired,
+		&i.BonusPoints,
+		&i.Title,
+		&i.Description,
+		&i.IsActive,
+	)
+	return i, err
+}
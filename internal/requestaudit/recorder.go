@@ -0,0 +1,199 @@
+// Package requestaudit records the state changing requests Verisafe serves.
+//
+// OVERVIEW:
+// Every POST, PUT, PATCH and DELETE request is recorded in the
+// request_audit_log table along with the account that made it, the route it
+// matched, the path parameters naming the resources it touched, the status it
+// was answered with and how long it took. The log is the backbone of the
+// audit endpoints, which read it rather than keeping logs of their own.
+//
+// ASYNCHRONOUS WRITES:
+// Requests are not slowed down by the log. The middleware hands entries to
+// the Recorder which queues them in a bounded buffer and writes them in the
+// background. When the database falls behind and the buffer fills up entries
+// are dropped and logged instead of blocking requests.
+//
+// RETENTION:
+// Entries older than the retention period are pruned in the background.
+package requestaudit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// How often entries past the retention period are pruned
+const pruneInterval = time.Hour
+
+// How long entries still buffered at shutdown may take to be written
+const flushTimeout = 5 * time.Second
+
+// Entry describes a state changing request that was served
+type Entry struct {
+	RequestID string
+	// Account that made the request, nil when it was not authenticated
+	ActorID *uuid.UUID
+	Method  string
+	// Route pattern the request matched e.g. PATCH /api/v1/roles/{id}
+	Route string
+	Path  string
+	// Path parameters of the route keyed by name
+	ResourceIDs map[string]string
+	Status      int
+	Latency     time.Duration
+	IPAddress   string
+	UserAgent   string
+	OccurredAt  time.Time
+}
+
+// Recorder writes entries to the request audit log in the background. A nil
+// Recorder is safe to use and records nothing
+type Recorder struct {
+	pool          *pgxpool.Pool
+	logger        *slog.Logger
+	retentionDays int32
+	entries       chan Entry
+}
+
+// NewRecorder creates a new Recorder. Call Start to begin writing entries.
+func NewRecorder(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Recorder {
+	retentionDays := cfg.RequestAuditConfig.RetentionDays
+	if retentionDays <= 0 {
+		retentionDays = 90
+	}
+	bufferSize := cfg.RequestAuditConfig.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+
+	return &Recorder{
+		pool:          pool,
+		logger:        logger,
+		retentionDays: int32(retentionDays),
+		entries:       make(chan Entry, bufferSize),
+	}
+}
+
+// Record queues an entry to be written without waiting for it. The entry is
+// dropped when the buffer is full
+func (rec *Recorder) Record(entry Entry) {
+	if rec == nil {
+		return
+	}
+
+	select {
+	case rec.entries <- entry:
+	default:
+		rec.logger.Warn("Request audit buffer is full, dropping entry",
+			slog.String("request_id", entry.RequestID),
+			slog.String("route", entry.Route),
+			slog.Int("status", entry.Status),
+		)
+	}
+}
+
+// Start writes queued entries and prunes entries past the retention period
+// until the context is cancelled. Entries still queued by then are written
+// before it returns
+func (rec *Recorder) Start(ctx context.Context) {
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	rec.logger.Info("Request audit recorder started",
+		slog.Int("retention_days", int(rec.retentionDays)),
+		slog.Int("buffer_size", cap(rec.entries)),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			rec.flush()
+			rec.logger.Info("Request audit recorder stopped")
+			return
+		case entry := <-rec.entries:
+			rec.write(ctx, entry)
+		case <-ticker.C:
+			pruned, err := repository.New(rec.pool).PruneRequestAuditEntries(ctx, rec.retentionDays)
+			if err != nil {
+				if ctx.Err() == nil {
+					rec.logger.Error("Failed to prune request audit entries", slog.Any("error", err))
+				}
+				continue
+			}
+			if pruned > 0 {
+				rec.logger.Info("Pruned request audit entries", slog.Int64("count", pruned))
+			}
+		}
+	}
+}
+
+// flush writes the entries still queued once the recorder is stopped
+func (rec *Recorder) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case entry := <-rec.entries:
+			rec.write(ctx, entry)
+		default:
+			return
+		}
+	}
+}
+
+// write stores an entry, logging it when it cannot be stored
+func (rec *Recorder) write(ctx context.Context, entry Entry) {
+	actorID := pgtype.UUID{}
+	if entry.ActorID != nil {
+		actorID = pgtype.UUID{Bytes: *entry.ActorID, Valid: true}
+	}
+	resourceIDs := entry.ResourceIDs
+	if resourceIDs == nil {
+		resourceIDs = map[string]string{}
+	}
+	rawResourceIDs, _ := json.Marshal(resourceIDs)
+
+	latency := entry.Latency.Milliseconds()
+	if latency > math.MaxInt32 {
+		latency = math.MaxInt32
+	}
+
+	err := repository.New(rec.pool).CreateRequestAuditEntry(ctx, repository.CreateRequestAuditEntryParams{
+		RequestID:   entry.RequestID,
+		ActorID:     actorID,
+		Method:      entry.Method,
+		Route:       entry.Route,
+		Path:        entry.Path,
+		ResourceIds: rawResourceIDs,
+		Status:      int32(entry.Status),
+		LatencyMs:   int32(latency),
+		IpAddress:   optional(entry.IPAddress),
+		UserAgent:   optional(entry.UserAgent),
+		OccurredAt:  pgtype.Timestamp{Time: entry.OccurredAt.UTC(), Valid: true},
+	})
+	if err != nil {
+		rec.logger.Error("Failed to record request audit entry",
+			slog.String("request_id", entry.RequestID),
+			slog.String("route", entry.Route),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// optional turns empty strings into NULLs
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
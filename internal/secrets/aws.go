@@ -0,0 +1,40 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// awsProvider reads secrets from AWS Secrets Manager. Credentials and the
+// region are found the way every AWS SDK finds them, from the environment,
+// the shared configuration files or the role of the instance
+type awsProvider struct {
+	client *secretsmanager.Client
+}
+
+func newAWSProvider(ctx context.Context) (*awsProvider, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &awsProvider{client: secretsmanager.NewFromConfig(cfg)}, nil
+}
+
+// Fetch reads the current version of a secret. The secret is its name or ARN
+func (p *awsProvider) Fetch(ctx context.Context, secret, key string) (string, error) {
+	out, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secret),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	value := aws.ToString(out.SecretString)
+	if out.SecretString == nil {
+		value = string(out.SecretBinary)
+	}
+	return field(value, key)
+}
@@ -0,0 +1,77 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+
+// gcpProvider reads secrets through the Secret Manager REST API.
+// Credentials are the application default credentials, i.e.
+// GOOGLE_APPLICATION_CREDENTIALS or the service account of the workload
+type gcpProvider struct {
+	client *http.Client
+}
+
+func newGCPProvider(ctx context.Context, client *http.Client) (*gcpProvider, error) {
+	tokens, err := google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, err
+	}
+	return &gcpProvider{
+		client: &http.Client{
+			Timeout: client.Timeout,
+			Transport: &oauth2.Transport{
+				Source: tokens,
+				Base:   http.DefaultTransport,
+			},
+		},
+	}, nil
+}
+
+// Fetch reads a version of a secret. The secret is the resource name of a
+// secret, e.g. projects/academia/secrets/verisafe, whose latest version is
+// read, or of one of its versions
+func (p *gcpProvider) Fetch(ctx context.Context, secret, key string) (string, error) {
+	name := strings.Trim(secret, "/")
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gcpSecretManagerURL+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("accessing %q failed with %s: %s", name, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode %q: %w", name, err)
+	}
+	value, err := base64.StdEncoding.DecodeString(payload.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode %q: %w", name, err)
+	}
+	return field(string(value), key)
+}
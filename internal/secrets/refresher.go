@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Refresher fetches the secrets named by settings again on an interval, so
+// that rotated secrets are picked up without a restart.
+//
+// Most settings are only read at startup, a rotation of their secret is
+// logged and applied on the next start. Live settings are read through Get
+// whenever they are used and apply rotations right away.
+type Refresher struct {
+	resolver *Resolver
+	refs     map[string]string
+	live     []string
+	logger   *slog.Logger
+	interval time.Duration
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewRefresher creates a new Refresher of the settings naming secrets in refs,
+// whose current values are in values. Call Start to begin refreshing them.
+func NewRefresher(resolver *Resolver, refs, values map[string]string, interval time.Duration,
+	logger *slog.Logger, live ...string) *Refresher {
+	current := make(map[string]string, len(values))
+	for setting, value := range values {
+		current[setting] = value
+	}
+
+	return &Refresher{
+		resolver: resolver,
+		refs:     refs,
+		live:     live,
+		logger:   logger,
+		interval: interval,
+		values:   current,
+	}
+}
+
+// Get returns the latest value of a setting naming a secret, false when the
+// setting holds its value itself
+func (r *Refresher) Get(setting string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.values[setting]
+	return value, ok
+}
+
+// Start refreshes the secrets on every interval until the context is
+// cancelled. It returns right away when no setting names a secret or the
+// interval is not positive
+func (r *Refresher) Start(ctx context.Context) {
+	if len(r.refs) == 0 || r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.logger.Info("Secret refresher started",
+		slog.Duration("interval", r.interval),
+		slog.Int("secrets", len(r.refs)),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Secret refresher stopped")
+			return
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+// refresh fetches every secret again. Secrets that cannot be fetched keep
+// their previous value
+func (r *Refresher) refresh(ctx context.Context) {
+	for setting, ref := range r.refs {
+		value, err := r.resolver.Resolve(ctx, ref)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("Failed to refresh secret",
+					slog.String("setting", setting),
+					slog.Any("error", err),
+				)
+			}
+			continue
+		}
+
+		r.mu.Lock()
+		previous := r.values[setting]
+		r.values[setting] = value
+		r.mu.Unlock()
+		if value == previous {
+			continue
+		}
+
+		if slices.Contains(r.live, setting) {
+			r.logger.Info("Secret rotated", slog.String("setting", setting))
+		} else {
+			r.logger.Warn("Secret rotated, restart Verisafe to apply it", slog.String("setting", setting))
+		}
+	}
+}
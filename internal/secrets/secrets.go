@@ -0,0 +1,167 @@
+// Package secrets fetches secrets from secret stores, so that settings such
+// as API_SECRET or DB_PASSWORD can name a secret instead of holding it.
+//
+// A setting names a secret with a reference of the form
+// <store>:<secret>[#<key>], e.g.
+//
+//	vault:secret/data/verisafe#api_secret
+//	aws-sm:verisafe/production#db_password
+//	gcp-sm:projects/academia/secrets/verisafe-api-secret
+//
+// The key picks a field of secrets holding a JSON object.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// HashiCorp Vault, the secret is the API path of a KV secret
+	VaultStore = "vault"
+	// AWS Secrets Manager, the secret is the name or ARN of a secret
+	AWSStore = "aws-sm"
+	// GCP Secret Manager, the secret is the resource name of a secret or
+	// one of its versions
+	GCPStore = "gcp-sm"
+)
+
+// How long fetching a single secret may take
+const fetchTimeout = 10 * time.Second
+
+var errUnknownStore = errors.New("unknown secret store")
+
+// Provider fetches secrets from a secret store
+type Provider interface {
+	// Fetch returns the value of a secret, or of the field key of a secret
+	// holding a JSON object when key is not empty
+	Fetch(ctx context.Context, secret, key string) (string, error)
+}
+
+// Options configures the secret stores
+type Options struct {
+	VaultAddress string
+	// Token Verisafe authenticates to Vault with, read from VaultTokenFile
+	// on every fetch when set so that renewed tokens are picked up
+	VaultToken     string
+	VaultTokenFile string
+	VaultNamespace string
+}
+
+// Resolver fetches the secrets named by references. Providers are created
+// the first time a reference names their store
+type Resolver struct {
+	options Options
+	client  *http.Client
+
+	mu        sync.Mutex
+	providers map[string]Provider
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(options Options) *Resolver {
+	return &Resolver{
+		options:   options,
+		client:    &http.Client{Timeout: fetchTimeout},
+		providers: map[string]Provider{},
+	}
+}
+
+// IsRef reports whether a setting names a secret instead of holding it
+func IsRef(value string) bool {
+	store, _, ok := strings.Cut(value, ":")
+	if !ok {
+		return false
+	}
+	switch store {
+	case VaultStore, AWSStore, GCPStore:
+		return true
+	}
+	return false
+}
+
+// Resolve fetches the secret a reference names
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	store, secret, ok := strings.Cut(ref, ":")
+	if !ok || secret == "" {
+		return "", fmt.Errorf("invalid secret reference %q, expected <store>:<secret>[#<key>]", ref)
+	}
+	secret, key, _ := strings.Cut(secret, "#")
+
+	provider, err := r.provider(ctx, store)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	value, err := provider.Fetch(ctx, secret, key)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", store, err)
+	}
+	return value, nil
+}
+
+// provider returns the provider of a store, creating it when first used
+func (r *Resolver) provider(ctx context.Context, store string) (Provider, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if provider, ok := r.providers[store]; ok {
+		return provider, nil
+	}
+
+	var provider Provider
+	var err error
+	switch store {
+	case VaultStore:
+		provider, err = newVaultProvider(r.options, r.client)
+	case AWSStore:
+		provider, err = newAWSProvider(ctx)
+	case GCPStore:
+		provider, err = newGCPProvider(ctx, r.client)
+	default:
+		return nil, fmt.Errorf("%w %q, expected %s, %s or %s", errUnknownStore, store, VaultStore, AWSStore, GCPStore)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", store, err)
+	}
+	r.providers[store] = provider
+	return provider, nil
+}
+
+// field returns a field of a secret holding a JSON object, or the secret
+// itself when key is empty
+func field(value, key string) (string, error) {
+	if key == "" {
+		return value, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object, cannot read key %q", key)
+	}
+	return fieldOf(fields, key)
+}
+
+// fieldOf returns a field of a decoded JSON object as a string
+func fieldOf(fields map[string]any, key string) (string, error) {
+	value, ok := fields[key]
+	if !ok {
+		return "", fmt.Errorf("secret has no key %q", key)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
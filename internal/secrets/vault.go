@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// vaultProvider reads KV secrets through the Vault HTTP API
+type vaultProvider struct {
+	address   string
+	token     string
+	tokenFile string
+	namespace string
+	client    *http.Client
+}
+
+func newVaultProvider(options Options, client *http.Client) (*vaultProvider, error) {
+	if options.VaultAddress == "" {
+		return nil, errors.New("VAULT_ADDR is required")
+	}
+	if options.VaultToken == "" && options.VaultTokenFile == "" {
+		return nil, errors.New("VAULT_TOKEN or VAULT_TOKEN_FILE is required")
+	}
+	return &vaultProvider{
+		address:   strings.TrimSuffix(options.VaultAddress, "/"),
+		token:     options.VaultToken,
+		tokenFile: options.VaultTokenFile,
+		namespace: options.VaultNamespace,
+		client:    client,
+	}, nil
+}
+
+// Fetch reads a field of a secret. The secret is its API path, e.g.
+// secret/data/verisafe for the verisafe secret of a KV version 2 engine
+// mounted at secret/
+func (p *vaultProvider) Fetch(ctx context.Context, secret, key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("reference to %q needs the #key of the field to read", secret)
+	}
+
+	token := p.token
+	if p.tokenFile != "" {
+		contents, err := os.ReadFile(p.tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read VAULT_TOKEN_FILE: %w", err)
+		}
+		token = strings.TrimSpace(string(contents))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.address+"/v1/"+strings.TrimPrefix(secret, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("reading %q failed with %s: %s", secret, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var payload struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode %q: %w", secret, err)
+	}
+
+	// KV version 2 nests the fields of the secret next to its metadata
+	fields := payload.Data
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+	return fieldOf(fields, key)
+}
@@ -0,0 +1,85 @@
+// Package tenancy tells the tenant work is done for and restricts the
+// database connections it uses to the accounts of that tenant.
+//
+// ISOLATION:
+// Row level security decides which accounts a connection sees from the
+// verisafe.tenant setting, see verisafe_current_tenant. Pools configured with
+// ConfigurePool set it whenever a connection is acquired, from the context
+// the connection is acquired with, and fail closed: a connection acquired for
+// a context naming neither a tenant nor every tenant sees no account at all.
+//
+// Requests name their tenant, see middleware.WithTenant. Migrations, commands
+// and background jobs work across tenants and are marked with WithAllTenants.
+package tenancy
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+const (
+	TenantContextKey     = "tenancy.tenant"
+	AllTenantsContextKey = "tenancy.all_tenants"
+)
+
+// The verisafe.tenant setting of connections seeing every tenant
+const allTenants = "*"
+
+// WithTenant returns a copy of ctx doing work for a tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, TenantContextKey, tenant)
+}
+
+// FromContext returns the tenant ctx does work for, empty when it names none
+func FromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(TenantContextKey).(string)
+	return tenant
+}
+
+// WithAllTenants returns a copy of ctx doing work across tenants. A tenant
+// named with WithTenant takes precedence
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, AllTenantsContextKey, true)
+}
+
+// setting returns the verisafe.tenant setting of the connections acquired
+// for ctx, empty when they serve no tenant
+func setting(ctx context.Context) string {
+	if tenant := FromContext(ctx); tenant != "" {
+		return tenant
+	}
+	if all, _ := ctx.Value(AllTenantsContextKey).(bool); all {
+		return allTenants
+	}
+	return ""
+}
+
+// ConfigurePool enforces tenancy on the connections of a pool and restricts
+// them to the tenant of the context they are acquired with. The restriction
+// is lifted before a connection is handed out again
+func ConfigurePool(dbConfig *pgxpool.Config) {
+	dbConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+		return repository.New(conn).EnforceTenancy(ctx)
+	}
+	dbConfig.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		tenant := setting(ctx)
+		if tenant == "" {
+			return true, nil
+		}
+		// A connection that could not be restricted is destroyed rather
+		// than handed out in an unknown state
+		if err := repository.New(conn).SetCurrentTenant(ctx, tenant); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	dbConfig.AfterRelease = func(conn *pgx.Conn) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return repository.New(conn).SetCurrentTenant(ctx, "") == nil
+	}
+}
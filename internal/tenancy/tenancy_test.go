@@ -0,0 +1,160 @@
+package tenancy_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+// createAccount creates an account in a tenant
+func createAccount(t *testing.T, pool *pgxpool.Pool, tenant, email string) repository.Account {
+	t.Helper()
+	ctx := context.Background()
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("Could not begin transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+	repo := repository.New(tx)
+
+	if err := repo.SetCurrentTenant(ctx, tenant); err != nil {
+		t.Fatalf("Could not switch to tenant %s: %v", tenant, err)
+	}
+	account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+		Email: email,
+		Name:  email,
+		Type:  repository.AccountTypeHuman,
+	})
+	if err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Could not commit account: %v", err)
+	}
+	return account
+}
+
+// newPool opens a pool configured with ConfigurePool as a role row level
+// security applies to. It holds a single connection so that every acquire
+// reuses the connection the previous one released
+func newPool(t *testing.T, admin *pgxpool.Pool) *pgxpool.Pool {
+	t.Helper()
+	config := testdb.Unprivileged(t, admin)
+	config.MaxConns = 1
+	tenancy.ConfigurePool(config)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Could not open pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+func TestConfigurePoolIsolatesTenants(t *testing.T) {
+	admin := testdb.New(t)
+	acme := createAccount(t, admin, "acme", "ada@acme.example")
+	globex := createAccount(t, admin, "globex", "grace@globex.example")
+	repo := repository.New(newPool(t, admin))
+
+	tests := []struct {
+		name       string
+		ctx        context.Context
+		seesAcme   bool
+		seesGlobex bool
+	}{
+		{"tenant", tenancy.WithTenant(context.Background(), "acme"), true, false},
+		{"no tenant", context.Background(), false, false},
+		{"other tenant", tenancy.WithTenant(context.Background(), "globex"), false, true},
+		{"all tenants", tenancy.WithAllTenants(context.Background()), true, true},
+		{"tenant over all tenants", tenancy.WithTenant(tenancy.WithAllTenants(context.Background()), "acme"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, account := range []struct {
+				account repository.Account
+				sees    bool
+			}{{acme, tt.seesAcme}, {globex, tt.seesGlobex}} {
+				_, err := repo.GetAccountByID(tt.ctx, account.account.ID)
+				switch {
+				case account.sees && err != nil:
+					t.Errorf("Expected to see %s, got %v", account.account.Email, err)
+				case !account.sees && !errors.Is(err, pgx.ErrNoRows):
+					t.Errorf("Expected not to see %s, got %v", account.account.Email, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigurePoolRejectsWritesWithoutTenant(t *testing.T) {
+	admin := testdb.New(t)
+	repo := repository.New(newPool(t, admin))
+
+	if _, err := repo.CreateAccount(context.Background(), repository.CreateAccountParams{
+		Email: "nobody@example.com",
+		Name:  "Nobody",
+		Type:  repository.AccountTypeHuman,
+	}); err == nil {
+		t.Errorf("Expected an account created without a tenant to be rejected")
+	}
+
+	ctx := tenancy.WithTenant(context.Background(), "acme")
+	account, err := repo.CreateAccount(ctx, repository.CreateAccountParams{
+		Email: "ada@acme.example",
+		Name:  "Ada",
+		Type:  repository.AccountTypeHuman,
+	})
+	if err != nil {
+		t.Fatalf("Could not create account: %v", err)
+	}
+	if account.TenantID != "acme" {
+		t.Errorf("Expected the account to belong to acme, got %q", account.TenantID)
+	}
+}
+
+func TestLeaderboardIsRankedPerTenant(t *testing.T) {
+	admin := testdb.New(t)
+	createAccount(t, admin, "acme", "ada@acme.example")
+	createAccount(t, admin, "globex", "grace@globex.example")
+	if _, err := admin.Exec(context.Background(), "REFRESH MATERIALIZED VIEW account_vibepoint_rank"); err != nil {
+		t.Fatalf("Could not refresh the leaderboard: %v", err)
+	}
+	repo := repository.New(newPool(t, admin))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want int64
+	}{
+		{"tenant", tenancy.WithTenant(context.Background(), "acme"), 1},
+		{"no tenant", context.Background(), 0},
+		{"all tenants", tenancy.WithAllTenants(context.Background()), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := repo.GetGlobalLeaderBoardCount(tt.ctx)
+			if err != nil {
+				t.Fatalf("Could not count the leaderboard: %v", err)
+			}
+			if count != tt.want {
+				t.Errorf("Expected %d ranked accounts, got %d", tt.want, count)
+			}
+
+			leaderboard, err := repo.GetLeaderboard(tt.ctx, repository.GetLeaderboardParams{Limit: 10})
+			if err != nil {
+				t.Fatalf("Could not list the leaderboard: %v", err)
+			}
+			if int64(len(leaderboard)) != tt.want {
+				t.Errorf("Expected %d listed accounts, got %d", tt.want, len(leaderboard))
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package testdb_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+	"github.com/opencrafts-io/verisafe/internal/testdb"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(testdb.Main(m))
+}
+
+func TestNewIsMigratedAndSeeded(t *testing.T) {
+	repo := repository.New(testdb.New(t))
+
+	permissions, err := repo.GetPermissionNames(context.Background())
+	if err != nil {
+		t.Fatalf("Could not list permissions: %v", err)
+	}
+	if len(permissions) == 0 {
+		t.Errorf("Expected the seeded permissions, got none")
+	}
+}
+
+func TestTxIsRolledBack(t *testing.T) {
+	const email = "testdb@example.com"
+
+	t.Run("create", func(t *testing.T) {
+		repo := repository.New(testdb.Tx(t))
+		if _, err := repo.CreateAccount(context.Background(), repository.CreateAccountParams{
+			Email: email,
+			Name:  "Test Database",
+			Type:  repository.AccountTypeHuman,
+		}); err != nil {
+			t.Fatalf("Could not create account: %v", err)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		repo := repository.New(testdb.Tx(t))
+		_, err := repo.GetAccountByEmail(context.Background(), email)
+		if !errors.Is(err, pgx.ErrNoRows) {
+			t.Errorf("Expected the account to be rolled back, got %v", err)
+		}
+	})
+}
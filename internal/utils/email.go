@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"strings"
+)
+
+// NormalizeEmail trims surrounding whitespace and lower cases an email so that
+// addresses differing only in case resolve to the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// EmailDomain returns the lower cased domain part of an email address or an
+// empty string if the address has no domain
+func EmailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// IsEmailDomainBlocked reports whether the domain of the email, or any of its
+// parent domains, appears in the blocked list.
+// e.g. with "mailinator.com" blocked both "a@mailinator.com" and
+// "a@eu.mailinator.com" are rejected
+func IsEmailDomainBlocked(email string, blocked []string) bool {
+	domain := EmailDomain(email)
+	if domain == "" {
+		return false
+	}
+
+	for _, b := range blocked {
+		b = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(b), "@"))
+		if b == "" {
+			continue
+		}
+		if domain == b || strings.HasSuffix(domain, "."+b) {
+			return true
+		}
+	}
+	return false
+}
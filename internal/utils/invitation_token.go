@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidInvitationToken is returned for tokens that were not signed by
+// Verisafe or were tampered with
+var ErrInvalidInvitationToken = errors.New("invalid invitation token")
+
+// SignInvitationToken returns a token carrying the invitation id along with
+// an HMAC of it so that invitations can neither be guessed nor forged
+func SignInvitationToken(invitationID uuid.UUID, secret string) string {
+	id := base64.RawURLEncoding.EncodeToString(invitationID[:])
+	return id + "." + invitationSignature(id, secret)
+}
+
+// ParseInvitationToken verifies the signature of a token created by
+// SignInvitationToken and returns the invitation id it carries
+func ParseInvitationToken(token, secret string) (uuid.UUID, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(invitationSignature(id, secret))) {
+		return uuid.Nil, ErrInvalidInvitationToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidInvitationToken
+	}
+	invitationID, err := uuid.FromBytes(raw)
+	if err != nil {
+		return uuid.Nil, ErrInvalidInvitationToken
+	}
+	return invitationID, nil
+}
+
+func invitationSignature(id, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("institution_invitation:" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// PathUUID reads the path value with the given name as a UUID. When it is
+// not one a 400 response naming the path value is written and false is
+// returned, the handler has nothing left to do
+func PathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		writeInvalidPathValue(w, name, "must be a UUID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// PathInt32 reads the path value with the given name as a 32-bit integer,
+// like the serial ids of institutions. When it is not one a 400 response
+// naming the path value is written and false is returned
+func PathInt32(w http.ResponseWriter, r *http.Request, name string) (int32, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 32)
+	if err != nil {
+		writeInvalidPathValue(w, name, "must be an integer")
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// PathInt64 reads the path value with the given name as a 64-bit integer.
+// When it is not one a 400 response naming the path value is written and
+// false is returned
+func PathInt64(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil {
+		writeInvalidPathValue(w, name, "must be an integer")
+		return 0, false
+	}
+	return id, true
+}
+
+// writeInvalidPathValue writes the 400 response to a request whose path
+// value cannot be parsed
+func writeInvalidPathValue(w http.ResponseWriter, name, problem string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{
+		Error:  "Invalid path parameter",
+		Fields: map[string]string{name: problem},
+	})
+}
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"errors"
+	"regexp"
 	"time"
 
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"github.com/golang-jwt/jwt/v5"
@@ -25,11 +27,31 @@ func HashToken(token string) string {
 	return base64.StdEncoding.EncodeToString(hash[:])
 }
 
+// GenerateServiceToken generates a cryptographically secure service token.
+// The vst_ prefix identifies service tokens wherever they are found
+func GenerateServiceToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "vst_" + base64.URLEncoding.EncodeToString(bytes), nil
+}
+
+var serviceTokenScopePattern = regexp.MustCompile(`^[a-zA-Z0-9:._-]+$`)
+
+// IsValidServiceTokenScope reports whether a scope only holds letters,
+// digits, colons, dots, underscores and dashes
+func IsValidServiceTokenScope(scope string) bool {
+	return serviceTokenScopePattern.MatchString(scope)
+}
+
 // GenerateJWT creates a new token for a given user ID.
 // Provide an optional token type although by default its goin
-// to generate a basic user token
+// to generate a basic user token. The tenant of the account is only
+// recorded when tenancy is enabled
 func GenerateJWT(
 	subject uuid.UUID,
+	tenant string,
 	cfg config.Config,
 	tokenTypeOptional ...VerisafeTokenType,
 ) (string, error) {
@@ -55,12 +77,15 @@ func GenerateJWT(
 		&VerisafeClaims{
 			RegisteredClaims: jwt.RegisteredClaims{
 				ExpiresAt: jwt.NewNumericDate(expiry),
-				Audience:  jwt.ClaimStrings{"https://academia.opencrafts.io/"},
-				Issuer:    "https://verisafe.opencrafts.io/",
+				Audience:  jwt.ClaimStrings{cfg.JWTConfig.Audience},
+				Issuer:    cfg.JWTConfig.Issuer,
 				Subject:   subject.String(),
 				IssuedAt:  jwt.NewNumericDate(time.Now()),
 			},
 		}
+	if cfg.TenancyConfig.Enabled {
+		claims.Tenant = tenant
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(cfg.JWTConfig.ApiSecret))
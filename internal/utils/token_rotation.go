@@ -3,6 +3,7 @@ package utils
 import (
 	"context"
 	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/opencrafts-io/verisafe/internal/repository"
@@ -22,13 +23,25 @@ func NewTokenRotationScheduler(repo *repository.Queries, logger *slog.Logger) *T
 	}
 }
 
-// StartScheduler starts the background scheduler for token rotation and cleanup
+// StartScheduler runs the background scheduler for token rotation and cleanup
+// until the context is cancelled
 func (trs *TokenRotationScheduler) StartScheduler(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+
 	// Run cleanup every hour
-	go trs.runCleanupScheduler(ctx)
-	
+	go func() {
+		defer wg.Done()
+		trs.runCleanupScheduler(ctx)
+	}()
+
 	// Run rotation check every 6 hours
-	go trs.runRotationScheduler(ctx)
+	go func() {
+		defer wg.Done()
+		trs.runRotationScheduler(ctx)
+	}()
+
+	wg.Wait()
 }
 
 // runCleanupScheduler runs the cleanup task periodically
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+// ValidationErrorResponse is the body of the 400 responses to requests whose
+// body cannot be decoded or fails validation
+type ValidationErrorResponse struct {
+	Error string `json:"error"`
+	// What is wrong with each invalid field, keyed by the field's path in
+	// the request body e.g. account.email
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+var validate = newValidator()
+
+// newValidator returns a validator naming fields after their JSON names so
+// that errors refer to fields the way clients send them
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// Like required but also rejects strings made of whitespace only
+	v.RegisterValidation("notblank", validators.NotBlank)
+
+	// sqlc generates the repository parameters some handlers decode request
+	// bodies into, so their rules cannot be written as validate tags
+	v.RegisterStructValidationMapRules(map[string]string{
+		"ID":    "required",
+		"Name":  "notblank",
+		"Email": "omitempty,email",
+	}, repository.UpdateAccountDetailsParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"ID":    "required",
+		"Phone": "min=5",
+	}, repository.UpdateAccountPhoneNumberParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"Name":            "notblank",
+		"CooldownSeconds": "min=0",
+	}, repository.CreateActivityParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"CooldownSeconds": "omitempty,min=0",
+	}, repository.UpdateActivityParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"Name": "notblank",
+	}, repository.CreateInstitutionParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"AccountID":      "required",
+		"InstitutionID":  "required",
+		"MembershipRole": "omitempty,oneof=owner admin member",
+	}, repository.AddAccountInstitutionParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"AccountID":     "required",
+		"InstitutionID": "required",
+	}, repository.RemoveAccountInstitutionParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"Name": "notblank",
+	}, repository.CreatePermissionParams{}, repository.CreateRoleParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"ID":   "required",
+		"Name": "notblank",
+	}, repository.UpdatePermissionParams{}, repository.UpdateRoleParams{})
+	v.RegisterStructValidationMapRules(map[string]string{
+		"DaysRequired": "gt=0",
+		"BonusPoints":  "min=1,max=10",
+		"Title":        "notblank",
+	}, repository.CreateStreakMilestoneParams{})
+	return v
+}
+
+// DecodeAndValidate decodes the JSON body of a request into v and validates
+// it against the validate tags of v's fields. An empty body is validated as
+// an empty object. When the body cannot be decoded or is invalid a 400
+// response naming the invalid fields is written and false is returned, the
+// handler has nothing left to do
+func DecodeAndValidate(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		response := ValidationErrorResponse{Error: "Invalid request body"}
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			response.Fields = map[string]string{typeErr.Field: "must be " + jsonTypeName(typeErr.Type)}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(response)
+		return false
+	}
+
+	if fields := ValidationErrors(v); len(fields) > 0 {
+		WriteValidationErrors(w, fields)
+		return false
+	}
+	return true
+}
+
+// WriteValidationErrors writes a 400 response naming what is wrong with each
+// invalid field, for rules handlers check themselves
+func WriteValidationErrors(w http.ResponseWriter, fields map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(ValidationErrorResponse{
+		Error:  "Validation failed",
+		Fields: fields,
+	})
+}
+
+// ValidationErrors validates a struct against the validate tags of its
+// fields and returns what is wrong with each invalid field, keyed by the
+// field's JSON path. It returns nil when the struct is valid
+func ValidationErrors(v any) map[string]string {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return map[string]string{"": err.Error()}
+	}
+
+	fields := make(map[string]string, len(invalid))
+	for _, fieldErr := range invalid {
+		// The namespace starts with the name of the validated struct
+		_, path, _ := strings.Cut(fieldErr.Namespace(), ".")
+		if _, ok := fields[path]; !ok {
+			fields[path] = validationMessage(fieldErr)
+		}
+	}
+	return fields
+}
+
+// validationMessage describes the rule a field broke
+func validationMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	counted := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		counted = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		counted = " items"
+	}
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "hexcolor":
+		return "must be a hex colour e.g. #1a2b3c"
+	case "ip":
+		return "must be an IP address"
+	case "ipv4":
+		return "must be an IPv4 address"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "len":
+		return fmt.Sprintf("must have exactly %s%s", param, counted)
+	case "min":
+		if counted != "" {
+			return fmt.Sprintf("must have at least %s%s", param, counted)
+		}
+		return "must be at least " + param
+	case "max":
+		if counted != "" {
+			return fmt.Sprintf("must have at most %s%s", param, counted)
+		}
+		return "must be at most " + param
+	case "gte":
+		return "must be at least " + param
+	case "lte":
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	}
+	return "is invalid"
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from, e.g. an integer
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "a string"
+}
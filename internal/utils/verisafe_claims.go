@@ -7,4 +7,6 @@ import (
 // Claims structure for JWT
 type VerisafeClaims struct {
 	jwt.RegisteredClaims
+	// The tenant the token was issued for, only set when tenancy is enabled
+	Tenant string `json:"tenant,omitempty"`
 }
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// VersionETag returns the entity tag of a record at the given version, sent
+// in the ETag header so that clients can send it back in If-Match
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ExpectedVersion returns the version a client expects the record it updates
+// to be at, read from the If-Match header or else from the version in the
+// request body. nil means the client did not ask for a check and the update
+// is applied whatever the version. When If-Match is not a version a 400
+// response is written and false is returned
+func ExpectedVersion(w http.ResponseWriter, r *http.Request, body *int64) (*int64, bool) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return body, true
+	}
+
+	tag := strings.TrimPrefix(ifMatch, "W/")
+	version, err := strconv.ParseInt(strings.Trim(tag, `"`), 10, 64)
+	if err != nil || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		WriteValidationErrors(w, map[string]string{
+			"If-Match": "must be the ETag of the record, e.g. \"3\"",
+		})
+		return nil, false
+	}
+	return &version, true
+}
@@ -0,0 +1,265 @@
+// Package webhooks delivers Verisafe events to partner HTTPS endpoints.
+//
+// OVERVIEW:
+// Not every consumer can connect to our RabbitMQ broker. Partners instead
+// register an HTTPS endpoint together with the event types they are
+// interested in. Whenever one of those events is published the Dispatcher
+// records a delivery row per matching endpoint and a background loop POSTs
+// the event payload to the endpoint.
+//
+// SIGNATURES:
+// Every request carries the X-Verisafe-Timestamp and X-Verisafe-Signature
+// headers. The signature is an HMAC-SHA256 over "<timestamp>.<body>" keyed
+// with the endpoint's secret, see Sign.
+//
+// RETRIES:
+// Any non 2xx response or transport error is retried with exponential
+// backoff until the configured number of attempts is exhausted, after which
+// the delivery is marked as failed. Failed deliveries may be redelivered
+// manually through the delivery log API.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/repository"
+)
+
+const (
+	// How many due deliveries are picked per poll
+	deliveryBatchSize = 20
+	// How long a claimed delivery is hidden from other dispatchers
+	deliveryLeaseSeconds = 120
+	// First retry delay, doubled on every subsequent attempt
+	baseRetryDelay = 30 * time.Second
+	// Upper bound on the delay between two attempts
+	maxRetryDelay = 6 * time.Hour
+	// Maximum number of response body bytes kept in the delivery log
+	maxLoggedResponseBytes = 512
+)
+
+// Dispatcher records webhook deliveries and sends them in the background
+type Dispatcher struct {
+	pool         *pgxpool.Pool
+	logger       *slog.Logger
+	client       *http.Client
+	maxAttempts  int
+	pollInterval time.Duration
+}
+
+// NewDispatcher creates a new webhook Dispatcher. Call Start to begin
+// sending deliveries.
+func NewDispatcher(cfg *config.Config, pool *pgxpool.Pool, logger *slog.Logger) *Dispatcher {
+	maxAttempts := cfg.WebhookConfig.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	pollInterval := time.Duration(cfg.WebhookConfig.PollIntervalSeconds) * time.Second
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	timeout := time.Duration(cfg.WebhookConfig.RequestTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &Dispatcher{
+		pool:         pool,
+		logger:       logger,
+		client:       &http.Client{Timeout: timeout},
+		maxAttempts:  maxAttempts,
+		pollInterval: pollInterval,
+	}
+}
+
+// Enqueue records a delivery of the event for every active endpoint
+// subscribed to the event type. The event is serialized as is and sent as the
+// request body.
+func (d *Dispatcher) Enqueue(ctx context.Context, eventType string, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	repo := repository.New(d.pool)
+	endpoints, err := repo.GetActiveWebhookEndpointsForEvent(ctx, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve webhook endpoints: %w", err)
+	}
+
+	for _, endpoint := range endpoints {
+		if _, err := repo.CreateWebhookDelivery(ctx, repository.CreateWebhookDeliveryParams{
+			EndpointID: endpoint.ID,
+			EventType:  eventType,
+			Payload:    payload,
+		}); err != nil {
+			return fmt.Errorf("failed to record webhook delivery for endpoint %s: %w", endpoint.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// Start polls for due deliveries until the context is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	d.logger.Info("Webhook dispatcher started",
+		slog.Duration("poll_interval", d.pollInterval),
+		slog.Int("max_attempts", d.maxAttempts),
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.dispatchDue(ctx)
+		}
+	}
+}
+
+// dispatchDue claims a batch of due deliveries and sends them
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	repo := repository.New(d.pool)
+	deliveries, err := repo.ClaimDueWebhookDeliveries(ctx, repository.ClaimDueWebhookDeliveriesParams{
+		LeaseSeconds: deliveryLeaseSeconds,
+		BatchSize:    deliveryBatchSize,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			d.logger.Error("Failed to claim webhook deliveries", slog.Any("error", err))
+		}
+		return
+	}
+
+	endpoints := map[uuid.UUID]repository.WebhookEndpoint{}
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			endpoint, err = repo.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+			if err != nil {
+				d.logger.Error("Failed to retrieve webhook endpoint",
+					slog.Any("error", err),
+					slog.String("endpoint_id", delivery.EndpointID.String()),
+				)
+				continue
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		d.deliver(ctx, repo, endpoint, delivery)
+	}
+}
+
+// deliver sends a single delivery and records the outcome
+func (d *Dispatcher) deliver(ctx context.Context, repo *repository.Queries, endpoint repository.WebhookEndpoint, delivery repository.WebhookDelivery) {
+	statusCode, err := d.send(ctx, endpoint, delivery)
+	if err == nil {
+		code := int32(statusCode)
+		if err := repo.MarkWebhookDeliverySucceeded(ctx, repository.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: &code,
+		}); err != nil {
+			d.logger.Error("Failed to record webhook delivery success",
+				slog.Any("error", err),
+				slog.Int64("delivery_id", delivery.ID),
+			)
+		}
+		return
+	}
+
+	attempt := int(delivery.Attempts) + 1
+	status := repository.WebhookDeliveryStatusPending
+	if attempt >= d.maxAttempts || !endpoint.IsActive {
+		status = repository.WebhookDeliveryStatusFailed
+	}
+
+	var lastStatusCode *int32
+	if statusCode != 0 {
+		code := int32(statusCode)
+		lastStatusCode = &code
+	}
+	lastError := err.Error()
+
+	d.logger.Warn("Webhook delivery failed",
+		slog.Any("error", err),
+		slog.Int64("delivery_id", delivery.ID),
+		slog.String("endpoint_id", endpoint.ID.String()),
+		slog.Int("attempt", attempt),
+		slog.String("status", string(status)),
+	)
+
+	if err := repo.MarkWebhookDeliveryFailed(ctx, repository.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		LastStatusCode: lastStatusCode,
+		LastError:      &lastError,
+		Status:         status,
+		RetryInSeconds: int32(RetryDelay(attempt).Seconds()),
+	}); err != nil {
+		d.logger.Error("Failed to record webhook delivery failure",
+			slog.Any("error", err),
+			slog.Int64("delivery_id", delivery.ID),
+		)
+	}
+}
+
+// send POSTs the signed payload to the endpoint returning the response
+// status code. Any non 2xx response is reported as an error
+func (d *Dispatcher) send(ctx context.Context, endpoint repository.WebhookEndpoint, delivery repository.WebhookDelivery) (int, error) {
+	if !endpoint.IsActive {
+		return 0, fmt.Errorf("endpoint is disabled")
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Verisafe-Webhooks/1.0")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedResponseBytes))
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
+
+// RetryDelay returns how long to wait before retrying after the given
+// (1-based) attempt failed
+func RetryDelay(attempt int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,27 @@
+package webhooks
+
+import "slices"
+
+// SupportedEventTypes lists the event types partners may subscribe to
+var SupportedEventTypes = []string{
+	"user.created",
+	"user.updated",
+	"user.deleted",
+	"authz.changed",
+	"role.assigned",
+	"role.revoked",
+	"season.closed",
+	"rank.top_entered",
+	"rank.overtaken",
+	"institution.created",
+	"institution.updated",
+	"institution.deleted",
+	"institution.restored",
+	"institution.join_request.approved",
+	"institution.join_request.rejected",
+}
+
+// IsSupportedEventType reports whether partners may subscribe to eventType
+func IsSupportedEventType(eventType string) bool {
+	return slices.Contains(SupportedEventTypes, eventType)
+}
@@ -2,33 +2,152 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/opencrafts-io/verisafe/internal/app"
 	"github.com/opencrafts-io/verisafe/internal/config"
+	"github.com/opencrafts-io/verisafe/internal/logs"
+	"github.com/opencrafts-io/verisafe/internal/tenancy"
 )
 
+const usage = `Usage: verisafe [command]
+
+Commands:
+  serve          Start the server, the default without a command
+  migrate        Manage the database schema
+  seed           Apply pending migrations, the RBAC seed and the SEED_ accounts
+  create-admin   Grant the Administrator role to an account, creating it
+  token create   Create a service token for a bot account
+  help           Show this help
+
+Run verisafe <command> -h for the options of a command.
+`
+
+// command is a subcommand of the binary, run once the configuration is
+// loaded. Commands returning their usage error get their usage shown
+type command struct {
+	usage    string
+	errUsage error
+	run      func(ctx context.Context, logger *slog.Logger, cfg *config.Config, args []string, out io.Writer) error
+}
+
 func main() {
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	// Logs are written as JSON until the configuration tells the format. The
+	// level and the share of request logs kept are set once it is loaded and
+	// on every reload
+	logLevel := new(slog.LevelVar)
+	requestSampler := logs.NewSampler(1)
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: logLevel}))
+
+	commands := map[string]command{
+		"serve": {run: func(ctx context.Context, logger *slog.Logger, cfg *config.Config, args []string, _ io.Writer) error {
+			if len(args) > 0 {
+				return fmt.Errorf("serve takes no arguments")
+			}
+			return serve(ctx, logger, cfg, logLevel, requestSampler)
+		}},
+		"migrate": {usage: app.MigrateUsage, errUsage: app.ErrMigrateUsage, run: app.Migrate},
+		"seed": {run: func(ctx context.Context, logger *slog.Logger, cfg *config.Config, args []string, _ io.Writer) error {
+			if len(args) > 0 {
+				return fmt.Errorf("seed takes no arguments")
+			}
+			return app.Seed(ctx, logger, cfg)
+		}},
+		"create-admin": {usage: app.CreateAdminUsage, errUsage: app.ErrCreateAdminUsage, run: app.CreateAdmin},
+		"token":        {usage: app.TokenUsage, errUsage: app.ErrTokenUsage, run: app.Token},
+	}
+
+	name, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		name, args = os.Args[1], os.Args[2:]
+	}
+	if name == "help" || isHelpFlag(name) {
+		fmt.Print(usage)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+
+	// Asking a command for its help needs no configuration
+	if cmd.usage != "" && slices.ContainsFunc(args, isHelpFlag) {
+		fmt.Print(cmd.usage)
+		return
+	}
+
 	cfg, err := config.LoadConfig()
 	if err != nil {
+		// Every problem is listed at once so they can all be fixed before
+		// the next start
+		var invalid *config.ValidationError
+		if errors.As(err, &invalid) {
+			fmt.Fprintln(os.Stderr, err)
+			logger.Error("Invalid configuration", slog.Any("problems", invalid.Problems))
+			os.Exit(1)
+		}
 		logger.Error("Failed to load configuration file", slog.Any("error", err))
-		return
+		os.Exit(1)
+	}
+	logLevel.Set(cfg.LogLevel())
+	requestSampler.SetRate(cfg.LogConfig.RequestSampleRate)
+	handler, err := logs.NewHandler(os.Stdout, logs.Options{
+		Format:  cfg.LogConfig.Format,
+		Level:   logLevel,
+		Sampler: requestSampler,
+	})
+	if err != nil {
+		logger.Error("Failed to create log handler", slog.Any("error", err))
+		os.Exit(1)
 	}
+	logger = slog.New(handler)
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	// Orchestrators ask to stop with SIGTERM. A second signal while
+	// draining stops Verisafe right away
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
+	go func() {
+		<-ctx.Done()
+		cancel()
+	}()
 
+	// Commands, migrations and the background jobs of the server work on the
+	// accounts of every tenant. Requests do not derive from this context and
+	// only see the accounts of the tenant they are made to
+	if err := cmd.run(tenancy.WithAllTenants(ctx), logger, cfg, args, os.Stdout); err != nil {
+		if cmd.errUsage != nil && errors.Is(err, cmd.errUsage) {
+			fmt.Fprintf(os.Stderr, "%s\n\n%s", err, cmd.usage)
+			os.Exit(2)
+		}
+		logger.Error("Command failed.", slog.String("command", name), slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// serve runs Verisafe until the context is cancelled
+func serve(ctx context.Context, logger *slog.Logger, cfg *config.Config, logLevel *slog.LevelVar, requestSampler *logs.Sampler) error {
 	app, err := app.New(logger, cfg)
 	if err != nil {
-		logger.Error("Failed to create app.", slog.Any("error", err))
+		return fmt.Errorf("failed to create app: %w", err)
 	}
 
-	if err := app.Start(ctx); err != nil {
-		logger.Error("Failed to start app.", slog.Any("error", err))
-	}
+	app.OnReload(func(cfg *config.Config) {
+		logLevel.Set(cfg.LogLevel())
+		requestSampler.SetRate(cfg.LogConfig.RequestSampleRate)
+	})
 
+	return app.Start(ctx)
 }
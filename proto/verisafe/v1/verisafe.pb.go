@@ -0,0 +1,861 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: verisafe/v1/verisafe.proto
+
+package verisafev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ValidateTokenRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The access token, without the Bearer prefix
+	Token         string `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateTokenRequest) Reset() {
+	*x = ValidateTokenRequest{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateTokenRequest) ProtoMessage() {}
+
+func (x *ValidateTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateTokenRequest.ProtoReflect.Descriptor instead.
+func (*ValidateTokenRequest) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ValidateTokenRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type ValidateTokenResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Account   *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Names of the global roles of the account
+	Roles []string `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	// Permissions granted through the global roles of the account
+	Permissions []string `protobuf:"bytes,4,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// Permissions explicitly denied to the account, they take precedence over
+	// the granted permissions
+	DeniedPermissions []string `protobuf:"bytes,5,rep,name=denied_permissions,json=deniedPermissions,proto3" json:"denied_permissions,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ValidateTokenResponse) Reset() {
+	*x = ValidateTokenResponse{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateTokenResponse) ProtoMessage() {}
+
+func (x *ValidateTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateTokenResponse.ProtoReflect.Descriptor instead.
+func (*ValidateTokenResponse) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidateTokenResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+func (x *ValidateTokenResponse) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ValidateTokenResponse) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *ValidateTokenResponse) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *ValidateTokenResponse) GetDeniedPermissions() []string {
+	if x != nil {
+		return x.DeniedPermissions
+	}
+	return nil
+}
+
+type GetAccountRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AccountId     string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountRequest) Reset() {
+	*x = GetAccountRequest{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountRequest) ProtoMessage() {}
+
+func (x *GetAccountRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountRequest.ProtoReflect.Descriptor instead.
+func (*GetAccountRequest) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetAccountRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+type GetAccountResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       *Account               `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAccountResponse) Reset() {
+	*x = GetAccountResponse{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAccountResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAccountResponse) ProtoMessage() {}
+
+func (x *GetAccountResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAccountResponse.ProtoReflect.Descriptor instead.
+func (*GetAccountResponse) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetAccountResponse) GetAccount() *Account {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+type Account struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email     string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Name      string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Username  *string                `protobuf:"bytes,4,opt,name=username,proto3,oneof" json:"username,omitempty"`
+	AvatarUrl *string                `protobuf:"bytes,5,opt,name=avatar_url,json=avatarUrl,proto3,oneof" json:"avatar_url,omitempty"`
+	Bio       *string                `protobuf:"bytes,6,opt,name=bio,proto3,oneof" json:"bio,omitempty"`
+	Phone     *string                `protobuf:"bytes,7,opt,name=phone,proto3,oneof" json:"phone,omitempty"`
+	// Either human or bot
+	Type       string                 `protobuf:"bytes,8,opt,name=type,proto3" json:"type,omitempty"`
+	VibePoints int64                  `protobuf:"varint,9,opt,name=vibe_points,json=vibePoints,proto3" json:"vibe_points,omitempty"`
+	CreatedAt  *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt  *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Set while the account waits to be deleted
+	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	DeactivatedAt *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=deactivated_at,json=deactivatedAt,proto3" json:"deactivated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Account) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Account) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Account) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Account) GetUsername() string {
+	if x != nil && x.Username != nil {
+		return *x.Username
+	}
+	return ""
+}
+
+func (x *Account) GetAvatarUrl() string {
+	if x != nil && x.AvatarUrl != nil {
+		return *x.AvatarUrl
+	}
+	return ""
+}
+
+func (x *Account) GetBio() string {
+	if x != nil && x.Bio != nil {
+		return *x.Bio
+	}
+	return ""
+}
+
+func (x *Account) GetPhone() string {
+	if x != nil && x.Phone != nil {
+		return *x.Phone
+	}
+	return ""
+}
+
+func (x *Account) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Account) GetVibePoints() int64 {
+	if x != nil {
+		return x.VibePoints
+	}
+	return 0
+}
+
+func (x *Account) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Account) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Account) GetDeletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeletedAt
+	}
+	return nil
+}
+
+func (x *Account) GetDeactivatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DeactivatedAt
+	}
+	return nil
+}
+
+type CheckPermissionRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Subject    string                 `protobuf:"bytes,1,opt,name=subject,proto3" json:"subject,omitempty"`
+	Permission string                 `protobuf:"bytes,2,opt,name=permission,proto3" json:"permission,omitempty"`
+	// The account owning the resource, if any
+	ResourceOwnerId *string `protobuf:"bytes,3,opt,name=resource_owner_id,json=resourceOwnerId,proto3,oneof" json:"resource_owner_id,omitempty"`
+	// The institution the resource belongs to, if any
+	InstitutionId *int32 `protobuf:"varint,4,opt,name=institution_id,json=institutionId,proto3,oneof" json:"institution_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPermissionRequest) Reset() {
+	*x = CheckPermissionRequest{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPermissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPermissionRequest) ProtoMessage() {}
+
+func (x *CheckPermissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPermissionRequest.ProtoReflect.Descriptor instead.
+func (*CheckPermissionRequest) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CheckPermissionRequest) GetSubject() string {
+	if x != nil {
+		return x.Subject
+	}
+	return ""
+}
+
+func (x *CheckPermissionRequest) GetPermission() string {
+	if x != nil {
+		return x.Permission
+	}
+	return ""
+}
+
+func (x *CheckPermissionRequest) GetResourceOwnerId() string {
+	if x != nil && x.ResourceOwnerId != nil {
+		return *x.ResourceOwnerId
+	}
+	return ""
+}
+
+func (x *CheckPermissionRequest) GetInstitutionId() int32 {
+	if x != nil && x.InstitutionId != nil {
+		return *x.InstitutionId
+	}
+	return 0
+}
+
+type CheckPermissionResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Allowed bool                   `protobuf:"varint,1,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	// One of role, institution_role, policy, denied, deny_rule or
+	// unknown_subject
+	Reason string `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Set when the checked permission is deprecated and names the permission
+	// it was evaluated as
+	ReplacedBy    string `protobuf:"bytes,3,opt,name=replaced_by,json=replacedBy,proto3" json:"replaced_by,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckPermissionResponse) Reset() {
+	*x = CheckPermissionResponse{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckPermissionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckPermissionResponse) ProtoMessage() {}
+
+func (x *CheckPermissionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckPermissionResponse.ProtoReflect.Descriptor instead.
+func (*CheckPermissionResponse) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CheckPermissionResponse) GetAllowed() bool {
+	if x != nil {
+		return x.Allowed
+	}
+	return false
+}
+
+func (x *CheckPermissionResponse) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CheckPermissionResponse) GetReplacedBy() string {
+	if x != nil {
+		return x.ReplacedBy
+	}
+	return ""
+}
+
+type ListInstitutionMembersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InstitutionId int32                  `protobuf:"varint,1,opt,name=institution_id,json=institutionId,proto3" json:"institution_id,omitempty"`
+	// Defaults to 10, at most 100
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInstitutionMembersRequest) Reset() {
+	*x = ListInstitutionMembersRequest{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstitutionMembersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstitutionMembersRequest) ProtoMessage() {}
+
+func (x *ListInstitutionMembersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstitutionMembersRequest.ProtoReflect.Descriptor instead.
+func (*ListInstitutionMembersRequest) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListInstitutionMembersRequest) GetInstitutionId() int32 {
+	if x != nil {
+		return x.InstitutionId
+	}
+	return 0
+}
+
+func (x *ListInstitutionMembersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListInstitutionMembersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListInstitutionMembersResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Members []*InstitutionMember   `protobuf:"bytes,1,rep,name=members,proto3" json:"members,omitempty"`
+	// Number of members of the institution
+	Total         int64 `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListInstitutionMembersResponse) Reset() {
+	*x = ListInstitutionMembersResponse{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListInstitutionMembersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListInstitutionMembersResponse) ProtoMessage() {}
+
+func (x *ListInstitutionMembersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListInstitutionMembersResponse.ProtoReflect.Descriptor instead.
+func (*ListInstitutionMembersResponse) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListInstitutionMembersResponse) GetMembers() []*InstitutionMember {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *ListInstitutionMembersResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type InstitutionMember struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	AccountId string                 `protobuf:"bytes,1,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Name      string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Username  *string                `protobuf:"bytes,3,opt,name=username,proto3,oneof" json:"username,omitempty"`
+	AvatarUrl *string                `protobuf:"bytes,4,opt,name=avatar_url,json=avatarUrl,proto3,oneof" json:"avatar_url,omitempty"`
+	// One of owner, admin or member
+	MembershipRole string `protobuf:"bytes,5,opt,name=membership_role,json=membershipRole,proto3" json:"membership_role,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InstitutionMember) Reset() {
+	*x = InstitutionMember{}
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstitutionMember) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstitutionMember) ProtoMessage() {}
+
+func (x *InstitutionMember) ProtoReflect() protoreflect.Message {
+	mi := &file_verisafe_v1_verisafe_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstitutionMember.ProtoReflect.Descriptor instead.
+func (*InstitutionMember) Descriptor() ([]byte, []int) {
+	return file_verisafe_v1_verisafe_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *InstitutionMember) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *InstitutionMember) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InstitutionMember) GetUsername() string {
+	if x != nil && x.Username != nil {
+		return *x.Username
+	}
+	return ""
+}
+
+func (x *InstitutionMember) GetAvatarUrl() string {
+	if x != nil && x.AvatarUrl != nil {
+		return *x.AvatarUrl
+	}
+	return ""
+}
+
+func (x *InstitutionMember) GetMembershipRole() string {
+	if x != nil {
+		return x.MembershipRole
+	}
+	return ""
+}
+
+var File_verisafe_v1_verisafe_proto protoreflect.FileDescriptor
+
+const file_verisafe_v1_verisafe_proto_rawDesc = "" +
+	"\n" +
+	"\x1averisafe/v1/verisafe.proto\x12\vverisafe.v1\x1a\x1fgoogle/protobuf/timestamp.proto\",\n" +
+	"\x14ValidateTokenRequest\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\xe9\x01\n" +
+	"\x15ValidateTokenResponse\x12.\n" +
+	"\aaccount\x18\x01 \x01(\v2\x14.verisafe.v1.AccountR\aaccount\x129\n" +
+	"\n" +
+	"expires_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x14\n" +
+	"\x05roles\x18\x03 \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\x04 \x03(\tR\vpermissions\x12-\n" +
+	"\x12denied_permissions\x18\x05 \x03(\tR\x11deniedPermissions\"2\n" +
+	"\x11GetAccountRequest\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\"D\n" +
+	"\x12GetAccountResponse\x12.\n" +
+	"\aaccount\x18\x01 \x01(\v2\x14.verisafe.v1.AccountR\aaccount\"\x91\x04\n" +
+	"\aAccount\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x1f\n" +
+	"\busername\x18\x04 \x01(\tH\x00R\busername\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"avatar_url\x18\x05 \x01(\tH\x01R\tavatarUrl\x88\x01\x01\x12\x15\n" +
+	"\x03bio\x18\x06 \x01(\tH\x02R\x03bio\x88\x01\x01\x12\x19\n" +
+	"\x05phone\x18\a \x01(\tH\x03R\x05phone\x88\x01\x01\x12\x12\n" +
+	"\x04type\x18\b \x01(\tR\x04type\x12\x1f\n" +
+	"\vvibe_points\x18\t \x01(\x03R\n" +
+	"vibePoints\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x129\n" +
+	"\n" +
+	"deleted_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12A\n" +
+	"\x0edeactivated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\rdeactivatedAtB\v\n" +
+	"\t_usernameB\r\n" +
+	"\v_avatar_urlB\x06\n" +
+	"\x04_bioB\b\n" +
+	"\x06_phone\"\xd8\x01\n" +
+	"\x16CheckPermissionRequest\x12\x18\n" +
+	"\asubject\x18\x01 \x01(\tR\asubject\x12\x1e\n" +
+	"\n" +
+	"permission\x18\x02 \x01(\tR\n" +
+	"permission\x12/\n" +
+	"\x11resource_owner_id\x18\x03 \x01(\tH\x00R\x0fresourceOwnerId\x88\x01\x01\x12*\n" +
+	"\x0einstitution_id\x18\x04 \x01(\x05H\x01R\rinstitutionId\x88\x01\x01B\x14\n" +
+	"\x12_resource_owner_idB\x11\n" +
+	"\x0f_institution_id\"l\n" +
+	"\x17CheckPermissionResponse\x12\x18\n" +
+	"\aallowed\x18\x01 \x01(\bR\aallowed\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x1f\n" +
+	"\vreplaced_by\x18\x03 \x01(\tR\n" +
+	"replacedBy\"t\n" +
+	"\x1dListInstitutionMembersRequest\x12%\n" +
+	"\x0einstitution_id\x18\x01 \x01(\x05R\rinstitutionId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x03 \x01(\x05R\x06offset\"p\n" +
+	"\x1eListInstitutionMembersResponse\x128\n" +
+	"\amembers\x18\x01 \x03(\v2\x1e.verisafe.v1.InstitutionMemberR\amembers\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"\xd0\x01\n" +
+	"\x11InstitutionMember\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x01 \x01(\tR\taccountId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x1f\n" +
+	"\busername\x18\x03 \x01(\tH\x00R\busername\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"avatar_url\x18\x04 \x01(\tH\x01R\tavatarUrl\x88\x01\x01\x12'\n" +
+	"\x0fmembership_role\x18\x05 \x01(\tR\x0emembershipRoleB\v\n" +
+	"\t_usernameB\r\n" +
+	"\v_avatar_url2\x89\x03\n" +
+	"\x0fVerisafeService\x12V\n" +
+	"\rValidateToken\x12!.verisafe.v1.ValidateTokenRequest\x1a\".verisafe.v1.ValidateTokenResponse\x12M\n" +
+	"\n" +
+	"GetAccount\x12\x1e.verisafe.v1.GetAccountRequest\x1a\x1f.verisafe.v1.GetAccountResponse\x12\\\n" +
+	"\x0fCheckPermission\x12#.verisafe.v1.CheckPermissionRequest\x1a$.verisafe.v1.CheckPermissionResponse\x12q\n" +
+	"\x16ListInstitutionMembers\x12*.verisafe.v1.ListInstitutionMembersRequest\x1a+.verisafe.v1.ListInstitutionMembersResponseB@Z>github.com/opencrafts-io/verisafe/proto/verisafe/v1;verisafev1b\x06proto3"
+
+var (
+	file_verisafe_v1_verisafe_proto_rawDescOnce sync.Once
+	file_verisafe_v1_verisafe_proto_rawDescData []byte
+)
+
+func file_verisafe_v1_verisafe_proto_rawDescGZIP() []byte {
+	file_verisafe_v1_verisafe_proto_rawDescOnce.Do(func() {
+		file_verisafe_v1_verisafe_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_verisafe_v1_verisafe_proto_rawDesc), len(file_verisafe_v1_verisafe_proto_rawDesc)))
+	})
+	return file_verisafe_v1_verisafe_proto_rawDescData
+}
+
+var file_verisafe_v1_verisafe_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_verisafe_v1_verisafe_proto_goTypes = []any{
+	(*ValidateTokenRequest)(nil),           // 0: verisafe.v1.ValidateTokenRequest
+	(*ValidateTokenResponse)(nil),          // 1: verisafe.v1.ValidateTokenResponse
+	(*GetAccountRequest)(nil),              // 2: verisafe.v1.GetAccountRequest
+	(*GetAccountResponse)(nil),             // 3: verisafe.v1.GetAccountResponse
+	(*Account)(nil),                        // 4: verisafe.v1.Account
+	(*CheckPermissionRequest)(nil),         // 5: verisafe.v1.CheckPermissionRequest
+	(*CheckPermissionResponse)(nil),        // 6: verisafe.v1.CheckPermissionResponse
+	(*ListInstitutionMembersRequest)(nil),  // 7: verisafe.v1.ListInstitutionMembersRequest
+	(*ListInstitutionMembersResponse)(nil), // 8: verisafe.v1.ListInstitutionMembersResponse
+	(*InstitutionMember)(nil),              // 9: verisafe.v1.InstitutionMember
+	(*timestamppb.Timestamp)(nil),          // 10: google.protobuf.Timestamp
+}
+var file_verisafe_v1_verisafe_proto_depIdxs = []int32{
+	4,  // 0: verisafe.v1.ValidateTokenResponse.account:type_name -> verisafe.v1.Account
+	10, // 1: verisafe.v1.ValidateTokenResponse.expires_at:type_name -> google.protobuf.Timestamp
+	4,  // 2: verisafe.v1.GetAccountResponse.account:type_name -> verisafe.v1.Account
+	10, // 3: verisafe.v1.Account.created_at:type_name -> google.protobuf.Timestamp
+	10, // 4: verisafe.v1.Account.updated_at:type_name -> google.protobuf.Timestamp
+	10, // 5: verisafe.v1.Account.deleted_at:type_name -> google.protobuf.Timestamp
+	10, // 6: verisafe.v1.Account.deactivated_at:type_name -> google.protobuf.Timestamp
+	9,  // 7: verisafe.v1.ListInstitutionMembersResponse.members:type_name -> verisafe.v1.InstitutionMember
+	0,  // 8: verisafe.v1.VerisafeService.ValidateToken:input_type -> verisafe.v1.ValidateTokenRequest
+	2,  // 9: verisafe.v1.VerisafeService.GetAccount:input_type -> verisafe.v1.GetAccountRequest
+	5,  // 10: verisafe.v1.VerisafeService.CheckPermission:input_type -> verisafe.v1.CheckPermissionRequest
+	7,  // 11: verisafe.v1.VerisafeService.ListInstitutionMembers:input_type -> verisafe.v1.ListInstitutionMembersRequest
+	1,  // 12: verisafe.v1.VerisafeService.ValidateToken:output_type -> verisafe.v1.ValidateTokenResponse
+	3,  // 13: verisafe.v1.VerisafeService.GetAccount:output_type -> verisafe.v1.GetAccountResponse
+	6,  // 14: verisafe.v1.VerisafeService.CheckPermission:output_type -> verisafe.v1.CheckPermissionResponse
+	8,  // 15: verisafe.v1.VerisafeService.ListInstitutionMembers:output_type -> verisafe.v1.ListInstitutionMembersResponse
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_verisafe_v1_verisafe_proto_init() }
+func file_verisafe_v1_verisafe_proto_init() {
+	if File_verisafe_v1_verisafe_proto != nil {
+		return
+	}
+	file_verisafe_v1_verisafe_proto_msgTypes[4].OneofWrappers = []any{}
+	file_verisafe_v1_verisafe_proto_msgTypes[5].OneofWrappers = []any{}
+	file_verisafe_v1_verisafe_proto_msgTypes[9].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_verisafe_v1_verisafe_proto_rawDesc), len(file_verisafe_v1_verisafe_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_verisafe_v1_verisafe_proto_goTypes,
+		DependencyIndexes: file_verisafe_v1_verisafe_proto_depIdxs,
+		MessageInfos:      file_verisafe_v1_verisafe_proto_msgTypes,
+	}.Build()
+	File_verisafe_v1_verisafe_proto = out.File
+	file_verisafe_v1_verisafe_proto_goTypes = nil
+	file_verisafe_v1_verisafe_proto_depIdxs = nil
+}
@@ -0,0 +1,261 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: verisafe/v1/verisafe.proto
+
+package verisafev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	VerisafeService_ValidateToken_FullMethodName          = "/verisafe.v1.VerisafeService/ValidateToken"
+	VerisafeService_GetAccount_FullMethodName             = "/verisafe.v1.VerisafeService/GetAccount"
+	VerisafeService_CheckPermission_FullMethodName        = "/verisafe.v1.VerisafeService/CheckPermission"
+	VerisafeService_ListInstitutionMembers_FullMethodName = "/verisafe.v1.VerisafeService/ListInstitutionMembers"
+)
+
+// VerisafeServiceClient is the client API for VerisafeService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// VerisafeService serves the core operations internal services call on every
+// request over gRPC. Clients authenticate with a TLS client certificate.
+type VerisafeServiceClient interface {
+	// ValidateToken validates an access token issued by Verisafe and returns
+	// the account it was issued to along with its roles and permissions.
+	// Invalid or expired tokens and deactivated accounts are answered with
+	// UNAUTHENTICATED.
+	ValidateToken(ctx context.Context, in *ValidateTokenRequest, opts ...grpc.CallOption) (*ValidateTokenResponse, error)
+	// GetAccount returns an account. Unknown accounts are answered with
+	// NOT_FOUND.
+	GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*GetAccountResponse, error)
+	// CheckPermission decides whether a subject may perform an action, like
+	// POST /api/v1/authz/check.
+	CheckPermission(ctx context.Context, in *CheckPermissionRequest, opts ...grpc.CallOption) (*CheckPermissionResponse, error)
+	// ListInstitutionMembers returns the members of an institution ranked by
+	// membership role.
+	ListInstitutionMembers(ctx context.Context, in *ListInstitutionMembersRequest, opts ...grpc.CallOption) (*ListInstitutionMembersResponse, error)
+}
+
+type verisafeServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewVerisafeServiceClient(cc grpc.ClientConnInterface) VerisafeServiceClient {
+	return &verisafeServiceClient{cc}
+}
+
+func (c *verisafeServiceClient) ValidateToken(ctx context.Context, in *ValidateTokenRequest, opts ...grpc.CallOption) (*ValidateTokenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateTokenResponse)
+	err := c.cc.Invoke(ctx, VerisafeService_ValidateToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verisafeServiceClient) GetAccount(ctx context.Context, in *GetAccountRequest, opts ...grpc.CallOption) (*GetAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAccountResponse)
+	err := c.cc.Invoke(ctx, VerisafeService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verisafeServiceClient) CheckPermission(ctx context.Context, in *CheckPermissionRequest, opts ...grpc.CallOption) (*CheckPermissionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckPermissionResponse)
+	err := c.cc.Invoke(ctx, VerisafeService_CheckPermission_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verisafeServiceClient) ListInstitutionMembers(ctx context.Context, in *ListInstitutionMembersRequest, opts ...grpc.CallOption) (*ListInstitutionMembersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListInstitutionMembersResponse)
+	err := c.cc.Invoke(ctx, VerisafeService_ListInstitutionMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// VerisafeServiceServer is the server API for VerisafeService service.
+// All implementations must embed UnimplementedVerisafeServiceServer
+// for forward compatibility.
+//
+// VerisafeService serves the core operations internal services call on every
+// request over gRPC. Clients authenticate with a TLS client certificate.
+type VerisafeServiceServer interface {
+	// ValidateToken validates an access token issued by Verisafe and returns
+	// the account it was issued to along with its roles and permissions.
+	// Invalid or expired tokens and deactivated accounts are answered with
+	// UNAUTHENTICATED.
+	ValidateToken(context.Context, *ValidateTokenRequest) (*ValidateTokenResponse, error)
+	// GetAccount returns an account. Unknown accounts are answered with
+	// NOT_FOUND.
+	GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error)
+	// CheckPermission decides whether a subject may perform an action, like
+	// POST /api/v1/authz/check.
+	CheckPermission(context.Context, *CheckPermissionRequest) (*CheckPermissionResponse, error)
+	// ListInstitutionMembers returns the members of an institution ranked by
+	// membership role.
+	ListInstitutionMembers(context.Context, *ListInstitutionMembersRequest) (*ListInstitutionMembersResponse, error)
+	mustEmbedUnimplementedVerisafeServiceServer()
+}
+
+// UnimplementedVerisafeServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedVerisafeServiceServer struct{}
+
+func (UnimplementedVerisafeServiceServer) ValidateToken(context.Context, *ValidateTokenRequest) (*ValidateTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateToken not implemented")
+}
+func (UnimplementedVerisafeServiceServer) GetAccount(context.Context, *GetAccountRequest) (*GetAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedVerisafeServiceServer) CheckPermission(context.Context, *CheckPermissionRequest) (*CheckPermissionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckPermission not implemented")
+}
+func (UnimplementedVerisafeServiceServer) ListInstitutionMembers(context.Context, *ListInstitutionMembersRequest) (*ListInstitutionMembersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListInstitutionMembers not implemented")
+}
+func (UnimplementedVerisafeServiceServer) mustEmbedUnimplementedVerisafeServiceServer() {}
+func (UnimplementedVerisafeServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeVerisafeServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to VerisafeServiceServer will
+// result in compilation errors.
+type UnsafeVerisafeServiceServer interface {
+	mustEmbedUnimplementedVerisafeServiceServer()
+}
+
+func RegisterVerisafeServiceServer(s grpc.ServiceRegistrar, srv VerisafeServiceServer) {
+	// If the following call pancis, it indicates UnimplementedVerisafeServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&VerisafeService_ServiceDesc, srv)
+}
+
+func _VerisafeService_ValidateToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerisafeServiceServer).ValidateToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerisafeService_ValidateToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerisafeServiceServer).ValidateToken(ctx, req.(*ValidateTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerisafeService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerisafeServiceServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerisafeService_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerisafeServiceServer).GetAccount(ctx, req.(*GetAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerisafeService_CheckPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckPermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerisafeServiceServer).CheckPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerisafeService_CheckPermission_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerisafeServiceServer).CheckPermission(ctx, req.(*CheckPermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerisafeService_ListInstitutionMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListInstitutionMembersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerisafeServiceServer).ListInstitutionMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerisafeService_ListInstitutionMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerisafeServiceServer).ListInstitutionMembers(ctx, req.(*ListInstitutionMembersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// VerisafeService_ServiceDesc is the grpc.ServiceDesc for VerisafeService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var VerisafeService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "verisafe.v1.VerisafeService",
+	HandlerType: (*VerisafeServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ValidateToken",
+			Handler:    _VerisafeService_ValidateToken_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _VerisafeService_GetAccount_Handler,
+		},
+		{
+			MethodName: "CheckPermission",
+			Handler:    _VerisafeService_CheckPermission_Handler,
+		},
+		{
+			MethodName: "ListInstitutionMembers",
+			Handler:    _VerisafeService_ListInstitutionMembers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "verisafe/v1/verisafe.proto",
+}